@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/telemetry"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+func main() {
+	serverAddr := flag.String("server", "ws://localhost:8080/ws", "WebSocket server address")
+	difficulty := flag.String("difficulty", "medium", "Bot difficulty: easy, medium or hard")
+	bots := flag.Int("bots", 1, "Number of bots to run")
+	name := flag.String("name", "CPU", "Display name prefix for the bots")
+	mode := flag.String("mode", string(game.ModeMarathon), "Game mode: marathon, sprint or ultra")
+	restart := flag.Bool("restart", true, "Start a new game after each game over")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/gRPC collector to export traces of the messages sent and received to, e.g. http://localhost:4317; empty disables tracing (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	traceRatio := flag.Float64("trace-sample-ratio", 1, "Fraction of messages traced when exporting traces, from 0 to 1")
+	flag.Parse()
+
+	level, err := ai.ParseDifficulty(*difficulty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !game.Mode(*mode).IsValid() {
+		log.Fatalf("unknown game mode: %q", *mode)
+	}
+
+	if *otlpEndpoint != "" {
+		stopTracing, err := telemetry.Setup(context.Background(), telemetry.Config{
+			Endpoint:    *otlpEndpoint,
+			ServiceName: "tetris-bot",
+			SampleRatio: *traceRatio,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		defer stopTracing(context.Background())
+	}
+
+	stop := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Println("Stopping bots...")
+		close(stop)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= *bots; i++ {
+		botName := *name
+		if *bots > 1 {
+			botName = fmt.Sprintf("%s-%d", *name, i)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client := wsclient.New(*serverAddr)
+			client.SetClientVersion("bot")
+			defer client.Close()
+
+			opts := ai.RemoteOptions{Name: botName, Mode: game.Mode(*mode), AutoRestart: *restart}
+			log.Printf("[%s] Playing on %s (%s)", botName, *serverAddr, level)
+			if err := ai.New(level).PlayRemote(client, opts, stop); err != nil {
+				log.Printf("[%s] Stopped: %v", botName, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+var (
+	serverAddr = flag.String("server", "ws://localhost:8080/ws", "WebSocket server address")
+	numClients = flag.Int("clients", 20, "Number of concurrent clients")
+	duration   = flag.Duration("duration", 30*time.Second, "How long to run the test")
+	chaos      = flag.Bool("chaos", false, "Randomly kill connections, delay reads, send malformed messages and reconnect")
+	maxHeapMB  = flag.Int("max-heap-mb", 256, "Fail if the server heap grows beyond this many MB")
+	maxGrowth  = flag.Int("max-goroutine-growth", 20, "Fail if server goroutines do not return within this many of the baseline")
+)
+
+// commands are the control messages sent by well-behaved clients
+var commands = []protocol.MessageType{
+	protocol.MessageTypeMoveLeft,
+	protocol.MessageTypeMoveRight,
+	protocol.MessageTypeMoveDown,
+	protocol.MessageTypeRotate,
+	protocol.MessageTypeRotate180,
+	protocol.MessageTypeHardDrop,
+}
+
+// malformedMessages are payloads a hostile or buggy client might send
+var malformedMessages = [][]byte{
+	[]byte(`{`),
+	[]byte(`not json at all`),
+	[]byte(`{"type":""}`),
+	[]byte(`{"type":"launch_missiles"}`),
+	[]byte(`{"type":123}`),
+	[]byte(`[]`),
+	[]byte(`null`),
+}
+
+// healthReport is the subset of the server's /health response the tool checks
+type healthReport struct {
+	Status     string `json:"status"`
+	Clients    int    `json:"clients"`
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc"`
+}
+
+// stats counts what the clients did during the run
+type stats struct {
+	connects  int64
+	failures  int64
+	sent      int64
+	received  int64
+	kills     int64
+	malformed int64
+}
+
+func main() {
+	flag.Parse()
+
+	healthURL, err := healthURLFromWS(*serverAddr)
+	if err != nil {
+		log.Fatalf("Invalid server address: %v", err)
+	}
+
+	baseline, err := fetchHealth(healthURL)
+	if err != nil {
+		log.Fatalf("Server is not healthy before the test: %v", err)
+	}
+	log.Printf("Baseline: %d goroutines, %.1f MB heap", baseline.Goroutines, float64(baseline.HeapAlloc)/1e6)
+
+	mode := "load"
+	if *chaos {
+		mode = "chaos"
+	}
+	log.Printf("Running %s test with %d clients for %v against %s", mode, *numClients, *duration, *serverAddr)
+
+	var st stats
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < *numClients; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			runClient(id, stop, &st)
+		}(i)
+	}
+
+	// Watch the server while the clients run
+	var healthFailures int
+	var peakHeap uint64
+	ticker := time.NewTicker(1 * time.Second)
+	deadline := time.After(*duration)
+
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			report, err := fetchHealth(healthURL)
+			if err != nil {
+				healthFailures++
+				log.Printf("Health check failed: %v", err)
+				continue
+			}
+			if report.HeapAlloc > peakHeap {
+				peakHeap = report.HeapAlloc
+			}
+		case <-deadline:
+			break loop
+		}
+	}
+	ticker.Stop()
+	close(stop)
+	wg.Wait()
+
+	// Give the server time to clean up closed connections
+	time.Sleep(3 * time.Second)
+	final, err := fetchHealth(healthURL)
+	if err != nil {
+		log.Fatalf("FAIL: server unhealthy after the test: %v", err)
+	}
+
+	log.Printf("Connects: %d, failures: %d, sent: %d, received: %d, kills: %d, malformed: %d",
+		atomic.LoadInt64(&st.connects), atomic.LoadInt64(&st.failures), atomic.LoadInt64(&st.sent),
+		atomic.LoadInt64(&st.received), atomic.LoadInt64(&st.kills), atomic.LoadInt64(&st.malformed))
+	log.Printf("Final: %d clients, %d goroutines, peak heap %.1f MB",
+		final.Clients, final.Goroutines, float64(peakHeap)/1e6)
+
+	var problems []string
+	if healthFailures > 0 {
+		problems = append(problems, fmt.Sprintf("%d health checks failed", healthFailures))
+	}
+	if peakHeap > uint64(*maxHeapMB)*1e6 {
+		problems = append(problems, fmt.Sprintf("peak heap %.1f MB exceeds %d MB", float64(peakHeap)/1e6, *maxHeapMB))
+	}
+	if final.Goroutines > baseline.Goroutines+*maxGrowth {
+		problems = append(problems, fmt.Sprintf("goroutines grew from %d to %d", baseline.Goroutines, final.Goroutines))
+	}
+	if final.Clients > baseline.Clients {
+		problems = append(problems, fmt.Sprintf("%d clients still registered", final.Clients-baseline.Clients))
+	}
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			log.Printf("FAIL: %s", p)
+		}
+		os.Exit(1)
+	}
+	log.Println("PASS")
+}
+
+// runClient connects repeatedly until stop is closed
+func runClient(id int, stop <-chan struct{}, st *stats) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano() + int64(id)))
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		conn, _, err := websocket.DefaultDialer.Dial(*serverAddr, nil)
+		if err != nil {
+			atomic.AddInt64(&st.failures, 1)
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+		atomic.AddInt64(&st.connects, 1)
+
+		runSession(conn, rnd, stop, st)
+		conn.Close()
+
+		// Well-behaved clients back off before reconnecting, chaos clients do not
+		if !*chaos {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// runSession drives one connection until it ends or stop is closed
+func runSession(conn *websocket.Conn, rnd *rand.Rand, stop <-chan struct{}, st *stats) {
+	// A rand.Rand is not safe for concurrent use, so the reader gets its own
+	readRnd := rand.New(rand.NewSource(rnd.Int63()))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if *chaos && readRnd.Intn(20) == 0 {
+				// Slow reader: let the server's send buffer fill up
+				time.Sleep(time.Duration(readRnd.Intn(2000)) * time.Millisecond)
+			}
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			atomic.AddInt64(&st.received, 1)
+		}
+	}()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if *chaos {
+			switch rnd.Intn(100) {
+			case 0, 1:
+				// Kill the TCP connection without a close handshake
+				atomic.AddInt64(&st.kills, 1)
+				conn.UnderlyingConn().Close()
+				return
+			case 2, 3, 4, 5, 6:
+				atomic.AddInt64(&st.malformed, 1)
+				msg := malformedMessages[rnd.Intn(len(malformedMessages))]
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+				continue
+			case 7:
+				// Binary frame the server does not expect
+				atomic.AddInt64(&st.malformed, 1)
+				if err := conn.WriteMessage(websocket.BinaryMessage, []byte{0xff, 0x00, 0xfe}); err != nil {
+					return
+				}
+				continue
+			}
+		}
+
+		cmd := protocol.ControlMessage{Type: commands[rnd.Intn(len(commands))]}
+		data, _ := json.Marshal(cmd)
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return
+		}
+		atomic.AddInt64(&st.sent, 1)
+	}
+}
+
+// healthURLFromWS converts a WebSocket address into the server's /health URL
+func healthURLFromWS(wsURL string) (string, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	u.Path = "/health"
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// fetchHealth queries the server's health endpoint
+func fetchHealth(healthURL string) (*healthReport, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(healthURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var report healthReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
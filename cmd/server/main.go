@@ -7,19 +7,148 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/puzzle"
 	"github.com/ican2002/tetris/pkg/server"
+	"github.com/ican2002/tetris/pkg/telemetry"
 )
 
 func main() {
-	// Parse command line flags
-	addr := flag.String("addr", ":8080", "WebSocket server address")
+	// Parse command line flags; container platforms set the port in $PORT
+	defaultListen := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		defaultListen = ":" + port
+	}
+	listen := flag.String("listen", defaultListen, "Address to serve the game, API and pages on (default: :$PORT if set)")
+	addr := flag.String("addr", "", "Deprecated: use -listen")
+	metricsAddr := flag.String("metrics-addr", "", "Separate address to serve /metrics, /healthz and /readyz on, e.g. :9100; they are served on -listen as well")
+	dataDir := flag.String("data-dir", "", "Directory for the server's files, e.g. /data: saved games, bans, accounts and the audit log default to games.json, bans.json, tetris.db and events.jsonl in it")
+	maxClients := flag.Int("max-clients", 0, "Maximum concurrent players; 0 means unlimited")
+	maxHostedGames := flag.Int("max-hosted-games", 1000, "Maximum games hosted at once for the gRPC and REST APIs; 0 means unlimited")
+	idlePause := flag.Duration("idle-pause", 2*time.Minute, "Pause games with no input for this long; 0 disables")
+	idleTimeout := flag.Duration("idle-timeout", 10*time.Minute, "Disconnect clients with no input for this long; 0 disables")
+	writeTimeout := flag.Duration("write-timeout", 10*time.Second, "Deadline for each write to a client")
+	slowClient := flag.Duration("slow-client-timeout", 5*time.Second, "Disconnect clients whose send queue stays full this long; 0 disables")
+	grpcAddr := flag.String("grpc-addr", "", "gRPC control API address, e.g. :9090; empty disables")
+	accountsDB := flag.String("accounts-db", "", "Database file for player accounts and lifetime stats, e.g. tetris.db; empty disables registration")
+	spawnDelay := flag.Duration("spawn-delay", 0, "Entry delay (ARE) before the next piece spawns after a lock, during which rotate, hold and the last move are buffered")
+	lineClearDelay := flag.Duration("line-clear-delay", 0, "Time full rows stay on the board, flagged in the state, before they are removed")
+	shutdownGrace := flag.Duration("shutdown-grace", 5*time.Second, "Time players are warned before the server shuts down")
+	saveGames := flag.String("save-games", "", "File to save games in progress to on shutdown and restore them from on start, e.g. games.json; empty discards them")
+	puzzles := flag.String("puzzles", "", "JSON file of puzzle levels offered in puzzle mode; empty offers the built-in levels")
+	allowedOrigins := flag.String("allowed-origins", "", "Comma-separated origins browsers may connect from besides this server's own pages, e.g. https://tetris.example.com")
+	allowAnyOrigin := flag.Bool("allow-any-origin", false, "Accept WebSocket connections from any origin (development only)")
+	maxConnsPerIP := flag.Int("max-conns-per-ip", 0, "Maximum concurrent game connections from one IP address; 0 means unlimited")
+	adminToken := flag.String("admin-token", os.Getenv("TETRIS_ADMIN_TOKEN"), "Bearer token of the admin API, e.g. /api/admin/bans; empty disables it (default: $TETRIS_ADMIN_TOKEN)")
+	banList := flag.String("ban-list", "", "File to keep banned IP addresses in across restarts, e.g. bans.json; empty keeps them in memory")
+	auditLog := flag.String("audit-log", "", "File to append every session's events to as JSON lines, e.g. events.jsonl; empty keeps recent ones in memory only")
+	otlpEndpoint := flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/gRPC collector to export traces of message handling to, e.g. http://localhost:4317; empty disables tracing (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	traceRatio := flag.Float64("trace-sample-ratio", 1, "Fraction of messages traced when exporting traces, from 0 to 1")
 	flag.Parse()
+	if *addr != "" {
+		*listen = *addr
+	}
+
+	if *dataDir != "" {
+		if err := os.MkdirAll(*dataDir, 0o755); err != nil {
+			log.Fatalf("Failed to create data directory: %v", err)
+		}
+		for _, file := range []struct {
+			path *string
+			name string
+		}{
+			{saveGames, "games.json"},
+			{banList, "bans.json"},
+			{accountsDB, "tetris.db"},
+			{auditLog, "events.jsonl"},
+		} {
+			if *file.path == "" {
+				*file.path = filepath.Join(*dataDir, file.name)
+			}
+		}
+	}
 
 	// Create server
-	srv := server.New(*addr)
+	srv := server.New(*listen)
+	srv.MaxClients = *maxClients
+	srv.MaxHostedGames = *maxHostedGames
+	srv.IdlePause = *idlePause
+	srv.IdleTimeout = *idleTimeout
+	srv.WriteTimeout = *writeTimeout
+	srv.SlowClientTimeout = *slowClient
+	srv.SpawnDelay = *spawnDelay
+	srv.LineClearDelay = *lineClearDelay
+	srv.ShutdownGrace = *shutdownGrace
+	srv.SnapshotPath = *saveGames
+	srv.AllowAnyOrigin = *allowAnyOrigin
+	srv.MaxConnsPerIP = *maxConnsPerIP
+	srv.AdminToken = *adminToken
+	for _, origin := range strings.Split(*allowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			srv.AllowedOrigins = append(srv.AllowedOrigins, origin)
+		}
+	}
+
+	if *otlpEndpoint != "" {
+		stopTracing, err := telemetry.Setup(context.Background(), telemetry.Config{
+			Endpoint:    *otlpEndpoint,
+			ServiceName: "tetris-server",
+			SampleRatio: *traceRatio,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		// Flush the spans of the last messages once the server stopped
+		defer stopTracing(context.Background())
+		log.Printf("Exporting traces to %s", *otlpEndpoint)
+	}
+
+	if *puzzles != "" {
+		levels, err := puzzle.Load(*puzzles)
+		if err != nil {
+			log.Fatalf("Failed to load puzzles: %v", err)
+		}
+		srv.Puzzles = levels
+		log.Printf("Loaded %d puzzles", len(levels))
+	}
+
+	if *banList != "" {
+		n, err := srv.LoadBans(*banList)
+		if err != nil {
+			log.Fatalf("Failed to load ban list: %v", err)
+		}
+		log.Printf("Loaded %d bans", n)
+	}
+
+	if *auditLog != "" {
+		if err := srv.OpenAuditLog(*auditLog); err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+	}
+
+	if *saveGames != "" {
+		n, err := srv.RestoreSessions(*saveGames)
+		if err != nil {
+			log.Fatalf("Failed to restore saved games: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Restored %d saved games", n)
+		}
+	}
+
+	if *accountsDB != "" {
+		store, err := accounts.Open(*accountsDB)
+		if err != nil {
+			log.Fatalf("Failed to open accounts database: %v", err)
+		}
+		defer store.Close()
+		srv.Accounts = store
+	}
 
 	// Handle shutdown signals
 	ctx, cancel := context.WithCancel(context.Background())
@@ -35,6 +164,20 @@ func main() {
 			errChan <- err
 		}
 	}()
+	if *grpcAddr != "" {
+		go func() {
+			if err := srv.StartGRPC(*grpcAddr); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+	if *metricsAddr != "" {
+		go func() {
+			if err := srv.StartMetrics(*metricsAddr); err != nil && err != http.ErrServerClosed {
+				errChan <- err
+			}
+		}()
+	}
 
 	// Wait for shutdown signal or error
 	select {
@@ -46,7 +189,7 @@ func main() {
 
 	// Graceful shutdown
 	log.Println("Shutting down server...")
-	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, *shutdownGrace+10*time.Second)
 	defer shutdownCancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/render"
+)
+
+func main() {
+	in := flag.String("in", "", "Recording written by tetris -record")
+	out := flag.String("out", "", "Output file (.gif or .cast)")
+	format := flag.String("format", "", "Output format: gif or cast (default: from the output file extension)")
+	cellSize := flag.Int("cell", 16, "Pixels per board cell in GIF output")
+	flag.Parse()
+
+	if *in == "" || *out == "" {
+		fmt.Fprintln(os.Stderr, "usage: tetris-export -in game.jsonl -out game.gif")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*out), ".")
+	}
+
+	src, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("Failed to open recording: %v", err)
+	}
+	frames, err := render.ReadRecording(src)
+	src.Close()
+	if err != nil {
+		log.Fatalf("Failed to read recording: %v", err)
+	}
+
+	dst, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create output: %v", err)
+	}
+
+	switch *format {
+	case "gif":
+		err = render.WriteGIF(dst, frames, render.GIFOptions{CellSize: *cellSize})
+	case "cast":
+		err = render.WriteCast(dst, frames, render.CastOptions{Title: "Tetris - " + filepath.Base(*in)})
+	default:
+		err = fmt.Errorf("unknown format %q (want gif or cast)", *format)
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*out)
+		log.Fatalf("Failed to export: %v", err)
+	}
+
+	log.Printf("Exported %d frames to %s", len(frames), *out)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// Account is a player account registered with --register
+type Account struct {
+	Server string `json:"server"` // Server the account was registered on
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+}
+
+// defaultAccountPath returns $XDG_CONFIG_HOME/tetris/account.json (or the OS equivalent)
+func defaultAccountPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetris", "account.json"), nil
+}
+
+// LoadAccount loads the account file at path
+// Returns nil without an error if no account was registered
+func LoadAccount(path string) (*Account, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var acct Account
+	if err := json.Unmarshal(data, &acct); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &acct, nil
+}
+
+// Save writes the account to path, readable only by the user since it holds the token
+func (a *Account) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// registerAccount registers name on the server and saves the account to path
+func registerAccount(server, name, path string) (*Account, error) {
+	reg, err := wsclient.RegisterPlayer(server, name)
+	if err != nil {
+		return nil, err
+	}
+
+	acct := &Account{Server: server, ID: reg.Profile.ID, Name: reg.Profile.Name, Token: reg.Token}
+	if err := acct.Save(path); err != nil {
+		return nil, err
+	}
+	return acct, nil
+}
+
+// showProfile fetches and shows the player's lifetime statistics until a key is pressed
+func showProfile(ui *tui.TUI, server string, acct *Account) {
+	style := tcell.StyleDefault
+
+	var profile *protocol.PlayerProfile
+	message := "No player account - restart with --register to create one"
+	if acct != nil {
+		message = "Loading..."
+		ui.Clear()
+		ui.DrawProfileScreen(nil, message, style)
+		ui.Sync()
+
+		var err error
+		if profile, err = wsclient.FetchProfile(server, acct.ID); err != nil {
+			message = fmt.Sprintf("Profile unavailable: %v", err)
+		}
+	}
+
+	for {
+		ui.Clear()
+		ui.DrawProfileScreen(profile, message, style)
+		ui.Sync()
+
+		if _, ok := ui.PollEventWithTimeout(time.Second).(*tcell.EventKey); ok {
+			return
+		}
+	}
+}
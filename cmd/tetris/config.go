@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+// ClientConfig holds the settings changed from the in-game settings menu
+type ClientConfig struct {
+	Theme string `json:"theme,omitempty"`
+	Ghost bool   `json:"ghost"`
+	DASMs int    `json:"das_ms"` // Delay before a held move key starts repeating
+	ARRMs int    `json:"arr_ms"` // Delay between repeated moves while the key is held
+	Bell  bool   `json:"bell"`   // Ring the terminal bell when the stack gets close to the top
+	Fills bool   `json:"fills"`  // Fill each piece type with its own pattern, for color vision deficiency
+}
+
+// DefaultClientConfig returns the settings used when there is no config file
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{Theme: "classic", Ghost: true}
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/tetris/config.json (or the OS equivalent)
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetris", "config.json"), nil
+}
+
+// LoadClientConfig loads the config file at path
+// A missing file is not an error and yields the default settings
+func LoadClientConfig(path string) (ClientConfig, error) {
+	cfg := DefaultClientConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultClientConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to path
+func (cfg ClientConfig) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Apply puts the settings into effect
+func (cfg ClientConfig) Apply(ui *tui.TUI, repeat *RepeatFilter) {
+	if theme, err := tui.LookupTheme(cfg.Theme); err == nil {
+		ui.SetTheme(theme)
+	}
+	ui.SetGhost(cfg.Ghost)
+	ui.SetPatterns(cfg.Fills)
+	repeat.DAS = time.Duration(cfg.DASMs) * time.Millisecond
+	repeat.ARR = time.Duration(cfg.ARRMs) * time.Millisecond
+}
+
+// settingItems are the entries of the settings menu; change steps a value up
+// (delta 1) or down (delta -1)
+var settingItems = []struct {
+	name   string
+	value  func(cfg *ClientConfig) string
+	change func(cfg *ClientConfig, delta int)
+}{
+	{
+		name:  "Theme",
+		value: func(cfg *ClientConfig) string { return cfg.Theme },
+		change: func(cfg *ClientConfig, delta int) {
+			names := tui.ThemeNames()
+			i := 0
+			for j, name := range names {
+				if name == cfg.Theme {
+					i = j
+				}
+			}
+			cfg.Theme = names[(i+delta+len(names))%len(names)]
+		},
+	},
+	{
+		name: "Ghost piece",
+		value: func(cfg *ClientConfig) string {
+			if cfg.Ghost {
+				return "On"
+			}
+			return "Off"
+		},
+		change: func(cfg *ClientConfig, delta int) { cfg.Ghost = !cfg.Ghost },
+	},
+	{
+		name:   "DAS",
+		value:  func(cfg *ClientConfig) string { return fmt.Sprintf("%d ms", cfg.DASMs) },
+		change: func(cfg *ClientConfig, delta int) { cfg.DASMs = clampSetting(cfg.DASMs+delta*20, 0, 500) },
+	},
+	{
+		name:   "ARR",
+		value:  func(cfg *ClientConfig) string { return fmt.Sprintf("%d ms", cfg.ARRMs) },
+		change: func(cfg *ClientConfig, delta int) { cfg.ARRMs = clampSetting(cfg.ARRMs+delta*10, 0, 200) },
+	},
+	{
+		name: "Piece patterns",
+		value: func(cfg *ClientConfig) string {
+			if cfg.Fills {
+				return "On"
+			}
+			return "Off"
+		},
+		change: func(cfg *ClientConfig, delta int) { cfg.Fills = !cfg.Fills },
+	},
+	{
+		name: "Danger bell",
+		value: func(cfg *ClientConfig) string {
+			if cfg.Bell {
+				return "On"
+			}
+			return "Off"
+		},
+		change: func(cfg *ClientConfig, delta int) { cfg.Bell = !cfg.Bell },
+	},
+}
+
+// clampSetting limits v to [min, max]
+func clampSetting(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// SettingsMenu is the settings part of the help overlay
+type SettingsMenu struct {
+	Selected int
+}
+
+// Settings returns the menu entries with the values in cfg
+func (m *SettingsMenu) Settings(cfg *ClientConfig) []tui.Setting {
+	settings := make([]tui.Setting, len(settingItems))
+	for i, item := range settingItems {
+		settings[i] = tui.Setting{Name: item.name, Value: item.value(cfg)}
+	}
+	return settings
+}
+
+// HandleKey moves the selection with up/down and changes the selected
+// setting with left/right; returns whether cfg changed
+func (m *SettingsMenu) HandleKey(ev *tcell.EventKey, cfg *ClientConfig) bool {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		m.Selected = (m.Selected + len(settingItems) - 1) % len(settingItems)
+	case tcell.KeyDown:
+		m.Selected = (m.Selected + 1) % len(settingItems)
+	case tcell.KeyLeft:
+		settingItems[m.Selected].change(cfg, -1)
+		return true
+	case tcell.KeyRight, tcell.KeyEnter:
+		settingItems[m.Selected].change(cfg, 1)
+		return true
+	}
+	return false
+}
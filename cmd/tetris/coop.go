@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+// outOfTurn reports whether action would move the falling piece of a co-op
+// game while it is the partner's; the server would refuse it
+func outOfTurn(turn *protocol.YourTurnMessage, action tui.Action) bool {
+	return turn != nil && !turn.YourTurn && actionCommands[action].MovesPiece()
+}
+
+// turnPlayer names the player whose piece is falling in a co-op game
+func turnPlayer(turn protocol.YourTurnMessage) string {
+	switch {
+	case turn.YourTurn:
+		return "Your"
+	case turn.Player == "":
+		return "Partner's"
+	default:
+		return turn.Player + "'s"
+	}
+}
+
+// parseYourTurnMessage parses a your_turn message
+func parseYourTurnMessage(data interface{}) (protocol.YourTurnMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.YourTurnMessage{}, err
+	}
+
+	var turn protocol.YourTurnMessage
+	if err := json.Unmarshal(jsonBytes, &turn); err != nil {
+		return protocol.YourTurnMessage{}, err
+	}
+
+	return turn, nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// cpuResult describes the end of a game against the CPU: the server ends the
+// player's game as completed when the bot tops out first
+func cpuResult(over protocol.GameOverMessage) string {
+	if over.Completed {
+		return fmt.Sprintf("You beat the CPU! Score: %d", over.Score)
+	}
+	return fmt.Sprintf("The CPU wins! Score: %d", over.Score)
+}
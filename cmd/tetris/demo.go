@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+const (
+	// demoIdleTimeout is how long the welcome screen waits for a key before
+	// the demo starts
+	demoIdleTimeout = 15 * time.Second
+	// demoDifficulty sets how fast and how well the demo bot plays
+	demoDifficulty = ai.DifficultyMedium
+)
+
+// Demo is a local game played by the bot for the welcome screen's attract mode
+type Demo struct {
+	game     *game.Game
+	bot      *ai.Bot
+	nextMove time.Time
+}
+
+// NewDemo starts a new demo game
+func NewDemo() *Demo {
+	return &Demo{
+		game:     game.New(),
+		bot:      ai.New(demoDifficulty),
+		nextMove: time.Now().Add(demoDifficulty.MoveDelay()),
+	}
+}
+
+// Update applies gravity and places a piece whenever the bot's move delay has
+// passed; returns false once the demo game is over
+func (d *Demo) Update(now time.Time) bool {
+	d.game.Update()
+	if !now.Before(d.nextMove) {
+		d.bot.Play(d.game)
+		d.nextMove = now.Add(demoDifficulty.MoveDelay())
+	}
+	return !d.game.IsGameOver()
+}
+
+// State returns the demo game's state for drawing
+func (d *Demo) State() *protocol.StateMessage {
+	state := protocol.NewStateMessage(d.game).Data.(protocol.StateMessage)
+	return &state
+}
+
+// drawDemo draws the demo game with a banner inviting the player to start
+func drawDemo(ui *tui.TUI, demo *Demo, style tcell.Style) {
+	state := demo.State()
+	layout := ui.Layout(state)
+	screenW, _ := ui.GetSize()
+
+	if layout.TooSmall {
+		ui.DrawWelcomeScreen(style)
+		return
+	}
+
+	ui.DrawBox(layout.Frame.X, layout.Frame.Y, layout.Frame.W, layout.Frame.H, "DEMO", style)
+	ui.DrawBoard(layout.Board.X, layout.Board.Y, layout.Cell, state, style)
+	ui.DrawInfoPanel(layout.Info, state, style)
+	ui.DrawTextAligned(0, layout.StatusY, screenW, "DEMO - Press any key to play", 0,
+		style.Bold(true).Foreground(ui.Theme().Accent))
+}
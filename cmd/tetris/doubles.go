@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// partnerBoard is the board of a doubles game played with the partner keymap
+const partnerBoard = 1
+
+// sendPartnerCommand sends the command of a partner keymap action to the
+// second board of a doubles game; returns true if the command was sent
+// Its inputs are not predicted, so they carry no seq
+func sendPartnerCommand(client *wsclient.Client, action tui.Action, logBuffer *tui.LogBuffer) bool {
+	cmdType, ok := actionCommands[action]
+	if !ok {
+		return false
+	}
+
+	cmd := protocol.ControlMessage{Type: cmdType, BoardIndex: partnerBoard}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal %s: %v", cmdType, err))
+		return false
+	}
+
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send %s: %v", cmdType, err))
+		return false
+	}
+	logBuffer.Debug(fmt.Sprintf("→ %s (board %d)", cmdType, partnerBoard+1))
+	return true
+}
+
+// boardOver reports whether the board shown by state has ended
+func boardOver(state *protocol.StateMessage) bool {
+	return state != nil && state.State == "gameover"
+}
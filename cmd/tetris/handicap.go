@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// validateHandicaps checks the handicaps of a match's seats, separated by "/"
+// in seat order, before they are sent to the server
+func validateHandicaps(s string) error {
+	for seat, spec := range strings.Split(s, "/") {
+		if _, err := game.ParseHandicap(spec); err != nil {
+			return fmt.Errorf("seat %d: %w", seat+1, err)
+		}
+	}
+	return nil
+}
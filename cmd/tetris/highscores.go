@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// maxRecentGames is the number of recent games kept in the high-score file
+const maxRecentGames = 10
+
+// RecentGame is a finished game recorded on this machine
+type RecentGame struct {
+	Score    int       `json:"score"`
+	Level    int       `json:"level"`
+	Lines    int       `json:"lines"`
+	Mode     string    `json:"mode,omitempty"`
+	PlayedAt time.Time `json:"played_at"`
+}
+
+// HighScores holds the best results and most recent games
+type HighScores struct {
+	BestScore int          `json:"best_score"`
+	BestLevel int          `json:"best_level"`
+	BestLines int          `json:"best_lines"`
+	Recent    []RecentGame `json:"recent"`
+}
+
+// HighScoreStore persists high scores as JSON in the user's config directory
+type HighScoreStore struct {
+	path   string
+	scores HighScores
+	mu     sync.Mutex
+}
+
+// defaultHighScorePath returns $XDG_CONFIG_HOME/tetris/highscores.json (or the OS equivalent)
+func defaultHighScorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetris", "highscores.json"), nil
+}
+
+// LoadHighScores loads the high-score file at path
+// A missing file is not an error and yields an empty store
+func LoadHighScores(path string) (*HighScoreStore, error) {
+	store := &HighScoreStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.scores); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Record adds a finished game and saves the file
+// Returns true if the game set a new best score
+func (s *HighScoreStore) Record(msg protocol.GameOverMessage) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newBest := msg.Score > s.scores.BestScore
+	if newBest {
+		s.scores.BestScore = msg.Score
+	}
+	if msg.Level > s.scores.BestLevel {
+		s.scores.BestLevel = msg.Level
+	}
+	if msg.Lines > s.scores.BestLines {
+		s.scores.BestLines = msg.Lines
+	}
+
+	// Newest first
+	s.scores.Recent = append([]RecentGame{{
+		Score:    msg.Score,
+		Level:    msg.Level,
+		Lines:    msg.Lines,
+		Mode:     msg.Mode,
+		PlayedAt: time.Now(),
+	}}, s.scores.Recent...)
+	if len(s.scores.Recent) > maxRecentGames {
+		s.scores.Recent = s.scores.Recent[:maxRecentGames]
+	}
+
+	return newBest, s.save()
+}
+
+// save writes the scores to disk; must be called with mu held
+func (s *HighScoreStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s.scores, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Get returns a copy of the current high scores
+func (s *HighScoreStore) Get() HighScores {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := s.scores
+	scores.Recent = append([]RecentGame(nil), s.scores.Recent...)
+	return scores
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// PendingInput is a command sent to the server that has not been acknowledged yet
+type PendingInput struct {
+	Seq    uint64
+	Type   protocol.MessageType
+	SentAt time.Time
+}
+
+// InputTracker numbers outgoing commands and matches them against the
+// ack_seq the server echoes in state frames
+type InputTracker struct {
+	next    uint64
+	pending []PendingInput
+	mu      sync.Mutex
+}
+
+// NewInputTracker creates an input tracker
+func NewInputTracker() *InputTracker {
+	return &InputTracker{}
+}
+
+// Next returns the sequence number for the next command
+func (t *InputTracker) Next() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next++
+	return t.next
+}
+
+// Sent records a command as awaiting acknowledgement
+func (t *InputTracker) Sent(seq uint64, cmdType protocol.MessageType) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, PendingInput{Seq: seq, Type: cmdType, SentAt: time.Now()})
+}
+
+// Ack drops every pending command up to and including seq
+func (t *InputTracker) Ack(seq uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := 0
+	for i < len(t.pending) && t.pending[i].Seq <= seq {
+		i++
+	}
+	t.pending = t.pending[i:]
+}
+
+// Pending returns the commands not yet acknowledged, oldest first
+func (t *InputTracker) Pending() []PendingInput {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PendingInput(nil), t.pending...)
+}
+
+// Reset forgets pending commands, e.g. after the connection dropped
+func (t *InputTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = nil
+}
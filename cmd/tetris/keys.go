@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+// defaultKeymapPath returns $XDG_CONFIG_HOME/tetris/keys.json (or the OS equivalent)
+func defaultKeymapPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetris", "keys.json"), nil
+}
+
+// resolveKeymap returns the keymap selected by --keys: a preset name, a keymap
+// file, or when empty the keymap file in the config directory if one exists
+// Returns nil to keep the default keymap
+func resolveKeymap(keys string) (*tui.Keymap, error) {
+	if keys == "" {
+		path, err := defaultKeymapPath()
+		if err != nil {
+			return nil, nil
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return tui.LoadKeymap(path)
+	}
+
+	if _, err := os.Stat(keys); err == nil {
+		return tui.LoadKeymap(keys)
+	}
+	return tui.PresetKeymap(keys)
+}
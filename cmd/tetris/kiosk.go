@@ -0,0 +1,99 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/tui"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+const (
+	// kioskPageDuration is how long each attract screen page is shown
+	kioskPageDuration = 6 * time.Second
+	// kioskPromptTimeout cancels an abandoned passcode prompt
+	kioskPromptTimeout = 15 * time.Second
+)
+
+// KioskConfig configures unattended play at events
+type KioskConfig struct {
+	Enabled     bool
+	Station     string        // Station id reported with every result
+	Passcode    string        // Required to quit
+	IdleTimeout time.Duration // Idle time on the game-over screen before returning to the attract screen
+}
+
+// showAttract cycles the welcome screen and the leaderboard until a player presses a key
+// Quit keys ask for the passcode; returns false if the operator quit
+func showAttract(ui *tui.TUI, logBuffer *tui.LogBuffer, lobby *wsclient.LobbyFetcher, highScores *HighScoreStore, kiosk KioskConfig) bool {
+	style := tcell.StyleDefault
+	started := time.Now()
+
+	logBuffer.Add("Attract mode - waiting for a player")
+
+	for {
+		ui.Clear()
+		page := int(time.Since(started)/kioskPageDuration) % 2
+		if page == 0 || lobby == nil {
+			ui.DrawWelcomeScreen(style)
+			drawPersonalBest(ui, highScores, false, style)
+		} else {
+			info, _ := lobby.Cached()
+			if info != nil {
+				ui.DrawLeaderboard(info.TopScores, style)
+			} else {
+				ui.DrawLeaderboard(nil, style)
+			}
+		}
+		drawLobby(ui, lobby, style)
+		ui.Sync()
+
+		ev, ok := ui.PollEventWithTimeout(500 * time.Millisecond).(*tcell.EventKey)
+		if !ok {
+			continue
+		}
+		if isQuitKey(ui, ev) {
+			if promptPasscode(ui, kiosk.Passcode) {
+				return false
+			}
+			continue
+		}
+
+		logBuffer.Add("Starting game...")
+		return true
+	}
+}
+
+// promptPasscode asks for the kiosk passcode on top of the current screen
+// Returns true if the passcode was entered correctly
+func promptPasscode(ui *tui.TUI, passcode string) bool {
+	style := tcell.StyleDefault
+	var entered []rune
+
+	for {
+		ui.DrawPasscodePrompt("Operator", len(entered), style)
+		ui.Sync()
+
+		ev := ui.PollEventWithTimeout(kioskPromptTimeout)
+		if ev == nil {
+			return false
+		}
+		key, ok := ev.(*tcell.EventKey)
+		if !ok {
+			continue
+		}
+
+		switch key.Key() {
+		case tcell.KeyEscape:
+			return false
+		case tcell.KeyEnter:
+			return passcode != "" && string(entered) == passcode
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(entered) > 0 {
+				entered = entered[:len(entered)-1]
+			}
+		case tcell.KeyRune:
+			entered = append(entered, key.Rune())
+		}
+	}
+}
@@ -8,56 +8,144 @@ import (
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/audio"
+	"github.com/ican2002/tetris/pkg/game"
 	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/render"
 	"github.com/ican2002/tetris/pkg/tui"
 	"github.com/ican2002/tetris/pkg/wsclient"
 )
 
-// LogBuffer manages log messages with thread safety
-type LogBuffer struct {
-	messages []string
-	mu       sync.Mutex
-	maxSize  int
-}
+var (
+	serverAddr = flag.String("server", "ws://localhost:8080/ws", "WebSocket server address")
+	predict    = flag.Bool("predict", true, "Show moves immediately instead of waiting for the server")
+	share      = flag.Bool("share", false, "Print a result card for the last game to stdout on exit")
+	recordPath = flag.String("record", "", "Record the game states to this file for tetris-export")
+	racePath   = flag.String("race", "", "Race the ghost of a game recorded with --record: the info panel shows the points and lines you are ahead or behind")
+	playerName = flag.String("name", os.Getenv("USER"), "Display name shown to other players and on leaderboards")
+	register   = flag.Bool("register", false, "Register a player account under --name on the server; later games count towards its lifetime stats")
+	matchID    = flag.String("match", "", "Join a match: every player of the same match gets the same piece sequence; \"auto\" pairs you with an opponent of similar rating")
+	matchSeed  = flag.Int64("seed", 0, "Seed for a new match (default: chosen by the server)")
+	handicaps  = flag.String("handicaps", "", "Handicaps for a new match, one per seat in join order separated by /: garbage:<rows>, gravity:<scale> and preview:0, e.g. \"/garbage:4,gravity:0.75\" handicaps the second player")
+	doubles    = flag.Bool("doubles", false, "Play split-screen doubles: two players at this keyboard on two boards with one team score; the second player uses W/A/S/D, Space and E")
+	coopID     = flag.String("coop", "", "Play co-op: you and a friend connecting with the same co-op id take turns placing the pieces of one board")
+	cpuLevel   = flag.String("cpu", "", "Play against a bot hosted by the server: easy, medium or hard; line clears send garbage to the other board and the first to top out loses")
+	continueIt = flag.Bool("continue", false, "Continue the game saved with the save key (V) instead of starting a new one")
+	puzzleName = flag.String("puzzle", "", "Puzzle level to play when choosing puzzle mode (default: the server's first)")
+	setupPath  = flag.String("setup", "", "Play practice mode on the board and next pieces set up in this file")
+	keysFlag   = flag.String("keys", "", "Key bindings: a preset (default, vi, wasd) or a keymap JSON file (default: tetris/keys.json in the config dir if present)")
+	themeFlag  = flag.String("theme", "", "Color theme: classic, pastel, high-contrast, or monochrome for terminals without color (default: the theme chosen in the settings menu)")
+	plainFlag  = flag.Bool("plain", false, "Screen reader friendly output: the board as plain text without colors or borders, and game events announced on the status line")
+	soundFlag  = flag.Bool("sound", false, "Play sound effects for line clears, level ups and game over (needs a build with -tags sound)")
+	volume     = flag.Int("volume", 70, "Sound effect volume from 0 to 100")
+
+	kioskMode     = flag.Bool("kiosk", false, "Run unattended for events: attract screen between players, passcode to quit")
+	kioskStation  = flag.String("station", "", "Kiosk station id reported with every result")
+	kioskPasscode = flag.String("kiosk-passcode", "", "Passcode required to quit in kiosk mode")
+	kioskIdle     = flag.Duration("kiosk-idle", 30*time.Second, "Idle time on the game-over screen before kiosk mode returns to the attract screen")
+)
 
-func NewLogBuffer(size int) *LogBuffer {
-	return &LogBuffer{
-		messages: make([]string, 0, size),
-		maxSize:  size,
+func main() {
+	flag.Parse()
+
+	name, err := protocol.NormalizeName(*playerName)
+	if err != nil && *playerName != "" {
+		fmt.Fprintf(os.Stderr, "Invalid --name: %v\n", err)
+		os.Exit(1)
 	}
-}
 
-func (lb *LogBuffer) Add(msg string) {
-	lb.mu.Lock()
-	defer lb.mu.Unlock()
+	keymap, err := resolveKeymap(*keysFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --keys: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Add timestamp
-	timestamp := time.Now().Format("15:04:05")
-	lb.messages = append(lb.messages, fmt.Sprintf("[%s] %s", timestamp, msg))
+	if *themeFlag != "" {
+		if _, err := tui.LookupTheme(*themeFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --theme: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *volume < 0 || *volume > 100 {
+		fmt.Fprintln(os.Stderr, "--volume must be between 0 and 100")
+		os.Exit(1)
+	}
 
-	// Keep only the last maxSize messages
-	if len(lb.messages) > lb.maxSize {
-		lb.messages = lb.messages[1:]
+	var practice *PracticeSetup
+	if *setupPath != "" {
+		if *matchID != "" || *kioskMode || *continueIt {
+			fmt.Fprintln(os.Stderr, "--setup cannot be combined with --match, --kiosk or --continue")
+			os.Exit(1)
+		}
+		if practice, err = LoadPracticeSetup(*setupPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --setup: %v\n", err)
+			os.Exit(1)
+		}
 	}
-}
 
-func (lb *LogBuffer) GetMessages() []string {
-	lb.mu.Lock()
-	defer lb.mu.Unlock()
-	return lb.messages
-}
+	if *handicaps != "" {
+		if *matchID == "" || *matchID == "auto" {
+			fmt.Fprintln(os.Stderr, "--handicaps needs --match with a match id other than auto")
+			os.Exit(1)
+		}
+		if err := validateHandicaps(*handicaps); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --handicaps: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-var (
-	serverAddr = flag.String("server", "ws://localhost:8080/ws", "WebSocket server address")
-)
+	if *doubles && (*matchID != "" || *setupPath != "" || *continueIt) {
+		fmt.Fprintln(os.Stderr, "--doubles cannot be combined with --match, --setup or --continue")
+		os.Exit(1)
+	}
+	if *coopID != "" && (*matchID != "" || *doubles || *setupPath != "" || *continueIt || *kioskMode) {
+		fmt.Fprintln(os.Stderr, "--coop cannot be combined with --match, --doubles, --setup, --continue or --kiosk")
+		os.Exit(1)
+	}
+	if *cpuLevel != "" {
+		if _, err := ai.ParseDifficulty(*cpuLevel); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --cpu: %v\n", err)
+			os.Exit(1)
+		}
+		if *matchID != "" || *doubles || *coopID != "" || *setupPath != "" || *continueIt || *kioskMode {
+			fmt.Fprintln(os.Stderr, "--cpu cannot be combined with --match, --doubles, --coop, --setup, --continue or --kiosk")
+			os.Exit(1)
+		}
+	}
 
-func main() {
-	flag.Parse()
+	kiosk := KioskConfig{
+		Enabled:     *kioskMode,
+		Station:     *kioskStation,
+		Passcode:    *kioskPasscode,
+		IdleTimeout: *kioskIdle,
+	}
+	if kiosk.Enabled && kiosk.Passcode == "" {
+		fmt.Fprintln(os.Stderr, "--kiosk requires --kiosk-passcode")
+		os.Exit(1)
+	}
+
+	accountPath, accountErr := defaultAccountPath()
+	if *register {
+		if accountErr != nil {
+			fmt.Fprintf(os.Stderr, "Cannot save the account: %v\n", accountErr)
+			os.Exit(1)
+		}
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "--register requires --name")
+			os.Exit(1)
+		}
+		acct, err := registerAccount(*serverAddr, name, accountPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Registration failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Registered %s (player id %s)\n", acct.Name, acct.ID)
+	}
 
 	// Ignore SIGINT (Ctrl+C) - let tcell handle it as a key event
 	// This prevents the terminal from sending the signal to the process
@@ -67,8 +155,61 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGTERM)
 
+	// Record received states for export as a GIF or asciinema cast
+	var recorder *render.Recorder
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --record: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		recorder = render.NewRecorder(f)
+	}
+
+	// Index the game to race by game time, so pauses do not put the ghost ahead
+	var pace *render.Timeline
+	if *racePath != "" {
+		f, err := os.Open(*racePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --race: %v\n", err)
+			os.Exit(1)
+		}
+		frames, err := render.ReadRecording(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --race: %v\n", err)
+			os.Exit(1)
+		}
+		pace = render.NewTimeline(frames)
+	}
+
 	// Create log buffer
-	logBuffer := NewLogBuffer(100)
+	logBuffer := tui.NewLogBuffer(100)
+	logView := tui.NewLogView(logBuffer)
+
+	// Play sound effects for the events found by comparing received states
+	var differ *wsclient.StateDiffer
+	if *soundFlag {
+		player, err := audio.New(float64(*volume) / 100)
+		if err != nil {
+			logBuffer.Error(fmt.Sprintf("✗ Sound unavailable: %v", err))
+		} else {
+			defer player.Close()
+			differ = wsclient.NewStateDiffer()
+			go player.Run(differ.Events())
+		}
+	}
+
+	// Print the last result after the TUI has restored the terminal
+	var lastResult *protocol.GameOverMessage
+	if *share {
+		defer func() {
+			if lastResult != nil {
+				fmt.Print(ResultCard(*lastResult, name, *serverAddr))
+			}
+		}()
+	}
 
 	// Create TUI
 	ui, err := tui.New()
@@ -76,84 +217,524 @@ func main() {
 		log.Fatalf("Failed to create TUI: %v", err)
 	}
 	defer ui.Close()
-
-	// Check minimum size
-	if !ui.CheckMinimumSize() {
-		log.Println("Terminal size must be at least 80x30")
-		return
+	if keymap != nil {
+		ui.SetKeymap(keymap)
 	}
 
 	logBuffer.Add("TUI initialized")
 
-	// Show welcome screen
-	showWelcome(ui, logBuffer)
+	// Load the settings changed from the help overlay; --theme and --plain
+	// override the saved theme
+	config := DefaultClientConfig()
+	configPath, err := defaultConfigPath()
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Settings unavailable: %v", err))
+	} else if config, err = LoadClientConfig(configPath); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to load settings: %v", err))
+	}
+	if *themeFlag != "" {
+		config.Theme = *themeFlag
+	}
+	if *plainFlag {
+		config.Theme = "plain"
+	}
+	repeat := &RepeatFilter{}
+	config.Apply(ui, repeat)
+
+	// Log in with the saved player account; kiosks are shared, so their players are guests
+	var account *Account
+	if accountErr != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Player account unavailable: %v", accountErr))
+	} else if account, err = LoadAccount(accountPath); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to load player account: %v", err))
+	}
+	switch {
+	case account == nil:
+	case kiosk.Enabled:
+		account = nil
+	case account.Server != *serverAddr:
+		logBuffer.Add(fmt.Sprintf("Player account is registered on %s - playing as a guest", account.Server))
+		account = nil
+	default:
+		logBuffer.Add(fmt.Sprintf("Playing as %s (player id %s)", account.Name, account.ID))
+		if !flagSet("name") {
+			name = account.Name
+		}
+	}
+
+	// Fetch lobby information for the welcome screen in the background
+	lobby, err := wsclient.NewLobbyFetcher(*serverAddr)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Lobby unavailable: %v", err))
+	} else {
+		go refreshLobby(lobby)
+	}
+
+	// Load personal high scores
+	var highScores *HighScoreStore
+	if path, err := defaultHighScorePath(); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ High scores unavailable: %v", err))
+	} else if highScores, err = LoadHighScores(path); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to load high scores: %v", err))
+	}
+
+	// Games saved with the save key are continued with --continue; kiosks and
+	// matches always start new games
+	savedGamePath, err := defaultSavedGamePath()
+	var savedGame *SavedGame
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Saved games unavailable: %v", err))
+	} else if savedGame, err = LoadSavedGame(savedGamePath); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to load saved game: %v", err))
+	}
+	switch {
+	case savedGame == nil:
+	case kiosk.Enabled || *matchID != "":
+		savedGame = nil
+	case savedGame.Server != *serverAddr:
+		logBuffer.Add(fmt.Sprintf("Saved game is from %s - starting a new game", savedGame.Server))
+		savedGame = nil
+	case !*continueIt:
+		logBuffer.Add(fmt.Sprintf("Saved %s game (score %d) - start with --continue to play it", savedGame.Mode, savedGame.Score))
+		savedGame = nil
+	}
+
+	mode := game.ModeMarathon
+	if kiosk.Enabled {
+		// Kiosks skip the menu and play marathon
+		if !showAttract(ui, logBuffer, lobby, highScores, kiosk) {
+			return
+		}
+	} else {
+		// Show welcome screen
+		showWelcome(ui, logBuffer, lobby, highScores)
+
+		// Choose a game mode, unless continuing a saved game or practicing a setup
+		if savedGame != nil {
+			mode = savedGame.Mode
+		} else if practice != nil {
+			mode = game.ModePractice
+		} else {
+			var ok bool
+			mode, ok = showModeSelect(ui, logBuffer, account)
+			if !ok {
+				return
+			}
+		}
+	}
 
 	// Create WebSocket client
 	client := wsclient.New(*serverAddr)
-	client.SetMaxRetries(5)
-	client.SetRetryDelay(3 * time.Second)
+	client.SetMaxRetries(8)
+	client.SetRetryDelay(1 * time.Second)
+	client.SetMaxRetryDelay(30 * time.Second)
+	client.SetMaxElapsedTime(5 * time.Minute)
+	client.SetClientVersion(tui.Version)
+	client.SetCompactState(true)
+	if kiosk.Station != "" {
+		client.SetStationID(kiosk.Station)
+	}
+	if account != nil {
+		client.SetPlayer(account.ID, account.Token)
+	}
+	// Players in a match can chat and send each other emotes
+	chat := tui.NewChatPane(50)
+	var emote tui.EmoteBubble
+	opponents := tui.NewOpponents()
+	// Line clears float their points up over the board
+	var popups tui.Popups
+	if *matchID != "" {
+		client.SetMatch(*matchID, *matchSeed)
+		client.SetHandicaps(*handicaps)
+		ui.Keymap().SetFeature(tui.FeatureChat, true)
+		ui.Keymap().SetFeature(tui.FeatureEmote, true)
+	} else if *coopID != "" {
+		client.SetCoop(*coopID)
+	} else if *cpuLevel != "" {
+		client.SetCPU(*cpuLevel)
+	} else if !kiosk.Enabled && !*doubles && savedGamePath != "" {
+		// Games played alone can be saved and continued later
+		ui.Keymap().SetFeature(tui.FeatureSave, true)
+	}
+	// The second player of a doubles game plays board 2 with keys of their own
+	var partnerKeys *tui.Keymap
+	partnerRepeat := &RepeatFilter{}
+	var partnerPopups tui.Popups
+	if *doubles {
+		client.SetBoards(protocol.MaxBoards)
+		partnerKeys = tui.PartnerKeymap()
+	}
+	ui.Keymap().SetFeature(tui.FeatureUndo, mode == game.ModePractice)
+
+	// Redraw only the parts of the screen that changed, at most 30 times a second
+	sched := tui.NewScheduler(30)
 
 	// Set up callbacks
 	var currentState *protocol.StateMessage
+	var partnerState *protocol.StateMessage // Board 2 of a doubles game
+	var teamScore int                       // Team score of a doubles game, from the latest frame of either board
+	var coopTurn *protocol.YourTurnMessage  // Whose piece is falling in a co-op game
+	var lastSeq uint64                      // Sequence of the last state frame shown; frame numbers restart per connection
+	inputs := NewInputTracker()
+	var predictor *Predictor
+	if *predict {
+		predictor = NewPredictor()
+	}
 	var statusMsg string
 	var gameOver bool
+	var restartPending bool
+	// Another player's vote to pause the match, until answered
+	var pauseVote *protocol.PauseVoteMessage
+	var helpOpen bool   // Help overlay shown; it captures all keys
+	var helpPaused bool // The game was paused by opening the help overlay
+	var settings SettingsMenu
+	var newPersonalBest bool
+	var reconnectAt time.Time
+	var reconnectAttempt int
+	var resuming bool // Connected to continue a game the server saved on shutdown
 
 	client.SetOnConnected(func() {
+		sched.Invalidate(tui.RegionAll)
+		reconnectAt = time.Time{}
 		statusMsg = "Connected to server"
 		logBuffer.Add("✓ Connected to server")
+		// A saved game is only continued once
+		resuming = client.Session() != ""
+		client.SetSession("")
+		// Every other connection starts a new server game, so register the
+		// name and select the mode each time
+		go func() {
+			if name != "" {
+				sendName(client, name, logBuffer)
+			}
+			switch {
+			case resuming:
+			case savedGame != nil:
+				// A saved game is only continued once
+				sendLoadGame(client, savedGame, logBuffer)
+				if err := os.Remove(savedGamePath); err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to remove saved game: %v", err))
+				}
+				savedGame = nil
+			default:
+				sendModeSelection(client, mode, logBuffer)
+				if practice != nil {
+					sendPracticeSetup(client, practice, logBuffer)
+				}
+			}
+		}()
 	})
-	client.SetOnDisconnected(func() {
-		statusMsg = "Disconnected from server - Press any key to reconnect"
-		logBuffer.Add("✗ Disconnected from server")
+	client.SetOnDisconnected(func(reason protocol.DisconnectReason) {
+		sched.Invalidate(tui.RegionAll)
+		statusMsg = fmt.Sprintf("Disconnected from server: %s - Press any key to reconnect", reason)
+		logBuffer.Error(fmt.Sprintf("✗ Disconnected from server: %s", reason))
 		// Clear game state to return to welcome screen
 		currentState = nil
+		partnerState = nil
+		teamScore = 0
+		lastSeq = 0
+		inputs.Reset()
+		if differ != nil {
+			differ.Reset()
+		}
 		gameOver = false
 	})
+	client.SetOnReconnecting(func(attempt int, nextDelay time.Duration) {
+		reconnectAttempt = attempt
+		reconnectAt = time.Now().Add(nextDelay)
+		logBuffer.Add(fmt.Sprintf("Reconnecting in %v (attempt %d)", nextDelay.Round(time.Second), attempt))
+	})
 	client.SetOnError(func(err error) {
 		statusMsg = fmt.Sprintf("Error: %v", err)
-		logBuffer.Add(fmt.Sprintf("✗ Error: %v", err))
+		logBuffer.Error(fmt.Sprintf("✗ Error: %v", err))
 	})
-	client.SetOnStateChange(func(data []byte) {
-		var msg protocol.Message
-		if err := json.Unmarshal(data, &msg); err != nil {
-			logBuffer.Add(fmt.Sprintf("✗ Failed to parse message: %v", err))
-			return
-		}
 
-		switch msg.Type {
-		case protocol.MessageTypeState:
-			// Parse StateMessage from map
-			state, err := parseStateMessage(msg.Data)
-			if err != nil {
-				logBuffer.Add(fmt.Sprintf("✗ Failed to parse state: %v", err))
-				return
-			}
-			currentState = state
+	// Consume decoded server messages; pings are answered by the client itself
+	messages := client.Subscribe()
+	go func() {
+		for msg := range messages.C() {
+			switch msg.Type {
+			case protocol.MessageTypeState, protocol.MessageTypeCompactState:
+				// Parse StateMessage from map
+				state, err := parseStateMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse state: %v", err))
+					continue
+				}
+				// Drop stale or out-of-order frames
+				if state.Seq != 0 && state.Seq <= lastSeq {
+					continue
+				}
+				lastSeq = state.Seq
+				teamScore = state.TeamScore
+				if state.BoardIndex == partnerBoard {
+					// Board 2 of a doubles game is only shown; sounds, recordings
+					// and predictions follow board 1
+					partnerState = state
+					sched.Invalidate(tui.RegionBoard | tui.RegionInfo)
+					continue
+				}
+				if differ != nil {
+					differ.Update(state)
+				}
+				if recorder != nil {
+					if err := recorder.Record(state); err != nil {
+						logBuffer.Error(fmt.Sprintf("✗ Failed to record state: %v", err))
+						recorder = nil
+					}
+				}
+				if resuming {
+					// The server starts a new game in its default mode when
+					// the saved one is gone
+					resuming = false
+					if state.Mode != string(mode) {
+						go sendModeSelection(client, mode, logBuffer)
+					} else {
+						statusMsg = "Saved game restored - press P to continue"
+						logBuffer.Add("✓ " + statusMsg)
+					}
+				}
+				inputs.Ack(state.AckSeq)
+				if predictor != nil {
+					state = predictor.Reconcile(state, inputs.Pending())
+				}
+				// The line clear animation would play on both boards of a
+				// doubles game, which show flashing rows instead
+				if !*doubles {
+					ui.Animator().Observe(currentState, state, time.Now())
+				}
+				if *plainFlag {
+					if text := announcement(currentState, state); text != "" {
+						statusMsg = text
+					}
+				}
+				currentState = state
+				sched.Invalidate(tui.RegionBoard | tui.RegionInfo)
+				continue
+
+			case protocol.MessageTypeError:
+				errMsg, err := parseErrorMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse error: %v", err))
+					continue
+				}
+				statusMsg = errMsg.Error
+				logBuffer.Error(fmt.Sprintf("✗ Server error (%s): %s", errMsg.Code, errMsg.Error))
+				// The game ended without this client seeing game_over, such as
+				// after a resume; show the game over screen so it can restart
+				// A doubles board that ended says so while the team plays on
+				if errMsg.Code == protocol.CodeGameOver && currentState != nil && !*doubles {
+					gameOver = true
+					sched.Invalidate(tui.RegionAll)
+				}
 
-		case protocol.MessageTypeError:
-			errMsg, err := parseErrorMessage(msg.Data)
-			if err != nil {
-				logBuffer.Add(fmt.Sprintf("✗ Failed to parse error: %v", err))
-				return
-			}
-			statusMsg = errMsg.Error
-			logBuffer.Add(fmt.Sprintf("✗ Server error: %s", errMsg.Error))
-
-		case protocol.MessageTypeGameOver:
-			gameOver = true
-			overMsg, err := parseGameOverMessage(msg.Data)
-			if err != nil {
-				logBuffer.Add(fmt.Sprintf("✗ Failed to parse game over: %v", err))
-				return
+			case protocol.MessageTypeGameOver:
+				overMsg, err := parseGameOverMessage(msg.Data)
+				if err != nil {
+					gameOver = true
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse game over: %v", err))
+					continue
+				}
+				// The team of a doubles game plays on until both boards are over;
+				// each board's final state arrives before its game_over
+				if *doubles && !(boardOver(currentState) && boardOver(partnerState)) {
+					statusMsg = fmt.Sprintf("Board %d is over - team score %d", overMsg.BoardIndex+1, overMsg.TeamScore)
+					logBuffer.Add("† " + statusMsg)
+					break
+				}
+				gameOver = true
+				statusMsg = fmt.Sprintf("Game Over! Score: %d", overMsg.Score)
+				if *doubles {
+					statusMsg = fmt.Sprintf("Game Over! Team score: %d", overMsg.TeamScore)
+				}
+				if *cpuLevel != "" {
+					statusMsg = cpuResult(overMsg)
+				}
+				if pace != nil {
+					statusMsg += fmt.Sprintf(" (ghost: %d)", pace.Final().Score)
+				}
+				lastResult = &overMsg
+				// Practice games, with their undos, are not personal bests, and
+				// neither are doubles, co-op and versus-CPU games
+				if highScores != nil && game.Mode(overMsg.Mode).Ranked() && !*doubles && *coopID == "" && *cpuLevel == "" {
+					best, err := highScores.Record(overMsg)
+					if err != nil {
+						logBuffer.Error(fmt.Sprintf("✗ Failed to save high scores: %v", err))
+					}
+					newPersonalBest = best
+				}
+				logBuffer.Add(fmt.Sprintf("† Game Over! Score: %d, Level: %d, Lines: %d (%s, seed %d)",
+					overMsg.Score, overMsg.Level, overMsg.Lines, overMsg.Ruleset, overMsg.Seed))
+
+			case protocol.MessageTypeMatchJoined:
+				joined, err := parseMatchJoinedMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse match: %v", err))
+					continue
+				}
+				logBuffer.Add(fmt.Sprintf("⚑ Match %s (seed %d): %s", joined.MatchID, joined.Seed, formatRoster(joined.Roster)))
+				opponents.Retain(joined.Roster)
+
+			case protocol.MessageTypeOpponentState:
+				oppMsg, err := parseOpponentStateMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse opponent state: %v", err))
+					continue
+				}
+				opponents.Update(oppMsg)
+				sched.Invalidate(tui.RegionOpponents)
+				continue
+
+			case protocol.MessageTypeMatchResult:
+				result, err := parseMatchResultMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse match result: %v", err))
+					continue
+				}
+				logBuffer.Add(fmt.Sprintf("⚑ Match %s result: %s", result.MatchID, formatStandings(result.Standings)))
+
+			case protocol.MessageTypeChat:
+				chatMsg, err := parseChatMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse chat message: %v", err))
+					continue
+				}
+				chat.Add(chatMsg)
+				sched.Invalidate(tui.RegionChat)
+				continue
+
+			case protocol.MessageTypeEmote:
+				emoteMsg, err := parseEmoteMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse emote: %v", err))
+					continue
+				}
+				emote.Show(emoteMsg, time.Now())
+				logBuffer.Add(fmt.Sprintf("☺ %s: %s", displayName(emoteMsg.From), tui.EmoteLabel(emoteMsg.Emote)))
+				sched.Invalidate(tui.RegionBoard | tui.RegionOpponents)
+				continue
+
+			case protocol.MessageTypeIdleTimeout:
+				idleMsg, err := parseIdleTimeoutMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse idle timeout: %v", err))
+					continue
+				}
+				// The server closes the connection next; reconnecting would only start a new idle game
+				client.SetReconnect(false)
+				idle := time.Duration(idleMsg.IdleMs) * time.Millisecond
+				statusMsg = fmt.Sprintf("Disconnected after %s without input", tui.FormatDuration(idle))
+				logBuffer.Error("✗ " + statusMsg)
+
+			case protocol.MessageTypeServerShutdown:
+				shutdownMsg, err := parseServerShutdownMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse server shutdown: %v", err))
+					continue
+				}
+				grace := time.Duration(shutdownMsg.GraceMs) * time.Millisecond
+				statusMsg = fmt.Sprintf("Server restarting in %s", tui.FormatDuration(grace))
+				if shutdownMsg.SessionID != "" {
+					// Reconnecting after the restart continues this game
+					client.SetSession(shutdownMsg.SessionID)
+					statusMsg += " - your game will be saved"
+				}
+				logBuffer.Error("! " + statusMsg)
+
+			case protocol.MessageTypeNotice:
+				noticeMsg, err := parseNoticeMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse notice: %v", err))
+					continue
+				}
+				statusMsg = "Notice: " + noticeMsg.Text
+				logBuffer.Add("! " + statusMsg)
+
+			case protocol.MessageTypeSavedGame:
+				savedMsg, err := parseSavedGameMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse saved game: %v", err))
+					continue
+				}
+				saved := &SavedGame{Server: *serverAddr, Mode: mode, SavedAt: time.Now(), Snapshot: savedMsg.Snapshot}
+				if currentState != nil {
+					saved.Score = currentState.Score
+				}
+				if err := saved.Save(savedGamePath); err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to save game: %v", err))
+					continue
+				}
+				statusMsg = "Game saved - continue it later with --continue"
+				logBuffer.Add("✓ " + statusMsg)
+
+			case protocol.MessageTypeRestartPending:
+				restartPending = true
+				logBuffer.Add("? Restart requested - confirm with Y")
+
+			case protocol.MessageTypePauseVote:
+				vote, err := parsePauseVoteMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse pause vote: %v", err))
+					continue
+				}
+				pauseVote = nil
+				if vote.Result == protocol.PauseVoteOpen && !vote.Voted {
+					pauseVote = &vote
+					logBuffer.Add("? " + pauseVotePrompt(vote) + " (Y/N)")
+				}
+				statusMsg = pauseVoteStatus(vote)
+				logBuffer.Debug("⏸ " + statusMsg)
+
+			case protocol.MessageTypeScoreEvent:
+				scoreMsg, err := parseScoreEventMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse score event: %v", err))
+					continue
+				}
+				if scoreMsg.BoardIndex == partnerBoard {
+					partnerPopups.Show(scoreMsg, time.Now())
+				} else {
+					popups.Show(scoreMsg, time.Now())
+				}
+				if scoreMsg.LevelUp {
+					logBuffer.Add(fmt.Sprintf("▲ Level %d", scoreMsg.Level))
+				}
+				sched.Invalidate(tui.RegionBoard)
+				continue
+
+			case protocol.MessageTypeYourTurn:
+				turn, err := parseYourTurnMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse your turn: %v", err))
+					continue
+				}
+				coopTurn = &turn
+				statusMsg = turnPlayer(turn) + " turn"
+				logBuffer.Debug("⇄ " + statusMsg)
+
+			case protocol.MessageTypePerfectClearAttack:
+				clear, err := parsePerfectClearAttackMessage(msg.Data)
+				if err != nil {
+					logBuffer.Error(fmt.Sprintf("✗ Failed to parse perfect clear: %v", err))
+					continue
+				}
+				if !isOpponent(opponents.List(), clear.Seat) {
+					statusMsg = "PERFECT CLEAR!"
+					logBuffer.Add("★ Perfect clear!")
+				} else {
+					logBuffer.Add(fmt.Sprintf("★ %s made a perfect clear, %d garbage", displayName(clear.Name), clear.Garbage))
+				}
 			}
-			statusMsg = fmt.Sprintf("Game Over! Score: %d", overMsg.Score)
-			logBuffer.Add(fmt.Sprintf("† Game Over! Score: %d, Level: %d, Lines: %d",
-				overMsg.Score, overMsg.Level, overMsg.Lines))
 
-		case protocol.MessageTypePing:
-			// Pings are handled automatically by the client
+			// Other messages change the page shown or the dialogs over it
+			sched.Invalidate(tui.RegionAll)
 		}
-	})
+		if messages.Lagged() {
+			statusMsg = "Display fell behind the server, restart to resync"
+			logBuffer.Error("✗ " + statusMsg)
+			sched.Invalidate(tui.RegionAll)
+		}
+	}()
 
 	// Connect to server
 	ui.SetRunning(true)
@@ -164,52 +745,156 @@ func main() {
 	go func() {
 		if err := client.Connect(); err != nil {
 			statusMsg = fmt.Sprintf("Failed to connect: %v", err)
-			logBuffer.Add(fmt.Sprintf("✗ Failed to connect: %v", err))
+			logBuffer.Error(fmt.Sprintf("✗ Failed to connect: %v", err))
 		}
 	}()
 
 	// Main loop
 	style := tcell.StyleDefault
+	lastInput := time.Now()
+	var lastLayout tui.Layout
+	var lastPlaying bool
+	var lastDanger bool
+	var lastStatus string
+	var lastConnected bool
+	var lastLogVersion uint64
+	var lastTick time.Time
+	var lastEmoteVisible bool
+	var lastTeamScore int
 
 	for ui.IsRunning() {
-		// Handle events first (with short timeout for responsive input)
-		ev := ui.PollEventWithTimeout(50 * time.Millisecond)
+		// Wait for input or a pending frame; an idle screen is still refreshed
+		// every second for the lobby ticker and countdowns
+		timeout := time.Second
+		if wait, ok := sched.NextFrame(time.Now()); ok {
+			timeout = wait
+		}
+		ev := ui.WaitEvent(sched.Wake(), timeout)
 
 		if ev != nil {
+			// Input can change any part of the screen
+			sched.Invalidate(tui.RegionAll)
+
 			switch ev := ev.(type) {
 			case *tcell.EventKey:
+				lastInput = time.Now()
+
 				// Log the key that was pressed (for debugging)
 				keyName := tcell.KeyNames[ev.Key()]
 				if keyName == "" {
 					keyName = fmt.Sprintf("Rune(%c)", ev.Rune())
 				}
-				logBuffer.Add(fmt.Sprintf("Key: %s", keyName))
+				logBuffer.Debug(fmt.Sprintf("Key: %s", keyName))
+
+				// A pending restart dialog captures all keys
+				if restartPending {
+					restartPending = false
+					if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+						cmd := protocol.ControlMessage{Type: protocol.MessageTypeRestartConfirm}
+						data, err := json.Marshal(cmd)
+						if err != nil {
+							logBuffer.Error(fmt.Sprintf("✗ Failed to marshal restart_confirm: %v", err))
+						} else if err := client.Send(data); err != nil {
+							logBuffer.Error(fmt.Sprintf("✗ Failed to send restart_confirm: %v", err))
+						} else {
+							logBuffer.Debug("→ restart_confirm")
+							statusMsg = "Restarting..."
+						}
+					} else {
+						logBuffer.Add("Restart cancelled")
+					}
+					continue
+				}
+
+				// Another player's pause vote captures all keys until answered
+				if pauseVote != nil {
+					if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+						sendPauseVote(client, true, logBuffer)
+					} else {
+						sendPauseVote(client, false, logBuffer)
+					}
+					pauseVote = nil
+					continue
+				}
+
+				// The chat pane captures all keys while a message is typed
+				if chat.Typing() {
+					if text, send := chat.HandleKey(ev); send {
+						sendChat(client, text, logBuffer)
+					}
+					continue
+				}
+
+				// The help overlay captures all keys until it is closed
+				action, _ := ui.Keymap().Lookup(ev)
+				if helpOpen {
+					if action == tui.ActionHelp || ev.Key() == tcell.KeyEscape {
+						helpOpen = false
+						if helpPaused {
+							sendCommand(client, protocol.MessageTypeResume, logBuffer)
+							helpPaused = false
+						}
+					} else if settings.HandleKey(ev, &config) {
+						config.Apply(ui, repeat)
+						if configPath != "" {
+							if err := config.Save(configPath); err != nil {
+								logBuffer.Error(fmt.Sprintf("✗ Failed to save settings: %v", err))
+							}
+						}
+					}
+					continue
+				}
+				// Kiosk players cannot change the station's settings
+				if logView.HandleKey(ev) {
+					continue
+				}
+				if action == tui.ActionHelp && !kiosk.Enabled {
+					helpOpen = true
+					// Pausing a match would ask the other players to vote
+					if currentState != nil && currentState.State == "playing" && client.IsConnected() && *matchID == "" {
+						helpPaused = sendCommand(client, protocol.MessageTypePause, logBuffer)
+					}
+					continue
+				}
 
 				// Check for quit keys FIRST (before any other logic)
 				// This prevents Q key from triggering reconnect when not connected
-				if isQuitKey(ev) {
+				if isQuitKey(ui, ev) {
+					// Kiosk players cannot quit without the operator passcode
+					if kiosk.Enabled && !promptPasscode(ui, kiosk.Passcode) {
+						continue
+					}
 					logBuffer.Add("Quit requested")
 					ui.SetRunning(false)
 					continue
 				}
 
 				if gameOver {
-					// Game over state - check for restart key
-					if ev.Key() == tcell.KeyRune && (ev.Rune() == 'r' || ev.Rune() == 'R') {
-						// Send restart command
-						cmd := protocol.ControlMessage{Type: protocol.MessageTypeRestart}
-						data, err := json.Marshal(cmd)
-						if err != nil {
-							logBuffer.Add(fmt.Sprintf("✗ Failed to marshal restart: %v", err))
-						} else if err := client.Send(data); err != nil {
-							logBuffer.Add(fmt.Sprintf("✗ Failed to send restart: %v", err))
+					// Copy the result card to the terminal's clipboard
+					if ev.Key() == tcell.KeyRune && (ev.Rune() == 'c' || ev.Rune() == 'C') && lastResult != nil {
+						if err := copyToClipboard(os.Stdout, ResultCard(*lastResult, name, *serverAddr)); err != nil {
+							logBuffer.Error(fmt.Sprintf("✗ Failed to copy result card: %v", err))
 						} else {
-							logBuffer.Add("→ restart")
+							logBuffer.Add("✓ Result card copied to clipboard")
+						}
+						continue
+					}
+
+					// Game over state - check for restart key
+					if action, ok := ui.Keymap().Lookup(ev); ok && action == tui.ActionRestart {
+						if sendRestart(client, logBuffer) {
 							statusMsg = "Restarting..."
 							// Clear game over state
 							gameOver = false
 						}
 					}
+					// Practice games can take back the piece that topped out
+					if action == tui.ActionUndo {
+						if _, sent := handleKeyEvent(ui, ev, client, inputs, logBuffer); sent {
+							statusMsg = "Undo"
+							gameOver = false
+						}
+					}
 					continue
 				}
 
@@ -220,50 +905,236 @@ func main() {
 					continue
 				}
 
-				// Handle game control keys
-				if handleKeyEvent(ev, client, logBuffer) {
-					ui.SetRunning(false)
+				if action == tui.ActionChat {
+					chat.Open()
+					continue
+				}
+				if e, ok := tui.EmoteForAction(action); ok {
+					sendEmote(client, e, logBuffer)
+					continue
+				}
+
+				// The second player's keys play board 2 of a doubles game
+				if partnerKeys != nil {
+					if action, ok := partnerKeys.Lookup(ev); ok {
+						partnerRepeat.DAS, partnerRepeat.ARR = repeat.DAS, repeat.ARR
+						if partnerRepeat.Allow(action, time.Now()) {
+							sendPartnerCommand(client, action, logBuffer)
+						}
+						continue
+					}
+				}
+
+				// The partner's piece in a co-op game is theirs to move
+				if outOfTurn(coopTurn, action) {
+					statusMsg = turnPlayer(*coopTurn) + " turn - wait for their piece to lock"
+					continue
+				}
+
+				// Hold is limited to once per piece; say so instead of sending a no-op
+				if action == tui.ActionHold && currentState != nil && !currentState.CanHold {
+					statusMsg = "Hold already used for this piece"
 					continue
 				}
 
+				// Apply DAS and ARR to the key repeats of held move keys
+				if !repeat.Allow(action, time.Now()) {
+					continue
+				}
+
+				// Handle game control keys
+				if cmdType, sent := handleKeyEvent(ui, ev, client, inputs, logBuffer); sent && predictor != nil {
+					if state := predictor.Apply(cmdType); state != nil {
+						currentState = state
+					}
+				}
+
 			case *tcell.EventResize:
 				ui.UpdateSize()
-				if !ui.CheckMinimumSize() {
-					statusMsg = "Terminal too small (min 80x30)"
+				layout := ui.Layout(currentState)
+				if *doubles {
+					layout = ui.DoublesLayout(currentState)
+				}
+				if layout.TooSmall {
+					statusMsg = fmt.Sprintf("Terminal too small (min %dx%d)", layout.MinWidth, layout.MinHeight)
 				}
 			}
 		}
 
-		// Then draw current state
-		ui.Clear()
+		// Show a countdown while waiting for the next reconnection attempt
+		if !client.IsConnected() && time.Now().Before(reconnectAt) {
+			remaining := time.Until(reconnectAt).Round(time.Second)
+			statusMsg = fmt.Sprintf("Reconnecting in %v (attempt %d)...", remaining, reconnectAttempt)
+		}
 
-		if currentState == nil && !gameOver {
-			// Show welcome screen
-			ui.DrawWelcomeScreen(style)
-		} else if gameOver {
-			// Show game over screen
-			if currentState != nil {
-				ui.DrawGameOverScreen(currentState, style)
+		// Find the regions changed by the status bar, the log and the clock
+		if statusMsg != lastStatus || client.IsConnected() != lastConnected {
+			lastStatus, lastConnected = statusMsg, client.IsConnected()
+			sched.Invalidate(tui.RegionStatus)
+		}
+		if v := logBuffer.Version(); v != lastLogVersion {
+			lastLogVersion = v
+			sched.Invalidate(tui.RegionLog)
+		}
+		// Line clear animations, flashing rows and score popups redraw the
+		// board every frame
+		if ui.Animator().Active(time.Now()) || popups.Active(time.Now()) || partnerPopups.Active(time.Now()) ||
+			(currentState != nil && len(currentState.ClearingRows) > 0) || (partnerState != nil && len(partnerState.ClearingRows) > 0) {
+			sched.Invalidate(tui.RegionBoard)
+		}
+		if v := emote.Visible(time.Now()); v != lastEmoteVisible {
+			lastEmoteVisible = v
+			sched.Invalidate(tui.RegionBoard | tui.RegionOpponents)
+		}
+		if time.Since(lastTick) >= time.Second {
+			lastTick = time.Now()
+			if currentState == nil {
+				sched.Invalidate(tui.RegionAll)
 			}
-		} else if currentState != nil {
-			// Draw game (use rows 1-20 for game)
-			// Draw a box around the entire game area
-			ui.DrawBox(1, 0, 78, 22, "", style)
-			ui.DrawBoard(2, 1, currentState, style)
-			ui.DrawInfoPanel(26, 1, currentState, style)
 		}
 
-		// Draw status bar (row 22)
-		ui.DrawStatusBar(0, 22, 80, statusMsg, client.IsConnected(), style)
+		// Then draw what changed; the welcome and game over pages, dialogs and
+		// layout changes redraw the whole screen
+		if damage := sched.Take(time.Now()); damage != 0 {
+			layout := ui.Layout(currentState)
+			opps := opponents.List()
+			if *matchID != "" || *cpuLevel != "" {
+				layout = ui.MatchLayout(currentState, len(opps))
+			} else if *doubles {
+				layout = ui.DoublesLayout(currentState)
+			}
+			emoteAt := emoteArea(layout, opps, &emote)
+			screenW, _ := ui.GetSize()
+			playing := currentState != nil && !gameOver && !layout.TooSmall
+			// The frame turns red while the stack is close to the top
+			danger := playing && (tui.InDanger(currentState) || tui.InDanger(partnerState))
+			// A lower team score, after a restart, is narrower than the one drawn
+			if !playing || !lastPlaying || restartPending || pauseVote != nil || helpOpen || layout != lastLayout || danger != lastDanger || teamScore < lastTeamScore {
+				damage = tui.RegionAll
+			}
+			lastTeamScore = teamScore
+			if danger && !lastDanger && config.Bell {
+				ui.Beep()
+			}
+			lastLayout, lastPlaying, lastDanger = layout, playing, danger
+			if damage == tui.RegionAll {
+				ui.Clear()
+			}
 
-		// Draw separator line
-		ui.DrawText(0, 23, strings.Repeat("─", 80), style.Dim(true))
+			if currentState == nil && !gameOver {
+				// Show welcome screen
+				ui.DrawWelcomeScreen(style)
+				drawLobby(ui, lobby, style)
+				drawPersonalBest(ui, highScores, false, style)
+			} else if gameOver {
+				// Show game over screen
+				if currentState != nil {
+					final := *currentState
+					if *doubles {
+						final.TeamScore = teamScore
+					}
+					ui.DrawGameOverScreen(&final, style)
+					drawPersonalBest(ui, highScores, newPersonalBest, style)
+				}
+			} else if layout.TooSmall {
+				ui.DrawTextAligned(0, 0, screenW, fmt.Sprintf("Terminal too small (min %dx%d)",
+					layout.MinWidth, layout.MinHeight), 0, style.Bold(true))
+			} else {
+				// Draw game below row 0, laid out for the board the server reports
+				// and the terminal size, with a box around the entire game area
+				if damage == tui.RegionAll {
+					frameState := currentState
+					if tui.InDanger(partnerState) {
+						frameState = partnerState
+					}
+					ui.DrawGameFrame(layout.Frame, frameState, style)
+					if coopTurn != nil {
+						ui.DrawTurn(layout.Frame, turnPlayer(*coopTurn), coopTurn.YourTurn, style)
+					}
+				}
+				if damage&tui.RegionBoard != 0 {
+					ui.ClearRect(layout.Board)
+					ui.DrawBoard(layout.Board.X, layout.Board.Y, layout.Cell, currentState, style)
+					ui.DrawPopups(&popups, layout.Board, time.Now(), style)
+					if emoteAt == layout.Board {
+						ui.DrawEmote(&emote, layout.Board, time.Now(), style)
+					}
+					if layout.Doubles && partnerState != nil {
+						ui.ClearRect(layout.Partner)
+						ui.DrawBoard(layout.Partner.X, layout.Partner.Y, layout.Cell, partnerState, style)
+						ui.DrawPopups(&partnerPopups, layout.Partner, time.Now(), style)
+					}
+				}
+				if damage&tui.RegionOpponents != 0 {
+					for i := 0; i < layout.OpponentCount && i < len(opps); i++ {
+						ui.ClearRect(layout.Opponents[i])
+						ui.DrawMiniBoard(layout.Opponents[i], opps[i], style)
+						if emoteAt == layout.Opponents[i] {
+							ui.DrawEmote(&emote, layout.Opponents[i], time.Now(), style)
+						}
+					}
+				}
+				if damage&tui.RegionInfo != 0 {
+					ui.ClearRect(layout.Info)
+					ui.DrawInfoPanel(layout.Info, currentState, style)
+					if pace != nil {
+						ghostState := pace.At(time.Duration(currentState.ElapsedMs) * time.Millisecond)
+						ui.DrawPace(layout.Info, currentState, ghostState, style)
+					}
+					if layout.Doubles {
+						if partnerState != nil {
+							ui.ClearRect(layout.PartnerInfo)
+							ui.DrawInfoPanel(layout.PartnerInfo, partnerState, style)
+						}
+						ui.DrawTeamScore(layout.Frame, teamScore, style)
+					}
+				}
+			}
 
-		// Draw log window (rows 24-29, 6 rows for logs)
-		drawLogWindow(ui, 0, 24, 80, 6, logBuffer, style)
+			if restartPending {
+				ui.DrawConfirmDialog("Restart", "Abandon the current game and restart?", style)
+			}
+			if pauseVote != nil {
+				ui.DrawConfirmDialog("Pause", pauseVotePrompt(*pauseVote), style)
+			}
+			if helpOpen {
+				ui.DrawHelpOverlay(settings.Settings(&config), settings.Selected, style)
+			}
 
-		// Update screen
-		ui.Sync()
+			// Draw status bar below the game area
+			if damage&tui.RegionStatus != 0 {
+				ui.DrawStatusBar(0, layout.StatusY, screenW, statusMsg, client.IsConnected(), style)
+			}
+
+			// Draw the log window below a separator line when there is room
+			if damage&tui.RegionLog != 0 && layout.ShowLog {
+				ui.ClearRect(layout.Log)
+				ui.DrawHLine(0, layout.StatusY+1, screenW, style.Dim(true))
+				ui.DrawLogView(logView, layout.Log, style)
+			}
+			if damage&tui.RegionChat != 0 && layout.ShowChat && playing {
+				ui.ClearRect(layout.Chat)
+				ui.DrawChatPane(chat, layout.Chat, style)
+			}
+
+			// Update screen
+			ui.Sync()
+		}
+
+		// An idle kiosk returns to the attract screen and starts a fresh game for the next player
+		if kiosk.Enabled && gameOver && time.Since(lastInput) > kiosk.IdleTimeout {
+			if !showAttract(ui, logBuffer, lobby, highScores, kiosk) {
+				ui.SetRunning(false)
+				continue
+			}
+			sched.Invalidate(tui.RegionAll)
+			if sendRestart(client, logBuffer) {
+				statusMsg = "Restarting..."
+				gameOver = false
+				newPersonalBest = false
+			}
+			lastInput = time.Now()
+		}
 
 		// Check for shutdown signals
 		select {
@@ -288,6 +1159,9 @@ func parseStateMessage(data interface{}) (*protocol.StateMessage, error) {
 	if err := json.Unmarshal(jsonBytes, &state); err != nil {
 		return nil, err
 	}
+	if err := state.ExpandBoard(); err != nil {
+		return nil, err
+	}
 
 	return &state, nil
 }
@@ -320,16 +1194,255 @@ func parseGameOverMessage(data interface{}) (protocol.GameOverMessage, error) {
 	return overMsg, nil
 }
 
-func showWelcome(ui *tui.TUI, logBuffer *LogBuffer) {
+func parseMatchJoinedMessage(data interface{}) (protocol.MatchJoinedMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.MatchJoinedMessage{}, err
+	}
+
+	var joined protocol.MatchJoinedMessage
+	if err := json.Unmarshal(jsonBytes, &joined); err != nil {
+		return protocol.MatchJoinedMessage{}, err
+	}
+
+	return joined, nil
+}
+
+func parseMatchResultMessage(data interface{}) (protocol.MatchResultMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.MatchResultMessage{}, err
+	}
+
+	var result protocol.MatchResultMessage
+	if err := json.Unmarshal(jsonBytes, &result); err != nil {
+		return protocol.MatchResultMessage{}, err
+	}
+
+	return result, nil
+}
+
+// parseChatMessage parses a chat message relayed by the server
+func parseChatMessage(data interface{}) (protocol.ChatMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.ChatMessage{}, err
+	}
+
+	var chatMsg protocol.ChatMessage
+	if err := json.Unmarshal(jsonBytes, &chatMsg); err != nil {
+		return protocol.ChatMessage{}, err
+	}
+
+	return chatMsg, nil
+}
+
+// parseEmoteMessage parses an emote relayed by the server
+func parseEmoteMessage(data interface{}) (protocol.EmoteMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.EmoteMessage{}, err
+	}
+
+	var emoteMsg protocol.EmoteMessage
+	if err := json.Unmarshal(jsonBytes, &emoteMsg); err != nil {
+		return protocol.EmoteMessage{}, err
+	}
+
+	return emoteMsg, nil
+}
+
+// parseScoreEventMessage parses the points awarded for a line clear
+func parseScoreEventMessage(data interface{}) (protocol.ScoreEventMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.ScoreEventMessage{}, err
+	}
+
+	var scoreMsg protocol.ScoreEventMessage
+	if err := json.Unmarshal(jsonBytes, &scoreMsg); err != nil {
+		return protocol.ScoreEventMessage{}, err
+	}
+
+	return scoreMsg, nil
+}
+
+// parseOpponentStateMessage parses the state of another player in the match
+func parseOpponentStateMessage(data interface{}) (protocol.OpponentStateMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.OpponentStateMessage{}, err
+	}
+
+	var oppMsg protocol.OpponentStateMessage
+	if err := json.Unmarshal(jsonBytes, &oppMsg); err != nil {
+		return protocol.OpponentStateMessage{}, err
+	}
+
+	return oppMsg, nil
+}
+
+// emoteArea returns where the emote being shown goes: over the sender's board
+// if it is on screen, otherwise over the player's own board
+func emoteArea(layout tui.Layout, opps []protocol.OpponentStateMessage, emote *tui.EmoteBubble) tui.Rect {
+	if seat, ok := emote.Seat(time.Now()); ok {
+		for i := 0; i < layout.OpponentCount && i < len(opps); i++ {
+			if opps[i].Seat == seat {
+				return layout.Opponents[i]
+			}
+		}
+	}
+	return layout.Board
+}
+
+// formatRoster lists the players of a match with the ratings of registered
+// players and the handicaps of handicapped ones
+func formatRoster(roster []protocol.MatchPlayer) string {
+	parts := make([]string, len(roster))
+	for i, p := range roster {
+		parts[i] = displayName(p.Name)
+		if p.Rating != 0 {
+			parts[i] += fmt.Sprintf(" (%d)", p.Rating)
+		}
+		if p.Handicap != "" {
+			parts[i] += " [" + p.Handicap + "]"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatStandings lists the final scores of a match with the rating changes
+func formatStandings(standings []protocol.MatchStanding) string {
+	parts := make([]string, len(standings))
+	for i, s := range standings {
+		parts[i] = fmt.Sprintf("%d. %s %d", i+1, displayName(s.Name), s.Score)
+		if s.Rating != 0 {
+			parts[i] += fmt.Sprintf(" (%d %+d)", s.Rating, s.RatingChange)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayName returns a player's name, or a placeholder for players without one
+func displayName(name string) string {
+	if name == "" {
+		return "anonymous"
+	}
+	return name
+}
+
+func parseSavedGameMessage(data interface{}) (protocol.SavedGameMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.SavedGameMessage{}, err
+	}
+
+	var savedMsg protocol.SavedGameMessage
+	if err := json.Unmarshal(jsonBytes, &savedMsg); err != nil {
+		return protocol.SavedGameMessage{}, err
+	}
+
+	return savedMsg, nil
+}
+
+func parseNoticeMessage(data interface{}) (protocol.NoticeMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.NoticeMessage{}, err
+	}
+
+	var noticeMsg protocol.NoticeMessage
+	if err := json.Unmarshal(jsonBytes, &noticeMsg); err != nil {
+		return protocol.NoticeMessage{}, err
+	}
+
+	return noticeMsg, nil
+}
+
+func parseServerShutdownMessage(data interface{}) (protocol.ServerShutdownMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.ServerShutdownMessage{}, err
+	}
+
+	var shutdownMsg protocol.ServerShutdownMessage
+	if err := json.Unmarshal(jsonBytes, &shutdownMsg); err != nil {
+		return protocol.ServerShutdownMessage{}, err
+	}
+
+	return shutdownMsg, nil
+}
+
+func parseIdleTimeoutMessage(data interface{}) (protocol.IdleTimeoutMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.IdleTimeoutMessage{}, err
+	}
+
+	var idleMsg protocol.IdleTimeoutMessage
+	if err := json.Unmarshal(jsonBytes, &idleMsg); err != nil {
+		return protocol.IdleTimeoutMessage{}, err
+	}
+
+	return idleMsg, nil
+}
+
+func parsePerfectClearAttackMessage(data interface{}) (protocol.PerfectClearAttackMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.PerfectClearAttackMessage{}, err
+	}
+
+	var clear protocol.PerfectClearAttackMessage
+	if err := json.Unmarshal(jsonBytes, &clear); err != nil {
+		return protocol.PerfectClearAttackMessage{}, err
+	}
+
+	return clear, nil
+}
+
+// isOpponent reports whether a seat belongs to one of the opponents shown
+func isOpponent(opps []protocol.OpponentStateMessage, seat int) bool {
+	for _, opp := range opps {
+		if opp.Seat == seat {
+			return true
+		}
+	}
+	return false
+}
+
+func showWelcome(ui *tui.TUI, logBuffer *tui.LogBuffer, lobby *wsclient.LobbyFetcher, highScores *HighScoreStore) {
 	style := tcell.StyleDefault
-	ui.DrawWelcomeScreen(style)
-	ui.Sync()
 
 	logBuffer.Add("Welcome! Press any key to start...")
 
-	// Wait for any key
+	// Wait for any key, redrawing so the lobby ticker stays current
+	// After a while without input the bot plays a demo game until it tops out
+	var demo *Demo
+	idleSince := time.Now()
 	for {
-		ev := ui.PollEvent()
+		now := time.Now()
+		if demo == nil && now.Sub(idleSince) >= demoIdleTimeout {
+			demo = NewDemo()
+		}
+		if demo != nil && !demo.Update(now) {
+			demo = nil
+			idleSince = now
+		}
+
+		ui.Clear()
+		poll := 500 * time.Millisecond
+		if demo != nil {
+			drawDemo(ui, demo, style)
+			poll = 50 * time.Millisecond
+		} else {
+			ui.DrawWelcomeScreen(style)
+			drawLobby(ui, lobby, style)
+			drawPersonalBest(ui, highScores, false, style)
+		}
+		ui.Sync()
+
+		ev := ui.PollEventWithTimeout(poll)
 		if _, ok := ev.(*tcell.EventKey); ok {
 			logBuffer.Add("Starting game...")
 			break
@@ -337,104 +1450,256 @@ func showWelcome(ui *tui.TUI, logBuffer *LogBuffer) {
 	}
 }
 
-func drawLogWindow(ui *tui.TUI, x, y, width, height int, logBuffer *LogBuffer, style tcell.Style) {
-	// Draw box border using TUI's DrawBox method
-	ui.DrawBox(x, y, width, height, "Messages", style)
+// showModeSelect lets the player choose a game mode or look at their profile
+// Returns false if the player chose to quit
+func showModeSelect(ui *tui.TUI, logBuffer *tui.LogBuffer, account *Account) (game.Mode, bool) {
+	style := tcell.StyleDefault
+	selected := 0
 
-	// Get log messages
-	messages := logBuffer.GetMessages()
+	for {
+		ui.Clear()
+		ui.DrawModeSelectScreen(selected, style)
+		ui.Sync()
 
-	// Calculate how many messages we can show
-	maxLines := height - 2
-	startIdx := len(messages) - maxLines
-	if startIdx < 0 {
-		startIdx = 0
-	}
+		ev, ok := ui.PollEvent().(*tcell.EventKey)
+		if !ok {
+			continue
+		}
 
-	// Draw messages (bottom-up, showing newest)
-	for i := 0; i < maxLines && i < len(messages); i++ {
-		msgIdx := startIdx + i
-		if msgIdx >= len(messages) {
-			break
+		switch {
+		case ev.Key() == tcell.KeyUp:
+			selected = (selected + len(tui.GameModes) - 1) % len(tui.GameModes)
+		case ev.Key() == tcell.KeyDown:
+			selected = (selected + 1) % len(tui.GameModes)
+		case ev.Key() == tcell.KeyEnter:
+			mode := tui.GameModes[selected].Mode
+			logBuffer.Add(fmt.Sprintf("Mode selected: %s", mode))
+			return mode, true
+		case ev.Key() == tcell.KeyRune && ev.Rune() >= '1' && ev.Rune() < '1'+rune(len(tui.GameModes)):
+			selected = int(ev.Rune() - '1')
+		case ev.Key() == tcell.KeyRune && (ev.Rune() == 'p' || ev.Rune() == 'P'):
+			showProfile(ui, *serverAddr, account)
+		case isQuitKey(ui, ev):
+			return "", false
 		}
+	}
+}
 
-		lineY := y + height - 2 - i
-		msg := messages[msgIdx]
+// sendRestart asks the server to restart the game; returns true if the command was sent
+func sendRestart(client *wsclient.Client, logBuffer *tui.LogBuffer) bool {
+	return sendCommand(client, protocol.MessageTypeRestart, logBuffer)
+}
 
-		// Truncate if too long
-		maxMsgLen := width - 4
-		if len(msg) > maxMsgLen {
-			msg = msg[:maxMsgLen]
-		}
+// sendCommand sends a control command that is not predicted or acknowledged
+func sendCommand(client *wsclient.Client, cmdType protocol.MessageType, logBuffer *tui.LogBuffer) bool {
+	cmd := protocol.ControlMessage{Type: cmdType}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal %s: %v", cmdType, err))
+		return false
+	}
 
-		// Draw message using TUI's DrawText method
-		ui.DrawText(x+2, lineY, msg, style)
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send %s: %v", cmdType, err))
+		return false
 	}
+	logBuffer.Debug(fmt.Sprintf("→ %s", cmdType))
+	return true
 }
 
-func handleKeyEvent(ev *tcell.EventKey, client *wsclient.Client, logBuffer *LogBuffer) bool {
-	var cmdType protocol.MessageType
+// sendChat sends a chat message to the other players of the match
+func sendChat(client *wsclient.Client, text string, logBuffer *tui.LogBuffer) {
+	cmd := protocol.ControlMessage{Type: protocol.MessageTypeChat, Text: text}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal chat: %v", err))
+		return
+	}
 
-	switch ev.Key() {
-	case tcell.KeyLeft:
-		cmdType = protocol.MessageTypeMoveLeft
-	case tcell.KeyRight:
-		cmdType = protocol.MessageTypeMoveRight
-	case tcell.KeyDown:
-		cmdType = protocol.MessageTypeMoveDown
-	case tcell.KeyUp:
-		cmdType = protocol.MessageTypeRotate
-	case tcell.KeyEnter:
-		cmdType = protocol.MessageTypeHardDrop
-	default:
-		switch ev.Rune() {
-		case ' ', 'x', 'X':
-			cmdType = protocol.MessageTypeHardDrop
-		case 'p', 'P':
-			cmdType = protocol.MessageTypeTogglePause
-		default:
-			return false
-		}
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send chat: %v", err))
+		return
 	}
+	logBuffer.Debug("→ chat")
+}
 
-	if cmdType != "" {
-		cmd := protocol.ControlMessage{Type: cmdType}
-		data, err := json.Marshal(cmd)
-		if err != nil {
-			log.Printf("Failed to marshal command: %v", err)
-			logBuffer.Add(fmt.Sprintf("✗ Failed to marshal command: %v", err))
-			return false
-		}
+// sendEmote sends an emote to the other players of the match
+func sendEmote(client *wsclient.Client, emote string, logBuffer *tui.LogBuffer) {
+	cmd := protocol.ControlMessage{Type: protocol.MessageTypeEmote, Emote: emote}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal emote: %v", err))
+		return
+	}
 
-		if err := client.Send(data); err != nil {
-			log.Printf("Failed to send command: %v", err)
-			logBuffer.Add(fmt.Sprintf("✗ Failed to send %s: %v", cmdType, err))
-		} else {
-			// Log key commands (including rotate for debugging)
-			switch cmdType {
-			case protocol.MessageTypeRotate:
-				logBuffer.Add("→ rotate")
-			case protocol.MessageTypeMoveLeft, protocol.MessageTypeMoveRight, protocol.MessageTypeMoveDown:
-				logBuffer.Add(fmt.Sprintf("→ %s", cmdType))
-			case protocol.MessageTypePause, protocol.MessageTypeResume, protocol.MessageTypeHardDrop:
-				logBuffer.Add(fmt.Sprintf("→ %s", cmdType))
-			}
-		}
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send emote: %v", err))
+		return
 	}
+	logBuffer.Add("☺ You: " + tui.EmoteLabel(emote))
+}
 
-	return false
+// sendName registers the player's display name with the server
+func sendName(client *wsclient.Client, name string, logBuffer *tui.LogBuffer) {
+	cmd := protocol.ControlMessage{Type: protocol.MessageTypeSetName, Name: name}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal set_name: %v", err))
+		return
+	}
+
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send set_name: %v", err))
+		return
+	}
+	logBuffer.Debug(fmt.Sprintf("→ set_name %s", name))
+}
+
+// sendModeSelection asks the server to start a game in the given mode
+func sendModeSelection(client *wsclient.Client, mode game.Mode, logBuffer *tui.LogBuffer) {
+	cmd := protocol.ControlMessage{Type: protocol.MessageTypeSelectMode, Mode: string(mode)}
+	if mode == game.ModePuzzle {
+		cmd.Puzzle = *puzzleName
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal select_mode: %v", err))
+		return
+	}
+
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send select_mode: %v", err))
+		return
+	}
+	logBuffer.Debug(fmt.Sprintf("→ select_mode %s", mode))
+}
+
+// sendLoadGame asks the server to continue a saved game instead of starting a new one
+func sendLoadGame(client *wsclient.Client, saved *SavedGame, logBuffer *tui.LogBuffer) {
+	cmd := protocol.ControlMessage{Type: protocol.MessageTypeLoadGame, Snapshot: saved.Snapshot}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal load_game: %v", err))
+		return
+	}
+
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send load_game: %v", err))
+		return
+	}
+	logBuffer.Add(fmt.Sprintf("Continuing %s game saved %s (score %d) - press P to continue",
+		saved.Mode, saved.SavedAt.Format("Jan 2 15:04"), saved.Score))
+}
+
+// drawPersonalBest draws the player's best results from the local high-score file
+func drawPersonalBest(ui *tui.TUI, highScores *HighScoreStore, newBest bool, style tcell.Style) {
+	if highScores == nil {
+		return
+	}
+	scores := highScores.Get()
+	if len(scores.Recent) == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("Personal best: %d  (Level %d, %d lines)  |  Last game: %d",
+		scores.BestScore, scores.BestLevel, scores.BestLines, scores.Recent[0].Score)
+	if newBest {
+		text = fmt.Sprintf("★ New personal best: %d ★", scores.BestScore)
+	}
+	ui.DrawPersonalBest(text, newBest, style)
+}
+
+// refreshLobby periodically refreshes the lobby information
+func refreshLobby(lobby *wsclient.LobbyFetcher) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		lobby.Refresh()
+		<-ticker.C
+	}
+}
+
+// drawLobby draws the lobby ticker at the top of the welcome screen
+func drawLobby(ui *tui.TUI, lobby *wsclient.LobbyFetcher, style tcell.Style) {
+	if lobby == nil {
+		return
+	}
+	info, offline := lobby.Cached()
+	ui.DrawLobbyTicker(1, info, offline, style)
+}
+
+// actionCommands maps keymap actions to the commands sent to the server
+var actionCommands = map[tui.Action]protocol.MessageType{
+	tui.ActionMoveLeft:  protocol.MessageTypeMoveLeft,
+	tui.ActionMoveRight: protocol.MessageTypeMoveRight,
+	tui.ActionSoftDrop:  protocol.MessageTypeMoveDown,
+	tui.ActionRotate:    protocol.MessageTypeRotate,
+	tui.ActionRotate180: protocol.MessageTypeRotate180,
+	tui.ActionHardDrop:  protocol.MessageTypeHardDrop,
+	tui.ActionHold:      protocol.MessageTypeHold,
+	tui.ActionPause:     protocol.MessageTypeTogglePause,
+	// The server asks for confirmation before restarting a running game
+	tui.ActionRestart: protocol.MessageTypeRestart,
+	// The server pauses the game and sends it back to be saved
+	tui.ActionSave: protocol.MessageTypeSaveGame,
+	tui.ActionUndo: protocol.MessageTypeUndo,
+}
+
+// handleKeyEvent sends the command bound to a key
+// Returns the command type and whether it was sent
+func handleKeyEvent(ui *tui.TUI, ev *tcell.EventKey, client *wsclient.Client, inputs *InputTracker, logBuffer *tui.LogBuffer) (protocol.MessageType, bool) {
+	action, ok := ui.Keymap().Lookup(ev)
+	if !ok {
+		return "", false
+	}
+	cmdType, ok := actionCommands[action]
+	if !ok || cmdType == "" {
+		return "", false
+	}
+
+	cmd := protocol.ControlMessage{Type: cmdType, Seq: inputs.Next()}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		log.Printf("Failed to marshal command: %v", err)
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal command: %v", err))
+		return cmdType, false
+	}
+
+	if err := client.Send(data); err != nil {
+		log.Printf("Failed to send command: %v", err)
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send %s: %v", cmdType, err))
+		return cmdType, false
+	}
+	inputs.Sent(cmd.Seq, cmdType)
+
+	// Log key commands (including rotate for debugging)
+	switch cmdType {
+	case protocol.MessageTypeRotate:
+		logBuffer.Debug("→ rotate")
+	case protocol.MessageTypeRotate180, protocol.MessageTypeMoveLeft, protocol.MessageTypeMoveRight, protocol.MessageTypeMoveDown:
+		logBuffer.Debug(fmt.Sprintf("→ %s", cmdType))
+	case protocol.MessageTypeTogglePause, protocol.MessageTypePause, protocol.MessageTypeResume,
+		protocol.MessageTypeHardDrop, protocol.MessageTypeRestart:
+		logBuffer.Debug(fmt.Sprintf("→ %s", cmdType))
+	}
+
+	return cmdType, true
 }
 
 // isQuitKey checks if the key event is a quit command
-func isQuitKey(ev *tcell.EventKey) bool {
-	switch ev.Key() {
-	case tcell.KeyEscape, tcell.KeyCtrlC, tcell.KeyCtrlD, tcell.KeyCtrlQ, tcell.KeyCtrlX:
-		return true
-	default:
-		switch ev.Rune() {
-		case 'q', 'Q':
-			return true
+func isQuitKey(ui *tui.TUI, ev *tcell.EventKey) bool {
+	action, ok := ui.Keymap().Lookup(ev)
+	return ok && action == tui.ActionQuit
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-	}
-	return false
+	})
+	return set
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// pauseVoteStatus describes how a match's pause vote stands
+func pauseVoteStatus(vote protocol.PauseVoteMessage) string {
+	switch vote.Result {
+	case protocol.PauseVotePassed:
+		return "Match paused by vote - resume with P"
+	case protocol.PauseVoteFailed:
+		return "Pause vote failed - the match goes on"
+	default:
+		return fmt.Sprintf("Pause vote: %d of %d agreed, %d declined", vote.Agreed, vote.Needed, vote.Declined)
+	}
+}
+
+// pauseVotePrompt asks the player to answer the pause vote another player opened
+func pauseVotePrompt(vote protocol.PauseVoteMessage) string {
+	return fmt.Sprintf("%s asks to pause the match. Agree?", displayName(vote.RequestedBy))
+}
+
+// sendPauseVote answers the match's pause vote
+func sendPauseVote(client *wsclient.Client, agree bool, logBuffer *tui.LogBuffer) {
+	data, err := json.Marshal(protocol.ControlMessage{Type: protocol.MessageTypePauseVote, Agree: agree})
+	if err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to marshal pause_vote: %v", err))
+		return
+	}
+	if err := client.Send(data); err != nil {
+		logBuffer.Error(fmt.Sprintf("✗ Failed to send pause_vote: %v", err))
+		return
+	}
+	logBuffer.Debug(fmt.Sprintf("→ pause_vote (agree=%v)", agree))
+}
+
+// parsePauseVoteMessage parses a pause_vote message
+func parsePauseVoteMessage(data interface{}) (protocol.PauseVoteMessage, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return protocol.PauseVoteMessage{}, err
+	}
+
+	var vote protocol.PauseVoteMessage
+	if err := json.Unmarshal(jsonBytes, &vote); err != nil {
+		return protocol.PauseVoteMessage{}, err
+	}
+
+	return vote, nil
+}
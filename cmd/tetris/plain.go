@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// announcement returns the events between two states as a sentence for the
+// status bar in --plain mode, where screen readers pick it up: the piece that
+// spawned, lines cleared, level ups and game over; empty if nothing happened
+func announcement(prev, next *protocol.StateMessage) string {
+	if prev == nil || next == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, ev := range wsclient.DiffStates(prev, next) {
+		switch ev.Type {
+		case wsclient.EventLineClear, wsclient.EventTetris:
+			if ev.Lines == 1 {
+				parts = append(parts, "1 line cleared")
+			} else {
+				parts = append(parts, fmt.Sprintf("%d lines cleared", ev.Lines))
+			}
+		case wsclient.EventLevelUp:
+			parts = append(parts, fmt.Sprintf("level %d", ev.Level))
+		case wsclient.EventGameOver:
+			parts = append(parts, fmt.Sprintf("game over, score %d", next.Score))
+		}
+	}
+	if spawned(prev, next) && next.PreviewHidden {
+		parts = append(parts, fmt.Sprintf("%s piece", next.CurrentPiece.Type))
+	} else if spawned(prev, next) {
+		parts = append(parts, fmt.Sprintf("%s piece, next %s", next.CurrentPiece.Type, next.NextPiece.Type))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// spawned reports whether a new piece came into play between two states: it
+// ended the spawn delay, or the current piece changed type or moved back up
+func spawned(prev, next *protocol.StateMessage) bool {
+	if next.Spawning || next.State != "playing" {
+		return false
+	}
+	return prev.Spawning || next.CurrentPiece.Type != prev.CurrentPiece.Type || next.CurrentPiece.Y < prev.CurrentPiece.Y
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// PracticeSetup is a stack and piece sequence to practice on, loaded with --setup
+// The file lists board rows such as "XXXX.XXXXX", bottom row last, and an
+// optional "next: TSZ" line with the pieces to come; lines starting with #
+// are comments
+type PracticeSetup struct {
+	Rows   []string
+	Pieces string
+}
+
+// LoadPracticeSetup loads a practice setup file
+func LoadPracticeSetup(path string) (*PracticeSetup, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	setup := &PracticeSetup{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "next:"):
+			setup.Pieces = strings.TrimSpace(strings.TrimPrefix(line, "next:"))
+			if _, err := protocol.ParsePieces(setup.Pieces); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		default:
+			setup.Rows = append(setup.Rows, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(setup.Rows) == 0 && setup.Pieces == "" {
+		return nil, fmt.Errorf("%s: no board rows or next pieces", path)
+	}
+	return setup, nil
+}
+
+// sendPracticeSetup sets up the board and next pieces of a new practice game
+func sendPracticeSetup(client *wsclient.Client, setup *PracticeSetup, logBuffer *tui.LogBuffer) {
+	var cmds []protocol.ControlMessage
+	if len(setup.Rows) > 0 {
+		cmds = append(cmds, protocol.ControlMessage{Type: protocol.MessageTypeSetBoard, Rows: setup.Rows})
+	}
+	if setup.Pieces != "" {
+		cmds = append(cmds, protocol.ControlMessage{Type: protocol.MessageTypeSetNextPiece, Pieces: setup.Pieces})
+	}
+
+	for _, cmd := range cmds {
+		data, err := json.Marshal(cmd)
+		if err != nil {
+			logBuffer.Error(fmt.Sprintf("✗ Failed to marshal %s: %v", cmd.Type, err))
+			return
+		}
+		if err := client.Send(data); err != nil {
+			logBuffer.Error(fmt.Sprintf("✗ Failed to send %s: %v", cmd.Type, err))
+			return
+		}
+		logBuffer.Debug(fmt.Sprintf("→ %s", cmd.Type))
+	}
+}
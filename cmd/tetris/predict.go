@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Predictor applies piece moves to a local game mirror as soon as they are
+// sent, and rebuilds the mirror from each authoritative server frame
+type Predictor struct {
+	server    *protocol.StateMessage // Last authoritative frame
+	mirror    *game.Game             // Local copy the predicted moves are applied to
+	predicted *protocol.StateMessage
+	mu        sync.Mutex
+}
+
+// NewPredictor creates a predictor with no state yet
+func NewPredictor() *Predictor {
+	return &Predictor{}
+}
+
+// Reconcile adopts an authoritative frame and replays the inputs the server
+// has not applied yet on top of it; returns the state to render
+func (p *Predictor) Reconcile(state *protocol.StateMessage, pending []PendingInput) *protocol.StateMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.server = state
+	p.mirror = nil
+	p.predicted = state
+
+	// During the spawn delay the current piece is locked, and moves wait for
+	// the next piece on the server
+	if state.State != game.StatePlaying.String() || state.Spawning {
+		return state
+	}
+
+	b := board.NewSized(state.Width, state.Height)
+	for y, row := range state.Board {
+		for x, color := range row {
+			if color != "" {
+				b.SetCell(x, y, piece.Color(color))
+			}
+		}
+	}
+	cp := state.CurrentPiece
+	p.mirror = game.NewMirror(b, &piece.Piece{Type: cp.Type, Color: cp.Color, X: cp.X, Y: cp.Y, Rotation: cp.Rotation})
+
+	for _, input := range pending {
+		if !p.applyLocked(input.Type) {
+			break
+		}
+	}
+	return p.predicted
+}
+
+// Apply predicts the effect of a command that was just sent
+// Returns the state to render, or nil if there is nothing to render yet
+func (p *Predictor) Apply(cmdType protocol.MessageType) *protocol.StateMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mirror == nil {
+		return p.predicted
+	}
+	p.applyLocked(cmdType)
+	return p.predicted
+}
+
+// applyLocked applies one command to the mirror and refreshes the predicted state
+// Only moves that cannot lock the piece are predicted; returns false for any
+// other command so replay stops where the outcome depends on the server
+// Must be called with mu held
+func (p *Predictor) applyLocked(cmdType protocol.MessageType) bool {
+	switch cmdType {
+	case protocol.MessageTypeMoveLeft:
+		p.mirror.MoveLeft()
+	case protocol.MessageTypeMoveRight:
+		p.mirror.MoveRight()
+	case protocol.MessageTypeRotate:
+		p.mirror.Rotate()
+	case protocol.MessageTypeRotate180:
+		p.mirror.Rotate180()
+	case protocol.MessageTypeMoveDown:
+		// A soft drop onto the stack locks the piece, which only the server can resolve
+		cur := p.mirror.GetCurrentPiece()
+		if p.mirror.GetBoard().CheckCollision(cur.X, cur.Y+1, cur.GetShape()) {
+			return false
+		}
+		p.mirror.MoveDown()
+	default:
+		return false
+	}
+
+	cur := p.mirror.GetCurrentPiece()
+	predicted := *p.server
+	predicted.CurrentPiece = protocol.PieceData{Type: cur.Type, Color: cur.Color, X: cur.X, Y: cur.Y, Rotation: cur.Rotation}
+	p.predicted = &predicted
+	return true
+}
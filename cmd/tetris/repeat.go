@@ -0,0 +1,53 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+// repeatGap is the longest pause between key events that still counts as the
+// terminal repeating a held key; terminals report presses, not releases
+const repeatGap = 100 * time.Millisecond
+
+// RepeatFilter applies delayed auto shift (DAS) and the auto repeat rate (ARR)
+// to the key repeats the terminal sends while a move key is held
+// The terminal's own repeat delay comes first, so DAS only lengthens it
+type RepeatFilter struct {
+	DAS time.Duration // Repeats are dropped until the key was held this long
+	ARR time.Duration // Minimum time between repeated moves (0 = every repeat)
+
+	action    tui.Action
+	pressedAt time.Time
+	lastEvent time.Time
+	lastMove  time.Time
+}
+
+// repeatable are the actions DAS and ARR apply to
+var repeatable = map[tui.Action]bool{
+	tui.ActionMoveLeft:  true,
+	tui.ActionMoveRight: true,
+	tui.ActionSoftDrop:  true,
+}
+
+// Allow reports whether a key event for action at now should be acted on
+func (f *RepeatFilter) Allow(action tui.Action, now time.Time) bool {
+	if !repeatable[action] {
+		return true
+	}
+
+	held := action == f.action && now.Sub(f.lastEvent) <= repeatGap
+	f.lastEvent = now
+	if !held {
+		f.action = action
+		f.pressedAt = now
+		f.lastMove = now
+		return true
+	}
+
+	if now.Sub(f.pressedAt) < f.DAS || now.Sub(f.lastMove) < f.ARR {
+		return false
+	}
+	f.lastMove = now
+	return true
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// SavedGame is a game saved with the save key, continued with --continue
+// Snapshot is the server's saved_game data, sent back in load_game
+type SavedGame struct {
+	Server   string          `json:"server"` // Server the game was played on
+	Mode     game.Mode       `json:"mode"`
+	Score    int             `json:"score"`
+	SavedAt  time.Time       `json:"saved_at"`
+	Snapshot json.RawMessage `json:"snapshot"`
+}
+
+// defaultSavedGamePath returns $XDG_CONFIG_HOME/tetris/saved-game.json (or the OS equivalent)
+func defaultSavedGamePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tetris", "saved-game.json"), nil
+}
+
+// LoadSavedGame loads the saved game file at path
+// Returns nil without an error if no game was saved
+func LoadSavedGame(path string) (*SavedGame, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved SavedGame
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &saved, nil
+}
+
+// Save writes the saved game to path, replacing any game saved before
+func (s *SavedGame) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/tui"
+)
+
+// pieceOrder is the order piece counts are listed on the result card
+var pieceOrder = []string{"I", "O", "T", "S", "Z", "J", "L"}
+
+// ResultCard formats a finished game as a plain-text card for pasting into
+// chat, with a link to play the same piece sequence on the server at wsURL
+func ResultCard(msg protocol.GameOverMessage, name, wsURL string) string {
+	elapsed := time.Duration(msg.ElapsedMs) * time.Millisecond
+
+	mode := msg.Mode
+	if mode == "" {
+		mode = "marathon"
+	}
+	if msg.Completed {
+		mode += " (cleared)"
+	}
+
+	pps := 0.0
+	if elapsed > 0 {
+		pps = float64(msg.Pieces) / elapsed.Seconds()
+	}
+
+	counts := make([]string, 0, len(pieceOrder))
+	for _, t := range pieceOrder {
+		counts = append(counts, fmt.Sprintf("%s:%d", t, msg.PieceCounts[t]))
+	}
+
+	title := "TETRIS RESULT"
+	if name != "" {
+		title += " - " + name
+	}
+
+	lines := []string{
+		title,
+		fmt.Sprintf("Score  %d", msg.Score),
+		fmt.Sprintf("Mode   %s", mode),
+		fmt.Sprintf("Time   %s", tui.FormatDuration(elapsed)),
+		fmt.Sprintf("Level  %d   Lines %d", msg.Level, msg.Lines),
+		fmt.Sprintf("Pieces %d   PPS %.2f", msg.Pieces, pps),
+		strings.Join(counts, " "),
+		fmt.Sprintf("Seed   %d (%s)", msg.Seed, msg.Ruleset),
+	}
+	if link := replayLink(wsURL, msg.Seed); link != "" {
+		lines = append(lines, "Replay "+link)
+	}
+
+	width := 0
+	for _, line := range lines {
+		if n := len([]rune(line)); n > width {
+			width = n
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("+" + strings.Repeat("-", width+2) + "+\n")
+	for i, line := range lines {
+		sb.WriteString("| " + line + strings.Repeat(" ", width-len([]rune(line))) + " |\n")
+		if i == 0 {
+			sb.WriteString("+" + strings.Repeat("-", width+2) + "+\n")
+		}
+	}
+	sb.WriteString("+" + strings.Repeat("-", width+2) + "+\n")
+	return sb.String()
+}
+
+// replayLink returns the address of the server's web client joining a match
+// on seed, so whoever opens it plays the same piece sequence and can race the
+// result; "" if the server address is not a WebSocket URL or there is no seed
+func replayLink(wsURL string, seed int64) string {
+	u, err := url.Parse(wsURL)
+	if err != nil || seed == 0 {
+		return ""
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return ""
+	}
+	s := strconv.FormatInt(seed, 10)
+	u.Path = "/"
+	u.RawQuery = url.Values{"match": {"replay-" + s}, "seed": {s}}.Encode()
+	return u.String()
+}
+
+// copyToClipboard asks the terminal to put text on the system clipboard using OSC 52
+// Terminals without OSC 52 support silently ignore the sequence
+func copyToClipboard(w io.Writer, text string) error {
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
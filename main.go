@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ican2002/tetris/pkg/clock"
 	"github.com/ican2002/tetris/pkg/game"
 )
 
@@ -11,8 +12,9 @@ func main() {
 	fmt.Println("=== Tetris Game Engine Demo ===")
 	fmt.Println()
 
-	// Create a new game
-	g := game.New()
+	// Create a new game on a fake clock so the demo need not wait for gravity
+	clk := clock.NewFake(time.Now())
+	g := game.NewWithConfig(game.Config{Clock: clk})
 
 	fmt.Printf("Game initialized!\n")
 	fmt.Printf("State: %s\n", g.GetState())
@@ -48,7 +50,7 @@ func main() {
 	fmt.Println("Running game loop for 5 ticks...")
 
 	for i := 0; i < 5; i++ {
-		time.Sleep(1100 * time.Millisecond)
+		clk.Advance(1100 * time.Millisecond)
 		updated := g.Update()
 		if updated {
 			fmt.Printf("Tick %d: Piece moved down, now at Y=%d\n", i+1, g.GetCurrentPiece().Y)
@@ -0,0 +1,190 @@
+// Package accounts stores registered players and their lifetime statistics
+// in an embedded database file
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ErrNotFound is returned for player ids that were never registered
+	ErrNotFound = errors.New("accounts: player not found")
+	// ErrBadToken is returned when a token does not match the player
+	ErrBadToken = errors.New("accounts: invalid token")
+)
+
+// playersBucket holds one record per player, keyed by id
+var playersBucket = []byte("players")
+
+// record is a player as stored in the database; only a hash of the token is kept
+type record struct {
+	protocol.PlayerProfile
+	TokenHash string `json:"token_hash"`
+}
+
+// Store is a database of registered players; safe for concurrent use
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the database at path, creating it if needed
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(playersBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Register creates a player with a new id and token
+func (s *Store) Register(name string, now time.Time) (protocol.PlayerRegistered, error) {
+	name, err := protocol.NormalizeName(name)
+	if err != nil {
+		return protocol.PlayerRegistered{}, err
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		return protocol.PlayerRegistered{}, err
+	}
+
+	var rec record
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(playersBucket)
+
+		// Ids are short, so retry on the unlikely collision
+		var id string
+		for id == "" || b.Get([]byte(id)) != nil {
+			if id, err = randomHex(6); err != nil {
+				return err
+			}
+		}
+
+		rec = record{
+			PlayerProfile: protocol.PlayerProfile{ID: id, Name: name, Rating: DefaultRating, CreatedAt: now},
+			TokenHash:     hashToken(token),
+		}
+		return put(b, rec)
+	})
+	if err != nil {
+		return protocol.PlayerRegistered{}, err
+	}
+	return protocol.PlayerRegistered{Token: token, Profile: rec.PlayerProfile}, nil
+}
+
+// Get returns a player's profile
+func (s *Store) Get(id string) (protocol.PlayerProfile, error) {
+	var rec record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = get(tx.Bucket(playersBucket), id)
+		return err
+	})
+	return rec.PlayerProfile, err
+}
+
+// Authenticate returns the profile of the player if token is theirs
+func (s *Store) Authenticate(id, token string) (protocol.PlayerProfile, error) {
+	var rec record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = get(tx.Bucket(playersBucket), id)
+		return err
+	})
+	if err != nil {
+		return protocol.PlayerProfile{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(rec.TokenHash), []byte(hashToken(token))) != 1 {
+		return protocol.PlayerProfile{}, ErrBadToken
+	}
+	return rec.PlayerProfile, nil
+}
+
+// RecordGame adds a finished game to a player's lifetime statistics
+func (s *Store) RecordGame(id string, score, lines int, at time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(playersBucket)
+		rec, err := get(b, id)
+		if err != nil {
+			return err
+		}
+
+		rec.GamesPlayed++
+		rec.TotalLines += lines
+		if score > rec.BestScore {
+			rec.BestScore = score
+		}
+		rec.LastPlayed = at
+		return put(b, rec)
+	})
+}
+
+// get reads a player record from the bucket
+func get(b *bolt.Bucket, id string) (record, error) {
+	data := b.Get([]byte(id))
+	if data == nil {
+		return record{}, ErrNotFound
+	}
+	return decode(data)
+}
+
+// decode parses a stored player record
+func decode(data []byte) (record, error) {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return record{}, err
+	}
+	// Players registered before ratings start at the default
+	if rec.Rating == 0 {
+		rec.Rating = DefaultRating
+	}
+	return rec, nil
+}
+
+// put writes a player record to the bucket
+func put(b *bolt.Bucket, rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(rec.ID), data)
+}
+
+// hashToken returns the hex SHA-256 of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
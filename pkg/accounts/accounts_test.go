@@ -0,0 +1,84 @@
+package accounts
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestStore opens a store in a temporary directory
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "players.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+// TestRegisterAndRecord verifies that registered players keep their statistics across restarts
+func TestRegisterAndRecord(t *testing.T) {
+	s, path := openTestStore(t)
+	now := time.Unix(1000, 0).UTC()
+
+	reg, err := s.Register("  alice ", now)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if reg.Profile.Name != "alice" || reg.Profile.ID == "" || reg.Token == "" {
+		t.Fatalf("Register() = %+v, want an id and token for alice", reg)
+	}
+
+	if err := s.RecordGame(reg.Profile.ID, 1200, 8, now.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordGame() error = %v", err)
+	}
+	if err := s.RecordGame(reg.Profile.ID, 500, 3, now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("RecordGame() error = %v", err)
+	}
+
+	// Reopen to check the statistics were written to disk
+	s.Close()
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	p, err := s.Get(reg.Profile.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if p.GamesPlayed != 2 || p.TotalLines != 11 || p.BestScore != 1200 {
+		t.Errorf("profile = %+v, want 2 games, 11 lines, best 1200", p)
+	}
+	if !p.LastPlayed.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("LastPlayed = %v, want %v", p.LastPlayed, now.Add(2*time.Minute))
+	}
+}
+
+// TestAuthenticate verifies that only the issued token is accepted
+func TestAuthenticate(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	reg, err := s.Register("bob", time.Now())
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, err := s.Authenticate(reg.Profile.ID, reg.Token); err != nil {
+		t.Errorf("Authenticate() with the issued token error = %v", err)
+	}
+	if _, err := s.Authenticate(reg.Profile.ID, "wrong"); !errors.Is(err, ErrBadToken) {
+		t.Errorf("Authenticate() with a wrong token error = %v, want %v", err, ErrBadToken)
+	}
+	if _, err := s.Authenticate("missing", reg.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Authenticate() of an unknown player error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := s.Register("", time.Now()); err == nil {
+		t.Error("Register() with an empty name succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+package accounts
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultRating is a player's rating before their first rated match
+const DefaultRating = 1500
+
+// kFactor is the largest rating change a match can cause
+const kFactor = 32
+
+// ErrDuplicatePlayer is returned when a player appears twice in a match
+var ErrDuplicatePlayer = errors.New("accounts: player listed twice in a match")
+
+// MatchResult is a registered player's final score in a match
+type MatchResult struct {
+	PlayerID string
+	Score    int
+}
+
+// ExpectedScore returns the chance that a player rated a beats a player rated b
+func ExpectedScore(a, b int) float64 {
+	return 1 / (1 + math.Pow(10, float64(b-a)/400))
+}
+
+// RatingChanges returns the Elo rating change of each player of a match
+// Every pair of players is scored as a head-to-head game won by the higher
+// score, and each change is averaged over the player's opponents so larger
+// matches do not move ratings further
+func RatingChanges(ratings, scores []int) []int {
+	changes := make([]int, len(ratings))
+	if len(ratings) < 2 {
+		return changes
+	}
+
+	for i := range ratings {
+		var sum float64
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+			actual := 0.5
+			switch {
+			case scores[i] > scores[j]:
+				actual = 1
+			case scores[i] < scores[j]:
+				actual = 0
+			}
+			sum += actual - ExpectedScore(ratings[i], ratings[j])
+		}
+		changes[i] = int(math.Round(kFactor * sum / float64(len(ratings)-1)))
+	}
+	return changes
+}
+
+// RateMatch updates the ratings of the players of a finished match and
+// returns their new profiles and rating changes in the order of results
+func (s *Store) RateMatch(results []MatchResult) ([]protocol.PlayerProfile, []int, error) {
+	profiles := make([]protocol.PlayerProfile, len(results))
+	var changes []int
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(playersBucket)
+
+		seen := make(map[string]bool, len(results))
+		recs := make([]record, len(results))
+		ratings := make([]int, len(results))
+		scores := make([]int, len(results))
+		for i, r := range results {
+			if seen[r.PlayerID] {
+				return ErrDuplicatePlayer
+			}
+			seen[r.PlayerID] = true
+
+			rec, err := get(b, r.PlayerID)
+			if err != nil {
+				return err
+			}
+			recs[i] = rec
+			ratings[i] = rec.Rating
+			scores[i] = r.Score
+		}
+
+		changes = RatingChanges(ratings, scores)
+		for i, change := range changes {
+			recs[i].Rating += change
+			recs[i].RatedGames++
+			if err := put(b, recs[i]); err != nil {
+				return err
+			}
+			profiles[i] = recs[i].PlayerProfile
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return profiles, changes, nil
+}
+
+// Ladder returns up to n players who have played a rated match, highest rating first
+func (s *Store) Ladder(n int) ([]protocol.PlayerProfile, error) {
+	var ladder []protocol.PlayerProfile
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(playersBucket).ForEach(func(_, v []byte) error {
+			rec, err := decode(v)
+			if err != nil {
+				return err
+			}
+			if rec.RatedGames > 0 {
+				ladder = append(ladder, rec.PlayerProfile)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(ladder, func(i, j int) bool {
+		return ladder[i].Rating > ladder[j].Rating
+	})
+	if len(ladder) > n {
+		ladder = ladder[:n]
+	}
+	return ladder, nil
+}
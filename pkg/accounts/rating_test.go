@@ -0,0 +1,72 @@
+package accounts
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestRatingChanges verifies Elo updates for head-to-head and larger matches
+func TestRatingChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratings []int
+		scores  []int
+		want    []int
+	}{
+		{"equal players, first wins", []int{1500, 1500}, []int{900, 400}, []int{16, -16}},
+		{"tie", []int{1500, 1500}, []int{500, 500}, []int{0, 0}},
+		{"favorite wins", []int{1900, 1500}, []int{900, 400}, []int{3, -3}},
+		{"upset", []int{1500, 1900}, []int{900, 400}, []int{29, -29}},
+		{"three players", []int{1500, 1500, 1500}, []int{300, 200, 100}, []int{16, 0, -16}},
+		{"alone", []int{1500}, []int{900}, []int{0}},
+	}
+
+	for _, tt := range tests {
+		if got := RatingChanges(tt.ratings, tt.scores); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: RatingChanges() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestRateMatch verifies that rated matches update profiles and the ladder
+func TestRateMatch(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	var ids []string
+	for _, name := range []string{"alice", "bob", "carol"} {
+		reg, err := s.Register(name, time.Now())
+		if err != nil {
+			t.Fatalf("Register(%q) error = %v", name, err)
+		}
+		if reg.Profile.Rating != DefaultRating {
+			t.Errorf("new player rating = %d, want %d", reg.Profile.Rating, DefaultRating)
+		}
+		ids = append(ids, reg.Profile.ID)
+	}
+
+	profiles, changes, err := s.RateMatch([]MatchResult{{ids[0], 900}, {ids[1], 400}})
+	if err != nil {
+		t.Fatalf("RateMatch() error = %v", err)
+	}
+	if profiles[0].Rating != 1516 || profiles[1].Rating != 1484 {
+		t.Errorf("ratings = %d, %d, want 1516, 1484", profiles[0].Rating, profiles[1].Rating)
+	}
+	if changes[0] != 16 || changes[1] != -16 {
+		t.Errorf("changes = %v, want [16 -16]", changes)
+	}
+
+	ladder, err := s.Ladder(10)
+	if err != nil {
+		t.Fatalf("Ladder() error = %v", err)
+	}
+	// Carol has not played a rated match
+	if len(ladder) != 2 || ladder[0].Name != "alice" || ladder[1].Name != "bob" {
+		t.Errorf("Ladder() = %+v, want alice then bob", ladder)
+	}
+
+	if _, _, err := s.RateMatch([]MatchResult{{ids[0], 1}, {ids[0], 2}}); !errors.Is(err, ErrDuplicatePlayer) {
+		t.Errorf("RateMatch() with a duplicate player error = %v, want %v", err, ErrDuplicatePlayer)
+	}
+}
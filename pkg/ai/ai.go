@@ -0,0 +1,229 @@
+package ai
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Difficulty controls how often the bot picks a worse move and how fast it plays
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+// ParseDifficulty converts a string to a Difficulty
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch d := Difficulty(s); d {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown difficulty: %q", s)
+	}
+}
+
+// MoveDelay returns how long the bot waits before placing each piece
+func (d Difficulty) MoveDelay() time.Duration {
+	switch d {
+	case DifficultyEasy:
+		return 600 * time.Millisecond
+	case DifficultyMedium:
+		return 300 * time.Millisecond
+	default:
+		return 100 * time.Millisecond
+	}
+}
+
+// mistakeChance is the probability of not playing the best move, and
+// mistakeRange how many of the top moves a mistake picks from
+var (
+	mistakeChance = map[Difficulty]float64{DifficultyEasy: 0.3, DifficultyMedium: 0.1, DifficultyHard: 0}
+	mistakeRange  = map[Difficulty]int{DifficultyEasy: 5, DifficultyMedium: 3, DifficultyHard: 1}
+)
+
+// Weights are the heuristic weights applied to each board feature
+type Weights struct {
+	AggregateHeight float64
+	Lines           float64
+	Holes           float64
+	Bumpiness       float64
+}
+
+// DefaultWeights are well-known weights for the four-feature heuristic
+var DefaultWeights = Weights{
+	AggregateHeight: -0.510066,
+	Lines:           0.760666,
+	Holes:           -0.35663,
+	Bumpiness:       -0.184483,
+}
+
+// Move is a placement: the piece rotated Rotation times and dropped at column X
+type Move struct {
+	Rotation int
+	X        int
+	Score    float64
+}
+
+// Bot chooses placements with a heuristic evaluation of the resulting board
+type Bot struct {
+	weights    Weights
+	difficulty Difficulty
+	rnd        *rand.Rand
+}
+
+// New creates a bot with the default weights
+func New(difficulty Difficulty) *Bot {
+	return &Bot{
+		weights:    DefaultWeights,
+		difficulty: difficulty,
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetWeights replaces the heuristic weights
+func (b *Bot) SetWeights(w Weights) {
+	b.weights = w
+}
+
+// Difficulty returns the bot's difficulty
+func (b *Bot) Difficulty() Difficulty {
+	return b.difficulty
+}
+
+// Moves returns every legal placement of p on brd, best first
+func (b *Bot) Moves(brd *board.Board, p *piece.Piece) []Move {
+	var moves []Move
+
+	rotations := 4
+	if p.Type == piece.TypeO {
+		rotations = 1
+	}
+
+	for rot := 0; rot < rotations; rot++ {
+		shape := (&piece.Piece{Type: p.Type, Rotation: rot}).GetShape()
+		for x := 0; x <= brd.Width()-shape.Width(); x++ {
+			y := p.Y
+			if brd.CheckCollision(x, y, shape) {
+				continue
+			}
+			for !brd.CheckCollision(x, y+1, shape) {
+				y++
+			}
+
+			result := brd.Clone()
+			result.LockPiece(&piece.Piece{Type: p.Type, Color: p.Color, X: x, Y: y, Rotation: rot})
+			lines := result.ClearLines().Count()
+			moves = append(moves, Move{Rotation: rot, X: x, Score: b.evaluate(result, lines)})
+		}
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Score > moves[j].Score })
+	return moves
+}
+
+// BestMove returns the placement the bot plays for p, including the
+// deliberate mistakes of its difficulty; false if p cannot be placed
+func (b *Bot) BestMove(brd *board.Board, p *piece.Piece) (Move, bool) {
+	moves := b.Moves(brd, p)
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	if b.rnd.Float64() < mistakeChance[b.difficulty] {
+		n := mistakeRange[b.difficulty]
+		if n > len(moves) {
+			n = len(moves)
+		}
+		return moves[b.rnd.Intn(n)], true
+	}
+	return moves[0], true
+}
+
+// evaluate scores a board after a placement that cleared lines
+func (b *Bot) evaluate(brd *board.Board, lines int) float64 {
+	aggregate := 0
+	for _, h := range brd.Heights() {
+		aggregate += h
+	}
+
+	return b.weights.AggregateHeight*float64(aggregate) +
+		b.weights.Lines*float64(lines) +
+		b.weights.Holes*float64(brd.Holes()) +
+		b.weights.Bumpiness*float64(brd.Bumpiness())
+}
+
+// Play places the current piece of g at the bot's chosen position
+// Returns false if the game is not playing or the piece cannot be placed
+func (b *Bot) Play(g *game.Game) bool {
+	if !g.IsPlaying() {
+		return false
+	}
+
+	current := g.GetCurrentPiece()
+	move, ok := b.BestMove(g.GetBoard(), current)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < move.Rotation; i++ {
+		g.Rotate()
+	}
+
+	// Wall kicks can shift the piece, so steer from its actual position
+	for g.GetCurrentPiece().X > move.X && g.MoveLeft() {
+	}
+	for g.GetCurrentPiece().X < move.X && g.MoveRight() {
+	}
+
+	g.HardDrop()
+	return true
+}
+
+// Commands returns the commands that place the piece in state at the bot's
+// chosen position, for driving a remote game
+func (b *Bot) Commands(state *protocol.StateMessage) []protocol.MessageType {
+	brd := BoardFromState(state)
+	cp := state.CurrentPiece
+	current := &piece.Piece{Type: cp.Type, Color: cp.Color, X: cp.X, Y: cp.Y, Rotation: cp.Rotation}
+
+	// Plan from the piece's spawn orientation
+	spawn := &piece.Piece{Type: cp.Type, Color: cp.Color, X: cp.X, Y: cp.Y}
+	move, ok := b.BestMove(brd, spawn)
+	if !ok {
+		return []protocol.MessageType{protocol.MessageTypeHardDrop}
+	}
+
+	var cmds []protocol.MessageType
+	for i := 0; i < (move.Rotation-current.Rotation+4)%4; i++ {
+		cmds = append(cmds, protocol.MessageTypeRotate)
+	}
+	for x := current.X; x > move.X; x-- {
+		cmds = append(cmds, protocol.MessageTypeMoveLeft)
+	}
+	for x := current.X; x < move.X; x++ {
+		cmds = append(cmds, protocol.MessageTypeMoveRight)
+	}
+	return append(cmds, protocol.MessageTypeHardDrop)
+}
+
+// BoardFromState rebuilds a board from the cells in a state message
+func BoardFromState(state *protocol.StateMessage) *board.Board {
+	brd := board.NewSized(state.Width, state.Height)
+	for y, row := range state.Board {
+		for x, color := range row {
+			if color != "" {
+				brd.SetCell(x, y, piece.Color(color))
+			}
+		}
+	}
+	return brd
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestBestMoveCompletesLine verifies that the bot fills the only gap in a nearly full row
+func TestBestMoveCompletesLine(t *testing.T) {
+	b, err := board.NewBuilder().Rows("XXXXXXXXX.").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// A vertical I piece is the only placement that clears the row
+	move, ok := New(DifficultyHard).BestMove(b, piece.New(piece.TypeI))
+	if !ok {
+		t.Fatal("BestMove() found no placement")
+	}
+	if move.X != 9 || move.Rotation%2 != 1 {
+		t.Errorf("BestMove() = %+v, want vertical I at column 9", move)
+	}
+}
+
+// TestPlaySurvives verifies that a hard bot keeps a seeded game alive
+func TestPlaySurvives(t *testing.T) {
+	g := game.NewWithSeed(1)
+	bot := New(DifficultyHard)
+
+	const pieces = 200
+	for i := 0; i < pieces; i++ {
+		if !bot.Play(g) {
+			t.Fatalf("game ended after %d pieces", i)
+		}
+	}
+	if g.GetLines() == 0 {
+		t.Error("bot cleared no lines")
+	}
+}
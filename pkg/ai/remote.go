@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// setupTimeout is how long the server may take to answer the bot's name and
+// mode selection
+const setupTimeout = 10 * time.Second
+
+// RemoteOptions configures a bot playing on a server
+type RemoteOptions struct {
+	Name        string    // Display name registered with the server (empty = none)
+	Mode        game.Mode // Game mode to select (empty = server default)
+	AutoRestart bool      // Start a new game after each game over
+}
+
+// PlayRemote connects client to its server and plays until stop is closed,
+// the connection is lost for good, or the game ends without AutoRestart
+func (b *Bot) PlayRemote(client *wsclient.Client, opts RemoteOptions, stop <-chan struct{}) error {
+	client.SetCompactState(true)
+	messages := client.Subscribe(protocol.MessageTypeState, protocol.MessageTypeCompactState, protocol.MessageTypeGameOver)
+	defer messages.Close()
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	// Wait for the server to accept the setup, so a rejected name or mode
+	// fails instead of playing on with the defaults
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	if opts.Name != "" {
+		if _, err := client.Call(ctx, protocol.ControlMessage{Type: protocol.MessageTypeSetName, Name: opts.Name}); err != nil {
+			return fmt.Errorf("set name: %w", err)
+		}
+	}
+	if opts.Mode != "" {
+		if _, err := client.Call(ctx, protocol.ControlMessage{Type: protocol.MessageTypeSelectMode, Mode: string(opts.Mode)}); err != nil {
+			return fmt.Errorf("select mode: %w", err)
+		}
+	}
+
+	// The board changes exactly when a new piece needs placing
+	var plannedBoard string
+
+	for {
+		var msg *protocol.Message
+		var ok bool
+		select {
+		case <-stop:
+			return nil
+		case msg, ok = <-messages.C():
+			if !ok {
+				if messages.Lagged() {
+					return errors.New("bot fell behind the server's messages")
+				}
+				return nil
+			}
+		}
+
+		switch msg.Type {
+		case protocol.MessageTypeState, protocol.MessageTypeCompactState:
+			var state protocol.StateMessage
+			if err := decode(msg.Data, &state); err != nil {
+				return err
+			}
+			if err := state.ExpandBoard(); err != nil {
+				return err
+			}
+			if state.State != game.StatePlaying.String() {
+				continue
+			}
+			key := fmt.Sprint(state.Board)
+			if key == plannedBoard {
+				continue
+			}
+			plannedBoard = key
+
+			time.Sleep(b.difficulty.MoveDelay())
+			for _, cmd := range b.Commands(&state) {
+				if err := sendCommand(client, protocol.ControlMessage{Type: cmd}); err != nil {
+					return err
+				}
+			}
+
+		case protocol.MessageTypeGameOver:
+			if !opts.AutoRestart {
+				return nil
+			}
+			plannedBoard = ""
+			time.Sleep(time.Second)
+			if err := sendCommand(client, protocol.ControlMessage{Type: protocol.MessageTypeRestart}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendCommand sends a control message to the server
+func sendCommand(client *wsclient.Client, cmd protocol.ControlMessage) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return client.Send(data)
+}
+
+// decode converts a decoded message payload into a typed message
+func decode(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,73 @@
+// Package audio plays sound effects for game events
+//
+// Effects are synthesized, so no sound files are needed. Playback uses oto,
+// which needs cgo and ALSA on Linux, so it is only built with the sound tag:
+//
+//	go build -tags sound ./cmd/tetris
+package audio
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// SampleRate is the sample rate of synthesized effects in Hz
+const SampleRate = 44100
+
+// ErrUnavailable is returned when the binary was built without audio support
+var ErrUnavailable = errors.New("built without sound support (rebuild with -tags sound)")
+
+// backend plays mono float32 samples at SampleRate
+type backend interface {
+	play(samples []float32) error
+	close() error
+}
+
+// effects maps game events to the sounds played for them
+var effects = map[wsclient.EventType]Sound{
+	wsclient.EventLineClear: {{NoteC5, 60}, {NoteE5, 80}},
+	wsclient.EventTetris:    {{NoteC5, 70}, {NoteE5, 70}, {NoteG5, 70}, {NoteC6, 160}},
+	wsclient.EventLevelUp:   {{NoteG4, 90}, {NoteC5, 90}, {NoteG5, 140}},
+	wsclient.EventGameOver:  {{NoteE4, 200}, {NoteC4, 200}, {NoteA3, 400}},
+}
+
+// Player plays the effect of each game event it receives
+type Player struct {
+	out    backend
+	volume float64
+}
+
+// New opens the audio device; volume ranges from 0 (silent) to 1
+func New(volume float64) (*Player, error) {
+	if volume < 0 || volume > 1 {
+		return nil, fmt.Errorf("volume %v out of range [0, 1]", volume)
+	}
+	out, err := openBackend()
+	if err != nil {
+		return nil, err
+	}
+	return &Player{out: out, volume: volume}, nil
+}
+
+// Play plays the effect for an event; events without an effect are ignored
+func (p *Player) Play(ev wsclient.Event) error {
+	sound, ok := effects[ev.Type]
+	if !ok || p.volume == 0 {
+		return nil
+	}
+	return p.out.play(sound.Render(SampleRate, p.volume))
+}
+
+// Run plays events until the channel is closed
+func (p *Player) Run(events <-chan wsclient.Event) {
+	for ev := range events {
+		p.Play(ev)
+	}
+}
+
+// Close releases the audio device
+func (p *Player) Close() error {
+	return p.out.close()
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/wsclient"
+)
+
+// recordingBackend records the length of every effect played
+type recordingBackend struct {
+	played []int
+}
+
+func (b *recordingBackend) play(samples []float32) error {
+	b.played = append(b.played, len(samples))
+	return nil
+}
+
+func (b *recordingBackend) close() error { return nil }
+
+// TestPlayerEffects verifies that every event type has an effect and that muted players stay silent
+func TestPlayerEffects(t *testing.T) {
+	out := &recordingBackend{}
+	p := &Player{out: out, volume: 0.5}
+
+	for _, typ := range []wsclient.EventType{
+		wsclient.EventLineClear, wsclient.EventTetris, wsclient.EventLevelUp, wsclient.EventGameOver,
+	} {
+		p.Play(wsclient.Event{Type: typ})
+	}
+	if len(out.played) != 4 {
+		t.Fatalf("played %d effects, want 4", len(out.played))
+	}
+	for i, n := range out.played {
+		if n == 0 {
+			t.Errorf("effect %d is empty", i)
+		}
+	}
+
+	p.volume = 0
+	p.Play(wsclient.Event{Type: wsclient.EventTetris})
+	if len(out.played) != 4 {
+		t.Error("muted player played an effect")
+	}
+}
+
+// TestRender verifies the length and amplitude of a synthesized sound
+func TestRender(t *testing.T) {
+	s := Sound{{NoteA3, 100}, {NoteC5, 50}}
+	samples := s.Render(SampleRate, 0.8)
+
+	if want := SampleRate * 150 / 1000; len(samples) != want {
+		t.Errorf("len(samples) = %d, want %d", len(samples), want)
+	}
+
+	var peak float32
+	for _, v := range samples {
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	if peak != 0.4 {
+		t.Errorf("peak = %v, want 0.4", peak)
+	}
+}
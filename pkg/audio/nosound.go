@@ -0,0 +1,8 @@
+//go:build !sound
+
+package audio
+
+// openBackend fails as playback is only built with the sound tag
+func openBackend() (backend, error) {
+	return nil, ErrUnavailable
+}
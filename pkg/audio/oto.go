@@ -0,0 +1,70 @@
+//go:build sound
+
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// otoBackend plays samples on the default audio device
+type otoBackend struct {
+	ctx     *oto.Context
+	playing []*oto.Player // Kept until finished so they are not collected mid-effect
+	mu      sync.Mutex
+}
+
+// openBackend opens the default audio device
+func openBackend() (backend, error) {
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   SampleRate,
+		ChannelCount: 1,
+		Format:       oto.FormatFloat32LE,
+	})
+	if err != nil {
+		return nil, err
+	}
+	<-ready
+	return &otoBackend{ctx: ctx}, nil
+}
+
+// play starts playing the samples without waiting for them to finish
+func (b *otoBackend) play(samples []float32) error {
+	pcm := make([]byte, 4*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(pcm[4*i:], math.Float32bits(s))
+	}
+
+	p := b.ctx.NewPlayer(bytes.NewReader(pcm))
+	p.Play()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	playing := b.playing[:0]
+	for _, old := range b.playing {
+		if old.IsPlaying() {
+			playing = append(playing, old)
+		} else {
+			old.Close()
+		}
+	}
+	b.playing = append(playing, p)
+	return nil
+}
+
+// close stops every effect; the oto context itself cannot be closed
+func (b *otoBackend) close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, p := range b.playing {
+		p.Close()
+	}
+	b.playing = nil
+	return b.ctx.Err()
+}
@@ -0,0 +1,51 @@
+package audio
+
+import "math"
+
+// Note frequencies in Hz
+const (
+	NoteA3 = 220.00
+	NoteC4 = 261.63
+	NoteE4 = 329.63
+	NoteG4 = 392.00
+	NoteC5 = 523.25
+	NoteE5 = 659.25
+	NoteG5 = 783.99
+	NoteC6 = 1046.50
+)
+
+// Tone is a note held for a number of milliseconds
+type Tone struct {
+	Freq float64
+	Ms   int
+}
+
+// Sound is a sequence of tones played one after another
+type Sound []Tone
+
+// fadeMs is how long each tone fades in and out, avoiding clicks between tones
+const fadeMs = 5
+
+// Render synthesizes the sound as mono samples in [-volume, volume]
+// Tones are square waves, like the sound chips of classic handhelds
+func (s Sound) Render(sampleRate int, volume float64) []float32 {
+	var samples []float32
+	for _, tone := range s {
+		n := sampleRate * tone.Ms / 1000
+		fade := sampleRate * fadeMs / 1000
+		for i := 0; i < n; i++ {
+			// Only half the range, as square waves sound loud
+			v := 0.5 * volume
+			if math.Mod(float64(i)*tone.Freq/float64(sampleRate), 1) >= 0.5 {
+				v = -v
+			}
+			if i < fade {
+				v *= float64(i) / float64(fade)
+			} else if n-i < fade {
+				v *= float64(n-i) / float64(fade)
+			}
+			samples = append(samples, float32(v))
+		}
+	}
+	return samples
+}
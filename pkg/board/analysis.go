@@ -0,0 +1,112 @@
+package board
+
+import "github.com/ican2002/tetris/pkg/piece"
+
+// Heights returns the height of the stack in each column, measured from the
+// bottom of the board to the highest occupied cell; a stack reaching into the
+// hidden rows is higher than the board
+func (b *Board) Heights() []int {
+	heights := make([]int, b.width)
+	for x := range heights {
+		for y, row := range b.cells {
+			if !row[x].Empty {
+				heights[x] = len(b.cells) - y
+				break
+			}
+		}
+	}
+	return heights
+}
+
+// GetColumnHeights returns the height of the stack in each column, the same
+// as Heights; kept for callers of the earlier name
+func (b *Board) GetColumnHeights() []int {
+	return b.Heights()
+}
+
+// StackHeight returns the height of the highest column of the stack
+func (b *Board) StackHeight() int {
+	height := 0
+	for _, h := range b.Heights() {
+		height = max(height, h)
+	}
+	return height
+}
+
+// Holes returns the number of empty cells covered by an occupied cell higher
+// up their column
+func (b *Board) Holes() int {
+	holes := 0
+	for x := 0; x < b.width; x++ {
+		covered := false
+		for _, row := range b.cells {
+			if !row[x].Empty {
+				covered = true
+			} else if covered {
+				holes++
+			}
+		}
+	}
+	return holes
+}
+
+// Bumpiness returns the sum of the height differences between neighbouring
+// columns, which is low for a flat stack
+func (b *Board) Bumpiness() int {
+	heights := b.Heights()
+	bumpiness := 0
+	for x := 1; x < len(heights); x++ {
+		bumpiness += abs(heights[x] - heights[x-1])
+	}
+	return bumpiness
+}
+
+// Wells returns the depth of the well in each column: how far it lies below
+// the lower of its neighbours, with the walls as high as the board, or 0
+// where it is not below both
+func (b *Board) Wells() []int {
+	heights := b.Heights()
+	wells := make([]int, len(heights))
+	for x, h := range heights {
+		left, right := len(b.cells), len(b.cells)
+		if x > 0 {
+			left = heights[x-1]
+		}
+		if x < len(heights)-1 {
+			right = heights[x+1]
+		}
+		wells[x] = max(min(left, right)-h, 0)
+	}
+	return wells
+}
+
+// HolesUnder returns the empty cells, as {x, y}, that p would cover if it
+// locked where it is: in each of its columns, those below its lowest cell
+// down to the stack or the floor
+func (b *Board) HolesUnder(p *piece.Piece) [][2]int {
+	shape := p.GetShape()
+	var holes [][2]int
+	for c := 0; c < shape.Width(); c++ {
+		bottom := -1
+		for r := range shape {
+			if shape[r][c] == 1 {
+				bottom = r
+			}
+		}
+		if bottom < 0 {
+			continue
+		}
+		x := p.X + c
+		for y := p.Y + bottom + 1; b.IsEmpty(x, y); y++ {
+			holes = append(holes, [2]int{x, y})
+		}
+	}
+	return holes
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package board
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestAnalysis verifies the heights, holes, bumpiness and wells of a few stacks
+func TestAnalysis(t *testing.T) {
+	tests := []struct {
+		name          string
+		rows          []string
+		wantHeights   []int
+		wantHoles     int
+		wantBumpiness int
+		wantWells     []int
+	}{
+		{
+			name:        "empty board",
+			wantHeights: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			wantWells:   []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "heights from the bottom to the highest cell",
+			rows: []string{
+				"X.........",
+				"X.X.......",
+				"XXX......X",
+			},
+			wantHeights:   []int{3, 1, 2, 0, 0, 0, 0, 0, 0, 1},
+			wantBumpiness: 2 + 1 + 2 + 1,
+			wantWells:     []int{0, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "holes under overhangs",
+			rows: []string{
+				"XXX.......",
+				"X.X.......",
+				"..XXXXXXX.",
+			},
+			wantHeights:   []int{3, 3, 3, 1, 1, 1, 1, 1, 1, 0},
+			wantHoles:     3,
+			wantBumpiness: 2 + 1,
+			wantWells:     []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		},
+		{
+			name: "well for an I piece",
+			rows: []string{
+				"XXXX.XXXXX",
+				"XXXX.XXXXX",
+				"XXXX.XXXXX",
+				"XXXX.XXXXX",
+			},
+			wantHeights:   []int{4, 4, 4, 4, 0, 4, 4, 4, 4, 4},
+			wantBumpiness: 8,
+			wantWells:     []int{0, 0, 0, 0, 4, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "wells against the walls",
+			rows: []string{
+				".XXXXXXXX.",
+				".XXXXXXXX.",
+			},
+			wantHeights:   []int{0, 2, 2, 2, 2, 2, 2, 2, 2, 0},
+			wantBumpiness: 4,
+			wantWells:     []int{2, 0, 0, 0, 0, 0, 0, 0, 0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBuilder().Rows(tt.rows...).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			if got := b.Heights(); !reflect.DeepEqual(got, tt.wantHeights) {
+				t.Errorf("Heights() = %v, want %v", got, tt.wantHeights)
+			}
+			if got := b.Holes(); got != tt.wantHoles {
+				t.Errorf("Holes() = %d, want %d", got, tt.wantHoles)
+			}
+			if got := b.Bumpiness(); got != tt.wantBumpiness {
+				t.Errorf("Bumpiness() = %d, want %d", got, tt.wantBumpiness)
+			}
+			if got := b.Wells(); !reflect.DeepEqual(got, tt.wantWells) {
+				t.Errorf("Wells() = %v, want %v", got, tt.wantWells)
+			}
+		})
+	}
+}
+
+// TestStackHeight verifies the stack height is that of the highest column
+func TestStackHeight(t *testing.T) {
+	b, err := NewBuilder().Rows("X.........", "X.X.......", "XXX......X").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if got := b.StackHeight(); got != 3 {
+		t.Errorf("StackHeight() = %d, want 3", got)
+	}
+	if got := New().StackHeight(); got != 0 {
+		t.Errorf("StackHeight() of an empty board = %d, want 0", got)
+	}
+}
+
+// TestHolesUnder verifies the cells a piece would cover are those below its
+// lowest cell in each of its columns, down to the stack
+func TestHolesUnder(t *testing.T) {
+	b, err := NewBuilder().Rows("X.X.......", "XXX.......").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	s := piece.New(piece.TypeS)
+	s.X, s.Y = 0, 16
+	want := [][2]int{{1, 18}, {2, 17}}
+	if got := b.HolesUnder(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("HolesUnder(S at 0,16) = %v, want %v", got, want)
+	}
+
+	o := piece.New(piece.TypeO)
+	o.X, o.Y = 4, 18
+	if got := b.HolesUnder(o); got != nil {
+		t.Errorf("HolesUnder(O on the floor) = %v, want none", got)
+	}
+}
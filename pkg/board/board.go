@@ -5,8 +5,13 @@ import (
 )
 
 const (
-	Width  = 10
-	Height = 20
+	// DefaultWidth is the standard board width
+	DefaultWidth = 10
+	// DefaultHeight is the standard board height
+	DefaultHeight = 20
+	// HiddenRows is the number of rows above the visible board, at y -1 up to
+	// -HiddenRows, where pieces spawn and the stack may grow before topping out
+	HiddenRows = 2
 )
 
 // Cell represents a single cell on the board
@@ -15,29 +20,82 @@ type Cell struct {
 	Empty bool        `json:"empty"`
 }
 
+// IsObstacle returns true for an obstacle cell (piece.ColorWall): it fills its
+// row like any other cell, but stays in place when the row is cleared and
+// cells above fall past it
+func (c Cell) IsObstacle() bool {
+	return !c.Empty && c.Color == piece.ColorWall
+}
+
 // Board represents the Tetris game board
 type Board struct {
-	cells [Height][Width]Cell
+	width  int
+	height int
+	cells  [][]Cell // The hidden rows, then the visible ones; use row(y)
 }
 
-// New creates a new empty board
+// New creates a new empty board of the default size
 func New() *Board {
-	b := &Board{}
-	for y := 0; y < Height; y++ {
-		for x := 0; x < Width; x++ {
-			b.cells[y][x] = Cell{Empty: true}
-		}
+	return NewSized(DefaultWidth, DefaultHeight)
+}
+
+// NewSized creates a new empty board with the given dimensions
+// Non-positive dimensions fall back to the defaults
+func NewSized(width, height int) *Board {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+
+	b := &Board{
+		width:  width,
+		height: height,
+		cells:  make([][]Cell, HiddenRows+height),
+	}
+	for y := range b.cells {
+		b.cells[y] = newEmptyRow(width)
 	}
 	return b
 }
 
+// row returns the cells of row y, which is negative for the hidden rows
+func (b *Board) row(y int) []Cell {
+	return b.cells[y+HiddenRows]
+}
+
+// visible returns the rows of the visible board, top to bottom
+func (b *Board) visible() [][]Cell {
+	return b.cells[HiddenRows:]
+}
+
+// newEmptyRow creates a row of empty cells
+func newEmptyRow(width int) []Cell {
+	row := make([]Cell, width)
+	for x := range row {
+		row[x] = Cell{Empty: true}
+	}
+	return row
+}
+
+// Width returns the number of columns on the board
+func (b *Board) Width() int {
+	return b.width
+}
+
+// Height returns the number of visible rows on the board
+func (b *Board) Height() int {
+	return b.height
+}
+
 // GetCell returns the cell at the given position
 // Returns error if position is out of bounds
 func (b *Board) GetCell(x, y int) (Cell, error) {
 	if !b.isValidPosition(x, y) {
 		return Cell{}, &OutOfBoundsError{X: x, Y: y}
 	}
-	return b.cells[y][x], nil
+	return b.row(y)[x], nil
 }
 
 // SetCell sets the cell at the given position
@@ -45,7 +103,7 @@ func (b *Board) SetCell(x, y int, color piece.Color) error {
 	if !b.isValidPosition(x, y) {
 		return &OutOfBoundsError{X: x, Y: y}
 	}
-	b.cells[y][x] = Cell{Color: color, Empty: false}
+	b.row(y)[x] = Cell{Color: color, Empty: false}
 	return nil
 }
 
@@ -54,7 +112,7 @@ func (b *Board) IsEmpty(x, y int) bool {
 	if !b.isValidPosition(x, y) {
 		return false
 	}
-	return b.cells[y][x].Empty
+	return b.row(y)[x].Empty
 }
 
 // IsOccupied returns true if the cell at (x, y) is occupied
@@ -62,9 +120,10 @@ func (b *Board) IsOccupied(x, y int) bool {
 	return !b.IsEmpty(x, y)
 }
 
-// IsValidPosition checks if a position is within the board boundaries
+// isValidPosition checks if a position is within the board boundaries,
+// including the hidden rows
 func (b *Board) isValidPosition(x, y int) bool {
-	return x >= 0 && x < Width && y >= 0 && y < Height
+	return x >= 0 && x < b.width && y >= -HiddenRows && y < b.height
 }
 
 // CheckCollision checks if placing a piece at (x, y) would cause a collision
@@ -108,50 +167,109 @@ func (b *Board) LockPiece(p *piece.Piece) error {
 	return nil
 }
 
-// ClearLines clears complete rows and returns the number of lines cleared
-func (b *Board) ClearLines() int {
-	linesCleared := 0
+// ClearResult describes the rows ClearLines removed
+type ClearResult struct {
+	// Rows are the indices of the removed rows before the clear, top to bottom
+	Rows []int
+	// Cells are the contents of the removed rows, in the order of Rows;
+	// obstacles among them stay on the board
+	Cells [][]Cell
+}
+
+// Count returns the number of lines cleared
+func (r ClearResult) Count() int {
+	return len(r.Rows)
+}
+
+// ClearLines clears complete rows and returns which rows it cleared
+func (b *Board) ClearLines() ClearResult {
+	var result ClearResult
 
 	// Find complete lines from bottom to top
-	for y := Height - 1; y >= 0; y-- {
+	for y := b.height - 1; y >= -HiddenRows; y-- {
 		if b.isLineComplete(y) {
+			// The rows above moved down one for each row removed below them
+			result.Rows = append(result.Rows, y-len(result.Rows))
+			result.Cells = append(result.Cells, append([]Cell(nil), b.row(y)...))
 			b.removeLine(y)
-			linesCleared++
 			y++ // Recheck this row as everything shifted down
 		}
 	}
 
-	return linesCleared
+	// Report the rows top to bottom, like FullRows
+	for i, j := 0, len(result.Rows)-1; i < j; i, j = i+1, j-1 {
+		result.Rows[i], result.Rows[j] = result.Rows[j], result.Rows[i]
+		result.Cells[i], result.Cells[j] = result.Cells[j], result.Cells[i]
+	}
+	return result
 }
 
-// isLineComplete checks if a row is completely filled
-func (b *Board) isLineComplete(y int) bool {
-	for x := 0; x < Width; x++ {
-		if b.IsEmpty(x, y) {
-			return false
+// FullRows returns the indices of the complete rows, top to bottom, which
+// ClearLines would remove
+func (b *Board) FullRows() []int {
+	var rows []int
+	for y := -HiddenRows; y < b.height; y++ {
+		if b.isLineComplete(y) {
+			rows = append(rows, y)
 		}
 	}
-	return true
+	return rows
 }
 
-// removeLine removes a row and shifts all rows above down
-func (b *Board) removeLine(y int) {
-	// Shift all rows above down
-	for row := y; row > 0; row-- {
-		for x := 0; x < Width; x++ {
-			b.cells[row][x] = b.cells[row-1][x]
+// ClearTopRows empties the hidden rows and the top n visible rows, leaving
+// the rows below in place
+func (b *Board) ClearTopRows(n int) {
+	for y := -HiddenRows; y < n && y < b.height; y++ {
+		b.cells[y+HiddenRows] = newEmptyRow(b.width)
+	}
+}
+
+// IsCleared returns true if every cell on the board but the obstacles is
+// empty (a perfect clear)
+func (b *Board) IsCleared() bool {
+	for _, row := range b.cells {
+		for _, cell := range row {
+			if !cell.Empty && !cell.IsObstacle() {
+				return false
+			}
 		}
 	}
+	return true
+}
+
+// isLineComplete checks if a row is completely filled
+func (b *Board) isLineComplete(y int) bool {
+	return isRowFull(b.row(y))
+}
 
-	// Clear the top row
-	for x := 0; x < Width; x++ {
-		b.cells[0][x] = Cell{Empty: true}
+// removeLine removes a row and shifts all rows above down; obstacles stay in
+// place, in the cleared row as well as above it, and the cells above fall
+// past them
+func (b *Board) removeLine(y int) {
+	for x := 0; x < b.width; x++ {
+		if b.row(y)[x].IsObstacle() {
+			continue
+		}
+		to := y
+		for from := y - 1; from >= -HiddenRows; from-- {
+			if b.row(from)[x].IsObstacle() {
+				continue
+			}
+			b.row(to)[x] = b.row(from)[x]
+			to = from
+		}
+		b.row(to)[x] = Cell{Empty: true}
 	}
 }
 
-// GetCells returns a 2D array of all cells
-func (b *Board) GetCells() [Height][Width]Cell {
-	return b.cells
+// GetCells returns a copy of the visible cells, indexed by row then column
+func (b *Board) GetCells() [][]Cell {
+	cells := make([][]Cell, b.height)
+	for y, row := range b.visible() {
+		cells[y] = make([]Cell, b.width)
+		copy(cells[y], row)
+	}
+	return cells
 }
 
 // OutOfBoundsError represents an error for out of bounds access
@@ -166,11 +284,9 @@ func (e *OutOfBoundsError) Error() string {
 
 // Clone creates a deep copy of the board
 func (b *Board) Clone() *Board {
-	newBoard := New()
-	for y := 0; y < Height; y++ {
-		for x := 0; x < Width; x++ {
-			newBoard.cells[y][x] = b.cells[y][x]
-		}
+	newBoard := NewSized(b.width, b.height)
+	for y, row := range b.cells {
+		copy(newBoard.cells[y], row)
 	}
 	return newBoard
 }
@@ -0,0 +1,80 @@
+package board
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestClearLines verifies ClearLines reports the rows it cleared as they were
+// before the clear, top to bottom, along with their cells
+func TestClearLines(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string // Bottom rows of a 4x6 board, each completed in the last column
+		fill []int    // Rows completed before clearing
+		want []int
+	}{
+		{"nothing full", []string{"XXX.", "XXX."}, nil, nil},
+		{"bottom row", []string{"XXX.", "XXX."}, []int{5}, []int{5}},
+		{"adjacent rows", []string{"XXX.", "XXX.", "XXX."}, []int{4, 5}, []int{4, 5}},
+		{"rows apart", []string{"XXX.", "X.X.", "XXX.", "XX.."}, []int{2, 4}, []int{2, 4}},
+		{"tetris", []string{"XXX.", "XXX.", "XXX.", "XXX."}, []int{2, 3, 4, 5}, []int{2, 3, 4, 5}},
+		{"obstacle in a cleared row", []string{"XXX.", "WXX."}, []int{4, 5}, []int{4, 5}},
+		// Cells falling past the obstacle leave the upper row with a gap
+		{"obstacle breaking a row", []string{"W.W.", "WXX.", "XXW."}, []int{4, 5}, []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewSizedBuilder(4, 6).Rows(tt.rows...).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			for _, y := range tt.fill {
+				if err := b.SetCell(3, y, piece.ColorCyan); err != nil {
+					t.Fatalf("SetCell(3, %d) error = %v", y, err)
+				}
+			}
+			got := b.ClearLines()
+			if !reflect.DeepEqual(got.Rows, tt.want) {
+				t.Errorf("ClearLines().Rows = %v, want %v", got.Rows, tt.want)
+			}
+			if got.Count() != len(tt.want) || len(got.Cells) != len(tt.want) {
+				t.Errorf("ClearLines() cleared %d rows with %d rows of cells, want %d", got.Count(), len(got.Cells), len(tt.want))
+			}
+			for i, row := range got.Cells {
+				if !isRowFull(row) {
+					t.Errorf("Cells[%d] = %v, want a full row", i, row)
+				}
+			}
+		})
+	}
+}
+
+// TestClearLinesCells verifies the cells of each cleared row are those it held
+func TestClearLinesCells(t *testing.T) {
+	b, err := NewSizedBuilder(4, 4).Rows("IIO.", "TTT.").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	b.SetCell(3, 2, piece.ColorCyan)
+	b.SetCell(3, 3, piece.ColorGreen)
+
+	got := b.ClearLines()
+	want := [][]piece.Color{
+		{piece.ColorCyan, piece.ColorCyan, piece.ColorYellow, piece.ColorCyan},
+		{piece.ColorPurple, piece.ColorPurple, piece.ColorPurple, piece.ColorGreen},
+	}
+	if len(got.Cells) != len(want) {
+		t.Fatalf("ClearLines() returned %d rows of cells, want %d", len(got.Cells), len(want))
+	}
+	for y, row := range want {
+		for x, color := range row {
+			if cell := got.Cells[y][x]; cell.Empty || cell.Color != color {
+				t.Errorf("Cells[%d][%d] = %+v, want %s", y, x, cell, color)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// paletteDigits are the characters Compact.Colors uses for palette indexes
+const paletteDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxCompactWidth is the widest board MarshalCompact can encode
+const MaxCompactWidth = 64
+
+// Compact is a board encoded as one bitmask per row plus a palette of colors
+// A standard board is about a tenth of the size of a grid of color strings
+type Compact struct {
+	Width   int      `json:"w"`
+	Height  int      `json:"h"`
+	Rows    []uint64 `json:"rows"`    // Bit x of Rows[y] is set if cell (x, y) is filled
+	Palette []string `json:"palette"` // Colors of the filled cells, in first-seen order
+	Colors  string   `json:"colors"`  // Palette index of each filled cell in row-major order, one digit per cell
+}
+
+// MarshalCompact encodes the board as a Compact
+func (b *Board) MarshalCompact() (*Compact, error) {
+	if b.width > MaxCompactWidth {
+		return nil, fmt.Errorf("board: width %d exceeds the compact limit of %d", b.width, MaxCompactWidth)
+	}
+
+	c := &Compact{
+		Width:  b.width,
+		Height: b.height,
+		Rows:   make([]uint64, b.height),
+	}
+	index := make(map[piece.Color]int)
+	var colors strings.Builder
+
+	for y, row := range b.visible() {
+		for x, cell := range row {
+			if cell.Empty {
+				continue
+			}
+			c.Rows[y] |= 1 << uint(x)
+
+			i, ok := index[cell.Color]
+			if !ok {
+				if len(c.Palette) == len(paletteDigits) {
+					return nil, fmt.Errorf("board: more than %d colors", len(paletteDigits))
+				}
+				i = len(c.Palette)
+				index[cell.Color] = i
+				c.Palette = append(c.Palette, string(cell.Color))
+			}
+			colors.WriteByte(paletteDigits[i])
+		}
+	}
+
+	c.Colors = colors.String()
+	return c, nil
+}
+
+// UnmarshalCompact decodes a board encoded by MarshalCompact
+func UnmarshalCompact(c *Compact) (*Board, error) {
+	if c == nil {
+		return nil, errors.New("board: nil compact board")
+	}
+	if c.Width <= 0 || c.Width > MaxCompactWidth || c.Height <= 0 {
+		return nil, fmt.Errorf("board: invalid compact size %dx%d", c.Width, c.Height)
+	}
+	if len(c.Rows) != c.Height {
+		return nil, fmt.Errorf("board: %d compact rows for height %d", len(c.Rows), c.Height)
+	}
+
+	filled := 0
+	for _, mask := range c.Rows {
+		if mask>>uint(c.Width) != 0 {
+			return nil, errors.New("board: compact row has cells outside the board")
+		}
+		filled += bits.OnesCount64(mask)
+	}
+	if filled != len(c.Colors) {
+		return nil, fmt.Errorf("board: %d filled cells but %d colors", filled, len(c.Colors))
+	}
+
+	b := NewSized(c.Width, c.Height)
+	next := 0
+	for y, mask := range c.Rows {
+		for x := 0; x < c.Width; x++ {
+			if mask&(1<<uint(x)) == 0 {
+				continue
+			}
+			i := strings.IndexByte(paletteDigits, c.Colors[next])
+			if i < 0 || i >= len(c.Palette) {
+				return nil, fmt.Errorf("board: invalid palette index %q", c.Colors[next])
+			}
+			b.row(y)[x] = Cell{Color: piece.Color(c.Palette[i])}
+			next++
+		}
+	}
+	return b, nil
+}
+
+// FromGrid creates a board from rows of cell colors, "" for empty cells
+func FromGrid(grid [][]string) *Board {
+	width := 0
+	if len(grid) > 0 {
+		width = len(grid[0])
+	}
+
+	b := NewSized(width, len(grid))
+	for y, row := range grid {
+		for x, color := range row {
+			if color != "" {
+				b.SetCell(x, y, piece.Color(color))
+			}
+		}
+	}
+	return b
+}
+
+// Grid returns the visible board as rows of cell colors, "" for empty cells
+func (b *Board) Grid() [][]string {
+	grid := make([][]string, b.height)
+	for y, row := range b.visible() {
+		grid[y] = make([]string, b.width)
+		for x, cell := range row {
+			if !cell.Empty {
+				grid[y][x] = string(cell.Color)
+			}
+		}
+	}
+	return grid
+}
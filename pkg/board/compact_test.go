@@ -0,0 +1,61 @@
+package board
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestCompactRoundTrip verifies that a board survives MarshalCompact and UnmarshalCompact
+func TestCompactRoundTrip(t *testing.T) {
+	b, err := NewBuilder().
+		Rows("..........", "....#.....", "##.#######", "#########.").
+		Cell(1, 19, piece.ColorRed).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	c, err := b.MarshalCompact()
+	if err != nil {
+		t.Fatalf("MarshalCompact() error = %v", err)
+	}
+	decoded, err := UnmarshalCompact(c)
+	if err != nil {
+		t.Fatalf("UnmarshalCompact() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Grid(), b.Grid()) {
+		t.Errorf("decoded board differs:\ngot  %v\nwant %v", decoded.Grid(), b.Grid())
+	}
+
+	compact, _ := json.Marshal(c)
+	grid, _ := json.Marshal(b.Grid())
+	if len(compact)*4 > len(grid) {
+		t.Errorf("compact encoding is %d bytes, grid %d bytes", len(compact), len(grid))
+	}
+}
+
+// TestUnmarshalCompactRejectsInvalid verifies that malformed boards are rejected
+func TestUnmarshalCompactRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Compact
+	}{
+		{"nil", nil},
+		{"bad size", &Compact{Width: 0, Height: 1, Rows: []uint64{0}}},
+		{"row count", &Compact{Width: 10, Height: 2, Rows: []uint64{0}}},
+		{"cell outside", &Compact{Width: 2, Height: 1, Rows: []uint64{4}, Palette: []string{"#fff"}, Colors: "0"}},
+		{"missing colors", &Compact{Width: 2, Height: 1, Rows: []uint64{3}, Palette: []string{"#fff"}, Colors: "0"}},
+		{"bad index", &Compact{Width: 2, Height: 1, Rows: []uint64{1}, Palette: []string{"#fff"}, Colors: "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalCompact(tt.c); err == nil {
+				t.Error("UnmarshalCompact() accepted an invalid board")
+			}
+		})
+	}
+}
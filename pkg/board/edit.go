@@ -0,0 +1,183 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+var (
+	// ErrFullRow is returned when an edit would leave a completely filled row
+	ErrFullRow = errors.New("board: edit would leave a completely filled row")
+)
+
+// patternColors maps row pattern characters to cell colors
+// '.' and ' ' are empty cells; piece letters use the piece color and 'W'
+// marks obstacles
+var patternColors = map[rune]piece.Color{
+	'X': piece.ColorGray,
+	'#': piece.ColorGray,
+	'W': piece.ColorWall,
+	'I': piece.ColorCyan,
+	'O': piece.ColorYellow,
+	'T': piece.ColorPurple,
+	'S': piece.ColorGreen,
+	'Z': piece.ColorRed,
+	'J': piece.ColorBlue,
+	'L': piece.ColorOrange,
+}
+
+// Region is a rectangular area of the board
+type Region struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// parsePattern converts a row pattern such as "XXXX.XXXXX" into cells
+func parsePattern(pattern string, width int) ([]Cell, error) {
+	runes := []rune(pattern)
+	if len(runes) != width {
+		return nil, fmt.Errorf("board: pattern %q must be %d cells wide", pattern, width)
+	}
+
+	row := make([]Cell, width)
+	for x, ch := range runes {
+		if ch == '.' || ch == ' ' {
+			row[x] = Cell{Empty: true}
+			continue
+		}
+		color, ok := patternColors[ch]
+		if !ok {
+			return nil, fmt.Errorf("board: invalid pattern character %q", ch)
+		}
+		row[x] = Cell{Color: color, Empty: false}
+	}
+
+	return row, nil
+}
+
+// isRowFull checks if a row of cells is completely filled; a row of nothing
+// but obstacles is never full, since clearing it would change nothing
+func isRowFull(row []Cell) bool {
+	obstacles := 0
+	for _, cell := range row {
+		if cell.Empty {
+			return false
+		}
+		if cell.IsObstacle() {
+			obstacles++
+		}
+	}
+	return obstacles < len(row)
+}
+
+// SetRow replaces row y with the given pattern
+// Returns an error if y is out of bounds, the pattern is invalid, or the row would be full
+func (b *Board) SetRow(y int, pattern string) error {
+	if !b.isValidPosition(0, y) {
+		return &OutOfBoundsError{X: 0, Y: y}
+	}
+
+	row, err := parsePattern(pattern, b.width)
+	if err != nil {
+		return err
+	}
+	if isRowFull(row) {
+		return ErrFullRow
+	}
+
+	b.cells[y+HiddenRows] = row
+	return nil
+}
+
+// Fill fills a region with the given color
+// The board is left unchanged if the region is out of bounds or would complete a row
+func (b *Board) Fill(region Region, color piece.Color) error {
+	if region.Width <= 0 || region.Height <= 0 {
+		return nil
+	}
+	if !b.isValidPosition(region.X, region.Y) {
+		return &OutOfBoundsError{X: region.X, Y: region.Y}
+	}
+	right, bottom := region.X+region.Width-1, region.Y+region.Height-1
+	if !b.isValidPosition(right, bottom) {
+		return &OutOfBoundsError{X: right, Y: bottom}
+	}
+
+	// Validate every affected row before changing anything
+	for y := region.Y; y <= bottom; y++ {
+		row := make([]Cell, b.width)
+		copy(row, b.row(y))
+		for x := region.X; x <= right; x++ {
+			row[x] = Cell{Color: color, Empty: false}
+		}
+		if isRowFull(row) {
+			return ErrFullRow
+		}
+	}
+
+	for y := region.Y; y <= bottom; y++ {
+		for x := region.X; x <= right; x++ {
+			b.row(y)[x] = Cell{Color: color, Empty: false}
+		}
+	}
+	return nil
+}
+
+// Builder constructs boards for puzzles, sandboxes and tests
+// The first error stops further edits and is returned by Build
+type Builder struct {
+	board *Board
+	err   error
+}
+
+// NewBuilder creates a builder starting from an empty board of the default size
+func NewBuilder() *Builder {
+	return &Builder{board: New()}
+}
+
+// NewSizedBuilder creates a builder starting from an empty board with the given dimensions
+func NewSizedBuilder(width, height int) *Builder {
+	return &Builder{board: NewSized(width, height)}
+}
+
+// Row sets row y from a pattern
+func (bb *Builder) Row(y int, pattern string) *Builder {
+	if bb.err == nil {
+		bb.err = bb.board.SetRow(y, pattern)
+	}
+	return bb
+}
+
+// Rows sets the bottom rows of the board; the last pattern is the bottom row
+func (bb *Builder) Rows(patterns ...string) *Builder {
+	top := bb.board.height - len(patterns)
+	for i, pattern := range patterns {
+		bb.Row(top+i, pattern)
+	}
+	return bb
+}
+
+// Fill fills a region with a color
+func (bb *Builder) Fill(region Region, color piece.Color) *Builder {
+	if bb.err == nil {
+		bb.err = bb.board.Fill(region, color)
+	}
+	return bb
+}
+
+// Cell fills a single cell with a color
+func (bb *Builder) Cell(x, y int, color piece.Color) *Builder {
+	return bb.Fill(Region{X: x, Y: y, Width: 1, Height: 1}, color)
+}
+
+// Build returns the constructed board or the first error encountered
+func (bb *Builder) Build() (*Board, error) {
+	if bb.err != nil {
+		return nil, bb.err
+	}
+	return bb.board.Clone(), nil
+}
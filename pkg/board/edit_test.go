@@ -0,0 +1,115 @@
+package board
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestSetRow verifies that SetRow accepts valid patterns and rejects invalid ones
+func TestSetRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		y       int
+		pattern string
+		wantErr bool
+	}{
+		{"garbage row with hole", DefaultHeight - 1, "XXXX.XXXXX", false},
+		{"piece colors", 0, "IOTSZJL...", false},
+		{"full row", DefaultHeight - 1, "XXXXXXXXXX", true},
+		{"obstacles", DefaultHeight - 1, "W.XXXXXXXW", false},
+		{"full row with obstacles", DefaultHeight - 1, "WXXXXXXXXW", true},
+		{"wall of obstacles", DefaultHeight - 1, "WWWWWWWWWW", false},
+		{"too short", DefaultHeight - 1, "XXX", true},
+		{"invalid character", DefaultHeight - 1, "XXXX?XXXX.", true},
+		{"out of bounds", DefaultHeight, "X.........", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			err := b.SetRow(tt.y, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetRow(%d, %q) error = %v, wantErr %v", tt.y, tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestFillRejectsFullRow verifies that a failed Fill leaves the board unchanged
+func TestFillRejectsFullRow(t *testing.T) {
+	b := New()
+	err := b.Fill(Region{X: 0, Y: DefaultHeight - 2, Width: DefaultWidth, Height: 2}, piece.ColorGray)
+	if !errors.Is(err, ErrFullRow) {
+		t.Fatalf("Fill() error = %v, want ErrFullRow", err)
+	}
+
+	if !b.IsEmpty(0, DefaultHeight-1) {
+		t.Error("Fill() modified the board despite returning an error")
+	}
+}
+
+// TestBuilder verifies that the builder places rows from the bottom and stops on the first error
+func TestBuilder(t *testing.T) {
+	b, err := NewBuilder().
+		Rows(
+			"X.........",
+			"XXXXXXXXX.",
+		).
+		Cell(5, 0, piece.ColorRed).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if b.IsEmpty(0, DefaultHeight-2) || b.IsEmpty(0, DefaultHeight-1) || !b.IsEmpty(9, DefaultHeight-1) {
+		t.Error("Rows() did not place patterns at the bottom of the board")
+	}
+	if cell, _ := b.GetCell(5, 0); cell.Color != piece.ColorRed {
+		t.Errorf("Cell() color = %q, want %q", cell.Color, piece.ColorRed)
+	}
+
+	if _, err := NewBuilder().Row(0, "XXXXXXXXXX").Cell(0, 1, piece.ColorRed).Build(); !errors.Is(err, ErrFullRow) {
+		t.Errorf("Build() error = %v, want ErrFullRow", err)
+	}
+}
+
+// TestObstacles verifies obstacles fill their rows but stay in place when the
+// rows are cleared, with the cells above falling past them
+func TestObstacles(t *testing.T) {
+	b, err := NewSizedBuilder(4, 4).
+		Rows(
+			"T...",
+			"W.W.",
+			"XXX.",
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if err := b.SetCell(3, 3, piece.ColorCyan); err != nil {
+		t.Fatalf("SetCell() error = %v", err)
+	}
+
+	if got := b.ClearLines().Count(); got != 1 {
+		t.Fatalf("ClearLines() = %d, want 1", got)
+	}
+	want := [][]string{
+		{"", "", "", ""},
+		{"", "", "", ""},
+		{string(piece.ColorWall), "", string(piece.ColorWall), ""},
+		{string(piece.ColorPurple), "", "", ""},
+	}
+	if got := b.Grid(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Grid() after clearing = %v, want %v", got, want)
+	}
+	if b.IsCleared() {
+		t.Error("IsCleared() = true with a piece cell left")
+	}
+	b.SetCell(0, 3, piece.ColorWall)
+	if !b.IsCleared() {
+		t.Error("IsCleared() = false with only obstacles left")
+	}
+}
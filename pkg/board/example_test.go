@@ -0,0 +1,60 @@
+package board_test
+
+import (
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// ExampleBoard_ClearLines fills the bottom two rows, clears them and shows
+// which rows were cleared and that the rows above fall into their place
+func ExampleBoard_ClearLines() {
+	b, err := board.NewBuilder().
+		Rows(
+			"X.........",
+			"XXXXXXXXX.",
+			"XXXXXXXXX.",
+		).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Complete the bottom two rows with a vertical piece in the last column
+	b.SetCell(9, board.DefaultHeight-1, piece.ColorCyan)
+	b.SetCell(9, board.DefaultHeight-2, piece.ColorCyan)
+
+	result := b.ClearLines()
+	fmt.Println("cleared:", result.Count(), result.Rows)
+	fmt.Println("bottom left occupied:", b.IsOccupied(0, board.DefaultHeight-1))
+	fmt.Println("bottom right occupied:", b.IsOccupied(9, board.DefaultHeight-1))
+	// Output:
+	// cleared: 2 [18 19]
+	// bottom left occupied: true
+	// bottom right occupied: false
+}
+
+// ExampleBoard_FullRows lists the complete rows that ClearLines would remove
+func ExampleBoard_FullRows() {
+	b, err := board.NewBuilder().
+		Rows(
+			"XXXXXXXXX.",
+			"XXXX.XXXXX",
+			"XXXXXXXXX.",
+		).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Complete the first and last of the three rows
+	b.SetCell(9, board.DefaultHeight-1, piece.ColorCyan)
+	b.SetCell(9, board.DefaultHeight-3, piece.ColorCyan)
+
+	fmt.Println(b.FullRows())
+	// Output:
+	// [17 19]
+}
@@ -0,0 +1,71 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// ErrTopOut is returned when garbage pushes occupied cells above the hidden
+// rows at the top of the board
+var ErrTopOut = errors.New("board: garbage pushed the stack above the top")
+
+// AddGarbageRows pushes the stack up by n rows and fills the bottom n rows
+// with gray garbage, leaving holeColumn empty in each
+// Cells pushed above the hidden rows are lost and ErrTopOut is returned; the
+// rows are still added so the caller can end the game on the resulting board
+func (b *Board) AddGarbageRows(n int, holeColumn int) error {
+	if n <= 0 {
+		return nil
+	}
+	if holeColumn < 0 || holeColumn >= b.width {
+		return fmt.Errorf("board: garbage hole column %d outside board width %d", holeColumn, b.width)
+	}
+	if n > b.height {
+		n = b.height
+	}
+
+	// Any occupied cell in the top n rows, counting the hidden rows, falls
+	// off the board
+	toppedOut := false
+	for y := 0; y < n && !toppedOut; y++ {
+		for x := 0; x < b.width; x++ {
+			if !b.cells[y][x].Empty {
+				toppedOut = true
+				break
+			}
+		}
+	}
+
+	// Shift rows up, then fill the bottom with garbage
+	copy(b.cells, b.cells[n:])
+	for y := len(b.cells) - n; y < len(b.cells); y++ {
+		row := newEmptyRow(b.width)
+		for x := range row {
+			if x != holeColumn {
+				row[x] = Cell{Color: piece.ColorGray}
+			}
+		}
+		b.cells[y] = row
+	}
+
+	if toppedOut {
+		return ErrTopOut
+	}
+	return nil
+}
+
+// GarbageRows returns the number of rows that still hold garbage cells
+func (b *Board) GarbageRows() int {
+	n := 0
+	for _, row := range b.cells {
+		for _, cell := range row {
+			if !cell.Empty && cell.Color == piece.ColorGray {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
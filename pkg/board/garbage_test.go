@@ -0,0 +1,103 @@
+package board
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestAddGarbageRowsShiftsStack verifies that garbage pushes existing cells up and leaves the hole open
+func TestAddGarbageRowsShiftsStack(t *testing.T) {
+	b, err := NewBuilder().Rows("TTT.......").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if err := b.AddGarbageRows(2, 4); err != nil {
+		t.Fatalf("AddGarbageRows() error = %v", err)
+	}
+
+	if b.IsEmpty(0, DefaultHeight-3) || !b.IsEmpty(3, DefaultHeight-3) {
+		t.Error("AddGarbageRows() did not move the existing row up by 2")
+	}
+	for y := DefaultHeight - 2; y < DefaultHeight; y++ {
+		for x := 0; x < DefaultWidth; x++ {
+			if want := x == 4; b.IsEmpty(x, y) != want {
+				t.Errorf("cell (%d,%d) empty = %v, want %v", x, y, !want, want)
+			}
+		}
+	}
+}
+
+// TestAddGarbageRowsTopOut verifies that pushing cells above the hidden rows
+// is reported
+func TestAddGarbageRowsTopOut(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		wantErr error
+	}{
+		{"fits below the stack", 1, nil},
+		{"rises into the hidden rows", HiddenRows + 1, nil},
+		{"pushes the top cell off", HiddenRows + 2, ErrTopOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			b.SetCell(0, 1, "#FF0000")
+
+			err := b.AddGarbageRows(tt.n, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddGarbageRows(%d) error = %v, want %v", tt.n, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := New().AddGarbageRows(1, DefaultWidth); err == nil {
+		t.Error("AddGarbageRows() accepted a hole column outside the board")
+	}
+}
+
+// TestGetColumnHeights verifies heights are measured from the bottom to the highest cell
+func TestGetColumnHeights(t *testing.T) {
+	b, err := NewBuilder().
+		Rows(
+			"X.........",
+			"X.X.......",
+			"XXX......X",
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := []int{3, 1, 2, 0, 0, 0, 0, 0, 0, 1}
+	if got := b.GetColumnHeights(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetColumnHeights() = %v, want %v", got, want)
+	}
+	if got := b.StackHeight(); got != 3 {
+		t.Errorf("StackHeight() = %d, want 3", got)
+	}
+	if got := New().StackHeight(); got != 0 {
+		t.Errorf("StackHeight() of an empty board = %d, want 0", got)
+	}
+}
+
+// TestGarbageRows verifies that rows count as garbage while any gray cell is left
+func TestGarbageRows(t *testing.T) {
+	b, err := NewBuilder().
+		Rows(
+			"TT........",
+			"X.TTTTTTTT",
+			"XXXX.XXXXX",
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := b.GarbageRows(); got != 2 {
+		t.Errorf("GarbageRows() = %d, want 2", got)
+	}
+}
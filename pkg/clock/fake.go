@@ -0,0 +1,154 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when Advance is called
+// Timers, tickers and sleepers fire in time order as it passes their deadline
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // Signalled when a waiter is added
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFake creates a fake clock reading start
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep blocks until the clock has been advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.NewTimer(d).C()
+}
+
+// NewTimer returns a timer that fires once the clock passes d from now
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.add(d, 0)
+}
+
+// NewTicker returns a ticker that fires each time the clock passes another d
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+// Advance moves the clock forward by d, firing every timer due on the way
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for len(f.waiters) > 0 && !f.waiters[0].when.After(end) {
+		t := f.waiters[0]
+		f.now = t.when
+		select {
+		case t.c <- f.now:
+		default:
+			// Like the time package, a slow receiver misses ticks
+		}
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			f.removeLocked(t)
+		}
+		f.sortLocked()
+	}
+	f.now = end
+}
+
+// BlockUntil waits until n timers, tickers or sleepers are pending, so a test
+// can advance the clock once the code under test is waiting on it
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add schedules a timer d from now that repeats every period if it is non-zero
+func (f *Fake) add(d, period time.Duration) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1), period: period}
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// scheduleLocked makes t fire d from now; must be called with mu held
+func (f *Fake) scheduleLocked(t *fakeTimer, d time.Duration) {
+	t.when = f.now.Add(d)
+	f.waiters = append(f.waiters, t)
+	f.sortLocked()
+	f.cond.Broadcast()
+}
+
+// removeLocked cancels t; returns false if it was not pending
+// Must be called with mu held
+func (f *Fake) removeLocked(t *fakeTimer) bool {
+	for i, w := range f.waiters {
+		if w == t {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// sortLocked orders the waiters by deadline; must be called with mu held
+func (f *Fake) sortLocked() {
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].when.Before(f.waiters[j].when)
+	})
+}
+
+// fakeTimer is a Timer or Ticker on a Fake clock
+type fakeTimer struct {
+	clock  *Fake
+	c      chan time.Time
+	when   time.Time
+	period time.Duration // Non-zero for tickers
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	pending := t.clock.removeLocked(t)
+	t.clock.scheduleLocked(t, d)
+	return pending
+}
+
+// fakeTicker adapts a repeating fakeTimer to the Ticker interface
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.c }
+func (t fakeTicker) Stop()               { t.t.Stop() }
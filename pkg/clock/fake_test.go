@@ -0,0 +1,89 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// received reports whether a value is waiting on c
+func received(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// TestFakeTimer verifies that a timer fires only once the clock reaches its deadline
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	if received(timer.C()) {
+		t.Fatal("timer fired before its deadline")
+	}
+	f.Advance(time.Millisecond)
+	if !received(timer.C()) {
+		t.Fatal("timer did not fire at its deadline")
+	}
+	if got := f.Since(start); got != time.Second {
+		t.Errorf("Since(start) = %v, want %v", got, time.Second)
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset() of a fired timer = true, want false")
+	}
+	if !timer.Stop() {
+		t.Error("Stop() of a pending timer = false, want true")
+	}
+	f.Advance(time.Hour)
+	if received(timer.C()) {
+		t.Error("stopped timer fired")
+	}
+}
+
+// TestFakeTicker verifies that a ticker fires at each interval until stopped
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Second)
+
+	for i := 1; i <= 3; i++ {
+		f.Advance(time.Second)
+		select {
+		case at := <-ticker.C():
+			if want := start.Add(time.Duration(i) * time.Second); !at.Equal(want) {
+				t.Errorf("tick %d at %v, want %v", i, at, want)
+			}
+		default:
+			t.Fatalf("tick %d missing", i)
+		}
+	}
+
+	ticker.Stop()
+	f.Advance(time.Hour)
+	if received(ticker.C()) {
+		t.Error("stopped ticker fired")
+	}
+}
+
+// TestFakeSleep verifies that Sleep returns once another goroutine advances the clock
+func TestFakeSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return after Advance")
+	}
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fastGravity drops the piece every millisecond, so Update moves it while
+// the readers below run
+func fastGravity(level int) time.Duration {
+	return time.Millisecond
+}
+
+// TestConcurrentAccess plays a game from one goroutine, as the server's game
+// loop does, while others read it through every getter; run with -race
+func TestConcurrentAccess(t *testing.T) {
+	readers := []struct {
+		name string
+		read func(g *Game)
+	}{
+		{"state", func(g *Game) { g.GetState(); g.IsPlaying(); g.IsPaused(); g.IsGameOver() }},
+		{"board", func(g *Game) { g.GetBoard().Grid(); g.GetStackHeight() }},
+		{"pieces", func(g *Game) {
+			if p := g.GetCurrentPiece(); p != nil {
+				p.GetShape()
+			}
+			g.GetNextPiece()
+			g.GetNextPieces(5)
+			g.GetHoldPiece()
+			g.CanHold()
+		}},
+		{"score", func(g *Game) { g.GetScore(); g.GetLevel(); g.GetLines(); g.GetDropInterval() }},
+		{"stats", func(g *Game) { g.GetPieceCounts(); g.GetPiecesPlaced(); g.GetSeq(); g.TimeUntilDrop() }},
+		{"config", func(g *Game) { g.GetSeed(); g.GetRuleset(); g.GetRandomizer(); g.GetMode() }},
+		{"mode", func(g *Game) { g.GetModeStatus(); g.GetElapsed(); g.IsCompleted() }},
+		{"spawn", func(g *Game) { g.IsSpawning(); g.GetClearingRows() }},
+		{"snapshots", func(g *Game) { g.GetStateSnapshot(); g.GetGameState() }},
+	}
+
+	g := NewWithConfig(Config{Seed: 1, Gravity: fastGravity, LockDelay: time.Millisecond})
+	inputs := []func() bool{
+		g.MoveLeft,
+		g.MoveRight,
+		g.Rotate,
+		g.MoveDown,
+		g.Hold,
+		g.Update,
+		func() bool { return g.HardDrop() > 0 },
+		func() bool { g.TogglePause(); g.TogglePause(); return true },
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, r := range readers {
+		wg.Add(1)
+		go func(read func(g *Game)) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					read(g)
+				}
+			}
+		}(r.read)
+	}
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline) && !g.IsGameOver(); i++ {
+		inputs[i%len(inputs)]()
+	}
+	close(done)
+	wg.Wait()
+}
+
+// TestGettersReturnCopies checks that changing a returned board or piece
+// leaves the game as it was
+func TestGettersReturnCopies(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+
+	current := g.GetCurrentPiece()
+	x := current.X
+	current.X += 3
+	if got := g.GetCurrentPiece().X; got != x {
+		t.Errorf("GetCurrentPiece().X = %d after changing a returned piece, want %d", got, x)
+	}
+
+	next := g.GetNextPiece()
+	next.Rotation = 2
+	if got := g.GetNextPiece().Rotation; got != 0 {
+		t.Errorf("GetNextPiece().Rotation = %d after changing a returned piece, want 0", got)
+	}
+
+	b := g.GetBoard()
+	bottom := b.Height() - 1
+	b.SetCell(0, bottom, current.Color)
+	if !g.GetBoard().IsEmpty(0, bottom) {
+		t.Error("cell (0, bottom) filled after changing a returned board, want empty")
+	}
+}
+
+// TestTogglePause verifies concurrent toggles each flip the state exactly
+// once, and that a finished game stays over
+func TestTogglePause(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+
+	const toggles = 100
+	var wg sync.WaitGroup
+	for i := 0; i < toggles; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.TogglePause()
+		}()
+	}
+	wg.Wait()
+	if !g.IsPlaying() {
+		t.Errorf("state after %d toggles = %v, want playing", toggles, g.GetState())
+	}
+
+	g.TogglePause()
+	if !g.IsPaused() {
+		t.Errorf("state after one more toggle = %v, want paused", g.GetState())
+	}
+	g.TogglePause()
+	g.Win()
+	g.TogglePause()
+	if !g.IsGameOver() {
+		t.Errorf("state after toggling a finished game = %v, want game over", g.GetState())
+	}
+}
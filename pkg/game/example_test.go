@@ -0,0 +1,33 @@
+package game_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// ExampleGame_HardDrop drops two pieces with tracing enabled, showing the
+// move-by-move log a contributor can use to follow the engine
+func ExampleGame_HardDrop() {
+	g := game.NewWithConfig(game.Config{Seed: 42, Trace: os.Stdout})
+
+	g.MoveLeft()
+	g.HardDrop()
+	g.Rotate()
+	g.HardDrop()
+
+	fmt.Println("score:", g.GetScore())
+	// Output:
+	// new game: mode marathon, seed 42, board 10x20
+	// spawn: I
+	// move_left: I at (2,0) rotation 0
+	// hard_drop: I fell 19 rows
+	// lock: I at (2,19), cleared 0, score 19, lines 0, level 1
+	// spawn: Z
+	// rotate: Z at (3,-1) rotation 1
+	// hard_drop: Z fell 17 rows
+	// lock: Z at (3,16), cleared 0, score 36, lines 0, level 1
+	// spawn: O
+	// score: 36
+}
@@ -1,11 +1,15 @@
 package game
 
 import (
+	"io"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/clock"
 	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/puzzle"
 )
 
 // State represents the current game state
@@ -27,57 +31,269 @@ func (s State) String() string {
 	return names[s]
 }
 
+// Ruleset identifies the set of rules a game is played under
+type Ruleset string
+
+const (
+	// RulesetClassic is the original scoring, gravity and rotation rules
+	RulesetClassic Ruleset = "classic"
+)
+
+// perfectClearAttack is the garbage sent for clearing the whole board
+const perfectClearAttack = 10
+
+// attackTable maps the number of lines cleared to garbage lines sent to an opponent
+var attackTable = map[int]int{
+	1: 0,
+	2: 1,
+	3: 2,
+	4: 4,
+}
+
+// Attack describes the garbage produced by a single line clear
+type Attack struct {
+	Lines        int  // Number of lines cleared
+	Garbage      int  // Garbage lines to send to an opponent
+	PerfectClear bool // True if the clear left the board empty
+}
+
+// ScoreEvent describes the points awarded for a single line clear
+type ScoreEvent struct {
+	Lines   int  // Number of lines cleared
+	Points  int  // Points added to the score
+	Level   int  // Level after the clear
+	LevelUp bool // True if the clear raised the level
+}
+
 // Game represents the Tetris game engine
 type Game struct {
 	board        *board.Board
-	generator    *piece.Generator
+	generator    piece.Randomizer
+	script       []piece.Type // Sequence of a scripted randomizer
+	seed         int64
+	ruleset      Ruleset
+	mode         Mode
 	current      *piece.Piece
 	next         *piece.Piece
+	hold         *piece.Piece // Held piece, nil until the first hold
+	canHold      bool         // False once hold has been used for the current piece
 	state        State
 	score        int
 	level        int
 	lines        int
 	dropInterval time.Duration
 	lastDrop     time.Time
+	gravity      Gravity            // Drop interval for each level
+	lockDelay    time.Duration      // How long a resting piece waits before it locks
+	spawnDelay   time.Duration      // How long the next piece waits to spawn after a lock
+	spawnAt      time.Time          // When the next piece spawns or clearing ends; zero unless waiting
+	clearDelay   time.Duration      // How long full rows stay on the board before they are removed
+	clearing     []int              // Full rows waiting out the line clear delay, top to bottom
+	landedAt     time.Time          // When the current piece came to rest; zero while it can fall
+	attacks      []Attack           // Attacks produced since the last TakeAttacks call
+	events       []ScoreEvent       // Score events produced since the last TakeScoreEvents call
+	pieceCounts  map[piece.Type]int // Pieces locked so far, by type
+	heatmap      Heatmap            // Where pieces locked and holes formed; allocated at the first lock
+	undo         []*snapshot        // State at the start of each placed piece, newest last; practice mode only
+	undoStart    *snapshot          // State when the current piece spawned; practice mode only
+	queue        []piece.Type       // Pieces set up to come after next, before the bag continues
+	digRows      int                // Garbage rows the board started with (dig mode only)
+	puzzle       *puzzle.Level      // Level of a puzzle game, nil in other modes
+	ihs          bool               // Hold pressed during the spawn delay (Initial Hold System)
+	irs          int                // Rotations pressed during the spawn delay (Initial Rotation System)
+	ims          Action             // Last move pressed during the spawn delay, "" if none
+	seq          uint64             // Incremented on every state change
+	trace        io.Writer          // Move-by-move log, nil if disabled
+	startedAt    time.Time
+	pausedAt     time.Time     // When the current pause started
+	pausedFor    time.Duration // Total time spent paused
+	endedAt      time.Time
+	completed    bool         // True if the game ended by reaching the mode's goal
+	clock        clock.Clock  // Source of the current time
+	sim          *clock.Fake  // Clock of a headless game, advanced only by Step; nil otherwise
 	mu           sync.RWMutex // Protects game state during concurrent access
+
+	// Garbage sent by a versus opponent, waiting for a lock without a line clear
+	pendingGarbage int
+	garbageHoles   *rand.Rand // Chooses the hole column of the garbage; allocated at the first rise
+
+	// Handicap of a versus game; its gravity scale is already part of gravity
+	handicap Handicap
+}
+
+// Config holds the options a game is created with
+type Config struct {
+	Seed   int64 // Seed for the piece generator (0 = time-based)
+	Mode   Mode  // Game mode (empty = marathon)
+	Width  int   // Board width (0 = board.DefaultWidth)
+	Height int   // Board height (0 = board.DefaultHeight)
+
+	// Gravity sets the drop interval for each level (nil = GuidelineGravity)
+	Gravity Gravity
+	// LockDelay is how long a resting piece waits before it locks (0 = DefaultLockDelay)
+	LockDelay time.Duration
+	// SpawnDelay is how long the next piece waits to spawn after a lock
+	// (0 = at once); rotate, hold and the last move pressed meanwhile apply
+	// as it spawns
+	SpawnDelay time.Duration
+	// LineClearDelay is how long full rows stay on the board, reported by
+	// GetClearingRows, before they are removed and the spawn delay starts
+	// (0 = removed at once)
+	LineClearDelay time.Duration
+	// Randomizer chooses the piece sequence (empty = piece.RandomizerBag)
+	Randomizer piece.RandomizerKind
+	// Script is the sequence of a piece.RandomizerScripted game, repeated;
+	// without one the game falls back to the bag randomizer
+	Script []piece.Type
+	// DigRows is the number of garbage rows a dig game starts with
+	// (0 = DefaultDigRows); at least 4 rows at the top are kept free
+	DigRows int
+	// Puzzle is the level of a puzzle game (nil = the first of
+	// puzzle.Builtin); it sets the board and the pieces, replacing Randomizer
+	Puzzle *puzzle.Level
+	// Handicap evens out a versus game: starting garbage, gravity speed and
+	// preview (zero = none)
+	Handicap Handicap
+
+	// Headless runs the game on a clock.Fake advanced only by Step, ignoring
+	// Clock, for deterministic simulations much faster than real time
+	Headless bool
+	// Clock supplies the time of a real-time game (nil = the wall clock);
+	// tests can pass a clock.Fake to control gravity without sleeping
+	Clock clock.Clock
+
+	// Trace receives a move-by-move log of the game (nil = no tracing)
+	Trace io.Writer
 }
 
-// New creates a new game
+// New creates a new marathon game with a time-based seed
 func New() *Game {
+	return NewWithConfig(Config{})
+}
+
+// NewWithSeed creates a new game with a specific seed
+// Games created with the same seed receive the same piece sequence
+func NewWithSeed(seed int64) *Game {
+	return NewWithConfig(Config{Seed: seed})
+}
+
+// NewWithConfig creates a new game from the given configuration
+func NewWithConfig(cfg Config) *Game {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if !cfg.Mode.IsValid() {
+		cfg.Mode = ModeMarathon
+	}
+	if cfg.Gravity == nil {
+		cfg.Gravity = GuidelineGravity
+	}
+	cfg.Gravity = cfg.Handicap.scaleGravity(cfg.Gravity)
+	if cfg.LockDelay <= 0 {
+		cfg.LockDelay = DefaultLockDelay
+	}
+	var start *board.Board
+	if cfg.Mode != ModePuzzle {
+		cfg.Puzzle = nil
+	} else {
+		if cfg.Puzzle == nil {
+			cfg.Puzzle = &puzzle.Builtin[0]
+		}
+		// Levels are validated when they are loaded
+		start, _ = cfg.Puzzle.Board(cfg.Width, cfg.Height)
+		cfg.Randomizer = piece.RandomizerScripted
+		cfg.Script, _ = cfg.Puzzle.Sequence()
+	}
+	if start == nil {
+		start = board.NewSized(cfg.Width, cfg.Height)
+	}
+	generator, err := piece.NewRandomizer(cfg.Randomizer, cfg.Seed, cfg.Script)
+	if err != nil {
+		generator = piece.NewGeneratorWithSeed(cfg.Seed)
+	}
+	if generator.Kind() != piece.RandomizerScripted {
+		cfg.Script = nil
+	}
+	if cfg.Mode != ModeDig {
+		cfg.DigRows = 0
+	} else if cfg.DigRows <= 0 {
+		cfg.DigRows = DefaultDigRows
+	}
+
+	var sim *clock.Fake
+	if cfg.Headless {
+		sim = clock.NewFake(simEpoch)
+		cfg.Clock = sim
+	}
+	cfg.Clock = clock.OrReal(cfg.Clock)
+
+	now := cfg.Clock.Now()
 	g := &Game{
-		board:        board.New(),
-		generator:    piece.NewGenerator(),
+		board:        start,
+		generator:    generator,
+		script:       append([]piece.Type(nil), cfg.Script...),
+		seed:         cfg.Seed,
+		ruleset:      RulesetClassic,
+		mode:         cfg.Mode,
 		state:        StatePlaying,
 		score:        0,
 		level:        1,
 		lines:        0,
-		dropInterval: calculateDropInterval(1),
-		lastDrop:     time.Now(),
+		dropInterval: cfg.Gravity(1),
+		lastDrop:     now,
+		gravity:      cfg.Gravity,
+		lockDelay:    cfg.LockDelay,
+		spawnDelay:   max(cfg.SpawnDelay, 0),
+		clearDelay:   max(cfg.LineClearDelay, 0),
+		startedAt:    now,
+		pieceCounts:  make(map[piece.Type]int),
+		canHold:      true,
+		trace:        cfg.Trace,
+		clock:        cfg.Clock,
+		sim:          sim,
+		puzzle:       cfg.Puzzle,
+		handicap:     cfg.Handicap,
+	}
+	if cfg.DigRows > 0 {
+		g.digRows = min(cfg.DigRows, g.board.Height()-4)
+		g.fillDigRowsLocked()
 	}
 
+	g.tracef("new game: mode %s, seed %d, board %dx%d", g.mode, g.seed, g.board.Width(), g.board.Height())
+	if cfg.Handicap.GarbageRows > 0 {
+		// Like dig rows, the starting garbage leaves 4 rows at the top free
+		g.pendingGarbage = max(min(cfg.Handicap.GarbageRows, g.board.Height()-4-g.board.StackHeight()), 0)
+		g.raiseGarbageLocked()
+	}
 	g.spawnPiece()
 	g.prepareNext()
+	g.markPieceStartLocked(now)
 
 	return g
 }
 
-// NewWithSeed creates a new game with a specific seed (for testing)
-func NewWithSeed(seed int64) *Game {
-	g := &Game{
-		board:        board.New(),
-		generator:    piece.NewGeneratorWithSeed(seed),
+// NewMirror creates a playing game over an existing board and current piece,
+// so a client can apply moves locally while it waits for the server
+// Pieces spawned after a lock are not meaningful in a mirror
+func NewMirror(b *board.Board, current *piece.Piece) *Game {
+	now := time.Now()
+	return &Game{
+		board:        b,
+		generator:    piece.NewGeneratorWithSeed(1),
+		ruleset:      RulesetClassic,
+		mode:         ModeMarathon,
+		current:      current,
 		state:        StatePlaying,
-		score:        0,
 		level:        1,
-		lines:        0,
-		dropInterval: calculateDropInterval(1),
-		lastDrop:     time.Now(),
+		dropInterval: GuidelineGravity(1),
+		lastDrop:     now,
+		gravity:      GuidelineGravity,
+		lockDelay:    DefaultLockDelay,
+		startedAt:    now,
+		pieceCounts:  make(map[piece.Type]int),
+		canHold:      true,
+		clock:        clock.Real{},
 	}
-
-	g.spawnPiece()
-	g.prepareNext()
-
-	return g
 }
 
 // spawnPiece creates a new current piece
@@ -89,18 +305,41 @@ func (g *Game) spawnPiece() {
 		g.current = g.generator.Next()
 	}
 
+	g.placeAtSpawn()
+	g.tracef("spawn: %s", g.current.Type)
+}
+
+// placeAtSpawn moves the current piece to the spawn position and ends the
+// game if it does not fit (block out); in zen mode the top of the stack is
+// cleared instead
+func (g *Game) placeAtSpawn() {
+	g.current.Spawn(g.board.Width())
+	g.landedAt = time.Time{}
+
 	// Check for game over
 	if g.board.CheckCollision(g.current.X, g.current.Y, g.current.GetShape()) {
-		g.state = StateGameOver
+		if g.mode == ModeZen {
+			g.board.ClearTopRows(g.board.Height() / 2)
+			g.tracef("zen: cleared the top %d rows", g.board.Height()/2)
+			return
+		}
+		g.finishLocked(g.clock.Now(), false)
 	}
 }
 
-// prepareNext prepares the next piece
+// prepareNext prepares the next piece, taking it from the queue set up in
+// practice mode before the generator
 func (g *Game) prepareNext() {
+	if len(g.queue) > 0 {
+		g.next = piece.New(g.queue[0])
+		g.queue = g.queue[1:]
+		return
+	}
 	g.next = g.generator.Next()
 }
 
-// MoveLeft attempts to move the current piece left
+// MoveLeft attempts to move the current piece left; during the spawn delay
+// it moves the next piece as it spawns
 func (g *Game) MoveLeft() bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -108,15 +347,26 @@ func (g *Game) MoveLeft() bool {
 	if g.state != StatePlaying {
 		return false
 	}
+	if g.spawningLocked() {
+		g.bufferMoveLocked(ActionMoveLeft)
+		return true
+	}
 
 	collision := func(x, y int, shape piece.Shape) bool {
 		return g.board.CheckCollision(x, y, shape)
 	}
 
-	return g.current.MoveLeft(collision)
+	if !g.current.MoveLeft(collision) {
+		g.traceMove("move_left", false)
+		return false
+	}
+	g.traceMove("move_left", true)
+	g.markChanged()
+	return true
 }
 
-// MoveRight attempts to move the current piece right
+// MoveRight attempts to move the current piece right; during the spawn
+// delay it moves the next piece as it spawns
 func (g *Game) MoveRight() bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -124,15 +374,26 @@ func (g *Game) MoveRight() bool {
 	if g.state != StatePlaying {
 		return false
 	}
+	if g.spawningLocked() {
+		g.bufferMoveLocked(ActionMoveRight)
+		return true
+	}
 
 	collision := func(x, y int, shape piece.Shape) bool {
 		return g.board.CheckCollision(x, y, shape)
 	}
 
-	return g.current.MoveRight(collision)
+	if !g.current.MoveRight(collision) {
+		g.traceMove("move_right", false)
+		return false
+	}
+	g.traceMove("move_right", true)
+	g.markChanged()
+	return true
 }
 
-// MoveDown attempts to move the current piece down (soft drop)
+// MoveDown attempts to move the current piece down (soft drop); during the
+// spawn delay it moves the next piece down a row as it spawns
 func (g *Game) MoveDown() bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -140,6 +401,10 @@ func (g *Game) MoveDown() bool {
 	if g.state != StatePlaying {
 		return false
 	}
+	if g.spawningLocked() {
+		g.bufferMoveLocked(ActionMoveDown)
+		return true
+	}
 
 	collision := func(x, y int, shape piece.Shape) bool {
 		return g.board.CheckCollision(x, y, shape)
@@ -149,7 +414,10 @@ func (g *Game) MoveDown() bool {
 	if !success {
 		// Piece locked, spawn new piece
 		g.lockAndSpawnLocked()
+	} else {
+		g.traceMove("move_down", true)
 	}
+	g.markChanged()
 
 	return success
 }
@@ -159,7 +427,7 @@ func (g *Game) HardDrop() int {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if g.state != StatePlaying {
+	if g.state != StatePlaying || g.spawningLocked() {
 		return 0
 	}
 
@@ -168,17 +436,56 @@ func (g *Game) HardDrop() int {
 	}
 
 	dropDistance := g.current.HardDrop(collision)
+	g.tracef("hard_drop: %s fell %d rows", g.current.Type, dropDistance)
 
 	// Award hard drop bonus points
 	g.score += dropDistance * g.level
 
 	// Lock and spawn new piece
 	g.lockAndSpawnLocked()
+	g.markChanged()
 
 	return dropDistance
 }
 
-// Rotate attempts to rotate the current piece
+// Hold swaps the current piece with the held piece, or stores it and spawns
+// the next piece if nothing is held yet; during the spawn delay it holds the
+// next piece as it spawns
+// Hold can be used once per piece; returns false if it is not available
+func (g *Game) Hold() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state != StatePlaying || !g.canHold {
+		return false
+	}
+	if g.spawningLocked() {
+		return g.bufferHoldLocked()
+	}
+	g.holdLocked()
+	g.markChanged()
+	return true
+}
+
+// holdLocked swaps the current piece with the held piece
+// Must be called with mu held
+func (g *Game) holdLocked() {
+	// The held piece always returns in its spawn orientation
+	held := piece.New(g.current.Type)
+	g.tracef("hold: %s", held.Type)
+	if g.hold == nil {
+		g.spawnPiece()
+		g.prepareNext()
+	} else {
+		g.current = g.hold
+		g.placeAtSpawn()
+	}
+	g.hold = held
+	g.canHold = false
+}
+
+// Rotate attempts to rotate the current piece; during the spawn delay it
+// rotates the next piece as it spawns
 func (g *Game) Rotate() bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -186,12 +493,63 @@ func (g *Game) Rotate() bool {
 	if g.state != StatePlaying {
 		return false
 	}
+	if g.spawningLocked() {
+		g.bufferRotateLocked(1)
+		return true
+	}
+
+	collision := func(x, y int, shape piece.Shape) bool {
+		return g.board.CheckCollision(x, y, shape)
+	}
+
+	if !g.current.Rotate(collision) {
+		g.traceMove("rotate", false)
+		return false
+	}
+	g.traceMove("rotate", true)
+	g.markChanged()
+	return true
+}
+
+// Rotate180 attempts to turn the current piece 180° in one step; during the
+// spawn delay it buffers two rotations for the next piece
+func (g *Game) Rotate180() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state != StatePlaying {
+		return false
+	}
+	if g.spawningLocked() {
+		g.bufferRotateLocked(2)
+		return true
+	}
 
 	collision := func(x, y int, shape piece.Shape) bool {
 		return g.board.CheckCollision(x, y, shape)
 	}
 
-	return g.current.Rotate(collision)
+	if !g.current.Rotate180(collision) {
+		g.traceMove("rotate 180", false)
+		return false
+	}
+	g.traceMove("rotate 180", true)
+	g.markChanged()
+	return true
+}
+
+// markChanged records that the game state changed
+// Must be called with mu held
+func (g *Game) markChanged() {
+	g.seq++
+}
+
+// GetSeq returns a sequence number that increases whenever the game state
+// changes, so callers can skip sending unchanged state
+func (g *Game) GetSeq() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.seq
 }
 
 // lockAndSpawn locks the current piece and spawns a new one
@@ -204,16 +562,62 @@ func (g *Game) lockAndSpawn() {
 
 // lockAndSpawnLocked is the internal implementation that assumes mu is already held
 func (g *Game) lockAndSpawnLocked() {
+	g.pushUndoLocked()
+
 	// Lock the piece
+	g.recordHeatmapLocked()
 	g.board.LockPiece(g.current)
-
-	// Clear lines and update score
-	linesCleared := g.board.ClearLines()
+	g.pieceCounts[g.current.Type]++
+	lockedOut := g.current.Y+g.current.GetShape().Height() <= 0
+
+	// Clear lines and update score; with a line clear delay the full rows
+	// stay on the board until it passes
+	now := g.clock.Now()
+	cleared := g.board
+	full := g.board.FullRows()
+	if g.clearDelay > 0 && len(full) > 0 {
+		cleared = g.board.Clone()
+	}
+	linesCleared := cleared.ClearLines().Count()
 	g.updateScore(linesCleared)
+	g.recordAttack(linesCleared, cleared.IsCleared())
+	g.tracef("lock: %s at (%d,%d), cleared %d, score %d, lines %d, level %d",
+		g.current.Type, g.current.X, g.current.Y, linesCleared, g.score, g.lines, g.level)
+
+	g.canHold = true
+	// A piece locked entirely in the hidden rows ends the game (lock out);
+	// zen games clear the top of the stack when the next piece spawns instead
+	if lockedOut && linesCleared == 0 && g.mode != ModeZen {
+		g.tracef("lock out: %s above the board", g.current.Type)
+		g.finishLocked(now, false)
+		return
+	}
+	// Garbage from a versus opponent rises when a lock clears nothing
+	if linesCleared == 0 && !g.raiseGarbageLocked() {
+		g.tracef("top out: garbage pushed the stack above the board")
+		g.finishLocked(now, false)
+		return
+	}
+	if cleared != g.board {
+		g.clearing = full
+		g.spawnAt = now.Add(g.clearDelay)
+		return
+	}
+	g.finishLockLocked(now)
+}
 
-	// Spawn new piece
-	g.spawnPiece()
-	g.prepareNext()
+// finishLockLocked ends the game if the mode's goal was reached by the last
+// lock, or brings in the next piece
+// Must be called with mu held
+func (g *Game) finishLockLocked(now time.Time) {
+	// Stop before spawning if the mode's goal was reached
+	g.checkGoalLocked(now)
+	if g.state == StateGameOver {
+		return
+	}
+
+	// Spawn new piece; hold became available again at the lock
+	g.spawnAfterLockLocked(now)
 }
 
 // updateScore updates the score based on lines cleared
@@ -230,57 +634,121 @@ func (g *Game) updateScore(linesCleared int) {
 		4: 800,
 	}
 
-	g.score += scoreMultiplier[linesCleared] * g.level
+	points := scoreMultiplier[linesCleared] * g.level
+	g.score += points
 
 	// Update lines
 	g.lines += linesCleared
 
-	// Update level every 10 lines
+	// Update level every 10 lines; zen games stay at level 1, so gravity
+	// never speeds up
+	event := ScoreEvent{Lines: linesCleared, Points: points}
 	newLevel := (g.lines / 10) + 1
-	if newLevel > g.level {
+	if newLevel > g.level && g.mode != ModeZen {
 		g.level = newLevel
-		g.dropInterval = calculateDropInterval(g.level)
+		g.dropInterval = g.gravity(g.level)
+		event.LevelUp = true
 	}
+	event.Level = g.level
+	g.events = append(g.events, event)
 }
 
-// calculateDropInterval calculates the drop interval for a given level
-func calculateDropInterval(level int) time.Duration {
-	// Formula: max(100ms, 1000ms - (level-1) * 100ms)
-	// Level 1: 1000ms, Level 10: 100ms
-	ms := 1000 - (level-1)*100
-	if ms < 100 {
-		ms = 100
+// recordAttack queues the attack produced by a line clear
+// perfectClear is true if the clear left the board empty
+func (g *Game) recordAttack(linesCleared int, perfectClear bool) {
+	if linesCleared == 0 {
+		return
 	}
-	return time.Duration(ms) * time.Millisecond
+
+	attack := Attack{
+		Lines:   linesCleared,
+		Garbage: attackTable[linesCleared],
+	}
+	if perfectClear {
+		attack.PerfectClear = true
+		attack.Garbage += perfectClearAttack
+	}
+	attack.Garbage = g.offsetGarbageLocked(attack.Garbage)
+
+	g.attacks = append(g.attacks, attack)
+}
+
+// TakeAttacks returns the attacks produced since the last call and clears the queue
+func (g *Game) TakeAttacks() []Attack {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	attacks := g.attacks
+	g.attacks = nil
+	return attacks
+}
+
+// TakeScoreEvents returns the score events produced since the last call and
+// clears the queue
+func (g *Game) TakeScoreEvents() []ScoreEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	events := g.events
+	g.events = nil
+	return events
 }
 
 // Pause pauses the game
 func (g *Game) Pause() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-
-	if g.state == StatePlaying {
-		g.state = StatePaused
-	}
+	g.pauseLocked()
 }
 
 // Resume resumes the game
 func (g *Game) Resume() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	g.resumeLocked()
+}
 
-	if g.state == StatePaused {
-		g.state = StatePlaying
-		g.lastDrop = time.Now()
+// TogglePause pauses a running game or resumes a paused one
+// The state is checked and changed under one lock, so a single toggle
+// cannot race with gravity or game over
+func (g *Game) TogglePause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case StatePlaying:
+		g.pauseLocked()
+	case StatePaused:
+		g.resumeLocked()
 	}
 }
 
-// TogglePause toggles the pause state
-func (g *Game) TogglePause() {
+// pauseLocked pauses a running game; must be called with mu held
+func (g *Game) pauseLocked() {
 	if g.state == StatePlaying {
-		g.Pause()
-	} else if g.state == StatePaused {
-		g.Resume()
+		g.state = StatePaused
+		g.pausedAt = g.clock.Now()
+		g.tracef("pause")
+		g.markChanged()
+	}
+}
+
+// resumeLocked resumes a paused game; must be called with mu held
+func (g *Game) resumeLocked() {
+	if g.state == StatePaused {
+		pause := g.clock.Now().Sub(g.pausedAt)
+		g.state = StatePlaying
+		g.pausedFor += pause
+		// Time spent paused does not count toward the next drop or spawn
+		g.lastDrop = g.lastDrop.Add(pause)
+		if !g.landedAt.IsZero() {
+			g.landedAt = g.landedAt.Add(pause)
+		}
+		if g.spawningLocked() {
+			g.spawnAt = g.spawnAt.Add(pause)
+		}
+		g.tracef("resume")
+		g.markChanged()
 	}
 }
 
@@ -289,82 +757,208 @@ func (g *Game) Update() bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	return g.updateLocked(g.clock.Now())
+}
+
+// updateLocked applies the goal check and gravity due at now
+// Must be called with mu held
+func (g *Game) updateLocked(now time.Time) bool {
 	if g.state != StatePlaying {
 		return false
 	}
 
-	now := time.Now()
-	if now.Sub(g.lastDrop) >= g.dropInterval {
-		g.lastDrop = now
+	// Timed modes can end between drops
+	g.checkGoalLocked(now)
+	if g.state != StatePlaying {
+		g.markChanged()
+		return true
+	}
 
-		collision := func(x, y int, shape piece.Shape) bool {
-			return g.board.CheckCollision(x, y, shape)
-		}
+	if !g.applyGravityLocked(now) {
+		return false
+	}
+	g.markChanged()
+	return true
+}
 
-		// Try to move down
-		if !g.current.MoveDown(collision) {
-			// Piece locked, spawn new piece
-			g.lockAndSpawnLocked()
-		}
+// TimeUntilDrop returns how long until gravity next moves the piece
+// When the game is not playing it returns the full drop interval
+func (g *Game) TimeUntilDrop() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
-		return true
+	if g.state != StatePlaying {
+		return g.dropInterval
 	}
-
-	return false
+	return g.timeUntilGravityLocked(g.clock.Now())
 }
 
 // GetState returns the current game state
 func (g *Game) GetState() State {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.state
 }
 
-// GetBoard returns the game board
+// GetBoard returns a copy of the game board
 func (g *Game) GetBoard() *board.Board {
-	return g.board
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.board.Clone()
 }
 
-// GetCurrentPiece returns the current piece
+// GetCurrentPiece returns a copy of the current piece, or nil if there is none
 func (g *Game) GetCurrentPiece() *piece.Piece {
-	return g.current
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return clonePiece(g.current)
 }
 
-// GetNextPiece returns the next piece
+// GetNextPiece returns a copy of the next piece, or nil if there is none
 func (g *Game) GetNextPiece() *piece.Piece {
-	return g.next
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return clonePiece(g.next)
+}
+
+// clonePiece returns a copy of p, so callers can read it without the lock
+func clonePiece(p *piece.Piece) *piece.Piece {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// GetNextPieces returns the types of the next n pieces, starting with the next
+// piece, followed by the pieces set up in practice mode and the randomizer's
+func (g *Game) GetNextPieces(n int) []piece.Type {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if n <= 0 || g.next == nil {
+		return nil
+	}
+	types := append([]piece.Type{g.next.Type}, g.queue...)
+	if len(types) >= n {
+		return types[:n]
+	}
+	// Peeking may shuffle bags ahead, which changes the generator
+	return append(types, g.generator.PeekN(n-len(types))...)
 }
 
 // GetScore returns the current score
 func (g *Game) GetScore() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.score
 }
 
 // GetLevel returns the current level
 func (g *Game) GetLevel() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.level
 }
 
 // GetLines returns the number of lines cleared
 func (g *Game) GetLines() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.lines
 }
 
+// GetStackHeight returns the height of the highest column of locked cells
+func (g *Game) GetStackHeight() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.board.StackHeight()
+}
+
+// GetSeed returns the seed used for the piece generator
+func (g *Game) GetSeed() int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.seed
+}
+
+// GetRandomizer returns the algorithm choosing the piece sequence
+func (g *Game) GetRandomizer() piece.RandomizerKind {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.generator.Kind()
+}
+
+// GetRuleset returns the ruleset the game is played under
+func (g *Game) GetRuleset() Ruleset {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.ruleset
+}
+
+// GetHoldPiece returns a copy of the held piece, or nil if nothing is held
+func (g *Game) GetHoldPiece() *piece.Piece {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return clonePiece(g.hold)
+}
+
+// CanHold returns true if hold is available for the current piece
+func (g *Game) CanHold() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.canHold
+}
+
+// GetPieceCounts returns the number of pieces locked so far, by type
+func (g *Game) GetPieceCounts() map[piece.Type]int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	counts := make(map[piece.Type]int, len(g.pieceCounts))
+	for t, n := range g.pieceCounts {
+		counts[t] = n
+	}
+	return counts
+}
+
+// GetPiecesPlaced returns the total number of pieces locked so far
+func (g *Game) GetPiecesPlaced() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	total := 0
+	for _, n := range g.pieceCounts {
+		total += n
+	}
+	return total
+}
+
 // GetDropInterval returns the current drop interval
 func (g *Game) GetDropInterval() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.dropInterval
 }
 
 // IsGameOver returns true if the game is over
 func (g *Game) IsGameOver() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.state == StateGameOver
 }
 
 // IsPaused returns true if the game is paused
 func (g *Game) IsPaused() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.state == StatePaused
 }
 
 // IsPlaying returns true if the game is playing
 func (g *Game) IsPlaying() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.state == StatePlaying
 }
 
@@ -387,11 +981,11 @@ func (g *Game) GetStateSnapshot() (boardCopy [][]string, current *piece.Piece, n
 	defer g.mu.RUnlock()
 
 	// Clone board
-	boardCopy = make([][]string, board.Height)
 	b := g.board
-	for y := 0; y < board.Height; y++ {
-		boardCopy[y] = make([]string, board.Width)
-		for x := 0; x < board.Width; x++ {
+	boardCopy = make([][]string, b.Height())
+	for y := 0; y < b.Height(); y++ {
+		boardCopy[y] = make([]string, b.Width())
+		for x := 0; x < b.Width(); x++ {
 			cell, _ := b.GetCell(x, y)
 			if cell.Empty {
 				boardCopy[y][x] = ""
@@ -402,25 +996,8 @@ func (g *Game) GetStateSnapshot() (boardCopy [][]string, current *piece.Piece, n
 	}
 
 	// Clone pieces to avoid shared references
-	if g.current != nil {
-		current = &piece.Piece{
-			Type:     g.current.Type,
-			Color:    g.current.Color,
-			X:        g.current.X,
-			Y:        g.current.Y,
-			Rotation: g.current.Rotation,
-		}
-	}
-
-	if g.next != nil {
-		next = &piece.Piece{
-			Type:     g.next.Type,
-			Color:    g.next.Color,
-			X:        g.next.X,
-			Y:        g.next.Y,
-			Rotation: g.next.Rotation,
-		}
-	}
+	current = clonePiece(g.current)
+	next = clonePiece(g.next)
 
 	stateStr = g.state.String()
 	score = g.score
@@ -433,10 +1010,13 @@ func (g *Game) GetStateSnapshot() (boardCopy [][]string, current *piece.Piece, n
 
 // GetGameState returns a complete snapshot of the game state
 func (g *Game) GetGameState() GameState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
 	return GameState{
 		Board:        g.board.Clone(),
-		CurrentPiece: g.current,
-		NextPiece:    g.next,
+		CurrentPiece: clonePiece(g.current),
+		NextPiece:    clonePiece(g.next),
 		State:        g.state,
 		Score:        g.score,
 		Level:        g.level,
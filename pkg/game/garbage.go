@@ -0,0 +1,70 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/ican2002/tetris/pkg/board"
+)
+
+// ReceiveGarbage queues rows of garbage sent by an opponent
+// The rows rise into the board when the next piece locks without clearing a
+// line; line clears first cancel queued rows with the garbage they would send
+func (g *Game) ReceiveGarbage(rows int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if rows <= 0 || g.state == StateGameOver {
+		return
+	}
+	g.pendingGarbage += rows
+	g.tracef("garbage: %d rows queued, %d pending", rows, g.pendingGarbage)
+	g.markChanged()
+}
+
+// GetPendingGarbage returns the garbage rows queued to rise into the board
+func (g *Game) GetPendingGarbage() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pendingGarbage
+}
+
+// offsetGarbageLocked cancels queued garbage with the garbage of an attack,
+// returning what is left of the attack to send
+// Must be called with mu held
+func (g *Game) offsetGarbageLocked(garbage int) int {
+	cancelled := min(g.pendingGarbage, garbage)
+	g.pendingGarbage -= cancelled
+	return garbage - cancelled
+}
+
+// raiseGarbageLocked pushes the queued garbage into the bottom of the board,
+// with a hole in one column chosen by the game's seed; returns false if it
+// pushed the stack over the top
+// Must be called with mu held
+func (g *Game) raiseGarbageLocked() bool {
+	if g.pendingGarbage == 0 {
+		return true
+	}
+	if g.garbageHoles == nil {
+		g.garbageHoles = rand.New(rand.NewSource(g.seed))
+	}
+	rows := g.pendingGarbage
+	g.pendingGarbage = 0
+	err := g.board.AddGarbageRows(rows, g.garbageHoles.Intn(g.board.Width()))
+	g.tracef("garbage: %d rows raised", rows)
+	return !errors.Is(err, board.ErrTopOut)
+}
+
+// Win ends a versus game whose opponent topped out; the game counts as
+// completed, like one that reached its mode's goal
+func (g *Game) Win() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state == StateGameOver {
+		return
+	}
+	g.finishLocked(g.clock.Now(), true)
+	g.markChanged()
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestReceiveGarbage verifies that queued garbage rises when a piece locks
+// without clearing a line
+func TestReceiveGarbage(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+	g.ReceiveGarbage(3)
+	if got := g.GetPendingGarbage(); got != 3 {
+		t.Fatalf("GetPendingGarbage() = %d, want 3", got)
+	}
+	g.HardDrop()
+	if got := g.GetPendingGarbage(); got != 0 {
+		t.Errorf("GetPendingGarbage() after a lock = %d, want 0", got)
+	}
+	if got := g.GetBoard().GarbageRows(); got != 3 {
+		t.Errorf("GarbageRows() = %d, want 3", got)
+	}
+}
+
+// TestAttacks verifies the garbage each line clear sends, and the bonus for
+// a perfect clear
+func TestAttacks(t *testing.T) {
+	well := "XXXXXXXXX."
+	tests := []struct {
+		name   string
+		rows   []string
+		rotate bool // Drop the I piece upright into the right column
+		want   Attack
+	}{
+		{"single", []string{well, "XXXXX.XXXX"}, true, Attack{Lines: 1}},
+		{"double", []string{well, well, "XXXXX.XXXX"}, true, Attack{Lines: 2, Garbage: 1}},
+		{"triple", []string{well, well, well, "XXXXX.XXXX"}, true, Attack{Lines: 3, Garbage: 2}},
+		{"tetris", []string{well, well, well, well, "XXXXX.XXXX"}, true, Attack{Lines: 4, Garbage: 4}},
+		{"perfect single", []string{"XXXXXX...."}, false, Attack{Lines: 1, Garbage: 10, PerfectClear: true}},
+		{"perfect tetris", []string{well, well, well, well}, true, Attack{Lines: 4, Garbage: 14, PerfectClear: true}},
+	}
+
+	for _, tt := range tests {
+		g := NewWithConfig(Config{Mode: ModePractice, Randomizer: piece.RandomizerScripted,
+			Script: []piece.Type{piece.TypeI}, Headless: true})
+		if err := g.SetBoard(tt.rows); err != nil {
+			t.Fatalf("%s: SetBoard() error = %v", tt.name, err)
+		}
+		if tt.rotate {
+			g.Rotate()
+		}
+		for g.MoveRight() {
+		}
+		g.HardDrop()
+		if got := g.TakeAttacks(); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s: TakeAttacks() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+	g.HardDrop()
+	if got := g.TakeAttacks(); len(got) != 0 {
+		t.Errorf("TakeAttacks() after a lock without a clear = %+v, want none", got)
+	}
+}
+
+// TestGarbageOffset verifies that a line clear cancels queued garbage before
+// sending any
+func TestGarbageOffset(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Mode: ModePractice, Headless: true})
+	well := "XXXXXXXXX."
+	if err := g.SetBoard([]string{well, well, well, well}); err != nil {
+		t.Fatalf("SetBoard() error = %v", err)
+	}
+	if err := g.SetNextPieces([]piece.Type{piece.TypeI}); err != nil {
+		t.Fatalf("SetNextPieces() error = %v", err)
+	}
+	g.HardDrop()
+	g.TakeAttacks()
+
+	g.ReceiveGarbage(6)
+	g.Rotate()
+	for g.MoveRight() {
+	}
+	g.HardDrop()
+	attacks := g.TakeAttacks()
+	if len(attacks) != 1 || attacks[0].Lines != 4 || attacks[0].Garbage != 0 {
+		t.Errorf("TakeAttacks() = %+v, want a tetris with its 4 rows cancelled", attacks)
+	}
+	if got := g.GetPendingGarbage(); got != 2 {
+		t.Errorf("GetPendingGarbage() = %d, want 2", got)
+	}
+}
+
+func TestWin(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+	g.Win()
+	if !g.IsGameOver() || !g.IsCompleted() {
+		t.Errorf("after Win() game over = %v, completed = %v, want both", g.IsGameOver(), g.IsCompleted())
+	}
+}
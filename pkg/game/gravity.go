@@ -0,0 +1,117 @@
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// Gravity returns the time the current piece takes to fall one row at a level
+// An interval of 0 is 20G: the piece falls to the floor as soon as it spawns
+type Gravity func(level int) time.Duration
+
+const (
+	// Frame is one frame at 60 Hz, the unit guideline gravity is specified in
+	Frame = time.Second / 60
+	// DefaultLockDelay is how long a piece rests on the stack before it locks
+	DefaultLockDelay = 500 * time.Millisecond
+	// Level20G is the first level at which the guideline curve is 20G
+	Level20G = 20
+)
+
+// GuidelineGravity is the guideline gravity curve,
+// (0.8 - (level-1) * 0.007) ^ (level-1) seconds per row, with 20G from Level20G
+func GuidelineGravity(level int) time.Duration {
+	if level < 1 {
+		level = 1
+	}
+	if level >= Level20G {
+		return 0
+	}
+	seconds := math.Pow(0.8-float64(level-1)*0.007, float64(level-1))
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// LinearGravity is the original curve: 1000ms at level 1, 100ms less per
+// level down to 100ms at level 10 and above
+func LinearGravity(level int) time.Duration {
+	ms := 1000 - (level-1)*100
+	if ms < 100 {
+		ms = 100
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// canFallLocked reports whether the current piece can move down a row
+// Must be called with mu held
+func (g *Game) canFallLocked() bool {
+	p := g.current
+	return !g.board.CheckCollision(p.X, p.Y+1, p.GetShape())
+}
+
+// applyGravityLocked moves the current piece down by gravity and locks it
+// once it has rested on the stack for the lock delay
+// Returns true if the game changed; must be called with mu held
+func (g *Game) applyGravityLocked(now time.Time) bool {
+	if g.spawningLocked() {
+		if now.Before(g.spawnAt) {
+			return false
+		}
+		g.lastDrop = now
+		g.endDelayLocked(now)
+		return true
+	}
+	if !g.canFallLocked() {
+		if g.landedAt.IsZero() {
+			g.landedAt = now
+		}
+		if now.Sub(g.landedAt) < g.lockDelay {
+			return false
+		}
+		g.lastDrop = now
+		g.lockAndSpawnLocked()
+		return true
+	}
+
+	// Moving off a ledge cancels the lock delay
+	g.landedAt = time.Time{}
+	elapsed := now.Sub(g.lastDrop)
+	if elapsed < g.dropInterval {
+		return false
+	}
+	g.lastDrop = now
+
+	// At fast levels several rows can pass between updates
+	rows := g.board.Height()
+	if g.dropInterval > 0 && int(elapsed/g.dropInterval) < rows {
+		rows = int(elapsed / g.dropInterval)
+	}
+
+	collision := func(x, y int, shape piece.Shape) bool {
+		return g.board.CheckCollision(x, y, shape)
+	}
+	for i := 0; i < rows && g.current.MoveDown(collision); i++ {
+	}
+	if !g.canFallLocked() {
+		g.landedAt = now
+	}
+	return true
+}
+
+// timeUntilGravityLocked returns how long until applyGravityLocked next has work to do
+// Must be called with mu held
+func (g *Game) timeUntilGravityLocked(now time.Time) time.Duration {
+	var d time.Duration
+	if g.spawningLocked() {
+		d = g.spawnAt.Sub(now)
+	} else if !g.landedAt.IsZero() && !g.canFallLocked() {
+		d = g.lockDelay - now.Sub(g.landedAt)
+	} else {
+		d = g.dropInterval - now.Sub(g.lastDrop)
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+)
+
+// TestGuidelineGravity checks the guideline interval at each level
+func TestGuidelineGravity(t *testing.T) {
+	tests := []struct {
+		level int
+		want  time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 793 * time.Millisecond},
+		{3, 617796 * time.Microsecond},
+		{4, 472729 * time.Microsecond},
+		{5, 355197 * time.Microsecond},
+		{6, 262004 * time.Microsecond},
+		{7, 189677 * time.Microsecond},
+		{8, 134735 * time.Microsecond},
+		{9, 93882 * time.Microsecond},
+		{10, 64152 * time.Microsecond},
+		{11, 42976 * time.Microsecond},
+		{12, 28218 * time.Microsecond},
+		{13, 18153 * time.Microsecond},
+		{14, 11439 * time.Microsecond},
+		{15, 7059 * time.Microsecond}, // Faster than one frame from here on
+		{16, 4264 * time.Microsecond},
+		{17, 2520 * time.Microsecond},
+		{18, 1457 * time.Microsecond},
+		{19, 824 * time.Microsecond},
+		{20, 0}, // 20G
+		{30, 0},
+	}
+
+	for _, tt := range tests {
+		got := GuidelineGravity(tt.level)
+		if diff := got - tt.want; diff < -time.Microsecond || diff > time.Microsecond {
+			t.Errorf("GuidelineGravity(%d) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+// TestGravity20G checks that a 20G piece falls to the floor at once and
+// locks only after the lock delay
+func TestGravity20G(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	g := NewWithConfig(Config{
+		Seed:      1,
+		Gravity:   func(int) time.Duration { return 0 },
+		LockDelay: 20 * time.Millisecond,
+		Clock:     clk,
+	})
+	spawnY := g.GetCurrentPiece().Y
+
+	if !g.Update() {
+		t.Fatal("Update() did not move the piece")
+	}
+	landedY := g.GetCurrentPiece().Y
+	if g.canFallLocked() {
+		t.Fatalf("piece at y=%d can still fall after a 20G update", landedY)
+	}
+	if landedY <= spawnY {
+		t.Fatalf("piece at y=%d after a 20G update, want below its spawn row %d", landedY, spawnY)
+	}
+	if g.GetPiecesPlaced() != 0 {
+		t.Fatal("piece locked before the lock delay")
+	}
+
+	clk.Advance(19 * time.Millisecond)
+	g.Update()
+	if g.GetPiecesPlaced() != 0 {
+		t.Fatal("piece locked before the lock delay")
+	}
+
+	clk.Advance(time.Millisecond)
+	g.Update()
+	if g.GetPiecesPlaced() != 1 {
+		t.Fatalf("pieces placed = %d after the lock delay, want 1", g.GetPiecesPlaced())
+	}
+	if got := g.GetCurrentPiece().Y; got >= landedY {
+		t.Errorf("current piece at y=%d after lock, want a new piece above y=%d where the last one landed", got, landedY)
+	}
+}
+
+// TestLockDelayPaused checks that time spent paused does not count toward
+// the lock delay of a landed piece
+func TestLockDelayPaused(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	g := NewWithConfig(Config{
+		Seed:      1,
+		Gravity:   func(int) time.Duration { return 0 },
+		LockDelay: 20 * time.Millisecond,
+		Clock:     clk,
+	})
+	g.Update()
+
+	clk.Advance(10 * time.Millisecond)
+	g.Pause()
+	clk.Advance(10 * time.Second)
+	g.Resume()
+	g.Update()
+	if g.GetPiecesPlaced() != 0 {
+		t.Fatal("piece locked on the first update after a pause")
+	}
+	if got, want := g.TimeUntilDrop(), 10*time.Millisecond; got != want {
+		t.Errorf("TimeUntilDrop() after the pause = %v, want %v", got, want)
+	}
+
+	clk.Advance(10 * time.Millisecond)
+	g.Update()
+	if g.GetPiecesPlaced() != 1 {
+		t.Errorf("pieces placed = %d after the rest of the lock delay, want 1", g.GetPiecesPlaced())
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits of a handicap, keeping a handicapped game playable
+const (
+	MaxHandicapGarbage = 12   // Most garbage rows a board may start with
+	MinHandicapGravity = 0.25 // Fastest gravity scale: four times the usual speed
+	MaxHandicapGravity = 4    // Slowest gravity scale: a quarter of the usual speed
+)
+
+// Handicap evens out a versus game between players of mixed skill
+// The zero Handicap leaves the game as it is
+type Handicap struct {
+	GarbageRows int     // Garbage rows the board starts with
+	Gravity     float64 // Scales the drop interval: 2 falls half as fast, 0.5 twice as fast (0 = 1)
+	HidePreview bool    // The next piece is not shown until it spawns
+}
+
+// ParseHandicap parses a handicap written as comma-separated settings:
+// garbage:<rows>, gravity:<scale> and preview:<pieces>, e.g.
+// "garbage:4,gravity:1.5,preview:0"; the game shows one piece ahead, so the
+// preview can only be reduced to 0. An empty string is no handicap
+func ParseHandicap(s string) (Handicap, error) {
+	var h Handicap
+	for _, setting := range strings.Split(s, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(setting, ":")
+		if !ok {
+			return Handicap{}, fmt.Errorf("handicap setting %q is not key:value", setting)
+		}
+		switch key {
+		case "garbage":
+			rows, err := strconv.Atoi(value)
+			if err != nil || rows < 0 || rows > MaxHandicapGarbage {
+				return Handicap{}, fmt.Errorf("handicap garbage %q is not 0 to %d rows", value, MaxHandicapGarbage)
+			}
+			h.GarbageRows = rows
+		case "gravity":
+			scale, err := strconv.ParseFloat(value, 64)
+			if err != nil || scale < MinHandicapGravity || scale > MaxHandicapGravity {
+				return Handicap{}, fmt.Errorf("handicap gravity %q is not %g to %g", value, MinHandicapGravity, float64(MaxHandicapGravity))
+			}
+			h.Gravity = scale
+		case "preview":
+			switch value {
+			case "0":
+				h.HidePreview = true
+			case "1":
+				h.HidePreview = false
+			default:
+				return Handicap{}, fmt.Errorf("handicap preview %q is not 0 or 1", value)
+			}
+		default:
+			return Handicap{}, fmt.Errorf("unknown handicap setting %q", key)
+		}
+	}
+	return h, nil
+}
+
+// String returns the handicap in the form ParseHandicap reads, "" for none
+func (h Handicap) String() string {
+	var parts []string
+	if h.GarbageRows > 0 {
+		parts = append(parts, fmt.Sprintf("garbage:%d", h.GarbageRows))
+	}
+	if h.Gravity > 0 && h.Gravity != 1 {
+		parts = append(parts, "gravity:"+strconv.FormatFloat(h.Gravity, 'g', -1, 64))
+	}
+	if h.HidePreview {
+		parts = append(parts, "preview:0")
+	}
+	return strings.Join(parts, ",")
+}
+
+// IsZero reports whether the handicap leaves the game as it is
+func (h Handicap) IsZero() bool {
+	return h.String() == ""
+}
+
+// scaleGravity returns gravity with every drop interval scaled by the
+// handicap; 20G stays 20G
+func (h Handicap) scaleGravity(gravity Gravity) Gravity {
+	if h.Gravity <= 0 || h.Gravity == 1 {
+		return gravity
+	}
+	return func(level int) time.Duration {
+		return time.Duration(float64(gravity(level)) * h.Gravity)
+	}
+}
+
+// GetHandicap returns the handicap the game was created with
+func (g *Game) GetHandicap() Handicap {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.handicap
+}
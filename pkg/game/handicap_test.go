@@ -0,0 +1,56 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseHandicap checks handicaps parse and print back in the same form
+func TestParseHandicap(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Handicap
+		wantErr bool
+	}{
+		{"", Handicap{}, false},
+		{"garbage:4", Handicap{GarbageRows: 4}, false},
+		{"garbage:4,gravity:1.5,preview:0", Handicap{GarbageRows: 4, Gravity: 1.5, HidePreview: true}, false},
+		{" gravity:0.5 , preview:1", Handicap{Gravity: 0.5}, false},
+		{"garbage:13", Handicap{}, true},
+		{"gravity:10", Handicap{}, true},
+		{"preview:3", Handicap{}, true},
+		{"speed:2", Handicap{}, true},
+		{"garbage", Handicap{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHandicap(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHandicap(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHandicap(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if again, _ := ParseHandicap(got.String()); again != got {
+			t.Errorf("ParseHandicap(%q.String()) = %+v, want %+v", tt.in, again, got)
+		}
+	}
+}
+
+// TestHandicapGame verifies a handicapped game starts on garbage with scaled
+// gravity
+func TestHandicapGame(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Handicap: Handicap{GarbageRows: 3, Gravity: 2}})
+	if got := g.GetStackHeight(); got != 3 {
+		t.Errorf("stack height = %d, want 3 rows of garbage", got)
+	}
+	if got, want := g.GetDropInterval(), 2*time.Second; got != want {
+		t.Errorf("drop interval = %v, want %v", got, want)
+	}
+
+	plain := NewWithConfig(Config{Seed: 1})
+	if got := plain.GetStackHeight(); got != 0 {
+		t.Errorf("stack height without a handicap = %d, want 0", got)
+	}
+}
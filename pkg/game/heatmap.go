@@ -0,0 +1,81 @@
+package game
+
+// Heatmap counts, for each cell of the visible board, top row first, how many
+// locked pieces filled it and how many times a lock covered it as a hole
+// Cells locked in the hidden rows are not counted
+type Heatmap struct {
+	Placements [][]int `json:"placements"`
+	Holes      [][]int `json:"holes"`
+}
+
+// NewHeatmap creates a heatmap of a board with all counts at zero
+func NewHeatmap(width, height int) Heatmap {
+	return Heatmap{Placements: newGrid(width, height), Holes: newGrid(width, height)}
+}
+
+func newGrid(width, height int) [][]int {
+	grid := make([][]int, height)
+	for y := range grid {
+		grid[y] = make([]int, width)
+	}
+	return grid
+}
+
+// Add adds the counts of another heatmap to h; it returns false, leaving h
+// unchanged, if o is of another board size
+func (h Heatmap) Add(o Heatmap) bool {
+	if len(o.Placements) != len(h.Placements) || len(o.Holes) != len(h.Holes) {
+		return false
+	}
+	for y := range h.Placements {
+		if len(o.Placements[y]) != len(h.Placements[y]) || len(o.Holes[y]) != len(h.Holes[y]) {
+			return false
+		}
+	}
+	for y := range h.Placements {
+		for x := range h.Placements[y] {
+			h.Placements[y][x] += o.Placements[y][x]
+			h.Holes[y][x] += o.Holes[y][x]
+		}
+	}
+	return true
+}
+
+// recordHeatmapLocked counts the cells the current piece is about to lock
+// into and the holes it covers
+// Must be called with mu held, before the piece is locked
+func (g *Game) recordHeatmapLocked() {
+	if g.heatmap.Placements == nil {
+		g.heatmap = NewHeatmap(g.board.Width(), g.board.Height())
+	}
+	count := func(grid [][]int, x, y int) {
+		if y >= 0 && y < len(grid) && x >= 0 && x < len(grid[y]) {
+			grid[y][x]++
+		}
+	}
+
+	shape := g.current.GetShape()
+	for r := range shape {
+		for c, filled := range shape[r] {
+			if filled == 1 {
+				count(g.heatmap.Placements, g.current.X+c, g.current.Y+r)
+			}
+		}
+	}
+	for _, cell := range g.board.HolesUnder(g.current) {
+		count(g.heatmap.Holes, cell[0], cell[1])
+	}
+}
+
+// GetHeatmap returns where the pieces of this game were locked and where
+// holes formed under them
+func (g *Game) GetHeatmap() Heatmap {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	h := NewHeatmap(g.board.Width(), g.board.Height())
+	if g.heatmap.Placements != nil {
+		h.Add(g.heatmap)
+	}
+	return h
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestHeatmap verifies the heatmap counts the cells pieces locked into and the
+// holes they covered
+func TestHeatmap(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Randomizer: piece.RandomizerScripted,
+		Script: []piece.Type{piece.TypeO, piece.TypeS}, Headless: true})
+	g.HardDrop() // O at the bottom of columns 4 and 5
+	g.HardDrop() // S on top of it, overhanging column 3 and covering column 5
+
+	h := g.GetHeatmap()
+	placements := [][2]int{{4, 18}, {5, 18}, {4, 19}, {5, 19}, {4, 16}, {5, 16}, {3, 17}, {4, 17}}
+	holes := [][2]int{{3, 18}, {3, 19}, {5, 17}}
+	for _, tt := range []struct {
+		name  string
+		grid  [][]int
+		cells [][2]int
+	}{{"Placements", h.Placements, placements}, {"Holes", h.Holes, holes}} {
+		want := NewHeatmap(10, 20).Placements
+		for _, c := range tt.cells {
+			want[c[1]][c[0]]++
+		}
+		for y := range want {
+			for x := range want[y] {
+				if tt.grid[y][x] != want[y][x] {
+					t.Errorf("%s[%d][%d] = %d, want %d", tt.name, y, x, tt.grid[y][x], want[y][x])
+				}
+			}
+		}
+	}
+}
+
+// TestHeatmapAdd verifies heatmaps of the same size add up and others are refused
+func TestHeatmapAdd(t *testing.T) {
+	total := NewHeatmap(10, 20)
+	h := NewHeatmap(10, 20)
+	h.Placements[19][0], h.Holes[18][1] = 2, 1
+	if !total.Add(h) || !total.Add(h) {
+		t.Fatal("Add() of a heatmap of the same size = false")
+	}
+	if total.Placements[19][0] != 4 || total.Holes[18][1] != 2 {
+		t.Errorf("counts = %d, %d, want 4, 2", total.Placements[19][0], total.Holes[18][1])
+	}
+	if total.Add(NewHeatmap(10, 16)) {
+		t.Error("Add() of a heatmap of another size = true")
+	}
+}
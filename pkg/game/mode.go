@@ -0,0 +1,212 @@
+package game
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/puzzle"
+)
+
+// Mode identifies a game mode and its win condition
+type Mode string
+
+const (
+	ModeMarathon Mode = "marathon" // Play until the stack tops out
+	ModeSprint   Mode = "sprint"   // Clear 40 lines as fast as possible
+	ModeUltra    Mode = "ultra"    // Score as much as possible in 2 minutes
+	ModePractice Mode = "practice" // Marathon with Undo; not ranked
+	ModeDig      Mode = "dig"      // Clear the garbage rows the board starts with
+	ModeZen      Mode = "zen"      // Endless play at constant gravity; topping out clears the top rows; not ranked
+	ModePuzzle   Mode = "puzzle"   // Reach a puzzle level's objective with its pieces; not ranked
+)
+
+const (
+	// SprintLines is the number of lines to clear in sprint mode
+	SprintLines = 40
+	// UltraDuration is the time limit in ultra mode
+	UltraDuration = 2 * time.Minute
+	// DefaultDigRows is the number of garbage rows a dig game starts with
+	DefaultDigRows = 10
+)
+
+// IsValid returns true if m is a known game mode
+func (m Mode) IsValid() bool {
+	switch m {
+	case ModeMarathon, ModeSprint, ModeUltra, ModePractice, ModeDig, ModeZen, ModePuzzle:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ranked returns true if games in mode m count towards leaderboards and stats
+func (m Mode) Ranked() bool {
+	return m != ModePractice && m != ModeZen && m != ModePuzzle
+}
+
+// ModeStatus reports progress towards the current mode's goal
+type ModeStatus struct {
+	Mode             Mode
+	Elapsed          time.Duration // Play time, excluding pauses
+	LinesRemaining   int           // Lines left to clear (sprint and line puzzles)
+	TimeRemaining    time.Duration // Time left (ultra only)
+	GarbageRemaining int           // Garbage rows left to clear (dig and garbage puzzles)
+	GarbageTotal     int           // Garbage rows the game started with (dig only)
+	Completed        bool          // True if the mode's goal was reached
+
+	// Puzzle progress
+	Puzzle          string // Name of the level
+	Objective       string // The level's objective, for players
+	PiecesRemaining int    // Pieces left to reach the objective with
+}
+
+// elapsedLocked returns the play time so far, excluding pauses
+// Must be called with mu held
+func (g *Game) elapsedLocked(now time.Time) time.Duration {
+	end := now
+	if !g.endedAt.IsZero() {
+		end = g.endedAt
+	} else if g.state == StatePaused {
+		end = g.pausedAt
+	}
+	return end.Sub(g.startedAt) - g.pausedFor
+}
+
+// checkGoalLocked ends the game if the mode's goal has been reached
+// Must be called with mu held
+func (g *Game) checkGoalLocked(now time.Time) {
+	if g.state == StateGameOver {
+		return
+	}
+
+	switch g.mode {
+	case ModeSprint:
+		if g.lines >= SprintLines {
+			g.finishLocked(now, true)
+		}
+	case ModeUltra:
+		if g.elapsedLocked(now) >= UltraDuration {
+			g.finishLocked(now, true)
+		}
+	case ModeDig:
+		if g.board.GarbageRows() == 0 {
+			g.finishLocked(now, true)
+		}
+	case ModePuzzle:
+		if g.puzzle.Done(g.board, g.lines) {
+			g.finishLocked(now, true)
+		} else if g.piecesRemainingLocked() == 0 {
+			g.finishLocked(now, false)
+		}
+	}
+}
+
+// piecesRemainingLocked returns how many pieces of the puzzle level are left
+// to place
+// Must be called with mu held
+func (g *Game) piecesRemainingLocked() int {
+	placed := 0
+	for _, n := range g.pieceCounts {
+		placed += n
+	}
+	return max(len(g.puzzle.Pieces)-placed, 0)
+}
+
+// GetPuzzle returns the level of a puzzle game, or nil in other modes
+func (g *Game) GetPuzzle() *puzzle.Level {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.puzzle
+}
+
+// fillDigRowsLocked fills the bottom of the board with the garbage rows of a
+// dig game, each with a hole in a column chosen by the game's seed
+// Must be called with mu held
+func (g *Game) fillDigRowsLocked() {
+	rng := rand.New(rand.NewSource(g.seed))
+	for i := 0; i < g.digRows; i++ {
+		g.board.AddGarbageRows(1, rng.Intn(g.board.Width()))
+	}
+}
+
+// finishLocked ends the game, recording whether the goal was reached
+// Must be called with mu held
+func (g *Game) finishLocked(now time.Time, completed bool) {
+	g.state = StateGameOver
+	g.completed = completed
+	g.endedAt = now
+	g.tracef("game over: completed %v, score %d, lines %d", completed, g.score, g.lines)
+}
+
+// GetElapsed returns the play time so far, excluding time spent paused
+func (g *Game) GetElapsed() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.elapsedLocked(g.clock.Now())
+}
+
+// GetPausedDuration returns the total time spent paused, including a pause in progress
+func (g *Game) GetPausedDuration() time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	paused := g.pausedFor
+	if g.state == StatePaused {
+		paused += g.clock.Now().Sub(g.pausedAt)
+	}
+	return paused
+}
+
+// GetMode returns the game mode
+func (g *Game) GetMode() Mode {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.mode
+}
+
+// IsCompleted returns true if the game ended by reaching the mode's goal
+func (g *Game) IsCompleted() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.completed
+}
+
+// GetModeStatus returns the progress towards the current mode's goal
+func (g *Game) GetModeStatus() ModeStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	status := ModeStatus{
+		Mode:      g.mode,
+		Elapsed:   g.elapsedLocked(g.clock.Now()),
+		Completed: g.completed,
+	}
+
+	switch g.mode {
+	case ModeSprint:
+		status.LinesRemaining = SprintLines - g.lines
+		if status.LinesRemaining < 0 {
+			status.LinesRemaining = 0
+		}
+	case ModeUltra:
+		status.TimeRemaining = UltraDuration - status.Elapsed
+		if status.TimeRemaining < 0 {
+			status.TimeRemaining = 0
+		}
+	case ModeDig:
+		status.GarbageRemaining = g.board.GarbageRows()
+		status.GarbageTotal = g.digRows
+	case ModePuzzle:
+		status.Puzzle = g.puzzle.Name
+		status.Objective = g.puzzle.Describe()
+		status.PiecesRemaining = g.piecesRemainingLocked()
+		switch g.puzzle.Objective {
+		case puzzle.ObjectiveLines:
+			status.LinesRemaining = max(g.puzzle.Lines-g.lines, 0)
+		case puzzle.ObjectiveGarbage:
+			status.GarbageRemaining = g.board.GarbageRows()
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/puzzle"
+)
+
+// TestDigMode verifies that a dig game starts on its garbage rows and is
+// completed once they are cleared
+func TestDigMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    Mode
+		digRows int
+		want    int
+	}{
+		{"default", ModeDig, 0, DefaultDigRows},
+		{"custom", ModeDig, 3, 3},
+		{"leaves room to spawn", ModeDig, 100, board.DefaultHeight - 4},
+		{"other modes", ModeMarathon, 5, 0},
+	}
+	for _, tt := range tests {
+		g := NewWithConfig(Config{Seed: 2, Mode: tt.mode, DigRows: tt.digRows, Headless: true})
+		status := g.GetModeStatus()
+		if got := g.GetBoard().GarbageRows(); got != tt.want || status.GarbageRemaining != tt.want || status.GarbageTotal != tt.want {
+			t.Errorf("%s: garbage rows %d, left %d, total %d, want %d", tt.name, got, status.GarbageRemaining, status.GarbageTotal, tt.want)
+		}
+	}
+
+	// The same seed digs through the same holes
+	a := NewWithConfig(Config{Seed: 2, Mode: ModeDig, Headless: true})
+	b := NewWithConfig(Config{Seed: 2, Mode: ModeDig, Headless: true})
+	if !reflect.DeepEqual(a.GetBoard().Grid(), b.GetBoard().Grid()) {
+		t.Error("games with the same seed start with different garbage")
+	}
+
+	// Clearing the last garbage row completes the game
+	g := NewWithConfig(Config{Seed: 2, Mode: ModeDig, DigRows: 1, Headless: true})
+	grid := g.GetBoard().Grid()
+	hole := 0
+	for x, cell := range grid[len(grid)-1] {
+		if cell == "" {
+			hole = x
+		}
+	}
+	g.mu.Lock()
+	g.current = piece.New(piece.TypeI)
+	g.mu.Unlock()
+	g.Rotate()
+	cur := g.GetCurrentPiece()
+	for x, cell := range cur.GetShape()[0] {
+		if cell != 0 {
+			hole -= x
+		}
+	}
+	for g.GetCurrentPiece().X > hole && g.MoveLeft() {
+	}
+	for g.GetCurrentPiece().X < hole && g.MoveRight() {
+	}
+	g.HardDrop()
+
+	if !g.IsGameOver() || !g.IsCompleted() {
+		t.Errorf("after clearing the garbage: state %v, completed %v, want a completed game", g.GetState(), g.IsCompleted())
+	}
+}
+
+// TestZenMode verifies that a zen game clears the top of the stack instead of
+// topping out, and keeps its gravity
+func TestZenMode(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 3, Mode: ModeZen, Headless: true})
+	for i := 0; i < 200; i++ {
+		g.HardDrop()
+		if g.IsGameOver() {
+			t.Fatalf("zen game over after %d drops", i+1)
+		}
+	}
+
+	// Stacking in the middle tops out a marathon game the same way
+	m := NewWithConfig(Config{Seed: 3, Headless: true})
+	for i := 0; i < 200 && !m.IsGameOver(); i++ {
+		m.HardDrop()
+	}
+	if !m.IsGameOver() {
+		t.Error("marathon game still playing after 200 drops")
+	}
+
+	g.mu.Lock()
+	interval := g.dropInterval
+	g.updateScore(4)
+	g.updateScore(4)
+	g.updateScore(4)
+	g.mu.Unlock()
+	if got := g.GetLevel(); got != 1 {
+		t.Errorf("zen level after 12 lines = %d, want 1", got)
+	}
+	if g.dropInterval != interval {
+		t.Errorf("zen drop interval = %v, want %v", g.dropInterval, interval)
+	}
+	if ModeZen.Ranked() {
+		t.Error("ModeZen.Ranked() = true, want false")
+	}
+}
+
+// TestTakeScoreEvents verifies each scoring clear queues an event with its
+// points, and the clear reaching 10 lines reports the level up
+func TestTakeScoreEvents(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Headless: true})
+
+	g.mu.Lock()
+	g.updateScore(4)
+	g.updateScore(0)
+	g.updateScore(3)
+	g.updateScore(4)
+	g.mu.Unlock()
+
+	want := []ScoreEvent{
+		{Lines: 4, Points: 800, Level: 1},
+		{Lines: 3, Points: 500, Level: 1},
+		{Lines: 4, Points: 800, Level: 2, LevelUp: true},
+	}
+	if got := g.TakeScoreEvents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TakeScoreEvents() = %+v, want %+v", got, want)
+	}
+	if got := g.TakeScoreEvents(); len(got) != 0 {
+		t.Errorf("TakeScoreEvents() after taking = %+v, want none", got)
+	}
+}
+
+// TestPuzzleMode verifies a puzzle game starts on its level's board and ends
+// completed once the objective is reached, or failed when the pieces run out
+func TestPuzzleMode(t *testing.T) {
+	level, _ := puzzle.Find(puzzle.Builtin, "first-steps")
+
+	g := NewWithConfig(Config{Seed: 1, Mode: ModePuzzle, Puzzle: level, Headless: true})
+	if got := g.GetBoard().GarbageRows(); got != 2 {
+		t.Fatalf("puzzle board has %d garbage rows, want 2", got)
+	}
+	status := g.GetModeStatus()
+	if status.Puzzle != "first-steps" || status.PiecesRemaining != 1 || status.LinesRemaining != 2 {
+		t.Errorf("GetModeStatus() = %+v, want first-steps with 1 piece and 2 lines left", status)
+	}
+	for g.GetCurrentPiece().X < 4 {
+		g.MoveRight()
+	}
+	g.HardDrop()
+	if !g.IsGameOver() || !g.IsCompleted() {
+		t.Errorf("after filling the gap: game over %v, completed %v, want both", g.IsGameOver(), g.IsCompleted())
+	}
+
+	// Dropping the piece beside the gap uses up the only piece
+	g = NewWithConfig(Config{Seed: 1, Mode: ModePuzzle, Puzzle: level, Headless: true})
+	for g.GetCurrentPiece().X > 0 {
+		g.MoveLeft()
+	}
+	g.HardDrop()
+	if !g.IsGameOver() || g.IsCompleted() {
+		t.Errorf("after missing the gap: game over %v, completed %v, want game over only", g.IsGameOver(), g.IsCompleted())
+	}
+
+	// Without a level the first built-in one is played
+	if got := NewWithConfig(Config{Mode: ModePuzzle, Headless: true}).GetPuzzle(); got != &puzzle.Builtin[0] {
+		t.Errorf("GetPuzzle() = %v, want the first built-in level", got)
+	}
+	if ModePuzzle.Ranked() {
+		t.Error("ModePuzzle.Ranked() = true, want false")
+	}
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// maxQueuedPieces is how many pieces SetNextPieces accepts at once
+const maxQueuedPieces = 14
+
+var (
+	// ErrPracticeOnly is returned by the board setup methods outside practice mode
+	ErrPracticeOnly = errors.New("game: board setup is only available in practice mode")
+	// ErrNoSpawnRoom is returned by SetBoard for a board the current piece cannot spawn on
+	ErrNoSpawnRoom = errors.New("game: board leaves no room for the current piece to spawn")
+)
+
+// SetBoard replaces the board of a practice game with rows of cell patterns
+// such as "XXXX.XXXXX", bottom row last as in board.Builder.Rows, to set up a
+// stack to practice on; the current piece goes back to its spawn position
+func (g *Game) SetBoard(rows []string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.setupAllowedLocked(); err != nil {
+		return err
+	}
+	b, err := board.NewSizedBuilder(g.board.Width(), g.board.Height()).Rows(rows...).Build()
+	if err != nil {
+		return err
+	}
+	current := piece.New(g.current.Type)
+	current.Spawn(b.Width())
+	if b.CheckCollision(current.X, current.Y, current.GetShape()) {
+		return ErrNoSpawnRoom
+	}
+
+	g.board = b
+	g.current = current
+	g.placeAtSpawn()
+	g.tracef("set_board: %d rows", len(rows))
+	g.markPieceStartLocked(g.clock.Now())
+	g.markChanged()
+	return nil
+}
+
+// SetNextPieces sets the pieces that come after the current one, in order,
+// before the bag continues, to practice a sequence in a practice game
+func (g *Game) SetNextPieces(types []piece.Type) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.setupAllowedLocked(); err != nil {
+		return err
+	}
+	if len(types) == 0 || len(types) > maxQueuedPieces {
+		return fmt.Errorf("game: between 1 and %d next pieces can be set", maxQueuedPieces)
+	}
+
+	g.next = piece.New(types[0])
+	g.queue = append([]piece.Type(nil), types[1:]...)
+	g.tracef("set_next_piece: %v", types)
+	g.markPieceStartLocked(g.clock.Now())
+	g.markChanged()
+	return nil
+}
+
+// setupAllowedLocked returns an error unless the game is a practice game in progress
+// Must be called with mu held
+func (g *Game) setupAllowedLocked() error {
+	if g.mode != ModePractice {
+		return ErrPracticeOnly
+	}
+	if g.state == StateGameOver {
+		return errors.New("game: game is over")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestSetBoard verifies that a practice game takes a board setup and that
+// other modes and boards without room to spawn are refused
+func TestSetBoard(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Mode: ModePractice, Headless: true})
+	g.MoveLeft()
+	rows := []string{
+		"XXXXXX..XX",
+		"XXXXXXX.XX",
+	}
+	if err := g.SetBoard(rows); err != nil {
+		t.Fatalf("SetBoard() error = %v", err)
+	}
+	grid := g.GetBoard().Grid()
+	if grid[len(grid)-1][7] != "" || grid[len(grid)-1][0] != string(piece.ColorGray) {
+		t.Errorf("bottom row = %v, want the pattern %q", grid[len(grid)-1], rows[1])
+	}
+	cur := g.GetCurrentPiece()
+	spawn := piece.New(cur.Type)
+	spawn.Spawn(g.GetBoard().Width())
+	if cur.X != spawn.X || cur.Y != spawn.Y {
+		t.Errorf("current piece at (%d,%d), want back at spawn (%d,%d)", cur.X, cur.Y, spawn.X, spawn.Y)
+	}
+
+	tests := []struct {
+		name string
+		mode Mode
+		rows []string
+		want error
+	}{
+		{"marathon", ModeMarathon, rows, ErrPracticeOnly},
+		{"no room to spawn", ModePractice, fullStack(20), ErrNoSpawnRoom},
+	}
+	for _, tt := range tests {
+		g := NewWithConfig(Config{Seed: 1, Mode: tt.mode, Headless: true})
+		if err := g.SetBoard(tt.rows); !errors.Is(err, tt.want) {
+			t.Errorf("%s: SetBoard() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := g.SetBoard([]string{"XXXXXXXXXX"}); err == nil {
+		t.Error("SetBoard() with a full row succeeded")
+	}
+}
+
+// TestSetNextPieces verifies that the pieces set up come in order before the bag continues
+func TestSetNextPieces(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 1, Mode: ModePractice, Headless: true})
+	bag := NewWithConfig(Config{Seed: 1, Headless: true})
+	bag.HardDrop()
+
+	want := []piece.Type{piece.TypeT, piece.TypeT, piece.TypeI}
+	if err := g.SetNextPieces(want); err != nil {
+		t.Fatalf("SetNextPieces() error = %v", err)
+	}
+	for i, w := range want {
+		g.HardDrop()
+		if got := g.GetCurrentPiece().Type; got != w {
+			t.Errorf("piece %d = %v, want %v", i+1, got, w)
+		}
+	}
+	// The bag continues where it left off
+	if got, w := g.GetNextPiece().Type, bag.GetNextPiece().Type; got != w {
+		t.Errorf("next piece after the queue = %v, want %v from the bag", got, w)
+	}
+
+	// Undo brings back the queue
+	g.Undo()
+	if got := g.GetNextPiece().Type; got != piece.TypeI {
+		t.Errorf("next piece after undo = %v, want I", got)
+	}
+
+	if err := g.SetNextPieces(nil); err == nil {
+		t.Error("SetNextPieces(nil) succeeded")
+	}
+}
+
+// TestGetNextPieces verifies that the look-ahead lists the pieces the game deals,
+// across the pieces set up and the bags after them
+func TestGetNextPieces(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 4, Mode: ModePractice, Headless: true})
+	if err := g.SetNextPieces([]piece.Type{piece.TypeT, piece.TypeT, piece.TypeI}); err != nil {
+		t.Fatalf("SetNextPieces() error = %v", err)
+	}
+
+	want := g.GetNextPieces(12)
+	if len(want) != 12 {
+		t.Fatalf("GetNextPieces(12) returned %d pieces", len(want))
+	}
+	for i, w := range want {
+		g.HardDrop()
+		g.SetBoard(nil)
+		if got := g.GetCurrentPiece().Type; got != w {
+			t.Errorf("piece %d = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// fullStack returns n rows with one hole each
+func fullStack(n int) []string {
+	rows := make([]string, n)
+	for i := range rows {
+		rows[i] = ".XXXXXXXXX"
+	}
+	return rows
+}
@@ -0,0 +1,151 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// simEpoch is the start time of every headless game, so runs are reproducible
+var simEpoch = time.Unix(0, 0).UTC()
+
+// ErrNotHeadless is returned by Step for games that follow the wall clock
+var ErrNotHeadless = errors.New("game: Step requires a headless game")
+
+// Action is a player input applied by Simulate
+type Action string
+
+// Actions share their names with the protocol's control messages
+const (
+	ActionMoveLeft  Action = "move_left"
+	ActionMoveRight Action = "move_right"
+	ActionMoveDown  Action = "move_down"
+	ActionRotate    Action = "rotate"
+	ActionRotate180 Action = "rotate_180"
+	ActionHardDrop  Action = "hard_drop"
+	ActionHold      Action = "hold"
+)
+
+// Input is an action applied At a point in game time, measured from the start
+type Input struct {
+	At     time.Duration
+	Action Action
+}
+
+// Result summarizes a simulated game
+type Result struct {
+	Score     int
+	Lines     int
+	Level     int
+	Pieces    int
+	Elapsed   time.Duration // Game time when the simulation stopped
+	GameOver  bool
+	Completed bool // True if the mode's goal was reached
+}
+
+// Step advances a headless game by dt, applying every gravity drop and lock due
+// in between exactly as a real-time game would
+func (g *Game) Step(dt time.Duration) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.sim == nil {
+		return ErrNotHeadless
+	}
+	g.advanceLocked(g.sim.Now().Add(dt))
+	return nil
+}
+
+// advanceLocked moves the fake clock of a headless game to target one
+// gravity event at a time
+// Must be called with mu held
+func (g *Game) advanceLocked(target time.Time) {
+	for g.state == StatePlaying {
+		now := g.sim.Now()
+		next := now.Add(g.timeUntilGravityLocked(now))
+		if next.After(target) {
+			break
+		}
+		g.sim.Advance(next.Sub(now))
+		g.updateLocked(next)
+	}
+	if d := target.Sub(g.sim.Now()); d > 0 {
+		g.sim.Advance(d)
+	}
+	if g.state == StatePlaying {
+		// Timed modes can end between gravity events
+		g.updateLocked(g.sim.Now())
+	}
+}
+
+// Simulate plays a headless game with the given inputs, which must be in time
+// order, and returns the result once the inputs run out or the game ends
+// The same config and inputs always produce the same result
+func Simulate(cfg Config, inputs []Input) (Result, error) {
+	cfg.Headless = true
+	g := NewWithConfig(cfg)
+
+	var last time.Duration
+	for i, in := range inputs {
+		if in.At < last {
+			return Result{}, fmt.Errorf("input %d at %v is before the previous input at %v", i, in.At, last)
+		}
+		last = in.At
+
+		g.mu.Lock()
+		g.advanceLocked(simEpoch.Add(in.At))
+		g.mu.Unlock()
+		if !g.IsPlaying() {
+			break
+		}
+
+		if err := g.apply(in.Action); err != nil {
+			return Result{}, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	return g.result(), nil
+}
+
+// apply performs a player action
+func (g *Game) apply(a Action) error {
+	switch a {
+	case ActionMoveLeft:
+		g.MoveLeft()
+	case ActionMoveRight:
+		g.MoveRight()
+	case ActionMoveDown:
+		g.MoveDown()
+	case ActionRotate:
+		g.Rotate()
+	case ActionRotate180:
+		g.Rotate180()
+	case ActionHardDrop:
+		g.HardDrop()
+	case ActionHold:
+		g.Hold()
+	default:
+		return fmt.Errorf("unknown action %q", a)
+	}
+	return nil
+}
+
+// result summarizes the game so far
+func (g *Game) result() Result {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	pieces := 0
+	for _, n := range g.pieceCounts {
+		pieces += n
+	}
+	return Result{
+		Score:     g.score,
+		Lines:     g.lines,
+		Level:     g.level,
+		Pieces:    pieces,
+		Elapsed:   g.elapsedLocked(g.clock.Now()),
+		GameOver:  g.state == StateGameOver,
+		Completed: g.completed,
+	}
+}
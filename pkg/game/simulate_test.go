@@ -0,0 +1,78 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSimulateDeterministic verifies that the same config and inputs always give the same result
+func TestSimulateDeterministic(t *testing.T) {
+	inputs := []Input{
+		{At: 0, Action: ActionMoveLeft},
+		{At: 100 * time.Millisecond, Action: ActionHardDrop},
+		{At: 2 * time.Second, Action: ActionRotate},
+		{At: 2 * time.Second, Action: ActionHardDrop},
+		{At: 5 * time.Second, Action: ActionHold},
+	}
+
+	first, err := Simulate(Config{Seed: 7}, inputs)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		got, err := Simulate(Config{Seed: 7}, inputs)
+		if err != nil {
+			t.Fatalf("Simulate() error = %v", err)
+		}
+		if got != first {
+			t.Errorf("run %d: Simulate() = %+v, want %+v", i, got, first)
+		}
+	}
+	if first.Elapsed != 5*time.Second {
+		t.Errorf("Elapsed = %v, want %v", first.Elapsed, 5*time.Second)
+	}
+	if first.Pieces < 2 {
+		t.Errorf("Pieces = %d, want at least 2", first.Pieces)
+	}
+}
+
+// TestSimulateGravityOnly verifies that gravity alone tops out a game without inputs
+func TestSimulateGravityOnly(t *testing.T) {
+	got, err := Simulate(Config{Seed: 1}, []Input{{At: time.Hour, Action: ActionHold}})
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if !got.GameOver {
+		t.Errorf("GameOver = false after an hour of gravity, want true")
+	}
+}
+
+// TestSimulateErrors verifies that out-of-order and unknown inputs are rejected
+func TestSimulateErrors(t *testing.T) {
+	if _, err := Simulate(Config{}, []Input{{At: time.Second, Action: ActionRotate}, {At: 0, Action: ActionRotate}}); err == nil {
+		t.Error("Simulate() with out-of-order inputs: want error")
+	}
+	if _, err := Simulate(Config{}, []Input{{Action: "jump"}}); err == nil {
+		t.Error("Simulate() with unknown action: want error")
+	}
+}
+
+// TestStep verifies that Step drives gravity on the virtual clock and needs a headless game
+func TestStep(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 3, Headless: true})
+	y := g.GetCurrentPiece().Y
+	if err := g.Step(GuidelineGravity(1)); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if got := g.GetCurrentPiece().Y; got != y+1 {
+		t.Errorf("Y after one interval = %d, want %d", got, y+1)
+	}
+	if got := g.GetElapsed(); got != GuidelineGravity(1) {
+		t.Errorf("GetElapsed() = %v, want %v", got, GuidelineGravity(1))
+	}
+
+	if err := New().Step(time.Second); !errors.Is(err, ErrNotHeadless) {
+		t.Errorf("Step() on a real-time game error = %v, want ErrNotHeadless", err)
+	}
+}
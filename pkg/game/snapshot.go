@@ -0,0 +1,357 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/puzzle"
+)
+
+// snapshotVersion is the format of the snapshots written by Snapshot
+const snapshotVersion = 1
+
+// Limits of a restored game, which is rebuilt by replaying its randomizer:
+// the pieces it placed bound how many pieces the randomizer dealt, so a
+// snapshot cannot ask for a replay far longer than the game
+const (
+	maxSnapshotPieces = 1000000 // Most pieces a restored game may have placed
+	maxSnapshotBag    = 14      // Most pieces left in the bag: one bag and one peeked ahead
+	maxUnplacedPieces = 3       // Pieces dealt but not placed: current, next and hold
+)
+
+// ErrSnapshotVersion is returned by Restore for snapshots of an unknown format
+var ErrSnapshotVersion = errors.New("game: unsupported snapshot version")
+
+// snapshot is the serialized form of a game
+// Timers are stored as durations, since the game may be restored in another
+// process with another clock
+type snapshot struct {
+	Version     int                `json:"version"`
+	Seed        int64              `json:"seed"`
+	Ruleset     Ruleset            `json:"ruleset"`
+	Mode        Mode               `json:"mode"`
+	DigRows     int                `json:"dig_rows,omitempty"`
+	Board       [][]string         `json:"board"`
+	Hidden      [][]string         `json:"hidden,omitempty"` // Rows above the board, if any cell there is occupied
+	Current     *pieceSnapshot     `json:"current,omitempty"`
+	Next        *pieceSnapshot     `json:"next,omitempty"`
+	Hold        *pieceSnapshot     `json:"hold,omitempty"`
+	CanHold     bool               `json:"can_hold"`
+	Bag         []piece.Type       `json:"bag"`             // Pieces the generator has shuffled but not dealt
+	Refills     int                `json:"refills"`         // Bags the generator has shuffled
+	Queue       []piece.Type       `json:"queue,omitempty"` // Pieces set up to come after next
+	State       State              `json:"state"`
+	Score       int                `json:"score"`
+	Level       int                `json:"level"`
+	Lines       int                `json:"lines"`
+	PieceCounts map[piece.Type]int `json:"piece_counts"`
+	Elapsed     time.Duration      `json:"elapsed"`    // Play time, excluding pauses
+	PausedFor   time.Duration      `json:"paused_for"` // Time spent paused
+	Completed   bool               `json:"completed"`
+
+	// A randomizer other than the bag is restored by dealing Draws pieces again
+	Randomizer piece.RandomizerKind `json:"randomizer,omitempty"`
+	Script     []piece.Type         `json:"script,omitempty"` // Sequence of a scripted randomizer
+	Draws      int                  `json:"draws,omitempty"`
+
+	Spawning bool `json:"spawning,omitempty"` // The next piece was waiting out the line clear or spawn delay
+
+	// The level of a puzzle game, whose objective outlives its source
+	Puzzle *puzzle.Level `json:"puzzle,omitempty"`
+}
+
+// pieceSnapshot is the serialized form of a piece
+type pieceSnapshot struct {
+	Type     piece.Type `json:"type"`
+	X        int        `json:"x"`
+	Y        int        `json:"y"`
+	Rotation int        `json:"rotation"`
+}
+
+// Snapshot serializes the game: board, pieces, the generator's bag, score and
+// timers, so it can be continued later with Restore
+func (g *Game) Snapshot() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return json.Marshal(g.snapshotLocked(g.clock.Now()))
+}
+
+// snapshotLocked captures the game's state
+// Must be called with mu held
+func (g *Game) snapshotLocked(now time.Time) snapshot {
+	paused := g.pausedFor
+	if g.state == StatePaused {
+		paused += now.Sub(g.pausedAt)
+	}
+	counts := make(map[piece.Type]int, len(g.pieceCounts))
+	for t, n := range g.pieceCounts {
+		counts[t] = n
+	}
+
+	snap := snapshot{
+		Version:     snapshotVersion,
+		Seed:        g.seed,
+		Ruleset:     g.ruleset,
+		Mode:        g.mode,
+		DigRows:     g.digRows,
+		Board:       g.board.Grid(),
+		Current:     snapshotPiece(g.current),
+		Next:        snapshotPiece(g.next),
+		Hold:        snapshotPiece(g.hold),
+		CanHold:     g.canHold,
+		Queue:       append([]piece.Type(nil), g.queue...),
+		State:       g.state,
+		Score:       g.score,
+		Level:       g.level,
+		Lines:       g.lines,
+		PieceCounts: counts,
+		Elapsed:     g.elapsedLocked(now),
+		PausedFor:   paused,
+		Completed:   g.completed,
+	}
+	snap.Spawning = g.spawningLocked()
+	snap.Puzzle = g.puzzle
+	if len(g.clearing) > 0 {
+		// Rows waiting out the line clear delay are saved as removed
+		cleared := g.board.Clone()
+		cleared.ClearLines()
+		snap.Board = cleared.Grid()
+		snap.Hidden = hiddenGrid(cleared)
+	} else {
+		snap.Hidden = hiddenGrid(g.board)
+	}
+	if gen, ok := g.generator.(*piece.Generator); ok {
+		snap.Bag = gen.Remaining()
+		snap.Refills = gen.Refills()
+	} else {
+		snap.Randomizer = g.generator.Kind()
+		snap.Script = append([]piece.Type(nil), g.script...)
+		snap.Draws = g.generator.Draws()
+	}
+	return snap
+}
+
+// Restore replaces the game with one saved by Snapshot
+// The game keeps its clock, gravity and lock delay; a restored game that was
+// paused stays paused, and gravity starts over from the time of the restore
+func (g *Game) Restore(data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("game: invalid snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	if len(snap.Board) == 0 || len(snap.Board[0]) == 0 || snap.Current == nil || snap.Next == nil {
+		return errors.New("game: invalid snapshot: missing board or pieces")
+	}
+	if !snap.Mode.IsValid() {
+		return fmt.Errorf("game: invalid snapshot: unknown mode %q", snap.Mode)
+	}
+	if snap.Mode == ModePuzzle {
+		if snap.Puzzle == nil {
+			return errors.New("game: invalid snapshot: puzzle game without a level")
+		}
+		if err := snap.Puzzle.Validate(); err != nil {
+			return fmt.Errorf("game: invalid snapshot: %w", err)
+		}
+	}
+	if snap.Randomizer != "" {
+		if _, err := piece.NewRandomizer(snap.Randomizer, snap.Seed, snap.Script); err != nil {
+			return fmt.Errorf("game: invalid snapshot: %w", err)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Snapshots come from clients, so nothing in them is trusted
+	if err := snap.check(g.board.Width(), g.board.Height()); err != nil {
+		return fmt.Errorf("game: invalid snapshot: %w", err)
+	}
+
+	now := g.clock.Now()
+	g.restoreLocked(snap, now)
+	// Placements before the snapshot cannot be undone
+	g.undo = nil
+	g.undoStart = nil
+	g.markPieceStartLocked(now)
+	g.tracef("restored: mode %s, seed %d, score %d, lines %d", g.mode, g.seed, g.score, g.lines)
+	g.markChanged()
+	return nil
+}
+
+// check verifies that a snapshot fits a board of the game's size and holds
+// only known piece types
+func (snap *snapshot) check(width, height int) error {
+	if len(snap.Board) != height {
+		return fmt.Errorf("board has %d rows, want %d", len(snap.Board), height)
+	}
+	if len(snap.Hidden) > board.HiddenRows {
+		return fmt.Errorf("%d hidden rows, want at most %d", len(snap.Hidden), board.HiddenRows)
+	}
+	for y, row := range append(append([][]string(nil), snap.Board...), snap.Hidden...) {
+		if len(row) != width {
+			return fmt.Errorf("row %d has %d cells, want %d", y, len(row), width)
+		}
+	}
+	for name, p := range map[string]*pieceSnapshot{"current": snap.Current, "next": snap.Next, "hold": snap.Hold} {
+		if p != nil && !p.Type.IsValid() {
+			return fmt.Errorf("unknown %s piece type %d", name, p.Type)
+		}
+	}
+	for _, types := range [][]piece.Type{snap.Queue, snap.Bag, snap.Script} {
+		for _, t := range types {
+			if !t.IsValid() {
+				return fmt.Errorf("unknown piece type %d", t)
+			}
+		}
+	}
+	return snap.checkDealt()
+}
+
+// checkDealt verifies that the randomizer replay asked for by a snapshot is
+// no longer than the pieces the game placed allow
+func (snap *snapshot) checkDealt() error {
+	placed := 0
+	for t, n := range snap.PieceCounts {
+		if n < 0 {
+			return fmt.Errorf("negative count %d of piece type %d", n, t)
+		}
+		placed += n
+		if placed > maxSnapshotPieces {
+			return fmt.Errorf("more than %d pieces placed", maxSnapshotPieces)
+		}
+	}
+	if snap.Refills < 0 || snap.Draws < 0 {
+		return fmt.Errorf("negative refills %d or draws %d", snap.Refills, snap.Draws)
+	}
+	if len(snap.Bag) > maxSnapshotBag {
+		return fmt.Errorf("%d pieces in the bag, want at most %d", len(snap.Bag), maxSnapshotBag)
+	}
+	dealt := placed + maxUnplacedPieces
+	if snap.Refills > (dealt+len(snap.Bag))/7+1 {
+		return fmt.Errorf("%d bags shuffled for %d pieces placed", snap.Refills, placed)
+	}
+	if snap.Draws > dealt {
+		return fmt.Errorf("%d pieces drawn for %d pieces placed", snap.Draws, placed)
+	}
+	return nil
+}
+
+// restoreLocked replaces the game's state with a snapshot
+// Must be called with mu held
+func (g *Game) restoreLocked(snap snapshot, now time.Time) {
+	g.board = board.FromGrid(snap.Board)
+	for y, row := range snap.Hidden {
+		for x, color := range row {
+			if color != "" {
+				g.board.SetCell(x, y-board.HiddenRows, piece.Color(color))
+			}
+		}
+	}
+	g.generator = restoreRandomizer(snap)
+	g.script = append([]piece.Type(nil), snap.Script...)
+	g.queue = append([]piece.Type(nil), snap.Queue...)
+	g.seed = snap.Seed
+	g.ruleset = snap.Ruleset
+	g.mode = snap.Mode
+	g.digRows = snap.DigRows
+	g.puzzle = nil
+	if snap.Mode == ModePuzzle {
+		g.puzzle = snap.Puzzle
+	}
+	g.current = snap.Current.piece()
+	g.next = snap.Next.piece()
+	g.hold = snap.Hold.piece()
+	g.canHold = snap.CanHold
+	g.state = snap.State
+	g.score = snap.Score
+	g.level = snap.Level
+	g.lines = snap.Lines
+	g.dropInterval = g.gravity(snap.Level)
+	g.lastDrop = now
+	g.landedAt = time.Time{}
+	g.spawnAt = time.Time{}
+	g.clearing = nil
+	if snap.Spawning {
+		// The next piece spawns on the next update
+		g.spawnAt = now
+	}
+	g.ihs = false
+	g.irs = 0
+	g.ims = ""
+	g.attacks = nil
+	g.events = nil
+	// Copied, since undo restores the same snapshot more than once
+	g.pieceCounts = make(map[piece.Type]int, len(snap.PieceCounts))
+	for t, n := range snap.PieceCounts {
+		g.pieceCounts[t] = n
+	}
+	g.startedAt = now.Add(-snap.Elapsed - snap.PausedFor)
+	g.pausedFor = snap.PausedFor
+	g.pausedAt = time.Time{}
+	g.endedAt = time.Time{}
+	switch g.state {
+	case StatePaused:
+		// The pause goes on from now
+		g.pausedAt = now
+	case StateGameOver:
+		g.endedAt = now
+	}
+	g.completed = snap.Completed
+}
+
+// restoreRandomizer recreates the randomizer of a snapshot checked by Restore
+func restoreRandomizer(snap snapshot) piece.Randomizer {
+	if snap.Randomizer == "" || snap.Randomizer == piece.RandomizerBag {
+		return piece.RestoreGenerator(snap.Seed, snap.Refills, snap.Bag)
+	}
+	r, err := piece.RestoreRandomizer(snap.Randomizer, snap.Seed, snap.Script, snap.Draws)
+	if err != nil {
+		return piece.RestoreGenerator(snap.Seed, snap.Refills, snap.Bag)
+	}
+	return r
+}
+
+// hiddenGrid returns the hidden rows of b as rows of cell colors, top to
+// bottom, or nil if they are empty
+func hiddenGrid(b *board.Board) [][]string {
+	grid := make([][]string, board.HiddenRows)
+	occupied := false
+	for y := range grid {
+		grid[y] = make([]string, b.Width())
+		for x := range grid[y] {
+			if cell, _ := b.GetCell(x, y-board.HiddenRows); !cell.Empty {
+				grid[y][x] = string(cell.Color)
+				occupied = true
+			}
+		}
+	}
+	if !occupied {
+		return nil
+	}
+	return grid
+}
+
+// snapshotPiece returns the serialized form of p, or nil if p is nil
+func snapshotPiece(p *piece.Piece) *pieceSnapshot {
+	if p == nil {
+		return nil
+	}
+	return &pieceSnapshot{Type: p.Type, X: p.X, Y: p.Y, Rotation: p.Rotation}
+}
+
+// piece returns the piece a snapshot describes, or nil for a nil snapshot
+func (s *pieceSnapshot) piece() *piece.Piece {
+	if s == nil {
+		return nil
+	}
+	p := piece.New(s.Type)
+	p.X, p.Y, p.Rotation = s.X, s.Y, (s.Rotation%4+4)%4
+	return p
+}
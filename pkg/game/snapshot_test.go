@@ -0,0 +1,232 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/puzzle"
+)
+
+// TestSnapshotRestore verifies that a restored game continues exactly like the original
+func TestSnapshotRestore(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 11, Mode: ModeSprint, Headless: true})
+	// Place enough pieces to start a second bag, and hold one
+	g.Hold()
+	for i := 0; i < 9; i++ {
+		g.MoveLeft()
+		g.HardDrop()
+		g.Step(100 * time.Millisecond)
+	}
+
+	data, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	restored := NewWithConfig(Config{Headless: true})
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// Drop the pieces on the empty right side without waiting for gravity
+	for i := 0; i < 6; i++ {
+		for _, game := range []*Game{g, restored} {
+			for j := 0; j < 4; j++ {
+				game.MoveRight()
+			}
+			game.HardDrop()
+		}
+		if got, want := restored.GetCurrentPiece().Type, g.GetCurrentPiece().Type; got != want {
+			t.Fatalf("piece %d after restore = %v, want %v", i, got, want)
+		}
+	}
+	if got, want := restored.result(), g.result(); got != want {
+		t.Errorf("restored result = %+v, want %+v", got, want)
+	}
+	if restored.GetMode() != ModeSprint || restored.GetSeed() != 11 {
+		t.Errorf("restored mode, seed = %s, %d, want sprint, 11", restored.GetMode(), restored.GetSeed())
+	}
+}
+
+// TestSnapshotRestorePaused verifies that a paused game stays paused and keeps its play time
+func TestSnapshotRestorePaused(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 5, Headless: true})
+	g.Step(3 * time.Second)
+	g.Pause()
+	g.Step(time.Minute)
+
+	data, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	restored := NewWithConfig(Config{Headless: true})
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	restored.Step(time.Minute)
+
+	if !restored.IsPaused() {
+		t.Error("restored game is not paused")
+	}
+	if got := restored.GetElapsed(); got != 3*time.Second {
+		t.Errorf("GetElapsed() = %v, want %v", got, 3*time.Second)
+	}
+}
+
+// TestRestoreErrors verifies that invalid snapshots are rejected
+func TestRestoreErrors(t *testing.T) {
+	g := New()
+	if err := g.Restore([]byte(`{"version": 99}`)); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("Restore() of a newer version error = %v, want ErrSnapshotVersion", err)
+	}
+	if err := g.Restore([]byte(`{"version": 1}`)); err == nil {
+		t.Error("Restore() without a board: want error")
+	}
+	if err := g.Restore([]byte(`not json`)); err == nil {
+		t.Error("Restore() of invalid JSON: want error")
+	}
+}
+
+// TestSnapshotRestoreRandomizers verifies that a restored game deals the same
+// pieces as the original with every randomizer
+func TestSnapshotRestoreRandomizers(t *testing.T) {
+	for _, kind := range []piece.RandomizerKind{piece.RandomizerBag, piece.RandomizerRandom, piece.RandomizerHistory, piece.RandomizerScripted} {
+		g := NewWithConfig(Config{Seed: 7, Randomizer: kind, Script: []piece.Type{piece.TypeT, piece.TypeI, piece.TypeO}, Headless: true})
+		for i := 0; i < 5; i++ {
+			g.HardDrop()
+		}
+
+		data, err := g.Snapshot()
+		if err != nil {
+			t.Fatalf("%s: Snapshot() error = %v", kind, err)
+		}
+		restored := NewWithConfig(Config{Headless: true})
+		if err := restored.Restore(data); err != nil {
+			t.Fatalf("%s: Restore() error = %v", kind, err)
+		}
+		if got := restored.GetRandomizer(); got != kind {
+			t.Errorf("%s: restored randomizer = %s", kind, got)
+		}
+		for i := 0; i < 10; i++ {
+			want := g.generator.Next().Type
+			if got := restored.generator.Next().Type; got != want {
+				t.Errorf("%s: piece %d after restore = %v, want %v", kind, i, got, want)
+				break
+			}
+		}
+	}
+}
+
+// TestSnapshotRestorePuzzle verifies a restored puzzle game keeps its level and obstacles
+func TestSnapshotRestorePuzzle(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 5, Mode: ModePuzzle, Puzzle: &puzzle.Builtin[2], Headless: true})
+	data, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	restored := NewWithConfig(Config{Headless: true})
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := restored.GetModeStatus(); got.Puzzle != puzzle.Builtin[2].Name || got.PiecesRemaining != 1 {
+		t.Errorf("restored GetModeStatus() = %+v, want %s with 1 piece left", got, puzzle.Builtin[2].Name)
+	}
+	if cell, _ := restored.GetBoard().GetCell(0, restored.GetBoard().Height()-1); !cell.IsObstacle() {
+		t.Errorf("restored cell = %+v, want an obstacle", cell)
+	}
+}
+
+// TestRestoreUntrusted verifies that snapshots with unknown pieces or a board
+// of the wrong size are rejected and leave the game as it was, and that a
+// rotation out of range is reduced to a quarter turn count
+func TestRestoreUntrusted(t *testing.T) {
+	g := NewWithSeed(1)
+	data, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit := func(change func(snap map[string]any)) []byte {
+		var snap map[string]any
+		if err := json.Unmarshal(data, &snap); err != nil {
+			t.Fatal(err)
+		}
+		change(snap)
+		edited, err := json.Marshal(snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return edited
+	}
+
+	tests := []struct {
+		name   string
+		change func(snap map[string]any)
+	}{
+		{"current type", func(s map[string]any) { s["current"].(map[string]any)["type"] = 42 }},
+		{"next type", func(s map[string]any) { s["next"].(map[string]any)["type"] = -1 }},
+		{"hold type", func(s map[string]any) { s["hold"] = map[string]any{"type": 7} }},
+		{"queue type", func(s map[string]any) { s["queue"] = []int{0, 9} }},
+		{"board height", func(s map[string]any) { s["board"] = s["board"].([]any)[1:] }},
+		{"row length", func(s map[string]any) { s["board"].([]any)[3] = []string{""} }},
+		{"hidden rows", func(s map[string]any) { s["hidden"] = make([][]string, 3) }},
+		{"refills", func(s map[string]any) { s["refills"] = 20000000 }},
+		{"negative refills", func(s map[string]any) { s["refills"] = -1 }},
+		{"bag length", func(s map[string]any) { s["bag"] = make([]int, 15) }},
+		{"piece count", func(s map[string]any) { s["piece_counts"] = map[string]int{"0": -1} }},
+		{"pieces placed", func(s map[string]any) {
+			s["piece_counts"] = map[string]int{"0": 20000000}
+			s["refills"] = 20000000 / 7
+		}},
+		{"draws", func(s map[string]any) {
+			s["randomizer"] = piece.RandomizerHistory
+			s["draws"] = 20000000
+		}},
+	}
+	for _, tt := range tests {
+		if err := g.Restore(edit(tt.change)); err == nil {
+			t.Errorf("Restore() with a bad %s: want error", tt.name)
+		}
+	}
+	if got := g.GetScore(); got != 0 || g.GetCurrentPiece() == nil {
+		t.Error("rejected snapshot changed the game")
+	}
+
+	spun := edit(func(s map[string]any) { s["current"].(map[string]any)["rotation"] = 2000000001 })
+	if err := g.Restore(spun); err != nil {
+		t.Fatalf("Restore() with a large rotation error = %v", err)
+	}
+	if got := g.GetCurrentPiece().Rotation; got != 1 {
+		t.Errorf("restored rotation = %d, want 1", got)
+	}
+	g.MoveLeft()
+}
+
+// TestRestoreOversized verifies a snapshot asking for a replay far longer
+// than its game is rejected before the replay starts
+func TestRestoreOversized(t *testing.T) {
+	g := NewWithSeed(1)
+	data, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	snap.Refills = 20000000
+	data, err = json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := g.Restore(data); err == nil {
+		t.Error("Restore() with 20000000 refills: want error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Restore() took %v to reject the snapshot", elapsed)
+	}
+}
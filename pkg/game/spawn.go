@@ -0,0 +1,137 @@
+package game
+
+import (
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// During the line clear and spawn delays the locked piece stays current, so
+// the game always has a piece to report; hard drops are ignored while hold,
+// rotations and the last move are buffered and applied as the next piece
+// spawns (the Initial Hold and Rotation Systems of modern games), so inputs
+// pressed right at lock time are not lost
+
+// spawningLocked reports whether the next piece is waiting out the line clear
+// or spawn delay
+// Must be called with mu held
+func (g *Game) spawningLocked() bool {
+	return !g.spawnAt.IsZero()
+}
+
+// IsSpawning returns true while the next piece waits out the line clear or spawn delay
+func (g *Game) IsSpawning() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.spawningLocked()
+}
+
+// GetClearingRows returns the full rows waiting out the line clear delay
+// before they are removed, top to bottom
+func (g *Game) GetClearingRows() []int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]int(nil), g.clearing...)
+}
+
+// endDelayLocked removes the full rows at the end of the line clear delay, or
+// spawns the next piece at the end of the spawn delay
+// Must be called with mu held
+func (g *Game) endDelayLocked(now time.Time) {
+	g.spawnAt = time.Time{}
+	if len(g.clearing) > 0 {
+		g.board.ClearLines()
+		g.clearing = nil
+		g.finishLockLocked(now)
+		return
+	}
+	g.spawnNextLocked(now)
+}
+
+// spawnAfterLockLocked brings in the next piece after a lock, at once or
+// after the spawn delay
+// Must be called with mu held
+func (g *Game) spawnAfterLockLocked(now time.Time) {
+	if g.spawnDelay > 0 {
+		g.spawnAt = now.Add(g.spawnDelay)
+		return
+	}
+	g.spawnNextLocked(now)
+}
+
+// spawnNextLocked spawns the next piece, applying the hold and rotations
+// buffered during the spawn delay
+// Must be called with mu held
+func (g *Game) spawnNextLocked(now time.Time) {
+	g.spawnAt = time.Time{}
+	g.spawnPiece()
+	g.prepareNext()
+
+	if g.ihs && g.state == StatePlaying {
+		g.tracef("initial hold")
+		g.holdLocked()
+	}
+	collision := func(x, y int, shape piece.Shape) bool {
+		return g.board.CheckCollision(x, y, shape)
+	}
+	rotations := g.irs
+	if rotations >= 2 && g.state == StatePlaying {
+		g.traceMove("initial rotate 180", g.current.Rotate180(collision))
+		rotations -= 2
+	}
+	if rotations == 1 && g.state == StatePlaying {
+		g.traceMove("initial rotate", g.current.Rotate(collision))
+	}
+	if g.ims != "" && g.state == StatePlaying {
+		g.applyInitialMoveLocked(collision)
+	}
+	g.ihs = false
+	g.irs = 0
+	g.ims = ""
+	g.markPieceStartLocked(now)
+}
+
+// bufferRotateLocked records a rotation by the given number of 90° turns
+// pressed during the spawn delay
+// Must be called with mu held
+func (g *Game) bufferRotateLocked(turns int) {
+	g.irs = (g.irs + turns) % 4
+	g.markChanged()
+}
+
+// bufferMoveLocked records a move pressed during the line clear or spawn
+// delay; only the last one is kept, since it is what the player meant last
+// Must be called with mu held
+func (g *Game) bufferMoveLocked(move Action) {
+	g.ims = move
+	g.markChanged()
+}
+
+// applyInitialMoveLocked moves the piece that just spawned by the buffered
+// move; a soft drop that is blocked leaves the piece in place rather than
+// locking it
+// Must be called with mu held
+func (g *Game) applyInitialMoveLocked(collision func(x, y int, shape piece.Shape) bool) {
+	var moved bool
+	switch g.ims {
+	case ActionMoveLeft:
+		moved = g.current.MoveLeft(collision)
+	case ActionMoveRight:
+		moved = g.current.MoveRight(collision)
+	case ActionMoveDown:
+		moved = g.current.MoveDown(collision)
+	}
+	g.traceMove("initial "+string(g.ims), moved)
+}
+
+// bufferHoldLocked records a hold pressed during the spawn delay; returns
+// false if hold is not available for the next piece
+// Must be called with mu held
+func (g *Game) bufferHoldLocked() bool {
+	if !g.canHold || g.ihs {
+		return false
+	}
+	g.ihs = true
+	g.markChanged()
+	return true
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestSpawnDelay verifies that the next piece waits out the spawn delay and
+// spawns held and rotated by the inputs buffered meanwhile
+func TestSpawnDelay(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 6, SpawnDelay: 200 * time.Millisecond, Headless: true})
+	upcoming := g.GetNextPieces(2)
+
+	g.HardDrop()
+	if !g.IsSpawning() {
+		t.Fatal("IsSpawning() = false after a lock")
+	}
+	if g.HardDrop() != 0 {
+		t.Error("piece hard dropped during the spawn delay")
+	}
+	if !g.Rotate() || !g.Hold() {
+		t.Error("rotate or hold not buffered during the spawn delay")
+	}
+	if g.Hold() {
+		t.Error("second hold buffered during the spawn delay")
+	}
+
+	g.Step(150 * time.Millisecond)
+	if !g.IsSpawning() {
+		t.Fatal("next piece spawned before the spawn delay")
+	}
+	g.Step(50 * time.Millisecond)
+	if g.IsSpawning() {
+		t.Fatal("next piece did not spawn after the spawn delay")
+	}
+
+	// The next piece went to hold and the one after it spawned rotated
+	cur := g.GetCurrentPiece()
+	if cur.Type != upcoming[1] || cur.Rotation != 1 {
+		t.Errorf("spawned %v rotation %d, want %v rotation 1", cur.Type, cur.Rotation, upcoming[1])
+	}
+	if hold := g.GetHoldPiece(); hold == nil || hold.Type != upcoming[0] {
+		t.Errorf("held %v, want %v", hold, upcoming[0])
+	}
+	if g.CanHold() {
+		t.Error("CanHold() = true after an initial hold")
+	}
+}
+
+// TestLineClearDelay verifies that full rows stay on the board for the line
+// clear delay before they are removed and the spawn delay starts
+func TestLineClearDelay(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 6, Mode: ModePractice, LineClearDelay: 300 * time.Millisecond, SpawnDelay: 100 * time.Millisecond, Headless: true})
+	if err := g.SetBoard([]string{"XXX....XXX"}); err != nil {
+		t.Fatalf("SetBoard() error = %v", err)
+	}
+	g.mu.Lock()
+	g.current = piece.New(piece.TypeI)
+	g.placeAtSpawn()
+	g.mu.Unlock()
+
+	g.HardDrop()
+	bottom := g.GetBoard().Height() - 1
+	if got := g.GetClearingRows(); len(got) != 1 || got[0] != bottom {
+		t.Fatalf("GetClearingRows() = %v, want [%d]", got, bottom)
+	}
+	if g.GetLines() != 1 || g.GetBoard().IsEmpty(0, bottom) {
+		t.Errorf("lines %d, bottom row empty %v; want the line counted and the row still shown", g.GetLines(), g.GetBoard().IsEmpty(0, bottom))
+	}
+
+	g.Step(300 * time.Millisecond)
+	if len(g.GetClearingRows()) != 0 || !g.GetBoard().IsCleared() {
+		t.Error("full row not removed after the line clear delay")
+	}
+	if !g.IsSpawning() {
+		t.Error("next piece spawned without the spawn delay")
+	}
+	g.Step(100 * time.Millisecond)
+	if g.IsSpawning() {
+		t.Error("next piece did not spawn after the spawn delay")
+	}
+}
+
+// TestSpawnDelayRotate180 verifies a 180° rotation buffered during the spawn
+// delay adds to the other rotations the next piece spawns with
+func TestSpawnDelayRotate180(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []func(*Game) bool
+		want   int
+	}{
+		{"rotate 180", []func(*Game) bool{(*Game).Rotate180}, 2},
+		{"rotate 180 and rotate", []func(*Game) bool{(*Game).Rotate180, (*Game).Rotate}, 3},
+		{"rotate 180 twice", []func(*Game) bool{(*Game).Rotate180, (*Game).Rotate180}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithConfig(Config{Seed: 6, SpawnDelay: 100 * time.Millisecond, Headless: true})
+			g.HardDrop()
+			for _, input := range tt.inputs {
+				if !input(g) {
+					t.Fatal("rotation not buffered during the spawn delay")
+				}
+			}
+			g.Step(100 * time.Millisecond)
+			if got := g.GetCurrentPiece().Rotation; got != tt.want {
+				t.Errorf("spawned with rotation %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSpawnDelayMove verifies the last move pressed during the spawn delay
+// moves the next piece as it spawns
+func TestSpawnDelayMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []func(*Game) bool
+		dx, dy int
+	}{
+		{"left", []func(*Game) bool{(*Game).MoveLeft}, -1, 0},
+		{"right", []func(*Game) bool{(*Game).MoveRight}, 1, 0},
+		{"down", []func(*Game) bool{(*Game).MoveDown}, 0, 1},
+		{"last move wins", []func(*Game) bool{(*Game).MoveLeft, (*Game).MoveDown, (*Game).MoveRight}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithConfig(Config{Seed: 6, SpawnDelay: 100 * time.Millisecond, Headless: true})
+			g.HardDrop()
+			for _, input := range tt.inputs {
+				if !input(g) {
+					t.Fatal("move not buffered during the spawn delay")
+				}
+			}
+			g.Step(100 * time.Millisecond)
+
+			spawned := piece.New(g.GetCurrentPiece().Type)
+			spawned.Spawn(g.GetBoard().Width())
+			cur := g.GetCurrentPiece()
+			if cur.X != spawned.X+tt.dx || cur.Y != spawned.Y+tt.dy {
+				t.Errorf("spawned at (%d,%d), want (%d,%d)", cur.X, cur.Y, spawned.X+tt.dx, spawned.Y+tt.dy)
+			}
+		})
+	}
+}
+
+// TestSpawnDelayMoveBlocked verifies a buffered soft drop the stack blocks
+// leaves the next piece in place instead of locking it
+func TestSpawnDelayMoveBlocked(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 6, Mode: ModePractice, SpawnDelay: 100 * time.Millisecond, Headless: true})
+	// The stack reaches the row under the spawn position, except on the right
+	rows := make([]string, g.GetBoard().Height()-1)
+	for i := range rows {
+		rows[i] = "XXXXXXX..."
+	}
+	if err := g.SetBoard(rows); err != nil {
+		t.Fatalf("SetBoard() error = %v", err)
+	}
+	g.mu.Lock()
+	g.current = piece.New(piece.TypeO)
+	g.current.X = 8
+	g.mu.Unlock()
+
+	g.HardDrop()
+	if !g.MoveDown() {
+		t.Fatal("soft drop not buffered during the spawn delay")
+	}
+	g.Step(100 * time.Millisecond)
+
+	spawned := piece.New(g.GetCurrentPiece().Type)
+	spawned.Spawn(g.GetBoard().Width())
+	if g.IsGameOver() || g.GetPiecesPlaced() != 1 || g.GetCurrentPiece().Y != spawned.Y {
+		t.Errorf("game over %v, %d pieces placed, piece at row %d; want the next piece in play at row %d",
+			g.IsGameOver(), g.GetPiecesPlaced(), g.GetCurrentPiece().Y, spawned.Y)
+	}
+}
@@ -0,0 +1,25 @@
+package game
+
+import "fmt"
+
+// tracef writes one line to the trace log, if tracing is enabled
+// Must be called with mu held
+func (g *Game) tracef(format string, args ...interface{}) {
+	if g.trace == nil {
+		return
+	}
+	fmt.Fprintf(g.trace, format+"\n", args...)
+}
+
+// traceMove logs the outcome of a player move
+// Must be called with mu held
+func (g *Game) traceMove(action string, ok bool) {
+	if g.trace == nil {
+		return
+	}
+	if !ok {
+		g.tracef("%s: blocked", action)
+		return
+	}
+	g.tracef("%s: %s at (%d,%d) rotation %d", action, g.current.Type, g.current.X, g.current.Y, g.current.Rotation)
+}
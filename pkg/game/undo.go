@@ -0,0 +1,63 @@
+package game
+
+import "time"
+
+// maxUndo is how many piece placements a practice game remembers for Undo
+const maxUndo = 50
+
+// markPieceStartLocked remembers the state as the current piece spawned, so
+// Undo can bring it back once the piece is placed; practice mode only
+// Must be called with mu held
+func (g *Game) markPieceStartLocked(now time.Time) {
+	if g.mode != ModePractice {
+		return
+	}
+	snap := g.snapshotLocked(now)
+	g.undoStart = &snap
+}
+
+// pushUndoLocked adds the state from the start of the piece about to lock to
+// the undo history, dropping the oldest placement beyond maxUndo
+// Must be called with mu held
+func (g *Game) pushUndoLocked() {
+	if g.undoStart == nil {
+		return
+	}
+	g.undo = append(g.undo, g.undoStart)
+	if len(g.undo) > maxUndo {
+		g.undo = append(g.undo[:0], g.undo[1:]...)
+	}
+	g.undoStart = nil
+}
+
+// Undo reverts the last piece placement in practice mode: the board, score,
+// pieces and bag go back to when that piece spawned, which also revives a
+// game that topped out
+// Play time keeps running; returns false outside practice mode, while paused
+// or with nothing left to undo
+func (g *Game) Undo() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.mode != ModePractice || g.state == StatePaused || len(g.undo) == 0 {
+		return false
+	}
+
+	snap := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	startedAt, pausedFor := g.startedAt, g.pausedFor
+	g.restoreLocked(*snap, g.clock.Now())
+	g.startedAt, g.pausedFor = startedAt, pausedFor
+	g.undoStart = snap
+
+	g.tracef("undo: %s back at spawn, %d more placements can be undone", g.current.Type, len(g.undo))
+	g.markChanged()
+	return true
+}
+
+// UndoCount returns how many piece placements Undo can revert
+func (g *Game) UndoCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.undo)
+}
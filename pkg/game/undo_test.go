@@ -0,0 +1,74 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestUndo verifies that Undo brings back the board, score and piece sequence
+// from before each placement, and only in practice mode
+func TestUndo(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 3, Mode: ModePractice, Headless: true})
+	if g.Undo() {
+		t.Fatal("Undo() = true before any piece was placed")
+	}
+
+	first := g.GetCurrentPiece().Type
+	g.HardDrop()
+	board, score, next := g.GetBoard().Grid(), g.GetScore(), g.GetNextPiece().Type
+	second := g.GetCurrentPiece().Type
+	g.MoveLeft()
+	g.HardDrop()
+	g.Step(time.Second)
+
+	if !g.Undo() {
+		t.Fatal("Undo() = false after two placements")
+	}
+	if !reflect.DeepEqual(g.GetBoard().Grid(), board) || g.GetScore() != score || g.GetNextPiece().Type != next {
+		t.Errorf("after one undo: score %d, next %v, want the state after the first placement (score %d, next %v)",
+			g.GetScore(), g.GetNextPiece().Type, score, next)
+	}
+	if !g.Undo() {
+		t.Fatal("second Undo() = false")
+	}
+	if g.GetCurrentPiece().Type != first || g.GetScore() != 0 || g.GetPiecesPlaced() != 0 {
+		t.Errorf("after two undos: piece %v, score %d, placed %d, want %v, 0, 0",
+			g.GetCurrentPiece().Type, g.GetScore(), g.GetPiecesPlaced(), first)
+	}
+	if g.Undo() {
+		t.Error("Undo() = true with nothing left to undo")
+	}
+	if g.GetElapsed() < time.Second {
+		t.Errorf("elapsed after undo = %v, want play time to keep running", g.GetElapsed())
+	}
+
+	// The same pieces come again after an undo
+	g.HardDrop()
+	if got := g.GetCurrentPiece().Type; got != second {
+		t.Errorf("piece after replaying the first placement = %v, want %v", got, second)
+	}
+
+	marathon := NewWithConfig(Config{Seed: 3, Headless: true})
+	marathon.HardDrop()
+	if marathon.Undo() {
+		t.Error("Undo() = true in marathon mode")
+	}
+}
+
+// TestUndoGameOver verifies that undoing the placement that topped out revives the game
+func TestUndoGameOver(t *testing.T) {
+	g := NewWithConfig(Config{Seed: 9, Mode: ModePractice, Headless: true})
+	for i := 0; i < 100 && !g.IsGameOver(); i++ {
+		g.HardDrop()
+	}
+	if !g.IsGameOver() {
+		t.Fatal("game did not top out")
+	}
+	if !g.Undo() || !g.IsPlaying() {
+		t.Errorf("after undo: state %v, want playing", g.GetState())
+	}
+	if n := g.UndoCount(); n > maxUndo {
+		t.Errorf("UndoCount() = %d, want at most %d", n, maxUndo)
+	}
+}
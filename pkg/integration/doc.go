@@ -0,0 +1,4 @@
+// Package integration holds end-to-end tests that start the server on a
+// random port and play against it over WebSocket and HTTP, as the terminal
+// client, the web client and the admin page do
+package integration
@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest"
+)
+
+// health is the part of the /health response the tests check
+type health struct {
+	Status  string `json:"status"`
+	Clients int    `json:"clients"`
+}
+
+// waitForClients waits until /health reports n clients with status ok
+func waitForClients(t *testing.T, s *servertest.Server, n int) {
+	t.Helper()
+
+	var h health
+	servertest.Eventually(t, "the server to report clients", func() bool {
+		s.Do(http.MethodGet, "/health", nil, &h)
+		return h.Status == "ok" && h.Clients == n
+	})
+}
+
+// call sends a command with a correlation ID and returns the state of its ack
+func call(t *testing.T, conn *servertest.Conn, msgType protocol.MessageType, id string) protocol.StateMessage {
+	t.Helper()
+
+	conn.Send(protocol.ControlMessage{Type: msgType, ID: id})
+	reply := conn.Next(protocol.MessageTypeAck, protocol.MessageTypeError)
+	if reply.ReplyTo != id {
+		t.Fatalf("%s: reply to %q, want %q", msgType, reply.ReplyTo, id)
+	}
+	if reply.Type == protocol.MessageTypeError {
+		var errMsg protocol.ErrorMessage
+		servertest.Decode(t, reply, &errMsg)
+		t.Fatalf("%s: error %s: %s", msgType, errMsg.Code, errMsg.Error)
+	}
+	var state protocol.StateMessage
+	servertest.Decode(t, reply, &state)
+	return state
+}
+
+// TestPlaySession plays a game as a client would: moves, a hard drop, pause
+// and resume, then leaves
+func TestPlaySession(t *testing.T) {
+	s := servertest.New(t)
+	conn := s.Dial("/ws")
+
+	var state protocol.StateMessage
+	servertest.Decode(t, conn.Next(protocol.MessageTypeState), &state)
+	if state.State != "playing" || state.Width == 0 || state.Height == 0 {
+		t.Fatalf("first state = %s %dx%d, want a playing board", state.State, state.Width, state.Height)
+	}
+
+	commands := []protocol.MessageType{
+		protocol.MessageTypeMoveLeft,
+		protocol.MessageTypeMoveRight,
+		protocol.MessageTypeRotate,
+		protocol.MessageTypeMoveDown,
+		protocol.MessageTypeHardDrop,
+	}
+	for _, cmd := range commands {
+		state = call(t, conn, cmd, string(cmd))
+	}
+	if state.Score == 0 {
+		t.Errorf("score after a hard drop = 0, want points for the drop")
+	}
+
+	if state = call(t, conn, protocol.MessageTypePause, "pause"); state.State != "paused" {
+		t.Errorf("state after pause = %s, want paused", state.State)
+	}
+	if state = call(t, conn, protocol.MessageTypeResume, "resume"); state.State != "playing" {
+		t.Errorf("state after resume = %s, want playing", state.State)
+	}
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	waitForClients(t, s, 0)
+}
+
+// TestClientExit verifies the server stays up and forgets a client that
+// disconnects without a close frame, as when the terminal client is killed
+func TestClientExit(t *testing.T) {
+	s := servertest.New(t)
+	conn := s.Dial("/ws")
+	conn.Next(protocol.MessageTypeState)
+	waitForClients(t, s, 1)
+
+	conn.Close()
+	waitForClients(t, s, 0)
+}
+
+// TestMultipleClients verifies clients connected at once each get their own
+// game and are all counted until they leave
+func TestMultipleClients(t *testing.T) {
+	s := servertest.New(t)
+	conns := []*servertest.Conn{s.Dial("/ws"), s.Dial("/ws")}
+	waitForClients(t, s, len(conns))
+
+	for i, conn := range conns {
+		conn.Next(protocol.MessageTypeState)
+		call(t, conn, protocol.MessageTypeMoveLeft, "left")
+		// Only this client's game scores the drop
+		if state := call(t, conn, protocol.MessageTypeHardDrop, "drop"); state.Score == 0 {
+			t.Errorf("client %d: score after a hard drop = 0", i+1)
+		}
+	}
+	if state := call(t, conns[0], protocol.MessageTypeMoveDown, "down"); state.Score == 0 {
+		t.Errorf("client 1: score lost after client 2 played")
+	}
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	waitForClients(t, s, 0)
+}
+
+// adminUpdate is the part of the /ws/admin updates the tests check
+type adminUpdate struct {
+	CurrentClients int `json:"currentClients"`
+	TotalClients   int `json:"totalClients"`
+	Clients        []struct {
+		ID        string `json:"id"`
+		GameState string `json:"gameState"`
+		Score     int    `json:"score"`
+	} `json:"clients"`
+}
+
+// TestAdminUpdates verifies the admin page is told about a client joining
+// and its score as it plays
+func TestAdminUpdates(t *testing.T) {
+	s := servertest.New(t)
+	admin := s.Dial("/ws/admin")
+
+	var update adminUpdate
+	admin.ReadJSON(&update)
+	if update.CurrentClients != 0 {
+		t.Errorf("clients before anyone joined = %d, want 0", update.CurrentClients)
+	}
+
+	player := s.Dial("/ws")
+	player.Next(protocol.MessageTypeState)
+	call(t, player, protocol.MessageTypeMoveLeft, "left")
+	call(t, player, protocol.MessageTypeRotate, "rotate")
+	score := call(t, player, protocol.MessageTypeHardDrop, "drop").Score
+
+	// Updates are sent every second; wait for one taken after the drop
+	for i := 0; i < 5; i++ {
+		admin.ReadJSON(&update)
+		if len(update.Clients) == 1 && update.Clients[0].Score >= score {
+			break
+		}
+	}
+	if update.CurrentClients != 1 || update.TotalClients != 1 || len(update.Clients) != 1 {
+		t.Fatalf("admin update = %+v, want the one client", update)
+	}
+	if got := update.Clients[0]; got.GameState != "playing" || got.Score < score {
+		t.Errorf("admin sees %+v, want playing with score %d", got, score)
+	}
+}
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server"
+	"github.com/ican2002/tetris/pkg/server/servertest"
+)
+
+// dialFake connects a client to a server on a fake clock and places a piece,
+// so its game has something to lose on a restart
+func dialFake(t *testing.T) (*servertest.Conn, *clock.Fake) {
+	t.Helper()
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := servertest.New(t, func(s *server.Server) { s.Clock = clk })
+	conn := s.Dial("/ws")
+	conn.Next(protocol.MessageTypeState)
+	if state := call(t, conn, protocol.MessageTypeHardDrop, "drop"); state.Score == 0 {
+		t.Fatal("score after a hard drop = 0, want points for the drop")
+	}
+	return conn, clk
+}
+
+// restartPending asks to restart and returns how long the confirmation is open
+func restartPending(t *testing.T, conn *servertest.Conn) time.Duration {
+	t.Helper()
+
+	conn.Send(protocol.ControlMessage{Type: protocol.MessageTypeRestart})
+	var pending protocol.RestartPendingMessage
+	servertest.Decode(t, conn.Next(protocol.MessageTypeRestartPending), &pending)
+	return time.Duration(pending.ExpiresInMs) * time.Millisecond
+}
+
+// TestRestartConfirmed verifies a restart of a game in progress waits for
+// restart_confirm and happens once it arrives inside the window
+func TestRestartConfirmed(t *testing.T) {
+	conn, clk := dialFake(t)
+
+	window := restartPending(t, conn)
+	if window <= 0 {
+		t.Fatalf("restart_pending expires in %v, want a window to confirm", window)
+	}
+	if state := call(t, conn, protocol.MessageTypeMoveLeft, "move"); state.Score == 0 {
+		t.Error("game restarted before the confirmation")
+	}
+
+	clk.Advance(window - time.Second)
+	if state := call(t, conn, protocol.MessageTypeRestartConfirm, "confirm"); state.Score != 0 || state.State != "playing" {
+		t.Errorf("state after restart_confirm = %s with score %d, want a new game", state.State, state.Score)
+	}
+}
+
+// TestRestartConfirmExpired verifies a confirmation after the window is
+// refused and leaves the game as it was
+func TestRestartConfirmExpired(t *testing.T) {
+	conn, clk := dialFake(t)
+
+	clk.Advance(restartPending(t, conn) + time.Second)
+	conn.Send(protocol.ControlMessage{Type: protocol.MessageTypeRestartConfirm, ID: "confirm"})
+	reply := conn.Next(protocol.MessageTypeAck, protocol.MessageTypeError)
+	if reply.Type != protocol.MessageTypeError {
+		t.Fatalf("late restart_confirm got %s, want an error", reply.Type)
+	}
+	var errMsg protocol.ErrorMessage
+	servertest.Decode(t, reply, &errMsg)
+	if errMsg.Code != protocol.CodeNotFound {
+		t.Errorf("late restart_confirm error code = %s, want %s", errMsg.Code, protocol.CodeNotFound)
+	}
+	if state := call(t, conn, protocol.MessageTypeMoveLeft, "move"); state.Score == 0 {
+		t.Error("game restarted by a late confirmation")
+	}
+}
+
+// TestRestartWithoutConfirmation verifies a forced restart, and a restart of
+// a finished game, happen at once
+func TestRestartWithoutConfirmation(t *testing.T) {
+	conn, _ := dialFake(t)
+
+	conn.Send(protocol.ControlMessage{Type: protocol.MessageTypeRestart, ID: "force", Force: true})
+	var state protocol.StateMessage
+	servertest.Decode(t, conn.Next(protocol.MessageTypeAck), &state)
+	if state.Score != 0 {
+		t.Errorf("score after a forced restart = %d, want a new game", state.Score)
+	}
+
+	for i := 0; state.State != "gameover"; i++ {
+		if i == 100 {
+			t.Fatal("game still playing after 100 hard drops")
+		}
+		state = call(t, conn, protocol.MessageTypeHardDrop, "drop")
+	}
+	if state = call(t, conn, protocol.MessageTypeRestart, "restart"); state.State != "playing" || state.Score != 0 {
+		t.Errorf("state after restarting a finished game = %s with score %d, want a new game", state.State, state.Score)
+	}
+}
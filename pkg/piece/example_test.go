@@ -0,0 +1,110 @@
+package piece_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// ExampleGenerator_bag shows the 7-bag randomizer: every group of seven
+// pieces contains each piece type exactly once
+func ExampleGenerator_bag() {
+	gen := piece.NewGeneratorWithSeed(42)
+
+	for bag := 0; bag < 2; bag++ {
+		types := make([]string, 7)
+		for i := range types {
+			types[i] = gen.Next().Type.String()
+		}
+		fmt.Println(strings.Join(types, " "))
+	}
+	// Output:
+	// I Z O T S L J
+	// I J O Z S T L
+}
+
+// ExampleNewRandomizer_history shows the TGM-style randomizer, which rarely
+// deals a piece among the last four
+func ExampleNewRandomizer_history() {
+	r, _ := piece.NewRandomizer(piece.RandomizerHistory, 42, nil)
+
+	types := make([]string, 14)
+	for i := range types {
+		types[i] = r.Next().Type.String()
+	}
+	fmt.Println(strings.Join(types, " "))
+	// Output:
+	// T O J I L Z S T J L I Z T S
+}
+
+// ExampleNewRandomizer_scripted shows a fixed sequence, dealt in a loop
+func ExampleNewRandomizer_scripted() {
+	r, _ := piece.NewRandomizer(piece.RandomizerScripted, 0, []piece.Type{piece.TypeT, piece.TypeI})
+
+	types := make([]string, 5)
+	for i := range types {
+		types[i] = r.Next().Type.String()
+	}
+	fmt.Println(strings.Join(types, " "))
+	// Output:
+	// T I T I T
+}
+
+// ExampleGenerator_PeekN shows the look-ahead across bag boundaries, which
+// does not change the pieces dealt
+func ExampleGenerator_PeekN() {
+	gen := piece.NewGeneratorWithSeed(42)
+	gen.Next()
+
+	types := make([]string, 0, 9)
+	for _, t := range gen.PeekN(9) {
+		types = append(types, t.String())
+	}
+	fmt.Println(strings.Join(types, " "))
+	fmt.Println(gen.Next().Type)
+	// Output:
+	// Z O T S L J I J O
+	// Z
+}
+
+// ExamplePiece_Spawn shows where each piece spawns on a 10-wide board: the
+// 3-wide pieces round to the left, and all but the I piece start with their
+// top row hidden above the board
+func ExamplePiece_Spawn() {
+	for _, t := range []piece.Type{piece.TypeI, piece.TypeO, piece.TypeT} {
+		p := piece.New(t)
+		p.Spawn(10)
+		fmt.Printf("%s: columns %d-%d, top row %d\n", t, p.X, p.X+p.GetShape().Width()-1, p.Y)
+	}
+	// Output:
+	// I: columns 3-6, top row 0
+	// O: columns 4-5, top row -1
+	// T: columns 3-5, top row -1
+}
+
+// ExamplePiece_Rotate180 shows a T piece flipped in one step over a gap it
+// cannot flip into in place, kicking up a row instead
+func ExamplePiece_Rotate180() {
+	// A 10x20 board whose bottom row has cells either side of column 4
+	occupied := map[[2]int]bool{{3, 19}: true, {5, 19}: true}
+	collision := func(x, y int, shape piece.Shape) bool {
+		for row := range shape {
+			for col := range shape[row] {
+				if shape[row][col] == 0 {
+					continue
+				}
+				cx, cy := x+col, y+row
+				if cx < 0 || cx >= 10 || cy >= 20 || occupied[[2]int{cx, cy}] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	p := &piece.Piece{Type: piece.TypeT, X: 3, Y: 18, Rotation: 2}
+	fmt.Println(p.Rotate180(collision), p.X, p.Y, p.Rotation)
+	// Output:
+	// true 3 17 0
+}
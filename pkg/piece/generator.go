@@ -2,16 +2,18 @@ package piece
 
 import (
 	"math/rand"
+	"slices"
 	"time"
 )
 
 // allPieceTypes is a slice of all 7 Tetris piece types
 var allPieceTypes = []Type{TypeI, TypeO, TypeT, TypeS, TypeZ, TypeJ, TypeL}
 
-// Generator generates Tetris pieces using the 7-bag randomization algorithm
+// Generator is the Randomizer dealing Tetris pieces with the 7-bag algorithm
 type Generator struct {
-	bag []Type
-	rnd *rand.Rand
+	bag     []Type
+	rnd     *rand.Rand
+	refills int // Bags shuffled so far, so a seeded generator can be restored
 }
 
 // NewGenerator creates a new piece generator
@@ -30,6 +32,17 @@ func NewGeneratorWithSeed(seed int64) *Generator {
 	}
 }
 
+// RestoreGenerator recreates a seeded generator that had shuffled refills
+// bags and has bag left in the current one
+func RestoreGenerator(seed int64, refills int, bag []Type) *Generator {
+	g := NewGeneratorWithSeed(seed)
+	for i := 0; i < refills; i++ {
+		g.refillBag()
+	}
+	g.bag = append(make([]Type, 0, 7), bag...)
+	return g
+}
+
 // Next returns the next piece from the bag
 // If the bag is empty, it refills with a new shuffled bag of all 7 pieces
 func (g *Generator) Next() *Piece {
@@ -58,33 +71,57 @@ func (g *Generator) Peek() *Piece {
 	return New(TypeI) // fallback
 }
 
-// refillBag creates a new bag with all 7 pieces and shuffles it
+// PeekN returns the next n piece types without dealing them, shuffling as
+// many bags ahead as needed
+func (g *Generator) PeekN(n int) []Type {
+	for len(g.bag) < n {
+		g.refillBag()
+	}
+	return slices.Clone(g.bag[:max(n, 0)])
+}
+
+// refillBag appends a new shuffled bag with all 7 pieces
 func (g *Generator) refillBag() {
-	// Create a new bag with all 7 piece types
-	g.bag = make([]Type, 7)
-	copy(g.bag, allPieceTypes)
+	start := len(g.bag)
+	g.bag = append(g.bag, allPieceTypes...)
+	g.refills++
 
-	// Shuffle using Fisher-Yates algorithm
-	g.shuffle()
+	// Shuffle only the new bag using Fisher-Yates algorithm
+	g.shuffle(g.bag[start:])
 }
 
-// shuffle shuffles the bag using Fisher-Yates algorithm
-func (g *Generator) shuffle() {
-	n := len(g.bag)
+// shuffle shuffles a bag using Fisher-Yates algorithm
+func (g *Generator) shuffle(bag []Type) {
+	n := len(bag)
 	for i := n - 1; i > 0; i-- {
 		j := g.rnd.Intn(i + 1)
-		g.bag[i], g.bag[j] = g.bag[j], g.bag[i]
+		bag[i], bag[j] = bag[j], bag[i]
 	}
 }
 
-// BagSize returns the current size of the bag
+// BagSize returns the number of pieces shuffled but not dealt yet
 func (g *Generator) BagSize() int {
 	return len(g.bag)
 }
 
-// Remaining returns the remaining pieces in the bag
+// Refills returns the number of bags shuffled so far
+func (g *Generator) Refills() int {
+	return g.refills
+}
+
+// Remaining returns the pieces shuffled but not dealt yet, in order
 func (g *Generator) Remaining() []Type {
 	result := make([]Type, len(g.bag))
 	copy(result, g.bag)
 	return result
 }
+
+// Kind returns RandomizerBag
+func (g *Generator) Kind() RandomizerKind {
+	return RandomizerBag
+}
+
+// Draws returns the number of pieces dealt by Next so far
+func (g *Generator) Draws() int {
+	return g.refills*len(allPieceTypes) - len(g.bag)
+}
@@ -28,6 +28,8 @@ const (
 	ColorRed    Color = "#FF0000" // Z
 	ColorBlue   Color = "#0000FF" // J
 	ColorOrange Color = "#FFA500" // L
+	ColorGray   Color = "#808080" // Garbage and board setup cells
+	ColorWall   Color = "#404040" // Obstacles of puzzle boards, never cleared
 	ColorEmpty  Color = ""
 )
 
@@ -66,15 +68,27 @@ var colors = map[Type]Color{
 	TypeL: ColorOrange,
 }
 
-// New creates a new piece of the given type
+// standardWidth is the width of a guideline board, which New spawns pieces on
+const standardWidth = 10
+
+// New creates a new piece of the given type at its spawn position on a
+// standard 10-wide board
 func New(t Type) *Piece {
-	return &Piece{
-		Type:     t,
-		Color:    colors[t],
-		X:        3, // Start in the middle of a 10-wide board
-		Y:        0,
-		Rotation: 0,
+	p := &Piece{
+		Type:  t,
+		Color: colors[t],
 	}
+	p.Spawn(standardWidth)
+	return p
+}
+
+// Spawn moves the piece to where it spawns on a board of the given width:
+// centered, rounding left, with its bottom row in the top visible row and
+// the rows above it in the board's hidden rows
+func (p *Piece) Spawn(boardWidth int) {
+	shape := p.GetShape()
+	p.X = (boardWidth - shape.Width()) / 2
+	p.Y = 1 - shape.Height()
 }
 
 // GetShape returns the shape of the piece in its current rotation
@@ -114,12 +128,25 @@ func rotate90(shape Shape) Shape {
 // Rotate rotates the piece 90° clockwise
 // Returns true if successful, false if blocked
 func (p *Piece) Rotate(checkCollision func(x, y int, shape Shape) bool) bool {
+	return p.rotateBy(1, getWallKicks(p.Type, (p.Rotation+1)%4), checkCollision)
+}
+
+// Rotate180 rotates the piece 180° in one step, trying its own kicks
+// rather than those of two 90° rotations
+// Returns true if successful, false if blocked
+func (p *Piece) Rotate180(checkCollision func(x, y int, shape Shape) bool) bool {
+	return p.rotateBy(2, get180Kicks(p.Type), checkCollision)
+}
+
+// rotateBy rotates the piece by the given number of 90° clockwise turns in
+// place, or else at the first of kicks that fits
+func (p *Piece) rotateBy(turns int, kicks []wallKick, checkCollision func(x, y int, shape Shape) bool) bool {
 	if p.Type == TypeO {
 		// O piece doesn't change shape when rotated
 		return true
 	}
 
-	newRotation := (p.Rotation + 1) % 4
+	newRotation := (p.Rotation + turns) % 4
 	newShape := rotate(shapes[p.Type], newRotation)
 
 	// Try basic rotation
@@ -129,7 +156,6 @@ func (p *Piece) Rotate(checkCollision func(x, y int, shape Shape) bool) bool {
 	}
 
 	// Try wall kicks
-	kicks := getWallKicks(p.Type, newRotation)
 	for _, kick := range kicks {
 		newX := p.X + kick.dx
 		newY := p.Y + kick.dy
@@ -165,6 +191,20 @@ func getWallKicks(t Type, rotation int) []wallKick {
 	}
 }
 
+// get180Kicks returns the offsets tried for a 180° rotation, which keeps the
+// piece's bounding box: up first, then sideways, then up and sideways
+func get180Kicks(t Type) []wallKick {
+	if t == TypeI {
+		return []wallKick{
+			{0, -1}, {1, 0}, {-1, 0}, {2, 0}, {-2, 0},
+		}
+	}
+
+	return []wallKick{
+		{0, -1}, {1, 0}, {-1, 0}, {1, -1}, {-1, -1},
+	}
+}
+
 // MoveLeft attempts to move the piece left by one cell
 // Returns true if successful
 func (p *Piece) MoveLeft(checkCollision func(x, y int, shape Shape) bool) bool {
@@ -231,6 +271,21 @@ func (t Type) String() string {
 	return names[t]
 }
 
+// IsValid reports whether t is one of the seven piece types
+func (t Type) IsValid() bool {
+	return t >= TypeI && t <= TypeL
+}
+
+// ParseType returns the piece type named by a letter such as "T"
+func ParseType(name string) (Type, error) {
+	for t := TypeI; t <= TypeL; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("piece: unknown piece type %q", name)
+}
+
 // Width returns the width of a shape
 func (s Shape) Width() int {
 	if len(s) == 0 {
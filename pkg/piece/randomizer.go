@@ -0,0 +1,156 @@
+package piece
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// RandomizerKind identifies the algorithm that chooses the piece sequence
+type RandomizerKind string
+
+const (
+	RandomizerBag      RandomizerKind = "bag"      // Shuffled bags of all 7 pieces (the default)
+	RandomizerRandom   RandomizerKind = "random"   // Every piece equally likely, as in classic Tetris
+	RandomizerHistory  RandomizerKind = "history"  // TGM-style: rerolls pieces dealt among the last 4
+	RandomizerScripted RandomizerKind = "scripted" // A fixed sequence, repeated
+)
+
+// historyRolls is how many times the history randomizer rolls for a piece
+// that is not in its history before taking the last roll
+const historyRolls = 6
+
+// ErrEmptyScript is returned by NewRandomizer for a scripted randomizer without pieces
+var ErrEmptyScript = errors.New("piece: scripted randomizer needs at least one piece")
+
+// Randomizer chooses the sequence of pieces a game receives
+// Seeded randomizers deal the same sequence for the same seed
+type Randomizer interface {
+	// Next returns the next piece and advances the sequence
+	Next() *Piece
+	// Peek returns the next piece without advancing the sequence
+	Peek() *Piece
+	// PeekN returns the types of the next n pieces without advancing the sequence
+	PeekN(n int) []Type
+	// Kind returns the algorithm of the randomizer
+	Kind() RandomizerKind
+	// Draws returns the number of pieces dealt by Next so far
+	Draws() int
+}
+
+// IsValid returns true if k is a known randomizer
+func (k RandomizerKind) IsValid() bool {
+	switch k {
+	case RandomizerBag, RandomizerRandom, RandomizerHistory, RandomizerScripted:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewRandomizer creates a randomizer of the given kind; script is the
+// sequence of a scripted randomizer and ignored by the others
+func NewRandomizer(kind RandomizerKind, seed int64, script []Type) (Randomizer, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	switch kind {
+	case RandomizerBag:
+		return NewGeneratorWithSeed(seed), nil
+	case RandomizerRandom:
+		return &sequence{kind: kind, pick: func() Type {
+			return allPieceTypes[rnd.Intn(len(allPieceTypes))]
+		}}, nil
+	case RandomizerHistory:
+		return &sequence{kind: kind, pick: historyPicker(rnd)}, nil
+	case RandomizerScripted:
+		if len(script) == 0 {
+			return nil, ErrEmptyScript
+		}
+		script = slices.Clone(script)
+		i := 0
+		return &sequence{kind: kind, pick: func() Type {
+			t := script[i%len(script)]
+			i++
+			return t
+		}}, nil
+	default:
+		return nil, fmt.Errorf("piece: unknown randomizer %q", kind)
+	}
+}
+
+// RestoreRandomizer recreates a seeded randomizer that had dealt draws pieces
+// The bag randomizer is restored faster by RestoreGenerator
+func RestoreRandomizer(kind RandomizerKind, seed int64, script []Type, draws int) (Randomizer, error) {
+	r, err := NewRandomizer(kind, seed, script)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < draws; i++ {
+		r.Next()
+	}
+	return r, nil
+}
+
+// historyPicker returns the piece chooser of the TGM randomizer: it keeps the
+// last 4 pieces dealt and rolls up to historyRolls times for one that is not
+// among them; the first piece is never S, Z or O
+func historyPicker(rnd *rand.Rand) func() Type {
+	history := []Type{TypeZ, TypeS, TypeS, TypeZ}
+	first := true
+	return func() Type {
+		var t Type
+		if first {
+			first = false
+			starts := []Type{TypeI, TypeT, TypeJ, TypeL}
+			t = starts[rnd.Intn(len(starts))]
+		} else {
+			for i := 0; i < historyRolls; i++ {
+				t = allPieceTypes[rnd.Intn(len(allPieceTypes))]
+				if !slices.Contains(history, t) {
+					break
+				}
+			}
+		}
+		history = append(history[1:], t)
+		return t
+	}
+}
+
+// sequence is a Randomizer dealing the pieces chosen by pick
+type sequence struct {
+	kind  RandomizerKind
+	pick  func() Type
+	ahead []Type // Pieces picked for Peek but not dealt yet
+	draws int
+}
+
+// Next returns the next piece and advances the sequence
+func (s *sequence) Next() *Piece {
+	p := s.Peek()
+	s.ahead = s.ahead[1:]
+	s.draws++
+	return p
+}
+
+// Peek returns the next piece without advancing the sequence
+func (s *sequence) Peek() *Piece {
+	return New(s.PeekN(1)[0])
+}
+
+// PeekN returns the types of the next n pieces without advancing the sequence
+func (s *sequence) PeekN(n int) []Type {
+	for len(s.ahead) < n {
+		s.ahead = append(s.ahead, s.pick())
+	}
+	return slices.Clone(s.ahead[:max(n, 0)])
+}
+
+// Kind returns the algorithm of the randomizer
+func (s *sequence) Kind() RandomizerKind {
+	return s.kind
+}
+
+// Draws returns the number of pieces dealt by Next so far
+func (s *sequence) Draws() int {
+	return s.draws
+}
@@ -0,0 +1,77 @@
+package protocol
+
+import "fmt"
+
+// Close codes the server sends in the close frame when it ends a connection,
+// from the range WebSocket leaves to applications
+const (
+	CloseIdleTimeout    = 4000 // No control input for the idle timeout
+	CloseSlowClient     = 4001 // The send queue stayed full
+	CloseKicked         = 4002 // Disconnected by an admin, such as when banned
+	CloseServerShutdown = 4003 // The server is shutting down
+	CloseProtocolError  = 4004 // The client sent a message the server cannot read
+	CloseTimeout        = 4005 // The client stopped answering pings
+
+	// Standard codes the server also uses
+	CloseNormal        = 1000
+	CloseMessageTooBig = 1009 // A message was larger than MaxMessageSize
+	CloseServerFull    = 1013 // Try again later
+)
+
+// CloseReason names why a connection ended
+type CloseReason string
+
+const (
+	ReasonNormal         CloseReason = "normal"
+	ReasonIdleTimeout    CloseReason = "idle_timeout"
+	ReasonSlowClient     CloseReason = "slow_client"
+	ReasonKicked         CloseReason = "kicked"
+	ReasonServerShutdown CloseReason = "server_shutdown"
+	ReasonProtocolError  CloseReason = "protocol_error"
+	ReasonTimeout        CloseReason = "timeout"
+	ReasonServerFull     CloseReason = "server_full"
+	ReasonMessageTooBig  CloseReason = "message_too_big"
+	ReasonConnectionLost CloseReason = "connection_lost" // No close frame, such as a network failure
+	ReasonUnknown        CloseReason = "unknown"         // A close code of no reason above
+)
+
+// closeReasons maps close codes to their reasons
+var closeReasons = map[int]CloseReason{
+	CloseNormal:         ReasonNormal,
+	CloseIdleTimeout:    ReasonIdleTimeout,
+	CloseSlowClient:     ReasonSlowClient,
+	CloseKicked:         ReasonKicked,
+	CloseServerShutdown: ReasonServerShutdown,
+	CloseProtocolError:  ReasonProtocolError,
+	CloseTimeout:        ReasonTimeout,
+	CloseServerFull:     ReasonServerFull,
+	CloseMessageTooBig:  ReasonMessageTooBig,
+}
+
+// DisconnectReason describes how a connection ended: the close code and text
+// of the close frame, and the reason they stand for
+type DisconnectReason struct {
+	Reason CloseReason
+	Code   int    // 0 if the connection ended without a close frame
+	Text   string // Close reason sent by the peer, for people
+}
+
+// NewDisconnectReason returns the reason of a close frame with code and text
+func NewDisconnectReason(code int, text string) DisconnectReason {
+	reason, ok := closeReasons[code]
+	if !ok {
+		reason = ReasonUnknown
+	}
+	return DisconnectReason{Reason: reason, Code: code, Text: text}
+}
+
+// String describes the reason, such as "idle timeout (4000)"
+func (d DisconnectReason) String() string {
+	if d.Code == 0 {
+		return "connection lost"
+	}
+	if d.Text != "" {
+		return fmt.Sprintf("%s (%d)", d.Text, d.Code)
+	}
+	return fmt.Sprintf("%s (%d)", d.Reason, d.Code)
+}
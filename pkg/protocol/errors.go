@@ -0,0 +1,17 @@
+package protocol
+
+// ErrorCode tells clients what went wrong in an error message, so they can
+// react without matching on the error text
+type ErrorCode string
+
+const (
+	CodeInvalidMessage     ErrorCode = "invalid_message"     // The control message was invalid; kind tells how
+	CodeGameOver           ErrorCode = "game_over"           // The command needs a game in progress
+	CodeRateLimited        ErrorCode = "rate_limited"        // Too many messages, such as chat; slow down
+	CodeUnauthorized       ErrorCode = "unauthorized"        // The player's credentials were rejected
+	CodeRoomFull           ErrorCode = "room_full"           // The server is full; try again later
+	CodeUnsupportedVersion ErrorCode = "unsupported_version" // The saved game is from a version this server cannot load
+	CodeNotAllowed         ErrorCode = "not_allowed"         // The command is not available in this mode or match
+	CodeNotFound           ErrorCode = "not_found"           // The saved game, puzzle, board or pending restart does not exist
+	CodeInternal           ErrorCode = "internal"            // The server failed to carry out the command
+)
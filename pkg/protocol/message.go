@@ -3,55 +3,180 @@ package protocol
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/ican2002/tetris/pkg/board"
 	"github.com/ican2002/tetris/pkg/game"
 	"github.com/ican2002/tetris/pkg/piece"
 )
 
+// MaxNameLength is the longest display name a player can register
+const MaxNameLength = 16
+
+// MaxChatLength is the longest chat message a player can send, in characters
+const MaxChatLength = 200
+
+// MaxBoards is the most boards a connection can play, side by side in a
+// doubles game requested with boards= in the connect URL
+const MaxBoards = 2
+
+// Emotes are the quick messages players of a match can send each other;
+// being predefined, they need no moderation
+const (
+	EmoteGG   = "gg"
+	EmoteNice = "nice"
+	EmoteOops = "oops"
+)
+
+// Emotes lists the valid emotes in the order clients offer them
+var Emotes = []string{EmoteGG, EmoteNice, EmoteOops}
+
 // MessageType represents the type of message
 type MessageType string
 
 const (
 	// Client to Server messages
-	MessageTypeMoveLeft    MessageType = "move_left"
-	MessageTypeMoveRight   MessageType = "move_right"
-	MessageTypeMoveDown    MessageType = "move_down"
-	MessageTypeRotate      MessageType = "rotate"
-	MessageTypeHardDrop    MessageType = "hard_drop"
-	MessageTypeTogglePause MessageType = "toggle_pause"
-	MessageTypePause       MessageType = "pause"
-	MessageTypeResume      MessageType = "resume"
-	MessageTypeRestart     MessageType = "restart"
-	MessageTypePong        MessageType = "pong"
+	MessageTypeMoveLeft       MessageType = "move_left"
+	MessageTypeMoveRight      MessageType = "move_right"
+	MessageTypeMoveDown       MessageType = "move_down"
+	MessageTypeRotate         MessageType = "rotate"
+	MessageTypeRotate180      MessageType = "rotate_180"
+	MessageTypeHardDrop       MessageType = "hard_drop"
+	MessageTypeTogglePause    MessageType = "toggle_pause"
+	MessageTypePause          MessageType = "pause"
+	MessageTypeResume         MessageType = "resume"
+	MessageTypeRestart        MessageType = "restart"
+	MessageTypeRestartConfirm MessageType = "restart_confirm"
+	MessageTypeSelectMode     MessageType = "select_mode"
+	MessageTypeSetName        MessageType = "set_name"
+	MessageTypeHold           MessageType = "hold"
+	MessageTypePong           MessageType = "pong"
+	MessageTypeChat           MessageType = "chat"  // Also sent by the server to relay a message to the match
+	MessageTypeEmote          MessageType = "emote" // Also sent by the server to relay an emote to the match
+	MessageTypeSaveGame       MessageType = "save_game"
+	MessageTypeLoadGame       MessageType = "load_game"
+	MessageTypeUndo           MessageType = "undo"           // Practice mode only
+	MessageTypeSetBoard       MessageType = "set_board"      // Practice mode only
+	MessageTypeSetNextPiece   MessageType = "set_next_piece" // Practice mode only
+	MessageTypePauseVote      MessageType = "pause_vote"     // Match only; also sent by the server with the tally of the vote
 
 	// Server to Client messages
-	MessageTypeState    MessageType = "state"
-	MessageTypeError    MessageType = "error"
-	MessageTypePing     MessageType = "ping"
-	MessageTypeGameOver MessageType = "game_over"
+	MessageTypeState              MessageType = "state"
+	MessageTypeCompactState       MessageType = "compact_state" // State with the board as a bitmask, see board.Compact
+	MessageTypeError              MessageType = "error"
+	MessageTypePing               MessageType = "ping"
+	MessageTypeGameOver           MessageType = "game_over"
+	MessageTypePerfectClearAttack MessageType = "perfect_clear_attack"
+	MessageTypeRestartPending     MessageType = "restart_pending"
+	MessageTypeIdleTimeout        MessageType = "idle_timeout"
+	MessageTypeMatchJoined        MessageType = "match_joined"
+	MessageTypeMatchResult        MessageType = "match_result"
+	MessageTypeOpponentState      MessageType = "opponent_state"
+	MessageTypeServerShutdown     MessageType = "server_shutdown"
+	MessageTypeSavedGame          MessageType = "saved_game"
+	MessageTypeScoreEvent         MessageType = "score_event" // Sent after the state of a frame that cleared lines
+	MessageTypeNotice             MessageType = "notice"      // Server-wide announcement; admins send it with this type too
+	MessageTypeAck                MessageType = "ack"         // Reply to a control message with an id, carrying the state after it
+	MessageTypeYourTurn           MessageType = "your_turn"   // Co-op only: whose piece is falling on the shared board
 )
 
 // Message represents a WebSocket message
 type Message struct {
-	Type MessageType `json:"type"`
-	Data interface{} `json:"data,omitempty"`
+	Type    MessageType `json:"type"`
+	ReplyTo string      `json:"reply_to,omitempty"` // ID of the control message this answers, on its ack or error
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // ControlMessage represents a control command from client
 type ControlMessage struct {
-	Type MessageType `json:"type"`
+	Type  MessageType `json:"type"`
+	Force bool        `json:"force,omitempty"` // Restart without confirmation
+	Mode  string      `json:"mode,omitempty"`  // Game mode for select_mode
+	Name  string      `json:"name,omitempty"`  // Display name for set_name
+	Text  string      `json:"text,omitempty"`  // Message for chat
+	Emote string      `json:"emote,omitempty"` // One of Emotes, for emote
+	Seq   uint64      `json:"seq,omitempty"`   // Client-assigned input sequence, echoed back as ack_seq
+	ID    string      `json:"id,omitempty"`    // Client-assigned correlation ID, answered by an ack or error with this reply_to
+
+	// Board of a doubles game the command is for; 0 is the first
+	BoardIndex int `json:"board_index,omitempty"`
+
+	DigRows  int             `json:"dig_rows,omitempty"` // Garbage rows of a dig game for select_mode (0 = game.DefaultDigRows)
+	Puzzle   string          `json:"puzzle,omitempty"`   // Level of a puzzle game for select_mode (empty = the server's first)
+	Snapshot json.RawMessage `json:"snapshot,omitempty"` // Game saved by save_game, for load_game
+	Rows     []string        `json:"rows,omitempty"`     // Board rows for set_board such as "XXXX.XXXXX", bottom row last
+	Pieces   string          `json:"pieces,omitempty"`   // Piece letters for set_next_piece, e.g. "TSZ", next piece first
+
+	// Answer to a match's pause vote for pause_vote; false declines
+	Agree bool `json:"agree,omitempty"`
 }
 
 // StateMessage represents the game state sent to client
 type StateMessage struct {
-	Board        [][]string `json:"board"`
-	CurrentPiece PieceData  `json:"current_piece"`
-	NextPiece    PieceData  `json:"next_piece"`
-	State        string     `json:"state"`
-	Score        int        `json:"score"`
-	Level        int        `json:"level"`
-	Lines        int        `json:"lines"`
-	DropInterval int        `json:"drop_interval_ms"`
+	Seq          uint64         `json:"seq"`     // Increases with every frame on a connection; 0 if unsequenced
+	AckSeq       uint64         `json:"ack_seq"` // Seq of the last client input applied before this frame
+	Width        int            `json:"width"`
+	Height       int            `json:"height"`
+	Seed         int64          `json:"seed"`                    // Piece generator seed; equal for all players of a match
+	Randomizer   string         `json:"randomizer"`              // Algorithm choosing the pieces, see piece.RandomizerKind
+	Board        [][]string     `json:"board,omitempty"`         // Omitted in compact_state frames
+	CompactBoard *board.Compact `json:"compact_board,omitempty"` // Set instead of Board in compact_state frames
+	CurrentPiece PieceData      `json:"current_piece"`
+	NextPiece    PieceData      `json:"next_piece"`
+	HoldPiece    *PieceData     `json:"hold_piece,omitempty"` // Nil until the first hold
+	CanHold      bool           `json:"can_hold"`             // False once hold was used for the current piece
+	Spawning     bool           `json:"spawning,omitempty"`   // The next piece waits out the line clear or spawn delay; current_piece is already locked
+	State        string         `json:"state"`
+	Score        int            `json:"score"`
+	Level        int            `json:"level"`
+	Lines        int            `json:"lines"`
+	DropInterval int            `json:"drop_interval_ms"`
+	StackHeight  int            `json:"stack_height"` // Rows from the bottom up to the highest locked cell
+
+	// Game clock, excluding time spent paused
+	ElapsedMs int `json:"elapsed_ms"`
+	PausedMs  int `json:"paused_ms"`
+
+	// Mode progress
+	Mode             string `json:"mode"`
+	LinesRemaining   int    `json:"lines_remaining,omitempty"`
+	TimeRemainingMs  int    `json:"time_remaining_ms,omitempty"`
+	GarbageRemaining int    `json:"garbage_remaining,omitempty"` // Garbage rows left to clear in dig mode and garbage puzzles
+	GarbageTotal     int    `json:"garbage_total,omitempty"`     // Garbage rows the dig game started with
+	Puzzle           string `json:"puzzle,omitempty"`            // Level of a puzzle game
+	Objective        string `json:"objective,omitempty"`         // The puzzle level's objective, for players
+	PiecesRemaining  int    `json:"pieces_remaining,omitempty"`  // Pieces left to reach the puzzle objective with
+
+	// Full rows shown until the line clear delay ends, top to bottom
+	ClearingRows []int `json:"clearing_rows,omitempty"`
+
+	// Garbage rows sent by a versus opponent, rising at the next lock that clears nothing
+	PendingGarbage int `json:"pending_garbage,omitempty"`
+
+	// Set by a handicap that hides the next piece; next_piece is then empty
+	PreviewHidden bool `json:"preview_hidden,omitempty"`
+
+	// Doubles games only
+	BoardIndex int `json:"board_index,omitempty"` // Board the frame shows
+	TeamScore  int `json:"team_score,omitempty"`  // Sum of the scores of both boards
+}
+
+// ExpandBoard fills Board from CompactBoard, so compact and full frames can be
+// handled alike; it does nothing for full frames
+func (s *StateMessage) ExpandBoard() error {
+	if s.CompactBoard == nil {
+		return nil
+	}
+	b, err := board.UnmarshalCompact(s.CompactBoard)
+	if err != nil {
+		return err
+	}
+	s.Board = b.Grid()
+	s.CompactBoard = nil
+	return nil
 }
 
 // PieceData represents piece information for serialization
@@ -65,8 +190,9 @@ type PieceData struct {
 
 // ErrorMessage represents an error message
 type ErrorMessage struct {
-	Error string `json:"error"`
-	Code  int    `json:"code,omitempty"`
+	Error string    `json:"error"` // For people; may change between versions
+	Code  ErrorCode `json:"code"`
+	Kind  ErrorKind `json:"kind,omitempty"` // How the message was invalid, for invalid_message
 }
 
 // PingMessage represents a ping message
@@ -81,13 +207,264 @@ type PongMessage struct {
 
 // GameOverMessage represents a game over message
 type GameOverMessage struct {
-	Score int `json:"score"`
-	Level int `json:"level"`
-	Lines int `json:"lines"`
+	Score         int            `json:"score"`
+	Level         int            `json:"level"`
+	Lines         int            `json:"lines"`
+	Seed          int64          `json:"seed"`
+	Randomizer    string         `json:"randomizer"`
+	Ruleset       string         `json:"ruleset"`
+	ClientVersion string         `json:"client_version,omitempty"`
+	Mode          string         `json:"mode"`
+	Completed     bool           `json:"completed"` // True if the mode's goal was reached
+	ElapsedMs     int            `json:"elapsed_ms"`
+	Pieces        int            `json:"pieces"`
+	PieceCounts   map[string]int `json:"piece_counts"` // Pieces locked, keyed by piece type (I, O, T, ...)
+
+	// Doubles games only
+	BoardIndex int `json:"board_index,omitempty"` // Board that ended
+	TeamScore  int `json:"team_score,omitempty"`  // Sum of the scores of both boards
+}
+
+// PerfectClearAttackMessage announces a perfect clear and the garbage it sends
+// It goes to every player of the match, and from a CPU opponent to the player
+type PerfectClearAttackMessage struct {
+	Lines   int    `json:"lines"`
+	Garbage int    `json:"garbage"`
+	Seat    int    `json:"seat"` // Seat of the player who cleared, as in opponent_state
+	Name    string `json:"name,omitempty"`
+}
+
+// ScoreEventMessage announces the points awarded for a line clear, for clients
+// to show as a popup
+type ScoreEventMessage struct {
+	Lines   int  `json:"lines"`
+	Points  int  `json:"points"`
+	Level   int  `json:"level"`              // Level after the clear
+	LevelUp bool `json:"level_up,omitempty"` // The clear raised the level
+
+	BoardIndex int `json:"board_index,omitempty"` // Board that cleared, in doubles games
+}
+
+// ServerShutdownMessage warns that the server closes the connection in
+// GraceMs to shut down
+// SessionID is set if the game is saved: reconnecting with ?session= after the
+// restart continues it
+type ServerShutdownMessage struct {
+	GraceMs   int    `json:"grace_ms"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// SavedGameMessage answers save_game with the paused game in the format of
+// game.Snapshot; send it back in load_game to continue the game
+type SavedGameMessage struct {
+	Snapshot json.RawMessage `json:"snapshot"`
+}
+
+// RestartPendingMessage asks the client to confirm a restart
+type RestartPendingMessage struct {
+	ExpiresInMs int `json:"expires_in_ms"`
+}
+
+// MatchJoinedMessage confirms the match a client joined in the connect URL
+// Every player of a match receives the same piece sequence
+type MatchJoinedMessage struct {
+	MatchID string        `json:"match_id"`
+	Seed    int64         `json:"seed"`
+	Players int           `json:"players"`          // Players in the match, including this one
+	Roster  []MatchPlayer `json:"roster,omitempty"` // The players in the order they joined
+}
+
+// OpponentStateMessage is a view of another match player's game, sent at a
+// lower rate than the player's own state frames
+type OpponentStateMessage struct {
+	Seat           int            `json:"seat"` // Seat of the player in the roster
+	Name           string         `json:"name,omitempty"`
+	State          string         `json:"state"`
+	Score          int            `json:"score"`
+	Level          int            `json:"level"`
+	Lines          int            `json:"lines"`
+	PendingGarbage int            `json:"pending_garbage,omitempty"` // Garbage rows about to rise into the board
+	Board          *board.Compact `json:"board"`                     // Locked cells with the falling piece drawn in
+}
+
+// EmoteMessage is an emote relayed to the other players of a match
+type EmoteMessage struct {
+	Seat   int       `json:"seat"`           // Sender's seat in the roster
+	From   string    `json:"from,omitempty"` // Sender's display name
+	Emote  string    `json:"emote"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// ChatMessage is a chat message relayed to the players of a match
+type ChatMessage struct {
+	From   string    `json:"from,omitempty"` // Sender's display name
+	Text   string    `json:"text"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// NoticeMessage is an announcement an admin sent to every player, such as a
+// maintenance warning
+type NoticeMessage struct {
+	Text   string    `json:"text"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// MatchPlayer is a player in a match
+type MatchPlayer struct {
+	Seat   int    `json:"seat"` // Numbers the players of a match in the order they joined
+	Name   string `json:"name,omitempty"`
+	Rating int    `json:"rating,omitempty"` // Zero for guests, who are not rated
+
+	Handicap string `json:"handicap,omitempty"` // The player's handicap as game.ParseHandicap reads it; empty for none
+}
+
+// MatchResultMessage is sent to the players of a match once every player has
+// finished, with the rating changes of the registered players
+type MatchResultMessage struct {
+	MatchID   string          `json:"match_id"`
+	Standings []MatchStanding `json:"standings"` // Highest score first
+}
+
+// MatchStanding is a player's final result in a match
+type MatchStanding struct {
+	Name         string `json:"name,omitempty"`
+	Score        int    `json:"score"`
+	Rating       int    `json:"rating,omitempty"` // Rating after the match; zero for guests
+	RatingChange int    `json:"rating_change"`
+}
+
+// IdleTimeoutMessage tells the client its connection is being closed for inactivity
+type IdleTimeoutMessage struct {
+	IdleMs int `json:"idle_ms"` // How long no control input was received
+}
+
+// YourTurnMessage tells the players of a co-op game whose piece is falling
+// on their shared board; it is sent to both whenever the turn passes
+type YourTurnMessage struct {
+	YourTurn bool   `json:"your_turn"`        // The falling piece is this player's to place
+	Seat     int    `json:"seat"`             // Seat of the player whose piece it is; the first to join is 0
+	Player   string `json:"player,omitempty"` // Name of the player whose piece it is
+}
+
+// Outcomes of a pause vote
+const (
+	PauseVoteOpen   = "open"   // Waiting for answers
+	PauseVotePassed = "passed" // Enough players agreed; every game of the match is paused
+	PauseVoteFailed = "failed" // Too many players declined, or the vote timed out
+)
+
+// PauseVoteMessage tells the players of a match how a vote to pause it
+// stands; it is sent to all of them when the vote opens, on every answer and
+// once it is decided
+type PauseVoteMessage struct {
+	RequestedBy string `json:"requested_by,omitempty"` // Name of the player who asked to pause
+	Result      string `json:"result"`                 // PauseVoteOpen, PauseVotePassed or PauseVoteFailed
+	Agreed      int    `json:"agreed"`
+	Declined    int    `json:"declined"`
+	Needed      int    `json:"needed"`                  // Agreements that pause the match: a majority of its players
+	Voted       bool   `json:"voted"`                   // The player this is sent to has answered
+	ExpiresInMs int    `json:"expires_in_ms,omitempty"` // Time left to answer while the vote is open
+}
+
+// ScoreEntry represents a finished game on the leaderboard
+type ScoreEntry struct {
+	Name          string    `json:"name,omitempty"`
+	Score         int       `json:"score"`
+	Level         int       `json:"level"`
+	Lines         int       `json:"lines"`
+	Seed          int64     `json:"seed"`
+	Ruleset       string    `json:"ruleset"`
+	ClientVersion string    `json:"client_version,omitempty"`
+	Station       string    `json:"station,omitempty"` // Kiosk station the game was played on
+	Mode          string    `json:"mode"`
+	ElapsedMs     int       `json:"elapsed_ms"`
+	EndedAt       time.Time `json:"ended_at"`
+}
+
+// LobbyInfo represents the lobby summary served by the REST API
+type LobbyInfo struct {
+	ActivePlayers int          `json:"active_players"`
+	TopScores     []ScoreEntry `json:"top_scores"`
+}
+
+// CreateGameRequest is the body of POST /api/games; both fields are optional
+type CreateGameRequest struct {
+	Mode string `json:"mode,omitempty"` // Game mode (empty = marathon)
+	Seed int64  `json:"seed,omitempty"` // Piece generator seed (0 = time-based)
+
+	DigRows    int    `json:"dig_rows,omitempty"`   // Garbage rows of a dig game (0 = game.DefaultDigRows)
+	Randomizer string `json:"randomizer,omitempty"` // Piece randomizer, see piece.RandomizerKind (empty = bag)
+	Script     string `json:"script,omitempty"`     // Piece letters dealt in a loop by the scripted randomizer, e.g. "IOT"
+}
+
+// GameCreated is the response to POST /api/games
+type GameCreated struct {
+	GameID string       `json:"game_id"`
+	State  StateMessage `json:"state"`
+}
+
+// RegisterRequest is the body of POST /api/players
+type RegisterRequest struct {
+	Name string `json:"name"`
+}
+
+// BanRequest is the body of POST /api/admin/bans
+type BanRequest struct {
+	IP       string `json:"ip"`
+	Reason   string `json:"reason,omitempty"`
+	Duration string `json:"duration,omitempty"` // Such as "1h"; empty bans for good
+}
+
+// NoticeRequest is the body of POST /api/admin/notice
+type NoticeRequest struct {
+	Text string `json:"text"`
+}
+
+// PlayerProfile is a registered player's lifetime statistics, served by GET /api/players/{id}
+type PlayerProfile struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	GamesPlayed int       `json:"games_played"`
+	TotalLines  int       `json:"total_lines"`
+	BestScore   int       `json:"best_score"`
+	Rating      int       `json:"rating"`      // Elo rating from head-to-head matches
+	RatedGames  int       `json:"rated_games"` // Matches that changed the rating
+	CreatedAt   time.Time `json:"created_at"`
+	LastPlayed  time.Time `json:"last_played"` // Zero until the first game
+}
+
+// Ladder is the rating ladder served by GET /api/ladder
+type Ladder struct {
+	Players []PlayerProfile `json:"players"` // Highest rating first
+}
+
+// Heatmap is the aggregate served by GET /api/analytics/heatmap: for each
+// cell of the board, top row first, how many locked pieces filled it and how
+// many times a lock covered it as a hole, across the finished games
+type Heatmap struct {
+	Games      int     `json:"games"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Placements [][]int `json:"placements"`
+	Holes      [][]int `json:"holes"`
+}
+
+// PlayerRegistered is the response to POST /api/players
+// The token authenticates the player when connecting and is only returned once
+type PlayerRegistered struct {
+	Token   string        `json:"token"`
+	Profile PlayerProfile `json:"profile"`
 }
 
 // NewStateMessage creates a state message from game state
 func NewStateMessage(g *game.Game) *Message {
+	return NewSequencedStateMessage(g, 0, 0)
+}
+
+// NewSequencedStateMessage creates a state message carrying a frame sequence
+// number, so clients can drop stale or out-of-order frames, and the sequence
+// of the last input applied, so clients can reconcile predicted input
+func NewSequencedStateMessage(g *game.Game, seq, ackSeq uint64) *Message {
 	// Use GetStateSnapshot for consistent state and proper piece cloning
 	boardCopy, current, next, stateStr, score, level, lines, dropInterval := g.GetStateSnapshot()
 
@@ -101,15 +478,54 @@ func NewStateMessage(g *game.Game) *Message {
 		next = &piece.Piece{}
 	}
 
+	status := g.GetModeStatus()
+
+	var hold *PieceData
+	if held := g.GetHoldPiece(); held != nil {
+		data := pieceToData(held)
+		hold = &data
+	}
+
+	height := len(boardCopy)
+	width := 0
+	if height > 0 {
+		width = len(boardCopy[0])
+	}
+
 	state := StateMessage{
-		Board:        boardCopy,
-		CurrentPiece: pieceToData(current),
-		NextPiece:    pieceToData(next),
-		State:        stateStr,
-		Score:        score,
-		Level:        level,
-		Lines:        lines,
-		DropInterval: int(dropInterval.Milliseconds()),
+		Seq:              seq,
+		AckSeq:           ackSeq,
+		Width:            width,
+		Height:           height,
+		Seed:             g.GetSeed(),
+		Randomizer:       string(g.GetRandomizer()),
+		Board:            boardCopy,
+		CurrentPiece:     pieceToData(current),
+		NextPiece:        pieceToData(next),
+		HoldPiece:        hold,
+		CanHold:          g.CanHold(),
+		Spawning:         g.IsSpawning(),
+		ClearingRows:     g.GetClearingRows(),
+		StackHeight:      g.GetStackHeight(),
+		State:            stateStr,
+		Score:            score,
+		Level:            level,
+		Lines:            lines,
+		DropInterval:     int(dropInterval.Milliseconds()),
+		ElapsedMs:        int(status.Elapsed.Milliseconds()),
+		PausedMs:         int(g.GetPausedDuration().Milliseconds()),
+		Mode:             string(status.Mode),
+		LinesRemaining:   status.LinesRemaining,
+		TimeRemainingMs:  int(status.TimeRemaining.Milliseconds()),
+		GarbageRemaining: status.GarbageRemaining,
+		GarbageTotal:     status.GarbageTotal,
+		Puzzle:           status.Puzzle,
+		Objective:        status.Objective,
+		PiecesRemaining:  status.PiecesRemaining,
+		PendingGarbage:   g.GetPendingGarbage(),
+	}
+	if g.GetHandicap().HidePreview {
+		state.NextPiece, state.PreviewHidden = PieceData{}, true
 	}
 
 	return &Message{
@@ -132,8 +548,66 @@ func pieceToData(p *piece.Piece) PieceData {
 	}
 }
 
-// NewErrorMessage creates an error message
-func NewErrorMessage(err string, code int) *Message {
+// NewCompactStateMessage creates a sequenced state message with the board in
+// compact form, about a tenth of the size of a full state frame
+// Boards too wide to compact are sent as a full state frame
+func NewCompactStateMessage(g *game.Game, seq, ackSeq uint64) *Message {
+	msg := NewSequencedStateMessage(g, seq, ackSeq)
+	state := msg.Data.(StateMessage)
+
+	compact, err := board.FromGrid(state.Board).MarshalCompact()
+	if err != nil {
+		return msg
+	}
+	state.Board = nil
+	state.CompactBoard = compact
+
+	return &Message{
+		Type: MessageTypeCompactState,
+		Data: state,
+	}
+}
+
+// NewAckMessage creates the ack of the control message with correlation ID
+// id, carrying the state of g after the command
+func NewAckMessage(id string, g *game.Game) *Message {
+	msg := NewStateMessage(g)
+	msg.Type = MessageTypeAck
+	msg.ReplyTo = id
+	return msg
+}
+
+// SetBoard marks a state, ack, game over or score event message of a doubles
+// game with the board it concerns and, but for score events, the team score
+func (m *Message) SetBoard(index, teamScore int) {
+	switch data := m.Data.(type) {
+	case StateMessage:
+		data.BoardIndex, data.TeamScore = index, teamScore
+		m.Data = data
+	case GameOverMessage:
+		data.BoardIndex, data.TeamScore = index, teamScore
+		m.Data = data
+	case ScoreEventMessage:
+		data.BoardIndex = index
+		m.Data = data
+	}
+}
+
+// NewValidationErrorMessage creates the error message rejecting an invalid
+// control message
+func NewValidationErrorMessage(err *ValidationError) *Message {
+	return &Message{
+		Type: MessageTypeError,
+		Data: ErrorMessage{
+			Error: err.Message,
+			Code:  CodeInvalidMessage,
+			Kind:  err.Kind,
+		},
+	}
+}
+
+// NewErrorMessage creates an error message with one of the error codes
+func NewErrorMessage(code ErrorCode, err string) *Message {
 	return &Message{
 		Type: MessageTypeError,
 		Data: ErrorMessage{
@@ -152,29 +626,256 @@ func NewPingMessage(timestamp int64) *Message {
 }
 
 // NewGameOverMessage creates a game over message
-func NewGameOverMessage(g *game.Game) *Message {
+func NewGameOverMessage(g *game.Game, clientVersion string) *Message {
+	status := g.GetModeStatus()
+
+	counts := make(map[string]int)
+	pieces := 0
+	for t, n := range g.GetPieceCounts() {
+		counts[t.String()] = n
+		pieces += n
+	}
+
 	return &Message{
 		Type: MessageTypeGameOver,
 		Data: GameOverMessage{
-			Score: g.GetScore(),
-			Level: g.GetLevel(),
-			Lines: g.GetLines(),
+			Score:         g.GetScore(),
+			Level:         g.GetLevel(),
+			Lines:         g.GetLines(),
+			Seed:          g.GetSeed(),
+			Randomizer:    string(g.GetRandomizer()),
+			Ruleset:       string(g.GetRuleset()),
+			ClientVersion: clientVersion,
+			Mode:          string(status.Mode),
+			Completed:     status.Completed,
+			ElapsedMs:     int(status.Elapsed.Milliseconds()),
+			Pieces:        pieces,
+			PieceCounts:   counts,
 		},
 	}
 }
 
-// ParseControlMessage parses a control message from JSON
-func ParseControlMessage(data []byte) (MessageType, error) {
+// NewScoreEventMessage creates a score event message
+func NewScoreEventMessage(event game.ScoreEvent) *Message {
+	return &Message{
+		Type: MessageTypeScoreEvent,
+		Data: ScoreEventMessage{
+			Lines:   event.Lines,
+			Points:  event.Points,
+			Level:   event.Level,
+			LevelUp: event.LevelUp,
+		},
+	}
+}
+
+// NewPerfectClearAttackMessage creates a perfect clear attack message for a
+// clear by the player in the given seat
+func NewPerfectClearAttackMessage(attack game.Attack, seat int, name string) *Message {
+	return &Message{
+		Type: MessageTypePerfectClearAttack,
+		Data: PerfectClearAttackMessage{
+			Lines:   attack.Lines,
+			Garbage: attack.Garbage,
+			Seat:    seat,
+			Name:    name,
+		},
+	}
+}
+
+// NewRestartPendingMessage creates a restart pending message
+func NewRestartPendingMessage(expiresIn time.Duration) *Message {
+	return &Message{
+		Type: MessageTypeRestartPending,
+		Data: RestartPendingMessage{ExpiresInMs: int(expiresIn.Milliseconds())},
+	}
+}
+
+// NewMatchJoinedMessage creates a match joined message
+func NewMatchJoinedMessage(matchID string, seed int64, roster []MatchPlayer) *Message {
+	return &Message{
+		Type: MessageTypeMatchJoined,
+		Data: MatchJoinedMessage{MatchID: matchID, Seed: seed, Players: len(roster), Roster: roster},
+	}
+}
+
+// NewChatMessage creates a chat message relayed by the server
+func NewChatMessage(from, text string, sentAt time.Time) *Message {
+	return &Message{
+		Type: MessageTypeChat,
+		Data: ChatMessage{From: from, Text: text, SentAt: sentAt},
+	}
+}
+
+// NewNoticeMessage creates a notice message
+func NewNoticeMessage(text string, sentAt time.Time) *Message {
+	return &Message{
+		Type: MessageTypeNotice,
+		Data: NoticeMessage{Text: text, SentAt: sentAt},
+	}
+}
+
+// NewOpponentStateMessage creates an opponent state message showing g to the
+// other players of a match
+func NewOpponentStateMessage(seat int, name string, g *game.Game) (*Message, error) {
+	grid, current, _, stateStr, score, level, lines, _ := g.GetStateSnapshot()
+	if current != nil {
+		for row, cells := range current.GetShape() {
+			for col, filled := range cells {
+				y, x := current.Y+row, current.X+col
+				if filled == 1 && y >= 0 && y < len(grid) && x >= 0 && x < len(grid[y]) {
+					grid[y][x] = string(current.Color)
+				}
+			}
+		}
+	}
+
+	compact, err := board.FromGrid(grid).MarshalCompact()
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Type: MessageTypeOpponentState,
+		Data: OpponentStateMessage{
+			Seat:           seat,
+			Name:           name,
+			State:          stateStr,
+			Score:          score,
+			Level:          level,
+			Lines:          lines,
+			PendingGarbage: g.GetPendingGarbage(),
+			Board:          compact,
+		},
+	}, nil
+}
+
+// NewEmoteMessage creates an emote message relayed by the server
+func NewEmoteMessage(seat int, from, emote string, sentAt time.Time) *Message {
+	return &Message{
+		Type: MessageTypeEmote,
+		Data: EmoteMessage{Seat: seat, From: from, Emote: emote, SentAt: sentAt},
+	}
+}
+
+// NewMatchResultMessage creates a match result message
+func NewMatchResultMessage(matchID string, standings []MatchStanding) *Message {
+	return &Message{
+		Type: MessageTypeMatchResult,
+		Data: MatchResultMessage{MatchID: matchID, Standings: standings},
+	}
+}
+
+// NewServerShutdownMessage creates a server shutdown message
+func NewServerShutdownMessage(grace time.Duration, sessionID string) *Message {
+	return &Message{
+		Type: MessageTypeServerShutdown,
+		Data: ServerShutdownMessage{GraceMs: int(grace.Milliseconds()), SessionID: sessionID},
+	}
+}
+
+// NewYourTurnMessage creates a your turn message
+func NewYourTurnMessage(yourTurn bool, seat int, player string) *Message {
+	return &Message{
+		Type: MessageTypeYourTurn,
+		Data: YourTurnMessage{YourTurn: yourTurn, Seat: seat, Player: player},
+	}
+}
+
+// NewPauseVoteMessage creates a pause vote message
+func NewPauseVoteMessage(vote PauseVoteMessage) *Message {
+	return &Message{
+		Type: MessageTypePauseVote,
+		Data: vote,
+	}
+}
+
+// ParsePieces converts the piece letters of set_next_piece into piece types
+func ParsePieces(letters string) ([]piece.Type, error) {
+	types := make([]piece.Type, 0, len(letters))
+	for _, r := range letters {
+		t, err := piece.ParseType(string(unicode.ToUpper(r)))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// NewSavedGameMessage creates a saved game message
+func NewSavedGameMessage(snapshot []byte) *Message {
+	return &Message{
+		Type: MessageTypeSavedGame,
+		Data: SavedGameMessage{Snapshot: snapshot},
+	}
+}
+
+// NewIdleTimeoutMessage creates an idle timeout message
+func NewIdleTimeoutMessage(idle time.Duration) *Message {
+	return &Message{
+		Type: MessageTypeIdleTimeout,
+		Data: IdleTimeoutMessage{IdleMs: int(idle.Milliseconds())},
+	}
+}
+
+// ParseControlMessage parses a control message from JSON, rejecting unknown
+// fields; errors are *ValidationError. See Validate for checking the fields
+func ParseControlMessage(data []byte) (*ControlMessage, error) {
 	var msg ControlMessage
-	if err := json.Unmarshal(data, &msg); err != nil {
-		return "", fmt.Errorf("invalid message format: %w", err)
+	if err := decodeControlMessage(data, &msg); err != nil {
+		return nil, err
 	}
 
 	if msg.Type == "" {
-		return "", fmt.Errorf("missing message type")
+		return nil, invalid(KindUnknownType, "Missing message type")
+	}
+
+	return &msg, nil
+}
+
+// NormalizeName trims a display name and checks its length and characters
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name is empty")
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", fmt.Errorf("name is longer than %d characters", MaxNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("name contains unprintable characters")
+		}
+	}
+	return name, nil
+}
+
+// NormalizeChat trims a chat message and checks its length; control
+// characters such as newlines are replaced with spaces
+func NormalizeChat(text string) (string, error) {
+	text = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("message is empty")
+	}
+	if utf8.RuneCountInString(text) > MaxChatLength {
+		return "", fmt.Errorf("message is longer than %d characters", MaxChatLength)
 	}
+	return text, nil
+}
 
-	return msg.Type, nil
+// IsValidEmote checks if emote is one of Emotes
+func IsValidEmote(emote string) bool {
+	for _, e := range Emotes {
+		if e == emote {
+			return true
+		}
+	}
+	return false
 }
 
 // Serialize converts a message to JSON bytes
@@ -196,11 +897,24 @@ func DeserializeMessage(data []byte) (*Message, error) {
 	return &msg, nil
 }
 
+// MovesPiece reports whether a control command acts on the falling piece,
+// which only its owner may send in a co-op game
+func (t MessageType) MovesPiece() bool {
+	switch t {
+	case MessageTypeMoveLeft, MessageTypeMoveRight, MessageTypeMoveDown, MessageTypeRotate,
+		MessageTypeRotate180, MessageTypeHardDrop, MessageTypeHold:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsValidControlType checks if a message type is a valid control command
 func IsValidControlType(t MessageType) bool {
 	switch t {
 	case MessageTypeMoveLeft, MessageTypeMoveRight, MessageTypeMoveDown,
-		MessageTypeRotate, MessageTypeHardDrop, MessageTypeTogglePause, MessageTypePause, MessageTypeResume, MessageTypeRestart, MessageTypePong:
+		MessageTypeRotate, MessageTypeRotate180, MessageTypeHardDrop, MessageTypeTogglePause, MessageTypePause, MessageTypeResume, MessageTypeRestart, MessageTypeRestartConfirm, MessageTypeSelectMode, MessageTypeSetName, MessageTypeHold, MessageTypePong, MessageTypeChat, MessageTypeEmote,
+		MessageTypeSaveGame, MessageTypeLoadGame, MessageTypeUndo, MessageTypeSetBoard, MessageTypeSetNextPiece, MessageTypePauseVote:
 		return true
 	default:
 		return false
@@ -0,0 +1,4 @@
+// Package tetrispb holds the gRPC control API generated from tetris.proto
+package tetrispb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tetris.proto
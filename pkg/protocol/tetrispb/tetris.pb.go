@@ -0,0 +1,807 @@
+// gRPC control API, mirroring the JSON messages of pkg/protocol so services
+// and non-browser clients can play without the WebSocket protocol
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: tetris.proto
+
+package tetrispb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Direction of a move, matching the move_left, move_right and move_down commands
+type Direction int32
+
+const (
+	Direction_DIRECTION_UNSPECIFIED Direction = 0
+	Direction_DIRECTION_LEFT        Direction = 1
+	Direction_DIRECTION_RIGHT       Direction = 2
+	Direction_DIRECTION_DOWN        Direction = 3
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DIRECTION_UNSPECIFIED",
+		1: "DIRECTION_LEFT",
+		2: "DIRECTION_RIGHT",
+		3: "DIRECTION_DOWN",
+	}
+	Direction_value = map[string]int32{
+		"DIRECTION_UNSPECIFIED": 0,
+		"DIRECTION_LEFT":        1,
+		"DIRECTION_RIGHT":       2,
+		"DIRECTION_DOWN":        3,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_tetris_proto_enumTypes[0].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_tetris_proto_enumTypes[0]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{0}
+}
+
+type NewGameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`  // Game mode, as in select_mode (empty = marathon)
+	Seed          int64                  `protobuf:"varint,2,opt,name=seed,proto3" json:"seed,omitempty"` // Piece generator seed (0 = time-based)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NewGameRequest) Reset() {
+	*x = NewGameRequest{}
+	mi := &file_tetris_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewGameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewGameRequest) ProtoMessage() {}
+
+func (x *NewGameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewGameRequest.ProtoReflect.Descriptor instead.
+func (*NewGameRequest) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NewGameRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *NewGameRequest) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+type NewGameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	State         *State                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NewGameResponse) Reset() {
+	*x = NewGameResponse{}
+	mi := &file_tetris_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewGameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewGameResponse) ProtoMessage() {}
+
+func (x *NewGameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewGameResponse.ProtoReflect.Descriptor instead.
+func (*NewGameResponse) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NewGameResponse) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *NewGameResponse) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type MoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Direction     Direction              `protobuf:"varint,2,opt,name=direction,proto3,enum=tetris.v1.Direction" json:"direction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveRequest) Reset() {
+	*x = MoveRequest{}
+	mi := &file_tetris_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveRequest) ProtoMessage() {}
+
+func (x *MoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveRequest.ProtoReflect.Descriptor instead.
+func (*MoveRequest) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MoveRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+func (x *MoveRequest) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_UNSPECIFIED
+}
+
+type RotateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateRequest) Reset() {
+	*x = RotateRequest{}
+	mi := &file_tetris_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateRequest) ProtoMessage() {}
+
+func (x *RotateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateRequest.ProtoReflect.Descriptor instead.
+func (*RotateRequest) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RotateRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+type DropRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropRequest) Reset() {
+	*x = DropRequest{}
+	mi := &file_tetris_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropRequest) ProtoMessage() {}
+
+func (x *DropRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropRequest.ProtoReflect.Descriptor instead.
+func (*DropRequest) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DropRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+type StreamStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GameId        string                 `protobuf:"bytes,1,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamStateRequest) Reset() {
+	*x = StreamStateRequest{}
+	mi := &file_tetris_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStateRequest) ProtoMessage() {}
+
+func (x *StreamStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStateRequest.ProtoReflect.Descriptor instead.
+func (*StreamStateRequest) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamStateRequest) GetGameId() string {
+	if x != nil {
+		return x.GameId
+	}
+	return ""
+}
+
+// Piece mirrors protocol.PieceData
+type Piece struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          int32                  `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`  // piece.Type: 0-6 for I, O, T, S, Z, J, L
+	Color         string                 `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"` // Hex color, e.g. "#00FFFF"
+	X             int32                  `protobuf:"varint,3,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,4,opt,name=y,proto3" json:"y,omitempty"`
+	Rotation      int32                  `protobuf:"varint,5,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Piece) Reset() {
+	*x = Piece{}
+	mi := &file_tetris_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Piece) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Piece) ProtoMessage() {}
+
+func (x *Piece) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Piece.ProtoReflect.Descriptor instead.
+func (*Piece) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Piece) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Piece) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Piece) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Piece) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Piece) GetRotation() int32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+// Row is one board row; empty cells are ""
+type Row struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cells         []string               `protobuf:"bytes,1,rep,name=cells,proto3" json:"cells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	mi := &file_tetris_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Row.ProtoReflect.Descriptor instead.
+func (*Row) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Row) GetCells() []string {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+// State mirrors protocol.StateMessage
+type State struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Width           int32                  `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height          int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Seed            int64                  `protobuf:"varint,3,opt,name=seed,proto3" json:"seed,omitempty"`
+	Board           []*Row                 `protobuf:"bytes,4,rep,name=board,proto3" json:"board,omitempty"`
+	CurrentPiece    *Piece                 `protobuf:"bytes,5,opt,name=current_piece,json=currentPiece,proto3" json:"current_piece,omitempty"`
+	NextPiece       *Piece                 `protobuf:"bytes,6,opt,name=next_piece,json=nextPiece,proto3" json:"next_piece,omitempty"`
+	HoldPiece       *Piece                 `protobuf:"bytes,7,opt,name=hold_piece,json=holdPiece,proto3" json:"hold_piece,omitempty"` // Unset until the first hold
+	CanHold         bool                   `protobuf:"varint,8,opt,name=can_hold,json=canHold,proto3" json:"can_hold,omitempty"`
+	State           string                 `protobuf:"bytes,9,opt,name=state,proto3" json:"state,omitempty"` // "playing", "paused" or "game_over"
+	Score           int32                  `protobuf:"varint,10,opt,name=score,proto3" json:"score,omitempty"`
+	Level           int32                  `protobuf:"varint,11,opt,name=level,proto3" json:"level,omitempty"`
+	Lines           int32                  `protobuf:"varint,12,opt,name=lines,proto3" json:"lines,omitempty"`
+	DropIntervalMs  int32                  `protobuf:"varint,13,opt,name=drop_interval_ms,json=dropIntervalMs,proto3" json:"drop_interval_ms,omitempty"`
+	ElapsedMs       int64                  `protobuf:"varint,14,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`
+	PausedMs        int64                  `protobuf:"varint,15,opt,name=paused_ms,json=pausedMs,proto3" json:"paused_ms,omitempty"`
+	Mode            string                 `protobuf:"bytes,16,opt,name=mode,proto3" json:"mode,omitempty"`
+	LinesRemaining  int32                  `protobuf:"varint,17,opt,name=lines_remaining,json=linesRemaining,proto3" json:"lines_remaining,omitempty"`
+	TimeRemainingMs int64                  `protobuf:"varint,18,opt,name=time_remaining_ms,json=timeRemainingMs,proto3" json:"time_remaining_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_tetris_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_tetris_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_tetris_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *State) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *State) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *State) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *State) GetBoard() []*Row {
+	if x != nil {
+		return x.Board
+	}
+	return nil
+}
+
+func (x *State) GetCurrentPiece() *Piece {
+	if x != nil {
+		return x.CurrentPiece
+	}
+	return nil
+}
+
+func (x *State) GetNextPiece() *Piece {
+	if x != nil {
+		return x.NextPiece
+	}
+	return nil
+}
+
+func (x *State) GetHoldPiece() *Piece {
+	if x != nil {
+		return x.HoldPiece
+	}
+	return nil
+}
+
+func (x *State) GetCanHold() bool {
+	if x != nil {
+		return x.CanHold
+	}
+	return false
+}
+
+func (x *State) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *State) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *State) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *State) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *State) GetDropIntervalMs() int32 {
+	if x != nil {
+		return x.DropIntervalMs
+	}
+	return 0
+}
+
+func (x *State) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
+func (x *State) GetPausedMs() int64 {
+	if x != nil {
+		return x.PausedMs
+	}
+	return 0
+}
+
+func (x *State) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *State) GetLinesRemaining() int32 {
+	if x != nil {
+		return x.LinesRemaining
+	}
+	return 0
+}
+
+func (x *State) GetTimeRemainingMs() int64 {
+	if x != nil {
+		return x.TimeRemainingMs
+	}
+	return 0
+}
+
+var File_tetris_proto protoreflect.FileDescriptor
+
+const file_tetris_proto_rawDesc = "" +
+	"\n" +
+	"\ftetris.proto\x12\ttetris.v1\"8\n" +
+	"\x0eNewGameRequest\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\x03R\x04seed\"R\n" +
+	"\x0fNewGameResponse\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x12&\n" +
+	"\x05state\x18\x02 \x01(\v2\x10.tetris.v1.StateR\x05state\"Z\n" +
+	"\vMoveRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\x122\n" +
+	"\tdirection\x18\x02 \x01(\x0e2\x14.tetris.v1.DirectionR\tdirection\"(\n" +
+	"\rRotateRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\"&\n" +
+	"\vDropRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\"-\n" +
+	"\x12StreamStateRequest\x12\x17\n" +
+	"\agame_id\x18\x01 \x01(\tR\x06gameId\"i\n" +
+	"\x05Piece\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\x05R\x04type\x12\x14\n" +
+	"\x05color\x18\x02 \x01(\tR\x05color\x12\f\n" +
+	"\x01x\x18\x03 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x04 \x01(\x05R\x01y\x12\x1a\n" +
+	"\brotation\x18\x05 \x01(\x05R\brotation\"\x1b\n" +
+	"\x03Row\x12\x14\n" +
+	"\x05cells\x18\x01 \x03(\tR\x05cells\"\xca\x04\n" +
+	"\x05State\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\x12\x12\n" +
+	"\x04seed\x18\x03 \x01(\x03R\x04seed\x12$\n" +
+	"\x05board\x18\x04 \x03(\v2\x0e.tetris.v1.RowR\x05board\x125\n" +
+	"\rcurrent_piece\x18\x05 \x01(\v2\x10.tetris.v1.PieceR\fcurrentPiece\x12/\n" +
+	"\n" +
+	"next_piece\x18\x06 \x01(\v2\x10.tetris.v1.PieceR\tnextPiece\x12/\n" +
+	"\n" +
+	"hold_piece\x18\a \x01(\v2\x10.tetris.v1.PieceR\tholdPiece\x12\x19\n" +
+	"\bcan_hold\x18\b \x01(\bR\acanHold\x12\x14\n" +
+	"\x05state\x18\t \x01(\tR\x05state\x12\x14\n" +
+	"\x05score\x18\n" +
+	" \x01(\x05R\x05score\x12\x14\n" +
+	"\x05level\x18\v \x01(\x05R\x05level\x12\x14\n" +
+	"\x05lines\x18\f \x01(\x05R\x05lines\x12(\n" +
+	"\x10drop_interval_ms\x18\r \x01(\x05R\x0edropIntervalMs\x12\x1d\n" +
+	"\n" +
+	"elapsed_ms\x18\x0e \x01(\x03R\telapsedMs\x12\x1b\n" +
+	"\tpaused_ms\x18\x0f \x01(\x03R\bpausedMs\x12\x12\n" +
+	"\x04mode\x18\x10 \x01(\tR\x04mode\x12'\n" +
+	"\x0flines_remaining\x18\x11 \x01(\x05R\x0elinesRemaining\x12*\n" +
+	"\x11time_remaining_ms\x18\x12 \x01(\x03R\x0ftimeRemainingMs*c\n" +
+	"\tDirection\x12\x19\n" +
+	"\x15DIRECTION_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eDIRECTION_LEFT\x10\x01\x12\x13\n" +
+	"\x0fDIRECTION_RIGHT\x10\x02\x12\x12\n" +
+	"\x0eDIRECTION_DOWN\x10\x032\xa6\x02\n" +
+	"\x06Tetris\x12@\n" +
+	"\aNewGame\x12\x19.tetris.v1.NewGameRequest\x1a\x1a.tetris.v1.NewGameResponse\x120\n" +
+	"\x04Move\x12\x16.tetris.v1.MoveRequest\x1a\x10.tetris.v1.State\x124\n" +
+	"\x06Rotate\x12\x18.tetris.v1.RotateRequest\x1a\x10.tetris.v1.State\x120\n" +
+	"\x04Drop\x12\x16.tetris.v1.DropRequest\x1a\x10.tetris.v1.State\x12@\n" +
+	"\vStreamState\x12\x1d.tetris.v1.StreamStateRequest\x1a\x10.tetris.v1.State0\x01B2Z0github.com/ican2002/tetris/pkg/protocol/tetrispbb\x06proto3"
+
+var (
+	file_tetris_proto_rawDescOnce sync.Once
+	file_tetris_proto_rawDescData []byte
+)
+
+func file_tetris_proto_rawDescGZIP() []byte {
+	file_tetris_proto_rawDescOnce.Do(func() {
+		file_tetris_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_tetris_proto_rawDesc), len(file_tetris_proto_rawDesc)))
+	})
+	return file_tetris_proto_rawDescData
+}
+
+var file_tetris_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_tetris_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_tetris_proto_goTypes = []any{
+	(Direction)(0),             // 0: tetris.v1.Direction
+	(*NewGameRequest)(nil),     // 1: tetris.v1.NewGameRequest
+	(*NewGameResponse)(nil),    // 2: tetris.v1.NewGameResponse
+	(*MoveRequest)(nil),        // 3: tetris.v1.MoveRequest
+	(*RotateRequest)(nil),      // 4: tetris.v1.RotateRequest
+	(*DropRequest)(nil),        // 5: tetris.v1.DropRequest
+	(*StreamStateRequest)(nil), // 6: tetris.v1.StreamStateRequest
+	(*Piece)(nil),              // 7: tetris.v1.Piece
+	(*Row)(nil),                // 8: tetris.v1.Row
+	(*State)(nil),              // 9: tetris.v1.State
+}
+var file_tetris_proto_depIdxs = []int32{
+	9,  // 0: tetris.v1.NewGameResponse.state:type_name -> tetris.v1.State
+	0,  // 1: tetris.v1.MoveRequest.direction:type_name -> tetris.v1.Direction
+	8,  // 2: tetris.v1.State.board:type_name -> tetris.v1.Row
+	7,  // 3: tetris.v1.State.current_piece:type_name -> tetris.v1.Piece
+	7,  // 4: tetris.v1.State.next_piece:type_name -> tetris.v1.Piece
+	7,  // 5: tetris.v1.State.hold_piece:type_name -> tetris.v1.Piece
+	1,  // 6: tetris.v1.Tetris.NewGame:input_type -> tetris.v1.NewGameRequest
+	3,  // 7: tetris.v1.Tetris.Move:input_type -> tetris.v1.MoveRequest
+	4,  // 8: tetris.v1.Tetris.Rotate:input_type -> tetris.v1.RotateRequest
+	5,  // 9: tetris.v1.Tetris.Drop:input_type -> tetris.v1.DropRequest
+	6,  // 10: tetris.v1.Tetris.StreamState:input_type -> tetris.v1.StreamStateRequest
+	2,  // 11: tetris.v1.Tetris.NewGame:output_type -> tetris.v1.NewGameResponse
+	9,  // 12: tetris.v1.Tetris.Move:output_type -> tetris.v1.State
+	9,  // 13: tetris.v1.Tetris.Rotate:output_type -> tetris.v1.State
+	9,  // 14: tetris.v1.Tetris.Drop:output_type -> tetris.v1.State
+	9,  // 15: tetris.v1.Tetris.StreamState:output_type -> tetris.v1.State
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_tetris_proto_init() }
+func file_tetris_proto_init() {
+	if File_tetris_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tetris_proto_rawDesc), len(file_tetris_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tetris_proto_goTypes,
+		DependencyIndexes: file_tetris_proto_depIdxs,
+		EnumInfos:         file_tetris_proto_enumTypes,
+		MessageInfos:      file_tetris_proto_msgTypes,
+	}.Build()
+	File_tetris_proto = out.File
+	file_tetris_proto_goTypes = nil
+	file_tetris_proto_depIdxs = nil
+}
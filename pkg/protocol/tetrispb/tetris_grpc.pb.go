@@ -0,0 +1,294 @@
+// gRPC control API, mirroring the JSON messages of pkg/protocol so services
+// and non-browser clients can play without the WebSocket protocol
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: tetris.proto
+
+package tetrispb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Tetris_NewGame_FullMethodName     = "/tetris.v1.Tetris/NewGame"
+	Tetris_Move_FullMethodName        = "/tetris.v1.Tetris/Move"
+	Tetris_Rotate_FullMethodName      = "/tetris.v1.Tetris/Rotate"
+	Tetris_Drop_FullMethodName        = "/tetris.v1.Tetris/Drop"
+	Tetris_StreamState_FullMethodName = "/tetris.v1.Tetris/StreamState"
+)
+
+// TetrisClient is the client API for Tetris service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Tetris controls games hosted by the server
+type TetrisClient interface {
+	// NewGame starts a game and returns its id with the initial state
+	NewGame(ctx context.Context, in *NewGameRequest, opts ...grpc.CallOption) (*NewGameResponse, error)
+	// Move shifts the current piece one cell
+	Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*State, error)
+	// Rotate turns the current piece clockwise
+	Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*State, error)
+	// Drop hard-drops the current piece
+	Drop(ctx context.Context, in *DropRequest, opts ...grpc.CallOption) (*State, error)
+	// StreamState sends the state now and after every change until the game ends
+	StreamState(ctx context.Context, in *StreamStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[State], error)
+}
+
+type tetrisClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTetrisClient(cc grpc.ClientConnInterface) TetrisClient {
+	return &tetrisClient{cc}
+}
+
+func (c *tetrisClient) NewGame(ctx context.Context, in *NewGameRequest, opts ...grpc.CallOption) (*NewGameResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NewGameResponse)
+	err := c.cc.Invoke(ctx, Tetris_NewGame_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tetrisClient) Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Tetris_Move_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tetrisClient) Rotate(ctx context.Context, in *RotateRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Tetris_Rotate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tetrisClient) Drop(ctx context.Context, in *DropRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Tetris_Drop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tetrisClient) StreamState(ctx context.Context, in *StreamStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[State], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Tetris_ServiceDesc.Streams[0], Tetris_StreamState_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamStateRequest, State]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tetris_StreamStateClient = grpc.ServerStreamingClient[State]
+
+// TetrisServer is the server API for Tetris service.
+// All implementations must embed UnimplementedTetrisServer
+// for forward compatibility.
+//
+// Tetris controls games hosted by the server
+type TetrisServer interface {
+	// NewGame starts a game and returns its id with the initial state
+	NewGame(context.Context, *NewGameRequest) (*NewGameResponse, error)
+	// Move shifts the current piece one cell
+	Move(context.Context, *MoveRequest) (*State, error)
+	// Rotate turns the current piece clockwise
+	Rotate(context.Context, *RotateRequest) (*State, error)
+	// Drop hard-drops the current piece
+	Drop(context.Context, *DropRequest) (*State, error)
+	// StreamState sends the state now and after every change until the game ends
+	StreamState(*StreamStateRequest, grpc.ServerStreamingServer[State]) error
+	mustEmbedUnimplementedTetrisServer()
+}
+
+// UnimplementedTetrisServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTetrisServer struct{}
+
+func (UnimplementedTetrisServer) NewGame(context.Context, *NewGameRequest) (*NewGameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewGame not implemented")
+}
+func (UnimplementedTetrisServer) Move(context.Context, *MoveRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+}
+func (UnimplementedTetrisServer) Rotate(context.Context, *RotateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rotate not implemented")
+}
+func (UnimplementedTetrisServer) Drop(context.Context, *DropRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drop not implemented")
+}
+func (UnimplementedTetrisServer) StreamState(*StreamStateRequest, grpc.ServerStreamingServer[State]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamState not implemented")
+}
+func (UnimplementedTetrisServer) mustEmbedUnimplementedTetrisServer() {}
+func (UnimplementedTetrisServer) testEmbeddedByValue()                {}
+
+// UnsafeTetrisServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TetrisServer will
+// result in compilation errors.
+type UnsafeTetrisServer interface {
+	mustEmbedUnimplementedTetrisServer()
+}
+
+func RegisterTetrisServer(s grpc.ServiceRegistrar, srv TetrisServer) {
+	// If the following call pancis, it indicates UnimplementedTetrisServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Tetris_ServiceDesc, srv)
+}
+
+func _Tetris_NewGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewGameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TetrisServer).NewGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tetris_NewGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TetrisServer).NewGame(ctx, req.(*NewGameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tetris_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TetrisServer).Move(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tetris_Move_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TetrisServer).Move(ctx, req.(*MoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tetris_Rotate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TetrisServer).Rotate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tetris_Rotate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TetrisServer).Rotate(ctx, req.(*RotateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tetris_Drop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DropRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TetrisServer).Drop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Tetris_Drop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TetrisServer).Drop(ctx, req.(*DropRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tetris_StreamState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TetrisServer).StreamState(m, &grpc.GenericServerStream[StreamStateRequest, State]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Tetris_StreamStateServer = grpc.ServerStreamingServer[State]
+
+// Tetris_ServiceDesc is the grpc.ServiceDesc for Tetris service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tetris_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tetris.v1.Tetris",
+	HandlerType: (*TetrisServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewGame",
+			Handler:    _Tetris_NewGame_Handler,
+		},
+		{
+			MethodName: "Move",
+			Handler:    _Tetris_Move_Handler,
+		},
+		{
+			MethodName: "Rotate",
+			Handler:    _Tetris_Rotate_Handler,
+		},
+		{
+			MethodName: "Drop",
+			Handler:    _Tetris_Drop_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamState",
+			Handler:       _Tetris_StreamState_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "tetris.proto",
+}
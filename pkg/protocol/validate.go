@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxMessageSize is the largest control message the server reads, in bytes;
+// a saved game for load_game is the largest a client sends
+const MaxMessageSize = 64 << 10
+
+// MaxIDLength is the longest correlation ID a control message can carry
+const MaxIDLength = 64
+
+// ErrorKind tells clients how a control message rejected with
+// invalid_message was invalid
+type ErrorKind string
+
+const (
+	KindInvalidJSON    ErrorKind = "invalid_json"    // Not a JSON object
+	KindUnknownField   ErrorKind = "unknown_field"   // A field no control message has
+	KindUnknownType    ErrorKind = "unknown_type"    // Type is missing or not a control command
+	KindInvalidPayload ErrorKind = "invalid_payload" // A field is missing, of the wrong type or out of range
+)
+
+// ValidationError is why a control message was rejected
+type ValidationError struct {
+	Kind    ErrorKind
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid returns a ValidationError of the given kind
+func invalid(kind ErrorKind, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// decodeControlMessage decodes a control message, rejecting unknown fields
+func decodeControlMessage(data []byte, msg *ControlMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(msg)
+	if err == nil && dec.More() {
+		err = errors.New("data after the message")
+	}
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return invalid(KindInvalidPayload, "Invalid message format: %s must be a %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		return invalid(KindUnknownField, "Unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return invalid(KindInvalidJSON, "Invalid message format")
+	}
+}
+
+// Validate checks that the message is a known control command carrying the
+// fields its type needs, returning a *ValidationError if it is not
+func (m *ControlMessage) Validate() error {
+	if !IsValidControlType(m.Type) {
+		return invalid(KindUnknownType, "Unknown message type: %s", m.Type)
+	}
+	if len(m.ID) > MaxIDLength {
+		return invalid(KindInvalidPayload, "id is longer than %d characters", MaxIDLength)
+	}
+	if m.BoardIndex < 0 || m.BoardIndex >= MaxBoards {
+		return invalid(KindInvalidPayload, "board_index must be between 0 and %d", MaxBoards-1)
+	}
+
+	switch m.Type {
+	case MessageTypeSelectMode:
+		if m.Mode == "" {
+			return invalid(KindInvalidPayload, "select_mode needs a mode")
+		}
+		if m.DigRows < 0 {
+			return invalid(KindInvalidPayload, "dig_rows must not be negative")
+		}
+	case MessageTypeSetName:
+		if m.Name == "" {
+			return invalid(KindInvalidPayload, "set_name needs a name")
+		}
+	case MessageTypeChat:
+		if m.Text == "" {
+			return invalid(KindInvalidPayload, "chat needs a text")
+		}
+	case MessageTypeEmote:
+		if m.Emote == "" {
+			return invalid(KindInvalidPayload, "emote needs an emote")
+		}
+	case MessageTypeLoadGame:
+		if len(m.Snapshot) == 0 {
+			return invalid(KindInvalidPayload, "load_game needs a snapshot")
+		}
+	case MessageTypeSetBoard:
+		if len(m.Rows) == 0 {
+			return invalid(KindInvalidPayload, "set_board needs rows")
+		}
+	case MessageTypeSetNextPiece:
+		if m.Pieces == "" {
+			return invalid(KindInvalidPayload, "set_next_piece needs pieces")
+		}
+	}
+	return nil
+}
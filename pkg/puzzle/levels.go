@@ -0,0 +1,47 @@
+package puzzle
+
+// Builtin are the levels every server offers, easiest first
+var Builtin = []Level{
+	{
+		Name: "first-steps",
+		Rows: []string{
+			"XXXX..XXXX",
+			"XXXX..XXXX",
+		},
+		Pieces:    "O",
+		Objective: ObjectiveLines,
+		Lines:     2,
+	},
+	{
+		Name: "zigzag",
+		Rows: []string{
+			"XXX..XXXXX",
+			"XXXX..XXXX",
+		},
+		Pieces:    "SZ",
+		Objective: ObjectiveGarbage,
+	},
+	{
+		Name: "pillars",
+		Rows: []string{
+			"W.XXXXXXXW",
+			"W.XXXXXXXW",
+			"W.XXXXXXXW",
+			"W.XXXXXXXW",
+		},
+		Pieces:    "I",
+		Objective: ObjectivePerfectClear,
+	},
+	{
+		Name: "walled-in",
+		Rows: []string{
+			"...WW.....",
+			"XX.WWXXX.X",
+			"XX.WWXXX.X",
+			"XX.WWXXX.X",
+		},
+		Pieces:    "II",
+		Objective: ObjectiveLines,
+		Lines:     3,
+	},
+}
@@ -0,0 +1,168 @@
+// Package puzzle describes puzzle levels: a preset board, often with
+// obstacles, the pieces to play it with and the objective to reach
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// Objective is what a puzzle level asks the player to do
+type Objective string
+
+const (
+	ObjectiveLines        Objective = "lines"         // Clear Lines lines
+	ObjectiveGarbage      Objective = "garbage"       // Clear every garbage cell (X) of the board
+	ObjectivePerfectClear Objective = "perfect_clear" // Leave nothing on the board but obstacles
+)
+
+// freeRows is how many rows at the top of the board a level must leave
+// empty, so the pieces have room to spawn
+const freeRows = 4
+
+// ErrNoLevels is returned by Parse for a file without levels
+var ErrNoLevels = errors.New("puzzle: no levels")
+
+// Level is a puzzle: the board it starts on, the pieces dealt in order and
+// the objective; the level fails once all the pieces are placed without
+// reaching it
+type Level struct {
+	Name      string    `json:"name"`
+	Rows      []string  `json:"rows"`   // Board rows such as "WXXX.XXXXX", bottom row last, as in board.Builder.Rows; W marks obstacles
+	Pieces    string    `json:"pieces"` // Piece letters dealt in order, e.g. "TSZ"
+	Objective Objective `json:"objective"`
+	Lines     int       `json:"lines,omitempty"` // Lines to clear for ObjectiveLines
+}
+
+// IsValid returns true if o is a known objective
+func (o Objective) IsValid() bool {
+	switch o {
+	case ObjectiveLines, ObjectiveGarbage, ObjectivePerfectClear:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks that the level can be played on a board of the default size
+func (l *Level) Validate() error {
+	if l.Name == "" {
+		return errors.New("puzzle: level without a name")
+	}
+	if !l.Objective.IsValid() {
+		return fmt.Errorf("puzzle: level %q: unknown objective %q", l.Name, l.Objective)
+	}
+	if l.Objective == ObjectiveLines && l.Lines <= 0 {
+		return fmt.Errorf("puzzle: level %q: no lines to clear", l.Name)
+	}
+	if _, err := l.Sequence(); err != nil {
+		return fmt.Errorf("puzzle: level %q: %w", l.Name, err)
+	}
+	if len(l.Rows) > board.DefaultHeight-freeRows {
+		return fmt.Errorf("puzzle: level %q: at most %d rows, leaving room to spawn", l.Name, board.DefaultHeight-freeRows)
+	}
+	if _, err := l.Board(board.DefaultWidth, board.DefaultHeight); err != nil {
+		return fmt.Errorf("puzzle: level %q: %w", l.Name, err)
+	}
+	return nil
+}
+
+// Sequence returns the pieces of the level in the order they are dealt
+func (l *Level) Sequence() ([]piece.Type, error) {
+	if l.Pieces == "" {
+		return nil, errors.New("no pieces")
+	}
+	types := make([]piece.Type, 0, len(l.Pieces))
+	for _, r := range l.Pieces {
+		t, err := piece.ParseType(string(r))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// Board returns the board the level starts on, width x height cells
+func (l *Level) Board(width, height int) (*board.Board, error) {
+	return board.NewSizedBuilder(width, height).Rows(l.Rows...).Build()
+}
+
+// Done returns true if the objective is reached on b after clearing lines
+func (l *Level) Done(b *board.Board, lines int) bool {
+	switch l.Objective {
+	case ObjectiveLines:
+		return lines >= l.Lines
+	case ObjectiveGarbage:
+		return b.GarbageRows() == 0
+	case ObjectivePerfectClear:
+		return b.IsCleared()
+	default:
+		return false
+	}
+}
+
+// Describe returns the objective as a short phrase for players
+func (l *Level) Describe() string {
+	switch l.Objective {
+	case ObjectiveLines:
+		return fmt.Sprintf("Clear %d lines", l.Lines)
+	case ObjectiveGarbage:
+		return "Clear garbage"
+	case ObjectivePerfectClear:
+		return "Clear the board"
+	default:
+		return string(l.Objective)
+	}
+}
+
+// Find returns the level named name
+func Find(levels []Level, name string) (*Level, bool) {
+	for i := range levels {
+		if levels[i].Name == name {
+			return &levels[i], true
+		}
+	}
+	return nil, false
+}
+
+// Parse reads a JSON array of levels and validates them
+func Parse(data []byte) ([]Level, error) {
+	var levels []Level
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return nil, fmt.Errorf("puzzle: %w", err)
+	}
+	if len(levels) == 0 {
+		return nil, ErrNoLevels
+	}
+
+	names := make(map[string]bool, len(levels))
+	for i := range levels {
+		if err := levels[i].Validate(); err != nil {
+			return nil, err
+		}
+		if names[levels[i].Name] {
+			return nil, fmt.Errorf("puzzle: duplicate level %q", levels[i].Name)
+		}
+		names[levels[i].Name] = true
+	}
+	return levels, nil
+}
+
+// Load reads the levels of a JSON file written for Parse
+func Load(path string) ([]Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return levels, nil
+}
@@ -0,0 +1,95 @@
+package puzzle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestBuiltin verifies every built-in level is valid and named uniquely
+func TestBuiltin(t *testing.T) {
+	names := map[string]bool{}
+	for i := range Builtin {
+		level := &Builtin[i]
+		if err := level.Validate(); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+		if names[level.Name] {
+			t.Errorf("duplicate level %q", level.Name)
+		}
+		names[level.Name] = true
+	}
+
+	if level, ok := Find(Builtin, "pillars"); !ok || level.Objective != ObjectivePerfectClear {
+		t.Errorf("Find(pillars) = %v, %v", level, ok)
+	}
+	if _, ok := Find(Builtin, "missing"); ok {
+		t.Error("Find(missing) ok = true, want false")
+	}
+}
+
+// TestParse verifies levels are validated as they are read
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `[{"name": "a", "rows": ["XXXX..XXXX"], "pieces": "O", "objective": "garbage"}]`, false},
+		{"not json", `{`, true},
+		{"no levels", `[]`, true},
+		{"no name", `[{"rows": [], "pieces": "O", "objective": "garbage"}]`, true},
+		{"unknown objective", `[{"name": "a", "pieces": "O", "objective": "win"}]`, true},
+		{"no lines", `[{"name": "a", "pieces": "O", "objective": "lines"}]`, true},
+		{"no pieces", `[{"name": "a", "objective": "perfect_clear"}]`, true},
+		{"bad piece", `[{"name": "a", "pieces": "OQ", "objective": "perfect_clear"}]`, true},
+		{"full row", `[{"name": "a", "rows": ["XXXXXXXXXX"], "pieces": "O", "objective": "garbage"}]`, true},
+		{"duplicate", `[{"name": "a", "pieces": "O", "objective": "garbage"}, {"name": "a", "pieces": "I", "objective": "garbage"}]`, true},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if _, err := Parse([]byte(`[]`)); !errors.Is(err, ErrNoLevels) {
+		t.Errorf("Parse([]) error = %v, want ErrNoLevels", err)
+	}
+}
+
+// TestDone verifies each objective is reached on the board and lines it describes
+func TestDone(t *testing.T) {
+	level, _ := Find(Builtin, "pillars")
+	b, err := level.Board(board.DefaultWidth, board.DefaultHeight)
+	if err != nil {
+		t.Fatalf("Board() error = %v", err)
+	}
+	if level.Done(b, 0) {
+		t.Error("Done() = true on the starting board")
+	}
+
+	// The I piece down the gap clears the four rows, leaving only the obstacles
+	i := piece.New(piece.TypeI)
+	i.Rotation, i.X, i.Y = 1, 1, board.DefaultHeight-4
+	if b.CheckCollision(i.X, i.Y, i.GetShape()) {
+		t.Fatalf("I piece does not fit the gap")
+	}
+	b.LockPiece(i)
+	if got := b.ClearLines().Count(); got != 4 {
+		t.Fatalf("ClearLines() = %d, want 4", got)
+	}
+	if !level.Done(b, 4) {
+		t.Error("Done() = false with only obstacles left")
+	}
+	if got := b.IsOccupied(0, board.DefaultHeight-1); !got {
+		t.Error("obstacle was cleared with its row")
+	}
+
+	lines := &Level{Objective: ObjectiveLines, Lines: 3}
+	if lines.Done(b, 2) || !lines.Done(b, 3) {
+		t.Error("Done() does not compare the lines cleared with Lines")
+	}
+}
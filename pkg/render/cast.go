@@ -0,0 +1,113 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// CastOptions configures WriteCast
+type CastOptions struct {
+	Title    string        // Shown by asciinema players
+	MaxDelay time.Duration // Longest gap between frames, so pauses do not stall playback (0 = 2s)
+}
+
+// castHeader is the first line of an asciinema v2 cast file
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// WriteCast renders frames as an asciinema v2 cast, timed like the recorded game
+func WriteCast(w io.Writer, frames []Frame, opts CastOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to render")
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 2 * time.Second
+	}
+
+	first := frames[0].State
+	header, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     first.Width*2 + 24,
+		Height:    first.Height + 2,
+		Timestamp: time.Now().Unix(),
+		Title:     opts.Title,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", header); err != nil {
+		return err
+	}
+
+	var at time.Duration
+	for i, frame := range frames {
+		if i > 0 {
+			gap := frame.At - frames[i-1].At
+			if gap > opts.MaxDelay {
+				gap = opts.MaxDelay
+			}
+			at += gap
+		}
+
+		event, err := json.Marshal([]interface{}{at.Seconds(), "o", drawANSI(frame)})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawANSI draws a frame as terminal output: the board in 24-bit color with
+// the score beside it
+func drawANSI(frame Frame) string {
+	state := frame.State
+	grid := cells(state)
+	if len(grid) == 0 {
+		return ""
+	}
+
+	info := []string{
+		fmt.Sprintf("Score %d", state.Score),
+		fmt.Sprintf("Level %d", state.Level),
+		fmt.Sprintf("Lines %d", state.Lines),
+	}
+	if state.State != game.StatePlaying.String() {
+		info = append(info, "", strings.ToUpper(state.State))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	border := "+" + strings.Repeat("--", len(grid[0])) + "+"
+	sb.WriteString(border + "\r\n")
+	for y, row := range grid {
+		sb.WriteString("|")
+		for _, c := range row {
+			rgba, err := parseHexColor(c)
+			if c == "" || err != nil {
+				sb.WriteString("  ")
+				continue
+			}
+			fmt.Fprintf(&sb, "\x1b[48;2;%d;%d;%dm  \x1b[0m", rgba.R, rgba.G, rgba.B)
+		}
+		sb.WriteString("|")
+		if y < len(info) {
+			sb.WriteString("  " + info[y])
+		}
+		sb.WriteString("\r\n")
+	}
+	sb.WriteString(border + "\r\n")
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Timeline indexes the states of a recorded game by game time, so a ghost of
+// the game can be raced: At returns where the recorded player was when the
+// same time had passed in the new game
+type Timeline struct {
+	states []*protocol.StateMessage // By ElapsedMs, which pauses do not advance
+}
+
+// NewTimeline indexes the first game of a recording; frames after it ended or
+// was restarted are left out
+func NewTimeline(frames []Frame) *Timeline {
+	tl := &Timeline{}
+	for _, f := range frames {
+		if n := len(tl.states); n > 0 {
+			last := tl.states[n-1]
+			if f.State.ElapsedMs < last.ElapsedMs || last.State == game.StateGameOver.String() {
+				break
+			}
+		}
+		tl.states = append(tl.states, f.State)
+	}
+	return tl
+}
+
+// At returns the recorded state when elapsed game time had passed: the last
+// one received by then, the first if it is earlier than every state and the
+// final state once the recorded game is over
+func (tl *Timeline) At(elapsed time.Duration) *protocol.StateMessage {
+	if len(tl.states) == 0 {
+		return nil
+	}
+	ms := int(elapsed.Milliseconds())
+	i := sort.Search(len(tl.states), func(i int) bool { return tl.states[i].ElapsedMs > ms })
+	if i == 0 {
+		return tl.states[0]
+	}
+	return tl.states[i-1]
+}
+
+// Final returns the last state of the recorded game
+func (tl *Timeline) Final() *protocol.StateMessage {
+	if len(tl.states) == 0 {
+		return nil
+	}
+	return tl.states[len(tl.states)-1]
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"strconv"
+	"time"
+)
+
+// GIFOptions configures WriteGIF
+type GIFOptions struct {
+	CellSize int           // Pixels per board cell (0 = 16)
+	MaxDelay time.Duration // Longest a single frame is shown, so pauses do not stall the animation (0 = 2s)
+}
+
+var (
+	gifBackground = color.RGBA{0x10, 0x10, 0x10, 0xff}
+	gifGrid       = color.RGBA{0x28, 0x28, 0x28, 0xff}
+)
+
+// WriteGIF renders frames as an animated GIF, timed like the recorded game
+func WriteGIF(w io.Writer, frames []Frame, opts GIFOptions) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to render")
+	}
+	if opts.CellSize <= 0 {
+		opts.CellSize = 16
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 2 * time.Second
+	}
+
+	palette := color.Palette{gifBackground, gifGrid}
+	index := make(map[string]uint8)
+	anim := &gif.GIF{}
+
+	for i, frame := range frames {
+		grid := cells(frame.State)
+		height := len(grid)
+		width := 0
+		if height > 0 {
+			width = len(grid[0])
+		}
+
+		for _, row := range grid {
+			for _, c := range row {
+				if c == "" {
+					continue
+				}
+				if _, ok := index[c]; ok {
+					continue
+				}
+				if len(palette) == 256 {
+					return fmt.Errorf("more than %d colors", 256-2)
+				}
+				rgba, err := parseHexColor(c)
+				if err != nil {
+					return err
+				}
+				index[c] = uint8(len(palette))
+				palette = append(palette, rgba)
+			}
+		}
+
+		img := image.NewPaletted(image.Rect(0, 0, width*opts.CellSize, height*opts.CellSize), palette)
+		for y, row := range grid {
+			for x, c := range row {
+				fill := uint8(0)
+				if c != "" {
+					fill = index[c]
+				}
+				drawCell(img, x, y, opts.CellSize, fill)
+			}
+		}
+
+		// Each frame is shown until the next one arrived
+		delay := opts.MaxDelay
+		if i+1 < len(frames) {
+			if d := frames[i+1].At - frame.At; d < delay {
+				delay = d
+			}
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, gifDelay(delay))
+	}
+
+	// Palettes grow as colors appear; give every frame the final one
+	for _, img := range anim.Image {
+		img.Palette = palette
+	}
+
+	return gif.EncodeAll(w, anim)
+}
+
+// drawCell fills one board cell, leaving a one pixel grid line
+func drawCell(img *image.Paletted, x, y, size int, fill uint8) {
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			c := fill
+			if fill == 0 && (px == size-1 || py == size-1) {
+				c = 1
+			}
+			img.SetColorIndex(x*size+px, y*size+py, c)
+		}
+	}
+}
+
+// gifDelay converts a duration to GIF frame delay units of 10ms
+// Browsers show delays under 20ms as 100ms, so shorter frames are rounded up
+func gifDelay(d time.Duration) int {
+	delay := int(d / (10 * time.Millisecond))
+	if delay < 2 {
+		delay = 2
+	}
+	return delay
+}
+
+// parseHexColor parses a "#RRGGBB" color
+func parseHexColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
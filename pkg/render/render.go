@@ -0,0 +1,109 @@
+// Package render turns recorded games into shareable animations
+package render
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Frame is a game state and when it was received, relative to the first frame
+type Frame struct {
+	At    time.Duration
+	State *protocol.StateMessage
+}
+
+// recordedFrame is a Frame as stored in a recording, one JSON object per line
+type recordedFrame struct {
+	AtMs  int64                  `json:"t_ms"`
+	State *protocol.StateMessage `json:"state"`
+}
+
+// Recorder writes game states to a recording that ReadRecording can load
+type Recorder struct {
+	w     io.Writer
+	start time.Time
+}
+
+// NewRecorder creates a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Record appends a state to the recording, timestamped relative to the first one
+func (r *Recorder) Record(state *protocol.StateMessage) error {
+	now := time.Now()
+	if r.start.IsZero() {
+		r.start = now
+	}
+
+	data, err := json.Marshal(recordedFrame{AtMs: now.Sub(r.start).Milliseconds(), State: state})
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadRecording loads the frames written by a Recorder
+func ReadRecording(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec recordedFrame
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.State == nil {
+			return nil, fmt.Errorf("line %d: missing state", line)
+		}
+		if err := rec.State.ExpandBoard(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		frames = append(frames, Frame{At: time.Duration(rec.AtMs) * time.Millisecond, State: rec.State})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("recording has no frames")
+	}
+	return frames, nil
+}
+
+// cells returns the board of a state with the falling piece drawn in
+func cells(state *protocol.StateMessage) [][]string {
+	grid := make([][]string, len(state.Board))
+	for y, row := range state.Board {
+		grid[y] = append([]string(nil), row...)
+	}
+
+	if state.State == game.StateGameOver.String() {
+		return grid
+	}
+
+	cp := state.CurrentPiece
+	shape := (&piece.Piece{Type: cp.Type, Rotation: cp.Rotation}).GetShape()
+	for dy, row := range shape {
+		for dx, filled := range row {
+			x, y := cp.X+dx, cp.Y+dy
+			if filled == 0 || y < 0 || y >= len(grid) || x < 0 || x >= len(grid[y]) {
+				continue
+			}
+			grid[y][x] = string(cp.Color)
+		}
+	}
+	return grid
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"image/gif"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// recordGame plays a short seeded game and returns its recording
+func recordGame(t *testing.T) []Frame {
+	t.Helper()
+
+	g := game.NewWithConfig(game.Config{Seed: 42})
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	for i := 0; i < 5; i++ {
+		state := protocol.NewCompactStateMessage(g, uint64(i+1), 0).Data.(protocol.StateMessage)
+		if err := rec.Record(&state); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		g.HardDrop()
+	}
+
+	frames, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording() error = %v", err)
+	}
+	return frames
+}
+
+// TestWriteGIF verifies that every recorded frame becomes a GIF frame
+func TestWriteGIF(t *testing.T) {
+	frames := recordGame(t)
+	if len(frames) != 5 || frames[0].State.Board == nil {
+		t.Fatalf("ReadRecording() = %d frames, board %v", len(frames), frames[0].State.Board)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, frames, GIFOptions{CellSize: 4}); err != nil {
+		t.Fatalf("WriteGIF() error = %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error = %v", err)
+	}
+	if len(anim.Image) != len(frames) {
+		t.Errorf("GIF has %d frames, want %d", len(anim.Image), len(frames))
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != 40 || b.Dy() != 80 {
+		t.Errorf("GIF frame is %dx%d, want 40x80", b.Dx(), b.Dy())
+	}
+}
+
+// TestWriteCast verifies the asciinema header and one output event per frame
+func TestWriteCast(t *testing.T) {
+	frames := recordGame(t)
+
+	var buf bytes.Buffer
+	if err := WriteCast(&buf, frames, CastOptions{Title: "test"}); err != nil {
+		t.Fatalf("WriteCast() error = %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(nil, 1024*1024)
+	scanner.Scan()
+	var header castHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != 2 {
+		t.Fatalf("header = %s, error %v", scanner.Text(), err)
+	}
+
+	events := 0
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("event %d: %v", events, err)
+		}
+		if event[1] != "o" || !strings.Contains(event[2].(string), "Score") {
+			t.Errorf("event %d = %v", events, event)
+		}
+		events++
+	}
+	if events != len(frames) {
+		t.Errorf("cast has %d events, want %d", events, len(frames))
+	}
+}
+
+// TestTimeline verifies the ghost state at a game time is the last one
+// recorded by then, and that a restart ends the indexed game
+func TestTimeline(t *testing.T) {
+	frame := func(elapsedMs, score int, state string) Frame {
+		return Frame{State: &protocol.StateMessage{ElapsedMs: elapsedMs, Score: score, State: state}}
+	}
+	tl := NewTimeline([]Frame{
+		frame(0, 0, "playing"),
+		frame(1000, 100, "playing"),
+		frame(3000, 400, "playing"),
+		frame(3000, 400, "paused"),
+		frame(5000, 900, "gameover"),
+		frame(0, 0, "playing"), // Restarted
+		frame(6000, 50, "playing"),
+	})
+
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 0},
+		{999 * time.Millisecond, 0},
+		{time.Second, 100},
+		{4 * time.Second, 400},
+		{time.Minute, 900},
+	}
+	for _, tt := range tests {
+		if got := tl.At(tt.elapsed).Score; got != tt.want {
+			t.Errorf("At(%v).Score = %d, want %d", tt.elapsed, got, tt.want)
+		}
+	}
+	if got := tl.Final().Score; got != 900 {
+		t.Errorf("Final().Score = %d, want 900", got)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// upgrader returns the WebSocket upgrader, which only accepts the origins
+// checkOrigin allows
+func (s *Server) upgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkOrigin,
+	}
+}
+
+// checkOrigin allows WebSocket connections from pages served by this server,
+// from AllowedOrigins and from clients that send no Origin header, such as
+// the terminal client; AllowAnyOrigin allows every origin
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if s.AllowAnyOrigin || origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	log.Printf("Rejecting WebSocket from %s: origin %s not allowed", r.RemoteAddr, origin)
+	return false
+}
+
+// clientIP returns the IP address of a request's remote address
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// reserveConn counts a connection from ip, returning false if ip already
+// has MaxConnsPerIP
+func (s *Server) reserveConn(ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.MaxConnsPerIP > 0 && s.connsPerIP[ip] >= s.MaxConnsPerIP {
+		return false
+	}
+	s.connsPerIP[ip]++
+	return true
+}
+
+// releaseConn forgets a connection counted by reserveConn
+func (s *Server) releaseConn(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseConnLocked(ip)
+}
+
+// releaseConnLocked forgets a connection counted by reserveConn
+// Must be called with mu held
+func (s *Server) releaseConnLocked(ip string) {
+	if s.connsPerIP[ip]--; s.connsPerIP[ip] <= 0 {
+		delete(s.connsPerIP, ip)
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCheckOrigin verifies which origins may open a WebSocket
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    string
+		allowed   []string
+		anyOrigin bool
+		want      bool
+	}{
+		{"no origin", "", nil, false, true},
+		{"same origin", "http://tetris.test", nil, false, true},
+		{"other origin", "https://evil.test", nil, false, false},
+		{"allowed origin", "https://play.test", []string{"https://play.test/"}, false, true},
+		{"other port", "https://play.test:8443", []string{"https://play.test"}, false, false},
+		{"any origin", "https://evil.test", nil, true, true},
+	}
+
+	for _, tt := range tests {
+		s := New(":0")
+		s.AllowedOrigins = tt.allowed
+		s.AllowAnyOrigin = tt.anyOrigin
+		r := httptest.NewRequest("GET", "http://tetris.test/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := s.checkOrigin(r); got != tt.want {
+			t.Errorf("%s: checkOrigin() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestMaxConnsPerIP verifies connections are limited per address and that a
+// released connection frees its slot
+func TestMaxConnsPerIP(t *testing.T) {
+	s := New(":0")
+	s.MaxConnsPerIP = 2
+
+	if got := clientIP("192.0.2.1:5000"); got != "192.0.2.1" {
+		t.Fatalf("clientIP() = %q, want 192.0.2.1", got)
+	}
+	for i := 0; i < 2; i++ {
+		if !s.reserveConn("192.0.2.1") {
+			t.Fatalf("connection %d rejected, want accepted", i+1)
+		}
+	}
+	if s.reserveConn("192.0.2.1") {
+		t.Error("third connection accepted, want rejected")
+	}
+	if !s.reserveConn("192.0.2.2") {
+		t.Error("connection from another address rejected")
+	}
+
+	s.releaseConn("192.0.2.1")
+	if !s.reserveConn("192.0.2.1") {
+		t.Error("connection rejected after one was released")
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// requireAdmin wraps an admin API handler, which needs the AdminToken as a
+// bearer token; without an AdminToken the admin API is disabled
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.AdminToken == "" {
+			writeJSONError(w, http.StatusNotFound, "The admin API is not enabled")
+			return
+		}
+		token, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) != 1 {
+			s.recordFailure(clientIP(r.RemoteAddr))
+			writeJSONError(w, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// bearerToken returns the token of the request's "Authorization: Bearer"
+// header, if it has one
+func bearerToken(r *http.Request) (string, bool) {
+	return strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// handleListBans handles GET /api/admin/bans
+func (s *Server) handleListBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.bans.List(s.Clock.Now()))
+}
+
+// handleBan handles POST /api/admin/bans, banning {"ip": ..., "duration": "1h"}
+func (s *Server) handleBan(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if net.ParseIP(req.IP) == nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid IP address: "+req.IP)
+		return
+	}
+	var d time.Duration
+	if req.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(req.Duration); err != nil || d <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid duration: "+req.Duration)
+			return
+		}
+	}
+
+	ban, err := s.bans.Ban(req.IP, req.Reason, d, s.Clock.Now())
+	if err != nil {
+		log.Printf("Error saving ban list: %v", err)
+	}
+	log.Printf("Banned %s (%s) for %v", req.IP, req.Reason, d)
+	s.disconnectIP(req.IP)
+	writeJSON(w, http.StatusCreated, ban)
+}
+
+// handleUnban handles DELETE /api/admin/bans/{ip}
+func (s *Server) handleUnban(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	found, err := s.bans.Unban(ip, s.Clock.Now())
+	if err != nil {
+		log.Printf("Error saving ban list: %v", err)
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "Not banned: "+ip)
+		return
+	}
+	log.Printf("Unbanned %s", ip)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// throttle wraps the HTTP handler to refuse banned addresses and to ban
+// addresses that open game connections too often
+func (s *Server) throttle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r.RemoteAddr)
+		now := s.Clock.Now()
+		if r.URL.Path == "/ws" && s.bans.RecordConnect(ip, now) {
+			log.Printf("Banned %s for %v: too many connections", ip, autoBanDuration)
+		}
+		if ban, banned := s.bans.Banned(ip, now); banned {
+			writeJSONError(w, http.StatusForbidden, "Banned: "+ban.Reason)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordFailure notes a refused or failed connection from ip, such as a
+// WebSocket from a forbidden origin or a wrong admin token
+func (s *Server) recordFailure(ip string) {
+	if s.bans.RecordFailure(ip, s.Clock.Now()) {
+		log.Printf("Banned %s for %v: too many failed connections", ip, autoBanDuration)
+		s.disconnectIP(ip)
+	}
+}
+
+// disconnectIP closes the game connections from a banned address
+func (s *Server) disconnectIP(ip string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, client := range s.clients {
+		if clientIP(client.address) == ip && client.conn != nil {
+			go client.closeNow(protocol.CloseKicked, "banned")
+		}
+	}
+}
+
+// LoadBans keeps the ban list in path, loading the bans saved there by an
+// earlier run; returns how many are still in force
+func (s *Server) LoadBans(path string) (int, error) {
+	return s.bans.Load(path, s.Clock.Now())
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Analytics aggregates where pieces are placed and where holes form across
+// the finished games on a standard board, to show common failure patterns
+type Analytics struct {
+	games   int
+	heatmap game.Heatmap
+	mu      sync.Mutex
+}
+
+// NewAnalytics creates empty analytics
+func NewAnalytics() *Analytics {
+	return &Analytics{heatmap: game.NewHeatmap(board.DefaultWidth, board.DefaultHeight)}
+}
+
+// AddGame adds the heatmap of a finished game; games on boards of another
+// size are left out
+func (a *Analytics) AddGame(h game.Heatmap) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.heatmap.Add(h) {
+		a.games++
+	}
+}
+
+// Heatmap returns the aggregate of the games added so far
+func (a *Analytics) Heatmap() protocol.Heatmap {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	h := game.NewHeatmap(board.DefaultWidth, board.DefaultHeight)
+	h.Add(a.heatmap)
+	return protocol.Heatmap{
+		Games:      a.games,
+		Width:      board.DefaultWidth,
+		Height:     board.DefaultHeight,
+		Placements: h.Placements,
+		Holes:      h.Holes,
+	}
+}
+
+// handleHeatmap handles GET /api/analytics/heatmap
+func (s *Server) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.analytics.Heatmap())
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestHeatmapAPI verifies finished ranked games are added to the heatmap
+// served by the analytics endpoint, and practice games are not
+func TestHeatmapAPI(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	for _, mode := range []game.Mode{game.ModeMarathon, game.ModePractice} {
+		c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+		g := game.NewWithConfig(game.Config{Seed: 1, Mode: mode, Headless: true})
+		g.HardDrop()
+		c.session = s.sessions.Create(g, s.Clock.Now())
+		c.sendGameOver(0)
+	}
+	// Boards of another size do not fit the standard heatmap
+	s.analytics.AddGame(game.NewHeatmap(6, 12))
+
+	resp, err := http.Get(ts.URL + "/api/analytics/heatmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/analytics/heatmap = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var h protocol.Heatmap
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Games != 1 || h.Width != 10 || h.Height != 20 || len(h.Placements) != 20 || len(h.Holes) != 20 {
+		t.Fatalf("heatmap = %d games on %dx%d with %d rows, want 1 game on 10x20", h.Games, h.Width, h.Height, len(h.Placements))
+	}
+	placed := 0
+	for _, row := range h.Placements {
+		for _, n := range row {
+			placed += n
+		}
+	}
+	if placed != 4 {
+		t.Errorf("placements add up to %d, want the 4 cells of one piece", placed)
+	}
+}
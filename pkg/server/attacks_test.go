@@ -0,0 +1,73 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// perfectClearGame returns a practice game dealing only I pieces whose next
+// hard drop into the right column clears the whole board
+func perfectClearGame(t *testing.T, s *Server) *game.Game {
+	t.Helper()
+	g := game.NewWithConfig(game.Config{Mode: game.ModePractice, Randomizer: piece.RandomizerScripted,
+		Script: []piece.Type{piece.TypeI}, Clock: s.Clock})
+	well := "XXXXXXXXX."
+	if err := g.SetBoard([]string{well, well, well, well}); err != nil {
+		t.Fatal(err)
+	}
+	g.Rotate()
+	for g.MoveRight() {
+	}
+	return g
+}
+
+// perfectClears returns the perfect_clear_attack messages queued for a
+// client, draining its queue
+func perfectClears(t *testing.T, c *Client) []protocol.PerfectClearAttackMessage {
+	t.Helper()
+	var clears []protocol.PerfectClearAttackMessage
+	for _, msg := range queue.Drain(t, c.send).Of(protocol.MessageTypePerfectClearAttack) {
+		var clear protocol.PerfectClearAttackMessage
+		msg.Decode(t, &clear)
+		clears = append(clears, clear)
+	}
+	return clears
+}
+
+// TestPerfectClearBroadcast verifies every player of a match hears of a
+// perfect clear, with the seat and name of the player who made it
+func TestPerfectClearBroadcast(t *testing.T) {
+	s := New(":0")
+	players := []*Client{joinMatch(s, "c1"), joinMatch(s, "c2")}
+	clearing := players[1]
+	clearing.session = s.sessions.Create(perfectClearGame(t, s), s.Clock.Now())
+
+	clearing.Game().HardDrop()
+	clearing.sendAttacks()
+	want := protocol.PerfectClearAttackMessage{Lines: 4, Garbage: 14, Seat: 1, Name: "c2"}
+	for i, c := range players {
+		if got := perfectClears(t, c); len(got) != 1 || got[0] != want {
+			t.Errorf("player %d got perfect clears %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+// TestCPUPerfectClear verifies the player hears of a perfect clear by the bot
+func TestCPUPerfectClear(t *testing.T) {
+	c, _ := newCPUTest(game.ModeMarathon)
+	c.cpu.session = c.server.sessions.Create(perfectClearGame(t, c.server), c.server.Clock.Now())
+
+	c.cpu.session.Game().HardDrop()
+	c.updateCPU()
+	want := protocol.PerfectClearAttackMessage{Lines: 4, Garbage: 14, Seat: cpuSeat, Name: "CPU (hard)"}
+	if got := perfectClears(t, c); len(got) != 1 || got[0] != want {
+		t.Errorf("perfect clears = %+v, want %+v", got, want)
+	}
+	if got := c.Game().GetPendingGarbage(); got != 14 {
+		t.Errorf("player pending garbage = %d, want 14", got)
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// The audit log keeps the events of the latest maxAuditSessions sessions,
+// including ended ones, and up to maxAuditEvents events of each, dropping the
+// oldest first; the JSONL file keeps them all
+const (
+	maxAuditSessions = 1000
+	maxAuditEvents   = 10000
+)
+
+// AuditKind is what happened in an audit event
+type AuditKind string
+
+const (
+	AuditConnect    AuditKind = "connect"    // A connection started playing the session
+	AuditNewGame    AuditKind = "new_game"   // The session's game was restarted or loaded
+	AuditCommand    AuditKind = "command"    // A control message was received
+	AuditError      AuditKind = "error"      // A control message was answered with an error
+	AuditClear      AuditKind = "clear"      // Lines were cleared
+	AuditLevelUp    AuditKind = "level_up"   // A clear raised the level
+	AuditGameOver   AuditKind = "game_over"  // The game ended
+	AuditDisconnect AuditKind = "disconnect" // The connection playing the session ended
+)
+
+// AuditEvent is one entry of a session's audit log; the fields besides the
+// first four are set by the kinds they concern
+type AuditEvent struct {
+	Time    time.Time `json:"time"`
+	Session string    `json:"session"`
+	Client  string    `json:"client"`
+	Kind    AuditKind `json:"kind"`
+
+	Address string          `json:"address,omitempty"` // connect
+	Player  string          `json:"player,omitempty"`  // connect, for registered players
+	Mode    string          `json:"mode,omitempty"`    // connect and new_game
+	Seed    int64           `json:"seed,omitempty"`    // connect and new_game
+	Message json.RawMessage `json:"message,omitempty"` // command, as received
+	Lines   int             `json:"lines,omitempty"`   // clear and game_over
+	Points  int             `json:"points,omitempty"`  // clear
+	Level   int             `json:"level,omitempty"`   // clear, level_up and game_over
+	Score   int             `json:"score,omitempty"`   // game_over
+	Detail  string          `json:"detail,omitempty"`  // Error text, disconnect reason, or loaded for a loaded game
+}
+
+// AuditLog keeps the event log of recent game sessions for the admin API
+// and, if it has a file, appends every event to it as a line of JSON
+type AuditLog struct {
+	sessions map[string][]AuditEvent
+	order    []string // Session ids in the order they were first logged
+	file     *os.File // File events are appended to; nil keeps them in memory
+	mu       sync.Mutex
+}
+
+// NewAuditLog creates an empty audit log kept in memory
+func NewAuditLog() *AuditLog {
+	return &AuditLog{sessions: make(map[string][]AuditEvent)}
+}
+
+// Open appends the events recorded from now on to the JSONL file at path
+func (l *AuditLog) Open(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = f
+	return nil
+}
+
+// Close closes the JSONL file, if any
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Record adds an event to its session's log
+func (l *AuditLog) Record(ev AuditEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events, ok := l.sessions[ev.Session]
+	if !ok {
+		if len(l.order) == maxAuditSessions {
+			delete(l.sessions, l.order[0])
+			l.order = l.order[1:]
+		}
+		l.order = append(l.order, ev.Session)
+	}
+	if len(events) == maxAuditEvents {
+		events = events[1:]
+	}
+	l.sessions[ev.Session] = append(events, ev)
+
+	if l.file != nil {
+		data, err := json.Marshal(ev)
+		if err == nil {
+			_, err = l.file.Write(append(data, '\n'))
+		}
+		if err != nil {
+			log.Printf("Error writing audit log: %v", err)
+		}
+	}
+}
+
+// Events returns the logged events of a session, oldest first
+func (l *AuditLog) Events(session string) ([]AuditEvent, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events, ok := l.sessions[session]
+	return append([]AuditEvent(nil), events...), ok
+}
+
+// OpenAuditLog appends every session event from now on to the JSONL file at
+// path, besides keeping recent ones for the admin API
+func (s *Server) OpenAuditLog(path string) error {
+	return s.auditLog.Open(path)
+}
+
+// handleSessionEvents handles GET /api/admin/sessions/{id}/events
+func (s *Server) handleSessionEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	events, ok := s.auditLog.Events(id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "No events for session: "+id)
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// recordEvent adds an event of the client's session to the audit log
+func (c *Client) recordEvent(ev AuditEvent) {
+	ev.Time = c.server.Clock.Now()
+	ev.Session = c.session.ID
+	ev.Client = c.id
+	c.server.auditLog.Record(ev)
+}
+
+// recordNewGame logs the game the client's session now plays
+func (c *Client) recordNewGame(detail string) {
+	g := c.Game()
+	c.recordEvent(AuditEvent{Kind: AuditNewGame, Mode: string(g.GetMode()), Seed: g.GetSeed(), Detail: detail})
+}
+
+// recordCommand logs a control message received from the client; pongs only
+// keep the connection alive, so they are left out
+func (c *Client) recordCommand(msgType protocol.MessageType, data []byte) {
+	if msgType == protocol.MessageTypePong {
+		return
+	}
+	c.recordEvent(AuditEvent{Kind: AuditCommand, Message: json.RawMessage(data)})
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+)
+
+// TestAuditLog verifies events are kept per session, the oldest sessions are
+// evicted first and every event is appended to the file
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l := NewAuditLog()
+	if err := l.Open(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < maxAuditSessions+1; i++ {
+		session := fmt.Sprintf("s%d", i)
+		l.Record(AuditEvent{Session: session, Kind: AuditConnect})
+		l.Record(AuditEvent{Session: session, Kind: AuditDisconnect})
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := l.Events("s0"); ok {
+		t.Error("Events() of the oldest session found it after eviction")
+	}
+	last := fmt.Sprintf("s%d", maxAuditSessions)
+	events, ok := l.Events(last)
+	if !ok || len(events) != 2 || events[0].Kind != AuditConnect || events[1].Kind != AuditDisconnect {
+		t.Errorf("Events(%s) = %+v, %v, want connect and disconnect", last, events, ok)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		var ev AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+	}
+	if want := 2 * (maxAuditSessions + 1); lines != want {
+		t.Errorf("file has %d lines, want %d", lines, want)
+	}
+}
+
+// TestAuditClientEvents verifies a client's commands and the errors they get
+// are logged against its session, and pongs are not
+func TestAuditClientEvents(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	c.handleMessage([]byte(`{"type": "pong"}`))
+	c.handleMessage([]byte(`{"type": "move_left"}`))
+	c.handleMessage([]byte(`{"type": "restart_confirm"}`))
+
+	events, ok := s.auditLog.Events(c.session.ID)
+	if !ok {
+		t.Fatal("no events logged for the session")
+	}
+	var kinds []AuditKind
+	for _, ev := range events {
+		kinds = append(kinds, ev.Kind)
+		if ev.Client != "c1" {
+			t.Errorf("event %s logged for client %q, want c1", ev.Kind, ev.Client)
+		}
+	}
+	want := []AuditKind{AuditCommand, AuditCommand, AuditError}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("kinds = %v, want %v", kinds, want)
+			break
+		}
+	}
+	if got := string(events[0].Message); got != `{"type": "move_left"}` {
+		t.Errorf("Message = %s, want the move_left command as received", got)
+	}
+}
+
+// TestAdminSessionEventsAPI verifies the events endpoint needs the admin
+// token and returns the events of a known session
+func TestAdminSessionEventsAPI(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.AdminToken = "secret"
+	s.auditLog.Record(AuditEvent{Session: "s1", Client: "c1", Kind: AuditConnect, Address: "192.0.2.1"})
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	get := func(path, token string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	if resp := get("/api/admin/sessions/s1/events", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without the token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := get("/api/admin/sessions/nope/events", "secret"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	resp := get("/api/admin/sessions/s1/events", "secret")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("known session = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var events []AuditEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Kind != AuditConnect || events[0].Address != "192.0.2.1" {
+		t.Errorf("events = %+v, want the connect event", events)
+	}
+}
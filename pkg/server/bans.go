@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Abuse throttling: an address that fails failureBurst WebSocket upgrades, or
+// attempts connectBurst connections, within abuseWindow is banned for autoBanDuration
+const (
+	failureBurst    = 10
+	connectBurst    = 60
+	abuseWindow     = time.Minute
+	autoBanDuration = 10 * time.Minute
+	maxTrackedIPs   = 4096 // Addresses tracked before stale ones are swept
+)
+
+// Ban keeps an IP address from reaching the server
+type Ban struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Until     time.Time `json:"until,omitzero"` // Zero for a permanent ban
+	Automatic bool      `json:"automatic,omitempty"`
+}
+
+// Active reports whether the ban is in force at now
+func (b Ban) Active(now time.Time) bool {
+	return b.Until.IsZero() || now.Before(b.Until)
+}
+
+// Bans tracks the connection attempts and failed upgrades of each IP address,
+// banning abusive ones, and keeps the ban list, saved to a file if it has one
+type Bans struct {
+	path     string // File the ban list is saved to; empty keeps it in memory
+	bans     map[string]Ban
+	connects map[string][]time.Time // Recent connection attempts of each address
+	failures map[string][]time.Time // Recent failed upgrades of each address
+	mu       sync.Mutex
+}
+
+// NewBans creates an empty ban list kept in memory
+func NewBans() *Bans {
+	return &Bans{
+		bans:     make(map[string]Ban),
+		connects: make(map[string][]time.Time),
+		failures: make(map[string][]time.Time),
+	}
+}
+
+// Banned returns the ban in force against ip at now, if any
+func (b *Bans) Banned(ip string, now time.Time) (Ban, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, ok := b.bans[ip]
+	if !ok || !ban.Active(now) {
+		return Ban{}, false
+	}
+	return ban, true
+}
+
+// Ban bans ip for d from now, or for good if d is 0
+func (b *Bans) Ban(ip, reason string, d time.Duration, now time.Time) (Ban, error) {
+	ban := Ban{IP: ip, Reason: reason, CreatedAt: now}
+	if d > 0 {
+		ban.Until = now.Add(d)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bans[ip] = ban
+	return ban, b.saveLocked(now)
+}
+
+// Unban lifts the ban against ip, returning false if it was not banned
+func (b *Bans) Unban(ip string, now time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.bans[ip]; !ok {
+		return false, nil
+	}
+	delete(b.bans, ip)
+	delete(b.failures, ip)
+	delete(b.connects, ip)
+	return true, b.saveLocked(now)
+}
+
+// List returns the bans in force at now, oldest first
+func (b *Bans) List(now time.Time) []Ban {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		if ban.Active(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.Before(bans[j].CreatedAt)
+	})
+	return bans
+}
+
+// RecordConnect notes a connection attempt from ip at now, banning it
+// automatically past connectBurst; returns true if it got banned
+func (b *Bans) RecordConnect(ip string, now time.Time) bool {
+	return b.record(b.connects, connectBurst, "too many connections", ip, now)
+}
+
+// RecordFailure notes a failed WebSocket upgrade from ip at now, banning it
+// automatically past failureBurst; returns true if it got banned
+func (b *Bans) RecordFailure(ip string, now time.Time) bool {
+	return b.record(b.failures, failureBurst, "too many failed connections", ip, now)
+}
+
+// record notes an event of ip in events and bans ip once it has burst of
+// them within abuseWindow
+func (b *Bans) record(events map[string][]time.Time, burst int, reason, ip string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(events) >= maxTrackedIPs {
+		for other, times := range events {
+			if now.Sub(times[len(times)-1]) >= abuseWindow {
+				delete(events, other)
+			}
+		}
+	}
+	recent := slices.DeleteFunc(events[ip], func(t time.Time) bool {
+		return now.Sub(t) >= abuseWindow
+	})
+	recent = append(recent, now)
+	if len(recent) < burst {
+		events[ip] = recent
+		return false
+	}
+
+	delete(events, ip)
+	b.bans[ip] = Ban{IP: ip, Reason: reason, CreatedAt: now, Until: now.Add(autoBanDuration), Automatic: true}
+	if err := b.saveLocked(now); err != nil {
+		log.Printf("Error saving ban list: %v", err)
+	}
+	return true
+}
+
+// savedBans is the ban list as saved to a file
+type savedBans struct {
+	Bans []Ban `json:"bans"`
+}
+
+// saveLocked writes the bans in force at now to the ban file, if there is one
+// Must be called with mu held
+func (b *Bans) saveLocked(now time.Time) error {
+	if b.path == "" {
+		return nil
+	}
+
+	saved := savedBans{Bans: []Ban{}}
+	for ip, ban := range b.bans {
+		if !ban.Active(now) {
+			delete(b.bans, ip)
+			continue
+		}
+		saved.Bans = append(saved.Bans, ban)
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write a temporary file and rename it, so a crash never leaves half a file
+	tmp := filepath.Join(filepath.Dir(b.path), "."+filepath.Base(b.path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// Load keeps the ban list in path from now on, loading the bans saved
+// there still in force at now; a missing file loads nothing
+func (b *Bans) Load(path string, now time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var saved savedBans
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("invalid ban file %s: %w", path, err)
+	}
+	loaded := 0
+	for _, ban := range saved.Bans {
+		if ban.Active(now) {
+			b.bans[ban.IP] = ban
+			loaded++
+		}
+	}
+	return loaded, nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+)
+
+// TestBans verifies bans expire, can be lifted and survive a restart
+func TestBans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+	now := time.Unix(1000, 0)
+	bans := NewBans()
+	if n, err := bans.Load(path, now); err != nil || n != 0 {
+		t.Fatalf("Load() of a missing file = %d, %v, want 0, nil", n, err)
+	}
+
+	if _, err := bans.Ban("192.0.2.1", "spam", time.Hour, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bans.Ban("192.0.2.2", "cheating", 0, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bans.Ban("192.0.2.3", "", time.Hour, now); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := bans.Unban("192.0.2.3", now); !ok || err != nil {
+		t.Errorf("Unban() = %v, %v, want true, nil", ok, err)
+	}
+	if ok, _ := bans.Unban("192.0.2.9", now); ok {
+		t.Error("Unban() of an address never banned = true")
+	}
+
+	later := now.Add(2 * time.Hour)
+	if _, banned := bans.Banned("192.0.2.1", now); !banned {
+		t.Error("192.0.2.1 not banned within its hour")
+	}
+	if _, banned := bans.Banned("192.0.2.1", later); banned {
+		t.Error("192.0.2.1 still banned after its hour")
+	}
+
+	restarted := NewBans()
+	if n, err := restarted.Load(path, later); err != nil || n != 1 {
+		t.Fatalf("Load() after the restart = %d, %v, want 1, nil", n, err)
+	}
+	if ban, banned := restarted.Banned("192.0.2.2", later); !banned || ban.Reason != "cheating" {
+		t.Errorf("Banned(192.0.2.2) after the restart = %+v, %v, want the permanent ban", ban, banned)
+	}
+	if list := restarted.List(later); len(list) != 1 {
+		t.Errorf("List() = %d bans, want 1", len(list))
+	}
+}
+
+// TestAutoBan verifies an address is banned for a while once it fails too
+// often within the window
+func TestAutoBan(t *testing.T) {
+	bans := NewBans()
+	now := time.Unix(1000, 0)
+
+	// An old failure falls out of the window
+	bans.RecordFailure("192.0.2.1", now.Add(-abuseWindow))
+	for i := 0; i < failureBurst-1; i++ {
+		if bans.RecordFailure("192.0.2.1", now) {
+			t.Fatalf("banned after %d failures, want %d", i+1, failureBurst)
+		}
+	}
+	if !bans.RecordFailure("192.0.2.1", now) {
+		t.Fatalf("not banned after %d failures", failureBurst)
+	}
+	if ban, banned := bans.Banned("192.0.2.1", now); !banned || !ban.Automatic {
+		t.Errorf("Banned() = %+v, %v, want an automatic ban", ban, banned)
+	}
+	if _, banned := bans.Banned("192.0.2.1", now.Add(autoBanDuration)); banned {
+		t.Error("still banned after autoBanDuration")
+	}
+}
+
+// TestAdminBansAPI verifies the admin API needs its token, bans and unbans
+// addresses and that banned addresses are refused
+func TestAdminBansAPI(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.AdminToken = "secret"
+	ts := httptest.NewServer(s.throttle(s.countErrors(s.routes())))
+	t.Cleanup(ts.Close)
+
+	do := func(method, path, token, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		token, body  string
+		want         int
+	}{
+		{"no token", "GET", "/api/admin/bans", "", "", http.StatusUnauthorized},
+		{"wrong token", "GET", "/api/admin/bans", "guess", "", http.StatusUnauthorized},
+		{"invalid ip", "POST", "/api/admin/bans", "secret", `{"ip": "nope"}`, http.StatusBadRequest},
+		{"invalid duration", "POST", "/api/admin/bans", "secret", `{"ip": "192.0.2.1", "duration": "soon"}`, http.StatusBadRequest},
+		{"ban", "POST", "/api/admin/bans", "secret", `{"ip": "192.0.2.1", "reason": "spam", "duration": "1h"}`, http.StatusCreated},
+		{"list", "GET", "/api/admin/bans", "secret", "", http.StatusOK},
+		{"unban", "DELETE", "/api/admin/bans/192.0.2.1", "secret", "", http.StatusNoContent},
+		{"unban again", "DELETE", "/api/admin/bans/192.0.2.1", "secret", "", http.StatusNotFound},
+		{"ban self", "POST", "/api/admin/bans", "secret", `{"ip": "127.0.0.1"}`, http.StatusCreated},
+		{"banned", "GET", "/api/ladder", "", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.path, tt.token, tt.body); got != tt.want {
+			t.Errorf("%s: %s %s = %d, want %d", tt.name, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
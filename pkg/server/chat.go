@@ -0,0 +1,103 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Flood control: a player may send chatBurst chat messages and emotes in any chatWindow
+const (
+	chatBurst  = 5
+	chatWindow = 10 * time.Second
+)
+
+// handleChat relays a chat message to every player of the client's match,
+// including the sender, so everyone sees the messages in the same order
+func (c *Client) handleChat(text string) {
+	if c.match == nil {
+		c.sendError(protocol.CodeNotAllowed, "Chat is only available in a match")
+		return
+	}
+	text, err := protocol.NormalizeChat(text)
+	if err != nil {
+		c.sendError(protocol.CodeInvalidMessage, "Invalid chat message: "+err.Error())
+		return
+	}
+
+	now := c.server.Clock.Now()
+	if !c.allowChat(now) {
+		c.sendError(protocol.CodeRateLimited, "Too many chat messages, slow down")
+		return
+	}
+
+	log.Printf("[Client %s] Chat in match %s: %q", c.id, c.match.ID, text)
+	data, err := protocol.NewChatMessage(c.Name(), text, now).Serialize()
+	if err != nil {
+		log.Printf("Error serializing chat: %v", err)
+		return
+	}
+	for _, client := range c.server.matchClients(c.match) {
+		client.sendChat(data)
+	}
+}
+
+// handleEmote relays an emote to the other players of the client's match
+func (c *Client) handleEmote(emote string) {
+	if c.match == nil {
+		c.sendError(protocol.CodeNotAllowed, "Emotes are only available in a match")
+		return
+	}
+	if !protocol.IsValidEmote(emote) {
+		c.sendError(protocol.CodeInvalidMessage, "Unknown emote: "+emote)
+		return
+	}
+
+	now := c.server.Clock.Now()
+	if !c.allowChat(now) {
+		c.sendError(protocol.CodeRateLimited, "Too many chat messages, slow down")
+		return
+	}
+
+	data, err := protocol.NewEmoteMessage(c.seat, c.Name(), emote, now).Serialize()
+	if err != nil {
+		log.Printf("Error serializing emote: %v", err)
+		return
+	}
+	for _, client := range c.server.matchClients(c.match) {
+		if client != c {
+			client.sendChat(data)
+		}
+	}
+}
+
+// allowChat records a chat message sent at now, returning false if the
+// client already sent chatBurst messages within chatWindow
+// Only called from readPump, so chatTimes needs no lock
+func (c *Client) allowChat(now time.Time) bool {
+	recent := c.chatTimes[:0]
+	for _, t := range c.chatTimes {
+		if now.Sub(t) < chatWindow {
+			recent = append(recent, t)
+		}
+	}
+	c.chatTimes = recent
+
+	if len(c.chatTimes) >= chatBurst {
+		return false
+	}
+	c.chatTimes = append(c.chatTimes, now)
+	return true
+}
+
+// sendChat sends a serialized chat message or emote to the client
+func (c *Client) sendChat(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendChat: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
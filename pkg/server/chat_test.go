@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAllowChat verifies the chat flood control
+func TestAllowChat(t *testing.T) {
+	c := &Client{}
+	start := time.Unix(0, 0)
+
+	for i := 0; i < chatBurst; i++ {
+		if !c.allowChat(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatalf("message %d was refused", i+1)
+		}
+	}
+	if c.allowChat(start.Add(5 * time.Second)) {
+		t.Error("message beyond the burst was allowed")
+	}
+	// The first message falls out of the window
+	if !c.allowChat(start.Add(chatWindow)) {
+		t.Error("message after the window passed was refused")
+	}
+	if c.allowChat(start.Add(chatWindow)) {
+		t.Error("second message after the window passed was allowed")
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestInvalidMessagesDisconnect verifies a client is disconnected with a
+// protocol error only after maxInvalidMessages unreadable messages in a row
+func TestInvalidMessagesDisconnect(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 2*maxInvalidMessages), server: s,
+		closeReq: make(chan closeRequest, 1)}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	for i := 0; i < maxInvalidMessages-1; i++ {
+		c.handleMessage([]byte(`not json`))
+	}
+	// A valid message starts the count again
+	c.handleMessage([]byte(`{"type": "pong"}`))
+	for i := 0; i < maxInvalidMessages-1; i++ {
+		c.handleMessage([]byte(`{"type": "launch_missiles"}`))
+	}
+	select {
+	case req := <-c.closeReq:
+		t.Fatalf("disconnected with %+v before %d invalid messages in a row", req, maxInvalidMessages)
+	default:
+	}
+
+	c.handleMessage([]byte(`{`))
+	select {
+	case req := <-c.closeReq:
+		if req.code != protocol.CloseProtocolError {
+			t.Errorf("close code = %d, want %d", req.code, protocol.CloseProtocolError)
+		}
+	default:
+		t.Fatal("not disconnected after too many invalid messages")
+	}
+}
+
+// TestSlowClientEviction verifies a client whose send queue stays full for
+// SlowClientTimeout is closed with CloseSlowClient, and that the frames it
+// missed are counted
+func TestSlowClientEviction(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	s.SlowClientTimeout = 5 * time.Second
+
+	conns := make(chan *websocket.Conn, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := s.upgrader().Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	defer ts.Close()
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer peer.Close()
+	c := &Client{id: "c1", conn: <-conns, send: make(chan []byte, 1), server: s}
+
+	if !c.queue([]byte("first")) {
+		t.Fatal("queue() = false with room in the send queue")
+	}
+	tests := []struct {
+		advance     time.Duration
+		wantEvicted bool
+	}{
+		{0, false},
+		{4 * time.Second, false},
+		{time.Second, true},
+		{time.Second, true},
+	}
+	for i, tt := range tests {
+		clk.Advance(tt.advance)
+		if c.queue([]byte("frame")) {
+			t.Errorf("step %d: queue() = true with the send queue full", i)
+		}
+		c.queueMu.Lock()
+		evicted := c.evicted
+		c.queueMu.Unlock()
+		if evicted != tt.wantEvicted {
+			t.Errorf("step %d: evicted = %v, want %v", i, evicted, tt.wantEvicted)
+		}
+	}
+	if got := c.DroppedFrames(); got != len(tests) {
+		t.Errorf("DroppedFrames() = %d, want %d", got, len(tests))
+	}
+
+	peer.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = peer.ReadMessage()
+	if !websocket.IsCloseError(err, protocol.CloseSlowClient) {
+		t.Errorf("read after eviction = %v, want close %d", err, protocol.CloseSlowClient)
+	}
+}
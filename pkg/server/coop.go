@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Co-op games are played by two connections on one shared game: each client
+// connects with coop=<id>, and the second to name an id joins the first's
+// session. The players take turns, one piece each, starting with the first to
+// join; only the player whose piece is falling may move it, and both are told
+// with your_turn whenever the turn passes. The first player's game loop runs
+// the shared game's gravity, and every change is sent to both.
+
+// coopSize is the number of players in a co-op game
+const coopSize = 2
+
+// ErrCoopFull is returned when joining a co-op game that already has two players
+var ErrCoopFull = errors.New("co-op game is full")
+
+// Coop is a co-op game, guarded by the Coops' mu
+type Coop struct {
+	ID string
+
+	session *Session   // The shared game
+	members []string   // Connected players' client ids, in the order they joined
+	turn    int        // Index in members of the player whose piece is falling
+	game    *game.Game // Game the turn was last settled in; a new one starts over
+	placed  int        // Pieces placed in that game when the turn was last settled
+}
+
+// Coops tracks the co-op games clients have joined
+type Coops struct {
+	coops map[string]*Coop
+	mu    sync.Mutex
+}
+
+// NewCoops creates an empty co-op registry
+func NewCoops() *Coops {
+	return &Coops{
+		coops: make(map[string]*Coop),
+	}
+}
+
+// Join adds a player to the co-op game with the given id, creating it with
+// the session made by create if it does not exist
+func (r *Coops) Join(id, clientID string, create func() *Session) (*Coop, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	coop := r.coops[id]
+	if coop == nil {
+		sess := create()
+		coop = &Coop{ID: id, session: sess, game: sess.Game()}
+		r.coops[id] = coop
+	}
+	if len(coop.members) >= coopSize {
+		return nil, ErrCoopFull
+	}
+	coop.members = append(coop.members, clientID)
+	return coop, nil
+}
+
+// Leave removes a player from a co-op game, passing the turn on if it was
+// theirs; returns true if the game is left empty and forgotten
+func (r *Coops) Leave(coop *Coop, clientID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, id := range coop.members {
+		if id != clientID {
+			continue
+		}
+		coop.members = append(coop.members[:i], coop.members[i+1:]...)
+		if i < coop.turn {
+			coop.turn--
+		}
+		break
+	}
+	if len(coop.members) == 0 {
+		delete(r.coops, coop.ID)
+		return true
+	}
+	coop.turn %= len(coop.members)
+	return false
+}
+
+// Settle passes the turn on once for every piece placed since it was last
+// settled; a new game starts over with the first player's piece
+// Returns true if the turn changed hands
+func (r *Coops) Settle(coop *Coop) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g := coop.session.Game()
+	turn := coop.turn
+	if g != coop.game {
+		coop.game, coop.placed, coop.turn = g, g.GetPiecesPlaced(), 0
+		return turn != 0
+	}
+	placed := g.GetPiecesPlaced()
+	n := len(coop.members)
+	if n == 0 {
+		return false
+	}
+	coop.turn = ((coop.turn+placed-coop.placed)%n + n) % n
+	coop.placed = placed
+	return coop.turn != turn
+}
+
+// Turn returns the client id and seat of the player whose piece is falling,
+// or "" once every player has left
+func (r *Coops) Turn(coop *Coop) (string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(coop.members) == 0 {
+		return "", 0
+	}
+	return coop.members[coop.turn], coop.turn
+}
+
+// clientIDs returns the client ids of the players connected to a co-op game
+func (r *Coops) clientIDs(coop *Coop) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), coop.members...)
+}
+
+// coopClients returns the connected clients of a co-op game's players
+func (s *Server) coopClients(coop *Coop) []*Client {
+	ids := s.coops.clientIDs(coop)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(ids))
+	for _, id := range ids {
+		if client, ok := s.clients[id]; ok {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// sendCoopTurn tells the players of a co-op game whose piece is falling
+// A player who just joined is passed as joined, as the hub may not have added
+// them to the client list yet
+func (s *Server) sendCoopTurn(coop *Coop, joined *Client) {
+	clients := s.coopClients(coop)
+	if joined != nil && !slices.Contains(clients, joined) {
+		clients = append(clients, joined)
+	}
+	turnID, seat := s.coops.Turn(coop)
+	name := ""
+	for _, client := range clients {
+		if client.id == turnID {
+			name = client.Name()
+		}
+	}
+	for _, client := range clients {
+		client.sendYourTurn(client.id == turnID, seat, name)
+	}
+}
+
+// sendYourTurn tells a co-op player whose piece is falling
+func (c *Client) sendYourTurn(yourTurn bool, seat int, name string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendYourTurn: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewYourTurnMessage(yourTurn, seat, name).Serialize()
+	if err != nil {
+		log.Printf("Error serializing your turn: %v", err)
+		return
+	}
+	c.queue(data)
+}
+
+// coopLeft tells the players left in a co-op game whose turn it is, and wakes
+// their game loops since one of them may now run the game
+func (s *Server) coopLeft(coop *Coop) {
+	s.sendCoopTurn(coop, nil)
+	for _, client := range s.coopClients(coop) {
+		client.wakeGameLoop()
+	}
+}
+
+// runsGame reports whether the client's game loop runs its game's gravity,
+// which in a co-op game is left to the first player
+func (c *Client) runsGame() bool {
+	if c.coop == nil {
+		return true
+	}
+	ids := c.server.coops.clientIDs(c.coop)
+	return len(ids) > 0 && ids[0] == c.id
+}
+
+// myTurn reports whether the falling piece is the client's to place
+func (c *Client) myTurn() bool {
+	if c.coop == nil {
+		return true
+	}
+	id, _ := c.server.coops.Turn(c.coop)
+	return id == c.id
+}
+
+// checkTurn answers a command for the falling piece of a co-op game from the
+// player whose turn it is not with an error, returning false
+func (c *Client) checkTurn(msgType protocol.MessageType) bool {
+	if !msgType.MovesPiece() || c.myTurn() {
+		return true
+	}
+	c.sendError(protocol.CodeNotAllowed, "Not your turn: the falling piece is your partner's")
+	return false
+}
+
+// coopPeers returns the other connected players of the client's co-op game
+func (c *Client) coopPeers() []*Client {
+	if c.coop == nil {
+		return nil
+	}
+	var peers []*Client
+	for _, client := range c.server.coopClients(c.coop) {
+		if client != c {
+			peers = append(peers, client)
+		}
+	}
+	return peers
+}
+
+// syncCoop brings the other players of a co-op game up to date after the
+// client changed the shared game, telling everyone when the turn passes
+func (c *Client) syncCoop(over bool) {
+	if c.coop == nil {
+		return
+	}
+	for _, peer := range c.coopPeers() {
+		peer.sendState()
+		if over {
+			peer.sendGameOver(0)
+		}
+	}
+	if c.server.coops.Settle(c.coop) {
+		c.server.sendCoopTurn(c.coop, nil)
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// joinCoop connects a bare client to the co-op game "room" of s
+func joinCoop(t *testing.T, s *Server, id string) *Client {
+	t.Helper()
+	c := &Client{id: id, send: make(chan []byte, 64), server: s}
+	coop, err := s.coops.Join("room", id, func() *Session {
+		return s.sessions.Create(c.newGame(), s.Clock.Now())
+	})
+	if err != nil {
+		t.Fatalf("Join(%s) error = %v", id, err)
+	}
+	c.coop, c.session = coop, coop.session
+	s.clients[id] = c
+	return c
+}
+
+// coopFrames is what a co-op client was sent, as read by drainCoop
+type coopFrames struct {
+	states int
+	turns  []protocol.YourTurnMessage
+	codes  []protocol.ErrorCode
+}
+
+// drainCoop reads the messages queued for a client
+func drainCoop(t *testing.T, c *Client) coopFrames {
+	t.Helper()
+	msgs := queue.Drain(t, c.send)
+	got := coopFrames{states: len(msgs.Of(protocol.MessageTypeState))}
+	for _, msg := range msgs.Of(protocol.MessageTypeYourTurn) {
+		var turn protocol.YourTurnMessage
+		msg.Decode(t, &turn)
+		got.turns = append(got.turns, turn)
+	}
+	for _, e := range msgs.Errors(t) {
+		got.codes = append(got.codes, e.Code)
+	}
+	return got
+}
+
+// TestCoop verifies the players of a co-op game share one game, only the
+// player whose turn it is moves the piece, and the turn passes as pieces lock
+func TestCoop(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	host := joinCoop(t, s, "c1")
+	guest := joinCoop(t, s, "c2")
+	if host.Game() != guest.Game() {
+		t.Fatal("co-op players got games of their own")
+	}
+	if _, err := s.coops.Join("room", "c3", nil); err != ErrCoopFull {
+		t.Errorf("Join() of a third player error = %v, want %v", err, ErrCoopFull)
+	}
+
+	guest.handleMessage([]byte(`{"type": "move_left"}`))
+	if got := drainCoop(t, guest); len(got.codes) != 1 || got.codes[0] != protocol.CodeNotAllowed {
+		t.Errorf("move_left out of turn got errors %v, want %s", got.codes, protocol.CodeNotAllowed)
+	}
+
+	host.handleMessage([]byte(`{"type": "hard_drop"}`))
+	hostGot, guestGot := drainCoop(t, host), drainCoop(t, guest)
+	if guestGot.states != 1 {
+		t.Errorf("guest got %d state frames after the host's drop, want 1", guestGot.states)
+	}
+	if len(hostGot.turns) != 1 || hostGot.turns[0].YourTurn || hostGot.turns[0].Seat != 1 {
+		t.Errorf("host got turns %+v, want the guest's", hostGot.turns)
+	}
+	if len(guestGot.turns) != 1 || !guestGot.turns[0].YourTurn {
+		t.Errorf("guest got turns %+v, want their own", guestGot.turns)
+	}
+
+	host.handleMessage([]byte(`{"type": "rotate"}`))
+	if got := drainCoop(t, host); len(got.codes) != 1 || got.codes[0] != protocol.CodeNotAllowed {
+		t.Errorf("rotate out of turn got errors %v, want %s", got.codes, protocol.CodeNotAllowed)
+	}
+	guest.handleMessage([]byte(`{"type": "pause"}`))
+	if !host.Game().IsPaused() {
+		t.Error("pause by the waiting player did not pause the shared game")
+	}
+	guest.handleMessage([]byte(`{"type": "resume"}`))
+	guest.handleMessage([]byte(`{"type": "hard_drop"}`))
+	if !host.myTurn() || guest.myTurn() {
+		t.Error("turn did not return to the host after the guest's piece locked")
+	}
+	if !host.runsGame() || guest.runsGame() {
+		t.Error("game loop not left to the host")
+	}
+}
+
+func TestCoopsLeave(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	host := joinCoop(t, s, "c1")
+	joinCoop(t, s, "c2")
+	host.Game().HardDrop()
+	s.coops.Settle(host.coop)
+
+	if s.coops.Leave(host.coop, "c2") {
+		t.Fatal("Leave() of the guest forgot the game")
+	}
+	if id, seat := s.coops.Turn(host.coop); id != "c1" || seat != 0 {
+		t.Errorf("Turn() after the guest left = %s, %d, want c1, 0", id, seat)
+	}
+	if !s.coops.Leave(host.coop, "c1") {
+		t.Error("Leave() of the last player kept the game")
+	}
+	if _, err := s.coops.Join("room", "c3", func() *Session {
+		return s.sessions.Create(host.newGame(), s.Clock.Now())
+	}); err != nil {
+		t.Errorf("Join() after the game emptied error = %v", err)
+	}
+}
+
+// TestCoopScoreEventsPeerLeft verifies a partner whose send queue is already
+// closed does not stop the clearing player's score events
+func TestCoopScoreEventsPeerLeft(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	clearing, left := joinCoop(t, s, "c1"), joinCoop(t, s, "c2")
+	close(left.send)
+
+	g := game.NewWithConfig(game.Config{Mode: game.ModePractice, Randomizer: piece.RandomizerScripted,
+		Script: []piece.Type{piece.TypeI}, Clock: s.Clock})
+	well := "XXXXXXXXX."
+	if err := g.SetBoard([]string{well, well, well, well, well, well, well, well}); err != nil {
+		t.Fatal(err)
+	}
+	clearing.session = s.sessions.Create(g, s.Clock.Now())
+	for i := 0; i < 2; i++ {
+		g.Rotate()
+		for g.MoveRight() {
+		}
+		g.HardDrop()
+	}
+
+	clearing.sendScoreEvents()
+	if got := len(queue.Drain(t, clearing.send).Of(protocol.MessageTypeScoreEvent)); got != 2 {
+		t.Errorf("clearing player got %d score events, want 2", got)
+	}
+}
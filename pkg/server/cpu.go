@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Versus-CPU games pit a player against a bot from pkg/ai hosted by the
+// server: the client connects with cpu=<difficulty>, and its game loop runs
+// the bot's game beside the player's. The garbage each side's line clears send
+// rises into the other's board, and the bot's board is streamed to the player
+// as opponent_state. The first to top out loses; the other's game then ends as
+// won. A restart starts both games again on a fresh shared seed.
+
+// cpuSeat is the bot's seat in the opponent_state frames; the player's is 0
+const cpuSeat = 1
+
+// cpuOpponent is the bot a client plays against
+type cpuOpponent struct {
+	bot     *ai.Bot
+	session *Session // The bot's game, replaced on a restart; not registered with the GameManager
+
+	// Only gameLoop's goroutine uses these
+	nextMove time.Time     // When the bot places its next piece
+	relayed  opponentRelay // The bot's game state last sent to the player
+}
+
+// startCPU gives the client a bot opponent of the given difficulty
+func (c *Client) startCPU(difficulty ai.Difficulty) {
+	now := c.server.Clock.Now()
+	c.cpu = &cpuOpponent{
+		bot:      ai.New(difficulty),
+		session:  newSession(newSessionID(), c.newCPUGame(), now, false),
+		nextMove: now.Add(difficulty.MoveDelay()),
+	}
+}
+
+// newCPUGame creates a game for the bot, dealt the same pieces as the player's
+func (c *Client) newCPUGame() *game.Game {
+	return game.NewWithConfig(game.Config{Seed: c.Game().GetSeed(), SpawnDelay: c.server.SpawnDelay,
+		LineClearDelay: c.server.LineClearDelay, Clock: c.server.Clock})
+}
+
+// cpuName is the bot's name in the opponent_state frames
+func (o *cpuOpponent) cpuName() string {
+	return fmt.Sprintf("CPU (%s)", o.bot.Difficulty())
+}
+
+// updateCPU advances the bot's game, places its piece when its move delay has
+// passed, sends its garbage to the player and decides the winner once either
+// side topped out
+func (c *Client) updateCPU() {
+	if c.cpu == nil {
+		return
+	}
+	player, bot := c.Game(), c.cpu.session.Game()
+	c.followPause()
+
+	now := c.server.Clock.Now()
+	if bot.IsPlaying() {
+		bot.Update()
+		if !now.Before(c.cpu.nextMove) && !bot.IsSpawning() {
+			c.cpu.bot.Play(bot)
+			c.cpu.nextMove = now.Add(c.cpu.bot.Difficulty().MoveDelay())
+		}
+	}
+	for _, attack := range bot.TakeAttacks() {
+		player.ReceiveGarbage(attack.Garbage)
+		if attack.PerfectClear {
+			c.sendCPUPerfectClear(attack)
+		}
+	}
+
+	// The side still playing when the other tops out wins
+	won := bot.IsGameOver() && !player.IsGameOver()
+	if won {
+		log.Printf("[Client %s] Beat the %s", c.id, c.cpu.cpuName())
+		player.Win()
+	} else if player.IsGameOver() && !bot.IsGameOver() {
+		bot.Win()
+	}
+	c.sendState()
+	if won {
+		c.sendGameOver(0)
+	}
+	c.relayCPU()
+}
+
+// sendCPUPerfectClear tells the player the bot made a perfect clear
+func (c *Client) sendCPUPerfectClear(attack game.Attack) {
+	data, err := protocol.NewPerfectClearAttackMessage(attack, cpuSeat, c.cpu.cpuName()).Serialize()
+	if err != nil {
+		log.Printf("Error serializing perfect clear attack: %v", err)
+		return
+	}
+	c.sendPerfectClear(data)
+}
+
+// followPause pauses or resumes the bot's game with the player's
+func (c *Client) followPause() {
+	if c.cpu == nil {
+		return
+	}
+	bot := c.cpu.session.Game()
+	switch {
+	case c.Game().IsPaused():
+		bot.Pause()
+	case c.Game().IsPlaying():
+		bot.Resume()
+	}
+}
+
+// relayCPU sends the bot's game to the player if it changed, at most once per
+// opponentInterval
+func (c *Client) relayCPU() {
+	g := c.cpu.session.Game()
+	seq := g.GetSeq()
+	now := c.server.Clock.Now()
+	if (g == c.cpu.relayed.game && seq == c.cpu.relayed.seq) || now.Sub(c.cpu.relayed.at) < opponentInterval {
+		return
+	}
+	c.cpu.relayed = opponentRelay{game: g, seq: seq, at: now}
+
+	msg, err := protocol.NewOpponentStateMessage(cpuSeat, c.cpu.cpuName(), g)
+	if err != nil {
+		log.Printf("Error creating opponent state: %v", err)
+		return
+	}
+	data, err := msg.Serialize()
+	if err != nil {
+		log.Printf("Error serializing opponent state: %v", err)
+		return
+	}
+	c.sendOpponentState(data)
+}
+
+// cpuTick returns how long gameLoop may sleep before the bot's game needs an
+// update or its next move, or maxTickInterval without a bot
+func (c *Client) cpuTick() time.Duration {
+	if c.cpu == nil {
+		return maxTickInterval
+	}
+	g := c.cpu.session.Game()
+	d := tickInterval(g)
+	if g.IsPlaying() {
+		d = min(d, max(c.cpu.nextMove.Sub(c.server.Clock.Now()), minTickInterval))
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// newCPUTest creates a bare client playing a game of mode against a hard bot
+func newCPUTest(mode game.Mode) (*Client, *clock.Fake) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	c := &Client{id: "c1", send: make(chan []byte, 64), server: s, mode: mode}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+	c.startCPU(ai.DifficultyHard)
+	return c, clk
+}
+
+// TestCPUPlays verifies the bot places a piece once its move delay passes and
+// its board reaches the player as an opponent
+func TestCPUPlays(t *testing.T) {
+	c, clk := newCPUTest(game.ModeMarathon)
+	if got, want := c.cpu.session.Game().GetSeed(), c.Game().GetSeed(); got != want {
+		t.Errorf("bot seed = %d, want the player's %d", got, want)
+	}
+
+	c.updateCPU()
+	if got := c.cpu.session.Game().GetPiecesPlaced(); got != 0 {
+		t.Fatalf("bot placed %d pieces before its move delay", got)
+	}
+	clk.Advance(ai.DifficultyHard.MoveDelay())
+	c.updateCPU()
+	if got := c.cpu.session.Game().GetPiecesPlaced(); got != 1 {
+		t.Errorf("bot placed %d pieces after its move delay, want 1", got)
+	}
+
+	var opp protocol.OpponentStateMessage
+	if !queue.Drain(t, c.send).Of(protocol.MessageTypeOpponentState).Last(t, &opp) {
+		t.Fatal("no opponent_state sent for the bot")
+	}
+	if opp.Seat != cpuSeat || opp.Name != "CPU (hard)" {
+		t.Errorf("opponent = seat %d %q, want seat %d \"CPU (hard)\"", opp.Seat, opp.Name, cpuSeat)
+	}
+
+	c.handleMessage([]byte(`{"type": "pause"}`))
+	if !c.cpu.session.Game().IsPaused() {
+		t.Error("pausing did not pause the bot")
+	}
+	c.handleMessage([]byte(`{"type": "select_mode", "mode": "sprint"}`))
+	if got := queue.Drain(t, c.send).Errors(t); len(got) != 1 {
+		t.Errorf("select_mode got %d errors, want 1", len(got))
+	}
+}
+
+// TestCPUGarbage verifies the player's line clears send garbage to the bot
+func TestCPUGarbage(t *testing.T) {
+	c, _ := newCPUTest(game.ModePractice)
+	g := c.Game()
+	well := "XXXXXXXXX."
+	if err := g.SetBoard([]string{well, well, well, well}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetNextPieces([]piece.Type{piece.TypeI}); err != nil {
+		t.Fatal(err)
+	}
+	g.HardDrop()
+	g.Rotate()
+	for g.MoveRight() {
+	}
+	g.HardDrop()
+	c.sendAttacks()
+	if got := c.cpu.session.Game().GetPendingGarbage(); got != 4 {
+		t.Errorf("bot pending garbage after a tetris = %d, want 4", got)
+	}
+}
+
+// TestCPUToppedOut verifies the player wins once the bot tops out
+func TestCPUToppedOut(t *testing.T) {
+	c, _ := newCPUTest(game.ModeMarathon)
+	bot := c.cpu.session.Game()
+	bot.ReceiveGarbage(40)
+	bot.HardDrop()
+	if !bot.IsGameOver() {
+		t.Fatal("bot survived 40 rows of garbage")
+	}
+
+	c.updateCPU()
+	if !c.Game().IsGameOver() || !c.Game().IsCompleted() {
+		t.Error("player's game did not end as won")
+	}
+	overs := queue.Drain(t, c.send).Of(protocol.MessageTypeGameOver)
+	if len(overs) != 1 {
+		t.Fatalf("got %d game_over messages, want 1", len(overs))
+	}
+	var over protocol.GameOverMessage
+	overs[0].Decode(t, &over)
+	if !over.Completed {
+		t.Error("game_over not marked completed")
+	}
+	if got := len(c.server.leaderboard.Top(10, "")); got != 0 {
+		t.Errorf("leaderboard has %d entries, want versus-CPU games unranked", got)
+	}
+}
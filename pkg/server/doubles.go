@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Doubles games are played by two players sharing one connection, such as at
+// one keyboard: the client connects with boards=2 and addresses each control
+// message to a board with board_index. Each board is a game of its own, and
+// the team scores the sum of both; the team plays on until both boards are
+// over. Commands for the whole game, such as pause and restart, apply to both.
+
+// parseBoards returns the number of boards asked for in the connect URL,
+// which is 1 unless it is a number up to protocol.MaxBoards
+func parseBoards(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > protocol.MaxBoards {
+		return 1
+	}
+	return n
+}
+
+// startDoubles gives the client the second board of a doubles game
+// It is not registered with the GameManager, so it has no event stream and
+// is not saved on shutdown
+func (c *Client) startDoubles() {
+	c.partner = newSession(newSessionID(), c.newGame(), c.server.Clock.Now(), false)
+}
+
+// boardSessions returns the sessions of the client's boards, in board order
+func (c *Client) boardSessions() []*Session {
+	if c.partner == nil {
+		return []*Session{c.session}
+	}
+	return []*Session{c.session, c.partner}
+}
+
+// boardGame returns the game of board i, or nil if the client has no such board
+func (c *Client) boardGame(i int) *game.Game {
+	boards := c.boardSessions()
+	if i < 0 || i >= len(boards) {
+		return nil
+	}
+	return boards[i].Game()
+}
+
+// target returns the game of the board the control message being handled is for
+func (c *Client) target() *game.Game {
+	return c.boardGame(c.board)
+}
+
+// teamScore returns the sum of the scores of the client's boards
+func (c *Client) teamScore() int {
+	score := 0
+	for _, sess := range c.boardSessions() {
+		score += sess.Game().GetScore()
+	}
+	return score
+}
+
+// anyPlaying reports whether any of the client's boards is being played
+func (c *Client) anyPlaying() bool {
+	for _, sess := range c.boardSessions() {
+		if sess.Game().IsPlaying() {
+			return true
+		}
+	}
+	return false
+}
+
+// allOver reports whether all of the client's boards are over, which ends
+// the team's game
+func (c *Client) allOver() bool {
+	for _, sess := range c.boardSessions() {
+		if !sess.Game().IsGameOver() {
+			return false
+		}
+	}
+	return true
+}
+
+// forBoard marks a message about board i with it and the team score in a
+// doubles game; single board games leave it as it is
+func (c *Client) forBoard(msg *protocol.Message, i int) *protocol.Message {
+	if c.partner != nil {
+		msg.SetBoard(i, c.teamScore())
+	}
+	return msg
+}
+
+// checkBoard answers a command for a board the client does not have with an
+// error, returning false
+func (c *Client) checkBoard() bool {
+	if c.target() != nil {
+		return true
+	}
+	c.sendError(protocol.CodeNotFound, fmt.Sprintf("No board %d; connect with boards=%d to play doubles", c.board, protocol.MaxBoards))
+	return false
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// drainStates returns the last state frame of each board queued for a client,
+// by board, and the error codes queued with them
+func drainStates(t *testing.T, c *Client) (map[int]protocol.StateMessage, []protocol.ErrorCode) {
+	t.Helper()
+	msgs := queue.Drain(t, c.send)
+	states := make(map[int]protocol.StateMessage)
+	for _, msg := range msgs.Of(protocol.MessageTypeState) {
+		var state protocol.StateMessage
+		msg.Decode(t, &state)
+		states[state.BoardIndex] = state
+	}
+	var codes []protocol.ErrorCode
+	for _, e := range msgs.Errors(t) {
+		codes = append(codes, e.Code)
+	}
+	return states, codes
+}
+
+// TestDoubles verifies commands reach the board they name, frames carry the
+// board and team score, and pausing one board pauses both
+func TestDoubles(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+	c.startDoubles()
+
+	c.handleMessage([]byte(`{"type": "hard_drop", "board_index": 1}`))
+	states, codes := drainStates(t, c)
+	if len(codes) != 0 {
+		t.Fatalf("hard_drop on board 1 got errors %v", codes)
+	}
+	first, second := states[0], states[1]
+	if first.Score != 0 || second.Score == 0 {
+		t.Errorf("scores = %d and %d, want only board 1 to score", first.Score, second.Score)
+	}
+	if second.TeamScore != second.Score || first.TeamScore != second.Score {
+		t.Errorf("team scores = %d and %d, want %d", first.TeamScore, second.TeamScore, second.Score)
+	}
+
+	c.handleMessage([]byte(`{"type": "pause", "board_index": 1}`))
+	if !c.boardGame(0).IsPaused() || !c.boardGame(1).IsPaused() {
+		t.Error("pause on board 1 did not pause both boards")
+	}
+	c.handleMessage([]byte(`{"type": "save_game"}`))
+	if _, codes := drainStates(t, c); len(codes) != 1 || codes[0] != protocol.CodeNotAllowed {
+		t.Errorf("save_game got errors %v, want %s", codes, protocol.CodeNotAllowed)
+	}
+
+	single := &Client{id: "c2", send: make(chan []byte, 16), server: s}
+	single.session = s.sessions.Create(single.newGame(), s.Clock.Now())
+	single.handleMessage([]byte(`{"type": "rotate", "board_index": 1}`))
+	if _, codes := drainStates(t, single); len(codes) != 1 || codes[0] != protocol.CodeNotFound {
+		t.Errorf("board 1 of a single board game got errors %v, want %s", codes, protocol.CodeNotFound)
+	}
+	single.handleMessage([]byte(`{"type": "rotate", "board_index": 2}`))
+	if _, codes := drainStates(t, single); len(codes) != 1 || codes[0] != protocol.CodeInvalidMessage {
+		t.Errorf("board 2 got errors %v, want %s", codes, protocol.CodeInvalidMessage)
+	}
+}
+
+func TestParseBoards(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 1},
+		{"1", 1},
+		{"2", 2},
+		{"3", 1},
+		{"0", 1},
+		{"two", 1},
+	}
+	for _, tt := range tests {
+		if got := parseBoards(tt.value); got != tt.want {
+			t.Errorf("parseBoards(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestDoublesTogglePause verifies toggle_pause on either board pauses and
+// resumes both
+func TestDoublesTogglePause(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+	c.startDoubles()
+
+	tests := []struct {
+		board      int
+		wantPaused bool
+	}{
+		{1, true},
+		{0, false},
+		{0, true},
+		{1, false},
+	}
+	for i, tt := range tests {
+		c.handleMessage([]byte(fmt.Sprintf(`{"type": "toggle_pause", "board_index": %d}`, tt.board)))
+		for board := 0; board < 2; board++ {
+			if got := c.boardGame(board).IsPaused(); got != tt.wantPaused {
+				t.Errorf("toggle %d on board %d: board %d paused = %v, want %v", i, tt.board, board, got, tt.wantPaused)
+			}
+		}
+		queue.Drain(t, c.send)
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"log"
+	"net/http"
+)
+
+// A rolling deploy starts the new server next to the old one, then drains the
+// old one: it refuses new players and fails /readyz, so the load balancer
+// sends them to the new server, while the games in progress go on until they
+// end or SIGTERM saves them
+
+// Drain makes the server refuse new game connections and report itself not
+// ready, without disturbing the players already connected
+func (s *Server) Drain() {
+	s.mu.Lock()
+	s.draining = true
+	clients := len(s.clients)
+	s.mu.Unlock()
+	log.Printf("Draining: refusing new players, %d still connected", clients)
+}
+
+// Resume accepts new game connections again after Drain
+func (s *Server) Resume() {
+	s.mu.Lock()
+	s.draining = false
+	s.mu.Unlock()
+	log.Println("Resumed accepting new players")
+}
+
+// Draining reports whether Drain was called and Resume was not since
+func (s *Server) Draining() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.draining
+}
+
+// handleDrain handles POST /api/admin/drain, reporting the players left
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	s.Drain()
+	s.writeDrainStatus(w)
+}
+
+// handleResume handles DELETE /api/admin/drain
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.Resume()
+	s.writeDrainStatus(w)
+}
+
+func (s *Server) writeDrainStatus(w http.ResponseWriter) {
+	s.mu.RLock()
+	report := map[string]interface{}{
+		"draining": s.draining,
+		"clients":  len(s.clients),
+	}
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, report)
+}
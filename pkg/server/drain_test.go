@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+)
+
+// TestDrain verifies a draining server refuses new game connections and is
+// not ready until it resumes, and that only the admin can drain it
+func TestDrain(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.AdminToken = "secret"
+	go s.run()
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(func() {
+		ts.Close()
+		s.games.CloseAll()
+	})
+
+	admin := func(method string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+"/api/admin/drain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	var e map[string]interface{}
+	if code := doJSON(t, http.MethodPost, ts.URL+"/api/admin/drain", "", &e); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/admin/drain without the token = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := admin(http.MethodPost); code != http.StatusOK || !s.Draining() {
+		t.Fatalf("POST /api/admin/drain = %d, draining %v, want 200 and draining", code, s.Draining())
+	}
+
+	var ready struct {
+		Status string `json:"status"`
+	}
+	if code := doJSON(t, http.MethodGet, ts.URL+"/readyz", "", &ready); code != http.StatusServiceUnavailable || ready.Status != "draining" {
+		t.Errorf("GET /readyz while draining = %d %s, want 503 draining", code, ready.Status)
+	}
+	resp, err := http.Get(ts.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GET /ws while draining = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	if code := admin(http.MethodDelete); code != http.StatusOK || s.Draining() {
+		t.Fatalf("DELETE /api/admin/drain = %d, draining %v, want 200 and not draining", code, s.Draining())
+	}
+	if code := doJSON(t, http.MethodGet, ts.URL+"/readyz", "", &ready); code != http.StatusOK {
+		t.Errorf("GET /readyz after resuming = %d, want 200", code)
+	}
+}
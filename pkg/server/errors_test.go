@@ -0,0 +1,58 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestErrorCodes verifies rejected commands carry the error code for the
+// reason, so clients need not match on the text
+func TestErrorCodes(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := New(":0")
+	s.Clock = clk
+	over := game.NewWithConfig(game.Config{Clock: clk})
+	for i := 0; i < 100 && !over.IsGameOver(); i++ {
+		over.HardDrop()
+	}
+
+	tests := []struct {
+		name string
+		g    *game.Game
+		msg  string
+		want protocol.ErrorCode
+	}{
+		{"invalid", nil, `{"type": "rotate", "speed": 2}`, protocol.CodeInvalidMessage},
+		{"game over", over, `{"type": "rotate"}`, protocol.CodeGameOver},
+		{"chat outside a match", nil, `{"type": "chat", "text": "hi"}`, protocol.CodeNotAllowed},
+		{"unknown mode", nil, `{"type": "select_mode", "mode": "nope"}`, protocol.CodeInvalidMessage},
+		{"unknown puzzle", nil, `{"type": "select_mode", "mode": "puzzle", "puzzle": "nope"}`, protocol.CodeNotFound},
+		{"no restart pending", nil, `{"type": "restart_confirm"}`, protocol.CodeNotFound},
+		{"snapshot version", nil, `{"type": "load_game", "snapshot": {"version": 99}}`, protocol.CodeUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+			g := tt.g
+			if g == nil {
+				g = c.newGame()
+			}
+			c.session = s.sessions.Create(g, clk.Now())
+
+			c.handleMessage([]byte(tt.msg))
+			var code protocol.ErrorCode
+			for _, e := range queue.Drain(t, c.send).Errors(t) {
+				code = e.Code
+			}
+			if code != tt.want {
+				t.Errorf("error code = %q, want %q", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// watchers tells subscribers that a game's state changed
+// Changes that arrive while one is pending are merged, so a slow subscriber
+// never blocks the game
+type watchers struct {
+	chans map[chan struct{}]struct{}
+	mu    sync.Mutex
+}
+
+// add subscribes to changes; call the returned function to unsubscribe
+func (w *watchers) add() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.chans == nil {
+		w.chans = make(map[chan struct{}]struct{})
+	}
+	w.chans[ch] = struct{}{}
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		delete(w.chans, ch)
+		w.mu.Unlock()
+	}
+}
+
+// notify tells every subscriber that the state changed without blocking
+func (w *watchers) notify() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.chans {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// stateSource is a game whose state can be streamed: a hosted game or the
+// session of a WebSocket client
+type stateSource interface {
+	Game() *game.Game
+	Watch() (<-chan struct{}, func())
+	Done() <-chan struct{}
+}
+
+// stateSource finds the hosted game or session with the given id; the id of
+// a connected client finds the client's session
+func (s *Server) stateSource(id string) (stateSource, error) {
+	if hg, err := s.games.Get(id); err == nil {
+		return hg, nil
+	}
+	if sess, err := s.sessions.Get(id); err == nil {
+		return sess, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c, ok := s.clients[id]; ok {
+		return c.session, nil
+	}
+	return nil, ErrGameNotFound
+}
+
+// handleEvents handles GET /events/{gameID}, streaming the game's state as
+// Server-Sent Events for read-only integrations such as dashboards and
+// stream overlays; each "state" event carries the data of a state message
+// The stream lasts until the game is removed or the client disconnects, so an
+// overlay keeps following a player through restarts
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	src, err := s.stateSource(r.PathValue("gameID"))
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	changed, stop := src.Watch()
+	defer stop()
+
+	// Comments keep proxies from closing a stream whose game is paused
+	keepAlive := s.Clock.NewTicker(s.PingInterval)
+	defer keepAlive.Stop()
+
+	for {
+		data, err := json.Marshal(protocol.NewStateMessage(src.Game()).Data)
+		if err != nil {
+			log.Printf("Error serializing state event: %v", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: state\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+	wait:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-src.Done():
+				return
+			case <-s.done:
+				return
+			case <-changed:
+				break wait
+			case <-keepAlive.C():
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// readEvent reads the next SSE event, skipping keep-alive comments
+func readEvent(t *testing.T, r *bufio.Reader) (event string, state protocol.StateMessage) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &state); err != nil {
+				t.Fatalf("decoding event data: %v", err)
+			}
+		case line == "" && event != "":
+			return event, state
+		}
+	}
+}
+
+// TestEventsStream verifies that the event stream sends the state and every change to it
+func TestEventsStream(t *testing.T) {
+	ts := newRESTTest(t)
+
+	var created protocol.GameCreated
+	doJSON(t, "POST", ts.URL+"/api/games", "", &created)
+
+	resp, err := http.Get(ts.URL + "/events/" + created.GameID)
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	event, first := readEvent(t, r)
+	if event != "state" {
+		t.Errorf("event = %q, want state", event)
+	}
+
+	var moved protocol.StateMessage
+	doJSON(t, "POST", ts.URL+"/api/games/"+created.GameID+"/moves", `{"type": "move_left"}`, &moved)
+
+	_, next := readEvent(t, r)
+	if want := first.CurrentPiece.X - 1; next.CurrentPiece.X != want {
+		t.Errorf("streamed X after move_left = %d, want %d", next.CurrentPiece.X, want)
+	}
+}
+
+// TestEventsUnknownGame verifies that streaming an unknown game fails
+func TestEventsUnknownGame(t *testing.T) {
+	ts := newRESTTest(t)
+
+	resp, err := http.Get(ts.URL + "/events/missing")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
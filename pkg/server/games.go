@@ -0,0 +1,249 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Errors returned when controlling a hosted game
+var (
+	ErrGameNotFound  = errors.New("game not found")
+	ErrGameOver      = errors.New("game is over")
+	ErrInvalidAction = errors.New("invalid action")
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrTooManyGames  = errors.New("too many hosted games")
+)
+
+// HostedGame is a game the server runs for a client that has no WebSocket
+// connection, such as a gRPC or REST client; gravity is applied by the server and
+// watchers are told whenever the state changes
+type HostedGame struct {
+	ID    string
+	game  *game.Game
+	games *Games
+
+	// lastInput is when the game last received a command; guarded by inputMu
+	lastInput time.Time
+	inputMu   sync.Mutex
+
+	watchers watchers
+
+	wake      chan struct{} // Tells the game loop to reschedule after a command
+	done      chan struct{} // Closed when the game is removed
+	closeOnce sync.Once
+}
+
+// Games is the registry of hosted games
+type Games struct {
+	server *Server
+	games  map[string]*HostedGame
+	mu     sync.Mutex
+}
+
+// newGames creates an empty registry; games follow the server's clock and
+// are removed after its IdleTimeout without commands
+func newGames(s *Server) *Games {
+	return &Games{
+		server: s,
+		games:  make(map[string]*HostedGame),
+	}
+}
+
+// Create starts a new hosted game; returns ErrTooManyGames if the server
+// already hosts MaxHostedGames
+func (gs *Games) Create(cfg game.Config) (*HostedGame, error) {
+	cfg.Clock = gs.server.Clock
+	hg := &HostedGame{
+		ID:        newGameID(),
+		game:      game.NewWithConfig(cfg),
+		games:     gs,
+		lastInput: gs.server.Clock.Now(),
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	gs.mu.Lock()
+	if limit := gs.server.MaxHostedGames; limit > 0 && len(gs.games) >= limit {
+		gs.mu.Unlock()
+		return nil, ErrTooManyGames
+	}
+	gs.games[hg.ID] = hg
+	gs.mu.Unlock()
+
+	log.Printf("[Game %s] Created (mode %s)", hg.ID, hg.game.GetMode())
+	go hg.loop()
+	return hg, nil
+}
+
+// Get returns the hosted game with the given id
+func (gs *Games) Get(id string) (*HostedGame, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	hg, ok := gs.games[id]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return hg, nil
+}
+
+// Len returns the number of hosted games
+func (gs *Games) Len() int {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return len(gs.games)
+}
+
+// CloseAll removes every hosted game, ending their watchers
+func (gs *Games) CloseAll() {
+	gs.mu.Lock()
+	games := make([]*HostedGame, 0, len(gs.games))
+	for _, hg := range gs.games {
+		games = append(games, hg)
+	}
+	gs.mu.Unlock()
+
+	for _, hg := range games {
+		hg.Close()
+	}
+}
+
+// Game returns the underlying game
+func (hg *HostedGame) Game() *game.Game {
+	return hg.game
+}
+
+// State returns the game's current state message
+func (hg *HostedGame) State() protocol.StateMessage {
+	return protocol.NewStateMessage(hg.game).Data.(protocol.StateMessage)
+}
+
+// Apply performs a control command such as move_left or hard_drop
+func (hg *HostedGame) Apply(action protocol.MessageType) error {
+	// Undo can revive a practice game that topped out
+	if hg.game.IsGameOver() && action != protocol.MessageTypeUndo {
+		return ErrGameOver
+	}
+
+	switch action {
+	case protocol.MessageTypeMoveLeft:
+		hg.game.MoveLeft()
+	case protocol.MessageTypeMoveRight:
+		hg.game.MoveRight()
+	case protocol.MessageTypeMoveDown:
+		hg.game.MoveDown()
+	case protocol.MessageTypeRotate:
+		hg.game.Rotate()
+	case protocol.MessageTypeRotate180:
+		hg.game.Rotate180()
+	case protocol.MessageTypeHold:
+		hg.game.Hold()
+	case protocol.MessageTypeHardDrop:
+		hg.game.HardDrop()
+	case protocol.MessageTypeTogglePause:
+		hg.game.TogglePause()
+	case protocol.MessageTypePause:
+		hg.game.Pause()
+	case protocol.MessageTypeResume:
+		hg.game.Resume()
+	case protocol.MessageTypeUndo:
+		if hg.game.GetMode() != game.ModePractice {
+			return ErrInvalidAction
+		}
+		if !hg.game.Undo() {
+			return ErrNothingToUndo
+		}
+	default:
+		return ErrInvalidAction
+	}
+
+	hg.inputMu.Lock()
+	hg.lastInput = hg.games.server.Clock.Now()
+	hg.inputMu.Unlock()
+
+	hg.watchers.notify()
+	// Commands can resume the game or change the drop speed
+	select {
+	case hg.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Watch returns a channel that receives a value whenever the state changes;
+// call the returned function to stop watching
+func (hg *HostedGame) Watch() (<-chan struct{}, func()) {
+	return hg.watchers.add()
+}
+
+// Done returns a channel that is closed when the game is removed
+func (hg *HostedGame) Done() <-chan struct{} {
+	return hg.done
+}
+
+// Close removes the game from the registry and stops its loop
+func (hg *HostedGame) Close() {
+	hg.closeOnce.Do(func() {
+		hg.games.mu.Lock()
+		delete(hg.games.games, hg.ID)
+		hg.games.mu.Unlock()
+
+		close(hg.done)
+		log.Printf("[Game %s] Removed", hg.ID)
+	})
+}
+
+// loop applies gravity, waking when it is next due like a client's game loop,
+// and removes the game once it has had no commands for IdleTimeout
+func (hg *HostedGame) loop() {
+	clk := hg.games.server.Clock
+	timer := clk.NewTimer(tickInterval(hg.game))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-hg.done:
+			return
+		case <-hg.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+		case <-timer.C():
+			if hg.game.IsPlaying() {
+				seq := hg.game.GetSeq()
+				hg.game.Update()
+				if hg.game.GetSeq() != seq {
+					hg.watchers.notify()
+				}
+			}
+
+			hg.inputMu.Lock()
+			idle := clk.Since(hg.lastInput)
+			hg.inputMu.Unlock()
+			if timeout := hg.games.server.IdleTimeout; timeout > 0 && idle >= timeout {
+				log.Printf("[Game %s] Idle for %v, removing", hg.ID, idle.Round(time.Second))
+				hg.Close()
+				return
+			}
+		}
+		timer.Reset(tickInterval(hg.game))
+	}
+}
+
+// newGameID returns a random id that is hard to guess, since anyone who knows
+// a game's id can control it
+func newGameID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "game_" + hex.EncodeToString(b)
+}
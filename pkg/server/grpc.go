@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/protocol/tetrispb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StartGRPC serves the gRPC control API on addr alongside the WebSocket server
+// It blocks until the server stops, like Start
+func (s *Server) StartGRPC(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer()
+	tetrispb.RegisterTetrisServer(srv, &grpcService{server: s})
+	s.mu.Lock()
+	s.grpcServer = srv
+	s.mu.Unlock()
+
+	log.Printf("gRPC server starting on %s", addr)
+	return srv.Serve(lis)
+}
+
+// grpcService implements the gRPC control API on top of the hosted games
+type grpcService struct {
+	tetrispb.UnimplementedTetrisServer
+	server *Server
+}
+
+// NewGame starts a hosted game
+func (g *grpcService) NewGame(ctx context.Context, req *tetrispb.NewGameRequest) (*tetrispb.NewGameResponse, error) {
+	mode := game.Mode(req.GetMode())
+	if req.GetMode() != "" && !mode.IsValid() {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown game mode: %s", req.GetMode())
+	}
+
+	hg, err := g.server.games.Create(game.Config{Mode: mode, Seed: req.GetSeed()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &tetrispb.NewGameResponse{GameId: hg.ID, State: stateToProto(hg.State())}, nil
+}
+
+// Move shifts the current piece one cell
+func (g *grpcService) Move(ctx context.Context, req *tetrispb.MoveRequest) (*tetrispb.State, error) {
+	var action protocol.MessageType
+	switch req.GetDirection() {
+	case tetrispb.Direction_DIRECTION_LEFT:
+		action = protocol.MessageTypeMoveLeft
+	case tetrispb.Direction_DIRECTION_RIGHT:
+		action = protocol.MessageTypeMoveRight
+	case tetrispb.Direction_DIRECTION_DOWN:
+		action = protocol.MessageTypeMoveDown
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid direction: %v", req.GetDirection())
+	}
+	return g.apply(req.GetGameId(), action)
+}
+
+// Rotate turns the current piece clockwise
+func (g *grpcService) Rotate(ctx context.Context, req *tetrispb.RotateRequest) (*tetrispb.State, error) {
+	return g.apply(req.GetGameId(), protocol.MessageTypeRotate)
+}
+
+// Drop hard-drops the current piece
+func (g *grpcService) Drop(ctx context.Context, req *tetrispb.DropRequest) (*tetrispb.State, error) {
+	return g.apply(req.GetGameId(), protocol.MessageTypeHardDrop)
+}
+
+// StreamState sends the state now and after every change until the game
+// ends, is removed or the client goes away
+func (g *grpcService) StreamState(req *tetrispb.StreamStateRequest, stream tetrispb.Tetris_StreamStateServer) error {
+	hg, err := g.server.games.Get(req.GetGameId())
+	if err != nil {
+		return grpcError(err)
+	}
+
+	changed, stop := hg.Watch()
+	defer stop()
+
+	for {
+		if err := stream.Send(stateToProto(hg.State())); err != nil {
+			return err
+		}
+		if hg.Game().IsGameOver() {
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-hg.Done():
+			return status.Error(codes.NotFound, ErrGameNotFound.Error())
+		case <-changed:
+		}
+	}
+}
+
+// apply performs a command on a hosted game and returns the new state
+func (g *grpcService) apply(id string, action protocol.MessageType) (*tetrispb.State, error) {
+	hg, err := g.server.games.Get(id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := hg.Apply(action); err != nil {
+		return nil, grpcError(err)
+	}
+	return stateToProto(hg.State()), nil
+}
+
+// grpcError maps hosted game errors to gRPC status codes
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrGameNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrGameOver):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrInvalidAction):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrNothingToUndo):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrTooManyGames):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// stateToProto converts a state message to its protobuf form
+func stateToProto(st protocol.StateMessage) *tetrispb.State {
+	rows := make([]*tetrispb.Row, len(st.Board))
+	for y, cells := range st.Board {
+		rows[y] = &tetrispb.Row{Cells: cells}
+	}
+
+	var hold *tetrispb.Piece
+	if st.HoldPiece != nil {
+		hold = pieceToProto(*st.HoldPiece)
+	}
+
+	return &tetrispb.State{
+		Width:           int32(st.Width),
+		Height:          int32(st.Height),
+		Seed:            st.Seed,
+		Board:           rows,
+		CurrentPiece:    pieceToProto(st.CurrentPiece),
+		NextPiece:       pieceToProto(st.NextPiece),
+		HoldPiece:       hold,
+		CanHold:         st.CanHold,
+		State:           st.State,
+		Score:           int32(st.Score),
+		Level:           int32(st.Level),
+		Lines:           int32(st.Lines),
+		DropIntervalMs:  int32(st.DropInterval),
+		ElapsedMs:       int64(st.ElapsedMs),
+		PausedMs:        int64(st.PausedMs),
+		Mode:            st.Mode,
+		LinesRemaining:  int32(st.LinesRemaining),
+		TimeRemainingMs: int64(st.TimeRemainingMs),
+	}
+}
+
+// pieceToProto converts piece data to its protobuf form
+func pieceToProto(p protocol.PieceData) *tetrispb.Piece {
+	return &tetrispb.Piece{
+		Type:     int32(p.Type),
+		Color:    string(p.Color),
+		X:        int32(p.X),
+		Y:        int32(p.Y),
+		Rotation: int32(p.Rotation),
+	}
+}
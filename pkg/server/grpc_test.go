@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol/tetrispb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCTest serves the gRPC API of a server on a fake clock over an in-memory connection
+func newGRPCTest(t *testing.T) (tetrispb.TetrisClient, *clock.Fake) {
+	t.Helper()
+
+	clk := clock.NewFake(time.Unix(0, 0))
+	s := New(":0")
+	s.Clock = clk
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	tetrispb.RegisterTetrisServer(gs, &grpcService{server: s})
+	go gs.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.games.CloseAll()
+		gs.Stop()
+	})
+	return tetrispb.NewTetrisClient(conn), clk
+}
+
+// TestGRPCControl verifies that moves and drops apply to the hosted game
+func TestGRPCControl(t *testing.T) {
+	client, _ := newGRPCTest(t)
+	ctx := context.Background()
+
+	created, err := client.NewGame(ctx, &tetrispb.NewGameRequest{Seed: 42})
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	id := created.GetGameId()
+	x := created.GetState().GetCurrentPiece().GetX()
+
+	st, err := client.Move(ctx, &tetrispb.MoveRequest{GameId: id, Direction: tetrispb.Direction_DIRECTION_LEFT})
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if got := st.GetCurrentPiece().GetX(); got != x-1 {
+		t.Errorf("X after move left = %d, want %d", got, x-1)
+	}
+
+	st, err = client.Drop(ctx, &tetrispb.DropRequest{GameId: id})
+	if err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+	if st.GetScore() == 0 {
+		t.Error("score = 0 after a hard drop")
+	}
+	if st.GetSeed() != 42 {
+		t.Errorf("seed = %d, want 42", st.GetSeed())
+	}
+
+	_, err = client.Rotate(ctx, &tetrispb.RotateRequest{GameId: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Rotate() on an unknown game: code = %v, want NotFound", status.Code(err))
+	}
+	_, err = client.Move(ctx, &tetrispb.MoveRequest{GameId: id})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Move() without a direction: code = %v, want InvalidArgument", status.Code(err))
+	}
+}
+
+// TestGRPCStreamState verifies that the stream delivers a state for each gravity drop
+func TestGRPCStreamState(t *testing.T) {
+	client, clk := newGRPCTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	created, err := client.NewGame(ctx, &tetrispb.NewGameRequest{Seed: 1})
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	stream, err := client.StreamState(ctx, &tetrispb.StreamStateRequest{GameId: created.GetGameId()})
+	if err != nil {
+		t.Fatalf("StreamState() error = %v", err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	y := first.GetCurrentPiece().GetY()
+
+	// The game loop's timer is pending; a gravity interval later the piece falls
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	next, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if got := next.GetCurrentPiece().GetY(); got != y+1 {
+		t.Errorf("Y after one drop = %d, want %d", got, y+1)
+	}
+}
+
+// TestShutdownStopsGRPC verifies Shutdown stops a gRPC server started in a
+// goroutine of its own, as the server command starts it
+func TestShutdownStopsGRPC(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	served := make(chan error, 1)
+	go func() { served <- s.StartGRPC("127.0.0.1:0") }()
+
+	for started := false; !started; time.Sleep(time.Millisecond) {
+		s.mu.RLock()
+		started = s.grpcServer != nil
+		s.mu.RUnlock()
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("StartGRPC() = %v, want nil after Shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartGRPC() still serving after Shutdown")
+	}
+}
+
+// TestGRPCGameLimit verifies NewGame is refused once the server hosts
+// MaxHostedGames, and allowed again after one is removed
+func TestGRPCGameLimit(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.MaxHostedGames = 1
+	t.Cleanup(s.games.CloseAll)
+	svc := &grpcService{server: s}
+	ctx := context.Background()
+
+	first, err := svc.NewGame(ctx, &tetrispb.NewGameRequest{Seed: 1})
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if _, err := svc.NewGame(ctx, &tetrispb.NewGameRequest{Seed: 2}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("NewGame() over the limit error = %v, want ResourceExhausted", err)
+	}
+
+	hg, err := s.games.Get(first.GetGameId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hg.Close()
+	if _, err := svc.NewGame(ctx, &tetrispb.NewGameRequest{Seed: 3}); err != nil {
+		t.Errorf("NewGame() after a game was removed error = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// hubProbeTimeout is how long the hub may take to answer a probe before the
+// health checks report it wedged
+const hubProbeTimeout = 2 * time.Second
+
+// probeHub reports whether the hub goroutine is answering, which it stops
+// doing when it is wedged and clients can no longer connect or leave, or
+// once Shutdown stopped it
+func (s *Server) probeHub() bool {
+	timer := s.Clock.NewTimer(hubProbeTimeout)
+	defer timer.Stop()
+
+	done := make(chan struct{})
+	select {
+	case s.hubProbe <- done:
+	case <-timer.C():
+		return false
+	case <-s.done:
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	case <-timer.C():
+		return false
+	}
+}
+
+// handleLiveness handles GET /healthz: 503 once the hub is wedged, so the
+// process should be restarted
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	report := map[string]interface{}{
+		"status":     "ok",
+		"uptime_ms":  time.Since(s.startedAt).Milliseconds(),
+		"goroutines": runtime.NumGoroutine(),
+	}
+	status := http.StatusOK
+	if !s.probeHub() {
+		report["status"] = "wedged"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// handleReadiness handles GET /readyz: 503 while the hub is wedged, the
+// server is full or draining, so no new players should be sent to it
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	clientCount := len(s.clients)
+	admitted := s.admitted
+	draining := s.draining
+	queued, deepest := 0, 0
+	for _, client := range s.clients {
+		n := len(client.send)
+		queued += n
+		deepest = max(deepest, n)
+	}
+	s.mu.RUnlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	report := map[string]interface{}{
+		"status":      "ok",
+		"uptime_ms":   time.Since(s.startedAt).Milliseconds(),
+		"goroutines":  runtime.NumGoroutine(),
+		"clients":     clientCount,
+		"max_clients": s.MaxClients,
+		"memory": map[string]interface{}{
+			"heap_alloc": mem.HeapAlloc,
+			"heap_sys":   mem.HeapSys,
+			"sys":        mem.Sys,
+			"num_gc":     mem.NumGC,
+		},
+		// Messages waiting in the clients' send queues
+		"send_queues": map[string]interface{}{
+			"queued":   queued,
+			"deepest":  deepest,
+			"capacity": sendQueueSize,
+		},
+		"errors":   s.httpErrorCounts(),
+		"draining": draining,
+	}
+
+	status := http.StatusOK
+	if s.MaxClients > 0 {
+		report["utilization"] = float64(admitted) / float64(s.MaxClients)
+		if admitted >= s.MaxClients {
+			report["status"] = "full"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if draining {
+		report["status"] = "draining"
+		status = http.StatusServiceUnavailable
+	}
+	if !s.probeHub() {
+		report["status"] = "wedged"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// countErrors wraps the HTTP handler to count the error responses of each
+// endpoint, reported by /readyz
+func (s *Server) countErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status < http.StatusBadRequest {
+			return
+		}
+
+		// The mux sets the pattern it matched on the request
+		endpoint := r.Pattern
+		if endpoint == "" {
+			endpoint = r.URL.Path
+		}
+		s.httpErrorsMu.Lock()
+		s.httpErrors[endpoint]++
+		s.httpErrorsMu.Unlock()
+	})
+}
+
+// httpErrorCounts returns a copy of the error responses counted per endpoint
+func (s *Server) httpErrorCounts() map[string]int {
+	s.httpErrorsMu.Lock()
+	defer s.httpErrorsMu.Unlock()
+
+	counts := make(map[string]int, len(s.httpErrors))
+	for endpoint, n := range s.httpErrors {
+		counts[endpoint] = n
+	}
+	return counts
+}
+
+// statusRecorder remembers the status code written through it; WebSocket
+// upgrades and event streams still reach the hijacker and flusher beneath
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+)
+
+// TestHealthChecks verifies liveness and readiness while the hub runs, that
+// a full server is not ready and that error responses are counted per endpoint
+func TestHealthChecks(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	go s.run()
+	ts := httptest.NewServer(s.countErrors(s.routes()))
+	t.Cleanup(func() {
+		ts.Close()
+		s.games.CloseAll()
+	})
+
+	var live map[string]interface{}
+	if code := doJSON(t, http.MethodGet, ts.URL+"/healthz", "", &live); code != http.StatusOK || live["status"] != "ok" {
+		t.Errorf("GET /healthz = %d %v, want 200 ok", code, live["status"])
+	}
+
+	var errResp map[string]interface{}
+	doJSON(t, http.MethodGet, ts.URL+"/api/games/nope/state", "", &errResp)
+	var ready struct {
+		Status     string         `json:"status"`
+		SendQueues map[string]int `json:"send_queues"`
+		Errors     map[string]int `json:"errors"`
+	}
+	if code := doJSON(t, http.MethodGet, ts.URL+"/readyz", "", &ready); code != http.StatusOK || ready.Status != "ok" {
+		t.Errorf("GET /readyz = %d %s, want 200 ok", code, ready.Status)
+	}
+	if ready.SendQueues["capacity"] != sendQueueSize {
+		t.Errorf("send queue capacity = %d, want %d", ready.SendQueues["capacity"], sendQueueSize)
+	}
+	if got := ready.Errors["GET /api/games/{id}/state"]; got != 1 {
+		t.Errorf("errors of GET /api/games/{id}/state = %d, want 1", got)
+	}
+
+	s.MaxClients = 1
+	s.mu.Lock()
+	s.admitted = 1
+	s.mu.Unlock()
+	if code := doJSON(t, http.MethodGet, ts.URL+"/readyz", "", &ready); code != http.StatusServiceUnavailable || ready.Status != "full" {
+		t.Errorf("GET /readyz when full = %d %s, want 503 full", code, ready.Status)
+	}
+}
+
+// TestHealthWedgedHub verifies both checks fail once the hub stops answering
+func TestHealthWedgedHub(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+
+	// The hub is never started, as if stuck
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			close(done)
+		}()
+		clk.BlockUntil(1)
+		clk.Advance(hubProbeTimeout)
+		<-done
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("GET %s with a wedged hub = %d, want 503", path, rec.Code)
+		}
+	}
+}
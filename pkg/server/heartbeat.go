@@ -0,0 +1,21 @@
+package server
+
+import "time"
+
+// markAlive resets the client's heartbeat; any message or pong it sends
+// shows it is still there
+func (c *Client) markAlive() {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+	c.lastSeen = c.server.Clock.Now()
+}
+
+// heartbeatExpired returns how long the client has sent nothing, and whether
+// that is PongTimeout or more; writePump checks it before every ping
+func (c *Client) heartbeatExpired() (time.Duration, bool) {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+
+	silent := c.server.Clock.Since(c.lastSeen)
+	return silent, c.server.PongTimeout > 0 && silent >= c.server.PongTimeout
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// heartbeatConn is a test client that reports the pings it receives and
+// answers them only if pong is set
+type heartbeatConn struct {
+	conn   *websocket.Conn
+	pings  chan struct{}
+	closed chan error // The error that ended the connection
+}
+
+// dialHeartbeat connects a client to a server on a fake clock, returning it
+// once its writePump runs
+func dialHeartbeat(t *testing.T, pong bool) (*Server, *clock.Fake, *heartbeatConn) {
+	t.Helper()
+
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	s.ShutdownGrace = 0
+	s.PingInterval = 30 * time.Second
+	s.PongTimeout = 60 * time.Second
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		ts.Close()
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	hc := &heartbeatConn{conn: conn, pings: make(chan struct{}, 16), closed: make(chan error, 1)}
+	conn.SetPingHandler(func(data string) error {
+		if pong {
+			conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		}
+		hc.pings <- struct{}{}
+		return nil
+	})
+	// The first message comes from writePump, which owns the ping ticker
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("read first message: %v", err)
+	}
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				hc.closed <- err
+				return
+			}
+		}
+	}()
+	return s, clk, hc
+}
+
+// ping advances the clock to the next ping and waits for it to arrive
+func (hc *heartbeatConn) ping(t *testing.T, clk *clock.Fake, interval time.Duration) {
+	t.Helper()
+
+	clk.Advance(interval)
+	select {
+	case <-hc.pings:
+	case err := <-hc.closed:
+		t.Fatalf("connection ended waiting for a ping: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a ping")
+	}
+}
+
+// TestHeartbeatTimeout verifies a client that stops answering pings is
+// disconnected with the timeout close code once PongTimeout passes
+func TestHeartbeatTimeout(t *testing.T) {
+	s, clk, hc := dialHeartbeat(t, false)
+
+	hc.ping(t, clk, s.PingInterval)
+	clk.Advance(s.PingInterval)
+	select {
+	case err := <-hc.closed:
+		if !websocket.IsCloseError(err, protocol.CloseTimeout) {
+			t.Errorf("connection ended with %v, want close code %d", err, protocol.CloseTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("not disconnected after PongTimeout without pongs")
+	}
+}
+
+// TestHeartbeatReset verifies pongs and messages each reset the heartbeat,
+// keeping the connection open well past PongTimeout
+func TestHeartbeatReset(t *testing.T) {
+	tests := []struct {
+		name  string
+		pong  bool
+		input bool // Send a control message after each ping instead
+	}{
+		{"pongs", true, false},
+		{"messages", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clk, hc := dialHeartbeat(t, tt.pong)
+
+			for i := 0; i < 4; i++ {
+				hc.ping(t, clk, s.PingInterval)
+				if tt.input {
+					if err := hc.conn.WriteJSON(protocol.ControlMessage{Type: protocol.MessageTypePong}); err != nil {
+						t.Fatalf("send pong message: %v", err)
+					}
+				}
+				// The server handles what the client sent on its own goroutine
+				waitFor(t, "the heartbeat to reset", func() bool {
+					silent, _ := onlyClient(s).heartbeatExpired()
+					return silent == 0
+				})
+			}
+
+			select {
+			case err := <-hc.closed:
+				t.Fatalf("disconnected after %v with a reset heartbeat: %v", 4*s.PingInterval, err)
+			default:
+			}
+		})
+	}
+}
+
+// onlyClient returns the one client connected to s
+func onlyClient(s *Server) *Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.clients {
+		return c
+	}
+	return nil
+}
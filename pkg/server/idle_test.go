@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestCheckIdle verifies a client without input is paused after IdlePause and
+// disconnected with an idle_timeout message after IdleTimeout, and that input
+// starts both over
+func TestCheckIdle(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	s.IdlePause = time.Minute
+	s.IdleTimeout = 3 * time.Minute
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s, lastInput: clk.Now(),
+		closeReq: make(chan closeRequest, 1)}
+	c.session = s.sessions.Create(c.newGame(), clk.Now())
+
+	tests := []struct {
+		advance    time.Duration
+		input      bool // The client sends a command before the check
+		wantPaused bool
+		wantClosed bool
+	}{
+		{59 * time.Second, false, false, false},
+		{time.Second, false, true, false},
+		{time.Minute, true, false, false}, // The player resumes
+		{time.Minute, false, true, false},
+		{time.Minute, false, true, false},
+		{time.Minute, false, true, true},
+	}
+	for i, tt := range tests {
+		clk.Advance(tt.advance)
+		if tt.input {
+			c.recordInput()
+			c.Game().Resume()
+		}
+		closed := c.checkIdle()
+		if closed != tt.wantClosed {
+			t.Errorf("step %d: checkIdle() = %v, want %v", i, closed, tt.wantClosed)
+		}
+		if got := c.Game().IsPaused(); got != tt.wantPaused {
+			t.Errorf("step %d: paused = %v, want %v", i, got, tt.wantPaused)
+		}
+	}
+
+	var idle protocol.IdleTimeoutMessage
+	if !queue.Drain(t, c.send).Of(protocol.MessageTypeIdleTimeout).Last(t, &idle) {
+		t.Fatal("no idle_timeout message before the disconnect")
+	}
+	if want := int((3 * time.Minute).Milliseconds()); idle.IdleMs != want {
+		t.Errorf("idle_timeout idle_ms = %d, want %d", idle.IdleMs, want)
+	}
+	select {
+	case req := <-c.closeReq:
+		if req.code != protocol.CloseIdleTimeout {
+			t.Errorf("close code = %d, want %d", req.code, protocol.CloseIdleTimeout)
+		}
+	default:
+		t.Error("not disconnected after IdleTimeout")
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// maxLeaderboardEntries is the number of results kept on the leaderboard
+const maxLeaderboardEntries = 10
+
+// Leaderboard keeps the best finished games in memory
+type Leaderboard struct {
+	entries []protocol.ScoreEntry
+	mu      sync.RWMutex
+}
+
+// NewLeaderboard creates an empty leaderboard
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		entries: make([]protocol.ScoreEntry, 0, maxLeaderboardEntries),
+	}
+}
+
+// Add records a finished game, keeping only the highest scores
+func (l *Leaderboard) Add(entry protocol.ScoreEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, entry)
+	sort.SliceStable(l.entries, func(i, j int) bool {
+		return l.entries[i].Score > l.entries[j].Score
+	})
+
+	if len(l.entries) > maxLeaderboardEntries {
+		l.entries = l.entries[:maxLeaderboardEntries]
+	}
+}
+
+// Top returns up to n of the highest scores, best first
+// If ruleset is not empty only entries played under that ruleset are returned
+func (l *Leaderboard) Top(n int, ruleset string) []protocol.ScoreEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]protocol.ScoreEntry, 0, n)
+	for _, entry := range l.entries {
+		if len(result) >= n {
+			break
+		}
+		if ruleset != "" && entry.Ruleset != ruleset {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
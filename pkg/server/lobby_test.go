@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestLobby verifies the lobby endpoint counts the connected players and
+// lists the top scores, filtered by ruleset when one is asked for
+func TestLobby(t *testing.T) {
+	s := New(":0")
+	s.clients["c1"] = &Client{id: "c1", server: s}
+	s.clients["c2"] = &Client{id: "c2", server: s}
+	s.leaderboard.Add(protocol.ScoreEntry{Name: "ann", Score: 300, Ruleset: "classic"})
+	s.leaderboard.Add(protocol.ScoreEntry{Name: "bob", Score: 500, Ruleset: "modern"})
+	s.leaderboard.Add(protocol.ScoreEntry{Name: "cat", Score: 100, Ruleset: "classic"})
+	ts := httptest.NewServer(s.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		query string
+		want  []string // Names of the top scores, best first
+	}{
+		{"", []string{"bob", "ann", "cat"}},
+		{"?ruleset=classic", []string{"ann", "cat"}},
+		{"?ruleset=modern", []string{"bob"}},
+		{"?ruleset=sega", nil},
+	}
+	for _, tt := range tests {
+		var lobby protocol.LobbyInfo
+		if code := doJSON(t, http.MethodGet, ts.URL+"/api/lobby"+tt.query, "", &lobby); code != http.StatusOK {
+			t.Errorf("GET /api/lobby%s = %d, want 200", tt.query, code)
+			continue
+		}
+		if lobby.ActivePlayers != 2 {
+			t.Errorf("GET /api/lobby%s active players = %d, want 2", tt.query, lobby.ActivePlayers)
+		}
+		var names []string
+		for _, entry := range lobby.TopScores {
+			names = append(names, entry.Name)
+		}
+		if len(names) != len(tt.want) {
+			t.Errorf("GET /api/lobby%s top scores = %v, want %v", tt.query, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("GET /api/lobby%s top scores = %v, want %v", tt.query, names, tt.want)
+				break
+			}
+		}
+	}
+
+	resp, err := http.Post(ts.URL+"/api/lobby", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/lobby = %d, want 405", resp.StatusCode)
+	}
+}
+
+// TestLeaderboardTop verifies Top keeps the best scores first and counts
+// only entries of the ruleset asked for
+func TestLeaderboardTop(t *testing.T) {
+	l := NewLeaderboard()
+	for i := 1; i <= maxLeaderboardEntries+2; i++ {
+		ruleset := "classic"
+		if i%2 == 0 {
+			ruleset = "modern"
+		}
+		l.Add(protocol.ScoreEntry{Score: i * 100, Ruleset: ruleset})
+	}
+
+	tests := []struct {
+		n         int
+		ruleset   string
+		wantLen   int
+		wantFirst int
+	}{
+		{3, "", 3, 1200},
+		{maxLeaderboardEntries + 5, "", maxLeaderboardEntries, 1200},
+		{3, "classic", 3, 1100},
+		{maxLeaderboardEntries, "modern", 5, 1200},
+		{3, "sega", 0, 0},
+	}
+	for _, tt := range tests {
+		got := l.Top(tt.n, tt.ruleset)
+		if len(got) != tt.wantLen {
+			t.Errorf("Top(%d, %q) returned %d entries, want %d", tt.n, tt.ruleset, len(got), tt.wantLen)
+			continue
+		}
+		if len(got) > 0 && got[0].Score != tt.wantFirst {
+			t.Errorf("Top(%d, %q)[0].Score = %d, want %d", tt.n, tt.ruleset, got[0].Score, tt.wantFirst)
+		}
+		for i, entry := range got {
+			if tt.ruleset != "" && entry.Ruleset != tt.ruleset {
+				t.Errorf("Top(%d, %q)[%d] has ruleset %q", tt.n, tt.ruleset, i, entry.Ruleset)
+			}
+			if i > 0 && entry.Score > got[i-1].Score {
+				t.Errorf("Top(%d, %q) not sorted: %d after %d", tt.n, tt.ruleset, entry.Score, got[i-1].Score)
+			}
+		}
+	}
+}
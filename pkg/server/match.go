@@ -0,0 +1,309 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// AutoMatch is the match id that asks the server to pair the player with an
+// opponent of similar rating
+const AutoMatch = "auto"
+
+// autoMatchSize is the number of players in a match made by matchmaking
+const autoMatchSize = 2
+
+// maxHandicapSeats is the most seats a match creator may set handicaps for
+const maxHandicapSeats = 16
+
+// Matchmaking pairs players up to matchGap rating points apart, widening the
+// gap by matchGapStep for every matchGapInterval the first player has waited
+const (
+	matchGap         = 200
+	matchGapStep     = 100
+	matchGapInterval = 10 * time.Second
+)
+
+// Match groups clients that play the same piece sequence, so a race between
+// them is decided by skill rather than by luck of the draw
+// Once every player has finished, the registered players are rated by score
+type Match struct {
+	ID   string
+	Seed int64
+	Auto bool // Made by matchmaking; closed to others once full
+
+	// Handicaps of the players by seat, chosen by the player who created the
+	// match; seats past the end play without one
+	Handicaps []game.Handicap
+
+	created time.Time
+
+	members  []matchMember          // Connected players, in the order they joined
+	nextSeat int                    // Seat of the next player to join
+	results  map[string]matchResult // First finished game of each player, by client id
+	rated    bool                   // Results were reported; later games are unrated
+
+	pauseVote  *pauseVote // Open vote to pause the match; nil without one
+	votedPause bool       // A vote paused every game, until a player resumes them
+}
+
+// matchMember is a player connected to a match
+type matchMember struct {
+	clientID string
+	seat     int    // Set by Join; numbers the players of the match in the order they joined
+	playerID string // Registered player; empty for guests
+	name     string
+	rating   int // Zero for guests
+}
+
+// matchResult is a player's score in their first game of a match
+type matchResult struct {
+	matchMember
+	score int
+}
+
+// Matches tracks the matches clients have joined
+type Matches struct {
+	matches map[string]*Match
+	autoSeq int // Numbers the matches made by matchmaking
+	mu      sync.Mutex
+}
+
+// NewMatches creates an empty match registry
+func NewMatches() *Matches {
+	return &Matches{
+		matches: make(map[string]*Match),
+	}
+}
+
+// Join adds a player to the match with the given id, creating it with a
+// fresh seed if it does not exist; a non-zero seed and the handicaps set those
+// of a new match
+// Joining AutoMatch picks the open matchmaking match whose waiting player is
+// closest in rating, or opens a new one if nobody is close enough
+func (m *Matches) Join(id string, seed int64, handicaps []game.Handicap, member matchMember, now time.Time) *Match {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var match *Match
+	auto := id == AutoMatch
+	if auto {
+		match = m.findOpenLocked(member, now)
+		if match == nil {
+			m.autoSeq++
+			id = fmt.Sprintf("auto-%d", m.autoSeq)
+		}
+	} else {
+		match = m.matches[id]
+	}
+
+	if match == nil {
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		match = &Match{ID: id, Seed: seed, Auto: auto, Handicaps: handicaps, created: now, results: make(map[string]matchResult)}
+		m.matches[id] = match
+	}
+	member.seat = match.nextSeat
+	match.nextSeat++
+	match.members = append(match.members, member)
+	return match
+}
+
+// findOpenLocked returns the matchmaking match with room for member whose
+// players are closest to member's rating, or nil if none is close enough
+// Must be called with mu held
+func (m *Matches) findOpenLocked(member matchMember, now time.Time) *Match {
+	var best *Match
+	bestGap := 0
+	for _, match := range m.matches {
+		if !match.Auto || len(match.members) >= autoMatchSize || len(match.results) > 0 {
+			continue
+		}
+		gap := abs(effectiveRating(match.members[0].rating) - effectiveRating(member.rating))
+		if gap > matchGap+matchGapStep*int(now.Sub(match.created)/matchGapInterval) {
+			continue
+		}
+		if best == nil || gap < bestGap || (gap == bestGap && match.ID < best.ID) {
+			best, bestGap = match, gap
+		}
+	}
+	return best
+}
+
+// effectiveRating returns the rating used for matchmaking; guests count as new players
+func effectiveRating(rating int) int {
+	if rating == 0 {
+		return accounts.DefaultRating
+	}
+	return rating
+}
+
+// Leave removes a player from a match, forgetting the match once it is empty
+// Returns the results if the departure leaves every remaining player finished
+func (m *Matches) Leave(match *Match, clientID string) []matchResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, member := range match.members {
+		if member.clientID == clientID {
+			match.members = append(match.members[:i], match.members[i+1:]...)
+			break
+		}
+	}
+	if len(match.members) == 0 {
+		delete(m.matches, match.ID)
+	}
+	return match.completeLocked()
+}
+
+// Finish records the score of a player's game; only the first game of each
+// player counts
+// Returns the results once every player in the match has finished
+func (m *Matches) Finish(match *Match, clientID string, score int) []matchResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, done := match.results[clientID]; done {
+		return nil
+	}
+	for _, member := range match.members {
+		if member.clientID == clientID {
+			match.results[clientID] = matchResult{matchMember: member, score: score}
+			break
+		}
+	}
+	return match.completeLocked()
+}
+
+// completeLocked returns the results, once, when at least two players have
+// finished and no connected player is still playing
+// Players who leave before finishing are not rated
+// Must be called with the Matches lock held
+func (match *Match) completeLocked() []matchResult {
+	if match.rated || len(match.results) < 2 {
+		return nil
+	}
+	for _, member := range match.members {
+		if _, done := match.results[member.clientID]; !done {
+			return nil
+		}
+	}
+
+	match.rated = true
+	results := make([]matchResult, 0, len(match.results))
+	for _, r := range match.results {
+		results = append(results, r)
+	}
+	return results
+}
+
+// SetName changes the name a player is shown under in the match roster
+func (m *Matches) SetName(match *Match, clientID, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range match.members {
+		if match.members[i].clientID == clientID {
+			match.members[i].name = name
+		}
+	}
+}
+
+// Seat returns the seat of a player in a match, or -1 if they left
+func (m *Matches) Seat(match *Match, clientID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, member := range match.members {
+		if member.clientID == clientID {
+			return member.seat
+		}
+	}
+	return -1
+}
+
+// clientIDs returns the client ids of the players connected to a match
+func (m *Matches) clientIDs(match *Match) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, len(match.members))
+	for i, member := range match.members {
+		ids[i] = member.clientID
+	}
+	return ids
+}
+
+// Players returns the number of players in a match
+func (m *Matches) Players(match *Match) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(match.members)
+}
+
+// Roster returns the players in a match in the order they joined
+func (m *Matches) Roster(match *Match) []protocol.MatchPlayer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	roster := make([]protocol.MatchPlayer, len(match.members))
+	for i, member := range match.members {
+		roster[i] = protocol.MatchPlayer{Seat: member.seat, Name: member.name, Rating: member.rating,
+			Handicap: match.Handicap(member.seat).String()}
+	}
+	return roster
+}
+
+// Handicap returns the handicap of the player in a seat, the zero Handicap
+// for none
+func (match *Match) Handicap(seat int) game.Handicap {
+	if seat < 0 || seat >= len(match.Handicaps) {
+		return game.Handicap{}
+	}
+	return match.Handicaps[seat]
+}
+
+// handicapped reports whether any player of the match has a handicap
+func (match *Match) handicapped() bool {
+	for _, h := range match.Handicaps {
+		if !h.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHandicaps parses the handicaps of a match's seats, separated by "/" in
+// seat order, e.g. "/garbage:4,gravity:0.75" handicaps the second player only
+func parseHandicaps(s string) ([]game.Handicap, error) {
+	if s == "" {
+		return nil, nil
+	}
+	specs := strings.Split(s, "/")
+	if len(specs) > maxHandicapSeats {
+		return nil, fmt.Errorf("handicaps for %d seats, at most %d", len(specs), maxHandicapSeats)
+	}
+	handicaps := make([]game.Handicap, len(specs))
+	for seat, spec := range specs {
+		h, err := game.ParseHandicap(spec)
+		if err != nil {
+			return nil, fmt.Errorf("seat %d: %w", seat, err)
+		}
+		handicaps[seat] = h
+	}
+	return handicaps, nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
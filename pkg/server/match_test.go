@@ -0,0 +1,199 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestMatchmaking verifies that auto matches pair players of similar rating
+func TestMatchmaking(t *testing.T) {
+	m := NewMatches()
+	now := time.Unix(0, 0)
+
+	weak := m.Join(AutoMatch, 0, nil, matchMember{clientID: "a", rating: 1200}, now)
+	strong := m.Join(AutoMatch, 0, nil, matchMember{clientID: "b", rating: 1800}, now)
+	if weak == strong {
+		t.Fatal("players 600 points apart were paired straight away")
+	}
+
+	if got := m.Join(AutoMatch, 0, nil, matchMember{clientID: "c", rating: 1750}, now); got != strong {
+		t.Errorf("1750 joined %s, want %s", got.ID, strong.ID)
+	}
+	// Guests are rated as new players, too far from the waiting player at first
+	guest := m.Join(AutoMatch, 0, nil, matchMember{clientID: "d"}, now)
+	if guest == weak || guest == strong {
+		t.Errorf("guest joined %s straight away", guest.ID)
+	}
+
+	// The allowed gap widens while players wait
+	later := now.Add(time.Minute)
+	if got := m.Join(AutoMatch, 0, nil, matchMember{clientID: "e", rating: 1000}, later); got != weak {
+		t.Errorf("1000 joined %s after a minute, want %s", got.ID, weak.ID)
+	}
+	if got := m.Join(AutoMatch, 0, nil, matchMember{clientID: "f"}, later); got != guest {
+		t.Errorf("guest joined %s, want the waiting guest's %s", got.ID, guest.ID)
+	}
+}
+
+// TestMatchCompletion verifies that results are reported once, after every player finished
+func TestMatchCompletion(t *testing.T) {
+	m := NewMatches()
+	now := time.Unix(0, 0)
+	match := m.Join("final", 42, nil, matchMember{clientID: "a"}, now)
+	m.Join("final", 0, nil, matchMember{clientID: "b"}, now)
+	m.Join("final", 0, nil, matchMember{clientID: "c"}, now)
+
+	if got := m.Finish(match, "a", 500); got != nil {
+		t.Errorf("results after one player finished = %v, want none", got)
+	}
+	if got := m.Finish(match, "a", 900); got != nil {
+		t.Errorf("results after a second game = %v, want none", got)
+	}
+	if got := m.Finish(match, "b", 300); got != nil {
+		t.Errorf("results while c plays = %v, want none", got)
+	}
+
+	// c gives up; the finished players are reported
+	results := m.Leave(match, "c")
+	if len(results) != 2 {
+		t.Fatalf("results after c left = %v, want a and b", results)
+	}
+	for _, r := range results {
+		if r.clientID == "a" && r.score != 500 {
+			t.Errorf("a's score = %d, want the first game's 500", r.score)
+		}
+	}
+	if got := m.Leave(match, "b"); got != nil {
+		t.Errorf("results reported twice: %v", got)
+	}
+}
+
+// TestMatchSeats verifies that seats number players in join order and are not reused
+func TestMatchSeats(t *testing.T) {
+	m := NewMatches()
+	now := time.Unix(0, 0)
+	match := m.Join("final", 0, nil, matchMember{clientID: "a"}, now)
+	m.Join("final", 0, nil, matchMember{clientID: "b"}, now)
+	m.Leave(match, "a")
+	m.Join("final", 0, nil, matchMember{clientID: "c"}, now)
+
+	tests := []struct {
+		clientID string
+		want     int
+	}{
+		{"a", -1},
+		{"b", 1},
+		{"c", 2},
+	}
+	for _, tt := range tests {
+		if got := m.Seat(match, tt.clientID); got != tt.want {
+			t.Errorf("Seat(%s) = %d, want %d", tt.clientID, got, tt.want)
+		}
+	}
+	if roster := m.Roster(match); len(roster) != 2 || roster[0].Seat != 1 || roster[1].Seat != 2 {
+		t.Errorf("Roster = %+v, want seats 1 and 2", roster)
+	}
+}
+
+// TestMatchHandicaps verifies the handicaps chosen by a match's creator are
+// given to each seat's game and listed in the roster
+func TestMatchHandicaps(t *testing.T) {
+	handicaps, err := parseHandicaps("/garbage:4,preview:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseHandicaps("garbage:40"); err == nil {
+		t.Error("parseHandicaps() accepted 40 rows of garbage")
+	}
+
+	s := New(":0")
+	now := time.Unix(0, 0)
+	match := s.matches.Join("final", 0, handicaps, matchMember{clientID: "a"}, now)
+	s.matches.Join("final", 0, nil, matchMember{clientID: "b"}, now)
+	s.matches.Join("final", 0, []game.Handicap{{GarbageRows: 8}}, matchMember{clientID: "c"}, now)
+	if !match.handicapped() {
+		t.Error("match with a handicap not handicapped")
+	}
+
+	tests := []struct {
+		seat         int
+		wantHeight   int
+		wantHandicap string
+		wantPreview  bool
+	}{
+		{0, 0, "", true},
+		{1, 4, "garbage:4,preview:0", false},
+		{2, 0, "", true}, // The last to join cannot change the handicaps
+	}
+	roster := s.matches.Roster(match)
+	for _, tt := range tests {
+		c := &Client{id: "c", server: s, mode: game.ModeMarathon, match: match, seat: tt.seat}
+		g := c.newGame()
+		if got := g.GetStackHeight(); got != tt.wantHeight {
+			t.Errorf("seat %d stack height = %d, want %d", tt.seat, got, tt.wantHeight)
+		}
+		state := protocol.NewStateMessage(g).Data.(protocol.StateMessage)
+		if shown := !state.PreviewHidden && state.NextPiece.Color != ""; shown != tt.wantPreview {
+			t.Errorf("seat %d next piece shown = %v, want %v", tt.seat, shown, tt.wantPreview)
+		}
+		if got := roster[tt.seat].Handicap; got != tt.wantHandicap {
+			t.Errorf("seat %d roster handicap = %q, want %q", tt.seat, got, tt.wantHandicap)
+		}
+	}
+}
+
+// TestMatchJoin verifies joining an unknown match creates it with the given
+// seed, a full auto match takes nobody else, and an emptied match is forgotten
+func TestMatchJoin(t *testing.T) {
+	m := NewMatches()
+	now := time.Unix(0, 0)
+
+	match := m.Join("final", 42, nil, matchMember{clientID: "a"}, now)
+	if match.ID != "final" || match.Seed != 42 {
+		t.Errorf("Join(final, 42) = %s seed %d, want final seed 42", match.ID, match.Seed)
+	}
+	if got := m.Join("final", 7, nil, matchMember{clientID: "b"}, now); got != match || got.Seed != 42 {
+		t.Errorf("second player joined %s seed %d, want final seed 42", got.ID, got.Seed)
+	}
+	if fresh := m.Join("semi", 0, nil, matchMember{clientID: "c"}, now); fresh.Seed == 0 {
+		t.Error("match joined without a seed got seed 0, want a fresh one")
+	}
+
+	full := m.Join(AutoMatch, 0, nil, matchMember{clientID: "d"}, now)
+	if got := m.Join(AutoMatch, 0, nil, matchMember{clientID: "e"}, now); got != full {
+		t.Fatalf("second auto player joined %s, want %s", got.ID, full.ID)
+	}
+	if got := m.Join(AutoMatch, 0, nil, matchMember{clientID: "f"}, now); got == full {
+		t.Errorf("third auto player joined the full match %s", full.ID)
+	}
+
+	m.Leave(match, "a")
+	if got := m.Players(match); got != 1 {
+		t.Errorf("Players() after a left = %d, want 1", got)
+	}
+	m.Leave(match, "b")
+	if got := m.Join("final", 9, nil, matchMember{clientID: "g"}, now); got == match || got.Seed != 9 {
+		t.Errorf("joining the emptied match got seed %d, want a new match with seed 9", got.Seed)
+	}
+}
+
+// TestMatchSeed verifies every player of a match gets the same pieces
+func TestMatchSeed(t *testing.T) {
+	s := New(":0")
+	now := time.Unix(0, 0)
+	var pieces [][]piece.Type
+	for _, id := range []string{"a", "b"} {
+		match := s.matches.Join("final", 0, nil, matchMember{clientID: id}, now)
+		c := &Client{id: id, server: s, mode: game.ModeMarathon, match: match, seat: s.matches.Seat(match, id)}
+		g := c.newGame()
+		pieces = append(pieces, append([]piece.Type{g.GetCurrentPiece().Type}, g.GetNextPieces(14)...))
+	}
+	if !reflect.DeepEqual(pieces[0], pieces[1]) {
+		t.Errorf("players of a match got pieces %v and %v, want the same", pieces[0], pieces[1])
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// StartMetrics serves /metrics, /healthz and /readyz on a separate address,
+// so scrapers and probes can reach them on a port not exposed to players;
+// blocks like Start
+func (s *Server) StartMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /healthz", s.handleLiveness)
+	mux.HandleFunc("GET /readyz", s.handleReadiness)
+	s.metricsServer = &http.Server{Addr: addr, Handler: mux}
+
+	log.Printf("Metrics server starting on %s", addr)
+	return s.metricsServer.ListenAndServe()
+}
+
+// handleMetrics handles GET /metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	clients := len(s.clients)
+	total := s.TotalClients
+	queued := 0
+	for _, client := range s.clients {
+		queued += len(client.send)
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+	}
+	metric("tetris_clients", "gauge", "Connected game clients", float64(clients))
+	metric("tetris_clients_max", "gauge", "Maximum concurrent game clients, 0 if unlimited", float64(s.MaxClients))
+	metric("tetris_clients_total", "counter", "Game clients registered since the start", float64(total))
+	metric("tetris_sessions", "gauge", "Games played over WebSocket, including those waiting for a reconnect", float64(s.sessions.Len()))
+	metric("tetris_hosted_games", "gauge", "Games hosted for the REST and gRPC APIs", float64(s.games.Len()))
+	metric("tetris_send_queue_messages", "gauge", "Messages waiting in the clients' send queues", float64(queued))
+	metric("tetris_draining", "gauge", "1 while the server refuses new players before a handoff", boolMetric(s.Draining()))
+	metric("tetris_uptime_seconds", "gauge", "Time since the server started", time.Since(s.startedAt).Seconds())
+	metric("go_goroutines", "gauge", "Goroutines that currently exist", float64(runtime.NumGoroutine()))
+	writeErrorMetrics(w, s.httpErrorCounts())
+}
+
+// writeErrorMetrics writes the error responses counted per endpoint, sorted
+// so scrapes are stable
+func writeErrorMetrics(w io.Writer, counts map[string]int) {
+	endpoints := make([]string, 0, len(counts))
+	for endpoint := range counts {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	fmt.Fprint(w, "# HELP tetris_http_errors_total HTTP responses with an error status, by endpoint\n# TYPE tetris_http_errors_total counter\n")
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "tetris_http_errors_total{endpoint=%q} %d\n", endpoint, counts[endpoint])
+	}
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// TestMetrics verifies /metrics reports the server in the Prometheus text format
+func TestMetrics(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	ts := httptest.NewServer(s.countErrors(s.routes()))
+	t.Cleanup(func() {
+		ts.Close()
+		s.games.CloseAll()
+	})
+
+	if _, err := s.games.Create(game.Config{Seed: 1}); err != nil {
+		t.Fatal(err)
+	}
+	s.Drain()
+	http.Get(ts.URL + "/api/games/nope/state")
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	for _, want := range []string{
+		"# TYPE tetris_clients gauge\ntetris_clients 0\n",
+		"tetris_hosted_games 1\n",
+		"tetris_draining 1\n",
+		`tetris_http_errors_total{endpoint="GET /api/games/{id}/state"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
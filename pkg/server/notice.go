@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// maxNoticeBody caps the request body of POST /api/admin/notice
+const maxNoticeBody = 4 << 10
+
+// handleNotice handles POST /api/admin/notice, sending
+// {"text": "Maintenance in 10 minutes"} to every connected player
+func (s *Server) handleNotice(w http.ResponseWriter, r *http.Request) {
+	var req protocol.NoticeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxNoticeBody)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	text, err := protocol.NormalizeChat(req.Text)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid notice: "+err.Error())
+		return
+	}
+	n := s.BroadcastNotice(text)
+	log.Printf("Notice sent to %d clients: %q", n, text)
+	writeJSON(w, http.StatusOK, map[string]int{"clients": n})
+}
+
+// BroadcastNotice sends an announcement to every connected player and
+// returns how many received it
+func (s *Server) BroadcastNotice(text string) int {
+	data, err := protocol.NewNoticeMessage(text, s.Clock.Now()).Serialize()
+	if err != nil {
+		log.Printf("Error serializing notice: %v", err)
+		return 0
+	}
+
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+
+	for _, client := range clients {
+		client.sendNotice(data)
+	}
+	return len(clients)
+}
+
+// sendNotice sends a serialized notice to the client
+func (c *Client) sendNotice(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendNotice: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestAdminNotice verifies a notice sent by an admin reaches every player,
+// and that an empty notice or one without the admin token is not sent
+func TestAdminNotice(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.AdminToken = "secret"
+	var clients []*Client
+	for _, id := range []string{"c1", "c2"} {
+		c := &Client{id: id, send: make(chan []byte, 4), server: s}
+		s.clients[id] = c
+		clients = append(clients, c)
+	}
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+
+	notice := func(token, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/admin/notice", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	noneSent := func(what string) {
+		t.Helper()
+		for _, c := range clients {
+			if got := queue.Drain(t, c.send); len(got) != 0 {
+				t.Errorf("client %s got %s", c.id, what)
+			}
+		}
+	}
+
+	if got := notice("", `{"text": "Free skins at evil.example"}`); got != http.StatusUnauthorized {
+		t.Errorf("notice without a token = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := notice("guess", `{"text": "Free skins at evil.example"}`); got != http.StatusUnauthorized {
+		t.Errorf("notice with a wrong token = %d, want %d", got, http.StatusUnauthorized)
+	}
+	noneSent("an unauthenticated notice")
+
+	if got := notice("secret", `{"text": "  Maintenance in 10 minutes\n"}`); got != http.StatusOK {
+		t.Fatalf("notice = %d, want %d", got, http.StatusOK)
+	}
+	for _, c := range clients {
+		var notice protocol.NoticeMessage
+		if !queue.Drain(t, c.send).Of(protocol.MessageTypeNotice).Last(t, &notice) {
+			t.Fatalf("client %s got no notice", c.id)
+		}
+		if notice.Text != "Maintenance in 10 minutes" {
+			t.Errorf("client %s got %+v, want the notice", c.id, notice)
+		}
+	}
+
+	if got := notice("secret", `{"text": " "}`); got != http.StatusBadRequest {
+		t.Errorf("empty notice = %d, want %d", got, http.StatusBadRequest)
+	}
+	noneSent("an empty notice")
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// opponentInterval is the shortest time between two opponent_state frames of a
+// player, so fast play does not flood the other players of the match
+const opponentInterval = 200 * time.Millisecond
+
+// opponentRelay remembers the last game state relayed to the other players of
+// a match; guarded by relayMu
+type opponentRelay struct {
+	game *game.Game
+	seq  uint64
+	at   time.Time
+}
+
+// relayState sends the client's game to the other players of its match if it
+// changed, at most once per opponentInterval
+// A change held back is sent by the next game loop tick
+func (c *Client) relayState() {
+	if c.match == nil {
+		return
+	}
+
+	c.relayMu.Lock()
+	g := c.Game()
+	seq := g.GetSeq()
+	now := c.server.Clock.Now()
+	if (g == c.relayed.game && seq == c.relayed.seq) || now.Sub(c.relayed.at) < opponentInterval {
+		c.relayMu.Unlock()
+		return
+	}
+	c.relayed = opponentRelay{game: g, seq: seq, at: now}
+	c.relayMu.Unlock()
+
+	data, err := c.opponentState()
+	if err != nil {
+		log.Printf("Error serializing opponent state: %v", err)
+		return
+	}
+	for _, client := range c.server.matchClients(c.match) {
+		if client != c {
+			client.sendOpponentState(data)
+		}
+	}
+}
+
+// sendOpponentStates sends a player who joined a match the games of the
+// players already in it
+func (c *Client) sendOpponentStates() {
+	for _, client := range c.server.matchClients(c.match) {
+		if client == c {
+			continue
+		}
+		data, err := client.opponentState()
+		if err != nil {
+			log.Printf("Error serializing opponent state: %v", err)
+			continue
+		}
+		c.sendOpponentState(data)
+	}
+}
+
+// opponentState serializes the client's game as seen by the other players
+func (c *Client) opponentState() ([]byte, error) {
+	msg, err := protocol.NewOpponentStateMessage(c.seat, c.Name(), c.Game())
+	if err != nil {
+		return nil, err
+	}
+	return msg.Serialize()
+}
+
+// sendOpponentState sends a serialized opponent state to the client
+func (c *Client) sendOpponentState(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendOpponentState: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// The players of a match race on games of their own, and the match is only
+// decided once all of them finish, so one player pausing would stall it for
+// everyone. In a match with other players connected, pause opens a vote
+// instead: the others are asked with pause_vote, and once a majority of the
+// players agree every game of the match pauses together. A vote that too many
+// players decline, or that is not decided within pauseVoteWindow, fails. Any
+// player may resume a paused match.
+
+// pauseVoteWindow is how long the players of a match have to answer a pause vote
+const pauseVoteWindow = 15 * time.Second
+
+// pauseVote is a match's open vote to pause, guarded by the Matches' mu
+type pauseVote struct {
+	requestedBy string          // Name of the player who asked to pause
+	answers     map[string]bool // Answers by client id; true agrees
+	deadline    time.Time       // When the vote fails if still undecided
+}
+
+// pauseTally is how a pause vote stands after an answer
+type pauseTally struct {
+	requestedBy string
+	result      string          // One of protocol.PauseVoteOpen, PauseVotePassed or PauseVoteFailed
+	agreed      int             // Connected players who agreed
+	declined    int             // Connected players who declined
+	needed      int             // Agreements that pass the vote
+	answers     map[string]bool // Copy of the answers, by client id
+	deadline    time.Time
+}
+
+// StartPauseVote opens a vote to pause a match, counting the player who asked
+// as agreeing; returns false if a vote is already open
+func (m *Matches) StartPauseVote(match *Match, clientID, name string, now time.Time) (pauseTally, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match.pauseVote != nil {
+		return pauseTally{}, false
+	}
+	match.pauseVote = &pauseVote{
+		requestedBy: name,
+		answers:     map[string]bool{clientID: true},
+		deadline:    now.Add(pauseVoteWindow),
+	}
+	return match.tallyLocked(), true
+}
+
+// AnswerPauseVote records a player's answer to a match's open vote; returns
+// false if no vote is open
+func (m *Matches) AnswerPauseVote(match *Match, clientID string, agree bool) (pauseTally, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match.pauseVote == nil {
+		return pauseTally{}, false
+	}
+	match.pauseVote.answers[clientID] = agree
+	return match.tallyLocked(), true
+}
+
+// ExpirePauseVote fails a match's open vote once its deadline has passed;
+// returns false if no vote expired
+func (m *Matches) ExpirePauseVote(match *Match, now time.Time) (pauseTally, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match.pauseVote == nil || now.Before(match.pauseVote.deadline) {
+		return pauseTally{}, false
+	}
+	tally := match.tallyLocked()
+	if tally.result == protocol.PauseVoteOpen {
+		tally.result = protocol.PauseVoteFailed
+		match.pauseVote = nil
+	}
+	return tally, true
+}
+
+// SettlePauseVote counts a match's open vote again after a player left, who
+// may have been the one holding it up; returns false if no vote is open
+func (m *Matches) SettlePauseVote(match *Match) (pauseTally, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match.pauseVote == nil {
+		return pauseTally{}, false
+	}
+	return match.tallyLocked(), true
+}
+
+// ResumeVoted reports whether the match is paused by a vote, clearing the
+// mark: the player resuming it resumes every game of the match
+func (m *Matches) ResumeVoted(match *Match) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paused := match.votedPause
+	match.votedPause = false
+	return paused
+}
+
+// tallyLocked counts the answers of the connected players to the open vote,
+// closing it once it is decided
+// Must be called with the Matches lock held
+func (match *Match) tallyLocked() pauseTally {
+	vote := match.pauseVote
+	tally := pauseTally{
+		requestedBy: vote.requestedBy,
+		result:      protocol.PauseVoteOpen,
+		needed:      len(match.members)/2 + 1,
+		answers:     make(map[string]bool, len(vote.answers)),
+		deadline:    vote.deadline,
+	}
+	for _, member := range match.members {
+		agree, answered := vote.answers[member.clientID]
+		switch {
+		case !answered:
+			continue
+		case agree:
+			tally.agreed++
+		default:
+			tally.declined++
+		}
+		tally.answers[member.clientID] = agree
+	}
+
+	switch {
+	case tally.agreed >= tally.needed:
+		tally.result = protocol.PauseVotePassed
+		match.votedPause = true
+	case len(match.members)-tally.declined < tally.needed:
+		tally.result = protocol.PauseVoteFailed
+	default:
+		return tally
+	}
+	match.pauseVote = nil
+	return tally
+}
+
+// votesOnPause reports whether pausing the client's game needs a vote: it
+// plays in a match with other players connected
+func (c *Client) votesOnPause() bool {
+	return c.match != nil && c.server.matches.Players(c.match) > 1
+}
+
+// requestPause opens a vote to pause the client's match, or answers the open
+// one with an agreement
+func (c *Client) requestPause() {
+	tally, ok := c.server.matches.StartPauseVote(c.match, c.id, c.Name(), c.server.Clock.Now())
+	if !ok {
+		c.answerPauseVote(true)
+		return
+	}
+	log.Printf("[Client %s] Asked to pause match %s", c.id, c.match.ID)
+	c.server.announcePauseVote(c.match, tally)
+}
+
+// answerPauseVote records the client's answer to its match's pause vote
+func (c *Client) answerPauseVote(agree bool) {
+	if c.match == nil {
+		c.sendError(protocol.CodeNotAllowed, "Pause votes are only held in a match")
+		return
+	}
+	tally, ok := c.server.matches.AnswerPauseVote(c.match, c.id, agree)
+	if !ok {
+		c.sendError(protocol.CodeNotFound, "No pause vote open")
+		return
+	}
+	log.Printf("[Client %s] Answered the pause vote of match %s: %v", c.id, c.match.ID, agree)
+	c.server.announcePauseVote(c.match, tally)
+}
+
+// expirePauseVote fails the open pause vote of the client's match once its
+// time is up; the game loop of every player checks, and the first to see it
+// expire tells them all
+func (c *Client) expirePauseVote() {
+	if c.match == nil {
+		return
+	}
+	if tally, ok := c.server.matches.ExpirePauseVote(c.match, c.server.Clock.Now()); ok {
+		log.Printf("Pause vote of match %s timed out", c.match.ID)
+		c.server.announcePauseVote(c.match, tally)
+	}
+}
+
+// settlePauseVote decides the open pause vote of a match a player left
+func (s *Server) settlePauseVote(match *Match) {
+	if tally, ok := s.matches.SettlePauseVote(match); ok {
+		s.announcePauseVote(match, tally)
+	}
+}
+
+// announcePauseVote tells the players of a match how its pause vote stands,
+// pausing all their games once it passed
+func (s *Server) announcePauseVote(match *Match, tally pauseTally) {
+	clients := s.matchClients(match)
+	if tally.result == protocol.PauseVotePassed {
+		log.Printf("Match %s paused by vote", match.ID)
+		for _, client := range clients {
+			for _, sess := range client.boardSessions() {
+				sess.Game().Pause()
+			}
+		}
+	}
+
+	expires := max(tally.deadline.Sub(s.Clock.Now()), 0)
+	for _, client := range clients {
+		_, voted := tally.answers[client.id]
+		vote := protocol.PauseVoteMessage{
+			RequestedBy: tally.requestedBy,
+			Result:      tally.result,
+			Agreed:      tally.agreed,
+			Declined:    tally.declined,
+			Needed:      tally.needed,
+			Voted:       voted,
+		}
+		if tally.result == protocol.PauseVoteOpen {
+			vote.ExpiresInMs = int(expires.Milliseconds())
+		}
+		client.sendPauseVote(vote)
+		if tally.result == protocol.PauseVotePassed {
+			client.sendState()
+			client.wakeGameLoop()
+		}
+	}
+}
+
+// resumeMatch resumes every game of the client's match after a vote paused
+// it; returns false if the match was not paused by a vote
+func (c *Client) resumeMatch() bool {
+	if c.match == nil || !c.server.matches.ResumeVoted(c.match) {
+		return false
+	}
+	log.Printf("[Client %s] Resumed match %s", c.id, c.match.ID)
+	for _, client := range c.server.matchClients(c.match) {
+		for _, sess := range client.boardSessions() {
+			sess.Game().Resume()
+		}
+		if client != c {
+			client.sendState()
+			client.wakeGameLoop()
+		}
+	}
+	return true
+}
+
+// sendPauseVote tells the client how its match's pause vote stands
+func (c *Client) sendPauseVote(vote protocol.PauseVoteMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendPauseVote: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewPauseVoteMessage(vote).Serialize()
+	if err != nil {
+		log.Printf("Error serializing pause vote: %v", err)
+		return
+	}
+	c.queue(data)
+}
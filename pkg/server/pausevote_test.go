@@ -0,0 +1,114 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// joinMatch connects a bare client to the match "final" of s
+func joinMatch(s *Server, id string) *Client {
+	c := &Client{id: id, name: id, send: make(chan []byte, 64), server: s}
+	c.match = s.matches.Join("final", 42, nil, matchMember{clientID: id, name: id}, s.Clock.Now())
+	c.seat = s.matches.Seat(c.match, id)
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+	s.clients[id] = c
+	return c
+}
+
+// lastPauseVote returns the last pause vote queued for a client, draining
+// its queue
+func lastPauseVote(t *testing.T, c *Client) *protocol.PauseVoteMessage {
+	t.Helper()
+	var vote protocol.PauseVoteMessage
+	if !queue.Drain(t, c.send).Of(protocol.MessageTypePauseVote).Last(t, &vote) {
+		return nil
+	}
+	return &vote
+}
+
+// TestPauseVote verifies a player of a match cannot pause alone: a majority
+// pauses every game, anyone resumes them, and an unanswered vote times out
+func TestPauseVote(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	players := []*Client{joinMatch(s, "c1"), joinMatch(s, "c2"), joinMatch(s, "c3")}
+	paused := func() (n int) {
+		for _, c := range players {
+			if c.Game().IsPaused() {
+				n++
+			}
+		}
+		return n
+	}
+
+	players[0].handleMessage([]byte(`{"type": "pause"}`))
+	if n := paused(); n != 0 {
+		t.Fatalf("%d games paused by one player's request, want 0", n)
+	}
+	for i, c := range players {
+		vote := lastPauseVote(t, c)
+		if vote == nil {
+			t.Fatalf("player %d not asked to vote", i)
+		}
+		want := protocol.PauseVoteMessage{RequestedBy: "c1", Result: protocol.PauseVoteOpen, Agreed: 1, Needed: 2,
+			Voted: i == 0, ExpiresInMs: int(pauseVoteWindow.Milliseconds())}
+		if *vote != want {
+			t.Errorf("player %d got vote %+v, want %+v", i, *vote, want)
+		}
+	}
+
+	players[1].handleMessage([]byte(`{"type": "pause_vote", "agree": true}`))
+	if n := paused(); n != 3 {
+		t.Errorf("%d games paused after the vote passed, want 3", n)
+	}
+	if vote := lastPauseVote(t, players[2]); vote == nil || vote.Result != protocol.PauseVotePassed {
+		t.Errorf("player 2 got vote %+v, want passed", vote)
+	}
+	players[2].handleMessage([]byte(`{"type": "resume"}`))
+	if n := paused(); n != 0 {
+		t.Errorf("%d games paused after a player resumed the match, want 0", n)
+	}
+
+	players[1].handleMessage([]byte(`{"type": "toggle_pause"}`))
+	clk.Advance(pauseVoteWindow)
+	players[2].expirePauseVote()
+	if vote := lastPauseVote(t, players[0]); vote == nil || vote.Result != protocol.PauseVoteFailed {
+		t.Errorf("player 0 got vote %+v after the timeout, want failed", vote)
+	}
+	if n := paused(); n != 0 {
+		t.Errorf("%d games paused after the vote timed out, want 0", n)
+	}
+	players[0].handleMessage([]byte(`{"type": "pause_vote", "agree": true}`))
+	if got := queue.Drain(t, players[0].send).Errors(t); len(got) != 1 {
+		t.Errorf("answering a closed vote got %d errors, want 1", len(got))
+	}
+}
+
+// TestPauseVoteDeclined verifies a vote fails once too many players decline,
+// and a player left alone in a match pauses without one
+func TestPauseVoteDeclined(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	host, guest := joinMatch(s, "c1"), joinMatch(s, "c2")
+
+	host.handleMessage([]byte(`{"type": "pause"}`))
+	guest.handleMessage([]byte(`{"type": "pause_vote"}`))
+	if vote := lastPauseVote(t, host); vote == nil || vote.Result != protocol.PauseVoteFailed || vote.Declined != 1 {
+		t.Errorf("host got vote %+v, want failed with one decline", vote)
+	}
+	if host.Game().IsPaused() {
+		t.Error("host's game paused after the vote failed")
+	}
+
+	s.matches.Leave(host.match, "c2")
+	delete(s.clients, "c2")
+	host.handleMessage([]byte(`{"type": "pause"}`))
+	if !host.Game().IsPaused() {
+		t.Error("player alone in the match could not pause")
+	}
+}
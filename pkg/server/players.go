@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// errAccountsDisabled is returned when a player logs in to a server without an account store
+var errAccountsDisabled = errors.New("player accounts are not enabled on this server")
+
+// handleRegisterPlayer handles POST /api/players, registering a player by name
+// The response holds the only copy of the player's token
+func (s *Server) handleRegisterPlayer(w http.ResponseWriter, r *http.Request) {
+	if s.Accounts == nil {
+		writeJSONError(w, http.StatusNotFound, "Player accounts are not enabled")
+		return
+	}
+
+	var req protocol.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if _, err := protocol.NormalizeName(req.Name); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid name: "+err.Error())
+		return
+	}
+
+	reg, err := s.Accounts.Register(req.Name, s.Clock.Now())
+	if err != nil {
+		log.Printf("Error registering player %q: %v", req.Name, err)
+		writeJSONError(w, http.StatusInternalServerError, "Registration failed")
+		return
+	}
+	log.Printf("Registered player %s (%s)", reg.Profile.ID, reg.Profile.Name)
+	writeJSON(w, http.StatusCreated, reg)
+}
+
+// handlePlayerProfile handles GET /api/players/{id}
+func (s *Server) handlePlayerProfile(w http.ResponseWriter, r *http.Request) {
+	if s.Accounts == nil {
+		writeJSONError(w, http.StatusNotFound, "Player accounts are not enabled")
+		return
+	}
+
+	profile, err := s.Accounts.Get(r.PathValue("id"))
+	switch {
+	case errors.Is(err, accounts.ErrNotFound):
+		writeJSONError(w, http.StatusNotFound, "Player not found")
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, profile)
+	}
+}
+
+// authenticate logs the client in as a registered player, who is then shown
+// under the account's name until they set another; returns the player's rating
+func (c *Client) authenticate(id, token string) (int, error) {
+	if c.server.Accounts == nil {
+		return 0, errAccountsDisabled
+	}
+
+	profile, err := c.server.Accounts.Authenticate(id, token)
+	if err != nil {
+		return 0, err
+	}
+	c.playerID = profile.ID
+	c.setName(profile.Name)
+	return profile.Rating, nil
+}
+
+// recordPlayerGame adds the finished game to a registered player's statistics
+func (c *Client) recordPlayerGame() {
+	if c.playerID == "" || c.server.Accounts == nil {
+		return
+	}
+
+	g := c.Game()
+	err := c.server.Accounts.RecordGame(c.playerID, g.GetScore(), g.GetLines(), c.server.Clock.Now())
+	if err != nil {
+		log.Printf("[Client %s] Error recording game for player %s: %v", c.id, c.playerID, err)
+	}
+}
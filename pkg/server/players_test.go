@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/accounts"
+)
+
+// TestPlayerLogin verifies a registered player logs in with the token in an
+// Authorization header or, for browsers, in the URL, and that a wrong token
+// plays as a guest
+func TestPlayerLogin(t *testing.T) {
+	store, err := accounts.Open(filepath.Join(t.TempDir(), "players.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	reg, err := store.Register("alice", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(":0")
+	s.Accounts = store
+	s.ShutdownGrace = 0
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		ts.Close()
+	})
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?player=" + reg.Profile.ID
+
+	tests := []struct {
+		name   string
+		query  string
+		header http.Header
+		want   string // Player id the client plays as
+	}{
+		{"header", "", http.Header{"Authorization": {"Bearer " + reg.Token}}, reg.Profile.ID},
+		{"query", "&token=" + reg.Token, nil, reg.Profile.ID},
+		{"wrong header", "&token=" + reg.Token, http.Header{"Authorization": {"Bearer guess"}}, ""},
+	}
+	for _, tt := range tests {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+tt.query, tt.header)
+		if err != nil {
+			t.Fatalf("%s: dial: %v", tt.name, err)
+		}
+
+		var client *Client
+		for deadline := time.Now().Add(5 * time.Second); client == nil && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			s.mu.RLock()
+			for _, c := range s.clients {
+				client = c
+			}
+			s.mu.RUnlock()
+		}
+		if client == nil {
+			t.Fatalf("%s: client never registered", tt.name)
+		}
+		if client.playerID != tt.want {
+			t.Errorf("%s: playing as %q, want %q", tt.name, client.playerID, tt.want)
+		}
+
+		conn.Close()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			s.mu.RLock()
+			n := len(s.clients)
+			s.mu.RUnlock()
+			if n == 0 {
+				break
+			}
+		}
+	}
+}
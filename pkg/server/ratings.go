@@ -0,0 +1,109 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// defaultLadderSize is the number of players returned by GET /api/ladder without a limit
+const defaultLadderSize = 10
+
+// maxLadderSize caps the limit of GET /api/ladder
+const maxLadderSize = 100
+
+// reportMatch rates the registered players of a finished match and sends the
+// standings to the players still connected
+// A player registered on two connections is rated on their better score, and
+// a match with handicaps is not rated
+func (s *Server) reportMatch(match *Match, results []matchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	var rated []accounts.MatchResult
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.playerID != "" && !seen[r.playerID] {
+			seen[r.playerID] = true
+			rated = append(rated, accounts.MatchResult{PlayerID: r.playerID, Score: r.score})
+		}
+	}
+
+	updates := make(map[string]protocol.MatchStanding)
+	// Handicaps even out the scores, so they say nothing about skill
+	if s.Accounts != nil && len(rated) >= 2 && !match.handicapped() {
+		profiles, changes, err := s.Accounts.RateMatch(rated)
+		if err != nil {
+			log.Printf("Error rating match %s: %v", match.ID, err)
+		}
+		for i, p := range profiles {
+			updates[p.ID] = protocol.MatchStanding{Rating: p.Rating, RatingChange: changes[i]}
+		}
+	}
+
+	standings := make([]protocol.MatchStanding, len(results))
+	for i, r := range results {
+		standings[i] = protocol.MatchStanding{Name: r.name, Score: r.score}
+		if u, ok := updates[r.playerID]; ok {
+			standings[i].Rating = u.Rating
+			standings[i].RatingChange = u.RatingChange
+			// Only the better score of a player counts
+			delete(updates, r.playerID)
+		}
+	}
+	log.Printf("Match %s finished: %+v", match.ID, standings)
+
+	data, err := protocol.NewMatchResultMessage(match.ID, standings).Serialize()
+	if err != nil {
+		log.Printf("Error serializing match result: %v", err)
+		return
+	}
+	for _, client := range s.matchClients(match) {
+		client.sendMatchResult(data)
+	}
+}
+
+// sendMatchResult sends a serialized match result to the client
+func (c *Client) sendMatchResult(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendMatchResult: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
+
+// handleLadder handles GET /api/ladder, the registered players with the
+// highest ratings; ?limit= sets how many
+func (s *Server) handleLadder(w http.ResponseWriter, r *http.Request) {
+	if s.Accounts == nil {
+		writeJSONError(w, http.StatusNotFound, "Player accounts are not enabled")
+		return
+	}
+
+	limit := defaultLadderSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeJSONError(w, http.StatusBadRequest, "Invalid limit: "+v)
+			return
+		}
+		limit = min(n, maxLadderSize)
+	}
+
+	players, err := s.Accounts.Ladder(limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if players == nil {
+		players = []protocol.PlayerProfile{}
+	}
+	writeJSON(w, http.StatusOK, protocol.Ladder{Players: players})
+}
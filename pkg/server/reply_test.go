@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestReplies verifies a control message with an ID is answered once, by an
+// ack or an error carrying the ID, and one without is not
+func TestReplies(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	tests := []struct {
+		msg      string
+		wantType protocol.MessageType // Type of the reply; empty for none
+		wantID   string
+	}{
+		{`{"type": "rotate", "id": "r1"}`, protocol.MessageTypeAck, "r1"},
+		{`{"type": "set_name", "name": "Ann", "id": "n1"}`, protocol.MessageTypeAck, "n1"},
+		{`{"type": "select_mode", "mode": "nope", "id": "m1"}`, protocol.MessageTypeError, "m1"},
+		{`{"type": "launch_missiles", "id": "x1"}`, protocol.MessageTypeError, "x1"},
+		{`{"type": "rotate"}`, "", ""},
+	}
+
+	for _, tt := range tests {
+		c.handleMessage([]byte(tt.msg))
+		var replies queue.Messages
+		for _, msg := range queue.Drain(t, c.send) {
+			if msg.ReplyTo != "" {
+				replies = append(replies, msg)
+			}
+		}
+
+		if tt.wantType == "" {
+			if len(replies) != 0 {
+				t.Errorf("%s: got replies %+v, want none", tt.msg, replies)
+			}
+			continue
+		}
+		if len(replies) != 1 || replies[0].Type != tt.wantType || replies[0].ReplyTo != tt.wantID {
+			t.Errorf("%s: got replies %+v, want one %s to %s", tt.msg, replies, tt.wantType, tt.wantID)
+		}
+	}
+}
+
+// TestAckSeq verifies state frames acknowledge the seq of the last input
+// applied, not of one that was rejected
+func TestAckSeq(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	tests := []struct {
+		msg  string
+		want uint64
+	}{
+		{`{"type": "rotate", "seq": 1}`, 1},
+		{`{"type": "select_mode", "mode": "nope", "seq": 2}`, 1},
+		{`{"type": "undo", "seq": 3}`, 1},
+		{`{"type": "move_left", "seq": 4}`, 4},
+	}
+	for _, tt := range tests {
+		c.handleMessage([]byte(tt.msg))
+		queue.Drain(t, c.send)
+		if got := c.inputSeq[0]; got != tt.want {
+			t.Errorf("%s: acknowledged seq %d, want %d", tt.msg, got, tt.want)
+		}
+	}
+
+	for !c.Game().IsGameOver() {
+		c.Game().HardDrop()
+	}
+	c.handleMessage([]byte(`{"type": "rotate", "seq": 5}`))
+	if got := c.inputSeq[0]; got != 4 {
+		t.Errorf("input after game over: acknowledged seq %d, want 4", got)
+	}
+}
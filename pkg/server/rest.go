@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// maxRequestBody caps the request bodies of the hosted game endpoints
+const maxRequestBody = 4096
+
+// handleCreateGame handles POST /api/games, starting a hosted game
+func (s *Server) handleCreateGame(w http.ResponseWriter, r *http.Request) {
+	var req protocol.CreateGameRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	case err != nil && err != io.EOF:
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mode := game.Mode(req.Mode)
+	if req.Mode != "" && !mode.IsValid() {
+		writeJSONError(w, http.StatusBadRequest, "Unknown game mode: "+req.Mode)
+		return
+	}
+
+	randomizer := piece.RandomizerKind(req.Randomizer)
+	if req.Randomizer != "" && !randomizer.IsValid() {
+		writeJSONError(w, http.StatusBadRequest, "Unknown randomizer: "+req.Randomizer)
+		return
+	}
+	script, err := protocol.ParsePieces(req.Script)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid script: "+err.Error())
+		return
+	}
+	if randomizer == piece.RandomizerScripted && len(script) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "The scripted randomizer needs a script")
+		return
+	}
+
+	hg, err := s.games.Create(game.Config{
+		Mode:       mode,
+		Seed:       req.Seed,
+		DigRows:    req.DigRows,
+		Randomizer: randomizer,
+		Script:     script,
+	})
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, protocol.GameCreated{GameID: hg.ID, State: hg.State()})
+}
+
+// handleGameMove handles POST /api/games/{id}/moves, applying a control
+// command such as {"type":"move_left"} and returning the new state
+func (s *Server) handleGameMove(w http.ResponseWriter, r *http.Request) {
+	hg, err := s.games.Get(r.PathValue("id"))
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ctrl, err := protocol.ParseControlMessage(data)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid message format")
+		return
+	}
+
+	log.Printf("[Game %s] Command: %s", hg.ID, ctrl.Type)
+	if err := hg.Apply(ctrl.Type); err != nil {
+		writeGameError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hg.State())
+}
+
+// handleGameState handles GET /api/games/{id}/state
+func (s *Server) handleGameState(w http.ResponseWriter, r *http.Request) {
+	hg, err := s.games.Get(r.PathValue("id"))
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hg.State())
+}
+
+// writeGameError maps hosted game errors to HTTP status codes
+func writeGameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrGameNotFound):
+		writeJSONError(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, ErrGameOver):
+		writeCodedError(w, http.StatusConflict, protocol.CodeGameOver, "Game is over")
+	case errors.Is(err, ErrInvalidAction):
+		writeJSONError(w, http.StatusBadRequest, "Unsupported command")
+	case errors.Is(err, ErrNothingToUndo):
+		writeJSONError(w, http.StatusConflict, "Nothing to undo")
+	case errors.Is(err, ErrTooManyGames):
+		// Hosted games end once idle, freeing their slots
+		w.Header().Set("Retry-After", "60")
+		writeJSONError(w, http.StatusServiceUnavailable, "Too many hosted games, try again later")
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// writeJSONError writes an error message like the ones sent over WebSocket,
+// with the error code of the status
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeCodedError(w, status, statusErrorCode(status), msg)
+}
+
+// writeCodedError writes an error message with the given status and error code
+func writeCodedError(w http.ResponseWriter, status int, code protocol.ErrorCode, msg string) {
+	writeJSON(w, status, protocol.ErrorMessage{Error: msg, Code: code})
+}
+
+// statusErrorCode returns the error code of an HTTP error status
+func statusErrorCode(status int) protocol.ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return protocol.CodeInvalidMessage
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return protocol.CodeUnauthorized
+	case http.StatusNotFound:
+		return protocol.CodeNotFound
+	case http.StatusConflict:
+		return protocol.CodeNotAllowed
+	case http.StatusTooManyRequests:
+		return protocol.CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return protocol.CodeRoomFull
+	default:
+		return protocol.CodeInternal
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
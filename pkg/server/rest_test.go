@@ -0,0 +1,234 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// newRESTTest serves the HTTP endpoints of a server on a fake clock
+func newRESTTest(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(func() {
+		ts.Close()
+		s.games.CloseAll()
+	})
+	return ts
+}
+
+// doJSON sends a request and decodes the JSON response into v, returning the status code
+func doJSON(t *testing.T, method, url, body string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: decoding response: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+// TestRESTGame verifies creating a game, moving its piece and reading its state
+func TestRESTGame(t *testing.T) {
+	ts := newRESTTest(t)
+
+	var created protocol.GameCreated
+	if code := doJSON(t, "POST", ts.URL+"/api/games", `{"seed": 42}`, &created); code != http.StatusCreated {
+		t.Fatalf("POST /api/games status = %d, want %d", code, http.StatusCreated)
+	}
+	if created.State.Seed != 42 {
+		t.Errorf("seed = %d, want 42", created.State.Seed)
+	}
+	base := ts.URL + "/api/games/" + created.GameID
+
+	var moved protocol.StateMessage
+	if code := doJSON(t, "POST", base+"/moves", `{"type": "move_right"}`, &moved); code != http.StatusOK {
+		t.Fatalf("POST moves status = %d, want %d", code, http.StatusOK)
+	}
+	if want := created.State.CurrentPiece.X + 1; moved.CurrentPiece.X != want {
+		t.Errorf("X after move_right = %d, want %d", moved.CurrentPiece.X, want)
+	}
+
+	var state protocol.StateMessage
+	if code := doJSON(t, "GET", base+"/state", "", &state); code != http.StatusOK {
+		t.Fatalf("GET state status = %d, want %d", code, http.StatusOK)
+	}
+	if state.CurrentPiece != moved.CurrentPiece {
+		t.Errorf("state piece = %+v, want %+v", state.CurrentPiece, moved.CurrentPiece)
+	}
+}
+
+// TestRESTErrors verifies the status codes of failed requests
+func TestRESTErrors(t *testing.T) {
+	ts := newRESTTest(t)
+
+	var created, practice protocol.GameCreated
+	doJSON(t, "POST", ts.URL+"/api/games", "", &created)
+	doJSON(t, "POST", ts.URL+"/api/games", `{"mode": "practice"}`, &practice)
+	base := ts.URL + "/api/games/" + created.GameID
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		want   int
+	}{
+		{"unknown game", "GET", ts.URL + "/api/games/missing/state", "", http.StatusNotFound},
+		{"unknown mode", "POST", ts.URL + "/api/games", `{"mode": "blitz"}`, http.StatusBadRequest},
+		{"unknown randomizer", "POST", ts.URL + "/api/games", `{"randomizer": "nes"}`, http.StatusBadRequest},
+		{"scripted without script", "POST", ts.URL + "/api/games", `{"randomizer": "scripted"}`, http.StatusBadRequest},
+		{"invalid script", "POST", ts.URL + "/api/games", `{"randomizer": "scripted", "script": "IQ"}`, http.StatusBadRequest},
+		{"oversized body", "POST", ts.URL + "/api/games", `{"randomizer": "scripted", "script": "` + strings.Repeat("I", maxRequestBody) + `"}`, http.StatusRequestEntityTooLarge},
+		{"malformed move", "POST", base + "/moves", `{`, http.StatusBadRequest},
+		{"unsupported command", "POST", base + "/moves", `{"type": "set_name"}`, http.StatusBadRequest},
+		{"undo outside practice", "POST", base + "/moves", `{"type": "undo"}`, http.StatusBadRequest},
+		{"nothing to undo", "POST", ts.URL + "/api/games/" + practice.GameID + "/moves", `{"type": "undo"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		var e protocol.ErrorMessage
+		if code := doJSON(t, tt.method, tt.url, tt.body, &e); code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, code, tt.want)
+		}
+		if e.Error == "" {
+			t.Errorf("%s: empty error message", tt.name)
+		}
+	}
+}
+
+// TestRESTGameLimit verifies creating a game is refused with 503 once the
+// server hosts MaxHostedGames
+func TestRESTGameLimit(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.MaxHostedGames = 1
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(func() {
+		ts.Close()
+		s.games.CloseAll()
+	})
+
+	var created protocol.GameCreated
+	if code := doJSON(t, "POST", ts.URL+"/api/games", "", &created); code != http.StatusCreated {
+		t.Fatalf("POST /api/games status = %d, want %d", code, http.StatusCreated)
+	}
+	var e protocol.ErrorMessage
+	if code := doJSON(t, "POST", ts.URL+"/api/games", "", &e); code != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/games over the limit status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if e.Code != protocol.CodeRoomFull {
+		t.Errorf("error code = %q, want %q", e.Code, protocol.CodeRoomFull)
+	}
+}
+
+// TestPages verifies that the embedded browser clients are served
+func TestPages(t *testing.T) {
+	ts := newRESTTest(t)
+
+	for path, want := range map[string]string{
+		"/":           "<title>Tetris WebSocket Test Client</title>",
+		"/play":       "<title>Tetris WebSocket Test Client</title>",
+		"/admin":      "<title>Tetris Server Admin</title>",
+		"/overlay/s1": "<title>Tetris Overlay</title>",
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, http.StatusOK)
+		}
+		if !strings.Contains(string(body), want) {
+			t.Errorf("GET %s body does not contain %q", path, want)
+		}
+	}
+}
+
+// TestRESTPlayers verifies registering a player and reading their profile
+func TestRESTPlayers(t *testing.T) {
+	store, err := accounts.Open(filepath.Join(t.TempDir(), "players.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	s.Accounts = store
+	ts := httptest.NewServer(s.routes())
+	defer ts.Close()
+
+	var reg protocol.PlayerRegistered
+	if code := doJSON(t, "POST", ts.URL+"/api/players", `{"name": "alice"}`, &reg); code != http.StatusCreated {
+		t.Fatalf("POST /api/players status = %d, want %d", code, http.StatusCreated)
+	}
+	if reg.Token == "" || reg.Profile.Name != "alice" {
+		t.Fatalf("registration = %+v, want a token for alice", reg)
+	}
+	if err := store.RecordGame(reg.Profile.ID, 900, 6, time.Unix(60, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	var profile protocol.PlayerProfile
+	if code := doJSON(t, "GET", ts.URL+"/api/players/"+reg.Profile.ID, "", &profile); code != http.StatusOK {
+		t.Fatalf("GET profile status = %d, want %d", code, http.StatusOK)
+	}
+	if profile.GamesPlayed != 1 || profile.BestScore != 900 || profile.TotalLines != 6 {
+		t.Errorf("profile = %+v, want 1 game, best 900, 6 lines", profile)
+	}
+
+	// Only players who finished a rated match are on the ladder
+	other, err := store.Register("bob", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.RateMatch([]accounts.MatchResult{{PlayerID: other.Profile.ID, Score: 10}, {PlayerID: reg.Profile.ID, Score: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	var ladder protocol.Ladder
+	if code := doJSON(t, "GET", ts.URL+"/api/ladder?limit=1", "", &ladder); code != http.StatusOK {
+		t.Fatalf("GET /api/ladder status = %d, want %d", code, http.StatusOK)
+	}
+	if len(ladder.Players) != 1 || ladder.Players[0].Name != "bob" || ladder.Players[0].Rating != 1516 {
+		t.Errorf("ladder = %+v, want bob rated 1516", ladder.Players)
+	}
+
+	var e protocol.ErrorMessage
+	if code := doJSON(t, "GET", ts.URL+"/api/players/missing", "", &e); code != http.StatusNotFound {
+		t.Errorf("unknown player status = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := doJSON(t, "POST", ts.URL+"/api/players", `{"name": ""}`, &e); code != http.StatusBadRequest {
+		t.Errorf("empty name status = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	// Servers without an account store have no players
+	plain := newRESTTest(t)
+	if code := doJSON(t, "GET", plain.URL+"/api/players/"+reg.Profile.ID, "", &e); code != http.StatusNotFound {
+		t.Errorf("profile without accounts status = %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// handleSaveGame pauses the client's game and sends it back as a snapshot, so
+// the player can continue it later with load_game
+func (c *Client) handleSaveGame() {
+	if c.match != nil {
+		c.sendError(protocol.CodeNotAllowed, "Match games cannot be saved")
+		return
+	}
+	if c.partner != nil {
+		c.sendError(protocol.CodeNotAllowed, "Doubles games cannot be saved")
+		return
+	}
+	if c.coop != nil {
+		c.sendError(protocol.CodeNotAllowed, "Co-op games cannot be saved")
+		return
+	}
+	if c.cpu != nil {
+		c.sendError(protocol.CodeNotAllowed, "Games against the CPU cannot be saved")
+		return
+	}
+
+	g := c.Game()
+	g.Pause()
+	snapshot, err := g.Snapshot()
+	if err != nil {
+		log.Printf("[Client %s] Error saving game: %v", c.id, err)
+		c.sendError(protocol.CodeInternal, "Could not save the game")
+		return
+	}
+	log.Printf("[Client %s] Command: save_game (score %d)", c.id, g.GetScore())
+	c.sendSavedGame(snapshot)
+}
+
+// handleLoadGame replaces the client's game with one saved by save_game
+// The snapshot comes from the client and could have been edited, so a loaded
+// game does not count towards the leaderboard or lifetime stats
+func (c *Client) handleLoadGame(snapshot []byte) {
+	if c.match != nil {
+		c.sendError(protocol.CodeNotAllowed, "Saved games cannot be loaded in a match")
+		return
+	}
+	if c.partner != nil {
+		c.sendError(protocol.CodeNotAllowed, "Saved games cannot be loaded in a doubles game")
+		return
+	}
+	if c.coop != nil {
+		c.sendError(protocol.CodeNotAllowed, "Saved games cannot be loaded in a co-op game")
+		return
+	}
+	if c.cpu != nil {
+		c.sendError(protocol.CodeNotAllowed, "Saved games cannot be loaded against the CPU")
+		return
+	}
+
+	g := c.newGame()
+	if err := g.Restore(snapshot); err != nil {
+		code := protocol.CodeInvalidMessage
+		if errors.Is(err, game.ErrSnapshotVersion) {
+			code = protocol.CodeUnsupportedVersion
+		}
+		c.sendError(code, "Invalid saved game: "+err.Error())
+		return
+	}
+	log.Printf("[Client %s] Command: load_game (%s, score %d)", c.id, g.GetMode(), g.GetScore())
+	c.restartDeadline = time.Time{}
+	c.mode = g.GetMode()
+	c.session.loadGame(g)
+	c.recordNewGame("loaded")
+}
+
+// sendSavedGame sends a saved game to the client
+func (c *Client) sendSavedGame(snapshot []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendSavedGame: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewSavedGameMessage(snapshot).Serialize()
+	if err != nil {
+		log.Printf("Error serializing saved game: %v", err)
+		return
+	}
+
+	c.queue(data)
+}
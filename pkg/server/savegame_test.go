@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestSaveLoadGame verifies that a saved game comes back paused in the same
+// mode and that a loaded game stays unranked until the player restarts
+func TestSaveLoadGame(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 4), server: s, mode: game.ModeSprint}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+	c.Game().HardDrop()
+	score := c.Game().GetScore()
+
+	c.handleSaveGame()
+	var saved protocol.SavedGameMessage
+	if !queue.Drain(t, c.send).Of(protocol.MessageTypeSavedGame).Last(t, &saved) {
+		t.Fatal("save_game got no saved_game reply")
+	}
+	if !c.Game().IsPaused() {
+		t.Error("game not paused after saving")
+	}
+
+	c.mode = game.ModeMarathon
+	c.restart()
+	c.handleLoadGame(saved.Snapshot)
+	if got := c.Game().GetScore(); got != score {
+		t.Errorf("loaded score = %d, want %d", got, score)
+	}
+	if c.mode != game.ModeSprint {
+		t.Errorf("mode after load = %s, want %s", c.mode, game.ModeSprint)
+	}
+	if !c.session.Loaded() {
+		t.Error("loaded game not marked as loaded")
+	}
+
+	c.restart()
+	if c.session.Loaded() {
+		t.Error("restarted game still marked as loaded")
+	}
+
+	c.handleLoadGame([]byte(`{"version": 99}`))
+	if got := queue.Drain(t, c.send).Errors(t); len(got) != 1 {
+		t.Errorf("invalid snapshot got %d errors, want 1", len(got))
+	}
+	if c.session.Loaded() {
+		t.Error("invalid snapshot replaced the game")
+	}
+
+	// An unknown piece type would panic the game loop once installed
+	var snap map[string]any
+	if err := json.Unmarshal(saved.Snapshot, &snap); err != nil {
+		t.Fatal(err)
+	}
+	snap["current"] = map[string]any{"type": 42, "rotation": 1}
+	edited, _ := json.Marshal(snap)
+	c.handleLoadGame(edited)
+	if got := queue.Drain(t, c.send).Errors(t); len(got) != 1 {
+		t.Errorf("snapshot with an unknown piece got %d errors, want 1", len(got))
+	}
+	c.Game().Update()
+}
@@ -3,36 +3,148 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"runtime"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/accounts"
+	"github.com/ican2002/tetris/pkg/ai"
+	"github.com/ican2002/tetris/pkg/clock"
 	"github.com/ican2002/tetris/pkg/game"
 	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/puzzle"
+	"github.com/ican2002/tetris/web"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins in development
-	},
-}
-
 // Client represents a WebSocket client connection
 type Client struct {
 	id          string
 	conn        *websocket.Conn
 	send        chan []byte
 	server      *Server
-	game        *game.Game
+	session     *Session // The game the client plays, kept by the server's GameManager
+	partner     *Session // Second board of a doubles game, requested with boards=2; nil for one board
 	address     string
 	connectTime time.Time
+	version     string // Client version reported in the connect URL
+	station     string // Kiosk station id reported in the connect URL
+	playerID    string // Registered player authenticated in the connect URL; empty for guests
+	match       *Match // Match joined in the connect URL; nil to play alone
+	seat        int    // Seat in the match
+	coop        *Coop  // Co-op game joined in the connect URL, sharing its session; nil to play alone
+	compact     bool   // Send compact_state frames, requested with encoding=compact
+	mode        game.Mode
+	digRows     int           // Garbage rows of a dig game, chosen with select_mode
+	puzzle      *puzzle.Level // Level of a puzzle game, chosen with select_mode
+
+	// cpu is the bot opponent hosted by the server, requested with
+	// cpu=<difficulty>; nil without one
+	cpu *cpuOpponent
+
+	// name is the display name registered with set_name; guarded by nameMu
+	// because the admin broadcast reads it from another goroutine
+	name   string
+	nameMu sync.RWMutex
+
+	// relayed is the game state last sent to the other players of the match
+	relayed opponentRelay
+	relayMu sync.Mutex
+
+	// chatTimes are when recent chat messages and emotes were sent, for flood control
+	chatTimes []time.Time
+
+	// invalidRun counts the unreadable messages received in a row
+	invalidRun int
+
+	// replyTo is the correlation ID of the control message being handled until
+	// it is answered; only readPump's goroutine uses it
+	replyTo string
+	// span traces the control message being handled, nil between messages;
+	// only readPump's goroutine uses it
+	span trace.Span
+	// board is the board the control message being handled is for, 0 between
+	// messages; only readPump's goroutine uses it
+	board int
+
+	// restartDeadline is set while a restart awaits confirmation
+	restartDeadline time.Time
+
+	// Last state sent of each board, so unchanged state is not sent again;
+	// guarded by stateMu
+	stateMu  sync.Mutex
+	frameSeq uint64                        // Sequence number of the last state frame sent, of any board
+	sent     [protocol.MaxBoards]sentFrame // Last frame sent of each board
+	inputSeq [protocol.MaxBoards]uint64    // Seq of the last input applied to each board, echoed as ack_seq
+
+	// lastInput is when the client last sent a control command; guarded by inputMu
+	lastInput  time.Time
+	inputs     int  // Control commands received, for the admin input rate
+	idlePaused bool // The game was paused by the idle timer rather than the player
+	inputMu    sync.Mutex
+
+	// lastSeen is when the client last sent a message or pong, for the
+	// heartbeat; guarded by heartbeatMu
+	lastSeen    time.Time
+	heartbeatMu sync.Mutex
+
+	done     chan struct{}     // Closed when the connection ends; stops gameLoop
+	stopped  chan struct{}     // Closed when gameLoop returns
+	wake     chan struct{}     // Tells gameLoop to reschedule after a command
+	closeReq chan closeRequest // Asks writePump to send a close frame and end the connection
+
+	// Send queue health; guarded by queueMu
+	droppedFrames int       // Messages dropped because the send queue was full
+	fullSince     time.Time // When the queue filled up; zero while messages are accepted
+	evicted       bool
+	queueMu       sync.Mutex
+}
+
+// sentFrame describes the last state frame sent of a board
+type sentFrame struct {
+	game    *game.Game // Game the frame was taken from
+	gameSeq uint64     // That game's change sequence when it was sent
+	ackSeq  uint64     // The board's inputSeq when it was sent
+	at      time.Time  // When it was sent
+}
+
+// sendQueueSize is how many messages a client's send queue holds before
+// further messages are dropped
+const sendQueueSize = 256
+
+// closeRequest is a close frame writePump sends before closing the connection
+type closeRequest struct {
+	code   int
+	reason string
 }
 
+// maxInvalidMessages is how many unreadable messages in a row a client may
+// send before it is disconnected with a protocol error
+const maxInvalidMessages = 20
+
+// restartConfirmWindow is how long a client has to confirm a restart
+const restartConfirmWindow = 10 * time.Second
+
+// maxTickInterval is the longest the game loop sleeps, so timed-mode goals
+// are checked promptly even at slow drop speeds
+const maxTickInterval = 1 * time.Second
+
+// clockRefreshInterval is the longest a playing or paused board goes without
+// a state frame, so the game clock keeps moving on clients even while nothing
+// else changes
+const clockRefreshInterval = 1 * time.Second
+
+// minTickInterval keeps the game loop to one update per frame at fast
+// levels; gravity makes up the rows that passed in between
+const minTickInterval = game.Frame
+
 // Server represents the WebSocket server
 type Server struct {
 	clients         map[string]*Client
@@ -41,75 +153,294 @@ type Server struct {
 	unregister      chan *Client
 	registerAdmin   chan *websocket.Conn
 	unregisterAdmin chan *websocket.Conn
+	hubProbe        chan chan struct{} // Health checks ask the hub to close the channel, proving it runs
 	mu              sync.RWMutex
 	adminMu         sync.RWMutex
+	leaderboard     *Leaderboard
+	analytics       *Analytics
+	matches         *Matches
+	coops           *Coops
+	games           *Games       // Games hosted for the gRPC and REST APIs
+	sessions        *GameManager // Games played over WebSocket connections
 
 	// Configuration
+	// writePump pings clients every PingInterval and disconnects those that
+	// send nothing, not even a pong, for PongTimeout; 0 disables the timeout
 	PingInterval time.Duration
 	PongTimeout  time.Duration
+	MaxClients   int // Maximum concurrent game clients; 0 means unlimited
+
+	// Browsers may open WebSockets from pages of this server and from
+	// AllowedOrigins, such as "https://tetris.example.com"; AllowAnyOrigin
+	// lifts the check for development
+	AllowedOrigins []string
+	AllowAnyOrigin bool
+
+	// MaxConnsPerIP limits the game connections from one IP address; 0 means unlimited
+	MaxConnsPerIP int
+
+	// MaxHostedGames limits the games hosted for the gRPC and REST APIs at
+	// once; 0 means unlimited
+	MaxHostedGames int
+
+	// AdminToken is the bearer token of the admin API, such as /api/admin/bans;
+	// empty disables it
+	AdminToken string
+
+	// Clients that send no control input are paused after IdlePause and
+	// disconnected after IdleTimeout; 0 disables either
+	IdlePause   time.Duration
+	IdleTimeout time.Duration
+
+	// WriteTimeout bounds each write to a client; clients whose send queue
+	// stays full for SlowClientTimeout are disconnected
+	WriteTimeout      time.Duration
+	SlowClientTimeout time.Duration
+
+	// SpawnDelay is the entry delay (ARE) before the next piece of a WebSocket
+	// game spawns after a lock; rotate, hold and the last move pressed
+	// meanwhile apply as it spawns. Full rows stay on the board for
+	// LineClearDelay first
+	SpawnDelay     time.Duration
+	LineClearDelay time.Duration
+
+	// ShutdownGrace is how long Shutdown waits after warning the players with
+	// server_shutdown before closing their connections
+	ShutdownGrace time.Duration
+
+	// SnapshotPath is where Shutdown saves the games in progress, for
+	// RestoreSessions after the restart; empty discards them
+	SnapshotPath string
+
+	// Accounts stores registered players and their lifetime statistics;
+	// nil disables registration
+	Accounts *accounts.Store
+
+	// Puzzles are the levels offered in puzzle mode; nil offers puzzle.Builtin
+	Puzzles []puzzle.Level
+
+	// Clock drives game loops, heartbeats and idle timers; tests can replace
+	// it with a clock.Fake before Start
+	Clock clock.Clock
+
+	// TracerProvider traces each control message from its receipt through the
+	// game update to the state sent back; nil uses the global provider,
+	// which drops the spans unless telemetry.Setup installed an exporter
+	TracerProvider trace.TracerProvider
+
 	TotalClients int
 	PeakClients  int
 
+	// admitted counts connections accepted but not yet unregistered, so the
+	// MaxClients check is not fooled by clients still queued for the hub; guarded by mu
+	admitted int
+
+	// draining refuses new game connections ahead of a handoff to another
+	// server; guarded by mu
+	draining bool
+
+	// connsPerIP counts the game connections from each IP address, for
+	// MaxConnsPerIP; guarded by mu
+	connsPerIP map[string]int
+
+	// bans refuses banned addresses and bans abusive ones
+	bans *Bans
+
+	// auditLog records what happens in each session, for the admin API
+	auditLog *AuditLog
+
 	// HTTP Server
 	httpServer *http.Server
 	addr       string
+	startedAt  time.Time
+	hubOnce    sync.Once // Starts the hub and admin broadcast routines
+
+	// metricsServer serves /metrics and the probes on their own address once
+	// StartMetrics is called
+	metricsServer *http.Server
+
+	// done is closed by Shutdown to stop the hub and the background
+	// routines, which routines waits for
+	done     chan struct{}
+	stopOnce sync.Once
+	routines sync.WaitGroup
+
+	// httpErrors counts the error responses of each endpoint, reported by /readyz
+	httpErrors   map[string]int
+	httpErrorsMu sync.Mutex
+
+	// gRPC server, started by StartGRPC; guarded by mu, as StartGRPC usually
+	// runs in a goroutine of its own
+	grpcServer *grpc.Server
 }
 
 // New creates a new WebSocket server
 func New(addr string) *Server {
-	return &Server{
-		clients:         make(map[string]*Client),
-		adminClients:    make(map[string]*websocket.Conn),
-		register:        make(chan *Client),
-		unregister:      make(chan *Client),
-		registerAdmin:   make(chan *websocket.Conn),
-		unregisterAdmin: make(chan *websocket.Conn),
-		PingInterval:    30 * time.Second,
-		PongTimeout:     60 * time.Second,
-		TotalClients:    0,
-		PeakClients:     0,
-		addr:            addr,
+	s := &Server{
+		clients:           make(map[string]*Client),
+		adminClients:      make(map[string]*websocket.Conn),
+		register:          make(chan *Client),
+		unregister:        make(chan *Client),
+		registerAdmin:     make(chan *websocket.Conn),
+		unregisterAdmin:   make(chan *websocket.Conn),
+		hubProbe:          make(chan chan struct{}),
+		done:              make(chan struct{}),
+		leaderboard:       NewLeaderboard(),
+		analytics:         NewAnalytics(),
+		matches:           NewMatches(),
+		coops:             NewCoops(),
+		sessions:          NewGameManager(),
+		PingInterval:      30 * time.Second,
+		PongTimeout:       60 * time.Second,
+		IdlePause:         2 * time.Minute,
+		IdleTimeout:       10 * time.Minute,
+		WriteTimeout:      10 * time.Second,
+		SlowClientTimeout: 5 * time.Second,
+		ShutdownGrace:     5 * time.Second,
+		MaxHostedGames:    1000,
+		Clock:             clock.Real{},
+		TotalClients:      0,
+		PeakClients:       0,
+		addr:              addr,
+		startedAt:         time.Now(),
+		httpErrors:        make(map[string]int),
+		connsPerIP:        make(map[string]int),
+		bans:              NewBans(),
+		auditLog:          NewAuditLog(),
 	}
+	s.games = newGames(s)
+	return s
 }
 
 // Start starts the WebSocket server
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/ws", s.handleWebSocket)
-	mux.HandleFunc("/ws/admin", s.handleAdminWebSocket)
-	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/", s.handleRoot)
-	mux.HandleFunc("/admin", s.handleAdmin)
-
 	s.httpServer = &http.Server{
 		Addr:    s.addr,
-		Handler: mux,
+		Handler: s.Handler(),
 	}
 
 	log.Printf("WebSocket server starting on %s", s.addr)
 
-	// Start hub routine
-	go s.run()
-	// Start admin broadcast routine
-	go s.adminBroadcastLoop()
-
 	return s.httpServer.ListenAndServe()
 }
 
+// Handler returns the handler of every HTTP and WebSocket endpoint, starting
+// the hub the first time, so the server can be served by an http.Server of
+// the caller's, such as httptest's in tests
+func (s *Server) Handler() http.Handler {
+	s.hubOnce.Do(func() {
+		// Start hub routine
+		s.background(s.run)
+		// Start admin broadcast routine
+		s.background(s.adminBroadcastLoop)
+	})
+	return s.throttle(s.countErrors(s.routes()))
+}
+
+// routes returns the handler for every HTTP endpoint
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/ws/admin", s.handleAdminWebSocket)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("GET /healthz", s.handleLiveness)
+	mux.HandleFunc("GET /readyz", s.handleReadiness)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("/api/lobby", s.handleLobby)
+	mux.HandleFunc("POST /api/games", s.handleCreateGame)
+	mux.HandleFunc("POST /api/games/{id}/moves", s.handleGameMove)
+	mux.HandleFunc("GET /api/games/{id}/state", s.handleGameState)
+	mux.HandleFunc("POST /api/players", s.handleRegisterPlayer)
+	mux.HandleFunc("GET /api/players/{id}", s.handlePlayerProfile)
+	mux.HandleFunc("GET /api/ladder", s.handleLadder)
+	mux.HandleFunc("GET /api/admin/bans", s.requireAdmin(s.handleListBans))
+	mux.HandleFunc("POST /api/admin/bans", s.requireAdmin(s.handleBan))
+	mux.HandleFunc("DELETE /api/admin/bans/{ip}", s.requireAdmin(s.handleUnban))
+	mux.HandleFunc("GET /api/admin/sessions/{id}/events", s.requireAdmin(s.handleSessionEvents))
+	mux.HandleFunc("POST /api/admin/notice", s.requireAdmin(s.handleNotice))
+	mux.HandleFunc("POST /api/admin/drain", s.requireAdmin(s.handleDrain))
+	mux.HandleFunc("DELETE /api/admin/drain", s.requireAdmin(s.handleResume))
+	mux.HandleFunc("GET /api/analytics/heatmap", s.handleHeatmap)
+	mux.HandleFunc("GET /events/{gameID}", s.handleEvents)
+	mux.HandleFunc("/", s.handleRoot)
+	mux.HandleFunc("GET /play", s.handlePlay)
+	mux.HandleFunc("/admin", s.handleAdmin)
+	mux.HandleFunc("GET /overlay/{sessionID}", s.handleOverlay)
+	return mux
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("WebSocket server shutting down...")
 
-	// Close all client connections
-	s.mu.Lock()
-	for _, client := range s.clients {
-		client.conn.Close()
-		close(client.send)
+	// Warn the players and give their clients the grace period to show it,
+	// then save the games still in progress
+	if s.notifyShutdown() > 0 && s.ShutdownGrace > 0 {
+		timer := s.Clock.NewTimer(s.ShutdownGrace)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+	if s.SnapshotPath != "" {
+		if n, err := s.SaveSessions(s.SnapshotPath); err != nil {
+			log.Printf("Error saving games: %v", err)
+		} else {
+			log.Printf("Saved %d games to %s", n, s.SnapshotPath)
+		}
 	}
+
+	// Stop the hub and game loops, then close all client connections,
+	// telling the clients the server is going away
+	s.mu.Lock()
+	clients := s.clients
 	s.clients = make(map[string]*Client)
+	s.admitted = 0
+	s.connsPerIP = make(map[string]int)
 	s.mu.Unlock()
+	s.stopRoutines(ctx)
+	s.closeAdminClients()
+
+	var closing sync.WaitGroup
+	for _, client := range clients {
+		closing.Add(1)
+		go func(c *Client) {
+			defer closing.Done()
+			c.closeNow(protocol.CloseServerShutdown, "server shutting down")
+			// Only close the send queue once nothing else sends on it
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+			}
+			select {
+			case <-c.stopped:
+			case <-ctx.Done():
+			}
+			close(c.send)
+		}(client)
+	}
+	closing.Wait()
+	s.sessions.RemoveAll()
+	if err := s.auditLog.Close(); err != nil {
+		log.Printf("Error closing audit log: %v", err)
+	}
 
-	// Shutdown HTTP server
+	// End hosted games, so gRPC streams return, then stop the gRPC server
+	s.games.CloseAll()
+	s.mu.RLock()
+	grpcServer := s.grpcServer
+	s.mu.RUnlock()
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	// Shutdown the metrics and HTTP servers
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down metrics server: %v", err)
+		}
+	}
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
 	}
@@ -136,6 +467,23 @@ func (s *Server) run() {
 			if _, ok := s.clients[client.id]; ok {
 				delete(s.clients, client.id)
 				close(client.send)
+				s.admitted--
+				s.releaseConnLocked(clientIP(client.address))
+				// A co-op game's session lasts until its last player leaves
+				if client.coop == nil || s.coops.Leave(client.coop, client.id) {
+					s.sessions.Remove(client.session.ID)
+				} else {
+					go s.coopLeft(client.coop)
+				}
+				if client.match != nil {
+					// Reporting looks up the other players, which needs mu
+					if results := s.matches.Leave(client.match, client.id); results != nil {
+						go s.reportMatch(client.match, results)
+					} else {
+						go s.sendMatchRoster(client.match, "")
+						go s.settlePauseVote(client.match)
+					}
+				}
 				log.Printf("Client unregistered: %s (total: %d)", client.id, len(s.clients))
 			}
 			s.mu.Unlock()
@@ -157,50 +505,332 @@ func (s *Server) run() {
 				}
 			}
 			s.adminMu.Unlock()
+
+		case done := <-s.hubProbe:
+			close(done)
+
+		case <-s.done:
+			return
 		}
 	}
 }
 
+// background runs fn in a routine that Shutdown stops through s.done and
+// waits for
+func (s *Server) background(fn func()) {
+	s.routines.Add(1)
+	go func() {
+		defer s.routines.Done()
+		fn()
+	}()
+}
+
+// stopping reports whether Shutdown has stopped the hub
+func (s *Server) stopping() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopRoutines stops the hub and the background routines, waiting for them
+// to return until ctx is done
+func (s *Server) stopRoutines(ctx context.Context) {
+	s.stopOnce.Do(func() { close(s.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.routines.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("Background routines still running at shutdown: %v", ctx.Err())
+	}
+}
+
+// closeAdminClients closes the admin connections, telling them the server is
+// going away; their read routines unregister them
+func (s *Server) closeAdminClients() {
+	s.adminMu.RLock()
+	defer s.adminMu.RUnlock()
+
+	deadline := time.Now().Add(time.Second)
+	for _, conn := range s.adminClients {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(protocol.CloseServerShutdown, "server shutting down"), deadline)
+		conn.Close()
+	}
+}
+
 // handleWebSocket handles WebSocket connection upgrades
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if s.stopping() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if s.Draining() {
+		http.Error(w, "server draining, try again", http.StatusServiceUnavailable)
+		return
+	}
+	ip := clientIP(r.RemoteAddr)
+	if !s.reserveConn(ip) {
+		log.Printf("Rejecting %s: %d connections from this address", r.RemoteAddr, s.MaxConnsPerIP)
+		s.recordFailure(ip)
+		http.Error(w, "too many connections from your address", http.StatusTooManyRequests)
+		return
+	}
+
+	conn, err := s.upgrader().Upgrade(w, r, nil)
 	if err != nil {
+		s.releaseConn(ip)
+		s.recordFailure(ip)
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
+	if !s.admit() {
+		s.releaseConn(ip)
+		log.Printf("Rejecting %s: server full (%d clients)", r.RemoteAddr, s.MaxClients)
+		rejectFull(conn)
+		return
+	}
+
 	// Create new client
 	client := &Client{
 		id:          generateClientID(),
 		conn:        conn,
-		send:        make(chan []byte, 256),
+		send:        make(chan []byte, sendQueueSize),
 		server:      s,
-		game:        game.New(),
+		mode:        game.ModeMarathon,
 		address:     r.RemoteAddr,
-		connectTime: time.Now(),
+		connectTime: s.Clock.Now(),
+		version:     r.URL.Query().Get("client_version"),
+		station:     r.URL.Query().Get("station"),
+		compact:     r.URL.Query().Get("encoding") == "compact",
+		lastInput:   s.Clock.Now(),
+		lastSeen:    s.Clock.Now(),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		wake:        make(chan struct{}, 1),
+		closeReq:    make(chan closeRequest, 1),
 	}
 
-	// Register client
-	s.register <- client
+	// Registered players prove who they are with the token they were issued,
+	// sent in an "Authorization: Bearer" header, or in the token parameter by
+	// browsers, which cannot set headers on a WebSocket; bad credentials
+	// still get a game, but as a guest
+	authError := ""
+	rating := 0
+	if id := r.URL.Query().Get("player"); id != "" {
+		token, ok := bearerToken(r)
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		var err error
+		if rating, err = client.authenticate(id, token); err != nil {
+			log.Printf("[Client %s] Player %s not authenticated: %v", client.id, id, err)
+			authError = "Player login failed: " + err.Error()
+		}
+	}
+
+	// Players of the same match share a seed; the first one may choose it, and
+	// the handicaps of every seat. Matchmaking pairs players of similar
+	// rating, who have no use for handicaps
+	handicapError, handicapCode := "", protocol.CodeNotAllowed
+	if matchID := r.URL.Query().Get("match"); matchID != "" {
+		seed, _ := strconv.ParseInt(r.URL.Query().Get("seed"), 10, 64)
+		handicaps, err := parseHandicaps(r.URL.Query().Get("handicaps"))
+		switch {
+		case err != nil:
+			handicapError, handicapCode = "Invalid handicaps: "+err.Error()+", playing without", protocol.CodeInvalidMessage
+			handicaps = nil
+		case handicaps != nil && matchID == AutoMatch:
+			handicapError = "Handicaps are not available with matchmaking, playing without"
+			handicaps = nil
+		}
+		client.match = s.matches.Join(matchID, seed, handicaps, matchMember{
+			clientID: client.id,
+			playerID: client.playerID,
+			name:     client.Name(),
+			rating:   rating,
+		}, s.Clock.Now())
+		client.seat = s.matches.Seat(client.match, client.id)
+	}
+	// A player reconnecting after a restart continues their saved game
+	resumeError := ""
+	if id := r.URL.Query().Get("session"); id != "" {
+		if sess, err := s.sessions.Claim(id); err == nil {
+			client.session = sess
+			client.mode = sess.Game().GetMode()
+			log.Printf("[Client %s] Continuing saved session %s", client.id, id)
+		} else {
+			resumeError = "Saved game not found, starting a new one"
+		}
+	}
+	// Co-op players share the game of the first to join; the game is unranked
+	// and cannot be continued alone, so neither a match nor a saved game mixes with it
+	coopError := ""
+	if id := r.URL.Query().Get("coop"); id != "" {
+		if client.match != nil || client.session != nil {
+			coopError = "Co-op is not available in a match or a continued game, playing alone"
+		} else if coop, err := s.coops.Join(id, client.id, func() *Session {
+			return s.sessions.Create(client.newGame(), s.Clock.Now())
+		}); err == nil {
+			client.coop = coop
+			client.session = coop.session
+			log.Printf("[Client %s] Joined co-op game %s", client.id, id)
+		} else {
+			coopError = "Co-op game " + id + " already has two players, playing alone"
+		}
+	}
+	if client.session == nil {
+		client.session = s.sessions.Create(client.newGame(), s.Clock.Now())
+	}
+	// Doubles games are unranked, so a match is played on one board
+	doublesError := ""
+	if parseBoards(r.URL.Query().Get("boards")) > 1 {
+		if client.match != nil || client.coop != nil {
+			doublesError = "Doubles are not available in a match or co-op game, playing one board"
+		} else {
+			client.startDoubles()
+		}
+	}
+	// The CPU opponent is the only one the game can have, so it leaves out
+	// matches, co-op and doubles
+	cpuError, cpuCode := "", protocol.CodeNotAllowed
+	if value := r.URL.Query().Get("cpu"); value != "" {
+		difficulty, err := ai.ParseDifficulty(value)
+		switch {
+		case err != nil:
+			cpuError, cpuCode = "Unknown CPU difficulty "+strconv.Quote(value)+", playing alone", protocol.CodeInvalidMessage
+		case client.match != nil || client.coop != nil || client.partner != nil:
+			cpuError = "Versus CPU is not available in a match, co-op or doubles game, playing alone"
+		default:
+			client.startCPU(difficulty)
+		}
+	}
+
+	// Register client, unless the server shut down meanwhile
+	select {
+	case s.register <- client:
+	case <-s.done:
+		s.sessions.Remove(client.session.ID)
+		client.closeNow(protocol.CloseServerShutdown, "server shutting down")
+		return
+	}
+
+	client.recordEvent(AuditEvent{Kind: AuditConnect, Address: client.address, Player: client.playerID,
+		Mode: string(client.Game().GetMode()), Seed: client.Game().GetSeed()})
 
 	// Start client routines
 	go client.writePump()
 	go client.readPump()
+	go client.gameLoop()
 
 	// Send initial game state
+	client.sendMatchJoined()
+	if authError != "" {
+		client.sendError(protocol.CodeUnauthorized, authError)
+	}
+	if resumeError != "" {
+		client.sendError(protocol.CodeNotFound, resumeError)
+	}
+	if doublesError != "" {
+		client.sendError(protocol.CodeNotAllowed, doublesError)
+	}
+	if coopError != "" {
+		client.sendError(protocol.CodeNotAllowed, coopError)
+	}
+	if cpuError != "" {
+		client.sendError(cpuCode, cpuError)
+	}
+	if handicapError != "" {
+		client.sendError(handicapCode, handicapError)
+	}
 	client.sendState()
+	if client.coop != nil {
+		s.sendCoopTurn(client.coop, client)
+	}
+}
+
+// admit reserves a client slot, returning false if the server is at MaxClients
+func (s *Server) admit() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.MaxClients > 0 && s.admitted >= s.MaxClients {
+		return false
+	}
+	s.admitted++
+	return true
+}
+
+// rejectFull tells a client the server is full and closes the connection
+// The error message lets clients show the reason; the close code tells them to retry later
+func rejectFull(conn *websocket.Conn) {
+	defer conn.Close()
+
+	data, err := protocol.NewErrorMessage(protocol.CodeRoomFull, "server full, try again later").Serialize()
+	if err != nil {
+		log.Printf("Error serializing error: %v", err)
+		return
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	conn.WriteMessage(websocket.TextMessage, data)
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "server full"))
 }
 
 // handleHealth handles health check requests
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
 	clientCount := len(s.clients)
+	admitted := s.admitted
 	s.mu.RUnlock()
 
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	report := map[string]interface{}{
+		"status":      "ok",
+		"clients":     clientCount,
+		"max_clients": s.MaxClients,
+		"goroutines":  runtime.NumGoroutine(),
+		"heap_alloc":  mem.HeapAlloc,
+	}
+	if s.MaxClients > 0 {
+		report["utilization"] = float64(admitted) / float64(s.MaxClients)
+		if admitted >= s.MaxClients {
+			report["status"] = "full"
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "ok",
-		"clients": clientCount,
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleLobby returns the active player count and top scores
+func (s *Server) handleLobby(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	clientCount := len(s.clients)
+	s.mu.RUnlock()
+
+	// Optional ruleset filter keeps scores from different rules apart
+	ruleset := r.URL.Query().Get("ruleset")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.LobbyInfo{
+		ActivePlayers: clientCount,
+		TopScores:     s.leaderboard.Top(maxLeaderboardEntries, ruleset),
 	})
 }
 
@@ -211,7 +841,18 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.ServeFile(w, r, "test-client.html")
+	http.ServeFileFS(w, r, web.Files, "test-client.html")
+}
+
+// handlePlay serves the player client without its debugging panels
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, web.Files, "test-client.html")
+}
+
+// handleOverlay serves the stream overlay of a session, which follows its
+// state over /events; the page waits for sessions that do not exist yet
+func (s *Server) handleOverlay(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, web.Files, "overlay.html")
 }
 
 // handleAdmin handles admin page requests
@@ -221,30 +862,44 @@ func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.ServeFile(w, r, "admin-client.html")
+	http.ServeFileFS(w, r, web.Files, "admin-client.html")
 }
 
 // handleAdminWebSocket handles admin WebSocket connections
 func (s *Server) handleAdminWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if s.stopping() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	conn, err := s.upgrader().Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Admin WebSocket upgrade error: %v", err)
 		return
 	}
 
 	// Register admin client
-	s.registerAdmin <- conn
+	select {
+	case s.registerAdmin <- conn:
+	case <-s.done:
+		conn.Close()
+		return
+	}
 
-	// Read messages to keep connection alive
+	// The admin WebSocket only pushes updates; notices and other commands go
+	// through the admin API, which needs the admin token. Reading detects the
+	// connection closing
 	go func() {
 		defer func() {
-			s.unregisterAdmin <- conn
+			select {
+			case s.unregisterAdmin <- conn:
+			case <-s.done:
+			}
 			conn.Close()
 		}()
 
+		conn.SetReadLimit(protocol.MaxMessageSize)
 		for {
-			_, _, err := conn.ReadMessage()
-			if err != nil {
+			if _, _, err := conn.ReadMessage(); err != nil {
 				break
 			}
 		}
@@ -254,37 +909,47 @@ func (s *Server) handleAdminWebSocket(w http.ResponseWriter, r *http.Request) {
 // readPump handles messages from the WebSocket connection
 func (c *Client) readPump() {
 	defer func() {
-		c.server.unregister <- c
 		c.conn.Close()
+		// The hub closes the send queue, so gameLoop must stop sending first
+		close(c.done)
+		<-c.stopped
+		// Shutdown unregisters every client itself once the hub stopped
+		select {
+		case c.server.unregister <- c:
+		case <-c.server.done:
+		}
 	}()
 
-	c.conn.SetReadDeadline(time.Now().Add(c.server.PongTimeout))
+	// Larger messages end the connection with a message too big close frame
+	c.conn.SetReadLimit(protocol.MaxMessageSize)
+	// writePump owns the heartbeat; pongs and messages only reset it
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(c.server.PongTimeout))
+		c.markAlive()
 		return nil
 	})
 
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
+			c.recordEvent(AuditEvent{Kind: AuditDisconnect, Detail: err.Error()})
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				log.Printf("[Client %s] Message larger than %d bytes, disconnecting", c.id, protocol.MaxMessageSize)
+			}
 			break
 		}
 
+		c.markAlive()
 		c.handleMessage(message)
 	}
 }
 
 // writePump handles writing messages to the WebSocket connection
 func (c *Client) writePump() {
-	// Update game state periodically for smooth gameplay
-	// Use a longer interval to avoid race conditions with user input
-	gameTicker := time.NewTicker(200 * time.Millisecond)
-	pingTicker := time.NewTicker(c.server.PingInterval)
+	pingTicker := c.server.Clock.NewTicker(c.server.PingInterval)
 	defer func() {
-		gameTicker.Stop()
 		pingTicker.Stop()
 		c.conn.Close()
 	}()
@@ -292,9 +957,9 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(c.server.WriteTimeout))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(protocol.CloseNormal, ""))
 				return
 			}
 
@@ -315,13 +980,29 @@ func (c *Client) writePump() {
 				return
 			}
 
-		case <-gameTicker.C:
-			// Update game and send state
-			c.updateGame()
+		case req := <-c.closeReq:
+			// Deliver messages queued before the close, such as the reason for it
+			c.conn.SetWriteDeadline(time.Now().Add(c.server.WriteTimeout))
+			for n := len(c.send); n > 0; n-- {
+				message, ok := <-c.send
+				if !ok {
+					break
+				}
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					return
+				}
+			}
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(req.code, req.reason))
+			return
 
-		case <-pingTicker.C:
+		case <-pingTicker.C():
+			if silent, expired := c.heartbeatExpired(); expired {
+				log.Printf("[Client %s] Nothing received for %v, disconnecting", c.id, silent.Round(time.Second))
+				c.closeNow(protocol.CloseTimeout, "ping timeout")
+				return
+			}
 			// Send WebSocket protocol ping
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(c.server.WriteTimeout))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -329,80 +1010,634 @@ func (c *Client) writePump() {
 	}
 }
 
+// queue adds a message to the send queue without blocking
+// Returns false if the queue is full; a client whose queue stays full for
+// SlowClientTimeout is evicted
+func (c *Client) queue(data []byte) bool {
+	select {
+	case c.send <- data:
+		c.queueMu.Lock()
+		c.fullSince = time.Time{}
+		c.queueMu.Unlock()
+		return true
+	default:
+	}
+
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	c.droppedFrames++
+	now := c.server.Clock.Now()
+	if c.fullSince.IsZero() {
+		c.fullSince = now
+	}
+	if !c.evicted && c.server.SlowClientTimeout > 0 && now.Sub(c.fullSince) >= c.server.SlowClientTimeout {
+		c.evicted = true
+		log.Printf("[Client %s] Send queue full for %v (%d frames dropped), evicting",
+			c.id, now.Sub(c.fullSince).Round(time.Millisecond), c.droppedFrames)
+		go c.evict()
+	}
+	return false
+}
+
+// DroppedFrames returns how many messages were dropped because the send queue was full
+func (c *Client) DroppedFrames() int {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	return c.droppedFrames
+}
+
+// evict closes the connection of a client that is not reading its messages
+func (c *Client) evict() {
+	c.closeNow(protocol.CloseSlowClient, "slow client")
+}
+
+// closeNow sends a close frame with the given close code and closes the
+// connection at once; unlike disconnect it bypasses the send queue, so it
+// works while writePump is stuck writing
+func (c *Client) closeNow(code int, reason string) {
+	deadline := time.Now().Add(time.Second)
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	c.conn.Close()
+}
+
+// disconnect asks writePump to close the connection with the given close code
+// Messages already queued are sent first
+func (c *Client) disconnect(code int, reason string) {
+	select {
+	case c.closeReq <- closeRequest{code: code, reason: reason}:
+	default:
+		// A close is already pending
+	}
+}
+
+// Game returns the client's current game
+func (c *Client) Game() *game.Game {
+	return c.session.Game()
+}
+
+// Name returns the client's registered display name, or "" if none was set
+func (c *Client) Name() string {
+	c.nameMu.RLock()
+	defer c.nameMu.RUnlock()
+	return c.name
+}
+
+// setName sets the client's display name
+func (c *Client) setName(name string) {
+	c.nameMu.Lock()
+	defer c.nameMu.Unlock()
+	c.name = name
+}
+
 // handleMessage handles incoming messages from the client
 func (c *Client) handleMessage(data []byte) {
-	msgType, err := protocol.ParseControlMessage(data)
+	ctx, span := c.startMessageSpan(data)
+	defer c.endMessageSpan()
+
+	ctrl, err := protocol.ParseControlMessage(data)
 	if err != nil {
-		c.sendError("Invalid message format")
+		c.rejectInvalid(err)
 		return
 	}
+	msgType := ctrl.Type
+	span.SetName("receive " + string(msgType))
+	span.SetAttributes(attrMessageType.String(string(msgType)))
 
-	if !protocol.IsValidControlType(msgType) {
-		c.sendError("Unknown message type: " + string(msgType))
+	// A message with an ID is answered by an error or, once handled, an ack
+	if len(ctrl.ID) <= protocol.MaxIDLength {
+		c.replyTo = ctrl.ID
+	}
+	defer func() { c.board = 0 }()
+	defer c.acknowledge()
+
+	// Check the payload before acting on any of it
+	if err := ctrl.Validate(); err != nil {
+		c.rejectInvalid(err)
+		return
+	}
+	c.invalidRun = 0
+	c.recordCommand(msgType, data)
+
+	if msgType != protocol.MessageTypePong {
+		c.recordInput()
+	}
+
+	// Commands are for the board they name, the only one of a single board game
+	c.board = ctrl.BoardIndex
+	if !c.checkBoard() {
 		return
 	}
 
-	if c.game.IsGameOver() && msgType != protocol.MessageTypePong && msgType != protocol.MessageTypeRestart {
-		c.sendError("Game is over")
+	if c.target().IsGameOver() && msgType != protocol.MessageTypePong &&
+		msgType != protocol.MessageTypeRestart && msgType != protocol.MessageTypeRestartConfirm &&
+		msgType != protocol.MessageTypeSelectMode && msgType != protocol.MessageTypeSetName &&
+		msgType != protocol.MessageTypeChat && msgType != protocol.MessageTypeEmote &&
+		msgType != protocol.MessageTypeLoadGame && msgType != protocol.MessageTypeUndo &&
+		msgType != protocol.MessageTypePauseVote {
+		c.sendError(protocol.CodeGameOver, "Game is over")
 		return
 	}
+	if !c.checkTurn(msgType) {
+		return
+	}
+
+	_, update := c.server.tracer().Start(ctx, "game.update")
+	changed := c.applyControl(ctrl)
+	update.End()
+	if !changed {
+		return
+	}
+
+	// Only an input that was applied is acknowledged by the next frame
+	if ctrl.Seq != 0 {
+		c.stateMu.Lock()
+		c.inputSeq[c.board] = ctrl.Seq
+		c.stateMu.Unlock()
+	}
 
+	_, broadcast := c.server.tracer().Start(ctx, "state.send")
+	c.followPause()
+	c.sendState()
+	c.sendScoreEvents()
+	c.sendAttacks()
+
+	// Check for game over; only the board the command was for can have ended
+	over := c.target().IsGameOver()
+	if over {
+		c.sendGameOver(c.board)
+	}
+	c.syncCoop(over)
+	broadcast.End()
+
+	// Commands can restart the game, resume it or change the drop speed
+	c.wakeGameLoop()
+}
+
+// applyControl carries out a validated control message; returns false if it
+// was answered with an error or cannot have changed the game, so there is no
+// state to send
+func (c *Client) applyControl(ctrl *protocol.ControlMessage) bool {
+	msgType := ctrl.Type
+	g := c.target()
 	switch msgType {
 	case protocol.MessageTypeMoveLeft:
 		log.Printf("[Client %s] Command: move_left", c.id)
-		c.game.MoveLeft()
+		g.MoveLeft()
 	case protocol.MessageTypeMoveRight:
 		log.Printf("[Client %s] Command: move_right", c.id)
-		c.game.MoveRight()
+		g.MoveRight()
 	case protocol.MessageTypeMoveDown:
 		log.Printf("[Client %s] Command: move_down", c.id)
-		c.game.MoveDown()
+		g.MoveDown()
 	case protocol.MessageTypeRotate:
 		log.Printf("[Client %s] Command: rotate", c.id)
-		c.game.Rotate()
+		g.Rotate()
+	case protocol.MessageTypeRotate180:
+		log.Printf("[Client %s] Command: rotate_180", c.id)
+		g.Rotate180()
+	case protocol.MessageTypeHold:
+		log.Printf("[Client %s] Command: hold", c.id)
+		g.Hold()
+	case protocol.MessageTypeUndo:
+		log.Printf("[Client %s] Command: undo", c.id)
+		if g.GetMode() != game.ModePractice {
+			c.sendError(protocol.CodeNotAllowed, "Undo is only available in practice mode")
+			return false
+		}
+		if !g.Undo() {
+			c.sendError(protocol.CodeNotAllowed, "Nothing to undo")
+			return false
+		}
+	case protocol.MessageTypeSetBoard:
+		log.Printf("[Client %s] Command: set_board (%d rows)", c.id, len(ctrl.Rows))
+		if err := g.SetBoard(ctrl.Rows); err != nil {
+			c.sendError(protocol.CodeInvalidMessage, "Invalid board setup: "+err.Error())
+			return false
+		}
+	case protocol.MessageTypeSetNextPiece:
+		log.Printf("[Client %s] Command: set_next_piece %s", c.id, ctrl.Pieces)
+		types, err := protocol.ParsePieces(ctrl.Pieces)
+		if err == nil {
+			err = g.SetNextPieces(types)
+		}
+		if err != nil {
+			c.sendError(protocol.CodeInvalidMessage, "Invalid next pieces: "+err.Error())
+			return false
+		}
 	case protocol.MessageTypeHardDrop:
 		log.Printf("[Client %s] Command: hard_drop", c.id)
-		c.game.HardDrop()
+		g.HardDrop()
 	case protocol.MessageTypeTogglePause:
 		log.Printf("[Client %s] Command: toggle_pause", c.id)
-		c.game.TogglePause()
+		// A match is paused by vote and resumed for everyone
+		if g.IsPlaying() && c.votesOnPause() {
+			c.requestPause()
+			return false
+		}
+		if g.IsPaused() && c.resumeMatch() {
+			return true
+		}
+		g.TogglePause()
+		// The other board of a doubles game follows the board addressed
+		paused := g.IsPaused()
+		for _, sess := range c.boardSessions() {
+			switch other := sess.Game(); {
+			case other == g:
+			case paused:
+				other.Pause()
+			default:
+				other.Resume()
+			}
+		}
 	case protocol.MessageTypePause:
 		log.Printf("[Client %s] Command: pause", c.id)
-		c.game.Pause()
+		if g.IsPlaying() && c.votesOnPause() {
+			c.requestPause()
+			return false
+		}
+		for _, sess := range c.boardSessions() {
+			sess.Game().Pause()
+		}
 	case protocol.MessageTypeResume:
 		log.Printf("[Client %s] Command: resume", c.id)
-		c.game.Resume()
+		if c.resumeMatch() {
+			return true
+		}
+		for _, sess := range c.boardSessions() {
+			sess.Game().Resume()
+		}
 	case protocol.MessageTypeRestart:
-		log.Printf("[Client %s] Command: restart", c.id)
-		// Create a new game instance
-		c.game = game.New()
+		log.Printf("[Client %s] Command: restart (force=%v)", c.id, ctrl.Force)
+		// A finished game has nothing to lose, otherwise ask for confirmation
+		if !ctrl.Force && !c.allOver() {
+			c.requestRestartConfirmation()
+			return false
+		}
+		c.restart()
+	case protocol.MessageTypeRestartConfirm:
+		log.Printf("[Client %s] Command: restart_confirm", c.id)
+		// Confirming twice or after the window expired is a no-op
+		if c.restartDeadline.IsZero() || c.server.Clock.Now().After(c.restartDeadline) {
+			c.restartDeadline = time.Time{}
+			c.sendError(protocol.CodeNotFound, "No restart pending")
+			return false
+		}
+		c.restart()
+	case protocol.MessageTypeSelectMode:
+		log.Printf("[Client %s] Command: select_mode %s", c.id, ctrl.Mode)
+		mode := game.Mode(ctrl.Mode)
+		if !mode.IsValid() {
+			c.sendError(protocol.CodeInvalidMessage, "Unknown game mode: "+ctrl.Mode)
+			return false
+		}
+		// Unranked modes would let a player dodge a rated match
+		if c.match != nil && !mode.Ranked() {
+			c.sendError(protocol.CodeNotAllowed, "Unranked modes are not available in a match")
+			return false
+		}
+		// The bot plays marathon and the winner is decided by topping out
+		if c.cpu != nil {
+			c.sendError(protocol.CodeNotAllowed, "Versus CPU is played in marathon mode")
+			return false
+		}
+		var level *puzzle.Level
+		if mode == game.ModePuzzle && ctrl.Puzzle != "" {
+			var ok bool
+			if level, ok = puzzle.Find(c.server.puzzles(), ctrl.Puzzle); !ok {
+				c.sendError(protocol.CodeNotFound, "Unknown puzzle: "+ctrl.Puzzle)
+				return false
+			}
+		}
+		// Selecting a mode starts a fresh game in that mode
+		c.mode = mode
+		c.digRows = ctrl.DigRows
+		c.puzzle = level
+		c.restart()
+	case protocol.MessageTypeSetName:
+		name, err := protocol.NormalizeName(ctrl.Name)
+		if err != nil {
+			c.sendError(protocol.CodeInvalidMessage, "Invalid name: "+err.Error())
+			return false
+		}
+		log.Printf("[Client %s] Command: set_name %q", c.id, name)
+		c.setName(name)
+		if c.match != nil {
+			c.server.matches.SetName(c.match, c.id, name)
+			c.server.sendMatchRoster(c.match, "")
+		}
+		return false
+	case protocol.MessageTypeChat:
+		c.handleChat(ctrl.Text)
+		return false
+	case protocol.MessageTypeEmote:
+		c.handleEmote(ctrl.Emote)
+		return false
+	case protocol.MessageTypePauseVote:
+		log.Printf("[Client %s] Command: pause_vote (agree=%v)", c.id, ctrl.Agree)
+		c.answerPauseVote(ctrl.Agree)
+		return false
+	case protocol.MessageTypeSaveGame:
+		c.handleSaveGame()
+	case protocol.MessageTypeLoadGame:
+		c.handleLoadGame(ctrl.Snapshot)
 	case protocol.MessageTypePong:
-		// WebSocket protocol-level pong is handled by SetPongHandler in readPump
-		// No need to handle application-level pong anymore
+		// Clients whose WebSocket library does not answer pings send pong
+		// messages instead; readPump already reset the heartbeat
+		return false
+	}
+	return true
+}
+
+// restart replaces the games of the client's boards with new ones
+func (c *Client) restart() {
+	c.restartDeadline = time.Time{}
+	for _, sess := range c.boardSessions() {
+		sess.setGame(c.newGame())
+	}
+	if c.cpu != nil {
+		c.cpu.session.setGame(c.newCPUGame())
+	}
+	c.recordNewGame("")
+}
+
+// newGame creates a game in the client's mode, seeded by its match if it
+// joined one and handicapped as its seat in the match is
+func (c *Client) newGame() *game.Game {
+	cfg := game.Config{Mode: c.mode, DigRows: c.digRows, Puzzle: c.puzzleLevel(), SpawnDelay: c.server.SpawnDelay, LineClearDelay: c.server.LineClearDelay, Clock: c.server.Clock}
+	if c.match != nil {
+		cfg.Seed = c.match.Seed
+		cfg.Handicap = c.match.Handicap(c.seat)
+	}
+	return game.NewWithConfig(cfg)
+}
+
+// puzzleLevel returns the level of the client's puzzle game: the one chosen
+// with select_mode, or else the server's first
+func (c *Client) puzzleLevel() *puzzle.Level {
+	if c.mode != game.ModePuzzle {
+		return nil
+	}
+	if c.puzzle != nil {
+		return c.puzzle
+	}
+	return &c.server.puzzles()[0]
+}
+
+// puzzles returns the levels offered in puzzle mode
+func (s *Server) puzzles() []puzzle.Level {
+	if len(s.Puzzles) == 0 {
+		return puzzle.Builtin
+	}
+	return s.Puzzles
+}
+
+// requestRestartConfirmation asks the client to confirm a restart
+// Repeated requests while one is pending just extend the window
+func (c *Client) requestRestartConfirmation() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in requestRestartConfirmation: %v", r)
+		}
+	}()
+
+	c.restartDeadline = c.server.Clock.Now().Add(restartConfirmWindow)
+
+	msg := protocol.NewRestartPendingMessage(restartConfirmWindow)
+	data, err := msg.Serialize()
+	if err != nil {
+		log.Printf("Error serializing restart pending: %v", err)
 		return
 	}
 
-	c.sendState()
+	c.queue(data)
+}
+
+// sendMatchJoined tells a client which match it joined, the seed it plays
+// and who else is in it; the other players get the new roster too
+func (c *Client) sendMatchJoined() {
+	if c.match == nil {
+		return
+	}
+	log.Printf("[Client %s] Joined match %s (seed %d, %d players)", c.id, c.match.ID, c.match.Seed, c.server.matches.Players(c.match))
+
+	// The hub may not have added this client to the client list yet
+	c.sendMatchRoster(c.server.matches.Roster(c.match))
+	c.server.sendMatchRoster(c.match, c.id)
+	c.sendOpponentStates()
+}
 
-	// Check for game over
-	if c.game.IsGameOver() {
-		c.sendGameOver()
+// sendMatchRoster sends the match's roster to its connected players except skip
+func (s *Server) sendMatchRoster(match *Match, skip string) {
+	roster := s.matches.Roster(match)
+	for _, client := range s.matchClients(match) {
+		if client.id != skip {
+			client.sendMatchRoster(roster)
+		}
 	}
 }
 
-// updateGame updates the game state
+// matchClients returns the connected clients of a match's players
+func (s *Server) matchClients(match *Match) []*Client {
+	ids := s.matches.clientIDs(match)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(ids))
+	for _, id := range ids {
+		if client, ok := s.clients[id]; ok {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// sendMatchRoster sends the match's seed and roster to the client
+func (c *Client) sendMatchRoster(roster []protocol.MatchPlayer) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendMatchRoster: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewMatchJoinedMessage(c.match.ID, c.match.Seed, roster).Serialize()
+	if err != nil {
+		log.Printf("Error serializing match joined: %v", err)
+		return
+	}
+	c.queue(data)
+}
+
+// sendIdleTimeout tells the client it is about to be disconnected for inactivity
+func (c *Client) sendIdleTimeout(idle time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendIdleTimeout: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewIdleTimeoutMessage(idle).Serialize()
+	if err != nil {
+		log.Printf("Error serializing idle timeout: %v", err)
+		return
+	}
+
+	c.queue(data)
+}
+
+// updateGame updates the game state of the client's boards, refreshing the
+// ones that have not sent a frame for clockRefreshInterval
 func (c *Client) updateGame() {
-	if c.game.IsPlaying() {
-		c.game.Update()
-		c.sendState()
+	if !c.runsGame() {
+		return
+	}
+	defer c.refreshState()
+	updated := false
+	var ended []int
+	for i, sess := range c.boardSessions() {
+		g := sess.Game()
+		if !g.IsPlaying() {
+			continue
+		}
+		g.Update()
+		updated = true
+		if g.IsGameOver() {
+			ended = append(ended, i)
+		}
+	}
+	if !updated {
+		return
+	}
+
+	c.sendState()
+	c.sendScoreEvents()
+	c.sendAttacks()
+	for _, i := range ended {
+		c.sendGameOver(i)
+	}
+	c.syncCoop(len(ended) > 0)
+}
+
+// gameLoop advances the client's game, waking when gravity is next due
+// rather than on a fixed tick so fast levels are not throttled
+func (c *Client) gameLoop() {
+	defer close(c.stopped)
+	timer := c.server.Clock.NewTimer(c.nextTick())
+	defer timer.Stop()
 
-		if c.game.IsGameOver() {
-			c.sendGameOver()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.server.done:
+			return
+		case <-c.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C():
+				default:
+				}
+			}
+		case <-timer.C():
+			c.updateGame()
+			c.updateCPU()
+			c.relayState()
+			c.expirePauseVote()
+			if c.checkIdle() {
+				return
+			}
 		}
+		timer.Reset(c.nextTick())
 	}
 }
 
-// sendState sends the current game state to the client
+// recordInput notes that the client sent a control command
+func (c *Client) recordInput() {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+
+	c.lastInput = c.server.Clock.Now()
+	c.inputs++
+	c.idlePaused = false
+}
+
+// InputStats returns how many control commands the client sent and when it
+// sent the last one, or connected if it sent none
+func (c *Client) InputStats() (int, time.Time) {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	return c.inputs, c.lastInput
+}
+
+// checkIdle pauses the game of a client that stopped sending input and
+// disconnects it once IdleTimeout passes; returns true if it disconnected
+func (c *Client) checkIdle() bool {
+	c.inputMu.Lock()
+	idle := c.server.Clock.Since(c.lastInput)
+	pause := c.server.IdlePause > 0 && idle >= c.server.IdlePause && !c.idlePaused
+	if pause {
+		c.idlePaused = true
+	}
+	c.inputMu.Unlock()
+
+	if c.server.IdleTimeout > 0 && idle >= c.server.IdleTimeout {
+		log.Printf("[Client %s] Idle for %v, disconnecting", c.id, idle.Round(time.Second))
+		c.sendIdleTimeout(idle)
+		c.disconnect(protocol.CloseIdleTimeout, "idle timeout")
+		return true
+	}
+
+	// A co-op player waiting for their partner's piece is not holding up the game
+	if pause && c.anyPlaying() && c.myTurn() {
+		log.Printf("[Client %s] Idle for %v, pausing", c.id, idle.Round(time.Second))
+		for _, sess := range c.boardSessions() {
+			sess.Game().Pause()
+		}
+		c.sendState()
+		c.syncCoop(false)
+	}
+	return false
+}
+
+// nextTick returns how long gameLoop should sleep before the next update of
+// any of the client's boards
+func (c *Client) nextTick() time.Duration {
+	if !c.runsGame() {
+		return maxTickInterval
+	}
+	d := c.cpuTick()
+	for _, sess := range c.boardSessions() {
+		d = min(d, tickInterval(sess.Game()))
+	}
+	return d
+}
+
+// tickInterval returns how long a game loop should sleep before updating g
+func tickInterval(g *game.Game) time.Duration {
+	if !g.IsPlaying() {
+		return maxTickInterval
+	}
+	d := g.TimeUntilDrop()
+	if d > maxTickInterval {
+		d = maxTickInterval
+	}
+	if d < minTickInterval {
+		d = minTickInterval
+	}
+	return d
+}
+
+// wakeGameLoop asks gameLoop to reschedule without blocking
+func (c *Client) wakeGameLoop() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sendState sends the current game state of the client's boards to the
+// client, a frame for each board that changed
 func (c *Client) sendState() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -410,84 +1645,262 @@ func (c *Client) sendState() {
 		}
 	}()
 
-	msg := protocol.NewStateMessage(c.game)
-	data, err := msg.Serialize()
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	for i, sess := range c.boardSessions() {
+		c.sendBoardState(i, sess, false)
+	}
+	c.relayState()
+}
+
+// refreshState sends the state of each playing or paused board whose last
+// frame is older than clockRefreshInterval, even if nothing changed, so the
+// client's game clock and timers keep moving
+func (c *Client) refreshState() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in refreshState: %v", r)
+		}
+	}()
+
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	now := c.server.Clock.Now()
+	for i, sess := range c.boardSessions() {
+		g := sess.Game()
+		if (g.IsPlaying() || g.IsPaused()) && now.Sub(c.sent[i].at) >= clockRefreshInterval {
+			c.sendBoardState(i, sess, true)
+		}
+	}
+}
+
+// sendBoardState sends the state of board i unless nothing changed since its
+// last frame and force is not set; must be called with stateMu held
+func (c *Client) sendBoardState(i int, sess *Session, force bool) {
+	g := sess.Game()
+	gameSeq := g.GetSeq()
+	// An input that changed nothing still needs its acknowledgement
+	sent := c.sent[i]
+	if !force && g == sent.game && gameSeq == sent.gameSeq && c.inputSeq[i] == sent.ackSeq {
+		return
+	}
+
+	sess.watchers.notify()
+
+	msg := protocol.NewSequencedStateMessage(g, c.frameSeq+1, c.inputSeq[i])
+	if c.compact {
+		msg = protocol.NewCompactStateMessage(g, c.frameSeq+1, c.inputSeq[i])
+	}
+	data, err := c.forBoard(msg, i).Serialize()
 	if err != nil {
 		log.Printf("Error serializing state: %v", err)
 		return
 	}
 
-	select {
-	case c.send <- data:
-	default:
-		// Channel full or closed, skip this message
+	// A dropped frame is resent on the next change check
+	if c.queue(data) {
+		c.frameSeq++
+		c.sent[i] = sentFrame{game: g, gameSeq: gameSeq, ackSeq: c.inputSeq[i], at: c.server.Clock.Now()}
+	}
+}
+
+// sendScoreEvents sends an event for each line clear the game scored to the
+// client and its co-op partners
+func (c *Client) sendScoreEvents() {
+	for i, sess := range c.boardSessions() {
+		for _, event := range sess.Game().TakeScoreEvents() {
+			c.recordEvent(AuditEvent{Kind: AuditClear, Lines: event.Lines, Points: event.Points, Level: event.Level})
+			if event.LevelUp {
+				c.recordEvent(AuditEvent{Kind: AuditLevelUp, Level: event.Level})
+			}
+			data, err := c.forBoard(protocol.NewScoreEventMessage(event), i).Serialize()
+			if err != nil {
+				log.Printf("Error serializing score event: %v", err)
+				continue
+			}
+
+			c.sendScoreEvent(data)
+			for _, peer := range c.coopPeers() {
+				peer.sendScoreEvent(data)
+			}
+		}
+	}
+}
+
+// sendScoreEvent sends a serialized score event to the client
+func (c *Client) sendScoreEvent(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendScoreEvent: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
+
+// sendAttacks sends the garbage of every attack the game produced to the CPU
+// opponent, if the client has one, and announces each perfect clear to the
+// client and the other players of its match
+func (c *Client) sendAttacks() {
+	for _, sess := range c.boardSessions() {
+		for _, attack := range sess.Game().TakeAttacks() {
+			if c.cpu != nil {
+				c.cpu.session.Game().ReceiveGarbage(attack.Garbage)
+			}
+			if !attack.PerfectClear {
+				continue
+			}
+
+			data, err := protocol.NewPerfectClearAttackMessage(attack, c.seat, c.Name()).Serialize()
+			if err != nil {
+				log.Printf("Error serializing perfect clear attack: %v", err)
+				continue
+			}
+			c.sendPerfectClear(data)
+			if c.match == nil {
+				continue
+			}
+			for _, client := range c.server.matchClients(c.match) {
+				if client != c {
+					client.sendPerfectClear(data)
+				}
+			}
+		}
+	}
+}
+
+// sendPerfectClear sends a serialized perfect clear attack to the client
+func (c *Client) sendPerfectClear(data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendPerfectClear: %v", r)
+		}
+	}()
+
+	c.queue(data)
+}
+
+// rejectInvalid answers a message the server cannot read with the reason,
+// disconnecting a client that sends maxInvalidMessages of them in a row
+func (c *Client) rejectInvalid(err error) {
+	var invalid *protocol.ValidationError
+	if !errors.As(err, &invalid) {
+		invalid = &protocol.ValidationError{Kind: protocol.KindInvalidJSON, Message: err.Error()}
+	}
+	c.sendErrorMessage(protocol.NewValidationErrorMessage(invalid))
+	if c.invalidRun++; c.invalidRun == maxInvalidMessages {
+		log.Printf("[Client %s] %d invalid messages in a row, disconnecting", c.id, c.invalidRun)
+		c.disconnect(protocol.CloseProtocolError, "too many invalid messages")
 	}
 }
 
-// sendError sends an error message to the client
-func (c *Client) sendError(errMsg string) {
+// sendError sends an error message with the given error code to the client
+func (c *Client) sendError(code protocol.ErrorCode, errMsg string) {
+	c.sendErrorMessage(protocol.NewErrorMessage(code, errMsg))
+}
+
+// sendErrorMessage sends an error message to the client, answering the
+// control message being handled
+func (c *Client) sendErrorMessage(msg *protocol.Message) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Recovered in sendError: %v", r)
 		}
 	}()
 
-	msg := protocol.NewErrorMessage(errMsg, 400)
+	msg.ReplyTo, c.replyTo = c.replyTo, ""
+	c.markSpanError(msg)
+	if data, ok := msg.Data.(protocol.ErrorMessage); ok {
+		c.recordEvent(AuditEvent{Kind: AuditError, Detail: data.Error})
+	}
 	data, err := msg.Serialize()
 	if err != nil {
 		log.Printf("Error serializing error: %v", err)
 		return
 	}
 
-	select {
-	case c.send <- data:
-	default:
-		// Channel full or closed, skip this message
-	}
+	c.queue(data)
 }
 
-// sendPing sends a ping message to the client
-func (c *Client) sendPing() {
+// acknowledge answers the control message being handled with an ack carrying
+// the game state, unless it has no ID or was answered with an error
+func (c *Client) acknowledge() {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("Recovered in sendPing: %v", r)
+			log.Printf("Recovered in acknowledge: %v", r)
 		}
 	}()
 
-	msg := protocol.NewPingMessage(time.Now().Unix())
-	data, err := msg.Serialize()
-	if err != nil {
-		log.Printf("Error serializing ping: %v", err)
+	if c.replyTo == "" {
 		return
 	}
+	id := c.replyTo
+	c.replyTo = ""
 
-	select {
-	case c.send <- data:
-	default:
-		// Channel full or closed, skip this message
+	data, err := c.forBoard(protocol.NewAckMessage(id, c.target()), c.board).Serialize()
+	if err != nil {
+		log.Printf("Error serializing ack: %v", err)
+		return
 	}
+
+	c.queue(data)
 }
 
-// sendGameOver sends a game over message to the client
-func (c *Client) sendGameOver() {
+// sendGameOver sends the client a game over message for board i
+func (c *Client) sendGameOver(i int) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Recovered in sendGameOver: %v", r)
 		}
 	}()
 
-	msg := protocol.NewGameOverMessage(c.game)
+	g := c.boardGame(i)
+	c.recordEvent(AuditEvent{Kind: AuditGameOver, Score: g.GetScore(), Level: g.GetLevel(), Lines: g.GetLines()})
+	// Games loaded from a client's saved game may have been edited, so they
+	// are not ranked or added to the analytics, and neither are practice
+	// games, the boards of a doubles game, the shared game of a co-op game, a
+	// game against the CPU, whose garbage decides it, or a handicapped game
+	if c.partner == nil && c.coop == nil && c.cpu == nil && !c.session.Loaded() && g.GetMode().Ranked() && g.GetHandicap().IsZero() {
+		c.server.leaderboard.Add(protocol.ScoreEntry{
+			Name:          c.Name(),
+			Score:         g.GetScore(),
+			Level:         g.GetLevel(),
+			Lines:         g.GetLines(),
+			Seed:          g.GetSeed(),
+			Ruleset:       string(g.GetRuleset()),
+			ClientVersion: c.version,
+			Station:       c.station,
+			Mode:          string(g.GetMode()),
+			ElapsedMs:     int(g.GetModeStatus().Elapsed.Milliseconds()),
+			EndedAt:       c.server.Clock.Now(),
+		})
+		c.recordPlayerGame()
+		c.server.analytics.AddGame(g.GetHeatmap())
+	}
+	if c.match != nil {
+		if results := c.server.matches.Finish(c.match, c.id, g.GetScore()); results != nil {
+			c.server.reportMatch(c.match, results)
+		}
+	}
+
+	msg := c.forBoard(protocol.NewGameOverMessage(g, c.version), i)
 	data, err := msg.Serialize()
 	if err != nil {
 		log.Printf("Error serializing game over: %v", err)
 		return
 	}
 
-	select {
-	case c.send <- data:
-	default:
-		// Channel full or closed, skip this message
+	c.queue(data)
+}
+
+// matchID returns the id of a match, or "" for clients playing alone
+func matchID(m *Match) string {
+	if m == nil {
+		return ""
 	}
+	return m.ID
 }
 
 // generateClientID generates a unique client ID
@@ -503,12 +1916,16 @@ func generateClientID() string {
 
 // adminBroadcastLoop broadcasts client status to admin clients every second
 func (s *Server) adminBroadcastLoop() {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := s.Clock.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
-		s.broadcastClientStatus()
+		select {
+		case <-ticker.C():
+			s.broadcastClientStatus()
+		case <-s.done:
+			return
+		}
 	}
 }
 
@@ -532,7 +1949,10 @@ func (s *Server) broadcastClientStatus() {
 			// Connection error, close and remove
 			conn.Close()
 			go func() {
-				s.unregisterAdmin <- conn
+				select {
+				case s.unregisterAdmin <- conn:
+				case <-s.done:
+				}
 			}()
 		}
 	}
@@ -547,19 +1967,34 @@ func (s *Server) getClientsInfo() map[string]interface{} {
 	// Prepare client data
 	clients := make([]map[string]interface{}, 0, len(s.clients))
 	for _, client := range s.clients {
-		gameState := client.game.GetState().String()
-		score := client.game.GetScore()
-		level := client.game.GetLevel()
-		lines := client.game.GetLines()
+		g := client.Game()
+		gameState := g.GetState().String()
+		score := g.GetScore()
+		level := g.GetLevel()
+		lines := g.GetLines()
+		pieces := g.GetPiecesPlaced()
+		inputs, lastInput := client.InputStats()
 
 		clients = append(clients, map[string]interface{}{
-			"id":          client.id,
-			"address":     client.address,
-			"connectTime": client.connectTime,
-			"gameState":   gameState,
-			"score":       score,
-			"level":       level,
-			"lines":       lines,
+			"id":            client.id,
+			"session":       client.session.ID,
+			"name":          client.Name(),
+			"station":       client.station,
+			"match":         matchID(client.match),
+			"address":       client.address,
+			"connectTime":   client.connectTime,
+			"gameState":     gameState,
+			"score":         score,
+			"level":         level,
+			"lines":         lines,
+			"droppedFrames": client.DroppedFrames(),
+
+			// Telemetry for spotting stuck or abusive sessions
+			"dropIntervalMs":  g.GetDropInterval().Milliseconds(),
+			"piecesPlaced":    pieces,
+			"piecesPerSecond": perSecond(pieces, g.GetElapsed()),
+			"inputRate":       perSecond(inputs, s.Clock.Since(client.connectTime)),
+			"lastActivity":    lastInput,
 		})
 	}
 
@@ -571,3 +2006,11 @@ func (s *Server) getClientsInfo() map[string]interface{} {
 		"timestamp":      time.Now(),
 	}
 }
+
+// perSecond returns the rate of n events over d, rounded to hundredths
+func perSecond(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return math.Round(float64(n)/d.Seconds()*100) / 100
+}
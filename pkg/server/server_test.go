@@ -0,0 +1,193 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestClientsInfoTelemetry verifies the admin broadcast reports the pieces
+// placed and the rates of pieces and input per second
+func TestClientsInfoTelemetry(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	c := &Client{id: "c1", send: make(chan []byte, 4), server: s, connectTime: clk.Now(), lastInput: clk.Now()}
+	c.session = s.sessions.Create(c.newGame(), clk.Now())
+	s.clients[c.id] = c
+
+	clk.Advance(2 * time.Second)
+	for i := 0; i < 4; i++ {
+		c.recordInput()
+		c.Game().HardDrop()
+	}
+
+	clients := s.getClientsInfo()["clients"].([]map[string]interface{})
+	if len(clients) != 1 {
+		t.Fatalf("clients = %d, want 1", len(clients))
+	}
+	info := clients[0]
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"piecesPlaced", 4},
+		{"piecesPerSecond", 2.0},
+		{"inputRate", 2.0},
+		{"dropIntervalMs", c.Game().GetDropInterval().Milliseconds()},
+		{"lastActivity", clk.Now()},
+	}
+	for _, tt := range tests {
+		if got := info[tt.key]; got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestStateRefresh verifies a paused game, whose state does not change, still
+// gets a state frame every clockRefreshInterval
+func TestStateRefresh(t *testing.T) {
+	s := New(":0")
+	clk := clock.NewFake(time.Unix(0, 0))
+	s.Clock = clk
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s, connectTime: clk.Now(), lastInput: clk.Now()}
+	c.session = s.sessions.Create(c.newGame(), clk.Now())
+	s.clients[c.id] = c
+
+	c.Game().Pause()
+	c.sendState()
+	if got := len(queue.Drain(t, c.send).Of(protocol.MessageTypeState)); got != 1 {
+		t.Fatalf("got %d state frames after pausing, want 1", got)
+	}
+
+	tests := []struct {
+		advance time.Duration
+		want    int
+	}{
+		{clockRefreshInterval / 2, 0},
+		{clockRefreshInterval / 2, 1},
+		{clockRefreshInterval, 1},
+	}
+	for i, tt := range tests {
+		clk.Advance(tt.advance)
+		c.updateGame()
+		if got := len(queue.Drain(t, c.send).Of(protocol.MessageTypeState)); got != tt.want {
+			t.Errorf("tick %d: got %d state frames, want %d", i, got, tt.want)
+		}
+	}
+}
+
+// TestGameOverStamps verifies a finished game's result carries its seed,
+// ruleset and the client's version, in the game_over message and on the
+// leaderboard for ranked games
+func TestGameOverStamps(t *testing.T) {
+	tests := []struct {
+		mode       game.Mode
+		seed       int64
+		version    string
+		wantRanked bool
+	}{
+		{game.ModeMarathon, 7, "tetris/1.2.0", true},
+		{game.ModeSprint, 99, "", true},
+		{game.ModePractice, 7, "tetris/1.2.0", false},
+	}
+
+	for _, tt := range tests {
+		s := New(":0")
+		clk := clock.NewFake(time.Unix(0, 0))
+		s.Clock = clk
+		c := &Client{id: "c1", name: "ann", version: tt.version, station: "kiosk-1", mode: tt.mode,
+			send: make(chan []byte, 256), server: s}
+		g := game.NewWithConfig(game.Config{Seed: tt.seed, Mode: tt.mode, Clock: clk})
+		c.session = s.sessions.Create(g, clk.Now())
+		for i := 0; i < 100 && !g.IsGameOver(); i++ {
+			g.HardDrop()
+		}
+		if !g.IsGameOver() {
+			t.Fatalf("%s: game still playing after 100 hard drops", tt.mode)
+		}
+		c.sendGameOver(0)
+
+		var over protocol.GameOverMessage
+		if !queue.Drain(t, c.send).Of(protocol.MessageTypeGameOver).Last(t, &over) {
+			t.Fatalf("%s: no game_over sent", tt.mode)
+		}
+		wantOver := [...]string{string(tt.mode), string(game.RulesetClassic), tt.version}
+		if got := [...]string{over.Mode, over.Ruleset, over.ClientVersion}; got != wantOver || over.Seed != tt.seed {
+			t.Errorf("%s: game_over mode, ruleset, version = %v seed %d, want %v seed %d", tt.mode, got, over.Seed, wantOver, tt.seed)
+		}
+
+		top := s.leaderboard.Top(maxLeaderboardEntries, "")
+		if !tt.wantRanked {
+			if len(top) != 0 {
+				t.Errorf("%s: leaderboard = %+v, want unranked", tt.mode, top)
+			}
+			continue
+		}
+		want := protocol.ScoreEntry{Name: "ann", Score: over.Score, Level: over.Level, Lines: over.Lines, Seed: tt.seed,
+			Ruleset: string(game.RulesetClassic), ClientVersion: tt.version, Station: "kiosk-1", Mode: string(tt.mode),
+			ElapsedMs: over.ElapsedMs, EndedAt: clk.Now()}
+		if len(top) != 1 || top[0] != want {
+			t.Errorf("%s: leaderboard = %+v, want %+v", tt.mode, top, want)
+		}
+	}
+}
+
+// TestGameLoop verifies the game loop sends a state frame when gravity moves
+// the piece on a tick, and returns once the connection ends or the server
+// shuts down
+func TestGameLoop(t *testing.T) {
+	stops := []struct {
+		name string
+		stop func(s *Server, c *Client)
+	}{
+		{"connection ended", func(s *Server, c *Client) { close(c.done) }},
+		{"server shut down", func(s *Server, c *Client) { close(s.done) }},
+	}
+
+	for _, tt := range stops {
+		s := New(":0")
+		clk := clock.NewFake(time.Unix(0, 0))
+		s.Clock = clk
+		c := &Client{id: "c1", send: make(chan []byte, 16), server: s, lastInput: clk.Now(),
+			done: make(chan struct{}), stopped: make(chan struct{}), wake: make(chan struct{}, 1)}
+		c.session = s.sessions.Create(c.newGame(), clk.Now())
+		y := c.Game().GetCurrentPiece().Y
+		go c.gameLoop()
+
+		clk.BlockUntil(1)
+		clk.Advance(c.Game().GetDropInterval())
+		deadline := time.Now().Add(5 * time.Second)
+		for !movedDown(t, c, y) {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: no state frame with the piece a row lower after a tick", tt.name)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		tt.stop(s, c)
+		select {
+		case <-c.stopped:
+		case <-time.After(5 * time.Second):
+			t.Errorf("%s: game loop still running", tt.name)
+		}
+	}
+}
+
+// movedDown reports whether a state frame queued for a client shows the
+// current piece a row below y, draining its queue
+func movedDown(t *testing.T, c *Client, y int) bool {
+	t.Helper()
+	for _, msg := range queue.Drain(t, c.send).Of(protocol.MessageTypeState) {
+		var state protocol.StateMessage
+		msg.Decode(t, &state)
+		if state.CurrentPiece.Y == y+1 {
+			return true
+		}
+	}
+	return false
+}
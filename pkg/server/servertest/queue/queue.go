@@ -0,0 +1,82 @@
+// Package queue reads the messages a server queued for a client, for the
+// tests of package server that drive a Client without a connection; those
+// tests cannot import servertest, which imports package server
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Message is a queued message with its payload left undecoded
+type Message struct {
+	Type    protocol.MessageType `json:"type"`
+	ReplyTo string               `json:"reply_to,omitempty"`
+	Data    json.RawMessage      `json:"data"`
+}
+
+// Decode decodes the payload into v, such as a protocol.StateMessage,
+// failing the test if it cannot
+func (m Message) Decode(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(m.Data, v); err != nil {
+		t.Fatalf("decode %s: %v", m.Type, err)
+	}
+}
+
+// Messages are the messages read from a send queue, oldest first
+type Messages []Message
+
+// Drain reads every message queued on send without waiting for more,
+// failing the test if one is not JSON
+func Drain(t testing.TB, send chan []byte) Messages {
+	t.Helper()
+	var got Messages
+	for len(send) > 0 {
+		var msg Message
+		if err := json.Unmarshal(<-send, &msg); err != nil {
+			t.Fatalf("queued message is not JSON: %v", err)
+		}
+		got = append(got, msg)
+	}
+	return got
+}
+
+// Of returns the messages of the given types
+func (ms Messages) Of(types ...protocol.MessageType) Messages {
+	var got Messages
+	for _, msg := range ms {
+		for _, t := range types {
+			if msg.Type == t {
+				got = append(got, msg)
+				break
+			}
+		}
+	}
+	return got
+}
+
+// Last decodes the payload of the last message into v; returns false if
+// there are no messages
+func (ms Messages) Last(t testing.TB, v interface{}) bool {
+	t.Helper()
+	if len(ms) == 0 {
+		return false
+	}
+	ms[len(ms)-1].Decode(t, v)
+	return true
+}
+
+// Errors returns the payloads of the error messages
+func (ms Messages) Errors(t testing.TB) []protocol.ErrorMessage {
+	t.Helper()
+	var errs []protocol.ErrorMessage
+	for _, msg := range ms.Of(protocol.MessageTypeError) {
+		var e protocol.ErrorMessage
+		msg.Decode(t, &e)
+		errs = append(errs, e)
+	}
+	return errs
+}
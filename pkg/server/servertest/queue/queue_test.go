@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestDrain verifies queued messages are read in order and picked by type
+func TestDrain(t *testing.T) {
+	send := make(chan []byte, 4)
+	for _, msg := range []*protocol.Message{
+		protocol.NewNoticeMessage("first", time.Unix(0, 0)),
+		protocol.NewErrorMessage(protocol.CodeNotFound, "No pause vote open"),
+		protocol.NewNoticeMessage("second", time.Unix(0, 0)),
+	} {
+		data, err := msg.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		send <- data
+	}
+
+	msgs := Drain(t, send)
+	if len(msgs) != 3 || len(send) != 0 {
+		t.Fatalf("Drain() = %d messages leaving %d queued, want 3 leaving 0", len(msgs), len(send))
+	}
+	var notice protocol.NoticeMessage
+	if !msgs.Of(protocol.MessageTypeNotice).Last(t, &notice) || notice.Text != "second" {
+		t.Errorf("last notice = %+v, want second", notice)
+	}
+	if errs := msgs.Errors(t); len(errs) != 1 || errs[0].Code != protocol.CodeNotFound {
+		t.Errorf("Errors() = %+v, want one not_found", errs)
+	}
+	if Drain(t, send).Of(protocol.MessageTypeNotice).Last(t, &notice) {
+		t.Error("Last() of an empty queue = true, want false")
+	}
+}
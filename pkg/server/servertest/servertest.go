@@ -0,0 +1,218 @@
+// Package servertest serves a tetris server over httptest and dials it like a
+// real client, for tests of the WebSocket protocol and HTTP endpoints
+package servertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server"
+)
+
+// Timeout is how long reads and waits take before the test fails
+const Timeout = 5 * time.Second
+
+// Server is a tetris server listening on a random local port
+type Server struct {
+	*server.Server
+	URL string // Base URL, such as http://127.0.0.1:34567
+
+	t    testing.TB
+	http *httptest.Server
+}
+
+// New starts a server, after configure has set its options, and shuts it
+// down when the test ends
+func New(t testing.TB, configure ...func(*server.Server)) *Server {
+	t.Helper()
+
+	s := server.New("")
+	s.ShutdownGrace = 0
+	for _, fn := range configure {
+		fn(s)
+	}
+	ts := &Server{Server: s, t: t, http: httptest.NewServer(s.Handler())}
+	ts.URL = ts.http.URL
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		s.Shutdown(ctx)
+		ts.http.Close()
+	})
+	return ts
+}
+
+// WSURL returns the WebSocket URL of path, such as "/ws?encoding=compact"
+func (s *Server) WSURL(path string) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + path
+}
+
+// Dial connects to the WebSocket endpoint at path, failing the test if it cannot
+func (s *Server) Dial(path string) *Conn {
+	s.t.Helper()
+
+	conn, _, err := s.DialHeader(path, nil)
+	if err != nil {
+		s.t.Fatalf("dial %s: %v", path, err)
+	}
+	return conn
+}
+
+// DialHeader connects to the WebSocket endpoint at path with the given
+// request headers, such as Origin; the response tells why a dial failed
+func (s *Server) DialHeader(path string, header http.Header) (*Conn, *http.Response, error) {
+	ws, resp, err := websocket.DefaultDialer.Dial(s.WSURL(path), header)
+	if err != nil {
+		return nil, resp, err
+	}
+	c := &Conn{Conn: ws, t: s.t}
+	s.t.Cleanup(func() { ws.Close() })
+	return c, resp, nil
+}
+
+// Do sends an HTTP request to path and decodes the JSON response into v,
+// unless v is nil; returns the status code
+func (s *Server) Do(method, path string, body interface{}, v interface{}) int {
+	s.t.Helper()
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			s.t.Fatalf("encode %s %s: %v", method, path, err)
+		}
+	}
+	req, err := http.NewRequest(method, s.URL+path, &reqBody)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			s.t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// Conn is a WebSocket connection to the server
+type Conn struct {
+	*websocket.Conn
+
+	t       testing.TB
+	pending [][]byte // Messages of a batched frame not read yet
+}
+
+// Send sends a control message, failing the test if it cannot
+func (c *Conn) Send(msg protocol.ControlMessage) {
+	c.t.Helper()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("encode %s: %v", msg.Type, err)
+	}
+	if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
+		c.t.Fatalf("send %s: %v", msg.Type, err)
+	}
+}
+
+// Next returns the next message of one of types, or of any type if none are
+// given, skipping the others; fails the test if none arrives within Timeout
+func (c *Conn) Next(types ...protocol.MessageType) *protocol.Message {
+	c.t.Helper()
+
+	deadline := time.Now().Add(Timeout)
+	for {
+		data, err := c.read(deadline)
+		if err != nil {
+			c.t.Fatalf("waiting for %v: %v", types, err)
+		}
+		msg, err := protocol.DeserializeMessage(data)
+		if err != nil {
+			c.t.Fatalf("waiting for %v: %v", types, err)
+		}
+		if len(types) == 0 {
+			return msg
+		}
+		for _, t := range types {
+			if msg.Type == t {
+				return msg
+			}
+		}
+	}
+}
+
+// ReadJSON reads the next message without decoding it as a protocol
+// message, for endpoints such as /ws/admin that send their own JSON
+func (c *Conn) ReadJSON(v interface{}) {
+	c.t.Helper()
+
+	data, err := c.read(time.Now().Add(Timeout))
+	if err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		c.t.Fatalf("decode %s: %v", data, err)
+	}
+}
+
+// read returns the next message; the server batches queued messages into
+// one frame separated by newlines
+func (c *Conn) read(deadline time.Time) ([]byte, error) {
+	for len(c.pending) == 0 {
+		c.SetReadDeadline(deadline)
+		_, frame, err := c.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range bytes.Split(frame, []byte{'\n'}) {
+			if len(data) > 0 {
+				c.pending = append(c.pending, data)
+			}
+		}
+	}
+	data := c.pending[0]
+	c.pending = c.pending[1:]
+	return data, nil
+}
+
+// Decode converts the payload of a message into a typed message, such as a
+// protocol.StateMessage, failing the test if it cannot
+func Decode(t testing.TB, msg *protocol.Message, v interface{}) {
+	t.Helper()
+
+	raw, err := json.Marshal(msg.Data)
+	if err == nil {
+		err = json.Unmarshal(raw, v)
+	}
+	if err != nil {
+		t.Fatalf("decode %s: %v", msg.Type, err)
+	}
+}
+
+// Eventually polls cond until it holds, failing the test with what if it
+// does not within Timeout
+func Eventually(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(Timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,39 @@
+package servertest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestServer verifies a client can play over the harness and that the
+// server sees it come and go
+func TestServer(t *testing.T) {
+	s := New(t)
+	conn := s.Dial("/ws")
+
+	var state protocol.StateMessage
+	Decode(t, conn.Next(protocol.MessageTypeState), &state)
+	if state.State != "playing" {
+		t.Errorf("first state = %q, want playing", state.State)
+	}
+
+	conn.Send(protocol.ControlMessage{Type: protocol.MessageTypeRotate, ID: "r1"})
+	if ack := conn.Next(protocol.MessageTypeAck); ack.ReplyTo != "r1" {
+		t.Errorf("ack reply_to = %q, want r1", ack.ReplyTo)
+	}
+
+	var health struct {
+		Clients int `json:"clients"`
+	}
+	if code := s.Do(http.MethodGet, "/health", nil, &health); code != http.StatusOK || health.Clients != 1 {
+		t.Errorf("GET /health = %d with %d clients, want 200 with 1", code, health.Clients)
+	}
+
+	conn.Close()
+	Eventually(t, "the client to leave", func() bool {
+		s.Do(http.MethodGet, "/health", nil, &health)
+		return health.Clients == 0
+	})
+}
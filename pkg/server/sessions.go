@@ -0,0 +1,222 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// Session is the game of a WebSocket player, kept by the GameManager under its
+// own id rather than by the connection playing it, so connections can come
+// and go while observers follow the game
+type Session struct {
+	ID      string
+	Created time.Time
+
+	// game is replaced when the player restarts; guarded by mu
+	game   *game.Game
+	loaded bool // game was loaded from a snapshot sent by the client
+	mu     sync.RWMutex
+
+	watchers watchers // Told of every state change, for the event stream
+
+	// orphan is set for a session restored from a snapshot until a connection
+	// claims it; guarded by the GameManager's mu
+	orphan bool
+
+	done      chan struct{} // Closed when the session is removed
+	closeOnce sync.Once
+}
+
+// Game returns the session's current game
+func (s *Session) Game() *game.Game {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.game
+}
+
+// Loaded reports whether the current game was loaded from a client's saved game
+func (s *Session) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
+
+// setGame replaces the session's game, as on a restart
+func (s *Session) setGame(g *game.Game) {
+	s.replaceGame(g, false)
+}
+
+// loadGame replaces the session's game with a saved game sent by the client
+func (s *Session) loadGame(g *game.Game) {
+	s.replaceGame(g, true)
+}
+
+// replaceGame replaces the session's game and tells the watchers
+func (s *Session) replaceGame(g *game.Game, loaded bool) {
+	s.mu.Lock()
+	s.game = g
+	s.loaded = loaded
+	s.mu.Unlock()
+	s.watchers.notify()
+}
+
+// Watch returns a channel that receives a value whenever the game state
+// changes; call the returned function to stop watching
+func (s *Session) Watch() (<-chan struct{}, func()) {
+	return s.watchers.add()
+}
+
+// Done returns a channel that is closed when the session is removed
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// close ends the session's watchers
+func (s *Session) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// newSession creates a session playing g
+func newSession(id string, g *game.Game, now time.Time, orphan bool) *Session {
+	return &Session{
+		ID:      id,
+		Created: now,
+		game:    g,
+		orphan:  orphan,
+		done:    make(chan struct{}),
+	}
+}
+
+// GameManager is the registry of the games played over WebSocket connections, by session id
+type GameManager struct {
+	sessions map[string]*Session
+	mu       sync.RWMutex
+}
+
+// NewGameManager creates an empty registry
+func NewGameManager() *GameManager {
+	return &GameManager{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Create adds a session playing g under a new id
+func (m *GameManager) Create(g *game.Game, now time.Time) *Session {
+	return m.add(newSessionID(), g, now, false)
+}
+
+// Restore adds a saved session under its old id, waiting to be claimed by the
+// player reconnecting to it
+func (m *GameManager) Restore(id string, g *game.Game, now time.Time) *Session {
+	return m.add(id, g, now, true)
+}
+
+// add adds a session to the registry
+func (m *GameManager) add(id string, g *game.Game, now time.Time, orphan bool) *Session {
+	sess := newSession(id, g, now, orphan)
+
+	m.mu.Lock()
+	m.sessions[sess.ID] = sess
+	m.mu.Unlock()
+	return sess
+}
+
+// Claim hands a restored session to the connection continuing it; a session
+// can only be claimed once
+func (m *GameManager) Claim(id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess, ok := m.sessions[id]
+	if !ok || !sess.orphan {
+		return nil, ErrGameNotFound
+	}
+	sess.orphan = false
+	return sess, nil
+}
+
+// RemoveOrphans removes the restored sessions nobody claimed
+func (m *GameManager) RemoveOrphans() int {
+	var orphans []*Session
+	m.mu.Lock()
+	for id, sess := range m.sessions {
+		if sess.orphan {
+			delete(m.sessions, id)
+			orphans = append(orphans, sess)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, sess := range orphans {
+		sess.close()
+	}
+	return len(orphans)
+}
+
+// Get returns the session with the given id
+func (m *GameManager) Get(id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sess, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+	return sess, nil
+}
+
+// Remove removes a session, ending its watchers
+func (m *GameManager) Remove(id string) {
+	m.mu.Lock()
+	sess, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if ok {
+		sess.close()
+	}
+}
+
+// RemoveAll removes every session, ending their watchers
+func (m *GameManager) RemoveAll() {
+	for _, sess := range m.Sessions() {
+		m.Remove(sess.ID)
+	}
+}
+
+// Len returns the number of sessions
+func (m *GameManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.sessions)
+}
+
+// Sessions returns every session, oldest first
+func (m *GameManager) Sessions() []*Session {
+	m.mu.RLock()
+	list := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		list = append(list, sess)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Created.Equal(list[j].Created) {
+			return list[i].Created.Before(list[j].Created)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// newSessionID returns a random id that is hard to guess, like a hosted game's
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "session_" + hex.EncodeToString(b)
+}
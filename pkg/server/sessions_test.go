@@ -0,0 +1,108 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/game"
+)
+
+// TestGameManager verifies that sessions are found by id and end their watchers when removed
+func TestGameManager(t *testing.T) {
+	m := NewGameManager()
+	now := time.Unix(0, 0)
+	first := m.Create(game.New(), now)
+	second := m.Create(game.New(), now.Add(time.Second))
+
+	if got, err := m.Get(first.ID); err != nil || got != first {
+		t.Errorf("Get(%s) = %v, %v, want the first session", first.ID, got, err)
+	}
+	if list := m.Sessions(); len(list) != 2 || list[0] != first || list[1] != second {
+		t.Errorf("Sessions = %v, want oldest first", list)
+	}
+
+	changed, stop := first.Watch()
+	defer stop()
+	restarted := game.New()
+	first.setGame(restarted)
+	select {
+	case <-changed:
+	default:
+		t.Error("watcher not told of the new game")
+	}
+	if first.Game() != restarted {
+		t.Error("Game did not return the new game")
+	}
+
+	m.Remove(first.ID)
+	select {
+	case <-first.Done():
+	default:
+		t.Error("Done not closed after Remove")
+	}
+	if _, err := m.Get(first.ID); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("Get after Remove error = %v, want ErrGameNotFound", err)
+	}
+	m.Remove(first.ID) // Removing twice is harmless
+	if n := m.Len(); n != 1 {
+		t.Errorf("Len = %d, want 1", n)
+	}
+}
+
+// TestSaveRestoreSessions verifies that games saved on shutdown are restored
+// paused, can be claimed once, and are dropped if nobody returns for them
+func TestSaveRestoreSessions(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	path := filepath.Join(t.TempDir(), "games.json")
+
+	s := New(":0")
+	s.Clock = clk
+	g := game.NewWithConfig(game.Config{Clock: clk, Seed: 7})
+	g.HardDrop()
+	playing := s.sessions.Create(g, clk.Now())
+	over := game.NewWithConfig(game.Config{Clock: clk})
+	for i := 0; i < 100 && !over.IsGameOver(); i++ {
+		over.HardDrop()
+	}
+	s.sessions.Create(over, clk.Now())
+
+	if n, err := s.SaveSessions(path); err != nil || n != 1 {
+		t.Fatalf("SaveSessions = %d, %v, want 1 (finished games are not saved)", n, err)
+	}
+
+	restarted := New(":0")
+	restarted.Clock = clk
+	if n, err := restarted.RestoreSessions(path); err != nil || n != 1 {
+		t.Fatalf("RestoreSessions = %d, %v, want 1", n, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("session file not removed after restore: %v", err)
+	}
+
+	sess, err := restarted.sessions.Claim(playing.ID)
+	if err != nil {
+		t.Fatalf("Claim(%s) = %v", playing.ID, err)
+	}
+	if sess.Game().GetState() != game.StatePaused {
+		t.Errorf("restored state = %v, want paused", sess.Game().GetState())
+	}
+	if got, want := sess.Game().GetScore(), g.GetScore(); got != want {
+		t.Errorf("restored score = %d, want %d", got, want)
+	}
+	if _, err := restarted.sessions.Claim(playing.ID); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("second Claim = %v, want ErrGameNotFound", err)
+	}
+
+	// A session nobody claims is removed after restoredSessionTTL
+	restarted.sessions.Restore("session_orphan", game.New(), clk.Now())
+	if n := restarted.sessions.RemoveOrphans(); n != 1 {
+		t.Errorf("RemoveOrphans = %d, want 1", n)
+	}
+	if _, err := restarted.sessions.Get(playing.ID); err != nil {
+		t.Errorf("claimed session removed with the orphans: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// restoredSessionTTL is how long a restored game waits for its player to reconnect
+const restoredSessionTTL = 10 * time.Minute
+
+// savedSessions is the file SaveSessions writes
+type savedSessions struct {
+	SavedAt  time.Time      `json:"saved_at"`
+	Sessions []savedSession `json:"sessions"`
+}
+
+// savedSession is a game in progress, in the format of game.Snapshot
+type savedSession struct {
+	ID   string          `json:"id"`
+	Game json.RawMessage `json:"game"`
+}
+
+// notifyShutdown warns every connected player that the server is going down,
+// returning how many were warned
+// Players whose game will be saved are told the session to continue it in;
+// only the first board of a doubles game would be, so its players are not,
+// and a co-op game can only be continued by one of its players, so neither are
+// they; the bot of a versus-CPU game is not saved, so the player is not told either
+func (s *Server) notifyShutdown() int {
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+
+	log.Printf("Notifying %d clients of shutdown (grace %v)", len(clients), s.ShutdownGrace)
+	for _, client := range clients {
+		sessionID := ""
+		if s.SnapshotPath != "" && !client.Game().IsGameOver() && client.partner == nil && client.coop == nil && client.cpu == nil {
+			sessionID = client.session.ID
+		}
+		client.sendServerShutdown(s.ShutdownGrace, sessionID)
+	}
+	return len(clients)
+}
+
+// sendServerShutdown sends a server shutdown warning to the client
+func (c *Client) sendServerShutdown(grace time.Duration, sessionID string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered in sendServerShutdown: %v", r)
+		}
+	}()
+
+	data, err := protocol.NewServerShutdownMessage(grace, sessionID).Serialize()
+	if err != nil {
+		log.Printf("Error serializing server shutdown: %v", err)
+		return
+	}
+
+	c.queue(data)
+}
+
+// SaveSessions writes the games in progress to path, including restored
+// games whose players have not reconnected yet; returns how many it saved
+func (s *Server) SaveSessions(path string) (int, error) {
+	saved := savedSessions{SavedAt: s.Clock.Now()}
+	for _, sess := range s.sessions.Sessions() {
+		g := sess.Game()
+		if g.IsGameOver() {
+			continue
+		}
+		data, err := g.Snapshot()
+		if err != nil {
+			return 0, fmt.Errorf("snapshot of session %s: %w", sess.ID, err)
+		}
+		saved.Sessions = append(saved.Sessions, savedSession{ID: sess.ID, Game: data})
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return 0, err
+	}
+	// Write a temporary file and rename it, so a crash never leaves half a file
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+	return len(saved.Sessions), nil
+}
+
+// RestoreSessions loads the games saved by SaveSessions and deletes the file,
+// so they are restored once; returns how many it restored
+// Restored games are paused and wait restoredSessionTTL for their players to
+// reconnect with ?session=; a missing file restores nothing
+func (s *Server) RestoreSessions(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var saved savedSessions
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+
+	now := s.Clock.Now()
+	restored := 0
+	for _, ss := range saved.Sessions {
+		g := game.NewWithConfig(game.Config{SpawnDelay: s.SpawnDelay, LineClearDelay: s.LineClearDelay, Clock: s.Clock})
+		if err := g.Restore(ss.Game); err != nil {
+			log.Printf("Skipping saved session %s: %v", ss.ID, err)
+			continue
+		}
+		g.Pause()
+		s.sessions.Restore(ss.ID, g, now)
+		restored++
+	}
+	if err := os.Remove(path); err != nil {
+		log.Printf("Error removing session file %s: %v", path, err)
+	}
+
+	if restored > 0 {
+		s.background(func() {
+			timer := s.Clock.NewTimer(restoredSessionTTL)
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+			case <-s.done:
+				return
+			}
+			if n := s.sessions.RemoveOrphans(); n > 0 {
+				log.Printf("Removed %d restored games whose players did not return", n)
+			}
+		})
+	}
+	return restored, nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestShutdownStopsGoroutines verifies that Shutdown stops the hub, the
+// admin broadcast loop and the routines of every client, leaving no
+// goroutines behind
+func TestShutdownStopsGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	s := New(":0")
+	s.ShutdownGrace = 0
+	ts := httptest.NewServer(s.Handler())
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	var conns []*websocket.Conn
+	for _, path := range []string{"/ws", "/ws", "/ws/admin"} {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL+path, nil)
+		if err != nil {
+			t.Fatalf("dial %s: %v", path, err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	// Admin clients are only told about clients the hub registered
+	waitFor(t, "clients to register", func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return len(s.clients) == 2
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	ts.Close()
+
+	// Read until the server closes each connection
+	for i, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var err error
+		for err == nil {
+			_, _, err = conn.ReadMessage()
+		}
+		if !websocket.IsCloseError(err, protocol.CloseServerShutdown) {
+			t.Errorf("connection %d ended with %v, want close code %d", i, err, protocol.CloseServerShutdown)
+		}
+		conn.Close()
+	}
+
+	if s.probeHub() {
+		t.Error("probeHub() = true after Shutdown, want false")
+	}
+	waitFor(t, "goroutines to exit", func() bool {
+		return runtime.NumGoroutine() <= before
+	})
+}
+
+// TestConnectAfterShutdown verifies WebSocket upgrades are refused once the
+// server shut down, and that a second Shutdown is harmless
+func TestConnectAfterShutdown(t *testing.T) {
+	s := New(":0")
+	s.ShutdownGrace = 0
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown() = %v", err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	for _, path := range []string{"/ws", "/ws/admin"} {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL+path, nil)
+		if err == nil {
+			t.Errorf("dial %s after Shutdown succeeded, want refused", path)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("dial %s after Shutdown = %v, want status 503", path, err)
+		}
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after five seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("timed out waiting for %s; goroutines:\n%s", what, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the server's spans
+const tracerName = "github.com/ican2002/tetris/pkg/server"
+
+// Attributes of the message spans
+const (
+	attrClientID    = attribute.Key("tetris.client_id")
+	attrMessageType = attribute.Key("tetris.message_type")
+	attrMessageSize = attribute.Key("tetris.message_size")
+	attrErrorCode   = attribute.Key("tetris.error_code")
+)
+
+// tracer returns the tracer of the server's spans
+func (s *Server) tracer() trace.Tracer {
+	if s.TracerProvider != nil {
+		return s.TracerProvider.Tracer(tracerName)
+	}
+	return otel.Tracer(tracerName)
+}
+
+// startMessageSpan starts the span of a control message received from the
+// client, named after its type once it is parsed
+func (c *Client) startMessageSpan(data []byte) (context.Context, trace.Span) {
+	ctx, span := c.server.tracer().Start(context.Background(), "receive",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrClientID.String(c.id), attrMessageSize.Int(len(data))))
+	c.span = span
+	return ctx, span
+}
+
+// endMessageSpan ends the span of the control message handled
+func (c *Client) endMessageSpan() {
+	c.span.End()
+	c.span = nil
+}
+
+// markSpanError records the error the control message being handled was
+// answered with on its span
+func (c *Client) markSpanError(msg *protocol.Message) {
+	if c.span == nil {
+		return
+	}
+	if data, ok := msg.Data.(protocol.ErrorMessage); ok {
+		c.span.SetStatus(codes.Error, data.Error)
+		c.span.SetAttributes(attrErrorCode.String(string(data.Code)))
+	}
+}
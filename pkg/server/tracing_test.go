@@ -0,0 +1,57 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestMessageSpans verifies each control message is traced from its receipt
+// through the game update to the state sent, and errors mark the span
+func TestMessageSpans(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        string
+		wantRoot   string
+		wantStatus codes.Code
+		wantSpans  []string // Children of the message's span
+	}{
+		{"move", `{"type": "move_left"}`, "receive move_left", codes.Unset, []string{"game.update", "state.send"}},
+		{"no state to send", `{"type": "pong"}`, "receive pong", codes.Unset, []string{"game.update"}},
+		{"refused", `{"type": "undo"}`, "receive undo", codes.Error, []string{"game.update"}},
+		{"unreadable", `{`, "receive", codes.Error, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			s := New(":0")
+			s.Clock = clock.NewFake(time.Unix(0, 0))
+			s.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			c := &Client{id: "c1", send: make(chan []byte, 16), server: s, closeReq: make(chan closeRequest, 1)}
+			c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+			c.handleMessage([]byte(tt.msg))
+
+			spans := recorder.Ended()
+			if len(spans) != len(tt.wantSpans)+1 {
+				t.Fatalf("recorded %d spans, want %d", len(spans), len(tt.wantSpans)+1)
+			}
+			// The message's span ends after the spans it contains
+			root := spans[len(spans)-1]
+			if root.Name() != tt.wantRoot || root.Status().Code != tt.wantStatus {
+				t.Errorf("message span = %q with status %v, want %q with %v", root.Name(), root.Status().Code, tt.wantRoot, tt.wantStatus)
+			}
+			for i, want := range tt.wantSpans {
+				span := spans[i]
+				if span.Name() != want || span.Parent().SpanID() != root.SpanContext().SpanID() {
+					t.Errorf("span %d = %q under %v, want %q under the message span", i, span.Name(), span.Parent().SpanID(), want)
+				}
+			}
+		})
+	}
+}
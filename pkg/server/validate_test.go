@@ -0,0 +1,67 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/clock"
+	"github.com/ican2002/tetris/pkg/protocol"
+	"github.com/ican2002/tetris/pkg/server/servertest/queue"
+)
+
+// TestMessageValidation verifies invalid control messages are rejected with
+// the kind of problem before the server acts on them
+func TestMessageValidation(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s,
+		closeReq: make(chan closeRequest, 1)}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	tests := []struct {
+		msg  string
+		want protocol.ErrorKind // Kind of the error; empty for a valid message
+	}{
+		{`{"type": "rotate"}`, ""},
+		{`{"type": "select_mode", "mode": "sprint", "id": "a1"}`, ""},
+		{`not json`, protocol.KindInvalidJSON},
+		{`{"type": "rotate"} {"type": "rotate"}`, protocol.KindInvalidJSON},
+		{`{"type": "rotate", "speed": 2}`, protocol.KindUnknownField},
+		{`{"type": 123}`, protocol.KindInvalidPayload},
+		{`{"type": "select_mode", "dig_rows": "many", "mode": "dig"}`, protocol.KindInvalidPayload},
+		{`{}`, protocol.KindUnknownType},
+		{`{"type": "launch_missiles"}`, protocol.KindUnknownType},
+		{`{"type": "select_mode"}`, protocol.KindInvalidPayload},
+		{`{"type": "select_mode", "mode": "dig", "dig_rows": -1}`, protocol.KindInvalidPayload},
+		{`{"type": "set_name"}`, protocol.KindInvalidPayload},
+		{`{"type": "load_game"}`, protocol.KindInvalidPayload},
+		{`{"type": "set_next_piece", "pieces": ""}`, protocol.KindInvalidPayload},
+		{`{"type": "rotate", "id": "` + strings.Repeat("x", protocol.MaxIDLength+1) + `"}`, protocol.KindInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		c.handleMessage([]byte(tt.msg))
+		var kind protocol.ErrorKind
+		for _, e := range queue.Drain(t, c.send).Errors(t) {
+			kind = e.Kind
+		}
+		if kind != tt.want {
+			t.Errorf("%s: error kind = %q, want %q", tt.msg, kind, tt.want)
+		}
+	}
+}
+
+// TestPracticeCommandsAccepted verifies the practice and save commands pass
+// validation and reach their handlers
+func TestPracticeCommandsAccepted(t *testing.T) {
+	s := New(":0")
+	s.Clock = clock.NewFake(time.Unix(0, 0))
+	c := &Client{id: "c1", send: make(chan []byte, 16), server: s}
+	c.session = s.sessions.Create(c.newGame(), s.Clock.Now())
+
+	c.handleMessage([]byte(`{"type": "undo"}`))
+	if errs := queue.Drain(t, c.send).Errors(t); len(errs) != 1 || errs[0].Error != "Undo is only available in practice mode" {
+		t.Errorf("undo got errors %+v, want the practice mode error", errs)
+	}
+}
@@ -0,0 +1,52 @@
+// Package telemetry exports the OpenTelemetry spans of the server and its
+// clients to an OTLP collector, so operators can follow a message from the
+// client through the server's game update to the state sent back
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config describes where and how much to export
+type Config struct {
+	// Endpoint is the OTLP/gRPC collector, e.g. http://localhost:4317; an
+	// http:// URL or a bare host:port connects without TLS
+	Endpoint string
+	// ServiceName names the process in the collector, e.g. tetris-server
+	ServiceName string
+	// SampleRatio is the fraction of traces kept, from 0 to 1
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider that exports to cfg.Endpoint and
+// returns a function that flushes the remaining spans and stops it
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio %v is not between 0 and 1", cfg.SampleRatio)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(cfg.Endpoint)}
+	if !strings.Contains(cfg.Endpoint, "://") {
+		opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure()}
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Line clear animation: the full rows flash, then disappear for a moment
+// before the rows above collapse onto the stack
+const (
+	flashDuration    = 240 * time.Millisecond
+	flashBlink       = 60 * time.Millisecond // Half a period of the flashing
+	collapseDuration = 80 * time.Millisecond
+)
+
+// flashColor is the color full rows flash in, white in every theme
+const flashColor piece.Color = "#FFFFFF"
+
+// Animation is a visual effect that plays for a fixed time from its start
+type Animation struct {
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Progress returns how far the animation is at now, from 0 to 1, and whether
+// it is still playing
+func (a Animation) Progress(now time.Time) (float64, bool) {
+	if a.Duration <= 0 || now.Before(a.Start) {
+		return 0, false
+	}
+	elapsed := now.Sub(a.Start)
+	if elapsed >= a.Duration {
+		return 1, false
+	}
+	return float64(elapsed) / float64(a.Duration), true
+}
+
+// Animator plays the line clear animations of the player's board, found by
+// comparing successive states; safe for concurrent use
+type Animator struct {
+	clear  Animation
+	board  [][]string // Board with the full rows still in place
+	rows   []int      // Full rows, top to bottom
+	flash  time.Duration
+	tetris bool
+	mu     sync.Mutex
+}
+
+// clearFrame is the board to draw while a line clear animation plays
+type clearFrame struct {
+	board     [][]string
+	rows      []int
+	color     string // Color the full rows flash in; empty for their own colors
+	collapsed bool   // The full rows disappeared
+	tetris    bool
+}
+
+// Observe starts a line clear animation when next clears rows that prev
+// showed: the rows the server flags during its line clear delay collapse once
+// it removes them, and rows cleared at once are found by dropping prev's
+// current piece and flashed first
+func (a *Animator) Observe(prev, next *protocol.StateMessage, now time.Time) {
+	if a == nil || prev == nil || next == nil || len(next.ClearingRows) > 0 {
+		return
+	}
+
+	var board [][]string
+	var rows []int
+	flash := time.Duration(0)
+	switch {
+	case len(prev.ClearingRows) > 0:
+		board, rows = prev.Board, prev.ClearingRows
+	case next.Lines > prev.Lines:
+		board, rows = landedBoard(prev)
+		flash = flashDuration
+		if len(rows) != next.Lines-prev.Lines {
+			return
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clear = Animation{Start: now, Duration: flash + collapseDuration}
+	a.board, a.rows, a.flash = board, rows, flash
+	a.tetris = len(rows) >= 4
+}
+
+// Active reports whether an animation plays at now, so the board needs
+// redrawing every frame
+func (a *Animator) Active(now time.Time) bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, playing := a.clear.Progress(now)
+	return playing
+}
+
+// frame returns the board to draw at now, or false if no animation plays
+func (a *Animator) frame(now time.Time) (clearFrame, bool) {
+	if a == nil {
+		return clearFrame{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, playing := a.clear.Progress(now); !playing {
+		return clearFrame{}, false
+	}
+
+	f := clearFrame{board: a.board, rows: a.rows, tetris: a.tetris}
+	if elapsed := now.Sub(a.clear.Start); elapsed < a.flash {
+		f.color = blinkColor(elapsed)
+	} else {
+		f.collapsed = true
+	}
+	return f, true
+}
+
+// blinkColor returns the color of flashing rows elapsed into the flash,
+// alternating between white and the rows' own colors (empty)
+func blinkColor(elapsed time.Duration) string {
+	if (elapsed/flashBlink)%2 == 0 {
+		return string(flashColor)
+	}
+	return ""
+}
+
+// landedBoard returns the board of state with its current piece dropped into
+// place, and the rows that would then be full
+func landedBoard(state *protocol.StateMessage) ([][]string, []int) {
+	if len(state.Board) == 0 || state.Spawning || !isValidPieceType(state.CurrentPiece.Type) {
+		return nil, nil
+	}
+	shape := getPieceShape(state.CurrentPiece)
+	if shape == nil {
+		return nil, nil
+	}
+
+	board := make([][]string, len(state.Board))
+	for y := range state.Board {
+		board[y] = append([]string(nil), state.Board[y]...)
+	}
+	for _, cell := range ghostCells(board, shape, state.CurrentPiece.X, state.CurrentPiece.Y) {
+		board[cell[1]][cell[0]] = string(state.CurrentPiece.Color)
+	}
+
+	var rows []int
+	for y, row := range board {
+		full := len(row) > 0
+		for _, c := range row {
+			if c == "" {
+				full = false
+				break
+			}
+		}
+		if full {
+			rows = append(rows, y)
+		}
+	}
+	return board, rows
+}
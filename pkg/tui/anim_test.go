@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestAnimatorLineClear verifies that a line clear found by dropping the
+// previous state's piece flashes the full row and then collapses it
+func TestAnimatorLineClear(t *testing.T) {
+	gray := string(piece.ColorGray)
+	prev := &protocol.StateMessage{
+		Width:  10,
+		Height: 3,
+		Board: [][]string{
+			{"", "", "", "", "", "", "", "", "", ""},
+			{"", "", "", "", "", "", "", "", "", ""},
+			{gray, gray, gray, gray, gray, gray, "", "", "", ""},
+		},
+		CurrentPiece: protocol.PieceData{Type: piece.TypeI, Color: piece.ColorCyan, X: 6},
+		Lines:        4,
+	}
+	next := &protocol.StateMessage{Width: 10, Height: 3, Lines: 5}
+
+	now := time.Now()
+	var a Animator
+	a.Observe(prev, next, now)
+
+	tests := []struct {
+		name      string
+		at        time.Duration
+		active    bool
+		color     string
+		collapsed bool
+	}{
+		{"flash", 0, true, string(flashColor), false},
+		{"blink", flashBlink, true, "", false},
+		{"collapse", flashDuration, true, "", true},
+		{"done", flashDuration + collapseDuration, false, "", false},
+	}
+	for _, tt := range tests {
+		f, ok := a.frame(now.Add(tt.at))
+		if ok != tt.active || a.Active(now.Add(tt.at)) != tt.active {
+			t.Errorf("%s: playing = %v, want %v", tt.name, ok, tt.active)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(f.rows) != 1 || f.rows[0] != 2 || f.color != tt.color || f.collapsed != tt.collapsed {
+			t.Errorf("%s: rows %v, color %q, collapsed %v; want [2], %q, %v", tt.name, f.rows, f.color, f.collapsed, tt.color, tt.collapsed)
+		}
+	}
+
+	// A line count that does not match the rows found is not animated
+	var b Animator
+	b.Observe(prev, &protocol.StateMessage{Lines: 7}, now)
+	if b.Active(now) {
+		t.Error("animation started for lines the dropped piece does not clear")
+	}
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// FeatureChat enables the chat key binding; turn it on for match games
+const FeatureChat = "chat"
+
+// minChatWidth is the narrowest chat pane worth showing
+const minChatWidth = 24
+
+// ChatPane keeps the recent chat messages of a match and the message being
+// typed; safe for concurrent use
+type ChatPane struct {
+	messages []protocol.ChatMessage
+	maxSize  int
+	typing   bool
+	input    []rune
+	mu       sync.Mutex
+}
+
+// NewChatPane creates a chat pane keeping the last size messages
+func NewChatPane(size int) *ChatPane {
+	return &ChatPane{maxSize: size}
+}
+
+// Add adds a received message
+func (p *ChatPane) Add(msg protocol.ChatMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, msg)
+	if len(p.messages) > p.maxSize {
+		p.messages = p.messages[1:]
+	}
+}
+
+// Open starts typing a message
+func (p *ChatPane) Open() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.typing = true
+	p.input = p.input[:0]
+}
+
+// Typing reports whether a message is being typed; the pane then captures all keys
+func (p *ChatPane) Typing() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.typing
+}
+
+// HandleKey edits the message being typed
+// Enter returns the message with send set; Escape discards it
+func (p *ChatPane) HandleKey(ev *tcell.EventKey) (text string, send bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		p.typing = false
+		return string(p.input), len(p.input) > 0
+	case tcell.KeyEscape:
+		p.typing = false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case tcell.KeyRune:
+		if len(p.input) < protocol.MaxChatLength {
+			p.input = append(p.input, ev.Rune())
+		}
+	}
+	return "", false
+}
+
+// DrawChatPane draws the chat messages in area, newest at the bottom, above
+// the message being typed
+func (t *TUI) DrawChatPane(p *ChatPane, area Rect, style tcell.Style) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t.DrawBox(area.X, area.Y, area.W, area.H, "Chat", style)
+
+	rows := area.H - 2
+	textW := area.W - 4
+	if p.typing {
+		rows--
+		// Show the end of a message longer than the pane
+		line := TruncateStart("> "+string(p.input)+"_", textW)
+		t.DrawText(area.X+2, area.Y+area.H-2, line, style.Foreground(t.theme.Accent))
+	}
+
+	shown := p.messages
+	if len(shown) > rows {
+		shown = shown[len(shown)-rows:]
+	}
+	for i, msg := range shown {
+		from := msg.From
+		if from == "" {
+			from = "anonymous"
+		}
+		y := area.Y + 1 + rows - len(shown) + i
+		t.DrawText(area.X+2, y, Truncate(from+": "+msg.Text, textW), style)
+		t.DrawText(area.X+2, y, Truncate(from+":", textW), style.Bold(true))
+	}
+}
@@ -2,12 +2,35 @@ package tui
 
 import (
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/game"
 	"github.com/ican2002/tetris/pkg/piece"
 	"github.com/ican2002/tetris/pkg/protocol"
 )
 
+// ModeOption describes a game mode on the mode-select screen
+type ModeOption struct {
+	Mode        game.Mode
+	Name        string
+	Description string
+}
+
+// GameModes lists the modes offered on the mode-select screen
+var GameModes = []ModeOption{
+	{game.ModeMarathon, "Marathon", "Play until the stack reaches the top"},
+	{game.ModeSprint, "Sprint", "Clear 40 lines as fast as possible"},
+	{game.ModeUltra, "Ultra", "Score as much as you can in 2 minutes"},
+	{game.ModeDig, "Dig", "Clear 10 rows of garbage as fast as possible"},
+	{game.ModePractice, "Practice", "Marathon with undo (Z); not ranked"},
+	{game.ModeZen, "Zen", "Endless play, no game over; not ranked"},
+	{game.ModePuzzle, "Puzzle", "Reach the goal with the pieces given; not ranked"},
+}
+
 // isValidPieceType checks if a piece type is valid (one of the 7 Tetris pieces)
 func isValidPieceType(t piece.Type) bool {
 	switch t {
@@ -19,33 +42,119 @@ func isValidPieceType(t piece.Type) bool {
 	}
 }
 
-// DrawBoard draws the Tetris board
-func (t *TUI) DrawBoard(x, y int, state *protocol.StateMessage, style tcell.Style) {
+// boardSize returns the board dimensions reported in a state message
+// Falls back to the classic 10x20 board for servers that do not report a size
+func boardSize(state *protocol.StateMessage) (int, int) {
+	width, height := 10, 20
+	if state != nil && state.Width > 0 {
+		width = state.Width
+	}
+	if state != nil && state.Height > 0 {
+		height = state.Height
+	}
+	return width, height
+}
+
+// dangerRows is how close to the top the stack gets before the danger warning
+const dangerRows = 4
+
+// InDanger reports whether the stack in state reaches the top rows of the
+// board, close to topping out
+func InDanger(state *protocol.StateMessage) bool {
+	if state == nil {
+		return false
+	}
+	_, height := boardSize(state)
+	return state.StackHeight > height-dangerRows
+}
+
+// DrawGameFrame draws the box around the board and info panel, tinted red
+// with a warning while the stack is in danger
+func (t *TUI) DrawGameFrame(r Rect, state *protocol.StateMessage, style tcell.Style) {
+	if !InDanger(state) {
+		t.DrawBox(r.X, r.Y, r.W, r.H, "", style)
+		return
+	}
+	t.DrawBox(r.X, r.Y, r.W, r.H, " DANGER ", style.Foreground(t.theme.Bad))
+}
+
+// DrawTeamScore draws the team score of a doubles game on the top edge of the
+// frame r, left of any warning
+func (t *TUI) DrawTeamScore(r Rect, score int, style tcell.Style) {
+	t.DrawText(r.X+2, r.Y, fmt.Sprintf(" Team: %d ", score), style.Bold(true).Foreground(t.theme.Accent))
+}
+
+// DrawTurn draws whose piece is falling in a co-op game, such as "Your", on
+// the top edge of the frame r, left of any warning; the partner's is dimmed
+func (t *TUI) DrawTurn(r Rect, whose string, yourTurn bool, style tcell.Style) {
+	style = style.Bold(true).Foreground(t.theme.Accent)
+	if !yourTurn {
+		style = style.Bold(false).Dim(true)
+	}
+	t.DrawText(r.X+2, r.Y, fmt.Sprintf(" %s piece ", whose), style)
+}
+
+// DrawBoard draws the Tetris board, scaled to the size reported in the state
+// with cells drawn in the given mode
+// While a line clear animation plays it draws the animation's board instead,
+// and rows flagged by the server's line clear delay flash
+func (t *TUI) DrawBoard(x, y int, cell CellMode, state *protocol.StateMessage, style tcell.Style) {
+	width, height := boardSize(state)
+
+	source, showPiece := state.Board, !state.Spawning
+	f, animating := t.anim.frame(time.Now())
+	if t.theme.Plain {
+		// Screen readers would announce every frame of the animations
+		f = clearFrame{}
+	} else if animating {
+		source, showPiece = f.board, false
+	} else if len(state.ClearingRows) > 0 {
+		f = clearFrame{
+			rows:   state.ClearingRows,
+			color:  blinkColor(time.Duration(time.Now().UnixNano())),
+			tetris: len(state.ClearingRows) >= 4,
+		}
+	}
+
 	// Create a display board that includes locked pieces and current piece
-	displayBoard := make([][]string, 20)
-	for row := 0; row < 20; row++ {
-		displayBoard[row] = make([]string, 10)
-		if row < len(state.Board) {
-			for col := 0; col < 10; col++ {
-				if col < len(state.Board[row]) {
-					displayBoard[row][col] = state.Board[row][col]
+	displayBoard := make([][]string, height)
+	for row := 0; row < height; row++ {
+		displayBoard[row] = make([]string, width)
+		if row < len(source) {
+			for col := 0; col < width; col++ {
+				if col < len(source[row]) {
+					displayBoard[row][col] = source[row][col]
 				}
 			}
 		}
 	}
+	for _, row := range f.rows {
+		if row < 0 || row >= height || (f.color == "" && !f.collapsed) {
+			continue
+		}
+		for col := range displayBoard[row] {
+			displayBoard[row][col] = f.color
+		}
+	}
 
-	// Overlay the current piece on the display board
+	// Overlay the current piece on the display board, marking where it would
+	// land when the ghost piece is shown
+	var ghost [][2]int
 	currentPiece := state.CurrentPiece
-	// Check if the piece type is valid (TypeI = 0, so we need to check against valid types)
-	if isValidPieceType(currentPiece.Type) && currentPiece.Color != "" {
+	// Check if the piece type is valid (TypeI = 0, so we need to check against valid types);
+	// during the spawn delay the current piece is already locked into the board
+	if showPiece && isValidPieceType(currentPiece.Type) && currentPiece.Color != "" {
 		shape := getPieceShape(currentPiece)
 		if shape != nil {
+			if t.ghost {
+				ghost = ghostCells(displayBoard, shape, currentPiece.X, currentPiece.Y)
+			}
 			for row := 0; row < len(shape); row++ {
 				for col := 0; col < len(shape[row]); col++ {
 					if shape[row][col] == 1 {
 						boardY := currentPiece.Y + row
 						boardX := currentPiece.X + col
-						if boardY >= 0 && boardY < 20 && boardX >= 0 && boardX < 10 {
+						if boardY >= 0 && boardY < height && boardX >= 0 && boardX < width {
 							displayBoard[boardY][boardX] = string(currentPiece.Color)
 						}
 					}
@@ -54,30 +163,131 @@ func (t *TUI) DrawBoard(x, y int, state *protocol.StateMessage, style tcell.Styl
 		}
 	}
 
+	if f.tetris {
+		defer t.drawTetrisBanner(x, y, cell, width, height, style)
+	}
+
+	if cell == CellHalfBlock {
+		t.drawHalfBlocks(x, y, displayBoard, style)
+		return
+	}
+
+	cellWidth := 1
+	if cell == CellDouble {
+		cellWidth = 2
+	}
+
 	// Draw cells
-	for row := 0; row < 20; row++ {
-		for col := 0; col < 10; col++ {
-			cellX := x + col*2
-			cellY := y + row
-
-			colorStr := displayBoard[row][col]
-			if colorStr != "" {
-				// Filled cell
-				cellStyle := style.Background(GetColor(piece.Color(colorStr)))
-				t.screen.SetContent(cellX, cellY, ' ', nil, cellStyle)
-				t.screen.SetContent(cellX+1, cellY, ' ', nil, cellStyle)
-			} else {
-				// Empty cell
-				dimStyle := style.Dim(true)
-				t.screen.SetContent(cellX, cellY, '·', nil, dimStyle)
-				t.screen.SetContent(cellX+1, cellY, '·', nil, dimStyle)
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			t.drawCell(x+col*cellWidth, y+row, cellWidth, displayBoard[row][col], style)
+		}
+	}
+
+	// Draw the ghost piece in the cells the piece does not cover yet
+	for _, pos := range ghost {
+		if displayBoard[pos[1]][pos[0]] == "" {
+			t.drawGhostCell(x+pos[0]*cellWidth, y+pos[1], cellWidth, string(currentPiece.Color), style)
+		}
+	}
+}
+
+// drawTetrisBanner highlights a four-line clear over the middle of a board
+// drawn at x, y
+func (t *TUI) drawTetrisBanner(x, y int, cell CellMode, width, height int, style tcell.Style) {
+	w, h := width, height
+	switch cell {
+	case CellDouble:
+		w *= 2
+	case CellHalfBlock:
+		h = (h + 1) / 2
+	}
+	t.DrawTextAligned(x, y+h/2, w, Truncate(" TETRIS! ", w), 0, style.Foreground(t.theme.Accent).Bold(true).Reverse(true))
+}
+
+// ghostCells returns the board cells, as {x, y}, the piece shape at x, y
+// would occupy after a hard drop onto the locked cells of board
+func ghostCells(board [][]string, shape [][]int, x, y int) [][2]int {
+	fits := func(y int) bool {
+		for row := range shape {
+			for col := range shape[row] {
+				if shape[row][col] == 0 {
+					continue
+				}
+				bx, by := x+col, y+row
+				if bx < 0 || by >= len(board) || bx >= len(board[0]) {
+					return false
+				}
+				if by >= 0 && board[by][bx] != "" {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if !fits(y) {
+		return nil
+	}
+	for fits(y + 1) {
+		y++
+	}
+
+	var cells [][2]int
+	for row := range shape {
+		for col := range shape[row] {
+			if shape[row][col] == 1 && y+row >= 0 {
+				cells = append(cells, [2]int{x + col, y + row})
 			}
 		}
 	}
+	return cells
 }
 
-// DrawInfoPanel draws the information panel
-func (t *TUI) DrawInfoPanel(x, y int, state *protocol.StateMessage, style tcell.Style) {
+// drawHalfBlocks draws two board rows per terminal row, using the foreground
+// of a half block for the upper cell and the background for the lower one
+// ASCII themes draw ' for an upper block, , for a lower one and : for both
+func (t *TUI) drawHalfBlocks(x, y int, displayBoard [][]string, style tcell.Style) {
+	empty, upperOnly, lowerOnly, both := '·', '▀', '▄', '▀'
+	if t.theme.ASCII {
+		empty, upperOnly, lowerOnly, both = '.', '\'', ',', ':'
+	}
+
+	for row := 0; row < len(displayBoard); row += 2 {
+		for col := range displayBoard[row] {
+			upper := displayBoard[row][col]
+			lower := ""
+			if row+1 < len(displayBoard) {
+				lower = displayBoard[row+1][col]
+			}
+
+			cellX, cellY := x+col, y+row/2
+			upperColor := t.theme.Color(piece.Color(upper))
+			lowerColor := t.theme.Color(piece.Color(lower))
+			switch {
+			case upper == "" && lower == "":
+				t.screen.SetContent(cellX, cellY, empty, nil, style.Dim(!t.theme.ASCII))
+			case upper == "":
+				t.screen.SetContent(cellX, cellY, lowerOnly, nil, style.Foreground(lowerColor))
+			case lower == "":
+				t.screen.SetContent(cellX, cellY, upperOnly, nil, style.Foreground(upperColor))
+			case t.theme.ASCII:
+				t.screen.SetContent(cellX, cellY, both, nil, style)
+			default:
+				t.screen.SetContent(cellX, cellY, both, nil, style.Foreground(upperColor).Background(lowerColor))
+			}
+		}
+	}
+}
+
+// DrawInfoPanel draws the information panel in area, falling back to a compact
+// single column when the area is too small for the two-column panel
+func (t *TUI) DrawInfoPanel(area Rect, state *protocol.StateMessage, style tcell.Style) {
+	if area.W < infoPanelWidth || area.H < infoPanelHeight {
+		t.drawCompactInfoPanel(area, state, style)
+		return
+	}
+
+	x, y := area.X, area.Y
 	// Draw information
 	line := y + 1
 	t.DrawText(x, line, "Score:", style.Bold(true))
@@ -96,18 +306,165 @@ func (t *TUI) DrawInfoPanel(x, y int, state *protocol.StateMessage, style tcell.
 	stateStyle := style
 	switch state.State {
 	case "playing":
-		stateStyle = stateStyle.Foreground(tcell.ColorGreen.TrueColor())
+		stateStyle = stateStyle.Foreground(t.theme.Good)
 	case "paused":
-		stateStyle = stateStyle.Foreground(tcell.ColorYellow.TrueColor())
+		stateStyle = stateStyle.Foreground(t.theme.Accent)
 	case "gameover":
-		stateStyle = stateStyle.Foreground(tcell.ColorRed.TrueColor())
+		stateStyle = stateStyle.Foreground(t.theme.Bad)
 	}
 	t.DrawText(x, line+1, capitalize(state.State), stateStyle)
 
 	// Draw next piece preview
 	line += 3
 	t.DrawText(x, line, "Next:", style.Bold(true))
-	t.DrawPiecePreview(x, line+1, state.NextPiece, style)
+	t.drawNextPiece(x, line+1, state, style)
+
+	// Draw mode progress and the hold box in a second column
+	t.DrawModeInfo(x+24, y+1, state, style)
+	t.DrawHoldPiece(x+24, y+13, state, style)
+}
+
+// drawCompactInfoPanel draws the information panel as one column with a value on
+// each line, leaving out the previews that do not fit in area
+func (t *TUI) drawCompactInfoPanel(area Rect, state *protocol.StateMessage, style tcell.Style) {
+	x, line, bottom := area.X, area.Y, area.Y+area.H
+	for _, item := range []struct{ label, value string }{
+		{"Score", fmt.Sprintf("%d", state.Score)},
+		{"Level", fmt.Sprintf("%d", state.Level)},
+		{"Lines", fmt.Sprintf("%d", state.Lines)},
+	} {
+		t.DrawText(x, line, item.label, style.Bold(true))
+		t.DrawText(x+6, line, item.value, style)
+		line++
+	}
+	if state.Mode != "" {
+		t.DrawText(x, line, "Time", style.Bold(true))
+		t.DrawText(x+6, line, FormatDuration(time.Duration(state.ElapsedMs)*time.Millisecond), style)
+		line++
+	}
+	if state.Mode == string(game.ModePuzzle) {
+		t.DrawText(x, line, "Left", style.Bold(true))
+		t.DrawText(x+6, line, fmt.Sprintf("%d", state.PiecesRemaining), style)
+		line++
+	}
+
+	// Each preview is a label and four rows
+	if line+6 <= bottom {
+		t.DrawText(x, line+1, "Next:", style.Bold(true))
+		t.drawNextPiece(x, line+2, state, style)
+	}
+	if line+12 <= bottom {
+		t.DrawHoldPiece(x, line+7, state, style)
+	}
+}
+
+// DrawPace draws how the game in state compares with a ghost's at the same
+// time: the points and lines ahead of it or behind it, next to the score and
+// lines of the info panel in area, where they fit
+func (t *TUI) DrawPace(area Rect, state, ghost *protocol.StateMessage, style tcell.Style) {
+	// Where DrawInfoPanel puts the score and lines values
+	x, scoreY, linesY := area.X, area.Y+2, area.Y+8
+	if area.W < infoPanelWidth || area.H < infoPanelHeight {
+		x, scoreY, linesY = area.X+6, area.Y, area.Y+2
+	}
+
+	for _, item := range []struct {
+		y           int
+		value, diff int
+	}{
+		{scoreY, state.Score, state.Score - ghost.Score},
+		{linesY, state.Lines, state.Lines - ghost.Lines},
+	} {
+		text := fmt.Sprintf("%+d", item.diff)
+		dx := x + len(fmt.Sprintf("%d", item.value)) + 1
+		if dx+len(text) > area.X+area.W {
+			continue
+		}
+		paceStyle := style.Dim(true)
+		switch {
+		case item.diff > 0:
+			paceStyle = style.Foreground(t.theme.Good)
+		case item.diff < 0:
+			paceStyle = style.Foreground(t.theme.Bad)
+		}
+		t.DrawText(dx, item.y, text, paceStyle)
+	}
+}
+
+// DrawHoldPiece draws the held piece, greyed out while hold is unavailable
+func (t *TUI) DrawHoldPiece(x, y int, state *protocol.StateMessage, style tcell.Style) {
+	labelStyle := style.Bold(true)
+	if !state.CanHold {
+		labelStyle = style.Dim(true)
+	}
+	t.DrawText(x, y, "Hold:", labelStyle)
+
+	if state.HoldPiece == nil {
+		t.FillRect(x, y+1, 8, 4, ' ', style)
+		t.DrawText(x+2, y+2, "Empty", style.Dim(true))
+		return
+	}
+
+	held := *state.HoldPiece
+	if !state.CanHold {
+		held.Color = piece.ColorGray
+	}
+	t.DrawPiecePreview(x, y+1, held, style)
+}
+
+// DrawModeInfo draws the game mode and progress towards its goal
+func (t *TUI) DrawModeInfo(x, y int, state *protocol.StateMessage, style tcell.Style) {
+	if state.Mode == "" {
+		return
+	}
+
+	t.DrawText(x, y, "Mode:", style.Bold(true))
+	t.DrawText(x, y+1, capitalize(state.Mode), style)
+
+	t.DrawText(x, y+3, "Time:", style.Bold(true))
+	t.DrawText(x, y+4, FormatDuration(time.Duration(state.ElapsedMs)*time.Millisecond), style)
+
+	if state.PausedMs > 0 {
+		t.DrawText(x, y+9, "Paused:", style.Bold(true))
+		t.DrawText(x, y+10, FormatDuration(time.Duration(state.PausedMs)*time.Millisecond), style.Dim(true))
+	}
+
+	switch game.Mode(state.Mode) {
+	case game.ModeSprint:
+		t.DrawText(x, y+6, "Lines left:", style.Bold(true))
+		t.DrawText(x, y+7, fmt.Sprintf("%d", state.LinesRemaining), style)
+	case game.ModeUltra:
+		t.DrawText(x, y+6, "Time left:", style.Bold(true))
+		t.DrawText(x, y+7, FormatDuration(time.Duration(state.TimeRemainingMs)*time.Millisecond), style)
+	case game.ModeDig:
+		t.DrawText(x, y+6, "Garbage left:", style.Bold(true))
+		cleared := state.GarbageTotal - state.GarbageRemaining
+		t.DrawText(x, y+7, ProgressBar(cleared, state.GarbageTotal, digBarWidth), style.Foreground(t.theme.Good))
+		t.DrawText(x+digBarWidth+1, y+7, fmt.Sprintf("%d", state.GarbageRemaining), style)
+	case game.ModePuzzle:
+		t.DrawText(x, y+2, state.Puzzle, style.Dim(true))
+		t.DrawText(x, y+6, state.Objective, style.Bold(true))
+		t.DrawText(x, y+7, fmt.Sprintf("%d pieces left", state.PiecesRemaining), style)
+	default:
+		// Garbage sent by a versus opponent, rising at the next lock that clears nothing
+		if state.PendingGarbage > 0 {
+			t.DrawText(x, y+6, "Incoming:", style.Bold(true))
+			t.DrawText(x, y+7, fmt.Sprintf("%d rows", state.PendingGarbage), style.Foreground(t.theme.Bad))
+		}
+	}
+}
+
+// digBarWidth is the width of the dig mode progress bar
+const digBarWidth = 10
+
+// drawNextPiece draws the next piece preview, or that a handicap hides it
+func (t *TUI) drawNextPiece(x, y int, state *protocol.StateMessage, style tcell.Style) {
+	if !state.PreviewHidden {
+		t.DrawPiecePreview(x, y, state.NextPiece, style)
+		return
+	}
+	t.FillRect(x, y, 8, 4, ' ', style)
+	t.DrawText(x+2, y+1, "Hidden", style.Dim(true))
 }
 
 // DrawPiecePreview draws a piece preview (4x4 grid)
@@ -126,7 +483,7 @@ func (t *TUI) DrawPiecePreview(x, y int, pieceData protocol.PieceData, style tce
 	shape := getPieceShape(pieceData)
 	if shape == nil {
 		// Shape not found, show error
-		t.DrawText(x+1, y+1, "Error", style.Dim(true).Foreground(tcell.ColorRed))
+		t.DrawText(x+1, y+1, "Error", style.Dim(true).Foreground(t.theme.Bad))
 		return
 	}
 
@@ -138,12 +495,7 @@ func (t *TUI) DrawPiecePreview(x, y int, pieceData protocol.PieceData, style tce
 	for row := 0; row < len(shape); row++ {
 		for col := 0; col < len(shape[row]); col++ {
 			if shape[row][col] == 1 {
-				cellX := x + (col+offsetX)*2
-				cellY := y + row + offsetY
-
-				cellStyle := style.Background(GetColor(pieceData.Color))
-				t.screen.SetContent(cellX, cellY, ' ', nil, cellStyle)
-				t.screen.SetContent(cellX+1, cellY, ' ', nil, cellStyle)
+				t.drawCell(x+(col+offsetX)*2, y+row+offsetY, 2, string(pieceData.Color), style)
 			}
 		}
 	}
@@ -156,25 +508,32 @@ func (t *TUI) DrawStatusBar(x, y, width int, message string, connected bool, sty
 
 	// Draw connection status
 	statusText := "● Connected"
-	statusStyle := style.Foreground(tcell.ColorGreen.TrueColor())
+	statusStyle := style.Foreground(t.theme.Good)
 	if !connected {
 		statusText = "● Disconnected"
-		statusStyle = style.Foreground(tcell.ColorRed.TrueColor())
+		statusStyle = style.Foreground(t.theme.Bad)
+	}
+	if t.theme.Plain {
+		statusText = statusText[len("● "):] + ":"
 	}
 	t.DrawText(x+2, y, statusText, statusStyle.Reverse(true))
 
 	// Draw message
-	if message != "" {
-		msgX := x + len(statusText) + 4
-		if msgX+len(message) < x+width-2 {
-			t.DrawText(msgX, y, message, style.Reverse(true))
-		}
+	msgX := x + TextWidth(statusText) + 4
+	if message != "" && msgX+TextWidth(message) < x+width-2 {
+		t.DrawText(msgX, y, message, style.Reverse(true))
+		msgX += TextWidth(message)
+	}
+	if t.theme.Plain {
+		// Screen readers follow the cursor, so it stays on the status line
+		// where events are announced
+		t.screen.ShowCursor(msgX, y)
 	}
 
-	// Draw quit hint
-	hintText := "ESC/Ctrl+C/D/Q: Quit | P: Pause | Space: Drop | Arrows: Move"
-	hintX := x + width - len(hintText) - 2
-	if hintX > x+len(statusText)+4 {
+	// Draw key hints from the active keymap
+	hintText := t.keymap.StatusHint()
+	hintX := x + width - TextWidth(hintText) - 2
+	if hintX > x+TextWidth(statusText)+4 {
 		t.DrawText(hintX, y, hintText, style.Reverse(true).Dim(true))
 	}
 }
@@ -187,73 +546,245 @@ func (t *TUI) DrawWelcomeScreen(style tcell.Style) {
 	subtitle := "Terminal Edition"
 
 	// Center the title
-	titleX := (w - len(title)) / 2
+	titleX := (w - TextWidth(title)) / 2
 	titleY := h / 3
-	t.DrawText(titleX, titleY, title, style.Bold(true).Foreground(tcell.ColorTeal.TrueColor()))
-
-	subX := (w - len(subtitle)) / 2
-	t.DrawText(subX, titleY+2, subtitle, style.Foreground(tcell.ColorYellow.TrueColor()))
-
-	// Draw instructions
-	instructions := []string{
-		"Controls:",
-		"  ⬆️  Arrow Up    - Rotate",
-		"  ⬇️  Arrow Down  - Soft Drop",
-		"  ⬅️  Arrow Left  - Move Left",
-		"  ➡️  Arrow Right - Move Right",
-		"  ␣ Space        - Hard Drop",
-		"  P              - Pause/Resume",
-		"  Q / ESC        - Quit game",
-		"  Ctrl+C/D/Q/X   - Exit",
-		"",
-		"Press any key to connect...",
-	}
+	t.DrawText(titleX, titleY, title, style.Bold(true).Foreground(t.theme.Title))
+
+	subX := (w - TextWidth(subtitle)) / 2
+	t.DrawText(subX, titleY+2, subtitle, style.Foreground(t.theme.Accent))
+
+	// Draw instructions from the active keymap
+	instructions := append([]string{"Controls:"}, t.keymap.Instructions()...)
+	instructions = append(instructions, "", "Press any key to connect...")
 
 	instY := titleY + 6
 	for _, inst := range instructions {
-		instX := (w - len(inst)) / 2
+		instX := (w - TextWidth(inst)) / 2
 		t.DrawText(instX, instY, inst, style)
 		instY++
 	}
 
 	// Draw version info
-	version := "Version 1.0.0"
-	versionX := (w - len(version)) / 2
+	version := "Version " + Version
+	versionX := (w - TextWidth(version)) / 2
 	t.DrawText(versionX, h-3, version, style.Dim(true))
 }
 
+// DrawLobbyTicker draws a single line with the active player count and top scores
+func (t *TUI) DrawLobbyTicker(y int, lobby *protocol.LobbyInfo, offline bool, style tcell.Style) {
+	w, _ := t.screen.Size()
+
+	var text string
+	if lobby == nil {
+		if !offline {
+			return
+		}
+		text = "Leaderboard unavailable (offline)"
+	} else {
+		text = fmt.Sprintf("● %d playing now", lobby.ActivePlayers)
+		if len(lobby.TopScores) > 0 {
+			text += "  |  Top:"
+			for i, entry := range lobby.TopScores {
+				if i >= 5 {
+					break
+				}
+				if entry.Name != "" {
+					text += fmt.Sprintf("  %d. %s %d", i+1, entry.Name, entry.Score)
+				} else {
+					text += fmt.Sprintf("  %d. %d", i+1, entry.Score)
+				}
+			}
+		}
+		if offline {
+			text += "  (offline)"
+		}
+	}
+
+	tickerStyle := style.Foreground(t.theme.Good)
+	if offline {
+		tickerStyle = style.Dim(true)
+	}
+	t.DrawTextAligned(0, y, w, text, 0, tickerStyle)
+}
+
+// DrawModeSelectScreen draws the mode selection menu with the selected entry highlighted
+func (t *TUI) DrawModeSelectScreen(selected int, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	title := "SELECT MODE"
+	// Move the title up on short terminals so every mode and the help line fit
+	titleY := max(1, min(h/4, h-len(GameModes)*3-5))
+	t.DrawTextAligned(0, titleY, w, title, 0, style.Bold(true).Foreground(t.theme.Title))
+
+	y := titleY + 3
+	for i, opt := range GameModes {
+		itemStyle := style
+		marker := "  "
+		if i == selected {
+			itemStyle = style.Reverse(true)
+			marker = "> "
+		}
+		t.DrawTextAligned(0, y, w, fmt.Sprintf("%s%d. %-10s", marker, i+1, opt.Name), 0, itemStyle.Bold(true))
+		t.DrawTextAligned(0, y+1, w, opt.Description, 0, style.Dim(true))
+		y += 3
+	}
+
+	t.DrawTextAligned(0, y+1, w, fmt.Sprintf("↑/↓ or 1-%d: Choose | Enter: Start | P: Profile | Q/ESC: Quit", len(GameModes)), 0, style.Dim(true))
+}
+
+// DrawPersonalBest draws a line about the player's best results between the
+// title and the body of the welcome and game over screens
+func (t *TUI) DrawPersonalBest(text string, highlight bool, style tcell.Style) {
+	if text == "" {
+		return
+	}
+	w, h := t.screen.Size()
+
+	bestStyle := style.Dim(true)
+	if highlight {
+		bestStyle = style.Bold(true).Foreground(t.theme.Good)
+	}
+	t.DrawTextAligned(0, h/3+4, w, text, 0, bestStyle)
+}
+
+// DrawProfileScreen draws a registered player's lifetime statistics, or
+// message when there is no profile to show
+func (t *TUI) DrawProfileScreen(profile *protocol.PlayerProfile, message string, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	titleY := h / 4
+	t.DrawTextAligned(0, titleY, w, "PLAYER PROFILE", 0, style.Bold(true).Foreground(t.theme.Title))
+
+	y := titleY + 3
+	if profile == nil {
+		t.DrawTextAligned(0, y, w, message, 0, style)
+	} else {
+		t.DrawTextAligned(0, y, w, profile.Name, 0, style.Bold(true).Foreground(t.theme.Accent))
+		lastPlayed := "never"
+		if !profile.LastPlayed.IsZero() {
+			lastPlayed = profile.LastPlayed.Local().Format("2006-01-02 15:04")
+		}
+		lines := []string{
+			fmt.Sprintf("%-14s %16d", "Games played", profile.GamesPlayed),
+			fmt.Sprintf("%-14s %16d", "Total lines", profile.TotalLines),
+			fmt.Sprintf("%-14s %16d", "Best score", profile.BestScore),
+			fmt.Sprintf("%-14s %16s", "Rating", fmt.Sprintf("%d (%d matches)", profile.Rating, profile.RatedGames)),
+			fmt.Sprintf("%-14s %16s", "Member since", profile.CreatedAt.Local().Format("2006-01-02")),
+			fmt.Sprintf("%-14s %16s", "Last played", lastPlayed),
+		}
+		y += 2
+		for _, line := range lines {
+			t.DrawTextAligned(0, y, w, line, 0, style)
+			y++
+		}
+		t.DrawTextAligned(0, y+1, w, "Player ID: "+profile.ID, 0, style.Dim(true))
+		y++
+	}
+
+	t.DrawTextAligned(0, y+3, w, "Press any key to return", 0, style.Dim(true))
+}
+
 // DrawGameOverScreen draws the game over screen
 func (t *TUI) DrawGameOverScreen(state *protocol.StateMessage, style tcell.Style) {
 	w, h := t.screen.Size()
 
 	title := "GAME OVER"
 	subtitle := fmt.Sprintf("Final Score: %d", state.Score)
+	if state.TeamScore != 0 {
+		// The game over screen of a doubles game shows the team's result
+		subtitle = fmt.Sprintf("Team Score: %d", state.TeamScore)
+	}
 
 	// Center the title
-	titleX := (w - len(title)) / 2
+	titleX := (w - TextWidth(title)) / 2
 	titleY := h / 3
-	t.DrawText(titleX, titleY, title, style.Bold(true).Foreground(tcell.ColorRed.TrueColor()))
+	t.DrawText(titleX, titleY, title, style.Bold(true).Foreground(t.theme.Bad))
 
-	subX := (w - len(subtitle)) / 2
-	t.DrawText(subX, titleY+2, subtitle, style.Bold(true).Foreground(tcell.ColorYellow.TrueColor()))
+	subX := (w - TextWidth(subtitle)) / 2
+	t.DrawText(subX, titleY+2, subtitle, style.Bold(true).Foreground(t.theme.Accent))
 
 	// Draw stats
 	stats := []string{
 		fmt.Sprintf("Level: %d", state.Level),
 		fmt.Sprintf("Lines: %d", state.Lines),
+	}
+	if state.Mode != "" {
+		stats = append(stats, fmt.Sprintf("Mode: %s  Time: %s",
+			capitalize(state.Mode), FormatDuration(time.Duration(state.ElapsedMs)*time.Millisecond)))
+	}
+	stats = append(stats,
 		"",
 		"Press R to restart",
+		"Press C to copy result card",
 		"Press Q or ESC to quit...",
-	}
+	)
 
 	statsY := titleY + 6
 	for _, stat := range stats {
-		statX := (w - len(stat)) / 2
+		statX := (w - TextWidth(stat)) / 2
 		t.DrawText(statX, statsY, stat, style)
 		statsY++
 	}
 }
 
+// DrawConfirmDialog draws a centered dialog box asking the user to confirm an action
+func (t *TUI) DrawConfirmDialog(title, message string, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	hint := "Y: Yes | N/ESC: No"
+	width := TextWidth(message) + 6
+	if TextWidth(hint)+6 > width {
+		width = TextWidth(hint) + 6
+	}
+	height := 6
+	x := (w - width) / 2
+	y := (h - height) / 2
+
+	t.FillRect(x, y, width, height, ' ', style)
+	t.DrawBox(x, y, width, height, title, style.Foreground(t.theme.Accent))
+	t.DrawTextAligned(x, y+2, width, message, 0, style.Bold(true))
+	t.DrawTextAligned(x, y+3, width, hint, 0, style.Dim(true))
+}
+
+// DrawPasscodePrompt draws a centered dialog asking for a passcode, masking the digits entered
+func (t *TUI) DrawPasscodePrompt(title string, entered int, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	hint := "Enter: OK | ESC: Cancel"
+	width := TextWidth(hint) + 6
+	height := 6
+	x := (w - width) / 2
+	y := (h - height) / 2
+
+	t.FillRect(x, y, width, height, ' ', style)
+	t.DrawBox(x, y, width, height, title, style.Foreground(t.theme.Accent))
+	t.DrawTextAligned(x, y+2, width, "Passcode: "+strings.Repeat("*", entered), 0, style.Bold(true))
+	t.DrawTextAligned(x, y+3, width, hint, 0, style.Dim(true))
+}
+
+// DrawLeaderboard draws the top scores as a centered table
+func (t *TUI) DrawLeaderboard(entries []protocol.ScoreEntry, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	y := h / 4
+	t.DrawTextAligned(0, y, w, "HIGH SCORES", 0, style.Bold(true).Foreground(t.theme.Accent))
+	y += 2
+
+	if len(entries) == 0 {
+		t.DrawTextAligned(0, y, w, "No games played yet - be the first!", 0, style.Dim(true))
+		return
+	}
+
+	for i, entry := range entries {
+		name := entry.Name
+		if name == "" {
+			name = "-"
+		}
+		line := fmt.Sprintf("%2d. %-16s %8d  Lv %-2d %-8s", i+1, name, entry.Score, entry.Level, capitalize(entry.Mode))
+		t.DrawTextAligned(0, y+i, w, line, 0, style)
+	}
+}
+
 // getPieceShape returns the rotated shape for a piece
 func getPieceShape(pieceData protocol.PieceData) [][]int {
 	// Get base shape
@@ -304,10 +835,22 @@ func rotate90(shape [][]int) [][]int {
 	return rotated
 }
 
+// FormatDuration formats a duration as mm:ss.t
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	minutes := int(d / time.Minute)
+	seconds := int((d % time.Minute) / time.Second)
+	tenths := int((d % time.Second) / (100 * time.Millisecond))
+	return fmt.Sprintf("%02d:%02d.%d", minutes, seconds, tenths)
+}
+
 // capitalize capitalizes the first letter of a string
 func capitalize(s string) string {
-	if s == "" {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
 		return s
 	}
-	return string(s[0]-32) + s[1:]
+	return string(unicode.ToUpper(r)) + s[size:]
 }
@@ -118,3 +118,59 @@ func TestIsValidPieceType(t *testing.T) {
 		}
 	}
 }
+
+// TestGhostCells verifies that the ghost piece lands on the stack or the floor
+func TestGhostCells(t *testing.T) {
+	board := make([][]string, 6)
+	for row := range board {
+		board[row] = make([]string, 4)
+	}
+	board[4][1] = "#808080"
+	square := [][]int{{1, 1}, {1, 1}}
+
+	tests := []struct {
+		name string
+		x, y int
+		want [][2]int
+	}{
+		{"onto the stack", 0, 0, [][2]int{{0, 2}, {1, 2}, {0, 3}, {1, 3}}},
+		{"onto the floor", 2, 0, [][2]int{{2, 4}, {3, 4}, {2, 5}, {3, 5}}},
+		{"overlapping", 1, 3, nil},
+	}
+
+	for _, tt := range tests {
+		got := ghostCells(board, square, tt.x, tt.y)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: ghostCells() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: ghostCells() = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestInDanger verifies the warning starts once the stack reaches the top rows
+func TestInDanger(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *protocol.StateMessage
+		want  bool
+	}{
+		{"no state", nil, false},
+		{"low stack", &protocol.StateMessage{Width: 10, Height: 20, StackHeight: 8}, false},
+		{"below the top rows", &protocol.StateMessage{Width: 10, Height: 20, StackHeight: 16}, false},
+		{"in the top rows", &protocol.StateMessage{Width: 10, Height: 20, StackHeight: 17}, true},
+		{"taller board", &protocol.StateMessage{Width: 10, Height: 40, StackHeight: 17}, false},
+		{"no size reported", &protocol.StateMessage{StackHeight: 18}, true},
+	}
+
+	for _, tt := range tests {
+		if got := InDanger(tt.state); got != tt.want {
+			t.Errorf("%s: InDanger() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// FeatureEmote enables the emote key bindings; turn it on for match games
+const FeatureEmote = "emote"
+
+// emoteDuration is how long a received emote stays on screen
+const emoteDuration = 3 * time.Second
+
+// emoteActions maps the emote key bindings to the emotes they send
+var emoteActions = map[Action]string{
+	ActionEmoteGG:   protocol.EmoteGG,
+	ActionEmoteNice: protocol.EmoteNice,
+	ActionEmoteOops: protocol.EmoteOops,
+}
+
+// emoteLabels are the texts the emotes are shown as
+var emoteLabels = map[string]string{
+	protocol.EmoteGG:   "GG!",
+	protocol.EmoteNice: "Nice!",
+	protocol.EmoteOops: "Oops!",
+}
+
+// EmoteForAction returns the emote an action sends
+func EmoteForAction(action Action) (string, bool) {
+	emote, ok := emoteActions[action]
+	return emote, ok
+}
+
+// EmoteLabel returns the text an emote is shown as
+func EmoteLabel(emote string) string {
+	if label, ok := emoteLabels[emote]; ok {
+		return label
+	}
+	return emote
+}
+
+// EmoteBubble shows the last emote received from an opponent for a few
+// seconds; safe for concurrent use
+type EmoteBubble struct {
+	msg   protocol.EmoteMessage
+	until time.Time
+	mu    sync.Mutex
+}
+
+// Show shows a received emote until emoteDuration after now
+func (b *EmoteBubble) Show(msg protocol.EmoteMessage, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.msg = msg
+	b.until = now.Add(emoteDuration)
+}
+
+// Seat returns the seat of the player whose emote is shown at now
+func (b *EmoteBubble) Seat(now time.Time) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.msg.Seat, now.Before(b.until)
+}
+
+// Visible reports whether an emote is shown at now
+func (b *EmoteBubble) Visible(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Before(b.until)
+}
+
+// DrawEmote draws the emote shown at now centered over area, such as a board;
+// the sender's name is left out if area is too narrow for it
+func (t *TUI) DrawEmote(b *EmoteBubble, area Rect, now time.Time, style tcell.Style) {
+	b.mu.Lock()
+	msg, until := b.msg, b.until
+	b.mu.Unlock()
+	if !now.Before(until) {
+		return
+	}
+
+	from := msg.From
+	if from == "" {
+		from = "anonymous"
+	}
+	text := " " + from + ": " + EmoteLabel(msg.Emote) + " "
+	if TextWidth(text) > area.W {
+		text = Truncate(EmoteLabel(msg.Emote), area.W)
+	}
+	y := area.Y + area.H/2
+	t.ClearRect(Rect{X: area.X + (area.W-TextWidth(text))/2, Y: y, W: TextWidth(text), H: 1})
+	t.DrawTextAligned(area.X, y, area.W, text, 0, style.Foreground(t.theme.Accent).Bold(true))
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestEmoteActions verifies that every emote has a key binding and a label
+func TestEmoteActions(t *testing.T) {
+	bound := make(map[string]bool)
+	for _, b := range DefaultKeymap().Bindings {
+		if emote, ok := EmoteForAction(b.Action); ok {
+			bound[emote] = true
+		}
+	}
+	for _, emote := range protocol.Emotes {
+		if !bound[emote] {
+			t.Errorf("emote %q has no key binding", emote)
+		}
+		if EmoteLabel(emote) == emote {
+			t.Errorf("emote %q has no label", emote)
+		}
+	}
+}
+
+// TestEmoteBubbleVisible verifies that an emote is shown for emoteDuration
+func TestEmoteBubbleVisible(t *testing.T) {
+	var b EmoteBubble
+	now := time.Unix(0, 0)
+	if b.Visible(now) {
+		t.Error("Visible = true before any emote")
+	}
+
+	b.Show(protocol.EmoteMessage{From: "bob", Emote: protocol.EmoteGG}, now)
+	if !b.Visible(now.Add(emoteDuration - time.Millisecond)) {
+		t.Error("Visible = false before emoteDuration passed")
+	}
+	if b.Visible(now.Add(emoteDuration)) {
+		t.Error("Visible = true after emoteDuration passed")
+	}
+}
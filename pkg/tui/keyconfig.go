@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// KeymapConfig is the JSON keymap file format
+// Bindings override the keys of individual actions in the chosen preset, e.g.
+//
+//	{"preset": "wasd", "bindings": {"hold": ["Shift+Tab", "c"]}}
+type KeymapConfig struct {
+	Preset   string              `json:"preset,omitempty"`
+	Bindings map[Action][]string `json:"bindings,omitempty"`
+}
+
+// presetKeys are the keys each preset binds, by action; actions not listed keep the default keys
+var presetKeys = map[string]map[Action][]Key{
+	"default": {},
+	"vi": {
+		ActionMoveLeft:  {RuneKey('h'), {Key: tcell.KeyLeft}},
+		ActionMoveRight: {RuneKey('l'), {Key: tcell.KeyRight}},
+		ActionSoftDrop:  {RuneKey('j'), {Key: tcell.KeyDown}},
+		ActionRotate:    {RuneKey('k'), {Key: tcell.KeyUp}},
+	},
+	"wasd": {
+		ActionMoveLeft:  {RuneKey('a'), {Key: tcell.KeyLeft}},
+		ActionMoveRight: {RuneKey('d'), {Key: tcell.KeyRight}},
+		ActionSoftDrop:  {RuneKey('s'), {Key: tcell.KeyDown}},
+		ActionRotate:    {RuneKey('w'), {Key: tcell.KeyUp}},
+		ActionRotate180: {RuneKey('e')}, // A moves left
+	},
+}
+
+// PresetNames returns the names of the built-in keymap presets
+func PresetNames() []string {
+	return []string{"default", "vi", "wasd"}
+}
+
+// PresetKeymap returns a built-in keymap by name
+func PresetKeymap(name string) (*Keymap, error) {
+	keys, ok := presetKeys[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+
+	k := DefaultKeymap()
+	for action, actionKeys := range keys {
+		if err := k.Bind(action, actionKeys); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// LoadKeymap reads a keymap file
+func LoadKeymap(path string) (*Keymap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg KeymapConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	k, err := cfg.Keymap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// Keymap builds the keymap described by the config
+func (cfg KeymapConfig) Keymap() (*Keymap, error) {
+	preset := cfg.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	k, err := PresetKeymap(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	for action, names := range cfg.Bindings {
+		keys := make([]Key, 0, len(names))
+		for _, name := range names {
+			key, err := ParseKey(name)
+			if err != nil {
+				return nil, fmt.Errorf("binding for %s: %w", action, err)
+			}
+			keys = append(keys, key)
+		}
+		if err := k.Bind(action, keys); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Bind replaces the keys bound to an action, taking them away from any other
+// action so an earlier binding cannot shadow them
+// Ctrl+C always stays bound to quit so a keymap can never lock the player in
+func (k *Keymap) Bind(action Action, keys []Key) error {
+	target := -1
+	for i := range k.Bindings {
+		if k.Bindings[i].Action == action {
+			target = i
+		}
+	}
+	if target < 0 {
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	if action == ActionQuit && !containsKey(keys, Key{Key: tcell.KeyCtrlC}) {
+		keys = append(keys, Key{Key: tcell.KeyCtrlC})
+	}
+	for i := range k.Bindings {
+		if i != target {
+			k.Bindings[i].Keys = k.Bindings[i].withoutKeys(keys)
+		}
+	}
+	k.Bindings[target].Keys = keys
+	return nil
+}
+
+// withoutKeys returns the binding's keys minus the given ones; letters match
+// either case, and Ctrl+C is never taken from quit
+func (b Binding) withoutKeys(keys []Key) []Key {
+	kept := make([]Key, 0, len(b.Keys))
+	for _, key := range b.Keys {
+		if b.Action == ActionQuit && key == (Key{Key: tcell.KeyCtrlC}) {
+			kept = append(kept, key)
+			continue
+		}
+		taken := false
+		for _, other := range keys {
+			if key.sameAs(other) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// sameAs reports whether two keys match the same key presses
+func (key Key) sameAs(other Key) bool {
+	if key.Key != tcell.KeyRune || other.Key != tcell.KeyRune {
+		return key == other
+	}
+	return unicode.ToLower(key.Rune) == unicode.ToLower(other.Rune)
+}
+
+// containsKey reports whether keys contains key
+func containsKey(keys []Key, key Key) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// namedKeys are the special key names accepted by ParseKey, in lower case
+var namedKeys = map[string]tcell.Key{
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"enter":     tcell.KeyEnter,
+	"esc":       tcell.KeyEscape,
+	"escape":    tcell.KeyEscape,
+	"tab":       tcell.KeyTab,
+	"backspace": tcell.KeyBackspace2,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+	"insert":    tcell.KeyInsert,
+	"delete":    tcell.KeyDelete,
+}
+
+// ParseKey converts a key name such as "Left", "Space", "Ctrl+X" or "h" into a Key
+// It accepts the labels produced by Key.Label
+func ParseKey(name string) (Key, error) {
+	if name == " " || strings.EqualFold(name, "space") {
+		return RuneKey(' '), nil
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return RuneKey(runes[0]), nil
+	}
+	if key, ok := namedKeys[strings.ToLower(name)]; ok {
+		return Key{Key: key}, nil
+	}
+
+	// Ctrl+X style names, matched against tcell's own key names
+	tcellName := strings.Replace(name, "+", "-", 1)
+	for key, keyName := range tcell.KeyNames {
+		if strings.EqualFold(keyName, tcellName) {
+			return Key{Key: key}, nil
+		}
+	}
+	return Key{}, fmt.Errorf("unknown key %q", name)
+}
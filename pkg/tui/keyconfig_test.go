@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestParseKey verifies that key names and the labels from Key.Label parse back to keys
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Key
+		wantErr bool
+	}{
+		{"h", RuneKey('h'), false},
+		{"Space", RuneKey(' '), false},
+		{"Left", Key{Key: tcell.KeyLeft}, false},
+		{"ESC", Key{Key: tcell.KeyEscape}, false},
+		{"Ctrl+X", Key{Key: tcell.KeyCtrlX}, false},
+		{"Hyper+Q", Key{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKey(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKey(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestKeymapConfig verifies that a preset is applied and bindings override it
+func TestKeymapConfig(t *testing.T) {
+	k, err := KeymapConfig{
+		Preset:   "vi",
+		Bindings: map[Action][]string{ActionHold: {"Tab"}, ActionQuit: {"q"}},
+	}.Keymap()
+	if err != nil {
+		t.Fatalf("Keymap() error = %v", err)
+	}
+
+	tests := []struct {
+		ev   *tcell.EventKey
+		want Action
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModNone), ActionMoveLeft},
+		{tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), ActionHold},
+		{tcell.NewEventKey(tcell.KeyCtrlC, 0, tcell.ModCtrl), ActionQuit}, // Always kept on quit
+	}
+	for _, tt := range tests {
+		if got, ok := k.Lookup(tt.ev); !ok || got != tt.want {
+			t.Errorf("Lookup(%v) = %q, %v, want %q", tt.ev.Name(), got, ok, tt.want)
+		}
+	}
+
+	// A key bound to one action is taken away from the action that had it
+	k, err = KeymapConfig{Bindings: map[Action][]string{ActionMoveLeft: {"A"}}}.Keymap()
+	if err != nil {
+		t.Fatalf("Keymap() error = %v", err)
+	}
+	if got, ok := k.Lookup(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone)); !ok || got != ActionMoveLeft {
+		t.Errorf("Lookup(a) = %q, %v, want %q", got, ok, ActionMoveLeft)
+	}
+
+	if _, err := (KeymapConfig{Bindings: map[Action][]string{"teleport": {"t"}}}).Keymap(); err == nil {
+		t.Error("Keymap() accepted an unknown action")
+	}
+}
+
+// TestPresetRotate180 verifies each preset binds rotate 180 to a key that
+// does not shadow its movement keys
+func TestPresetRotate180(t *testing.T) {
+	tests := []struct {
+		preset string
+		key    rune
+		want   Action
+	}{
+		{"default", 'a', ActionRotate180},
+		{"vi", 'a', ActionRotate180},
+		{"wasd", 'e', ActionRotate180},
+		{"wasd", 'a', ActionMoveLeft},
+	}
+	for _, tt := range tests {
+		k, err := PresetKeymap(tt.preset)
+		if err != nil {
+			t.Fatalf("PresetKeymap(%q) error = %v", tt.preset, err)
+		}
+		ev := tcell.NewEventKey(tcell.KeyRune, tt.key, tcell.ModNone)
+		if got, ok := k.Lookup(ev); !ok || got != tt.want {
+			t.Errorf("%s: Lookup(%q) = %q, %v, want %q", tt.preset, tt.key, got, ok, tt.want)
+		}
+	}
+}
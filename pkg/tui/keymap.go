@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is a player command that can be bound to keys
+type Action string
+
+const (
+	ActionMoveLeft  Action = "move_left"
+	ActionMoveRight Action = "move_right"
+	ActionSoftDrop  Action = "soft_drop"
+	ActionRotate    Action = "rotate"
+	ActionRotate180 Action = "rotate_180"
+	ActionHardDrop  Action = "hard_drop"
+	ActionHold      Action = "hold"
+	ActionPause     Action = "pause"
+	ActionRestart   Action = "restart"
+	ActionQuit      Action = "quit"
+	ActionHelp      Action = "help"
+	ActionChat      Action = "chat"
+	ActionEmoteGG   Action = "emote_gg"
+	ActionEmoteNice Action = "emote_nice"
+	ActionEmoteOops Action = "emote_oops"
+	ActionSave      Action = "save"
+	ActionUndo      Action = "undo"
+)
+
+const (
+	// FeatureSave enables the save key binding; turn it on for games played alone
+	FeatureSave = "save"
+	// FeatureUndo enables the undo key binding; turn it on in practice mode
+	FeatureUndo = "undo"
+)
+
+// Key is a single key press; Rune is only used when Key is tcell.KeyRune
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+}
+
+// RuneKey returns a Key for a printable character
+func RuneKey(r rune) Key {
+	return Key{Key: tcell.KeyRune, Rune: r}
+}
+
+// Binding binds one or more keys to an action
+type Binding struct {
+	Action      Action
+	Keys        []Key
+	Description string // Shown in the welcome instructions
+	Hint        string // Short name shown in the status bar (empty = not shown)
+	Feature     string // Feature that must be enabled for the binding to be active (empty = always)
+}
+
+// Keymap is the ordered set of key bindings used for input and help text
+type Keymap struct {
+	Bindings []Binding
+	features map[string]bool
+}
+
+// DefaultKeymap returns the built-in key bindings
+func DefaultKeymap() *Keymap {
+	return &Keymap{
+		Bindings: []Binding{
+			{Action: ActionRotate, Keys: []Key{{Key: tcell.KeyUp}}, Description: "Rotate"},
+			{Action: ActionRotate180, Keys: []Key{RuneKey('a')}, Description: "Rotate 180°"},
+			{Action: ActionSoftDrop, Keys: []Key{{Key: tcell.KeyDown}}, Description: "Soft Drop"},
+			{Action: ActionMoveLeft, Keys: []Key{{Key: tcell.KeyLeft}}, Description: "Move Left", Hint: "Move"},
+			{Action: ActionMoveRight, Keys: []Key{{Key: tcell.KeyRight}}, Description: "Move Right", Hint: "Move"},
+			{Action: ActionHardDrop, Keys: []Key{RuneKey(' '), RuneKey('x'), {Key: tcell.KeyEnter}}, Description: "Hard Drop", Hint: "Drop"},
+			{Action: ActionHold, Keys: []Key{RuneKey('c')}, Description: "Hold", Hint: "Hold"},
+			{Action: ActionPause, Keys: []Key{RuneKey('p')}, Description: "Pause/Resume", Hint: "Pause"},
+			{Action: ActionRestart, Keys: []Key{RuneKey('r')}, Description: "Restart"},
+			{Action: ActionQuit, Keys: []Key{{Key: tcell.KeyEscape}, RuneKey('q'), {Key: tcell.KeyCtrlC},
+				{Key: tcell.KeyCtrlD}, {Key: tcell.KeyCtrlQ}, {Key: tcell.KeyCtrlX}}, Description: "Quit", Hint: "Quit"},
+			{Action: ActionHelp, Keys: []Key{{Key: tcell.KeyF1}, RuneKey('?')}, Description: "Help & Settings", Hint: "Help"},
+			{Action: ActionChat, Keys: []Key{RuneKey('t')}, Description: "Chat", Hint: "Chat", Feature: FeatureChat},
+			{Action: ActionEmoteGG, Keys: []Key{RuneKey('1')}, Description: "Emote: GG", Hint: "Emote", Feature: FeatureEmote},
+			{Action: ActionEmoteNice, Keys: []Key{RuneKey('2')}, Description: "Emote: Nice", Hint: "Emote", Feature: FeatureEmote},
+			{Action: ActionEmoteOops, Keys: []Key{RuneKey('3')}, Description: "Emote: Oops", Hint: "Emote", Feature: FeatureEmote},
+			{Action: ActionSave, Keys: []Key{RuneKey('v')}, Description: "Save & Continue Later", Feature: FeatureSave},
+			{Action: ActionUndo, Keys: []Key{RuneKey('z')}, Description: "Undo Last Piece", Hint: "Undo", Feature: FeatureUndo},
+		},
+		features: make(map[string]bool),
+	}
+}
+
+// PartnerKeymap returns the key bindings of the second player of a doubles
+// game, on the left of the keyboard; look them up before the first player's,
+// whose other keys for these actions stay free
+func PartnerKeymap() *Keymap {
+	return &Keymap{
+		Bindings: []Binding{
+			{Action: ActionRotate, Keys: []Key{RuneKey('w')}, Description: "Rotate"},
+			{Action: ActionSoftDrop, Keys: []Key{RuneKey('s')}, Description: "Soft Drop"},
+			{Action: ActionMoveLeft, Keys: []Key{RuneKey('a')}, Description: "Move Left"},
+			{Action: ActionMoveRight, Keys: []Key{RuneKey('d')}, Description: "Move Right"},
+			{Action: ActionHardDrop, Keys: []Key{RuneKey(' ')}, Description: "Hard Drop"},
+			{Action: ActionHold, Keys: []Key{RuneKey('e')}, Description: "Hold"},
+		},
+		features: make(map[string]bool),
+	}
+}
+
+// SetFeature enables or disables a feature; bindings that require a disabled
+// feature are ignored for input and hidden from help text
+func (k *Keymap) SetFeature(feature string, enabled bool) {
+	k.features[feature] = enabled
+}
+
+// isActive reports whether a binding's feature (if any) is enabled
+func (k *Keymap) isActive(b Binding) bool {
+	return b.Feature == "" || k.features[b.Feature]
+}
+
+// Lookup returns the action bound to a key event
+func (k *Keymap) Lookup(ev *tcell.EventKey) (Action, bool) {
+	for _, b := range k.Bindings {
+		if !k.isActive(b) {
+			continue
+		}
+		for _, key := range b.Keys {
+			if key.matches(ev) {
+				return b.Action, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matches reports whether a key event is this key; letters match either case
+func (key Key) matches(ev *tcell.EventKey) bool {
+	if key.Key != tcell.KeyRune {
+		return ev.Key() == key.Key
+	}
+	return ev.Key() == tcell.KeyRune && unicode.ToLower(ev.Rune()) == unicode.ToLower(key.Rune)
+}
+
+// Label returns a human readable name for the key
+func (key Key) Label() string {
+	switch key.Key {
+	case tcell.KeyRune:
+		if key.Rune == ' ' {
+			return "Space"
+		}
+		return string(unicode.ToUpper(key.Rune))
+	case tcell.KeyEscape:
+		return "ESC"
+	case tcell.KeyEnter:
+		return "Enter"
+	case tcell.KeyUp:
+		return "Up"
+	case tcell.KeyDown:
+		return "Down"
+	case tcell.KeyLeft:
+		return "Left"
+	case tcell.KeyRight:
+		return "Right"
+	}
+	if name, ok := tcell.KeyNames[key.Key]; ok {
+		return strings.Replace(name, "-", "+", 1)
+	}
+	return fmt.Sprintf("Key(%d)", key.Key)
+}
+
+// StatusHint returns the short key summary shown in the status bar
+// Bindings sharing a hint are combined, using the first key of each
+func (k *Keymap) StatusHint() string {
+	var order []string
+	labels := make(map[string][]string)
+
+	for _, b := range k.Bindings {
+		if b.Hint == "" || !k.isActive(b) || len(b.Keys) == 0 {
+			continue
+		}
+		if _, ok := labels[b.Hint]; !ok {
+			order = append(order, b.Hint)
+		}
+		labels[b.Hint] = append(labels[b.Hint], b.Keys[0].Label())
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, hint := range order {
+		parts = append(parts, strings.Join(labels[hint], "/")+": "+hint)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// Instructions returns one line per active binding for the welcome screen
+func (k *Keymap) Instructions() []string {
+	lines := make([]string, 0, len(k.Bindings))
+	for _, b := range k.Bindings {
+		if !k.isActive(b) || len(b.Keys) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(b.Keys))
+		for _, key := range b.Keys {
+			keys = append(keys, key.Label())
+		}
+		lines = append(lines, fmt.Sprintf("  %-18s - %s", strings.Join(keys, "/"), b.Description))
+	}
+	return lines
+}
@@ -0,0 +1,211 @@
+package tui
+
+import "github.com/ican2002/tetris/pkg/protocol"
+
+// CellMode is how board cells map to terminal cells
+type CellMode int
+
+const (
+	CellDouble    CellMode = iota // Two columns per cell, for large terminals
+	CellSingle                    // One column per cell
+	CellHalfBlock                 // One column per cell, two board rows per terminal row
+)
+
+// Info panel sizes; the full panel shows mode progress and hold in a second
+// column, the narrow one fits a single column next to any board
+const (
+	infoPanelWidth  = 40
+	infoPanelHeight = 18
+	infoNarrowWidth = 16
+)
+
+// Log window height limits; below the minimum the log is hidden
+const (
+	minLogHeight = 4
+	maxLogHeight = 10
+)
+
+// Rect is an area of the terminal
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Layout places the parts of the game screen for a terminal and board size
+type Layout struct {
+	Cell          CellMode
+	Frame         Rect // Box around the board and info panel
+	Board         Rect // Board cells, inside the frame
+	Info          Rect // Info panel, right of the board
+	StatusY       int  // Row of the status bar, which spans the terminal
+	Log           Rect // Log window below the status bar; zero if there is no room
+	ShowLog       bool
+	Opponents     [maxOpponents]Rect // Opponent boards, placed by WithOpponents
+	OpponentCount int                // Number of Opponents in use
+	Chat          Rect               // Chat pane, placed by WithChat
+	ShowChat      bool
+	TooSmall      bool // Even the most compact layout does not fit
+	MinWidth      int  // Smallest terminal the most compact layout fits in
+	MinHeight     int
+
+	// The second board of a doubles game and its info panel, right of the
+	// first one's; placed by ComputeDoublesLayout
+	Partner     Rect
+	PartnerInfo Rect
+	Doubles     bool
+}
+
+// layoutChoices lists cell modes and info panel widths from most to least roomy
+var layoutChoices = []struct {
+	cell      CellMode
+	infoWidth int
+}{
+	{CellDouble, infoPanelWidth},
+	{CellSingle, infoPanelWidth},
+	{CellSingle, infoNarrowWidth},
+	{CellHalfBlock, infoNarrowWidth},
+}
+
+// displaySize returns the size of a board drawn in this mode, in terminal cells
+func (m CellMode) displaySize(boardW, boardH int) (int, int) {
+	switch m {
+	case CellDouble:
+		return boardW * 2, boardH
+	case CellHalfBlock:
+		return boardW, (boardH + 1) / 2
+	default:
+		return boardW, boardH
+	}
+}
+
+// ComputeLayout picks the roomiest layout of a boardW x boardH board that fits
+// a screenW x screenH terminal, shrinking the cells and the info panel and
+// hiding the log window as space gets tight
+func ComputeLayout(screenW, screenH, boardW, boardH int) Layout {
+	return computeLayout(screenW, screenH, boardW, boardH, 1)
+}
+
+// ComputeDoublesLayout is ComputeLayout for the two boards of a doubles game,
+// side by side in one frame, each with its info panel on its right
+func ComputeDoublesLayout(screenW, screenH, boardW, boardH int) Layout {
+	return computeLayout(screenW, screenH, boardW, boardH, 2)
+}
+
+// computeLayout lays out boards boards of boardW x boardH and their info panels
+func computeLayout(screenW, screenH, boardW, boardH, boards int) Layout {
+	// Each board and info panel sit inside a frame with a gap between them,
+	// and the status bar takes the row below the frame
+	width := func(w, infoWidth int) int {
+		return boards*(w+infoWidth+4) + 4
+	}
+	fits := func(cell CellMode, infoWidth int) bool {
+		w, h := cell.displaySize(boardW, boardH)
+		if infoWidth == infoPanelWidth && h < infoPanelHeight {
+			return false
+		}
+		return width(w, infoWidth) <= screenW && h+3 <= screenH
+	}
+
+	minW, minH := CellHalfBlock.displaySize(boardW, boardH)
+	l := Layout{MinWidth: width(minW, infoNarrowWidth), MinHeight: minH + 3}
+
+	for _, choice := range layoutChoices {
+		if !fits(choice.cell, choice.infoWidth) {
+			continue
+		}
+
+		w, h := choice.cell.displaySize(boardW, boardH)
+		l.Cell = choice.cell
+		l.Frame = Rect{X: 1, Y: 0, W: screenW - 2, H: h + 2}
+		l.Board = Rect{X: 2, Y: 1, W: w, H: h}
+		l.Info = Rect{X: l.Board.X + w + 4, Y: 1, W: choice.infoWidth, H: h}
+		if boards > 1 {
+			l.Partner = Rect{X: l.Info.X + choice.infoWidth + 4, Y: 1, W: w, H: h}
+			l.PartnerInfo = Rect{X: l.Partner.X + w + 4, Y: 1, W: choice.infoWidth, H: h}
+			l.Doubles = true
+		}
+		l.StatusY = l.Frame.H
+
+		// A separator row, then the log with whatever rows remain
+		if rows := screenH - l.StatusY - 2; rows >= minLogHeight {
+			if rows > maxLogHeight {
+				rows = maxLogHeight
+			}
+			l.Log = Rect{X: 0, Y: l.StatusY + 2, W: screenW, H: rows}
+			l.ShowLog = true
+		}
+		return l
+	}
+
+	l.TooSmall = true
+	l.StatusY = screenH - 1
+	return l
+}
+
+// WithOpponents places up to n opponent boards of boardW x boardH side by
+// side right of the info panel, as many as the frame has room for
+func (l Layout) WithOpponents(n, boardW, boardH int) Layout {
+	if l.TooSmall {
+		return l
+	}
+
+	w, h := miniBoardSize(boardW, boardH)
+	if h > l.Board.H {
+		return l
+	}
+	x := l.Info.X + l.Info.W + 2
+	for l.OpponentCount < min(n, maxOpponents) && x+w <= l.Frame.X+l.Frame.W-1 {
+		l.Opponents[l.OpponentCount] = Rect{X: x, Y: l.Board.Y, W: w, H: h}
+		l.OpponentCount++
+		x += w + 2
+	}
+	return l
+}
+
+// WithChat places a chat pane for match games: right of the info panel and
+// any opponent boards if the frame has room, otherwise in the left half of
+// the log window
+// The layout is unchanged if neither has room
+func (l Layout) WithChat() Layout {
+	if l.TooSmall {
+		return l
+	}
+
+	x := l.Info.X + l.Info.W + 2
+	if l.OpponentCount > 0 {
+		last := l.Opponents[l.OpponentCount-1]
+		x = last.X + last.W + 2
+	}
+	if w := l.Frame.X + l.Frame.W - 1 - x; w >= minChatWidth {
+		l.Chat = Rect{X: x, Y: l.Board.Y, W: w, H: l.Board.H}
+		l.ShowChat = true
+	} else if l.ShowLog && l.Log.W/2 >= minChatWidth {
+		l.Chat = Rect{X: l.Log.X, Y: l.Log.Y, W: l.Log.W / 2, H: l.Log.H}
+		l.Log.X += l.Chat.W
+		l.Log.W -= l.Chat.W
+		l.ShowChat = true
+	}
+	return l
+}
+
+// Layout returns the layout of the board reported in state at the current
+// terminal size; a nil state lays out the classic 10x20 board
+func (t *TUI) Layout(state *protocol.StateMessage) Layout {
+	boardW, boardH := boardSize(state)
+	screenW, screenH := t.screen.Size()
+	return ComputeLayout(screenW, screenH, boardW, boardH)
+}
+
+// DoublesLayout returns the layout of a doubles game, whose boards are both
+// the size reported in state
+func (t *TUI) DoublesLayout(state *protocol.StateMessage) Layout {
+	boardW, boardH := boardSize(state)
+	screenW, screenH := t.screen.Size()
+	return ComputeDoublesLayout(screenW, screenH, boardW, boardH)
+}
+
+// MatchLayout returns the layout of a match game with the given number of
+// opponents, adding their boards and the chat pane
+func (t *TUI) MatchLayout(state *protocol.StateMessage, opponents int) Layout {
+	boardW, boardH := boardSize(state)
+	return t.Layout(state).WithOpponents(opponents, boardW, boardH).WithChat()
+}
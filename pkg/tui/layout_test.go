@@ -0,0 +1,206 @@
+package tui
+
+import "testing"
+
+// TestComputeLayout verifies that the layout shrinks cells, the info panel and
+// the log window as the terminal gets smaller
+func TestComputeLayout(t *testing.T) {
+	tests := []struct {
+		name           string
+		screenW        int
+		screenH        int
+		boardW         int
+		boardH         int
+		wantCell       CellMode
+		wantInfoW      int
+		wantShowLog    bool
+		wantTooSmall   bool
+		wantBoardWidth int
+	}{
+		{"large terminal", 120, 40, 10, 20, CellDouble, infoPanelWidth, true, false, 20},
+		{"classic 80x30", 80, 30, 10, 20, CellDouble, infoPanelWidth, true, false, 20},
+		{"80x24 hides log", 80, 24, 10, 20, CellDouble, infoPanelWidth, false, false, 20},
+		{"wide board", 80, 30, 20, 20, CellSingle, infoPanelWidth, true, false, 20},
+		{"narrow terminal", 40, 30, 10, 20, CellSingle, infoNarrowWidth, true, false, 10},
+		{"short terminal", 80, 16, 10, 20, CellHalfBlock, infoNarrowWidth, false, false, 10},
+		{"tiny terminal", 20, 10, 10, 20, 0, 0, false, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := ComputeLayout(tt.screenW, tt.screenH, tt.boardW, tt.boardH)
+
+			if l.TooSmall != tt.wantTooSmall {
+				t.Fatalf("TooSmall = %v, want %v", l.TooSmall, tt.wantTooSmall)
+			}
+			if l.TooSmall {
+				return
+			}
+			if l.Cell != tt.wantCell {
+				t.Errorf("Cell = %v, want %v", l.Cell, tt.wantCell)
+			}
+			if l.Info.W != tt.wantInfoW {
+				t.Errorf("Info.W = %d, want %d", l.Info.W, tt.wantInfoW)
+			}
+			if l.ShowLog != tt.wantShowLog {
+				t.Errorf("ShowLog = %v, want %v", l.ShowLog, tt.wantShowLog)
+			}
+			if l.Board.W != tt.wantBoardWidth {
+				t.Errorf("Board.W = %d, want %d", l.Board.W, tt.wantBoardWidth)
+			}
+
+			// Everything must stay on screen
+			if right := l.Info.X + l.Info.W; right > tt.screenW {
+				t.Errorf("info panel ends at column %d, beyond width %d", right, tt.screenW)
+			}
+			if l.StatusY >= tt.screenH {
+				t.Errorf("StatusY = %d, beyond height %d", l.StatusY, tt.screenH)
+			}
+			if l.ShowLog && l.Log.Y+l.Log.H > tt.screenH {
+				t.Errorf("log window ends at row %d, beyond height %d", l.Log.Y+l.Log.H, tt.screenH)
+			}
+		})
+	}
+}
+
+// TestComputeLayoutMinimumSize verifies that the reported minimum size fits the most compact layout
+func TestComputeLayoutMinimumSize(t *testing.T) {
+	l := ComputeLayout(1, 1, 10, 20)
+	if !l.TooSmall {
+		t.Fatal("TooSmall = false for a 1x1 terminal")
+	}
+
+	if fit := ComputeLayout(l.MinWidth, l.MinHeight, 10, 20); fit.TooSmall {
+		t.Errorf("layout at minimum size %dx%d is too small", l.MinWidth, l.MinHeight)
+	}
+	if fit := ComputeLayout(l.MinWidth-1, l.MinHeight, 10, 20); !fit.TooSmall {
+		t.Errorf("layout one column below minimum width %d fits", l.MinWidth)
+	}
+}
+
+// TestLayoutWithChat verifies that the chat pane fits beside the info panel or in the log window
+func TestLayoutWithChat(t *testing.T) {
+	tests := []struct {
+		name             string
+		screenW, screenH int
+		showChat         bool
+	}{
+		{"wide", 120, 40, true},
+		{"narrow with log", 60, 40, true},
+		{"too small", 30, 22, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := ComputeLayout(tt.screenW, tt.screenH, 10, 20)
+			l := base.WithChat()
+			if l.ShowChat != tt.showChat {
+				t.Fatalf("ShowChat = %v, want %v", l.ShowChat, tt.showChat)
+			}
+			if !l.ShowChat {
+				return
+			}
+			if l.Chat.W < minChatWidth {
+				t.Errorf("chat width = %d, want at least %d", l.Chat.W, minChatWidth)
+			}
+			if l.Chat.X+l.Chat.W > tt.screenW || l.Chat.Y+l.Chat.H > tt.screenH {
+				t.Errorf("chat %+v exceeds the %dx%d screen", l.Chat, tt.screenW, tt.screenH)
+			}
+			if l.Chat.X < base.Info.X+base.Info.W && l.Chat.Y < base.Info.Y+base.Info.H && l.Chat.Y+l.Chat.H > base.Info.Y {
+				t.Errorf("chat %+v overlaps the info panel %+v", l.Chat, base.Info)
+			}
+			if l.ShowLog && l.Chat.Y == l.Log.Y && l.Log.X < l.Chat.X+l.Chat.W {
+				t.Errorf("log %+v overlaps the chat %+v", l.Log, l.Chat)
+			}
+		})
+	}
+}
+
+// TestLayoutWithOpponents verifies that opponent boards fit in the frame right of the info panel
+func TestLayoutWithOpponents(t *testing.T) {
+	tests := []struct {
+		name             string
+		screenW, screenH int
+		opponents        int
+		want             int
+	}{
+		{"one", 120, 40, 1, 1},
+		{"more than fit", 100, 40, 5, 2},
+		{"capped", 300, 40, 5, maxOpponents},
+		{"no room", 70, 40, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := ComputeLayout(tt.screenW, tt.screenH, 10, 20)
+			l := base.WithOpponents(tt.opponents, 10, 20)
+			if l.OpponentCount != tt.want {
+				t.Fatalf("OpponentCount = %d, want %d", l.OpponentCount, tt.want)
+			}
+			for i := 0; i < l.OpponentCount; i++ {
+				r := l.Opponents[i]
+				if r.X < base.Info.X+base.Info.W || r.X+r.W > l.Frame.X+l.Frame.W-1 || r.Y+r.H > l.Board.Y+l.Board.H {
+					t.Errorf("opponent %d at %+v is outside the frame %+v", i, r, l.Frame)
+				}
+			}
+
+			// The chat pane goes right of the opponents
+			chat := l.WithChat()
+			if chat.ShowChat && chat.OpponentCount > 0 && chat.Chat.Y == l.Board.Y {
+				last := l.Opponents[l.OpponentCount-1]
+				if chat.Chat.X < last.X+last.W {
+					t.Errorf("chat %+v overlaps opponent %+v", chat.Chat, last)
+				}
+			}
+		})
+	}
+}
+
+// TestComputeDoublesLayout verifies that both boards of a doubles game and
+// their info panels fit side by side on screen without overlapping
+func TestComputeDoublesLayout(t *testing.T) {
+	tests := []struct {
+		name             string
+		screenW, screenH int
+		wantCell         CellMode
+		wantInfoW        int
+		wantTooSmall     bool
+	}{
+		{"wide", 140, 40, CellDouble, infoPanelWidth, false},
+		{"large terminal", 120, 40, CellSingle, infoPanelWidth, false},
+		{"classic 80x30", 80, 30, CellSingle, infoNarrowWidth, false},
+		{"too narrow", 50, 30, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := ComputeDoublesLayout(tt.screenW, tt.screenH, 10, 20)
+			if l.TooSmall != tt.wantTooSmall {
+				t.Fatalf("TooSmall = %v, want %v", l.TooSmall, tt.wantTooSmall)
+			}
+			if l.TooSmall {
+				return
+			}
+			if !l.Doubles {
+				t.Error("Doubles = false")
+			}
+			if l.Cell != tt.wantCell || l.PartnerInfo.W != tt.wantInfoW {
+				t.Errorf("Cell, PartnerInfo.W = %v, %d, want %v, %d", l.Cell, l.PartnerInfo.W, tt.wantCell, tt.wantInfoW)
+			}
+			if l.Partner.W != l.Board.W || l.Partner.H != l.Board.H {
+				t.Errorf("Partner = %+v, want the size of Board %+v", l.Partner, l.Board)
+			}
+			if l.Partner.X < l.Info.X+l.Info.W {
+				t.Errorf("Partner %+v overlaps the info panel %+v", l.Partner, l.Info)
+			}
+			if right := l.PartnerInfo.X + l.PartnerInfo.W; right > l.Frame.X+l.Frame.W-1 {
+				t.Errorf("partner info panel ends at column %d, beyond the frame %+v", right, l.Frame)
+			}
+		})
+	}
+
+	l := ComputeDoublesLayout(1, 1, 10, 20)
+	if fit := ComputeDoublesLayout(l.MinWidth, l.MinHeight, 10, 20); fit.TooSmall {
+		t.Errorf("layout at minimum size %dx%d is too small", l.MinWidth, l.MinHeight)
+	}
+}
@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// LogLevel is the severity of a log message
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota // Key presses and commands sent
+	LogInfo
+	LogError
+)
+
+// String returns the name of the level
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// LogEntry is a message in a LogBuffer
+type LogEntry struct {
+	Time  time.Time
+	Level LogLevel
+	Text  string
+}
+
+// String formats the entry with its timestamp
+func (e LogEntry) String() string {
+	return fmt.Sprintf("[%s] %s", e.Time.Format("15:04:05"), e.Text)
+}
+
+// LogBuffer keeps the most recent log messages; safe for concurrent use
+type LogBuffer struct {
+	entries []LogEntry
+	maxSize int
+	version uint64 // Incremented by every message
+	mu      sync.Mutex
+}
+
+// NewLogBuffer creates a log buffer keeping the last size messages
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{
+		entries: make([]LogEntry, 0, size),
+		maxSize: size,
+	}
+}
+
+// Add adds an info message
+func (lb *LogBuffer) Add(msg string) {
+	lb.Log(LogInfo, msg)
+}
+
+// Debug adds a debug message
+func (lb *LogBuffer) Debug(msg string) {
+	lb.Log(LogDebug, msg)
+}
+
+// Error adds an error message
+func (lb *LogBuffer) Error(msg string) {
+	lb.Log(LogError, msg)
+}
+
+// Log adds a message with the given level
+func (lb *LogBuffer) Log(level LogLevel, msg string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.entries = append(lb.entries, LogEntry{Time: time.Now(), Level: level, Text: msg})
+	lb.version++
+
+	// Keep only the last maxSize messages
+	if len(lb.entries) > lb.maxSize {
+		lb.entries = lb.entries[1:]
+	}
+}
+
+// Version returns a number that changes whenever a message is added, so a
+// renderer can tell whether the log needs redrawing
+func (lb *LogBuffer) Version() uint64 {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.version
+}
+
+// Entries returns the messages at or above minLevel, oldest first
+func (lb *LogBuffer) Entries(minLevel LogLevel) []LogEntry {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	entries := make([]LogEntry, 0, len(lb.entries))
+	for _, e := range lb.entries {
+		if e.Level >= minLevel {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// LogView is a scrollable, filterable window onto a LogBuffer
+// PgUp/PgDn scroll, F2 cycles the minimum level shown and F3 collapses the window
+type LogView struct {
+	Buffer    *LogBuffer
+	MinLevel  LogLevel
+	Collapsed bool
+
+	scroll int // Messages skipped from the newest one
+}
+
+// NewLogView creates a view showing every message in buf
+func NewLogView(buf *LogBuffer) *LogView {
+	return &LogView{Buffer: buf, MinLevel: LogDebug}
+}
+
+// LogViewKeys is the help text for the keys handled by a LogView
+const LogViewKeys = "PgUp/PgDn/F2/F3"
+
+// HandleKey scrolls, filters or collapses the view; returns whether the key was used
+func (v *LogView) HandleKey(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyPgUp:
+		v.Scroll(-5)
+	case tcell.KeyPgDn:
+		v.Scroll(5)
+	case tcell.KeyF2:
+		v.MinLevel = (v.MinLevel + 1) % (LogError + 1)
+		v.scroll = 0
+	case tcell.KeyF3:
+		v.Collapsed = !v.Collapsed
+	default:
+		return false
+	}
+	return true
+}
+
+// Scroll moves the view by n messages; positive n shows older messages
+// The view is kept in range when it is drawn
+func (v *LogView) Scroll(n int) {
+	v.scroll += n
+	if v.scroll < 0 {
+		v.scroll = 0
+	}
+}
+
+// visible returns the messages shown in rows lines, newest first, clamping the scroll position
+func (v *LogView) visible(rows int) []LogEntry {
+	entries := v.Buffer.Entries(v.MinLevel)
+	if max := len(entries) - rows; v.scroll > max {
+		v.scroll = max
+	}
+	if v.scroll < 0 {
+		v.scroll = 0
+	}
+
+	var shown []LogEntry
+	for i := len(entries) - 1 - v.scroll; i >= 0 && len(shown) < rows; i-- {
+		shown = append(shown, entries[i])
+	}
+	return shown
+}
+
+// DrawLogView draws the log window in area with the newest message on top
+// A collapsed view takes a single row
+func (t *TUI) DrawLogView(v *LogView, area Rect, style tcell.Style) {
+	if v.Collapsed {
+		t.DrawHLine(area.X, area.Y, area.W, style.Dim(true))
+		t.DrawText(area.X+2, area.Y, " Messages hidden (F3) ", style.Dim(true))
+		return
+	}
+
+	title := "Messages"
+	if v.MinLevel > LogDebug {
+		title += " (" + v.MinLevel.String() + "+)"
+	}
+	shown := v.visible(area.H - 2)
+	if v.scroll > 0 {
+		title += fmt.Sprintf(" ↓%d", v.scroll)
+	}
+	t.DrawBox(area.X, area.Y, area.W, area.H, title, style)
+
+	for i, e := range shown {
+		lineStyle := style
+		switch e.Level {
+		case LogDebug:
+			lineStyle = style.Dim(true)
+		case LogError:
+			lineStyle = style.Foreground(t.theme.Bad)
+		}
+		t.DrawText(area.X+2, area.Y+1+i, Truncate(e.String(), area.W-4), lineStyle)
+	}
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestLogBufferLimit verifies that the buffer keeps only the newest messages
+func TestLogBufferLimit(t *testing.T) {
+	lb := NewLogBuffer(3)
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		lb.Add(msg)
+	}
+
+	entries := lb.Entries(LogDebug)
+	if len(entries) != 3 || entries[0].Text != "b" || entries[2].Text != "d" {
+		t.Errorf("Entries() = %+v, want b, c, d", entries)
+	}
+}
+
+// TestLogViewFilter verifies that cycling the level filter hides lower levels
+func TestLogViewFilter(t *testing.T) {
+	lb := NewLogBuffer(10)
+	lb.Debug("Key: Up")
+	lb.Add("Connected")
+	lb.Error("✗ Failed")
+	v := NewLogView(lb)
+
+	tests := []struct {
+		level LogLevel
+		want  int
+	}{
+		{LogInfo, 2},
+		{LogError, 1},
+		{LogDebug, 3},
+	}
+	for _, tt := range tests {
+		v.HandleKey(tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone))
+		if v.MinLevel != tt.level {
+			t.Fatalf("MinLevel = %v, want %v", v.MinLevel, tt.level)
+		}
+		if got := len(v.visible(10)); got != tt.want {
+			t.Errorf("%v: %d messages shown, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+// TestLogViewScroll verifies that scrolling shows older messages and stays in range
+func TestLogViewScroll(t *testing.T) {
+	lb := NewLogBuffer(10)
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		lb.Add(msg)
+	}
+	v := NewLogView(lb)
+
+	if shown := v.visible(2); shown[0].Text != "5" {
+		t.Errorf("newest shown = %q, want 5", shown[0].Text)
+	}
+
+	v.Scroll(2)
+	if shown := v.visible(2); shown[0].Text != "3" || shown[1].Text != "2" {
+		t.Errorf("after scrolling 2: shown = %+v, want 3, 2", shown)
+	}
+
+	v.Scroll(100)
+	if shown := v.visible(2); shown[0].Text != "2" || shown[1].Text != "1" {
+		t.Errorf("after scrolling past the end: shown = %+v, want 2, 1", shown)
+	}
+
+	v.Scroll(-100)
+	if shown := v.visible(2); shown[0].Text != "5" {
+		t.Errorf("after scrolling back: newest shown = %q, want 5", shown[0].Text)
+	}
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/board"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// maxOpponents is the most opponent boards shown beside the player's own
+const maxOpponents = 3
+
+// Opponents keeps the latest state of the other players of a match, by seat;
+// safe for concurrent use
+type Opponents struct {
+	states map[int]protocol.OpponentStateMessage
+	mu     sync.Mutex
+}
+
+// NewOpponents creates an empty set of opponents
+func NewOpponents() *Opponents {
+	return &Opponents{states: make(map[int]protocol.OpponentStateMessage)}
+}
+
+// Update stores an opponent's state
+func (o *Opponents) Update(msg protocol.OpponentStateMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.states[msg.Seat] = msg
+}
+
+// Retain forgets the opponents who are no longer in the match roster
+func (o *Opponents) Retain(roster []protocol.MatchPlayer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	seats := make(map[int]bool, len(roster))
+	for _, p := range roster {
+		seats[p.Seat] = true
+	}
+	for seat := range o.states {
+		if !seats[seat] {
+			delete(o.states, seat)
+		}
+	}
+}
+
+// List returns the opponents in seat order
+func (o *Opponents) List() []protocol.OpponentStateMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	list := make([]protocol.OpponentStateMessage, 0, len(o.states))
+	for _, s := range o.states {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Seat < list[j].Seat })
+	return list
+}
+
+// miniBoardSize returns the size of an opponent board drawn by DrawMiniBoard:
+// a garbage meter column beside a half-block board, with the name above and
+// the score below
+func miniBoardSize(boardW, boardH int) (int, int) {
+	w, h := CellHalfBlock.displaySize(boardW, boardH)
+	return w + 1, h + 2
+}
+
+// DrawMiniBoard draws an opponent's board at half scale in area, with a red
+// meter left of the board for the garbage about to rise into it
+func (t *TUI) DrawMiniBoard(area Rect, opp protocol.OpponentStateMessage, style tcell.Style) {
+	over := opp.State == "game_over"
+	nameStyle := style.Bold(true)
+	if over {
+		nameStyle = style.Dim(true)
+	}
+	name := opp.Name
+	if name == "" {
+		name = "anonymous"
+	}
+	t.DrawText(area.X, area.Y, Truncate(name, area.W), nameStyle)
+
+	rows := area.H - 2
+	if opp.Board != nil {
+		if b, err := board.UnmarshalCompact(opp.Board); err == nil {
+			grid := b.Grid()
+			if len(grid) > rows*2 {
+				grid = grid[len(grid)-rows*2:]
+			}
+			t.drawHalfBlocks(area.X+1, area.Y+1, grid, style)
+		}
+	}
+
+	meter, meterStyle := '█', style.Foreground(t.theme.Bad)
+	if t.theme.ASCII {
+		meter = '#'
+	}
+	garbage := min((opp.PendingGarbage+1)/2, rows)
+	for i := 0; i < garbage; i++ {
+		t.screen.SetContent(area.X, area.Y+rows-i, meter, nil, meterStyle)
+	}
+
+	stats := fmt.Sprintf("%d", opp.Score)
+	if over {
+		stats = "OUT " + stats
+	}
+	t.DrawText(area.X, area.Y+area.H-1, Truncate(stats, area.W), style.Dim(over))
+}
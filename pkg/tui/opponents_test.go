@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestOpponentsRetain verifies that opponents are listed by seat and dropped when they leave the roster
+func TestOpponentsRetain(t *testing.T) {
+	o := NewOpponents()
+	for _, seat := range []int{3, 0, 2} {
+		o.Update(protocol.OpponentStateMessage{Seat: seat})
+	}
+	o.Update(protocol.OpponentStateMessage{Seat: 2, Score: 100})
+
+	o.Retain([]protocol.MatchPlayer{{Seat: 1}, {Seat: 2}, {Seat: 3}})
+	list := o.List()
+	if len(list) != 2 || list[0].Seat != 2 || list[1].Seat != 3 {
+		t.Fatalf("List = %+v, want seats 2 and 3", list)
+	}
+	if list[0].Score != 100 {
+		t.Errorf("seat 2 score = %d, want the latest 100", list[0].Score)
+	}
+}
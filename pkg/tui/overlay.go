@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Setting is an entry of the settings menu with its current value
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// DrawHelpOverlay draws a centered box listing the active key bindings above
+// the settings menu, with the selected setting highlighted
+func (t *TUI) DrawHelpOverlay(settings []Setting, selected int, style tcell.Style) {
+	w, h := t.screen.Size()
+
+	hint := "↑/↓: Select | ←/→: Change | F1/?/ESC: Close"
+	lines := append([]string{"Controls:"}, t.keymap.Instructions()...)
+	lines = append(lines, fmt.Sprintf("  %-18s - %s", LogViewKeys, "Scroll/Filter/Hide Log"))
+	lines = append(lines, "", "Settings:")
+	settingsY := len(lines)
+	for _, s := range settings {
+		lines = append(lines, fmt.Sprintf("  %-14s < %s >", s.Name, s.Value))
+	}
+
+	width := TextWidth(hint) + 6
+	for _, line := range lines {
+		if TextWidth(line)+6 > width {
+			width = TextWidth(line) + 6
+		}
+	}
+	height := len(lines) + 5
+	x := (w - width) / 2
+	y := (h - height) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	t.FillRect(x, y, width, height, ' ', style)
+	t.DrawBox(x, y, width, height, "Help & Settings", style.Foreground(t.theme.Accent))
+
+	for i, line := range lines {
+		lineStyle := style
+		switch {
+		case i == 0 || i == settingsY-1:
+			lineStyle = style.Bold(true)
+		case i-settingsY == selected:
+			lineStyle = style.Reverse(true)
+		}
+		t.DrawText(x+3, y+2+i, line, lineStyle)
+	}
+	t.DrawTextAligned(x, y+height-2, width, hint, 0, style.Dim(true))
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Score popups float up over the board for popupDuration, popupRise rows
+const (
+	popupDuration = 1200 * time.Millisecond
+	popupRise     = 4
+	maxPopups     = 3 // Older popups are dropped when more arrive at once
+)
+
+// clearNames are the names of line clears by the number of lines
+var clearNames = map[int]string{
+	1: "SINGLE",
+	2: "DOUBLE",
+	3: "TRIPLE",
+	4: "TETRIS!",
+}
+
+// popup is a text floating over the board
+type popup struct {
+	text  string
+	anim  Animation
+	level bool // A level up, drawn in the accent color
+}
+
+// Popups shows score events as texts floating up over the board; safe for
+// concurrent use
+type Popups struct {
+	popups []popup
+	mu     sync.Mutex
+}
+
+// ScorePopupText returns the popup shown for a line clear, such as
+// "+800 TETRIS!"
+func ScorePopupText(ev protocol.ScoreEventMessage) string {
+	name, ok := clearNames[ev.Lines]
+	if !ok {
+		name = fmt.Sprintf("%d LINES", ev.Lines)
+	}
+	return fmt.Sprintf("+%d %s", ev.Points, name)
+}
+
+// Show adds the popups of a score event at now: the points awarded and, if
+// the clear raised the level, the new level
+func (p *Popups) Show(ev protocol.ScoreEventMessage, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.popups = slices.DeleteFunc(p.popups, func(pp popup) bool {
+		_, playing := pp.anim.Progress(now)
+		return !playing
+	})
+	p.add(popup{text: ScorePopupText(ev), anim: Animation{Start: now, Duration: popupDuration}})
+	if ev.LevelUp {
+		p.add(popup{text: fmt.Sprintf("LEVEL %d!", ev.Level), anim: Animation{Start: now, Duration: popupDuration}, level: true})
+	}
+}
+
+// add appends a popup, dropping the oldest beyond maxPopups
+// Must be called with mu held
+func (p *Popups) add(pp popup) {
+	p.popups = append(p.popups, pp)
+	if len(p.popups) > maxPopups {
+		p.popups = p.popups[len(p.popups)-maxPopups:]
+	}
+}
+
+// Active reports whether a popup is shown at now, so the board needs
+// redrawing every frame
+func (p *Popups) Active(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pp := range p.popups {
+		if _, playing := pp.anim.Progress(now); playing {
+			return true
+		}
+	}
+	return false
+}
+
+// DrawPopups draws the popups shown at now over area, such as a board; each
+// starts in the lower half of area and rises as it plays, newer ones below
+// Plain themes leave them out, since the events are announced in the status bar
+func (t *TUI) DrawPopups(p *Popups, area Rect, now time.Time, style tcell.Style) {
+	if t.theme.Plain {
+		return
+	}
+	p.mu.Lock()
+	popups := append([]popup(nil), p.popups...)
+	p.mu.Unlock()
+
+	base := area.Y + area.H*2/3
+	for i, pp := range popups {
+		progress, playing := pp.anim.Progress(now)
+		if !playing {
+			continue
+		}
+		y := base + i - int(progress*popupRise)
+		if y < area.Y || y >= area.Y+area.H {
+			continue
+		}
+		text := Truncate(" "+pp.text+" ", area.W)
+		popupStyle := style.Foreground(t.theme.Good).Bold(true)
+		if pp.level {
+			popupStyle = style.Foreground(t.theme.Accent).Bold(true)
+		}
+		t.ClearRect(Rect{X: area.X + (area.W-TextWidth(text))/2, Y: y, W: TextWidth(text), H: 1})
+		t.DrawTextAligned(area.X, y, area.W, text, 0, popupStyle)
+	}
+}
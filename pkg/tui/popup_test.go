@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestScorePopupText verifies the popups name the line clears
+func TestScorePopupText(t *testing.T) {
+	tests := []struct {
+		ev   protocol.ScoreEventMessage
+		want string
+	}{
+		{protocol.ScoreEventMessage{Lines: 1, Points: 100}, "+100 SINGLE"},
+		{protocol.ScoreEventMessage{Lines: 3, Points: 1000}, "+1000 TRIPLE"},
+		{protocol.ScoreEventMessage{Lines: 4, Points: 800}, "+800 TETRIS!"},
+		{protocol.ScoreEventMessage{Lines: 5, Points: 0}, "+0 5 LINES"},
+	}
+
+	for _, tt := range tests {
+		if got := ScorePopupText(tt.ev); got != tt.want {
+			t.Errorf("ScorePopupText(%+v) = %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+// TestPopupsActive verifies popups play for popupDuration, and a level up adds a second one
+func TestPopupsActive(t *testing.T) {
+	start := time.Now()
+	var p Popups
+	if p.Active(start) {
+		t.Error("Active() = true before any score event")
+	}
+
+	p.Show(protocol.ScoreEventMessage{Lines: 4, Points: 800, Level: 2, LevelUp: true}, start)
+	if len(p.popups) != 2 {
+		t.Fatalf("Show() with a level up added %d popups, want 2", len(p.popups))
+	}
+	if !p.Active(start.Add(popupDuration / 2)) {
+		t.Error("Active() = false while the popups play")
+	}
+	if p.Active(start.Add(popupDuration)) {
+		t.Error("Active() = true after the popups ended")
+	}
+
+	// Finished popups are dropped when new ones arrive
+	p.Show(protocol.ScoreEventMessage{Lines: 1, Points: 100, Level: 2}, start.Add(2*popupDuration))
+	if len(p.popups) != 1 {
+		t.Errorf("Show() kept %d popups, want 1", len(p.popups))
+	}
+}
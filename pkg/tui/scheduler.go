@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Region is a part of the game screen that can be redrawn on its own
+type Region uint8
+
+const (
+	RegionBoard Region = 1 << iota
+	RegionInfo
+	RegionStatus
+	RegionLog
+	RegionChat
+	RegionOpponents
+
+	RegionAll = RegionBoard | RegionInfo | RegionStatus | RegionLog | RegionChat | RegionOpponents
+)
+
+// Scheduler collects the regions that need redrawing and decides when the
+// render loop draws a frame, so an idle screen is not redrawn at all
+// Regions may be invalidated from any goroutine
+type Scheduler struct {
+	dirty     Region
+	lastFrame time.Time
+	minFrame  time.Duration // Frames are at least this far apart
+	wake      chan struct{}
+	mu        sync.Mutex
+}
+
+// NewScheduler creates a scheduler drawing at most maxFPS frames per second
+// The first frame redraws the whole screen
+func NewScheduler(maxFPS int) *Scheduler {
+	return &Scheduler{
+		dirty:    RegionAll,
+		minFrame: time.Second / time.Duration(maxFPS),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Invalidate marks regions for redrawing and wakes the render loop
+func (s *Scheduler) Invalidate(r Region) {
+	s.mu.Lock()
+	s.dirty |= r
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wake returns a channel that receives when regions are invalidated
+func (s *Scheduler) Wake() <-chan struct{} {
+	return s.wake
+}
+
+// Take returns the regions to redraw now and marks them clean
+// Returns 0 when nothing changed or the previous frame was too recent; the
+// damage is then kept for a later frame
+func (s *Scheduler) Take(now time.Time) Region {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dirty == 0 || now.Sub(s.lastFrame) < s.minFrame {
+		return 0
+	}
+	r := s.dirty
+	s.dirty = 0
+	s.lastFrame = now
+	return r
+}
+
+// NextFrame returns how long until a pending frame may be drawn; ok is false
+// when nothing needs redrawing
+func (s *Scheduler) NextFrame(now time.Time) (wait time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dirty == 0 {
+		return 0, false
+	}
+	if wait := s.minFrame - now.Sub(s.lastFrame); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// WaitEvent waits up to timeout for an input event, returning early with nil
+// when wake receives
+func (t *TUI) WaitEvent(wake <-chan struct{}, timeout time.Duration) tcell.Event {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case ev := <-t.eventCh:
+		return ev
+	case <-wake:
+		return nil
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ClearRect blanks a rectangle before one region of the screen is redrawn
+func (t *TUI) ClearRect(r Rect) {
+	t.FillRect(r.X, r.Y, r.W, r.H, ' ', tcell.StyleDefault)
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSchedulerDamage verifies that invalidated regions are drawn once and frames are rate limited
+func TestSchedulerDamage(t *testing.T) {
+	s := NewScheduler(10)
+	start := time.Unix(0, 0)
+
+	if got := s.Take(start); got != RegionAll {
+		t.Fatalf("first frame = %b, want the whole screen", got)
+	}
+	if got := s.Take(start.Add(time.Second)); got != 0 {
+		t.Errorf("idle frame = %b, want nothing", got)
+	}
+
+	s.Invalidate(RegionLog)
+	if got := s.Take(start.Add(time.Second)); got != RegionLog {
+		t.Errorf("frame = %b, want the log", got)
+	}
+
+	s.Invalidate(RegionBoard)
+	s.Invalidate(RegionStatus)
+	select {
+	case <-s.Wake():
+	default:
+		t.Error("Invalidate did not wake the render loop")
+	}
+
+	// Frames are at least 100ms apart at 10 FPS
+	now := start.Add(time.Second + 50*time.Millisecond)
+	if got := s.Take(now); got != 0 {
+		t.Errorf("frame 50ms after the last = %b, want nothing yet", got)
+	}
+	if wait, ok := s.NextFrame(now); !ok || wait != 50*time.Millisecond {
+		t.Errorf("NextFrame() = %v, %v, want 50ms, true", wait, ok)
+	}
+	if got := s.Take(now.Add(50 * time.Millisecond)); got != RegionBoard|RegionStatus {
+		t.Errorf("frame = %b, want board and status", got)
+	}
+	if _, ok := s.NextFrame(now); ok {
+		t.Error("NextFrame() reports a pending frame after drawing")
+	}
+}
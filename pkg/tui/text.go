@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// TextWidth returns the number of terminal columns text occupies
+// Wide characters such as CJK and most emoji take two columns and combining
+// marks none, matching how tcell lays out the screen
+func TextWidth(text string) int {
+	return uniseg.StringWidth(text)
+}
+
+// Truncate cuts text to at most width terminal columns without splitting a character
+func Truncate(text string, width int) string {
+	used := 0
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		if used+g.Width() > width {
+			start, _ := g.Positions()
+			return text[:start]
+		}
+		used += g.Width()
+	}
+	return text
+}
+
+// TruncateStart cuts text to at most width terminal columns by dropping
+// characters from the start, keeping the end visible
+func TruncateStart(text string, width int) string {
+	excess := TextWidth(text) - width
+	g := uniseg.NewGraphemes(text)
+	for excess > 0 && g.Next() {
+		excess -= g.Width()
+		if excess <= 0 {
+			_, end := g.Positions()
+			return text[end:]
+		}
+	}
+	return text
+}
+
+// ProgressBar returns a bar width columns wide, filled in proportion to done out of total
+func ProgressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(max(done, 0)*width/total, width)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
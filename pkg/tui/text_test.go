@@ -0,0 +1,105 @@
+package tui
+
+import "testing"
+
+// TestTextWidth verifies that wide characters count as two columns
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"TETRIS", 6},
+		{"🎮 TETRIS 🎮", 12},
+		{"俄罗斯方块", 10},
+		{"● Connected", 11},
+		{"e\u0301", 1}, // e with a combining accent
+	}
+
+	for _, tt := range tests {
+		if got := TextWidth(tt.text); got != tt.want {
+			t.Errorf("TextWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestTruncate verifies that truncation never splits a character
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"Hello", 10, "Hello"},
+		{"Hello", 3, "Hel"},
+		{"✓ Connected", 3, "✓ C"},
+		{"俄罗斯方块", 5, "俄罗"},
+		{"🎮 TETRIS", 1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.text, tt.width); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+// TestTruncateStart verifies that truncating from the start keeps the end and never splits a character
+func TestTruncateStart(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"Hello", 10, "Hello"},
+		{"Hello", 3, "llo"},
+		{"俄罗斯方块", 5, "方块"},
+		{"> hi 🎮", 3, " 🎮"},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateStart(tt.text, tt.width); got != tt.want {
+			t.Errorf("TruncateStart(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+// TestCapitalize verifies that capitalize handles non-ASCII and already capitalized text
+func TestCapitalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"marathon", "Marathon"},
+		{"Sprint", "Sprint"},
+		{"élan", "Élan"},
+	}
+
+	for _, tt := range tests {
+		if got := capitalize(tt.in); got != tt.want {
+			t.Errorf("capitalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestProgressBar verifies that the bar fills in proportion and stays within its width
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		done, total, width int
+		want               string
+	}{
+		{0, 10, 5, "░░░░░"},
+		{5, 10, 4, "██░░"},
+		{10, 10, 3, "███"},
+		{12, 10, 3, "███"},
+		{-2, 10, 3, "░░░"},
+		{0, 0, 2, "░░"},
+	}
+
+	for _, tt := range tests {
+		if got := ProgressBar(tt.done, tt.total, tt.width); got != tt.want {
+			t.Errorf("ProgressBar(%d, %d, %d) = %q, want %q", tt.done, tt.total, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// Theme maps piece colors and UI chrome to terminal colors
+type Theme struct {
+	Name   string
+	Pieces map[piece.Color]tcell.Color // Missing colors use the terminal default
+
+	Title  tcell.Color // Screen titles
+	Accent tcell.Color // Subtitles, dialogs and paused state
+	Good   tcell.Color // Connected, playing and personal bests
+	Bad    tcell.Color // Disconnected, game over and errors
+
+	// ASCII draws blocks and borders with plain characters instead of colors
+	// and box-drawing characters, for terminals without color or Unicode
+	ASCII bool
+	// Plain also leaves out borders and animations and draws blocks as the
+	// letters of their pieces, so screen readers read the board as text lines
+	Plain bool
+}
+
+// Themes are the built-in themes by name
+var Themes = map[string]*Theme{
+	"classic": {
+		Name: "classic",
+		Pieces: map[piece.Color]tcell.Color{
+			piece.ColorCyan:   tcell.ColorTeal,
+			piece.ColorYellow: tcell.ColorYellow,
+			piece.ColorPurple: tcell.ColorPurple,
+			piece.ColorGreen:  tcell.ColorGreen,
+			piece.ColorRed:    tcell.ColorRed,
+			piece.ColorBlue:   tcell.ColorBlue,
+			piece.ColorOrange: tcell.ColorOrange,
+			piece.ColorGray:   tcell.ColorGray,
+			piece.ColorWall:   tcell.ColorDimGray,
+		},
+		Title:  tcell.ColorTeal.TrueColor(),
+		Accent: tcell.ColorYellow.TrueColor(),
+		Good:   tcell.ColorGreen.TrueColor(),
+		Bad:    tcell.ColorRed.TrueColor(),
+	},
+	"pastel": {
+		Name: "pastel",
+		Pieces: map[piece.Color]tcell.Color{
+			piece.ColorCyan:   tcell.NewHexColor(0x9BE7F0),
+			piece.ColorYellow: tcell.NewHexColor(0xFFF1A8),
+			piece.ColorPurple: tcell.NewHexColor(0xCDB4F6),
+			piece.ColorGreen:  tcell.NewHexColor(0xB5E8B0),
+			piece.ColorRed:    tcell.NewHexColor(0xF7A8B0),
+			piece.ColorBlue:   tcell.NewHexColor(0xA8C8F7),
+			piece.ColorOrange: tcell.NewHexColor(0xFFCFA0),
+			piece.ColorGray:   tcell.NewHexColor(0xC8C8C8),
+			piece.ColorWall:   tcell.NewHexColor(0x7A7A7A),
+		},
+		Title:  tcell.NewHexColor(0x9BE7F0),
+		Accent: tcell.NewHexColor(0xFFF1A8),
+		Good:   tcell.NewHexColor(0xB5E8B0),
+		Bad:    tcell.NewHexColor(0xF7A8B0),
+	},
+	// Bright colors from the 16-color palette, which every color terminal supports
+	"high-contrast": {
+		Name: "high-contrast",
+		Pieces: map[piece.Color]tcell.Color{
+			piece.ColorCyan:   tcell.ColorAqua,
+			piece.ColorYellow: tcell.ColorYellow,
+			piece.ColorPurple: tcell.ColorFuchsia,
+			piece.ColorGreen:  tcell.ColorLime,
+			piece.ColorRed:    tcell.ColorRed,
+			piece.ColorBlue:   tcell.ColorBlue,
+			piece.ColorOrange: tcell.ColorWhite,
+			piece.ColorGray:   tcell.ColorSilver,
+			piece.ColorWall:   tcell.ColorGray,
+		},
+		Title:  tcell.ColorAqua,
+		Accent: tcell.ColorYellow,
+		Good:   tcell.ColorLime,
+		Bad:    tcell.ColorRed,
+	},
+	"monochrome": {
+		Name:   "monochrome",
+		Title:  tcell.ColorDefault,
+		Accent: tcell.ColorDefault,
+		Good:   tcell.ColorDefault,
+		Bad:    tcell.ColorDefault,
+		ASCII:  true,
+	},
+	// Selected with --plain rather than in the settings menu
+	"plain": {
+		Name:   "plain",
+		Title:  tcell.ColorDefault,
+		Accent: tcell.ColorDefault,
+		Good:   tcell.ColorDefault,
+		Bad:    tcell.ColorDefault,
+		ASCII:  true,
+		Plain:  true,
+	},
+}
+
+// ThemeNames returns the names of the built-in themes
+func ThemeNames() []string {
+	return []string{"classic", "pastel", "high-contrast", "monochrome"}
+}
+
+// LookupTheme returns a built-in theme by name
+func LookupTheme(name string) (*Theme, error) {
+	th, ok := Themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return th, nil
+}
+
+// Color returns the terminal color of a piece color; rows flashing in a line
+// clear are white
+func (th *Theme) Color(color piece.Color) tcell.Color {
+	if color == flashColor {
+		return tcell.ColorWhite
+	}
+	if c, ok := th.Pieces[color]; ok {
+		return c
+	}
+	return tcell.ColorDefault
+}
+
+// boxRunes returns the corners (top-left, top-right, bottom-left, bottom-right)
+// and the horizontal and vertical lines of a box
+func (th *Theme) boxRunes() []rune {
+	if th.Plain {
+		return []rune("      ")
+	}
+	if th.ASCII {
+		return []rune("++++-|")
+	}
+	return []rune("┌┐└┘─│")
+}
+
+// Theme returns the active theme
+func (t *TUI) Theme() *Theme {
+	return t.theme
+}
+
+// SetTheme replaces the active theme
+func (t *TUI) SetTheme(th *Theme) {
+	t.theme = th
+}
+
+// drawCell draws one board or preview cell width columns wide; an empty color
+// draws an empty cell
+func (t *TUI) drawCell(x, y, width int, color string, style tcell.Style) {
+	glyphs := []rune{' ', ' '}
+	switch {
+	case color == "" && t.theme.ASCII:
+		glyphs = []rune{'.', ' '}
+	case color == "":
+		glyphs = []rune{'·', '·'}
+		style = style.Dim(true)
+	case t.theme.Plain:
+		glyphs = []rune{pieceLetter(piece.Color(color)), ' '}
+	case piece.Color(color) == flashColor && t.theme.ASCII:
+		glyphs = []rune{'=', '='}
+	case piece.Color(color) == piece.ColorWall && t.theme.ASCII:
+		glyphs = piecePatterns[piece.ColorWall]
+	case t.fills && piecePatterns[piece.Color(color)] != nil:
+		glyphs = piecePatterns[piece.Color(color)]
+		if !t.theme.ASCII {
+			style = style.Background(t.theme.Color(piece.Color(color))).Foreground(tcell.ColorBlack)
+		}
+	case t.theme.ASCII && width == 1:
+		glyphs = []rune{'#'}
+	case t.theme.ASCII:
+		glyphs = []rune{'[', ']'}
+	default:
+		style = style.Background(t.theme.Color(piece.Color(color)))
+	}
+
+	for i := 0; i < width; i++ {
+		t.screen.SetContent(x+i, y, glyphs[i], nil, style)
+	}
+}
+
+// piecePatterns are the fill characters of each piece type, drawn over the
+// piece colors when patterns are on; pieces told apart by color alone, S from
+// Z and J from L, get mirrored patterns
+var piecePatterns = map[piece.Color][]rune{
+	piece.ColorCyan:   []rune("||"), // I
+	piece.ColorYellow: []rune("[]"), // O
+	piece.ColorPurple: []rune("^^"), // T
+	piece.ColorGreen:  []rune("//"), // S
+	piece.ColorRed:    []rune(`\\`), // Z
+	piece.ColorBlue:   []rune("<<"), // J
+	piece.ColorOrange: []rune(">>"), // L
+	piece.ColorGray:   []rune("::"), // Garbage
+	piece.ColorWall:   []rune("##"), // Obstacles
+}
+
+// pieceLetter returns the letter of the piece a board cell color belongs to:
+// G for garbage, W for obstacles and # for colors of no piece
+func pieceLetter(color piece.Color) rune {
+	switch color {
+	case piece.ColorGray:
+		return 'G'
+	case piece.ColorWall:
+		return 'W'
+	}
+	for t := piece.TypeI; t <= piece.TypeL; t++ {
+		if piece.New(t).Color == color {
+			return rune(t.String()[0])
+		}
+	}
+	return '#'
+}
+
+// drawGhostCell draws a cell of the ghost piece as a shaded outline in the piece's color
+func (t *TUI) drawGhostCell(x, y, width int, color string, style tcell.Style) {
+	glyph := '░'
+	if t.theme.ASCII {
+		glyph = ':'
+	} else {
+		style = style.Foreground(t.theme.Color(piece.Color(color)))
+	}
+
+	for i := 0; i < width; i++ {
+		t.screen.SetContent(x+i, y, glyph, nil, style)
+	}
+}
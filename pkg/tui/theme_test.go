@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ican2002/tetris/pkg/piece"
+)
+
+// TestThemes verifies that every built-in theme can be looked up and colors every piece
+func TestThemes(t *testing.T) {
+	colors := []piece.Color{
+		piece.ColorCyan, piece.ColorYellow, piece.ColorPurple, piece.ColorGreen,
+		piece.ColorRed, piece.ColorBlue, piece.ColorOrange, piece.ColorGray, piece.ColorWall,
+	}
+
+	for _, name := range ThemeNames() {
+		th, err := LookupTheme(name)
+		if err != nil {
+			t.Fatalf("LookupTheme(%q) error = %v", name, err)
+		}
+		if th.Name != name {
+			t.Errorf("LookupTheme(%q).Name = %q", name, th.Name)
+		}
+		if th.ASCII {
+			continue
+		}
+		for _, c := range colors {
+			if th.Color(c) == tcell.ColorDefault {
+				t.Errorf("theme %q has no color for %s", name, c)
+			}
+		}
+	}
+
+	if _, err := LookupTheme("neon"); err == nil {
+		t.Error("LookupTheme(\"neon\") error = nil, want error")
+	}
+}
+
+// TestPlainTheme verifies the plain theme draws no borders and names the pieces of board cells
+func TestPlainTheme(t *testing.T) {
+	th, err := LookupTheme("plain")
+	if err != nil {
+		t.Fatalf("LookupTheme(\"plain\") error = %v", err)
+	}
+	if got := string(th.boxRunes()); got != "      " {
+		t.Errorf("plain boxRunes() = %q, want blanks", got)
+	}
+
+	tests := []struct {
+		color piece.Color
+		want  rune
+	}{
+		{piece.ColorCyan, 'I'},
+		{piece.ColorPurple, 'T'},
+		{piece.ColorOrange, 'L'},
+		{piece.ColorGray, 'G'},
+		{piece.ColorWall, 'W'},
+		{"#123456", '#'},
+	}
+	for _, tt := range tests {
+		if got := pieceLetter(tt.color); got != tt.want {
+			t.Errorf("pieceLetter(%s) = %q, want %q", tt.color, got, tt.want)
+		}
+	}
+}
+
+// TestPiecePatterns verifies every piece has a pattern of its own, also in
+// one-column cells
+func TestPiecePatterns(t *testing.T) {
+	seen := map[rune]piece.Type{}
+	for typ := piece.TypeI; typ <= piece.TypeL; typ++ {
+		pattern := piecePatterns[piece.New(typ).Color]
+		if len(pattern) != 2 {
+			t.Fatalf("pattern of %s = %q, want 2 characters", typ, string(pattern))
+		}
+		if other, ok := seen[pattern[0]]; ok {
+			t.Errorf("%s and %s both start with %q", other, typ, pattern[0])
+		}
+		seen[pattern[0]] = typ
+	}
+}
@@ -6,40 +6,33 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/rivo/uniseg"
 )
 
+// Version is the terminal client version
+const Version = "1.0.0"
+
 // TUI is the main UI struct
 type TUI struct {
-	screen   tcell.Screen
-	width    int
-	height   int
-	eventCh  chan tcell.Event
-	quitCh   chan struct{}
-
-	// Layout
-	boardX      int
-	boardY      int
-	boardWidth  int
-	boardHeight int
-	infoX       int
-	infoY       int
+	screen  tcell.Screen
+	width   int
+	height  int
+	eventCh chan tcell.Event
+	quitCh  chan struct{}
+
+	// Input
+	keymap *Keymap
+
+	// Appearance
+	theme *Theme
+	ghost bool // Show where the current piece will land
+	fills bool // Fill each piece type with its own pattern besides its color
+	anim  *Animator
 
 	// State
 	running bool
 }
 
-// Color mapping from hex colors to tcell colors
-var colorMap = map[piece.Color]tcell.Color{
-	piece.ColorCyan:   tcell.ColorTeal,
-	piece.ColorYellow: tcell.ColorYellow,
-	piece.ColorPurple: tcell.ColorPurple,
-	piece.ColorGreen:  tcell.ColorGreen,
-	piece.ColorRed:    tcell.ColorRed,
-	piece.ColorBlue:   tcell.ColorBlue,
-	piece.ColorOrange: tcell.ColorOrange,
-	piece.ColorEmpty:  tcell.ColorDefault,
-}
-
 // Color is a type alias for protocol color
 type Color = piece.Color
 
@@ -60,6 +53,9 @@ func New() (*TUI, error) {
 		height:  24,
 		eventCh: make(chan tcell.Event, 10),
 		quitCh:  make(chan struct{}),
+		keymap:  DefaultKeymap(),
+		theme:   Themes["classic"],
+		anim:    &Animator{},
 	}
 
 	// Set default styles
@@ -96,15 +92,6 @@ func (t *TUI) UpdateSize() {
 	w, h := t.screen.Size()
 	t.width = w
 	t.height = h
-
-	// Calculate layout
-	t.boardX = 2
-	t.boardY = 3
-	t.boardWidth = 22  // 10 cells * 2 width + borders
-	t.boardHeight = 22 // 20 cells + borders
-
-	t.infoX = t.boardX + t.boardWidth + 2
-	t.infoY = t.boardY
 }
 
 // Close closes the TUI and restores terminal state
@@ -124,6 +111,11 @@ func (t *TUI) Sync() {
 	t.screen.Show()
 }
 
+// Beep rings the terminal bell
+func (t *TUI) Beep() {
+	_ = t.screen.Beep()
+}
+
 // SetRunning sets the running state
 func (t *TUI) SetRunning(running bool) {
 	t.running = running
@@ -134,6 +126,32 @@ func (t *TUI) IsRunning() bool {
 	return t.running
 }
 
+// Keymap returns the active key bindings
+func (t *TUI) Keymap() *Keymap {
+	return t.keymap
+}
+
+// SetKeymap replaces the active key bindings
+func (t *TUI) SetKeymap(k *Keymap) {
+	t.keymap = k
+}
+
+// Animator returns the line clear animations of the board drawn by DrawBoard
+func (t *TUI) Animator() *Animator {
+	return t.anim
+}
+
+// SetGhost shows or hides the ghost piece marking where the current piece will land
+func (t *TUI) SetGhost(enabled bool) {
+	t.ghost = enabled
+}
+
+// SetPatterns fills each piece type with its own pattern, so pieces can be
+// told apart without their colors; boards drawn in half blocks keep colors only
+func (t *TUI) SetPatterns(enabled bool) {
+	t.fills = enabled
+}
+
 // PollEvent waits for and returns the next event
 func (t *TUI) PollEvent() tcell.Event {
 	return <-t.eventCh
@@ -154,56 +172,62 @@ func (t *TUI) PostEvent(ev tcell.Event) {
 	t.screen.PostEvent(ev)
 }
 
-// GetColor returns the tcell color for a piece color
+// GetColor returns the tcell color for a piece color in the classic theme
 func GetColor(color piece.Color) tcell.Color {
-	if c, ok := colorMap[color]; ok {
-		return c
-	}
-	return tcell.ColorDefault
+	return Themes["classic"].Color(color)
 }
 
 // DrawBox draws a box with borders
 func (t *TUI) DrawBox(x, y, width, height int, title string, style tcell.Style) {
+	box := t.theme.boxRunes()
+
 	// Draw corners and horizontal lines
-	t.screen.SetContent(x, y, '┌', nil, style)
-	t.screen.SetContent(x+width-1, y, '┐', nil, style)
-	t.screen.SetContent(x, y+height-1, '└', nil, style)
-	t.screen.SetContent(x+width-1, y+height-1, '┘', nil, style)
+	t.screen.SetContent(x, y, box[0], nil, style)
+	t.screen.SetContent(x+width-1, y, box[1], nil, style)
+	t.screen.SetContent(x, y+height-1, box[2], nil, style)
+	t.screen.SetContent(x+width-1, y+height-1, box[3], nil, style)
 
 	for i := x + 1; i < x+width-1; i++ {
-		t.screen.SetContent(i, y, '─', nil, style)
-		t.screen.SetContent(i, y+height-1, '─', nil, style)
+		t.screen.SetContent(i, y, box[4], nil, style)
+		t.screen.SetContent(i, y+height-1, box[4], nil, style)
 	}
 
 	// Draw vertical lines
 	for i := y + 1; i < y+height-1; i++ {
-		t.screen.SetContent(x, i, '│', nil, style)
-		t.screen.SetContent(x+width-1, i, '│', nil, style)
+		t.screen.SetContent(x, i, box[5], nil, style)
+		t.screen.SetContent(x+width-1, i, box[5], nil, style)
 	}
 
 	// Draw title if provided
-	if title != "" && width > len(title)+4 {
-		titleX := x + (width-len(title))/2
-		for i, ch := range title {
-			t.screen.SetContent(titleX+i, y, ch, nil, style.Bold(true))
-		}
+	if titleWidth := TextWidth(title); title != "" && width > titleWidth+4 {
+		t.DrawText(x+(width-titleWidth)/2, y, title, style.Bold(true))
+	}
+}
+
+// DrawHLine draws a horizontal line
+func (t *TUI) DrawHLine(x, y, width int, style tcell.Style) {
+	line := t.theme.boxRunes()[4]
+	for i := x; i < x+width; i++ {
+		t.screen.SetContent(i, y, line, nil, style)
 	}
 }
 
 // DrawText draws text at the specified position
+// Each character advances by its display width, so wide characters and
+// combining marks line up with the columns around them
 func (t *TUI) DrawText(x, y int, text string, style tcell.Style) {
-	for i, ch := range text {
-		t.screen.SetContent(x+i, y, ch, nil, style)
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		runes := g.Runes()
+		t.screen.SetContent(x, y, runes[0], runes[1:], style)
+		x += g.Width()
 	}
 }
 
 // DrawTextAligned draws aligned text
 func (t *TUI) DrawTextAligned(x, y, width int, text string, alignment int, style tcell.Style) {
-	textLen := len(text)
-	if textLen > width {
-		text = text[:width]
-		textLen = width
-	}
+	text = Truncate(text, width)
+	textLen := TextWidth(text)
 
 	var xPos int
 	switch alignment {
@@ -233,9 +257,3 @@ func (t *TUI) FillRect(x, y, width, height int, ch rune, style tcell.Style) {
 func (t *TUI) GetSize() (int, int) {
 	return t.screen.Size()
 }
-
-// CheckMinimumSize checks if terminal meets minimum size requirements
-func (t *TUI) CheckMinimumSize() bool {
-	w, h := t.screen.Size()
-	return w >= 80 && h >= 24
-}
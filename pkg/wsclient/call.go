@@ -0,0 +1,98 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// Call sends a control message and waits for the server's reply to it: the
+// ack, whose state after the command is returned, or an error message,
+// returned as a *ServerError. Unless msg has an ID, Call assigns one; IDs of
+// calls in flight must differ
+func (c *Client) Call(ctx context.Context, msg protocol.ControlMessage) (*protocol.StateMessage, error) {
+	reply := make(chan *protocol.Message, 1)
+	c.callMu.Lock()
+	if msg.ID == "" {
+		c.callSeq++
+		msg.ID = "call-" + strconv.FormatUint(c.callSeq, 10)
+	}
+	if c.calls == nil {
+		c.calls = make(map[string]chan *protocol.Message)
+	}
+	c.calls[msg.ID] = reply
+	c.callMu.Unlock()
+	defer c.forgetCall(msg.ID, reply)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Send(data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case resp, ok := <-reply:
+		if !ok {
+			return nil, ErrDisconnected
+		}
+		if resp.Type == protocol.MessageTypeError {
+			var errMsg protocol.ErrorMessage
+			if err := decodeData(resp.Data, &errMsg); err != nil {
+				return nil, err
+			}
+			return nil, &ServerError{Message: errMsg.Error, Code: errMsg.Code, Kind: errMsg.Kind}
+		}
+		var state protocol.StateMessage
+		if err := decodeData(resp.Data, &state); err != nil {
+			return nil, err
+		}
+		return &state, nil
+	}
+}
+
+// deliverReply hands a reply to the call awaiting it, if any
+func (c *Client) deliverReply(msg *protocol.Message) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	if reply, ok := c.calls[msg.ReplyTo]; ok {
+		delete(c.calls, msg.ReplyTo)
+		reply <- msg
+	}
+}
+
+// forgetCall stops awaiting the reply of a call that returned
+func (c *Client) forgetCall(id string, reply chan *protocol.Message) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	if c.calls[id] == reply {
+		delete(c.calls, id)
+	}
+}
+
+// abortCalls fails the calls awaiting a reply when the connection ends
+func (c *Client) abortCalls() {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	for id, reply := range c.calls {
+		close(reply)
+		delete(c.calls, id)
+	}
+}
+
+// decodeData converts a decoded message payload into a typed message
+func decodeData(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,89 @@
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/game"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// replyServer answers control messages like the game server: hard_drop with
+// an ack, set_name with an error and anything else not at all
+func replyServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			ctrl, err := protocol.ParseControlMessage(data)
+			if err != nil {
+				t.Errorf("server got %s: %v", data, err)
+				return
+			}
+
+			var reply *protocol.Message
+			switch ctrl.Type {
+			case protocol.MessageTypeHardDrop:
+				reply = protocol.NewAckMessage(ctrl.ID, game.New())
+			case protocol.MessageTypeSetName:
+				reply = protocol.NewErrorMessage(protocol.CodeInvalidMessage, "Invalid name: name is empty")
+				reply.ReplyTo = ctrl.ID
+			default:
+				continue
+			}
+			out, _ := reply.Serialize()
+			if err := conn.WriteMessage(websocket.TextMessage, out); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestCall(t *testing.T) {
+	srv := replyServer(t)
+	defer srv.Close()
+
+	c := New("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	state, err := c.Call(ctx, protocol.ControlMessage{Type: protocol.MessageTypeHardDrop})
+	if err != nil {
+		t.Fatalf("Call(hard_drop) error: %v", err)
+	}
+	if state.Width == 0 || state.Mode == "" {
+		t.Errorf("Call(hard_drop) = %+v, want the game state", state)
+	}
+
+	_, err = c.Call(ctx, protocol.ControlMessage{Type: protocol.MessageTypeSetName})
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Code != protocol.CodeInvalidMessage {
+		t.Errorf("Call(set_name) error = %v, want a ServerError with code %s", err, protocol.CodeInvalidMessage)
+	}
+
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := c.Call(short, protocol.ControlMessage{Type: protocol.MessageTypeRotate}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Call(rotate) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -2,12 +2,21 @@ package wsclient
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/clock"
 	"github.com/ican2002/tetris/pkg/protocol"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client represents a WebSocket client
@@ -18,27 +27,60 @@ type Client struct {
 	connected  bool
 	reconnect  bool
 	maxRetries int
-	retryDelay time.Duration
+	retryDelay time.Duration // Base delay, doubled after each failed attempt
+	maxDelay   time.Duration // Upper bound for a single backoff delay
+	maxElapsed time.Duration // Give up once this much time has passed (0 = no limit)
+	version    string
+	station    string      // Kiosk station id reported to the server
+	playerID   string      // Registered player to log in as
+	token      string      // The player's token
+	match      string      // Match to join; players of a match get the same pieces
+	matchSeed  int64       // Seed requested when creating the match
+	handicaps  string      // Handicaps of the match's seats, requested when creating it
+	session    string      // Saved game to continue, as announced by server_shutdown
+	compact    bool        // Ask for compact_state frames
+	boards     int         // Boards to play; 2 for a doubles game
+	coop       string      // Co-op game to join; its two players share one board
+	cpu        string      // Difficulty of a bot opponent hosted by the server; empty for none
+	clock      clock.Clock // Times the reconnection backoff
+
+	// Traces each message sent and received; nil uses the global provider
+	tracers trace.TracerProvider
 
 	// Write channel for thread-safe writes
-	send chan []byte
-	sendMu sync.Mutex // Protects send channel close
+	send       chan outgoing
+	sendMu     sync.Mutex // Protects send channel close
+	sendClosed bool       // Set when send is closed; guarded by sendMu
+
+	// Why the current connection ended; guarded by mu, empty while it is open
+	closeReason protocol.DisconnectReason
+
+	// Message subscribers
+	subscribers []*Subscription
+	subMu       sync.Mutex
+
+	// Calls awaiting their reply, by correlation ID
+	calls   map[string]chan *protocol.Message
+	callSeq uint64 // Numbers the IDs Call assigns
+	callMu  sync.Mutex
 
 	// Callbacks
-	onStateChange  func([]byte)
 	onConnected    func()
-	onDisconnected func()
+	onDisconnected func(protocol.DisconnectReason)
 	onError        func(error)
+	onReconnecting func(attempt int, nextDelay time.Duration)
 }
 
 // New creates a new WebSocket client
 func New(url string) *Client {
 	return &Client{
 		url:        url,
-		send:       make(chan []byte, 256),
+		send:       make(chan outgoing, 256),
 		reconnect:  true,
 		maxRetries: 5,
-		retryDelay: 3 * time.Second,
+		retryDelay: 1 * time.Second,
+		maxDelay:   30 * time.Second,
+		clock:      clock.Real{},
 	}
 }
 
@@ -47,16 +89,20 @@ func (c *Client) Connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+	conn, _, err := websocket.DefaultDialer.Dial(c.dialURL(), c.dialHeader())
 	if err != nil {
 		return err
 	}
 
 	c.conn = conn
 	c.connected = true
+	c.closeReason = protocol.DisconnectReason{}
 
 	// Create a new send channel for each connection
-	c.send = make(chan []byte, 256)
+	c.sendMu.Lock()
+	c.send = make(chan outgoing, 256)
+	c.sendClosed = false
+	c.sendMu.Unlock()
 
 	if c.onConnected != nil {
 		c.onConnected()
@@ -71,6 +117,63 @@ func (c *Client) Connect() error {
 	return nil
 }
 
+// dialURL returns the server URL with the client version, station, player, match, session, encoding, boards, co-op game and CPU opponent attached
+func (c *Client) dialURL() string {
+	if c.version == "" && c.station == "" && c.playerID == "" && c.match == "" && c.session == "" && !c.compact && c.boards <= 1 && c.coop == "" && c.cpu == "" {
+		return c.url
+	}
+
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return c.url
+	}
+	q := u.Query()
+	if c.version != "" {
+		q.Set("client_version", c.version)
+	}
+	if c.station != "" {
+		q.Set("station", c.station)
+	}
+	if c.playerID != "" {
+		q.Set("player", c.playerID)
+	}
+	if c.compact {
+		q.Set("encoding", "compact")
+	}
+	if c.match != "" {
+		q.Set("match", c.match)
+		if c.matchSeed != 0 {
+			q.Set("seed", strconv.FormatInt(c.matchSeed, 10))
+		}
+		if c.handicaps != "" {
+			q.Set("handicaps", c.handicaps)
+		}
+	}
+	if c.session != "" {
+		q.Set("session", c.session)
+	}
+	if c.boards > 1 {
+		q.Set("boards", strconv.Itoa(c.boards))
+	}
+	if c.coop != "" {
+		q.Set("coop", c.coop)
+	}
+	if c.cpu != "" {
+		q.Set("cpu", c.cpu)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// dialHeader returns the headers of the WebSocket handshake: the player's
+// token, kept out of the URL so it does not end up in access logs
+func (c *Client) dialHeader() http.Header {
+	if c.playerID == "" {
+		return nil
+	}
+	return http.Header{"Authorization": {"Bearer " + c.token}}
+}
+
 // writePump handles writing messages to the WebSocket connection
 func (c *Client) writePump() {
 	defer c.handleDisconnect()
@@ -80,12 +183,14 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			if !ok {
 				// Channel closed
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(protocol.CloseNormal, ""))
 				return
 			}
 
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			err := c.conn.WriteMessage(websocket.TextMessage, message.data)
+			message.end(err)
+			if err != nil {
 				return
 			}
 		}
@@ -100,47 +205,54 @@ func (c *Client) listen() {
 			if c.onError != nil {
 				c.onError(err)
 			}
+			c.setCloseReason(disconnectReason(err))
 			// Close the send channel to signal writePump to stop
-			// Use mutex to prevent duplicate close
-			c.sendMu.Lock()
-			select {
-			case _, ok := <-c.send:
-				if ok {
-					close(c.send)
-				}
-			default:
-				// Channel already closed or doesn't exist
-			}
-			c.sendMu.Unlock()
+			c.closeSend()
 			break
 		}
 
 		// Server may send multiple messages separated by newline
 		messages := splitMessages(message)
-		for _, msg := range messages {
-			// Check if this is a ping message that needs an automatic pong response
-			var protocolMsg protocol.Message
-			if err := json.Unmarshal(msg, &protocolMsg); err == nil {
-				if protocolMsg.Type == protocol.MessageTypePing {
-					// Automatically respond to ping with pong
-					pongMsg := protocol.ControlMessage{Type: protocol.MessageTypePong}
-					pongData, _ := json.Marshal(pongMsg)
-					// Send through channel for thread-safe write
-					select {
-					case c.send <- pongData:
-					default:
-						// Channel full, skip this pong
-					}
-					// Don't forward ping messages to the application
-					continue
-				}
-			}
+		for _, data := range messages {
+			c.receive(data)
+		}
+	}
+}
 
-			if c.onStateChange != nil {
-				c.onStateChange(msg)
-			}
+// receive handles one message from the server
+func (c *Client) receive(data []byte) {
+	span := c.startReceiveSpan(data)
+	defer span.End()
+
+	msg, err := protocol.DeserializeMessage(data)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		if c.onError != nil {
+			c.onError(err)
+		}
+		return
+	}
+	span.SetName("receive " + string(msg.Type))
+	span.SetAttributes(attrMessageType.String(string(msg.Type)))
+
+	// Automatically respond to ping with pong
+	if msg.Type == protocol.MessageTypePing {
+		pongMsg := protocol.ControlMessage{Type: protocol.MessageTypePong}
+		pongData, _ := json.Marshal(pongMsg)
+		// Send through channel for thread-safe write
+		select {
+		case c.send <- outgoing{data: pongData}:
+		default:
+			// Channel full, skip this pong
 		}
+		// Don't forward ping messages to the application
+		return
 	}
+
+	if msg.ReplyTo != "" {
+		c.deliverReply(msg)
+	}
+	c.publish(msg)
 }
 
 // splitMessages splits a message byte slice by newline characters
@@ -166,6 +278,26 @@ func splitFunc(data []byte, delimiter byte) [][]byte {
 	return result
 }
 
+// disconnectReason returns why a connection ended with the read error err:
+// the close frame the server sent, or a lost connection if it sent none
+func disconnectReason(err error) protocol.DisconnectReason {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) && closeErr.Code != websocket.CloseAbnormalClosure {
+		return protocol.NewDisconnectReason(closeErr.Code, closeErr.Text)
+	}
+	return protocol.DisconnectReason{Reason: protocol.ReasonConnectionLost}
+}
+
+// setCloseReason records why the connection ended, unless a reason was
+// already recorded
+func (c *Client) setCloseReason(reason protocol.DisconnectReason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closeReason.Reason == "" {
+		c.closeReason = reason
+	}
+}
+
 // handleDisconnect handles connection disconnection
 func (c *Client) handleDisconnect() {
 	c.mu.Lock()
@@ -173,10 +305,15 @@ func (c *Client) handleDisconnect() {
 		c.connected = false
 		c.conn.Close()
 	}
+	if c.closeReason.Reason == "" {
+		c.closeReason = protocol.DisconnectReason{Reason: protocol.ReasonConnectionLost}
+	}
+	reason := c.closeReason
 	c.mu.Unlock()
+	c.abortCalls()
 
 	if c.onDisconnected != nil {
-		c.onDisconnected()
+		c.onDisconnected(reason)
 	}
 
 	// Auto-reconnect if enabled - run in goroutine to avoid blocking
@@ -189,11 +326,37 @@ func (c *Client) handleDisconnect() {
 	}
 }
 
-// reconnectLoop attempts to reconnect to the server
+// reconnectLoop attempts to reconnect to the server using exponential backoff with jitter
 func (c *Client) reconnectLoop() {
-	for i := 0; i < c.maxRetries; i++ {
-		log.Printf("Attempting to reconnect (%d/%d)...", i+1, c.maxRetries)
-		time.Sleep(c.retryDelay)
+	c.mu.RLock()
+	maxRetries := c.maxRetries
+	baseDelay := c.retryDelay
+	maxDelay := c.maxDelay
+	maxElapsed := c.maxElapsed
+	clk := c.clock
+	c.mu.RUnlock()
+
+	start := clk.Now()
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		delay := backoffDelay(baseDelay, maxDelay, attempt)
+		if maxElapsed > 0 && clk.Since(start)+delay > maxElapsed {
+			log.Println("Max reconnection time reached")
+			return
+		}
+
+		log.Printf("Attempting to reconnect (%d/%d) in %v...", attempt, maxRetries, delay)
+		if c.onReconnecting != nil {
+			c.onReconnecting(attempt, delay)
+		}
+		clk.Sleep(delay)
+
+		// Stop if the client was closed while waiting
+		c.mu.RLock()
+		reconnect := c.reconnect
+		c.mu.RUnlock()
+		if !reconnect {
+			return
+		}
 
 		if err := c.Connect(); err == nil {
 			log.Println("Reconnected successfully")
@@ -204,20 +367,44 @@ func (c *Client) reconnectLoop() {
 	log.Println("Max reconnection attempts reached")
 }
 
+// backoffDelay returns the delay before the given attempt (starting at 1)
+// The delay doubles each attempt up to maxDelay (0 = no cap), with up to half
+// of it randomized
+func backoffDelay(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && (maxDelay <= 0 || delay < maxDelay) && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Equal jitter: keep half the delay and randomize the other half
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
 // Send sends a message to the server
 func (c *Client) Send(data []byte) error {
+	out := outgoing{data: data, span: c.startSendSpan(data)}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	if !c.connected || c.conn == nil {
+		out.end(ErrNotConnected)
 		return ErrNotConnected
 	}
 
 	select {
-	case c.send <- data:
+	case c.send <- out:
 		return nil
 	default:
 		// Channel full
+		out.end(ErrNotConnected)
 		return ErrNotConnected
 	}
 }
@@ -231,25 +418,36 @@ func (c *Client) Close() error {
 	c.connected = false
 
 	// Close the send channel to signal writePump to stop
-	// Use mutex to prevent duplicate close
-	c.sendMu.Lock()
-	select {
-	case _, ok := <-c.send:
-		if ok {
-			close(c.send)
-		}
-	default:
-		// Channel already closed
-	}
-	c.sendMu.Unlock()
+	c.closeSend()
+
+	c.closeSubscriptions()
 
 	if c.conn != nil {
+		if c.closeReason.Reason == "" {
+			c.closeReason = protocol.NewDisconnectReason(protocol.CloseNormal, "")
+		}
+		// Tell the server the client is leaving before dropping the connection
+		deadline := time.Now().Add(time.Second)
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(protocol.CloseNormal, ""), deadline)
 		return c.conn.Close()
 	}
 
 	return nil
 }
 
+// closeSend closes the send channel once per connection
+// Buffered messages may remain after a close, so the channel's contents
+// cannot tell whether it is already closed
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.send != nil && !c.sendClosed {
+		close(c.send)
+		c.sendClosed = true
+	}
+}
+
 // IsConnected returns whether the client is connected
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
@@ -257,18 +455,14 @@ func (c *Client) IsConnected() bool {
 	return c.connected
 }
 
-// SetOnStateChange sets the callback for state changes
-func (c *Client) SetOnStateChange(fn func([]byte)) {
-	c.onStateChange = fn
-}
-
 // SetOnConnected sets the callback for connection established
 func (c *Client) SetOnConnected(fn func()) {
 	c.onConnected = fn
 }
 
-// SetOnDisconnected sets the callback for disconnection
-func (c *Client) SetOnDisconnected(fn func()) {
+// SetOnDisconnected sets the callback for disconnection, which is told why
+// the connection ended
+func (c *Client) SetOnDisconnected(fn func(protocol.DisconnectReason)) {
 	c.onDisconnected = fn
 }
 
@@ -284,9 +478,140 @@ func (c *Client) SetMaxRetries(max int) {
 	c.maxRetries = max
 }
 
-// SetRetryDelay sets the delay between reconnection attempts
+// SetRetryDelay sets the base delay before the first reconnection attempt
 func (c *Client) SetRetryDelay(delay time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.retryDelay = delay
 }
+
+// SetMaxRetryDelay sets the upper bound for a single reconnection delay; 0
+// lets the delay keep doubling
+func (c *Client) SetMaxRetryDelay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxDelay = delay
+}
+
+// SetTracerProvider sets the provider of the spans that trace each message
+// sent and received; nil uses the global provider
+func (c *Client) SetTracerProvider(tp trace.TracerProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tracers = tp
+}
+
+// SetClock sets the clock that times reconnection delays, so tests can use a
+// clock.Fake instead of waiting
+func (c *Client) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock.OrReal(clk)
+}
+
+// SetMaxElapsedTime sets how long to keep trying to reconnect (0 = no limit)
+func (c *Client) SetMaxElapsedTime(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxElapsed = d
+}
+
+// SetReconnect enables or disables reconnecting after the connection drops
+func (c *Client) SetReconnect(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnect = enabled
+}
+
+// SetOnReconnecting sets the callback invoked before each reconnection attempt
+func (c *Client) SetOnReconnecting(fn func(attempt int, nextDelay time.Duration)) {
+	c.onReconnecting = fn
+}
+
+// SetClientVersion sets the version reported to the server when connecting
+func (c *Client) SetClientVersion(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = version
+}
+
+// SetStationID sets the kiosk station id reported to the server when connecting
+func (c *Client) SetStationID(station string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.station = station
+}
+
+// SetPlayer logs in as a registered player when connecting, so finished
+// games count towards the player's lifetime statistics
+func (c *Client) SetPlayer(id, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.playerID = id
+	c.token = token
+}
+
+// SetCompactState asks the server for compact_state frames, which encode the
+// board as a bitmask; expand them with StateMessage.ExpandBoard
+func (c *Client) SetCompactState(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compact = enabled
+}
+
+// SetMatch sets the match to join when connecting
+// seed is only used if this client creates the match; 0 lets the server choose
+func (c *Client) SetMatch(match string, seed int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.match = match
+	c.matchSeed = seed
+}
+
+// SetHandicaps sets the handicaps of the seats of the match to join, in seat
+// order separated by "/", e.g. "/garbage:4"; they are only used if this
+// client creates the match
+func (c *Client) SetHandicaps(handicaps string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handicaps = handicaps
+}
+
+// SetBoards sets the number of boards to play; 2 starts a doubles game, with
+// commands addressed to a board by their board_index
+func (c *Client) SetBoards(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.boards = n
+}
+
+// SetCoop sets the co-op game to join when connecting; the first player to
+// join a co-op game starts it
+func (c *Client) SetCoop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.coop = id
+}
+
+// SetCPU asks the server for a bot opponent of the given difficulty (easy,
+// medium or hard) when connecting; "" plays alone
+func (c *Client) SetCPU(difficulty string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cpu = difficulty
+}
+
+// SetSession sets the saved game to continue on the next connection, as told
+// by a server_shutdown message; "" starts a new game
+func (c *Client) SetSession(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session = id
+}
+
+// Session returns the saved game the next connection continues, if any
+func (c *Client) Session() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.session
+}
@@ -0,0 +1,63 @@
+package wsclient
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDialCredentials verifies a player's token is sent in the Authorization
+// header and kept out of the URL
+func TestDialCredentials(t *testing.T) {
+	c := New("ws://localhost:8080/ws")
+	if h := c.dialHeader(); h != nil {
+		t.Errorf("guest dial header = %v, want none", h)
+	}
+
+	c.SetPlayer("p1", "s3cret")
+	if got := c.dialURL(); got != "ws://localhost:8080/ws?player=p1" {
+		t.Errorf("dialURL() = %q, want the player without the token", got)
+	}
+	if got := c.dialHeader().Get("Authorization"); got != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer s3cret")
+	}
+}
+
+// TestBackoffDelay verifies each reconnection delay doubles from the base up
+// to the cap, with the upper half of it randomized
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		maxDelay time.Duration
+		attempt  int
+		want     time.Duration // Delay before jitter
+	}{
+		{time.Second, 30 * time.Second, 1, time.Second},
+		{time.Second, 30 * time.Second, 2, 2 * time.Second},
+		{time.Second, 30 * time.Second, 5, 16 * time.Second},
+		{time.Second, 30 * time.Second, 6, 30 * time.Second},
+		{time.Second, 30 * time.Second, 100, 30 * time.Second},
+		{3 * time.Second, time.Second, 1, time.Second},
+		{time.Second, 0, 8, 128 * time.Second},
+		{time.Nanosecond, 0, 1, time.Nanosecond},
+	}
+
+	for _, tt := range tests {
+		lo, hi := tt.want-tt.want/2, tt.want
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 100; i++ {
+			got := backoffDelay(tt.base, tt.maxDelay, tt.attempt)
+			if got < lo || got > hi {
+				t.Errorf("backoffDelay(%v, %v, %d) = %v, want %v to %v", tt.base, tt.maxDelay, tt.attempt, got, lo, hi)
+				break
+			}
+			seen[got] = true
+		}
+		if tt.want > time.Millisecond && len(seen) < 2 {
+			t.Errorf("backoffDelay(%v, %v, %d) always %v, want jitter", tt.base, tt.maxDelay, tt.attempt, lo)
+		}
+	}
+
+	if got := backoffDelay(time.Second, 0, 1000); got <= 0 {
+		t.Errorf("backoffDelay(1s, 0, 1000) = %v, want a positive delay", got)
+	}
+}
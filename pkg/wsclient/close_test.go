@@ -0,0 +1,39 @@
+package wsclient
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+func TestDisconnectReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want protocol.DisconnectReason
+	}{
+		{"idle", &websocket.CloseError{Code: protocol.CloseIdleTimeout, Text: "idle timeout"},
+			protocol.DisconnectReason{Reason: protocol.ReasonIdleTimeout, Code: 4000, Text: "idle timeout"}},
+		{"shutdown", &websocket.CloseError{Code: protocol.CloseServerShutdown},
+			protocol.DisconnectReason{Reason: protocol.ReasonServerShutdown, Code: 4003}},
+		{"unknown code", &websocket.CloseError{Code: 4999},
+			protocol.DisconnectReason{Reason: protocol.ReasonUnknown, Code: 4999}},
+		{"no close frame", &websocket.CloseError{Code: websocket.CloseAbnormalClosure},
+			protocol.DisconnectReason{Reason: protocol.ReasonConnectionLost}},
+		{"network", errors.New("connection reset"),
+			protocol.DisconnectReason{Reason: protocol.ReasonConnectionLost}},
+		{"eof", io.ErrUnexpectedEOF,
+			protocol.DisconnectReason{Reason: protocol.ReasonConnectionLost}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := disconnectReason(tt.err); got != tt.want {
+				t.Errorf("disconnectReason(%v) = %+v, want %+v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,26 @@
 package wsclient
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
 
 var (
 	// ErrNotConnected is returned when trying to send data while disconnected
 	ErrNotConnected = errors.New("websocket client is not connected")
+
+	// ErrDisconnected is returned by Call when the connection ends before the reply
+	ErrDisconnected = errors.New("websocket client disconnected before the reply")
 )
+
+// ServerError is the error message the server answered a call with
+type ServerError struct {
+	Message string
+	Code    protocol.ErrorCode
+	Kind    protocol.ErrorKind // How the message was invalid, for invalid_message
+}
+
+func (e *ServerError) Error() string {
+	return "server error: " + e.Message
+}
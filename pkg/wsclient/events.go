@@ -0,0 +1,90 @@
+package wsclient
+
+import (
+	"sync"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// EventType is a game event detected by comparing consecutive states
+type EventType string
+
+const (
+	EventLineClear EventType = "line_clear" // One to three lines cleared
+	EventTetris    EventType = "tetris"     // Four lines cleared at once
+	EventLevelUp   EventType = "level_up"
+	EventGameOver  EventType = "game_over"
+)
+
+// Event is a game event for presentation layers such as sound
+type Event struct {
+	Type  EventType
+	Lines int // Lines cleared, for line clears and tetrises
+	Level int // Level after the event
+}
+
+// eventBuffer is the number of events queued for a slow consumer
+const eventBuffer = 16
+
+// DiffStates returns the events that happened between two states
+// A state that starts a new game (fewer lines than before) has no events
+func DiffStates(prev, curr *protocol.StateMessage) []Event {
+	if prev == nil || curr == nil || curr.Lines < prev.Lines {
+		return nil
+	}
+
+	var events []Event
+	if cleared := curr.Lines - prev.Lines; cleared >= 4 {
+		events = append(events, Event{Type: EventTetris, Lines: cleared, Level: curr.Level})
+	} else if cleared > 0 {
+		events = append(events, Event{Type: EventLineClear, Lines: cleared, Level: curr.Level})
+	}
+	if curr.Level > prev.Level {
+		events = append(events, Event{Type: EventLevelUp, Level: curr.Level})
+	}
+	if curr.State == "gameover" && prev.State != "gameover" {
+		events = append(events, Event{Type: EventGameOver, Level: curr.Level})
+	}
+	return events
+}
+
+// StateDiffer turns the stream of received states into game events
+type StateDiffer struct {
+	prev *protocol.StateMessage
+	ch   chan Event
+	mu   sync.Mutex
+}
+
+// NewStateDiffer creates a state differ
+func NewStateDiffer() *StateDiffer {
+	return &StateDiffer{ch: make(chan Event, eventBuffer)}
+}
+
+// Events returns the channel events are delivered on
+// Events are dropped while the buffer is full, so a slow consumer never
+// holds up the game
+func (d *StateDiffer) Events() <-chan Event {
+	return d.ch
+}
+
+// Update compares a newly received state with the previous one and sends
+// the resulting events
+func (d *StateDiffer) Update(state *protocol.StateMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ev := range DiffStates(d.prev, state) {
+		select {
+		case d.ch <- ev:
+		default:
+		}
+	}
+	d.prev = state
+}
+
+// Reset forgets the previous state, e.g. after reconnecting to a new game
+func (d *StateDiffer) Reset() {
+	d.mu.Lock()
+	d.prev = nil
+	d.mu.Unlock()
+}
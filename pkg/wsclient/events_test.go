@@ -0,0 +1,56 @@
+package wsclient
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestDiffStates verifies the events found between consecutive states
+func TestDiffStates(t *testing.T) {
+	state := func(lines, level int, s string) *protocol.StateMessage {
+		return &protocol.StateMessage{Lines: lines, Level: level, State: s}
+	}
+
+	tests := []struct {
+		name string
+		prev *protocol.StateMessage
+		curr *protocol.StateMessage
+		want []Event
+	}{
+		{"first state", nil, state(0, 1, "playing"), nil},
+		{"no change", state(3, 1, "playing"), state(3, 1, "playing"), nil},
+		{"single", state(3, 1, "playing"), state(4, 1, "playing"), []Event{{Type: EventLineClear, Lines: 1, Level: 1}}},
+		{"tetris and level up", state(8, 1, "playing"), state(12, 2, "playing"),
+			[]Event{{Type: EventTetris, Lines: 4, Level: 2}, {Type: EventLevelUp, Level: 2}}},
+		{"game over", state(5, 1, "playing"), state(5, 1, "gameover"), []Event{{Type: EventGameOver, Level: 1}}},
+		{"still over", state(5, 1, "gameover"), state(5, 1, "gameover"), nil},
+		{"new game", state(30, 4, "gameover"), state(0, 1, "playing"), nil},
+	}
+
+	for _, tt := range tests {
+		if got := DiffStates(tt.prev, tt.curr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: DiffStates() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestStateDiffer verifies that a reset differ does not report events for the first state after it
+func TestStateDiffer(t *testing.T) {
+	d := NewStateDiffer()
+	d.Update(&protocol.StateMessage{Lines: 0, Level: 1})
+	d.Update(&protocol.StateMessage{Lines: 2, Level: 1})
+
+	if ev := <-d.Events(); ev.Type != EventLineClear || ev.Lines != 2 {
+		t.Errorf("event = %+v, want a 2-line clear", ev)
+	}
+
+	d.Reset()
+	d.Update(&protocol.StateMessage{Lines: 9, Level: 2})
+	select {
+	case ev := <-d.Events():
+		t.Errorf("event after reset = %+v, want none", ev)
+	default:
+	}
+}
@@ -0,0 +1,72 @@
+package wsclient
+
+import (
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// PiecePosition is an intermediate position of the current piece for rendering
+type PiecePosition struct {
+	X        float64
+	Y        float64
+	Rotation int
+	At       time.Duration // Offset from the moment the newer state was received
+}
+
+// Interpolate returns the positions of the current piece moving from prev to curr,
+// spread over curr's drop interval at the given frame rate
+// If there is no previous state or the piece was replaced between the two states,
+// only the final position is returned
+func Interpolate(prev, curr *protocol.StateMessage, fps int) []PiecePosition {
+	if curr == nil {
+		return nil
+	}
+
+	to := curr.CurrentPiece
+	final := PiecePosition{X: float64(to.X), Y: float64(to.Y), Rotation: to.Rotation}
+
+	interval := time.Duration(curr.DropInterval) * time.Millisecond
+	if prev == nil || fps <= 0 || interval <= 0 || !samePiece(prev.CurrentPiece, to) {
+		return []PiecePosition{final}
+	}
+
+	from := prev.CurrentPiece
+	frameDuration := time.Second / time.Duration(fps)
+	frames := int(interval / frameDuration)
+	if frames < 1 {
+		return []PiecePosition{final}
+	}
+
+	positions := make([]PiecePosition, 0, frames)
+	for i := 1; i <= frames; i++ {
+		t := float64(i) / float64(frames)
+		positions = append(positions, PiecePosition{
+			X:        lerp(float64(from.X), float64(to.X), t),
+			Y:        lerp(float64(from.Y), float64(to.Y), t),
+			Rotation: to.Rotation,
+			At:       time.Duration(i) * frameDuration,
+		})
+	}
+
+	// Make sure the last frame lands exactly on the server position
+	positions[len(positions)-1] = PiecePosition{
+		X:        final.X,
+		Y:        final.Y,
+		Rotation: final.Rotation,
+		At:       positions[len(positions)-1].At,
+	}
+
+	return positions
+}
+
+// samePiece reports whether two piece snapshots describe the same falling piece
+// A new piece is assumed when the type changes or the piece moved back up
+func samePiece(from, to protocol.PieceData) bool {
+	return from.Type == to.Type && to.Y >= from.Y
+}
+
+// lerp linearly interpolates between a and b
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,53 @@
+package wsclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/piece"
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestInterpolate verifies the frames between two states of the same piece
+// and the single final frame when there is nothing to interpolate
+func TestInterpolate(t *testing.T) {
+	state := func(typ piece.Type, x, y, rotation, intervalMs int) *protocol.StateMessage {
+		return &protocol.StateMessage{
+			CurrentPiece: protocol.PieceData{Type: typ, X: x, Y: y, Rotation: rotation},
+			DropInterval: intervalMs,
+		}
+	}
+
+	tests := []struct {
+		name string
+		prev *protocol.StateMessage
+		curr *protocol.StateMessage
+		fps  int
+		want []PiecePosition
+	}{
+		{"no state", nil, nil, 60, nil},
+		{"first state", nil, state(piece.TypeT, 4, 2, 0, 1000), 60, []PiecePosition{{X: 4, Y: 2}}},
+		{"fall", state(piece.TypeT, 4, 2, 0, 100), state(piece.TypeT, 4, 3, 1, 100), 40, []PiecePosition{
+			{X: 4, Y: 2.25, Rotation: 1, At: 25 * time.Millisecond},
+			{X: 4, Y: 2.5, Rotation: 1, At: 50 * time.Millisecond},
+			{X: 4, Y: 2.75, Rotation: 1, At: 75 * time.Millisecond},
+			{X: 4, Y: 3, Rotation: 1, At: 100 * time.Millisecond},
+		}},
+		{"slide", state(piece.TypeI, 2, 5, 0, 100), state(piece.TypeI, 6, 5, 0, 100), 20, []PiecePosition{
+			{X: 4, Y: 5, At: 50 * time.Millisecond},
+			{X: 6, Y: 5, At: 100 * time.Millisecond},
+		}},
+		{"new piece", state(piece.TypeT, 4, 18, 0, 100), state(piece.TypeT, 4, 0, 0, 100), 40, []PiecePosition{{X: 4}}},
+		{"new type", state(piece.TypeT, 4, 2, 0, 100), state(piece.TypeO, 4, 3, 0, 100), 40, []PiecePosition{{X: 4, Y: 3}}},
+		{"no frame rate", state(piece.TypeT, 4, 2, 0, 100), state(piece.TypeT, 4, 3, 0, 100), 0, []PiecePosition{{X: 4, Y: 3}}},
+		{"no interval", state(piece.TypeT, 4, 2, 0, 0), state(piece.TypeT, 4, 3, 0, 0), 60, []PiecePosition{{X: 4, Y: 3}}},
+		{"interval under a frame", state(piece.TypeT, 4, 2, 0, 10), state(piece.TypeT, 4, 3, 0, 10), 60, []PiecePosition{{X: 4, Y: 3}}},
+	}
+
+	for _, tt := range tests {
+		if got := Interpolate(tt.prev, tt.curr, tt.fps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Interpolate() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// LobbyFetcher fetches lobby information from the server's REST API
+// and keeps the last good result so it can be shown while offline
+type LobbyFetcher struct {
+	url        string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	cached    *protocol.LobbyInfo
+	fetchedAt time.Time
+	offline   bool
+}
+
+// NewLobbyFetcher creates a lobby fetcher for the given WebSocket server address
+func NewLobbyFetcher(wsURL string) (*LobbyFetcher, error) {
+	lobbyURL, err := lobbyURLFromWS(wsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LobbyFetcher{
+		url:        lobbyURL,
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+		ttl:        30 * time.Second,
+	}, nil
+}
+
+// lobbyURLFromWS converts a WebSocket address into the lobby REST endpoint
+func lobbyURLFromWS(wsURL string) (string, error) {
+	return apiURL(wsURL, "/api/lobby")
+}
+
+// apiURL converts a WebSocket address into the REST endpoint at path on the same server
+func apiURL(wsURL, path string) (string, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+
+	u.Path = path
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// Refresh fetches the lobby information unless the cached copy is still fresh
+// On failure the previous result is kept and the fetcher is marked offline
+func (f *LobbyFetcher) Refresh() error {
+	f.mu.Lock()
+	fresh := f.cached != nil && !f.offline && time.Since(f.fetchedAt) < f.ttl
+	f.mu.Unlock()
+
+	if fresh {
+		return nil
+	}
+
+	info, err := f.fetch()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err != nil {
+		f.offline = true
+		return err
+	}
+
+	f.cached = info
+	f.fetchedAt = time.Now()
+	f.offline = false
+	return nil
+}
+
+// fetch performs the HTTP request for the lobby information
+func (f *LobbyFetcher) fetch() (*protocol.LobbyInfo, error) {
+	resp, err := f.httpClient.Get(f.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var info protocol.LobbyInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid lobby response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// Cached returns the last fetched lobby information (nil if never fetched)
+// and whether the most recent refresh failed
+func (f *LobbyFetcher) Cached() (*protocol.LobbyInfo, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cached, f.offline
+}
+
+// SetTTL sets how long a fetched result is considered fresh
+func (f *LobbyFetcher) SetTTL(ttl time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ttl = ttl
+}
@@ -0,0 +1,82 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestLobbyFetcher verifies the lobby is fetched once per TTL and that the
+// last good copy is served, marked offline, when the server is unreachable
+func TestLobbyFetcher(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/lobby" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		json.NewEncoder(w).Encode(protocol.LobbyInfo{ActivePlayers: 3,
+			TopScores: []protocol.ScoreEntry{{Name: "ann", Score: 900}}})
+	}))
+	f, err := NewLobbyFetcher("ws" + strings.TrimPrefix(ts.URL, "http") + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetTTL(time.Hour)
+
+	if info, offline := f.Cached(); info != nil || offline {
+		t.Errorf("Cached() before a refresh = %+v, %v, want nothing", info, offline)
+	}
+	for i := 0; i < 2; i++ {
+		if err := f.Refresh(); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server got %d requests for two refreshes within the TTL, want 1", got)
+	}
+
+	ts.Close()
+	f.SetTTL(0)
+	if err := f.Refresh(); err == nil {
+		t.Fatal("Refresh() with the server down: want error")
+	}
+	info, offline := f.Cached()
+	if !offline {
+		t.Error("Cached() not offline after a failed refresh")
+	}
+	if info == nil || info.ActivePlayers != 3 || len(info.TopScores) != 1 || info.TopScores[0].Name != "ann" {
+		t.Errorf("Cached() while offline = %+v, want the last fetched lobby", info)
+	}
+}
+
+// TestLobbyURL verifies the lobby endpoint is found from the server address
+func TestLobbyURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"ws://localhost:8080/ws", "http://localhost:8080/api/lobby", false},
+		{"wss://tetris.example.com/ws?token=x", "https://tetris.example.com/api/lobby", false},
+		{"http://localhost:8080", "http://localhost:8080/api/lobby", false},
+		{"ftp://localhost", "", true},
+	}
+	for _, tt := range tests {
+		got, err := lobbyURLFromWS(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lobbyURLFromWS(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("lobbyURLFromWS(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package wsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// playersHTTPClient is used for the player account requests
+var playersHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// RegisterPlayer registers a player account on the server at the given
+// WebSocket address; keep the returned token to log in when connecting
+func RegisterPlayer(wsURL, name string) (*protocol.PlayerRegistered, error) {
+	endpoint, err := apiURL(wsURL, "/api/players")
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(protocol.RegisterRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := playersHTTPClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var reg protocol.PlayerRegistered
+	if err := decodeAPIResponse(resp, http.StatusCreated, &reg); err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
+
+// FetchProfile fetches a registered player's lifetime statistics
+func FetchProfile(wsURL, id string) (*protocol.PlayerProfile, error) {
+	endpoint, err := apiURL(wsURL, "/api/players/"+url.PathEscape(id))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := playersHTTPClient.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var profile protocol.PlayerProfile
+	if err := decodeAPIResponse(resp, http.StatusOK, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// decodeAPIResponse decodes a REST response into v, or returns the server's
+// error message if the status is not the expected one
+func decodeAPIResponse(resp *http.Response, want int, v interface{}) error {
+	if resp.StatusCode != want {
+		var e protocol.ErrorMessage
+		if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
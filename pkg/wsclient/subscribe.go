@@ -0,0 +1,139 @@
+package wsclient
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// subscriptionBuffer is the number of messages queued per subscriber
+const subscriptionBuffer = 64
+
+// publishTimeout is how long a message that cannot be dropped waits for room
+// in a subscriber's buffer before the subscriber is closed as lagged
+const publishTimeout = time.Second
+
+// Subscription delivers decoded protocol messages of the subscribed types
+type Subscription struct {
+	ch     chan *protocol.Message
+	types  map[protocol.MessageType]bool // Empty means all types
+	client *Client
+	once   sync.Once
+	lagged atomic.Bool // Set when closed for not keeping up
+}
+
+// Subscribe returns a subscription receiving messages of the given types
+// With no types the subscription receives every message except pings
+// A subscriber whose buffer is full misses state frames, which the next frame
+// supersedes; any other message, such as game_over, waits up to
+// publishTimeout for room, after which the subscription is closed and
+// Lagged reports true, so no other message is ever lost unnoticed
+func (c *Client) Subscribe(types ...protocol.MessageType) *Subscription {
+	sub := &Subscription{
+		ch:     make(chan *protocol.Message, subscriptionBuffer),
+		types:  make(map[protocol.MessageType]bool, len(types)),
+		client: c,
+	}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	c.subMu.Lock()
+	c.subscribers = append(c.subscribers, sub)
+	c.subMu.Unlock()
+
+	return sub
+}
+
+// C returns the channel messages are delivered on
+// The channel is closed when the subscription or the client is closed
+func (s *Subscription) C() <-chan *protocol.Message {
+	return s.ch
+}
+
+// Close stops delivery and closes the subscription channel
+func (s *Subscription) Close() {
+	s.client.subMu.Lock()
+	defer s.client.subMu.Unlock()
+
+	for i, sub := range s.client.subscribers {
+		if sub == s {
+			s.client.subscribers = append(s.client.subscribers[:i], s.client.subscribers[i+1:]...)
+			break
+		}
+	}
+	s.closeChannel()
+}
+
+// closeChannel closes the channel once; callers must hold subMu
+func (s *Subscription) closeChannel() {
+	s.once.Do(func() {
+		close(s.ch)
+	})
+}
+
+// Lagged reports whether the subscription was closed because its buffer
+// stayed full
+func (s *Subscription) Lagged() bool {
+	return s.lagged.Load()
+}
+
+// wants reports whether the subscription receives messages of type t
+func (s *Subscription) wants(t protocol.MessageType) bool {
+	return len(s.types) == 0 || s.types[t]
+}
+
+// publish delivers a message to every interested subscriber, closing the
+// ones that cannot take a message they must not miss
+func (c *Client) publish(msg *protocol.Message) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	kept := c.subscribers[:0]
+	for _, sub := range c.subscribers {
+		if sub.wants(msg.Type) && !sub.deliver(msg) {
+			sub.lagged.Store(true)
+			sub.closeChannel()
+			continue
+		}
+		kept = append(kept, sub)
+	}
+	clear(c.subscribers[len(kept):])
+	c.subscribers = kept
+}
+
+// deliver queues a message for the subscriber; returns false if it is a
+// message that cannot be dropped and the buffer stayed full for publishTimeout
+func (s *Subscription) deliver(msg *protocol.Message) bool {
+	select {
+	case s.ch <- msg:
+		return true
+	default:
+	}
+	// Subscriber is not keeping up; the next state frame replaces this one
+	if msg.Type == protocol.MessageTypeState || msg.Type == protocol.MessageTypeCompactState {
+		return true
+	}
+
+	timer := time.NewTimer(publishTimeout)
+	defer timer.Stop()
+	select {
+	case s.ch <- msg:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// closeSubscriptions closes every subscription
+func (c *Client) closeSubscriptions() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	for _, sub := range c.subscribers {
+		sub.closeChannel()
+	}
+	c.subscribers = nil
+}
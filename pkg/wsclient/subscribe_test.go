@@ -0,0 +1,49 @@
+package wsclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+)
+
+// TestPublishFullSubscriber verifies a full subscriber misses state frames
+// but not game_over, and is closed as lagged if it never makes room
+func TestPublishFullSubscriber(t *testing.T) {
+	c := New("ws://localhost:0/ws")
+	sub := c.Subscribe()
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		c.publish(&protocol.Message{Type: protocol.MessageTypeState})
+	}
+	if sub.Lagged() || len(sub.C()) != subscriptionBuffer {
+		t.Fatalf("after overfilling with state: lagged = %v, queued %d, want false, %d", sub.Lagged(), len(sub.C()), subscriptionBuffer)
+	}
+
+	// A reader making room in time still gets game_over
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-sub.C()
+	}()
+	c.publish(&protocol.Message{Type: protocol.MessageTypeGameOver})
+	if sub.Lagged() {
+		t.Fatal("subscriber that made room closed as lagged")
+	}
+	var last *protocol.Message
+	for len(sub.C()) > 0 {
+		last = <-sub.C()
+	}
+	if last == nil || last.Type != protocol.MessageTypeGameOver {
+		t.Fatalf("last message = %+v, want game_over", last)
+	}
+
+	// A reader that never makes room is closed
+	for i := 0; i < subscriptionBuffer; i++ {
+		c.publish(&protocol.Message{Type: protocol.MessageTypeState})
+	}
+	c.publish(&protocol.Message{Type: protocol.MessageTypeGameOver})
+	if !sub.Lagged() {
+		t.Error("stuck subscriber not closed as lagged")
+	}
+	for range sub.C() {
+	}
+}
@@ -0,0 +1,75 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the client's spans
+const tracerName = "github.com/ican2002/tetris/pkg/wsclient"
+
+// Attributes of the message spans, as the server names them
+const (
+	attrMessageType = attribute.Key("tetris.message_type")
+	attrMessageSize = attribute.Key("tetris.message_size")
+)
+
+// outgoing is a message queued for writePump, with the span that traces it
+// until it is written; pongs have no span
+type outgoing struct {
+	data []byte
+	span trace.Span
+}
+
+// end ends the span of the message, recording err if it was not sent
+func (o outgoing) end(err error) {
+	if o.span == nil {
+		return
+	}
+	if err != nil {
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+	o.span.End()
+}
+
+// tracer returns the tracer of the client's spans
+func (c *Client) tracer() trace.Tracer {
+	c.mu.RLock()
+	tp := c.tracers
+	c.mu.RUnlock()
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSendSpan starts the span of a message Send queues, named after its
+// type when the span is recorded
+func (c *Client) startSendSpan(data []byte) trace.Span {
+	_, span := c.tracer().Start(context.Background(), "send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrMessageSize.Int(len(data))))
+	if span.IsRecording() {
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &msg) == nil && msg.Type != "" {
+			span.SetName("send " + msg.Type)
+			span.SetAttributes(attrMessageType.String(msg.Type))
+		}
+	}
+	return span
+}
+
+// startReceiveSpan starts the span of a message from the server
+func (c *Client) startReceiveSpan(data []byte) trace.Span {
+	_, span := c.tracer().Start(context.Background(), "receive",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrMessageSize.Int(len(data))))
+	return span
+}
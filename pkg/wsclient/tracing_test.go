@@ -0,0 +1,53 @@
+package wsclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ican2002/tetris/pkg/protocol"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestMessageSpans verifies a message sent and the reply received are each
+// traced, named after their type
+func TestMessageSpans(t *testing.T) {
+	srv := replyServer(t)
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	c := New("ws" + strings.TrimPrefix(srv.URL, "http"))
+	c.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Call(ctx, protocol.ControlMessage{Type: protocol.MessageTypeHardDrop}); err != nil {
+		t.Fatalf("Call(hard_drop) error: %v", err)
+	}
+
+	want := map[string]trace.SpanKind{
+		"send hard_drop": trace.SpanKindProducer,
+		"receive ack":    trace.SpanKindConsumer,
+	}
+	// The reply's span ends once it is published, after Call returns
+	deadline := time.Now().Add(5 * time.Second)
+	for len(recorder.Ended()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	spans := recorder.Ended()
+	if len(spans) != len(want) {
+		t.Fatalf("recorded %d spans, want %d", len(spans), len(want))
+	}
+	for _, span := range spans {
+		if kind, ok := want[span.Name()]; !ok || span.SpanKind() != kind {
+			t.Errorf("span %q of kind %v, want one of %v", span.Name(), span.SpanKind(), want)
+		}
+	}
+}
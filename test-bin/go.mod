@@ -1,7 +0,0 @@
-module test
-
-go 1.24.12
-
-replace github.com/ican2002/tetris => ../
-
-require github.com/gorilla/websocket v1.5.3
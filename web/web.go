@@ -0,0 +1,11 @@
+// Package web embeds the browser clients so the server binary can serve them
+// from any working directory
+package web
+
+import "embed"
+
+// Files holds the player client (test-client.html), the admin client
+// (admin-client.html) and the stream overlay (overlay.html)
+//
+//go:embed test-client.html admin-client.html overlay.html
+var Files embed.FS